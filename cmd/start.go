@@ -23,9 +23,41 @@ var startCmd = &cobra.Command{
 			ImageCacheTTL:        viper.GetDuration("image_cache_ttl"),
 			ImageCleanupInterval: viper.GetDuration("image_cleanup_interval"),
 			SessionExpiry:        viper.GetDuration("session_expiry"),
+			SessionMaxLifetime:   viper.GetDuration("session_max_lifetime"),
 			ShutdownTimeout:      viper.GetDuration("shutdown_timeout"),
 			HTTPS:                viper.GetBool("https"),
 			AdminDefaultPassword: viper.GetString("admin_default_password"),
+			WebhookInterval:      viper.GetDuration("webhook_interval"),
+			ListSyncInterval:     viper.GetDuration("list_sync_interval"),
+			RegistrationMode:     viper.GetString("registration_mode"),
+			InviteExpiry:         viper.GetDuration("invite_expiry"),
+			OIDCIssuer:           viper.GetString("oidc_issuer"),
+			OIDCClientID:         viper.GetString("oidc_client_id"),
+			OIDCClientSecret:     viper.GetString("oidc_client_secret"),
+			OIDCRedirectURL:      viper.GetString("oidc_redirect_url"),
+			OIDCScopes:           viper.GetStringSlice("oidc_scopes"),
+			OIDCProviderName:     viper.GetString("oidc_provider_name"),
+			OIDCAdminGroup:       viper.GetString("oidc_admin_group"),
+			OIDCGroupsClaim:      viper.GetString("oidc_groups_claim"),
+			OIDCAutoProvision:    viper.GetBool("oidc_auto_provision"),
+			DisablePasswordLogin: viper.GetBool("disable_password_login"),
+			ProxyAuthUserHeader:  viper.GetString("proxy_auth_user_header"),
+			ProxyAuthEmailHeader: viper.GetString("proxy_auth_email_header"),
+			ProxyAuthNameHeader:  viper.GetString("proxy_auth_name_header"),
+			TrustedProxies:       viper.GetStringSlice("trusted_proxies"),
+			WebhookAllowedNets:   viper.GetStringSlice("webhook_allowed_networks"),
+			WebAuthnOrigin:       viper.GetString("webauthn_origin"),
+			LockoutThreshold:     viper.GetInt64("lockout_threshold"),
+			LockoutDuration:      viper.GetDuration("lockout_duration"),
+			AccountDeletionGrace: viper.GetDuration("account_deletion_grace"),
+			PublicURL:            viper.GetString("public_url"),
+			SMTPHost:             viper.GetString("smtp_host"),
+			SMTPPort:             viper.GetInt("smtp_port"),
+			SMTPUsername:         viper.GetString("smtp_username"),
+			SMTPPassword:         viper.GetString("smtp_password"),
+			SMTPFrom:             viper.GetString("smtp_from"),
+			SMTPTLS:              viper.GetString("smtp_tls"),
+			PasswordResetExpiry:  viper.GetDuration("password_reset_expiry"),
 		}
 		server.RunServer(cfg)
 	},
@@ -60,7 +92,25 @@ func init() {
 	viper.SetDefault("image_cache_ttl", "720h")
 	viper.SetDefault("image_cleanup_interval", "48h")
 	viper.SetDefault("session_expiry", "24h")
+	viper.SetDefault("session_max_lifetime", "720h")
 	viper.SetDefault("shutdown_timeout", "30s")
 	viper.SetDefault("https", false)
 	viper.SetDefault("admin_default_password", "Welcome123!")
+	viper.SetDefault("webhook_interval", "30s")
+	viper.SetDefault("list_sync_interval", "24h")
+	viper.SetDefault("registration_mode", "open")
+	viper.SetDefault("invite_expiry", "168h")
+	viper.SetDefault("oidc_scopes", []string{"openid", "email", "profile"})
+	viper.SetDefault("oidc_provider_name", "SSO")
+	viper.SetDefault("oidc_groups_claim", "groups")
+	viper.SetDefault("oidc_auto_provision", true)
+	viper.SetDefault("disable_password_login", false)
+	viper.SetDefault("proxy_auth_email_header", "Remote-Email")
+	viper.SetDefault("proxy_auth_name_header", "Remote-Name")
+	viper.SetDefault("lockout_threshold", 5)
+	viper.SetDefault("lockout_duration", "15m")
+	viper.SetDefault("account_deletion_grace", "168h")
+	viper.SetDefault("smtp_port", 587)
+	viper.SetDefault("smtp_tls", "starttls")
+	viper.SetDefault("password_reset_expiry", "1h")
 }
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	testDB, err := NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+	entry := models.AuditEntry{Action: models.AuditLoginFailed, Detail: "wrong password", CreatedAt: time.Now().UTC()}
+	if err := testDB.InsertAuditEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := testDB.db.ExecContext(ctx, "UPDATE audit_log SET detail = 'tampered'"); err == nil {
+		t.Error("expected audit entries to reject updates")
+	}
+	if _, err := testDB.db.ExecContext(ctx, "DELETE FROM audit_log"); err == nil {
+		t.Error("expected audit entries to reject deletes")
+	}
+}
@@ -2,6 +2,8 @@
 package db
 
 import (
+	"strings"
+
 	"github.com/marcosalvi-01/gowatch/db/sqlc"
 	"github.com/marcosalvi-01/gowatch/internal/models"
 )
@@ -45,3 +47,103 @@ func toModelsPerson(person sqlc.Person) models.Person {
 		Adult:              person.Adult,
 	}
 }
+
+func toModelsWebhook(webhook sqlc.Webhook) models.Webhook {
+	return models.Webhook{
+		ID:        webhook.ID,
+		URL:       webhook.Url,
+		Secret:    webhook.Secret,
+		Events:    splitWebhookEvents(webhook.Events),
+		Enabled:   webhook.Enabled,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
+
+func toModelsWebhookDelivery(delivery sqlc.WebhookDelivery) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		Event:          models.WebhookEvent(delivery.Event),
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+// joinWebhookEvents stores webhook events as a comma separated string
+func joinWebhookEvents(events []models.WebhookEvent) string {
+	parts := make([]string, len(events))
+	for i, event := range events {
+		parts[i] = string(event)
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitWebhookEvents(events string) []models.WebhookEvent {
+	if events == "" {
+		return nil
+	}
+	parts := strings.Split(events, ",")
+	result := make([]models.WebhookEvent, len(parts))
+	for i, part := range parts {
+		result[i] = models.WebhookEvent(part)
+	}
+	return result
+}
+
+func toModelsPasskey(passkey sqlc.Passkey) models.Passkey {
+	return models.Passkey{
+		ID:             passkey.ID,
+		UserID:         passkey.UserID,
+		CredentialID:   passkey.CredentialID,
+		PublicKey:      passkey.PublicKey,
+		SignCount:      passkey.SignCount,
+		BackupEligible: passkey.BackupEligible,
+		Name:           passkey.Name,
+		CreatedAt:      passkey.CreatedAt,
+		LastUsedAt:     passkey.LastUsedAt,
+	}
+}
+
+func toModelsSession(session sqlc.Session) models.Session {
+	return models.Session{
+		ID:           session.ID,
+		UserID:       session.UserID,
+		ExpiresAt:    session.ExpiresAt,
+		MaxExpiresAt: session.MaxExpiresAt,
+		CreatedAt:    session.CreatedAt,
+		UserAgent:    session.UserAgent,
+		IP:           session.Ip,
+		LastSeenAt:   session.LastSeenAt,
+
+		ImpersonatedUserID: session.ImpersonatedUserID,
+	}
+}
+
+func toModelsLoginLockout(lockout sqlc.LoginLockout) models.LoginLockout {
+	return models.LoginLockout{
+		UserID:       lockout.UserID,
+		FailedCount:  lockout.FailedCount,
+		LastFailedAt: lockout.LastFailedAt,
+		LockedUntil:  lockout.LockedUntil,
+	}
+}
+
+func toModelsAuditEntry(entry sqlc.AuditLog) models.AuditEntry {
+	return models.AuditEntry{
+		ID:          entry.ID,
+		Action:      models.AuditAction(entry.Action),
+		ActorID:     entry.ActorID,
+		ActorEmail:  entry.ActorEmail,
+		TargetID:    entry.TargetID,
+		TargetEmail: entry.TargetEmail,
+		IP:          entry.Ip,
+		Detail:      entry.Detail,
+		CreatedAt:   entry.CreatedAt,
+	}
+}
@@ -0,0 +1,17 @@
+package db
+
+import (
+	"errors"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// ErrListNameTaken is returned when a user already has a custom list with the name
+var ErrListNameTaken = errors.New("the user already has a list with this name")
+
+// isUniqueViolation reports whether err is SQLite refusing a row that breaks a unique index
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
@@ -17,6 +17,7 @@ type DB interface {
 
 	// Watched history and activity.
 	InsertWatched(ctx context.Context, watched InsertWatched) error
+	InsertWatchedMovies(ctx context.Context, watched []InsertWatched) error
 	UpdateWatched(ctx context.Context, watched UpdateWatched) (int64, error)
 	DeleteWatched(ctx context.Context, userID, watchedID int64) (int64, error)
 	GetWatchedJoinMovie(ctx context.Context, userID int64) ([]models.WatchedMovie, error)
@@ -64,18 +65,53 @@ type DB interface {
 	// Lists and watchlist.
 	InsertList(ctx context.Context, list InsertList) (int64, error)
 	GetList(ctx context.Context, userID, listID int64) (*models.List, error)
+	GetListOwner(ctx context.Context, listID int64) (int64, error)
 	GetAllLists(ctx context.Context, userID int64) ([]InsertList, error)
 	ExportLists(ctx context.Context, userID int64) ([]models.List, error)
 	AddMovieToList(ctx context.Context, userID int64, insertMovieList InsertMovieList) error
 	UpsertMovieInList(ctx context.Context, userID int64, insertMovieList InsertMovieList) error
+	UpdateList(ctx context.Context, userID, listID int64, name string, description *string) (int64, error)
+	SetListRanked(ctx context.Context, userID, listID int64, ranked bool) (int64, error)
+	ReorderList(ctx context.Context, userID, listID int64, movieIDs []int64) error
+	SetListShare(ctx context.Context, userID, listID int64, token *string, notes bool) (int64, error)
+	GetSharedList(ctx context.Context, token string) (*models.List, error)
 	DeleteListByID(ctx context.Context, userID, listID int64) error
 	DeleteMovieFromList(ctx context.Context, userID, listID, movieID int64) error
+	CopyListMovies(ctx context.Context, userID, fromListID, toListID int64, movieIDs []int64, move bool) ([]int64, error)
+	MergeList(ctx context.Context, userID, fromListID, intoListID int64, movieIDs, order []int64) error
+	DeleteListMovies(ctx context.Context, userID, listID int64, movieIDs []int64) ([]int64, error)
+	GetMemberLists(ctx context.Context, userID int64) ([]models.ListEntry, error)
+	GetMovieLists(ctx context.Context, userID, movieID int64) ([]models.MovieList, error)
+	GetListMembers(ctx context.Context, listID int64) ([]models.ListMember, error)
+	UpsertListMember(ctx context.Context, ownerID, listID, memberID int64, role models.ListRole) (int64, error)
+	DeleteListMember(ctx context.Context, userID, listID, memberID int64) (int64, error)
+	SetListRules(ctx context.Context, userID, listID int64, rules models.ListRules) (int64, error)
+	GetGenres(ctx context.Context) ([]models.Genre, error)
+	SetListSource(ctx context.Context, userID, listID int64, source models.ListSource) (int64, error)
+	SetListSourceSync(ctx context.Context, userID, listID int64, sync bool) (int64, error)
+	SetListSourceSyncedAt(ctx context.Context, listID int64, syncedAt time.Time) error
+	GetSyncedListSources(ctx context.Context) ([]SyncedListSource, error)
+	SetListMovieDetails(ctx context.Context, listID, movieID int64, item models.WatchlistItem) (int64, error)
+	// GetWatchNextFilter returns the watch next filter of userID, nil when they never set one
+	GetWatchNextFilter(ctx context.Context, userID int64) (*models.WatchNextFilter, error)
+	SetWatchNextFilter(ctx context.Context, userID int64, filter models.WatchNextFilter) error
+	// GetListView returns how userID arranged listID, nil when they never did
+	GetListView(ctx context.Context, userID, listID int64) (*models.ListView, error)
+	SetListView(ctx context.Context, userID, listID int64, view models.ListView) error
+	GetMoviesGenres(ctx context.Context, movieIDs []int64) (map[int64][]models.Genre, error)
+	// GetMovieWatchSummaries returns how userID watched the movies of movieIDs they watched
+	GetMovieWatchSummaries(ctx context.Context, userID int64, movieIDs []int64) (map[int64]models.MovieWatchSummary, error)
 	GetWatchlistID(ctx context.Context, userID int64) (int64, error)
 
 	// Sessions.
-	CreateSession(ctx context.Context, sessionID string, userID int64, expiresAt time.Time) error
+	CreateSession(ctx context.Context, sessionID string, userID int64, expiresAt, maxExpiresAt time.Time, client models.SessionClient) error
 	GetSession(ctx context.Context, sessionID string) (*models.Session, error)
+	GetSessionsByUser(ctx context.Context, userID int64) ([]models.Session, error)
+	TouchSession(ctx context.Context, sessionID string, seenAt, expiresAt time.Time, client models.SessionClient) error
+	SetSessionImpersonation(ctx context.Context, sessionID string, impersonatedUserID *int64) (int64, error)
 	DeleteSession(ctx context.Context, sessionID string) error
+	DeleteUserSession(ctx context.Context, userID int64, sessionID string) (int64, error)
+	DeleteSessionsByUser(ctx context.Context, userID int64) (int64, error)
 	CleanupExpiredSessions(ctx context.Context) error
 
 	// Users and authentication.
@@ -88,10 +124,87 @@ type DB interface {
 	AssignNilUserWatched(ctx context.Context, userID *int64) error
 	CountUsers(ctx context.Context) (int64, error)
 	SetAdmin(ctx context.Context, userID int64) error
+	UnsetAdmin(ctx context.Context, userID int64) error
 	GetAllUsersWithStats(ctx context.Context) ([]models.UserWithStats, error)
 	DeleteUser(ctx context.Context, userID int64) error
 	UpdateUserPassword(ctx context.Context, userID int64, passwordHash string) error
 	UpdatePasswordResetRequired(ctx context.Context, userID int64, reset bool) error
+	UpdateUserProfile(ctx context.Context, userID int64, name, email string) error
+	CountAdmins(ctx context.Context) (int64, error)
+	SetUserDisabledAt(ctx context.Context, userID int64, at *time.Time) error
+
+	// Self-service account deletion.
+	SetUserDeletionScheduledAt(ctx context.Context, userID int64, at *time.Time) error
+	GetUsersDueForDeletion(ctx context.Context, now time.Time) ([]int64, error)
+
+	// Registration invites.
+	CreateInvite(ctx context.Context, tokenHash string, createdBy int64, createdAt, expiresAt time.Time) (*models.Invite, error)
+	GetInvites(ctx context.Context) ([]models.Invite, error)
+	GetInviteByTokenHash(ctx context.Context, tokenHash string) (*models.Invite, error)
+	ClaimInvite(ctx context.Context, tokenHash string, now time.Time) (int64, error)
+	ReleaseInvite(ctx context.Context, inviteID int64) error
+	SetInviteUsedBy(ctx context.Context, inviteID, userID int64) error
+	DeleteInvite(ctx context.Context, inviteID int64) (int64, error)
+
+	// External identities.
+	GetUserByIdentity(ctx context.Context, issuer, subject string) (*models.User, error)
+	CreateUserIdentity(ctx context.Context, userID int64, issuer, subject string, createdAt time.Time) error
+
+	// Two-factor authentication.
+	UpsertUserTOTP(ctx context.Context, userID int64, secret string, createdAt time.Time) error
+	GetUserTOTP(ctx context.Context, userID int64) (*models.UserTOTP, error)
+	EnableUserTOTP(ctx context.Context, userID int64) error
+	DeleteUserTOTP(ctx context.Context, userID int64) (int64, error)
+	UseTOTPStep(ctx context.Context, userID, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	DeleteRecoveryCodes(ctx context.Context, userID int64) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string, usedAt time.Time) (bool, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID int64) (int64, error)
+	CreateMFAChallenge(ctx context.Context, tokenHash string, userID int64, expiresAt time.Time) error
+	GetMFAChallenge(ctx context.Context, tokenHash string, now time.Time) (*models.MFAChallenge, error)
+	IncrementMFAChallengeAttempts(ctx context.Context, tokenHash string) (int64, error)
+	DeleteMFAChallenge(ctx context.Context, tokenHash string) error
+	CleanupExpiredMFAChallenges(ctx context.Context, now time.Time) error
+
+	// Login lockout.
+	RecordFailedLogin(ctx context.Context, userID int64, failedAt time.Time) (int64, error)
+	LockUserLogin(ctx context.Context, userID int64, lockedUntil time.Time) error
+	GetLoginLockout(ctx context.Context, userID int64) (*models.LoginLockout, error)
+	ResetLoginLockout(ctx context.Context, userID int64) (int64, error)
+
+	// Password reset links.
+	CreatePasswordReset(ctx context.Context, tokenHash string, userID int64, createdAt, expiresAt time.Time) error
+	CountPasswordResetsSince(ctx context.Context, userID int64, since time.Time) (int64, error)
+	GetPasswordResetUserID(ctx context.Context, tokenHash string, now time.Time) (int64, error)
+	ClaimPasswordReset(ctx context.Context, tokenHash string, now time.Time) (int64, error)
+	DeletePasswordResetsByUser(ctx context.Context, userID int64) error
+	CleanupExpiredPasswordResets(ctx context.Context, now time.Time) error
+
+	// Audit log.
+	InsertAuditEntry(ctx context.Context, entry models.AuditEntry) error
+	ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+
+	// Passkeys.
+	CreatePasskey(ctx context.Context, passkey models.Passkey) (*models.Passkey, error)
+	GetPasskeysByUser(ctx context.Context, userID int64) ([]models.Passkey, error)
+	GetPasskeyByCredentialID(ctx context.Context, credentialID string) (*models.Passkey, error)
+	UpdatePasskeyUsage(ctx context.Context, passkeyID, signCount int64, usedAt time.Time) error
+	RenamePasskey(ctx context.Context, userID, passkeyID int64, name string) (int64, error)
+	DeletePasskey(ctx context.Context, userID, passkeyID int64) (int64, error)
+	CreateWebAuthnChallenge(ctx context.Context, challenge string, userID *int64, expiresAt time.Time) error
+	ClaimWebAuthnChallenge(ctx context.Context, challenge string, now time.Time) (*int64, error)
+	CleanupExpiredWebAuthnChallenges(ctx context.Context, now time.Time) error
+
+	// Webhooks.
+	InsertWebhook(ctx context.Context, userID int64, url, secret string, events []models.WebhookEvent) (*models.Webhook, error)
+	GetWebhooksByUser(ctx context.Context, userID int64) ([]models.Webhook, error)
+	GetEnabledWebhooksByUser(ctx context.Context, userID int64) ([]models.Webhook, error)
+	SetWebhookEnabled(ctx context.Context, userID, webhookID int64, enabled bool) (int64, error)
+	DeleteWebhook(ctx context.Context, userID, webhookID int64) (int64, error)
+	InsertWebhookDelivery(ctx context.Context, delivery InsertWebhookDelivery) error
+	GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+	UpdateWebhookDeliveryAttempt(ctx context.Context, attempt UpdateWebhookDeliveryAttempt) error
+	GetWebhookDeliveriesByUser(ctx context.Context, userID int64, limit int) ([]models.WebhookDelivery, error)
 }
 
 type InsertList struct {
@@ -100,6 +213,16 @@ type InsertList struct {
 	Name        string
 	Description *string
 	IsWatchlist bool
+	// Smart is read from the rules of the list, see SetListRules
+	Smart bool
+	// Synced is read from the source of the list, see SetListSource
+	Synced bool
+}
+
+// SyncedListSource is a list kept in sync with its TMDB source and the user who owns it
+type SyncedListSource struct {
+	ListID int64
+	UserID int64
 }
 
 type InsertMovieList struct {
@@ -125,3 +248,21 @@ type UpdateWatched struct {
 	InTheaters bool
 	Rating     *float64
 }
+
+type InsertWebhookDelivery struct {
+	WebhookID     int64
+	Event         models.WebhookEvent
+	Payload       string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+}
+
+type UpdateWebhookDeliveryAttempt struct {
+	ID             int64
+	Status         string
+	Attempts       int64
+	NextAttemptAt  time.Time
+	LastAttemptAt  time.Time
+	ResponseStatus *int64
+	LastError      *string
+}
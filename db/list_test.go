@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/pressly/goose/v3"
+)
+
+func TestListNamesAreUniquePerUser(t *testing.T) {
+	testDB, err := NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+	migrationsFS, err := fs.Sub(embedMigrations, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := goose.NewProvider(goose.DialectSQLite3, testDB.db, migrationsFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// duplicates from before the unique index are renamed by the migration
+	if _, err := provider.DownTo(ctx, 21); err != nil {
+		t.Fatal(err)
+	}
+	alice, err := testDB.CreateUser(ctx, "alice@example.com", "Alice", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := testDB.CreateUser(ctx, "bob@example.com", "Bob", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range []int64{alice.ID, alice.ID, bob.ID} {
+		if _, err := testDB.InsertList(ctx, InsertList{UserID: userID, Name: "Favorites"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := provider.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	lists, err := testDB.GetAllLists(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 2 || lists[0].Name != "Favorites" || lists[1].Name == "Favorites" {
+		t.Errorf("expected the second list to be renamed, got %+v", lists)
+	}
+	bobLists, err := testDB.GetAllLists(ctx, bob.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bobLists) != 1 || bobLists[0].Name != "Favorites" {
+		t.Errorf("expected lists of other users to keep their name, got %+v", bobLists)
+	}
+
+	if _, err := testDB.InsertList(ctx, InsertList{UserID: alice.ID, Name: "Favorites"}); !errors.Is(err, ErrListNameTaken) {
+		t.Errorf("expected a second list with the same name to fail with %v, got %v", ErrListNameTaken, err)
+	}
+	if _, err := testDB.UpdateList(ctx, alice.ID, lists[1].ID, "Favorites", nil); !errors.Is(err, ErrListNameTaken) {
+		t.Errorf("expected renaming a list to a taken name to fail with %v, got %v", ErrListNameTaken, err)
+	}
+	if _, err := testDB.InsertList(ctx, InsertList{UserID: alice.ID, Name: "Favorites", IsWatchlist: true}); err != nil {
+		t.Errorf("expected the watchlist to be exempt, got %v", err)
+	}
+	// other unique indexes are not mistaken for a taken name
+	_, err = testDB.InsertList(ctx, InsertList{UserID: alice.ID, Name: "Second Watchlist", IsWatchlist: true})
+	if err == nil || errors.Is(err, ErrListNameTaken) {
+		t.Errorf("expected a second watchlist to fail without %v, got %v", ErrListNameTaken, err)
+	}
+}
+
+func TestMergeListIsAllOrNothing(t *testing.T) {
+	testDB, err := NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = testDB.Close() }()
+
+	ctx := context.Background()
+	user, err := testDB.CreateUser(ctx, "alice@example.com", "Alice", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromID, err := testDB.InsertList(ctx, InsertList{UserID: user.ID, Name: "From"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	intoID, err := testDB.InsertList(ctx, InsertList{UserID: user.ID, Name: "Into"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.UpsertMovie(ctx, &models.MovieDetails{Movie: models.Movie{ID: 1, Title: "Movie"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.AddMovieToList(ctx, user.ID, InsertMovieList{MovieID: 1, ListID: fromID, DateAdded: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// an order missing the copied movie fails after the copy, which must be undone
+	if err := testDB.MergeList(ctx, user.ID, fromID, intoID, []int64{1}, []int64{2}); err == nil {
+		t.Fatal("expected an invalid order to fail the merge")
+	}
+	into, err := testDB.GetList(ctx, user.ID, intoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(into.Movies) != 0 {
+		t.Errorf("expected the failed merge to copy nothing, got %d movies", len(into.Movies))
+	}
+	if _, err := testDB.GetList(ctx, user.ID, fromID); err != nil {
+		t.Errorf("expected the failed merge to keep the list, got %v", err)
+	}
+
+	if err := testDB.MergeList(ctx, user.ID, fromID, intoID, []int64{1}, []int64{1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testDB.GetList(ctx, user.ID, fromID); err == nil {
+		t.Error("expected the merged list to be deleted")
+	}
+}
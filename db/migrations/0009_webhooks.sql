@@ -0,0 +1,45 @@
+-- +goose Up
+-- Outgoing webhooks configured per user.
+-- events is a comma separated list of subscribed event names.
+CREATE TABLE webhook (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT NOT NULL,
+    enabled BOOLEAN DEFAULT true NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_user_id ON webhook(user_id);
+
+-- Persistent delivery queue, also used as the delivery log.
+-- status is one of 'pending', 'delivered' or 'failed'.
+CREATE TABLE webhook_delivery (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT DEFAULT 'pending' NOT NULL,
+    attempts INTEGER DEFAULT 0 NOT NULL,
+    next_attempt_at DATETIME NOT NULL,
+    last_attempt_at DATETIME,
+    response_status INTEGER,
+    last_error TEXT,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_webhook_delivery_pending ON webhook_delivery(status, next_attempt_at);
+
+CREATE INDEX idx_webhook_delivery_webhook_id ON webhook_delivery(webhook_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_webhook_delivery_webhook_id;
+
+DROP INDEX IF EXISTS idx_webhook_delivery_pending;
+
+DROP TABLE IF EXISTS webhook_delivery;
+
+DROP INDEX IF EXISTS idx_webhook_user_id;
+
+DROP TABLE IF EXISTS webhook;
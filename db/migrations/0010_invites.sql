@@ -0,0 +1,16 @@
+-- +goose Up
+-- Single-use registration invites generated by admins.
+-- used_at is set when an invite is redeemed, used_by once the account exists.
+-- Only the SHA-256 of the token in the link is stored, like sessions.
+CREATE TABLE invite (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT UNIQUE NOT NULL,
+    created_by INTEGER REFERENCES user(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    used_by INTEGER REFERENCES user(id) ON DELETE SET NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS invite;
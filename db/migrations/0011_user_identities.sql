@@ -0,0 +1,17 @@
+-- +goose Up
+-- External identities (OIDC issuer + subject) linked to local users.
+CREATE TABLE user_identity (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX idx_user_identity_user_id ON user_identity(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_identity_user_id;
+
+DROP TABLE IF EXISTS user_identity;
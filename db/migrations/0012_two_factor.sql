@@ -0,0 +1,38 @@
+-- +goose Up
+-- TOTP secrets. enabled is set once the user confirmed a first code,
+-- last_step is the last accepted time step, so a code cannot be replayed.
+CREATE TABLE user_totp (
+    user_id INTEGER PRIMARY KEY REFERENCES user(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_step INTEGER,
+    created_at DATETIME NOT NULL
+);
+
+-- Single-use recovery codes, stored as SHA-256 hashes.
+CREATE TABLE recovery_code (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at DATETIME
+);
+
+CREATE INDEX idx_recovery_code_user_id ON recovery_code(user_id);
+
+-- Pending second login steps: the password was verified, the code was not yet.
+-- Looked up by the SHA-256 of the token in the cookie, like sessions.
+CREATE TABLE mfa_challenge (
+    token_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+    expires_at DATETIME NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS mfa_challenge;
+
+DROP INDEX IF EXISTS idx_recovery_code_user_id;
+
+DROP TABLE IF EXISTS recovery_code;
+
+DROP TABLE IF EXISTS user_totp;
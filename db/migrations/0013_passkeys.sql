@@ -0,0 +1,30 @@
+-- +goose Up
+-- WebAuthn credentials. credential_id is base64url encoded, public_key is the COSE key.
+-- backup_eligible is fixed at registration, WebAuthn refuses logins where it changes.
+CREATE TABLE passkey (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+    credential_id TEXT UNIQUE NOT NULL,
+    public_key BLOB NOT NULL,
+    sign_count INTEGER NOT NULL DEFAULT 0,
+    backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+    name TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    last_used_at DATETIME
+);
+
+CREATE INDEX idx_passkey_user_id ON passkey(user_id);
+
+-- Pending WebAuthn ceremonies. user_id is set for registrations and NULL for logins.
+CREATE TABLE webauthn_challenge (
+    challenge TEXT PRIMARY KEY,
+    user_id INTEGER REFERENCES user(id) ON DELETE CASCADE,
+    expires_at DATETIME NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS webauthn_challenge;
+
+DROP INDEX IF EXISTS idx_passkey_user_id;
+
+DROP TABLE IF EXISTS passkey;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- Where each session was started from and when it was last used, shown on the devices page.
+ALTER TABLE
+    session
+ADD
+    COLUMN user_agent TEXT DEFAULT '' NOT NULL;
+
+ALTER TABLE
+    session
+ADD
+    COLUMN ip TEXT DEFAULT '' NOT NULL;
+
+ALTER TABLE
+    session
+ADD
+    COLUMN last_seen_at DATETIME;
+
+CREATE INDEX idx_session_user_id ON session(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_session_user_id;
+
+ALTER TABLE
+    session DROP COLUMN last_seen_at;
+
+ALTER TABLE
+    session DROP COLUMN ip;
+
+ALTER TABLE
+    session DROP COLUMN user_agent;
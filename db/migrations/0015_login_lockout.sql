@@ -0,0 +1,12 @@
+-- +goose Up
+-- Failed password logins per account. A row only exists while the account has failures,
+-- locked_until is set once the lockout threshold is reached.
+CREATE TABLE login_lockout (
+    user_id INTEGER PRIMARY KEY REFERENCES user(id) ON DELETE CASCADE,
+    failed_count INTEGER NOT NULL,
+    last_failed_at DATETIME NOT NULL,
+    locked_until DATETIME
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS login_lockout;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- Sessions are now looked up by the SHA-256 of the cookie token, so the stored ids of
+-- existing sessions can no longer match and everyone has to sign in again.
+-- max_expires_at caps how far activity can push expires_at.
+DROP TABLE session;
+
+CREATE TABLE session (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    user_agent TEXT DEFAULT '' NOT NULL,
+    ip TEXT DEFAULT '' NOT NULL,
+    last_seen_at DATETIME,
+    max_expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_session_user_id ON session(user_id);
+
+-- +goose Down
+DROP TABLE session;
+
+CREATE TABLE session (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    user_agent TEXT DEFAULT '' NOT NULL,
+    ip TEXT DEFAULT '' NOT NULL,
+    last_seen_at DATETIME
+);
+
+CREATE INDEX idx_session_user_id ON session(user_id);
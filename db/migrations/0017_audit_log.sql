@@ -0,0 +1,38 @@
+-- +goose Up
+-- Security and admin events. Users are referenced without foreign keys and their email is
+-- copied, so entries outlive the accounts they mention.
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    action TEXT NOT NULL,
+    actor_id INTEGER,
+    actor_email TEXT DEFAULT '' NOT NULL,
+    target_id INTEGER,
+    target_email TEXT DEFAULT '' NOT NULL,
+    ip TEXT DEFAULT '' NOT NULL,
+    detail TEXT DEFAULT '' NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+
+-- the log is append-only
+-- +goose StatementBegin
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+
+DROP TRIGGER IF EXISTS audit_log_no_update;
+
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Set while a user has asked to delete their account, the account is removed once the
+-- time has passed unless the request is canceled first.
+ALTER TABLE user ADD COLUMN deletion_scheduled_at DATETIME;
+
+CREATE INDEX idx_user_deletion_scheduled_at ON user(deletion_scheduled_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_deletion_scheduled_at;
+
+ALTER TABLE user DROP COLUMN deletion_scheduled_at;
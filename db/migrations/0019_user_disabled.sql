@@ -0,0 +1,7 @@
+-- +goose Up
+-- Set while an admin has disabled the account, the user and their history are kept but
+-- they cannot sign in.
+ALTER TABLE user ADD COLUMN disabled_at DATETIME;
+
+-- +goose Down
+ALTER TABLE user DROP COLUMN disabled_at;
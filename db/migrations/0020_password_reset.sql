@@ -0,0 +1,15 @@
+-- +goose Up
+-- Single-use password reset links mailed to users. Only the SHA-256 of the token is stored,
+-- like session tokens.
+CREATE TABLE password_reset (
+    token_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME
+);
+
+CREATE INDEX idx_password_reset_user_id ON password_reset(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS password_reset;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Admins can view gowatch as another user from their own session, read-only.
+-- impersonated_user_id is the user they currently see, NULL when not impersonating.
+ALTER TABLE session ADD COLUMN impersonated_user_id INTEGER REFERENCES user(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE session DROP COLUMN impersonated_user_id;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Imports merge custom lists by name, so a user cannot have two with the same one.
+-- Existing duplicates keep their name with the list id appended.
+UPDATE
+    list
+SET
+    name = name || ' (' || id || ')'
+WHERE
+    is_watchlist = FALSE
+    AND EXISTS (
+        SELECT
+            1
+        FROM
+            list AS other
+        WHERE
+            other.user_id IS list.user_id
+            AND other.name = list.name
+            AND other.is_watchlist = FALSE
+            AND other.id < list.id
+    );
+
+CREATE UNIQUE INDEX idx_list_user_name ON list(user_id, name)
+WHERE
+    is_watchlist = FALSE;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_list_user_name;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Ranked lists are shown and exported in the order of list_movie.position, which users
+-- set by reordering the list.
+ALTER TABLE list ADD COLUMN ranked BOOLEAN DEFAULT FALSE NOT NULL;
+
+-- +goose Down
+ALTER TABLE list DROP COLUMN ranked;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Anyone with the share token of a list can view it read-only. The token is kept as is,
+-- unlike session tokens, so its owner can copy the link again; it grants no other access.
+ALTER TABLE list ADD COLUMN share_token TEXT;
+
+-- Notes on the movies are private unless the owner shares them with the list.
+ALTER TABLE list ADD COLUMN share_notes BOOLEAN DEFAULT FALSE NOT NULL;
+
+CREATE UNIQUE INDEX idx_list_share_token ON list(share_token);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_list_share_token;
+
+ALTER TABLE list DROP COLUMN share_notes;
+
+ALTER TABLE list DROP COLUMN share_token;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- Users a list is shared with besides its owner. Viewers can see the list, editors can
+-- also add, remove and reorder its movies. Only the owner in list.user_id can change the
+-- list itself or its members.
+CREATE TABLE list_member (
+    list_id INTEGER NOT NULL REFERENCES list(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('viewer', 'editor')),
+    added_at DATETIME NOT NULL,
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX idx_list_member_user_id ON list_member(user_id);
+
+-- Who added each movie to the list. There is no foreign key so the column can be dropped
+-- again; movies added by a deleted user simply lose their attribution.
+ALTER TABLE list_movie ADD COLUMN added_by INTEGER;
+
+UPDATE list_movie
+SET
+    added_by = (
+        SELECT
+            list.user_id
+        FROM
+            list
+        WHERE
+            list.id = list_movie.list_id
+    );
+
+-- +goose Down
+ALTER TABLE list_movie DROP COLUMN added_by;
+
+DROP INDEX IF EXISTS idx_list_member_user_id;
+
+DROP TABLE IF EXISTS list_member;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- The rules of a smart list. Its movies are not stored in list_movie but computed from the
+-- library of the list owner every time the list is read, every rule that is set must
+-- match. The watch rules (in_theater, watched_year, min_rating) must hold for the same
+-- watch of a movie.
+CREATE TABLE list_rule (
+    list_id INTEGER PRIMARY KEY REFERENCES list(id) ON DELETE CASCADE,
+    -- TRUE keeps watched movies, FALSE unwatched ones
+    watched BOOLEAN,
+    in_theater BOOLEAN,
+    watched_year INTEGER,
+    min_rating REAL,
+    min_runtime INTEGER,
+    max_runtime INTEGER,
+    release_year_from INTEGER,
+    release_year_to INTEGER,
+    genre_id INTEGER,
+    -- TRUE keeps movies on the watchlist of the owner, FALSE the others
+    in_watchlist BOOLEAN,
+    -- keeps movies of directors whose movies the owner rated this much on average
+    director_min_rating REAL,
+    -- keeps movies with this person in their cast or crew
+    person_name TEXT
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS list_rule;
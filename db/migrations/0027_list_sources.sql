@@ -0,0 +1,14 @@
+-- +goose Up
+-- The TMDB list or collection a list was imported from. While sync is set the list mirrors
+-- its source: the periodic sync adds the movies new to the source and removes the ones it
+-- dropped, and nobody can change the movies by hand.
+CREATE TABLE list_source (
+    list_id INTEGER PRIMARY KEY REFERENCES list(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('list', 'collection')),
+    tmdb_id INTEGER NOT NULL,
+    sync BOOLEAN DEFAULT FALSE NOT NULL,
+    synced_at DATETIME NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS list_source;
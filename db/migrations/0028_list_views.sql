@@ -0,0 +1,19 @@
+-- +goose Up
+-- How each user last arranged the movies of a list they can see: the sort, the filters
+-- and the layout. Lists shared between users keep a view per user.
+CREATE TABLE list_view (
+    user_id INTEGER NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+    list_id INTEGER NOT NULL REFERENCES list(id) ON DELETE CASCADE,
+    sort TEXT DEFAULT 'position' NOT NULL,
+    sort_desc BOOLEAN DEFAULT FALSE NOT NULL,
+    genre_id INTEGER,
+    -- TRUE keeps watched movies, FALSE unwatched ones
+    watched BOOLEAN,
+    -- the first year of the decade, like 1990
+    decade INTEGER,
+    layout TEXT DEFAULT 'grid' NOT NULL CHECK (layout IN ('grid', 'table')),
+    PRIMARY KEY (user_id, list_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS list_view;
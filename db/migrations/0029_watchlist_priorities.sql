@@ -0,0 +1,34 @@
+-- +goose Up
+-- What a user plans for the movies of their watchlist: how much they want to watch each
+-- one, where it can be watched and free-form tags like "with kids". The watch next panel
+-- of the home page is filtered by them as set in watch_next_filter.
+ALTER TABLE list_movie ADD COLUMN priority TEXT DEFAULT 'normal' NOT NULL;
+
+ALTER TABLE list_movie ADD COLUMN available_on TEXT;
+
+CREATE TABLE list_movie_tag (
+    movie_id INTEGER NOT NULL,
+    list_id INTEGER NOT NULL,
+    tag TEXT NOT NULL COLLATE NOCASE,
+    PRIMARY KEY (list_id, movie_id, tag),
+    FOREIGN KEY (movie_id, list_id) REFERENCES list_movie(movie_id, list_id) ON DELETE CASCADE
+);
+
+CREATE TABLE watch_next_filter (
+    user_id INTEGER PRIMARY KEY REFERENCES user(id) ON DELETE CASCADE,
+    -- movies of lower priority are left out
+    min_priority TEXT DEFAULT 'low' NOT NULL,
+    -- keeps the movies with this tag
+    tag TEXT,
+    -- leaves out the movies with this tag
+    exclude_tag TEXT
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS watch_next_filter;
+
+DROP TABLE IF EXISTS list_movie_tag;
+
+ALTER TABLE list_movie DROP COLUMN available_on;
+
+ALTER TABLE list_movie DROP COLUMN priority;
@@ -166,6 +166,42 @@ func (d *SqliteDB) InsertWatched(ctx context.Context, watched InsertWatched) err
 	return nil
 }
 
+// InsertWatchedMovies records every entry of watched, all or nothing
+func (d *SqliteDB) InsertWatchedMovies(ctx context.Context, watched []InsertWatched) error {
+	log.Debug("inserting watched records", "count", len(watched))
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to start database transaction for watched records", "error", err)
+		return fmt.Errorf("failed to start db transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	qtx := d.queries.WithTx(tx)
+
+	for _, entry := range watched {
+		_, err := qtx.InsertWatched(ctx, sqlc.InsertWatchedParams{
+			UserID:           &entry.UserID,
+			MovieID:          entry.MovieID,
+			WatchedDate:      date.New(entry.Date),
+			WatchedInTheater: entry.InTheaters,
+			Rating:           entry.Rating,
+		})
+		if err != nil {
+			log.Error("failed to insert watched record", "movieID", entry.MovieID, "error", err)
+			return fmt.Errorf("failed to insert watched record for movie ID %d: %w", entry.MovieID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit watched records transaction", "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Debug("successfully inserted watched records", "count", len(watched))
+	return nil
+}
+
 func (d *SqliteDB) UpdateWatched(ctx context.Context, watched UpdateWatched) (int64, error) {
 	log.Debug("updating watched record", "watchedID", watched.ID, "date", watched.Date, "inTheaters", watched.InTheaters)
 
@@ -430,6 +466,8 @@ func (d *SqliteDB) GetRecentWatchedMovies(ctx context.Context, userID int64, lim
 	return result, nil
 }
 
+// InsertList creates list, returning ErrListNameTaken when it is a custom list and the user
+// already has one with its name
 func (d *SqliteDB) InsertList(ctx context.Context, list InsertList) (int64, error) {
 	log.Debug("inserting new list into database", "name", list.Name)
 
@@ -440,6 +478,11 @@ func (d *SqliteDB) InsertList(ctx context.Context, list InsertList) (int64, erro
 		Description:  list.Description,
 		IsWatchlist:  list.IsWatchlist,
 	})
+	// the only unique index custom lists can break is the one on their names
+	if !list.IsWatchlist && isUniqueViolation(err) {
+		log.Debug("list name already taken", "name", list.Name)
+		return 0, ErrListNameTaken
+	}
 	if err != nil {
 		log.Error("failed to insert list", "name", list.Name, "error", err)
 		return 0, fmt.Errorf("failed to insert list %q: %w", list.Name, err)
@@ -449,6 +492,8 @@ func (d *SqliteDB) InsertList(ctx context.Context, list InsertList) (int64, erro
 	return id, nil
 }
 
+// GetList returns list id with its movies, when userID owns it or it is shared with them.
+// Role tells which of the two.
 func (d *SqliteDB) GetList(ctx context.Context, userID, id int64) (*models.List, error) {
 	log.Debug("retrieving list with movies", "listID", id)
 
@@ -461,40 +506,112 @@ func (d *SqliteDB) GetList(ctx context.Context, userID, id int64) (*models.List,
 
 	qtx := d.queries.WithTx(tx)
 
-	results, err := qtx.GetListJoinMovieByID(ctx, sqlc.GetListJoinMovieByIDParams{UserID: &userID, ID: id})
+	access, err := qtx.GetListAccess(ctx, sqlc.GetListAccessParams{UserID: userID, ID: id})
 	if err != nil {
-		log.Error("failed to fetch list with movies", "listID", id, "error", err)
-		return nil, fmt.Errorf("failed to fetch list with ID %d: %w", id, err)
+		// no list with that id exists, or the user cannot see it
+		return nil, fmt.Errorf("failed to get list by ID %d: %w", id, err)
 	}
-	if len(results) == 0 {
-		log.Debug("list has no movies associated to it", "listID", id)
-		// try to search for the list without joining in case it is empty
-		list, err := qtx.GetListByID(ctx, sqlc.GetListByIDParams{UserID: &userID, ID: id})
-		if err != nil {
-			// no list with that id exists, return an error
-			return nil, fmt.Errorf("failed to get list by ID %d: %w", id, err)
-		}
 
-		// this list exists but it is empty, return it anyway
-		creationDate, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", list.CreationDate)
-		if err != nil {
-			log.Error("failed to parse list creation_date", "listID", id, "error", err)
-			return nil, fmt.Errorf("failed to parse creation_date for list %d: %w", id, err)
+	list := access.List
+	creationDate, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", list.CreationDate)
+	if err != nil {
+		log.Error("failed to parse list creation_date", "listID", id, "error", err)
+		return nil, fmt.Errorf("failed to parse creation_date for list %d: %w", id, err)
+	}
+
+	var rules *models.ListRules
+	rulesRow, err := qtx.GetListRules(ctx, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// a list of movies added by hand
+	case err != nil:
+		log.Error("failed to fetch list rules", "listID", id, "error", err)
+		return nil, fmt.Errorf("failed to fetch rules of list with ID %d: %w", id, err)
+	default:
+		rules = toModelsListRules(rulesRow)
+	}
+
+	var source *models.ListSource
+	sourceRow, err := qtx.GetListSource(ctx, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// a list made in gowatch
+	case err != nil:
+		log.Error("failed to fetch list source", "listID", id, "error", err)
+		return nil, fmt.Errorf("failed to fetch source of list with ID %d: %w", id, err)
+	default:
+		source = &models.ListSource{
+			Kind:     models.ListSourceKind(sourceRow.Kind),
+			TMDBID:   sourceRow.TmdbID,
+			Sync:     sourceRow.Sync,
+			SyncedAt: sourceRow.SyncedAt,
 		}
+	}
 
-		return &models.List{
-			ID:           list.ID,
-			Name:         list.Name,
-			CreationDate: creationDate,
-			Description:  list.Description,
-			IsWatchlist:  list.IsWatchlist,
-			Movies:       []models.MovieItem{},
-		}, nil
+	var movies []models.MovieItem
+	if rules != nil {
+		movies, err = smartListMovies(ctx, qtx, id, creationDate)
+	} else {
+		movies, err = listMovies(ctx, qtx, id)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	list := results[0].List
-	movies := make([]models.MovieItem, len(results))
+	memberCount, err := qtx.CountListMembers(ctx, id)
+	if err != nil {
+		log.Error("failed to count list members", "listID", id, "error", err)
+		return nil, fmt.Errorf("failed to count members of list %d: %w", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit list retrieval transaction", "listID", id, "error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	role := models.ListRoleOwner
+	if access.MemberRole != nil && (list.UserID == nil || *list.UserID != userID) {
+		role = models.ListRole(*access.MemberRole)
+	}
+
+	log.Info("successfully retrieved list with movies", "listID", id, "movieCount", len(movies))
+	return &models.List{
+		ID:           list.ID,
+		Name:         list.Name,
+		CreationDate: creationDate,
+		Description:  list.Description,
+		IsWatchlist:  list.IsWatchlist,
+		Ranked:       list.Ranked,
+		ShareToken:   list.ShareToken,
+		ShareNotes:   list.ShareNotes,
+		OwnerName:    access.OwnerName,
+		Role:         role,
+		MemberCount:  memberCount,
+		Rules:        rules,
+		Source:       source,
+		Movies:       movies,
+	}, nil
+}
+
+// listMovies returns the movies added to list id
+func listMovies(ctx context.Context, q *sqlc.Queries, id int64) ([]models.MovieItem, error) {
+	results, err := q.GetListMovies(ctx, id)
+	if err != nil {
+		log.Error("failed to fetch list movies", "listID", id, "error", err)
+		return nil, fmt.Errorf("failed to fetch movies of list with ID %d: %w", id, err)
+	}
+
+	tagRows, err := q.GetListMovieTags(ctx, id)
+	if err != nil {
+		log.Error("failed to fetch list movie tags", "listID", id, "error", err)
+		return nil, fmt.Errorf("failed to fetch movie tags of list with ID %d: %w", id, err)
+	}
+	tags := make(map[int64][]string)
+	for _, row := range tagRows {
+		tags[row.MovieID] = append(tags[row.MovieID], row.Tag)
+	}
 
+	movies := make([]models.MovieItem, len(results))
 	for i, result := range results {
 		dateAdded, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", result.ListMovie.DateAdded)
 		if err != nil {
@@ -507,49 +624,364 @@ func (d *SqliteDB) GetList(ctx context.Context, userID, id int64) (*models.List,
 			DateAdded:    dateAdded,
 			Position:     result.ListMovie.Position,
 			Note:         result.ListMovie.Note,
+			AddedBy:      result.AddedByName,
+			Priority:     models.WatchlistPriority(result.ListMovie.Priority),
+			Tags:         tags[result.Movie.ID],
+			AvailableOn:  result.ListMovie.AvailableOn,
+		}
+	}
+	return movies, nil
+}
+
+// smartListMovies returns the movies matching the rules of smart list id, most recently
+// watched first. Movies count as added when they were last watched, or when the list was
+// created if the owner has not watched them.
+func smartListMovies(ctx context.Context, q *sqlc.Queries, id int64, creationDate time.Time) ([]models.MovieItem, error) {
+	results, err := q.GetSmartListMovies(ctx, sqlc.GetSmartListMoviesParams{ListIds: []int64{id}})
+	if err != nil {
+		log.Error("failed to evaluate smart list rules", "listID", id, "error", err)
+		return nil, fmt.Errorf("failed to evaluate rules of list with ID %d: %w", id, err)
+	}
+
+	movies := make([]models.MovieItem, len(results))
+	for i, result := range results {
+		dateAdded, err := scanDateValue(result.LastWatchedDate)
+		if err != nil {
+			log.Error("failed to parse last watched date in smart list", "listID", id, "movieID", result.Movie.ID, "error", err)
+			return nil, fmt.Errorf("failed to parse last watched date for movie %d: %w", result.Movie.ID, err)
+		}
+		if dateAdded.IsZero() {
+			dateAdded = creationDate
+		}
+
+		movies[i] = models.MovieItem{
+			MovieDetails: toModelsMovieDetails(result.Movie),
+			DateAdded:    dateAdded,
+		}
+	}
+	return movies, nil
+}
+
+func toModelsListRules(rules sqlc.ListRule) *models.ListRules {
+	return &models.ListRules{
+		Watched:           rules.Watched,
+		InTheater:         rules.InTheater,
+		WatchedYear:       rules.WatchedYear,
+		MinRating:         rules.MinRating,
+		MinRuntime:        rules.MinRuntime,
+		MaxRuntime:        rules.MaxRuntime,
+		ReleaseYearFrom:   rules.ReleaseYearFrom,
+		ReleaseYearTo:     rules.ReleaseYearTo,
+		GenreID:           rules.GenreID,
+		InWatchlist:       rules.InWatchlist,
+		DirectorMinRating: rules.DirectorMinRating,
+		PersonName:        rules.PersonName,
+	}
+}
+
+// SetListRules saves the rules of listID, turning it into a smart list, when userID owns
+// it. It returns how many lists were changed, 0 when the user does not own listID.
+func (d *SqliteDB) SetListRules(ctx context.Context, userID, listID int64, rules models.ListRules) (int64, error) {
+	log.Debug("setting list rules", "listID", listID)
+
+	rows, err := d.queries.UpsertListRules(ctx, sqlc.UpsertListRulesParams{
+		Watched:           rules.Watched,
+		InTheater:         rules.InTheater,
+		WatchedYear:       rules.WatchedYear,
+		MinRating:         rules.MinRating,
+		MinRuntime:        rules.MinRuntime,
+		MaxRuntime:        rules.MaxRuntime,
+		ReleaseYearFrom:   rules.ReleaseYearFrom,
+		ReleaseYearTo:     rules.ReleaseYearTo,
+		GenreID:           rules.GenreID,
+		InWatchlist:       rules.InWatchlist,
+		DirectorMinRating: rules.DirectorMinRating,
+		PersonName:        rules.PersonName,
+		ListID:            listID,
+		UserID:            &userID,
+	})
+	if err != nil {
+		log.Error("failed to set list rules", "listID", listID, "error", err)
+		return 0, fmt.Errorf("failed to set rules of list %d: %w", listID, err)
+	}
+
+	log.Info("successfully set list rules", "listID", listID, "rows", rows)
+	return rows, nil
+}
+
+// GetGenres returns every genre of the local library, by name
+func (d *SqliteDB) GetGenres(ctx context.Context) ([]models.Genre, error) {
+	log.Debug("retrieving genres")
+
+	results, err := d.queries.GetGenres(ctx)
+	if err != nil {
+		log.Error("failed to get genres", "error", err)
+		return nil, fmt.Errorf("failed to get genres: %w", err)
+	}
+
+	genres := make([]models.Genre, len(results))
+	for i, result := range results {
+		genres[i] = models.Genre{ID: result.ID, Name: result.Name}
+	}
+	return genres, nil
+}
+
+// SetListSource records the TMDB source of listID when userID owns it. It returns how many
+// lists were changed, 0 when the user does not own listID.
+func (d *SqliteDB) SetListSource(ctx context.Context, userID, listID int64, source models.ListSource) (int64, error) {
+	log.Debug("setting list source", "listID", listID, "kind", source.Kind, "tmdbID", source.TMDBID)
+
+	rows, err := d.queries.UpsertListSource(ctx, sqlc.UpsertListSourceParams{
+		Kind:     string(source.Kind),
+		TmdbID:   source.TMDBID,
+		Sync:     source.Sync,
+		SyncedAt: source.SyncedAt.UTC(),
+		ListID:   listID,
+		UserID:   &userID,
+	})
+	if err != nil {
+		log.Error("failed to set list source", "listID", listID, "error", err)
+		return 0, fmt.Errorf("failed to set source of list %d: %w", listID, err)
+	}
+
+	log.Info("successfully set list source", "listID", listID, "rows", rows)
+	return rows, nil
+}
+
+// SetListSourceSync starts or stops syncing listID with its source when userID owns it. It
+// returns how many lists were changed, 0 when the user does not own listID or it has no
+// source.
+func (d *SqliteDB) SetListSourceSync(ctx context.Context, userID, listID int64, sync bool) (int64, error) {
+	log.Debug("setting list source sync", "listID", listID, "sync", sync)
+
+	rows, err := d.queries.SetListSourceSync(ctx, sqlc.SetListSourceSyncParams{
+		Sync:   sync,
+		ListID: listID,
+		UserID: &userID,
+	})
+	if err != nil {
+		log.Error("failed to set list source sync", "listID", listID, "error", err)
+		return 0, fmt.Errorf("failed to set sync of list %d: %w", listID, err)
+	}
+
+	log.Info("successfully set list source sync", "listID", listID, "sync", sync, "rows", rows)
+	return rows, nil
+}
+
+func (d *SqliteDB) SetListSourceSyncedAt(ctx context.Context, listID int64, syncedAt time.Time) error {
+	log.Debug("setting list source synced at", "listID", listID)
+
+	err := d.queries.SetListSourceSyncedAt(ctx, sqlc.SetListSourceSyncedAtParams{
+		SyncedAt: syncedAt.UTC(),
+		ListID:   listID,
+	})
+	if err != nil {
+		log.Error("failed to set list source synced at", "listID", listID, "error", err)
+		return fmt.Errorf("failed to set synced at of list %d: %w", listID, err)
+	}
+	return nil
+}
+
+// GetSyncedListSources returns every list kept in sync with its source, least recently
+// synced first
+func (d *SqliteDB) GetSyncedListSources(ctx context.Context) ([]SyncedListSource, error) {
+	log.Debug("retrieving synced list sources")
+
+	results, err := d.queries.GetSyncedListSources(ctx)
+	if err != nil {
+		log.Error("failed to get synced list sources", "error", err)
+		return nil, fmt.Errorf("failed to get synced list sources: %w", err)
+	}
+
+	sources := make([]SyncedListSource, 0, len(results))
+	for _, result := range results {
+		if result.UserID == nil {
+			// lists from before users existed have no owner to sync them for
+			continue
+		}
+		sources = append(sources, SyncedListSource{ListID: result.ListID, UserID: *result.UserID})
+	}
+
+	log.Debug("retrieved synced list sources", "count", len(sources))
+	return sources, nil
+}
+
+// SetListMovieDetails replaces what is planned for movieID of listID, see
+// models.WatchlistItem. It returns how many movies were changed, 0 when movieID is not in
+// the list.
+func (d *SqliteDB) SetListMovieDetails(ctx context.Context, listID, movieID int64, item models.WatchlistItem) (int64, error) {
+	log.Debug("setting list movie details", "listID", listID, "movieID", movieID, "priority", item.Priority)
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		log.Error("failed to start database transaction for list movie details", "listID", listID, "error", err)
+		return 0, fmt.Errorf("failed to start db transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	qtx := d.queries.WithTx(tx)
+
+	rows, err := qtx.UpdateListMovieDetails(ctx, sqlc.UpdateListMovieDetailsParams{
+		Priority:    string(item.Priority),
+		AvailableOn: item.AvailableOn,
+		ListID:      listID,
+		MovieID:     movieID,
+	})
+	if err != nil {
+		log.Error("failed to update list movie details", "listID", listID, "movieID", movieID, "error", err)
+		return 0, fmt.Errorf("failed to update details of movie %d in list %d: %w", movieID, listID, err)
+	}
+	if rows == 0 {
+		return 0, nil
+	}
+
+	if err := qtx.DeleteListMovieTags(ctx, sqlc.DeleteListMovieTagsParams{ListID: listID, MovieID: movieID}); err != nil {
+		log.Error("failed to delete list movie tags", "listID", listID, "movieID", movieID, "error", err)
+		return 0, fmt.Errorf("failed to delete tags of movie %d in list %d: %w", movieID, listID, err)
+	}
+	for _, tag := range item.Tags {
+		err := qtx.InsertListMovieTag(ctx, sqlc.InsertListMovieTagParams{ListID: listID, MovieID: movieID, Tag: tag})
+		if err != nil {
+			log.Error("failed to insert list movie tag", "listID", listID, "movieID", movieID, "tag", tag, "error", err)
+			return 0, fmt.Errorf("failed to tag movie %d in list %d: %w", movieID, listID, err)
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		log.Error("failed to commit list retrieval transaction", "listID", id, "error", err)
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		log.Error("failed to commit list movie details transaction", "listID", listID, "error", err)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	return rows, nil
+}
 
-	creationDate, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", list.CreationDate)
+func (d *SqliteDB) GetWatchNextFilter(ctx context.Context, userID int64) (*models.WatchNextFilter, error) {
+	log.Debug("retrieving watch next filter", "userID", userID)
+
+	result, err := d.queries.GetWatchNextFilter(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
 	if err != nil {
-		log.Error("failed to parse list creation_date", "listID", id, "error", err)
-		return nil, fmt.Errorf("failed to parse creation_date for list %d: %w", id, err)
+		log.Error("failed to get watch next filter", "userID", userID, "error", err)
+		return nil, fmt.Errorf("failed to get watch next filter: %w", err)
 	}
 
-	log.Info("successfully retrieved list with movies", "listID", id, "movieCount", len(movies))
-	return &models.List{
-		ID:           list.ID,
-		Name:         list.Name,
-		CreationDate: creationDate,
-		Description:  list.Description,
-		IsWatchlist:  list.IsWatchlist,
-		Movies:       movies,
+	return &models.WatchNextFilter{
+		MinPriority: models.WatchlistPriority(result.MinPriority),
+		Tag:         result.Tag,
+		ExcludeTag:  result.ExcludeTag,
+	}, nil
+}
+
+func (d *SqliteDB) SetWatchNextFilter(ctx context.Context, userID int64, filter models.WatchNextFilter) error {
+	log.Debug("setting watch next filter", "userID", userID, "minPriority", filter.MinPriority)
+
+	err := d.queries.UpsertWatchNextFilter(ctx, sqlc.UpsertWatchNextFilterParams{
+		UserID:      userID,
+		MinPriority: string(filter.MinPriority),
+		Tag:         filter.Tag,
+		ExcludeTag:  filter.ExcludeTag,
+	})
+	if err != nil {
+		log.Error("failed to set watch next filter", "userID", userID, "error", err)
+		return fmt.Errorf("failed to set watch next filter: %w", err)
+	}
+	return nil
+}
+
+func (d *SqliteDB) GetListView(ctx context.Context, userID, listID int64) (*models.ListView, error) {
+	log.Debug("retrieving list view", "userID", userID, "listID", listID)
+
+	result, err := d.queries.GetListView(ctx, sqlc.GetListViewParams{UserID: userID, ListID: listID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error("failed to get list view", "userID", userID, "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to get view of list %d: %w", listID, err)
+	}
+
+	return &models.ListView{
+		Sort:    models.ListSort(result.Sort),
+		Desc:    result.SortDesc,
+		GenreID: result.GenreID,
+		Watched: result.Watched,
+		Decade:  result.Decade,
+		Layout:  models.ListLayout(result.Layout),
 	}, nil
 }
 
+func (d *SqliteDB) SetListView(ctx context.Context, userID, listID int64, view models.ListView) error {
+	log.Debug("setting list view", "userID", userID, "listID", listID, "sort", view.Sort, "layout", view.Layout)
+
+	err := d.queries.UpsertListView(ctx, sqlc.UpsertListViewParams{
+		UserID:   userID,
+		ListID:   listID,
+		Sort:     string(view.Sort),
+		SortDesc: view.Desc,
+		GenreID:  view.GenreID,
+		Watched:  view.Watched,
+		Decade:   view.Decade,
+		Layout:   string(view.Layout),
+	})
+	if err != nil {
+		log.Error("failed to set list view", "userID", userID, "listID", listID, "error", err)
+		return fmt.Errorf("failed to set view of list %d: %w", listID, err)
+	}
+	return nil
+}
+
+// GetMoviesGenres returns the genres of each of movieIDs, by name
+func (d *SqliteDB) GetMoviesGenres(ctx context.Context, movieIDs []int64) (map[int64][]models.Genre, error) {
+	log.Debug("retrieving genres of movies", "count", len(movieIDs))
+
+	results, err := d.queries.GetMoviesGenres(ctx, movieIDs)
+	if err != nil {
+		log.Error("failed to get genres of movies", "error", err)
+		return nil, fmt.Errorf("failed to get genres of movies: %w", err)
+	}
+
+	genres := make(map[int64][]models.Genre)
+	for _, result := range results {
+		genres[result.MovieID] = append(genres[result.MovieID], models.Genre{ID: result.Genre.ID, Name: result.Genre.Name})
+	}
+	return genres, nil
+}
+
+func (d *SqliteDB) GetMovieWatchSummaries(ctx context.Context, userID int64, movieIDs []int64) (map[int64]models.MovieWatchSummary, error) {
+	log.Debug("retrieving movie watch summaries", "userID", userID, "count", len(movieIDs))
+
+	results, err := d.queries.GetMoviesWatchSummaries(ctx, sqlc.GetMoviesWatchSummariesParams{
+		UserID:   &userID,
+		MovieIds: movieIDs,
+	})
+	if err != nil {
+		log.Error("failed to get movie watch summaries", "userID", userID, "error", err)
+		return nil, fmt.Errorf("failed to get movie watch summaries: %w", err)
+	}
+
+	watches := make(map[int64]models.MovieWatchSummary, len(results))
+	for _, result := range results {
+		watches[result.MovieID] = models.MovieWatchSummary{Count: result.WatchCount, Rating: result.Rating}
+	}
+	return watches, nil
+}
+
 func (d *SqliteDB) AddMovieToList(ctx context.Context, userID int64, insertMovieList InsertMovieList) error {
 	log.Debug("adding movie to list", "movieID", insertMovieList.MovieID, "position", insertMovieList.Position)
 
-	// First verify the list exists and belongs to the user
-	_, err := d.queries.GetListByID(ctx, sqlc.GetListByIDParams{UserID: &userID, ID: insertMovieList.ListID})
-	if err != nil {
-		log.Error("failed to verify list ownership", "listID", insertMovieList.ListID, "userID", userID, "error", err)
-		return fmt.Errorf("failed to verify list ownership: %w", err)
+	// First verify the list exists and the user can edit it
+	if err := d.checkListEditable(ctx, d.queries, userID, insertMovieList.ListID); err != nil {
+		return err
 	}
 
-	err = d.queries.AddMovieToList(ctx, sqlc.AddMovieToListParams{
+	err := d.queries.AddMovieToList(ctx, sqlc.AddMovieToListParams{
 		MovieID:   insertMovieList.MovieID,
-		ListID:    insertMovieList.ListID,
 		DateAdded: insertMovieList.DateAdded.Format("2006-01-02 15:04:05.999999999 -0700 MST"),
 		Position:  insertMovieList.Position,
 		Note:      insertMovieList.Note,
-		ID:        insertMovieList.ListID,
 		UserID:    &userID,
+		ListID:    insertMovieList.ListID,
 	})
 	if err != nil {
 		log.Error("failed to add movie to list", "movieID", insertMovieList.MovieID, "error", err)
@@ -560,6 +992,22 @@ func (d *SqliteDB) AddMovieToList(ctx context.Context, userID int64, insertMovie
 	return nil
 }
 
+// checkListEditable returns an error wrapping sql.ErrNoRows unless userID owns listID or
+// edits it as a member
+func (d *SqliteDB) checkListEditable(ctx context.Context, q *sqlc.Queries, userID, listID int64) error {
+	access, err := q.GetListAccess(ctx, sqlc.GetListAccessParams{UserID: userID, ID: listID})
+	if err != nil {
+		log.Error("failed to verify list access", "listID", listID, "userID", userID, "error", err)
+		return fmt.Errorf("failed to verify list access: %w", err)
+	}
+	owner := access.List.UserID != nil && *access.List.UserID == userID
+	if !owner && (access.MemberRole == nil || !models.ListRole(*access.MemberRole).CanEdit()) {
+		log.Warn("user cannot edit list", "listID", listID, "userID", userID)
+		return fmt.Errorf("user %d cannot edit list %d: %w", userID, listID, sql.ErrNoRows)
+	}
+	return nil
+}
+
 func (d *SqliteDB) UpsertMovieInList(ctx context.Context, userID int64, insertMovieList InsertMovieList) error {
 	log.Debug("upserting movie in list", "movieID", insertMovieList.MovieID, "listID", insertMovieList.ListID)
 
@@ -600,10 +1048,12 @@ func (d *SqliteDB) GetAllLists(ctx context.Context, userID int64) ([]InsertList,
 	lists := make([]InsertList, len(results))
 	for i, result := range results {
 		lists[i] = InsertList{
-			ID:          result.ID,
-			Name:        result.Name,
-			Description: result.Description,
-			IsWatchlist: result.IsWatchlist,
+			ID:          result.List.ID,
+			Name:        result.List.Name,
+			Description: result.List.Description,
+			IsWatchlist: result.List.IsWatchlist,
+			Smart:       result.Smart != 0,
+			Synced:      result.Synced != 0,
 		}
 	}
 
@@ -624,55 +1074,526 @@ func (d *SqliteDB) GetWatchedCount(ctx context.Context, userID int64) (int64, er
 	return count, nil
 }
 
-func (d *SqliteDB) DeleteListByID(ctx context.Context, userID, id int64) error {
-	log.Debug("deleting list by ID", "listID", id)
+// GetListOwner returns the ID of the user who created the list, whoever is asking
+func (d *SqliteDB) GetListOwner(ctx context.Context, listID int64) (int64, error) {
+	log.Debug("getting list owner", "listID", listID)
 
-	err := d.queries.DeleteListByID(ctx, sqlc.DeleteListByIDParams{UserID: &userID, ID: id})
+	userID, err := d.queries.GetListOwner(ctx, listID)
 	if err != nil {
-		log.Error("failed to delete list", "listID", id, "error", err)
-		return fmt.Errorf("failed to delete list with id '%d' in db: %w", id, err)
+		log.Error("failed to get list owner", "listID", listID, "error", err)
+		return 0, fmt.Errorf("failed to get owner of list %d: %w", listID, err)
 	}
-
-	log.Debug("successfully deleted list", "listID", id)
-	return nil
+	if userID == nil {
+		return 0, fmt.Errorf("list %d has no owner", listID)
+	}
+	return *userID, nil
 }
 
-func (d *SqliteDB) DeleteMovieFromList(ctx context.Context, userID, listID, movieID int64) error {
-	log.Debug("deleting movie from list", "listID", listID, "movieID", movieID)
+// UpdateList renames list id and replaces its description when userID owns it, returning
+// ErrListNameTaken when another custom list of the user has the name
+func (d *SqliteDB) UpdateList(ctx context.Context, userID, id int64, name string, description *string) (int64, error) {
+	log.Debug("updating list", "listID", id, "name", name)
 
-	err := d.queries.DeleteMovieFromList(ctx, sqlc.DeleteMovieFromListParams{
-		ListID:  listID,
-		MovieID: movieID,
-		UserID:  &userID,
+	rows, err := d.queries.UpdateList(ctx, sqlc.UpdateListParams{
+		Name:        name,
+		Description: description,
+		UserID:      &userID,
+		ID:          id,
 	})
+	// a rename only changes the name, the one unique index it can break
+	if isUniqueViolation(err) {
+		log.Debug("list name already taken", "listID", id, "name", name)
+		return 0, ErrListNameTaken
+	}
 	if err != nil {
-		log.Error("failed to delete movie from list", "listID", listID, "movieID", movieID, "error", err)
-		return fmt.Errorf("failed to delete movie '%d' from list '%d' in db: %w", movieID, listID, err)
+		log.Error("failed to update list", "listID", id, "error", err)
+		return 0, fmt.Errorf("failed to update list with id '%d' in db: %w", id, err)
 	}
 
-	log.Debug("successfully deleted movie from list", "listID", listID, "movieID", movieID)
-	return nil
+	log.Debug("successfully updated list", "listID", id, "rows", rows)
+	return rows, nil
 }
 
-func (d *SqliteDB) GetWatchlistID(ctx context.Context, userID int64) (int64, error) {
-	log.Debug("getting watchlist ID", "userID", userID)
+func (d *SqliteDB) SetListRanked(ctx context.Context, userID, id int64, ranked bool) (int64, error) {
+	log.Debug("setting list ranking", "listID", id, "ranked", ranked)
 
-	id, err := d.queries.GetWatchlistID(ctx, &userID)
+	rows, err := d.queries.SetListRanked(ctx, sqlc.SetListRankedParams{
+		Ranked: ranked,
+		UserID: &userID,
+		ID:     id,
+	})
 	if err != nil {
-		log.Error("failed to get watchlist ID", "userID", userID, "error", err)
-		return 0, fmt.Errorf("failed to get watchlist ID for user %d: %w", userID, err)
+		log.Error("failed to set list ranking", "listID", id, "error", err)
+		return 0, fmt.Errorf("failed to set ranking of list with id '%d' in db: %w", id, err)
 	}
-
-	log.Debug("retrieved watchlist ID", "userID", userID, "watchlistID", id)
-	return id, nil
+	return rows, nil
 }
 
-func (d *SqliteDB) GetWatchedStatsPerMonthLastYear(ctx context.Context, userID int64) ([]models.PeriodStats, error) {
-	log.Debug("getting watched stats per month last year")
+func (d *SqliteDB) SetListShare(ctx context.Context, userID, id int64, token *string, notes bool) (int64, error) {
+	log.Debug("setting list share link", "listID", id, "shared", token != nil, "notes", notes)
 
-	data, err := d.queries.GetWatchedStatsPerMonthLastYear(ctx, &userID)
+	rows, err := d.queries.SetListShare(ctx, sqlc.SetListShareParams{
+		ShareToken: token,
+		ShareNotes: notes,
+		UserID:     &userID,
+		ID:         id,
+	})
 	if err != nil {
-		log.Error("failed to get monthly stats", "error", err)
+		log.Error("failed to set list share link", "listID", id, "error", err)
+		return 0, fmt.Errorf("failed to set share link of list with id '%d' in db: %w", id, err)
+	}
+	return rows, nil
+}
+
+// GetSharedList returns the list shared with token, with its movies
+func (d *SqliteDB) GetSharedList(ctx context.Context, token string) (*models.List, error) {
+	log.Debug("retrieving shared list")
+
+	list, err := d.queries.GetListByShareToken(ctx, &token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list by share token: %w", err)
+	}
+	if list.UserID == nil {
+		return nil, fmt.Errorf("shared list %d has no owner: %w", list.ID, sql.ErrNoRows)
+	}
+
+	return d.GetList(ctx, *list.UserID, list.ID)
+}
+
+// ReorderList ranks the movies of a list in the order of movieIDs, which must hold every
+// movie of the list exactly once. Either every position is updated or none is.
+func (d *SqliteDB) ReorderList(ctx context.Context, userID, listID int64, movieIDs []int64) error {
+	log.Debug("reordering list", "listID", listID, "movieCount", len(movieIDs))
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to start database transaction for list reorder", "listID", listID, "error", err)
+		return fmt.Errorf("failed to start db transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	qtx := d.queries.WithTx(tx)
+
+	if err := d.checkListEditable(ctx, qtx, userID, listID); err != nil {
+		return err
+	}
+	if err := setListPositions(ctx, qtx, listID, movieIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit list reorder transaction", "listID", listID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Info("successfully reordered list", "listID", listID, "movieCount", len(movieIDs))
+	return nil
+}
+
+// setListPositions ranks the movies of listID in the order of movieIDs, which must hold
+// every movie of the list exactly once
+func setListPositions(ctx context.Context, q *sqlc.Queries, listID int64, movieIDs []int64) error {
+	count, err := q.CountListMovies(ctx, listID)
+	if err != nil {
+		return fmt.Errorf("failed to count movies of list %d: %w", listID, err)
+	}
+	if count != int64(len(movieIDs)) {
+		return fmt.Errorf("list %d has %d movies, got an order of %d", listID, count, len(movieIDs))
+	}
+
+	for i, movieID := range movieIDs {
+		position := int64(i + 1)
+		rows, err := q.SetListMoviePosition(ctx, sqlc.SetListMoviePositionParams{
+			Position: &position,
+			ListID:   listID,
+			MovieID:  movieID,
+		})
+		if err != nil {
+			log.Error("failed to set movie position", "listID", listID, "movieID", movieID, "error", err)
+			return fmt.Errorf("failed to set position of movie %d in list %d: %w", movieID, listID, err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("movie %d is not in list %d: %w", movieID, listID, sql.ErrNoRows)
+		}
+	}
+	return nil
+}
+
+func (d *SqliteDB) DeleteListByID(ctx context.Context, userID, id int64) error {
+	log.Debug("deleting list by ID", "listID", id)
+
+	err := d.queries.DeleteListByID(ctx, sqlc.DeleteListByIDParams{UserID: &userID, ID: id})
+	if err != nil {
+		log.Error("failed to delete list", "listID", id, "error", err)
+		return fmt.Errorf("failed to delete list with id '%d' in db: %w", id, err)
+	}
+
+	log.Debug("successfully deleted list", "listID", id)
+	return nil
+}
+
+func (d *SqliteDB) DeleteMovieFromList(ctx context.Context, userID, listID, movieID int64) error {
+	log.Debug("deleting movie from list", "listID", listID, "movieID", movieID)
+
+	err := d.queries.DeleteMovieFromList(ctx, sqlc.DeleteMovieFromListParams{
+		ListID:  listID,
+		MovieID: movieID,
+		UserID:  userID,
+	})
+	if err != nil {
+		log.Error("failed to delete movie from list", "listID", listID, "movieID", movieID, "error", err)
+		return fmt.Errorf("failed to delete movie '%d' from list '%d' in db: %w", movieID, listID, err)
+	}
+
+	log.Debug("successfully deleted movie from list", "listID", listID, "movieID", movieID)
+	return nil
+}
+
+// CopyListMovies copies movieIDs from fromListID to toListID with their date added,
+// position and note, moving them when move is set. userID must be able to edit the target,
+// and the source too when moving. A move returns the movies it removed from fromListID.
+func (d *SqliteDB) CopyListMovies(ctx context.Context, userID, fromListID, toListID int64, movieIDs []int64, move bool) ([]int64, error) {
+	log.Debug("copying movies between lists", "fromListID", fromListID, "toListID", toListID, "movieCount", len(movieIDs), "move", move)
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to start database transaction for list copy", "fromListID", fromListID, "toListID", toListID, "error", err)
+		return nil, fmt.Errorf("failed to start db transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	qtx := d.queries.WithTx(tx)
+
+	if err := d.checkListEditable(ctx, qtx, userID, toListID); err != nil {
+		return nil, err
+	}
+	if move {
+		if err := d.checkListEditable(ctx, qtx, userID, fromListID); err != nil {
+			return nil, err
+		}
+	} else if _, err := qtx.GetListAccess(ctx, sqlc.GetListAccessParams{UserID: userID, ID: fromListID}); err != nil {
+		log.Error("failed to verify list access", "listID", fromListID, "userID", userID, "error", err)
+		return nil, fmt.Errorf("failed to verify list access: %w", err)
+	}
+
+	err = qtx.CopyListMovies(ctx, sqlc.CopyListMoviesParams{
+		ToListID:   toListID,
+		UserID:     &userID,
+		FromListID: fromListID,
+		MovieIds:   movieIDs,
+	})
+	if err != nil {
+		log.Error("failed to copy movies between lists", "fromListID", fromListID, "toListID", toListID, "error", err)
+		return nil, fmt.Errorf("failed to copy movies from list %d to list %d: %w", fromListID, toListID, err)
+	}
+
+	var moved []int64
+	if move {
+		moved, err = qtx.DeleteListMovies(ctx, sqlc.DeleteListMoviesParams{ListID: fromListID, MovieIds: movieIDs})
+		if err != nil {
+			log.Error("failed to remove moved movies", "listID", fromListID, "error", err)
+			return nil, fmt.Errorf("failed to remove moved movies from list %d: %w", fromListID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit list copy transaction", "fromListID", fromListID, "toListID", toListID, "error", err)
+		return nil, fmt.Errorf("failed to commit list copy: %w", err)
+	}
+
+	log.Debug("successfully copied movies between lists", "fromListID", fromListID, "toListID", toListID, "movieCount", len(movieIDs))
+	return moved, nil
+}
+
+// MergeList copies movieIDs of fromListID to intoListID like CopyListMovies, then deletes
+// fromListID, all or nothing. userID must own fromListID and be able to edit intoListID.
+// When order is set the movies of intoListID are ranked in it once copied.
+func (d *SqliteDB) MergeList(ctx context.Context, userID, fromListID, intoListID int64, movieIDs, order []int64) error {
+	log.Debug("merging lists", "fromListID", fromListID, "intoListID", intoListID, "movieCount", len(movieIDs))
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to start database transaction for list merge", "fromListID", fromListID, "intoListID", intoListID, "error", err)
+		return fmt.Errorf("failed to start db transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	qtx := d.queries.WithTx(tx)
+
+	if _, err := qtx.GetListByID(ctx, sqlc.GetListByIDParams{UserID: &userID, ID: fromListID}); err != nil {
+		log.Error("failed to verify list ownership", "listID", fromListID, "userID", userID, "error", err)
+		return fmt.Errorf("failed to verify list ownership: %w", err)
+	}
+	if err := d.checkListEditable(ctx, qtx, userID, intoListID); err != nil {
+		return err
+	}
+
+	if len(movieIDs) > 0 {
+		err = qtx.CopyListMovies(ctx, sqlc.CopyListMoviesParams{
+			ToListID:   intoListID,
+			UserID:     &userID,
+			FromListID: fromListID,
+			MovieIds:   movieIDs,
+		})
+		if err != nil {
+			log.Error("failed to copy movies between lists", "fromListID", fromListID, "intoListID", intoListID, "error", err)
+			return fmt.Errorf("failed to copy movies from list %d to list %d: %w", fromListID, intoListID, err)
+		}
+	}
+	if order != nil {
+		if err := setListPositions(ctx, qtx, intoListID, order); err != nil {
+			return err
+		}
+	}
+
+	if err := qtx.DeleteListByID(ctx, sqlc.DeleteListByIDParams{UserID: &userID, ID: fromListID}); err != nil {
+		log.Error("failed to delete merged list", "listID", fromListID, "error", err)
+		return fmt.Errorf("failed to delete merged list %d: %w", fromListID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit list merge transaction", "fromListID", fromListID, "intoListID", intoListID, "error", err)
+		return fmt.Errorf("failed to commit list merge: %w", err)
+	}
+
+	log.Debug("successfully merged lists", "fromListID", fromListID, "intoListID", intoListID, "movieCount", len(movieIDs))
+	return nil
+}
+
+// DeleteListMovies removes movieIDs from listID when userID can edit it, returning the ones
+// that were in the list
+func (d *SqliteDB) DeleteListMovies(ctx context.Context, userID, listID int64, movieIDs []int64) ([]int64, error) {
+	log.Debug("deleting movies from list", "listID", listID, "movieCount", len(movieIDs))
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to start database transaction for list delete", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to start db transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	qtx := d.queries.WithTx(tx)
+
+	if err := d.checkListEditable(ctx, qtx, userID, listID); err != nil {
+		return nil, err
+	}
+
+	removed, err := qtx.DeleteListMovies(ctx, sqlc.DeleteListMoviesParams{ListID: listID, MovieIds: movieIDs})
+	if err != nil {
+		log.Error("failed to delete movies from list", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to delete movies from list %d: %w", listID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit list delete transaction", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to commit list delete: %w", err)
+	}
+
+	log.Debug("successfully deleted movies from list", "listID", listID, "count", len(removed))
+	return removed, nil
+}
+
+// GetMemberLists returns the lists shared with userID by other users
+func (d *SqliteDB) GetMemberLists(ctx context.Context, userID int64) ([]models.ListEntry, error) {
+	log.Debug("retrieving lists shared with user", "userID", userID)
+
+	results, err := d.queries.GetMemberLists(ctx, userID)
+	if err != nil {
+		log.Error("failed to get lists shared with user", "userID", userID, "error", err)
+		return nil, fmt.Errorf("failed to get lists shared with user %d: %w", userID, err)
+	}
+
+	lists := make([]models.ListEntry, len(results))
+	for i, result := range results {
+		lists[i] = models.ListEntry{
+			ID:        result.ID,
+			Name:      result.Name,
+			Role:      models.ListRole(result.Role),
+			OwnerName: result.OwnerName,
+			Smart:     result.Smart != 0,
+			Synced:    result.Synced != 0,
+		}
+	}
+
+	log.Debug("retrieved lists shared with user", "userID", userID, "count", len(lists))
+	return lists, nil
+}
+
+// GetMovieLists returns the lists the user can see that contain movieID, the watchlist
+// first, followed by the smart lists whose rules match the movie
+func (d *SqliteDB) GetMovieLists(ctx context.Context, userID, movieID int64) ([]models.MovieList, error) {
+	log.Debug("retrieving lists containing movie", "userID", userID, "movieID", movieID)
+
+	results, err := d.queries.GetMovieLists(ctx, sqlc.GetMovieListsParams{UserID: userID, MovieID: movieID})
+	if err != nil {
+		log.Error("failed to get lists containing movie", "movieID", movieID, "error", err)
+		return nil, fmt.Errorf("failed to get lists containing movie %d: %w", movieID, err)
+	}
+
+	lists := make([]models.MovieList, len(results))
+	for i, result := range results {
+		dateAdded, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", result.DateAdded)
+		if err != nil {
+			log.Error("failed to parse date added", "listID", result.ID, "movieID", movieID, "error", err)
+			return nil, fmt.Errorf("failed to parse date added: %w", err)
+		}
+
+		entry := models.ListEntry{
+			ID:     result.ID,
+			Name:   result.Name,
+			Role:   models.ListRoleOwner,
+			Synced: result.Synced != 0,
+		}
+		if result.MemberRole != nil {
+			entry.Role = models.ListRole(*result.MemberRole)
+			entry.OwnerName = result.OwnerName
+		}
+		lists[i] = models.MovieList{
+			List:        entry,
+			IsWatchlist: result.IsWatchlist,
+			Note:        result.Note,
+			DateAdded:   dateAdded,
+		}
+	}
+
+	smartLists, err := d.smartListsMatchingMovie(ctx, userID, movieID)
+	if err != nil {
+		return nil, err
+	}
+	lists = append(lists, smartLists...)
+
+	log.Debug("retrieved lists containing movie", "movieID", movieID, "count", len(lists))
+	return lists, nil
+}
+
+// smartListsMatchingMovie returns the smart lists the user can see whose rules match
+// movieID. The movie was never added to them, so they have no note or date added.
+func (d *SqliteDB) smartListsMatchingMovie(ctx context.Context, userID, movieID int64) ([]models.MovieList, error) {
+	smartLists, err := d.queries.GetVisibleSmartLists(ctx, userID)
+	if err != nil {
+		log.Error("failed to get smart lists", "userID", userID, "error", err)
+		return nil, fmt.Errorf("failed to get smart lists of user %d: %w", userID, err)
+	}
+
+	if len(smartLists) == 0 {
+		return nil, nil
+	}
+
+	listIDs := make([]int64, len(smartLists))
+	for i, smartList := range smartLists {
+		listIDs[i] = smartList.ID
+	}
+	// the rules of every list are evaluated at once, against the one movie
+	results, err := d.queries.GetSmartListMovies(ctx, sqlc.GetSmartListMoviesParams{ListIds: listIDs, MovieID: &movieID})
+	if err != nil {
+		log.Error("failed to evaluate smart list rules", "userID", userID, "movieID", movieID, "error", err)
+		return nil, fmt.Errorf("failed to evaluate rules of smart lists for movie %d: %w", movieID, err)
+	}
+	matching := make(map[int64]bool, len(results))
+	for _, result := range results {
+		matching[result.ListID] = true
+	}
+
+	var lists []models.MovieList
+	for _, smartList := range smartLists {
+		if !matching[smartList.ID] {
+			continue
+		}
+
+		entry := models.ListEntry{
+			ID:    smartList.ID,
+			Name:  smartList.Name,
+			Role:  models.ListRoleOwner,
+			Smart: true,
+		}
+		if smartList.MemberRole != nil {
+			entry.Role = models.ListRole(*smartList.MemberRole)
+			entry.OwnerName = smartList.OwnerName
+		}
+		lists = append(lists, models.MovieList{List: entry})
+	}
+	return lists, nil
+}
+
+// GetListMembers returns the users listID is shared with, callers check that the user
+// asking can see the list
+func (d *SqliteDB) GetListMembers(ctx context.Context, listID int64) ([]models.ListMember, error) {
+	log.Debug("retrieving list members", "listID", listID)
+
+	results, err := d.queries.GetListMembers(ctx, listID)
+	if err != nil {
+		log.Error("failed to get list members", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to get members of list %d: %w", listID, err)
+	}
+
+	members := make([]models.ListMember, len(results))
+	for i, result := range results {
+		members[i] = models.ListMember{
+			UserID:  result.ID,
+			Name:    result.Name,
+			Email:   result.Email,
+			Role:    models.ListRole(result.Role),
+			AddedAt: result.AddedAt,
+		}
+	}
+	return members, nil
+}
+
+// UpsertListMember shares listID of ownerID with memberID as role, or changes the role
+// of an existing member. It returns 0 rows when ownerID does not own a custom list listID.
+func (d *SqliteDB) UpsertListMember(ctx context.Context, ownerID, listID, memberID int64, role models.ListRole) (int64, error) {
+	log.Debug("setting list member", "listID", listID, "memberID", memberID, "role", role)
+
+	rows, err := d.queries.UpsertListMember(ctx, sqlc.UpsertListMemberParams{
+		MemberID: memberID,
+		Role:     string(role),
+		AddedAt:  time.Now().UTC(),
+		ListID:   listID,
+		UserID:   &ownerID,
+	})
+	if err != nil {
+		log.Error("failed to set list member", "listID", listID, "memberID", memberID, "error", err)
+		return 0, fmt.Errorf("failed to set member %d of list %d: %w", memberID, listID, err)
+	}
+	return rows, nil
+}
+
+// DeleteListMember stops sharing listID with memberID. userID must own the list or be
+// memberID leaving it, otherwise nothing is deleted.
+func (d *SqliteDB) DeleteListMember(ctx context.Context, userID, listID, memberID int64) (int64, error) {
+	log.Debug("removing list member", "listID", listID, "memberID", memberID)
+
+	rows, err := d.queries.DeleteListMember(ctx, sqlc.DeleteListMemberParams{
+		ListID:   listID,
+		MemberID: memberID,
+		UserID:   userID,
+	})
+	if err != nil {
+		log.Error("failed to remove list member", "listID", listID, "memberID", memberID, "error", err)
+		return 0, fmt.Errorf("failed to remove member %d from list %d: %w", memberID, listID, err)
+	}
+	return rows, nil
+}
+
+func (d *SqliteDB) GetWatchlistID(ctx context.Context, userID int64) (int64, error) {
+	log.Debug("getting watchlist ID", "userID", userID)
+
+	id, err := d.queries.GetWatchlistID(ctx, &userID)
+	if err != nil {
+		log.Error("failed to get watchlist ID", "userID", userID, "error", err)
+		return 0, fmt.Errorf("failed to get watchlist ID for user %d: %w", userID, err)
+	}
+
+	log.Debug("retrieved watchlist ID", "userID", userID, "watchlistID", id)
+	return id, nil
+}
+
+func (d *SqliteDB) GetWatchedStatsPerMonthLastYear(ctx context.Context, userID int64) ([]models.PeriodStats, error) {
+	log.Debug("getting watched stats per month last year")
+
+	data, err := d.queries.GetWatchedStatsPerMonthLastYear(ctx, &userID)
+	if err != nil {
+		log.Error("failed to get monthly stats", "error", err)
 		return nil, fmt.Errorf("failed to get monthly stats: %w", err)
 	}
 
@@ -1483,13 +2404,18 @@ func scanStringValue(value any) (string, error) {
 	}
 }
 
-func (d *SqliteDB) CreateSession(ctx context.Context, id string, userID int64, expiresAt time.Time) error {
+func (d *SqliteDB) CreateSession(ctx context.Context, id string, userID int64, expiresAt, maxExpiresAt time.Time, client models.SessionClient) error {
 	log.Debug("creating session", "sessionID", id, "userID", userID)
 
+	now := time.Now().UTC()
 	err := d.queries.CreateSession(ctx, sqlc.CreateSessionParams{
-		ID:        id,
-		UserID:    userID,
-		ExpiresAt: expiresAt,
+		ID:           id,
+		UserID:       userID,
+		ExpiresAt:    expiresAt,
+		MaxExpiresAt: maxExpiresAt,
+		UserAgent:    client.UserAgent,
+		Ip:           client.IP,
+		LastSeenAt:   &now,
 	})
 	if err != nil {
 		log.Error("failed to create session", "sessionID", id, "error", err)
@@ -1510,39 +2436,121 @@ func (d *SqliteDB) GetSession(ctx context.Context, id string) (*models.Session,
 
 	log.Debug("retrieved session", "sessionID", id, "userID", session.UserID)
 	return &models.Session{
-		UserID:    session.UserID,
-		ExpiresAt: session.ExpiresAt,
+		ID:                 id,
+		UserID:             session.UserID,
+		ExpiresAt:          session.ExpiresAt,
+		MaxExpiresAt:       session.MaxExpiresAt,
+		LastSeenAt:         session.LastSeenAt,
+		ImpersonatedUserID: session.ImpersonatedUserID,
 	}, nil
 }
 
-func (d *SqliteDB) DeleteSession(ctx context.Context, id string) error {
-	log.Debug("deleting session", "sessionID", id)
+func (d *SqliteDB) GetSessionsByUser(ctx context.Context, userID int64) ([]models.Session, error) {
+	log.Debug("retrieving sessions", "userID", userID)
 
-	err := d.queries.DeleteSession(ctx, id)
+	rows, err := d.queries.GetSessionsByUser(ctx, userID)
 	if err != nil {
-		log.Error("failed to delete session", "sessionID", id, "error", err)
-		return fmt.Errorf("failed to delete session with ID %s: %w", id, err)
+		log.Error("failed to get sessions", "userID", userID, "error", err)
+		return nil, fmt.Errorf("failed to get sessions of user %d: %w", userID, err)
 	}
 
-	log.Debug("successfully deleted session", "sessionID", id)
-	return nil
+	sessions := make([]models.Session, len(rows))
+	for i, row := range rows {
+		sessions[i] = toModelsSession(row)
+	}
+
+	log.Debug("retrieved sessions", "userID", userID, "count", len(sessions))
+	return sessions, nil
 }
 
-func (d *SqliteDB) CleanupExpiredSessions(ctx context.Context) error {
-	log.Debug("cleaning up expired sessions")
+func (d *SqliteDB) SetSessionImpersonation(ctx context.Context, sessionID string, impersonatedUserID *int64) (int64, error) {
+	log.Debug("setting session impersonation", "sessionID", sessionID, "impersonatedUserID", impersonatedUserID)
 
-	err := d.queries.DeleteExpiredSessions(ctx)
+	rows, err := d.queries.SetSessionImpersonation(ctx, sqlc.SetSessionImpersonationParams{
+		ImpersonatedUserID: impersonatedUserID,
+		ID:                 sessionID,
+	})
 	if err != nil {
-		log.Error("failed to cleanup expired sessions", "error", err)
-		return fmt.Errorf("failed to cleanup expired sessions: %w", err)
+		log.Error("failed to set session impersonation", "sessionID", sessionID, "error", err)
+		return 0, fmt.Errorf("failed to set impersonation of session %s: %w", sessionID, err)
 	}
+	return rows, nil
+}
 
-	log.Debug("successfully cleaned up expired sessions")
+func (d *SqliteDB) TouchSession(ctx context.Context, id string, seenAt, expiresAt time.Time, client models.SessionClient) error {
+	log.Debug("updating session last seen time", "sessionID", id, "expiresAt", expiresAt)
+
+	err := d.queries.TouchSession(ctx, sqlc.TouchSessionParams{
+		LastSeenAt: &seenAt,
+		ExpiresAt:  expiresAt,
+		UserAgent:  client.UserAgent,
+		Ip:         client.IP,
+		ID:         id,
+	})
+	if err != nil {
+		log.Error("failed to touch session", "sessionID", id, "error", err)
+		return fmt.Errorf("failed to touch session with ID %s: %w", id, err)
+	}
 	return nil
 }
 
-func (d *SqliteDB) CreateUser(ctx context.Context, email, name, passwordHash string) (*models.User, error) {
-	log.Debug("creating new user", "email", email)
+func (d *SqliteDB) DeleteUserSession(ctx context.Context, userID int64, id string) (int64, error) {
+	log.Debug("deleting user session", "sessionID", id, "userID", userID)
+
+	rows, err := d.queries.DeleteUserSession(ctx, sqlc.DeleteUserSessionParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Error("failed to delete user session", "sessionID", id, "userID", userID, "error", err)
+		return 0, fmt.Errorf("failed to delete session with ID %s: %w", id, err)
+	}
+
+	log.Debug("deleted user session", "sessionID", id, "rows", rows)
+	return rows, nil
+}
+
+func (d *SqliteDB) DeleteSessionsByUser(ctx context.Context, userID int64) (int64, error) {
+	log.Debug("deleting all sessions", "userID", userID)
+
+	rows, err := d.queries.DeleteSessionsByUser(ctx, userID)
+	if err != nil {
+		log.Error("failed to delete sessions", "userID", userID, "error", err)
+		return 0, fmt.Errorf("failed to delete sessions of user %d: %w", userID, err)
+	}
+
+	log.Debug("deleted all sessions", "userID", userID, "count", rows)
+	return rows, nil
+}
+
+func (d *SqliteDB) DeleteSession(ctx context.Context, id string) error {
+	log.Debug("deleting session", "sessionID", id)
+
+	err := d.queries.DeleteSession(ctx, id)
+	if err != nil {
+		log.Error("failed to delete session", "sessionID", id, "error", err)
+		return fmt.Errorf("failed to delete session with ID %s: %w", id, err)
+	}
+
+	log.Debug("successfully deleted session", "sessionID", id)
+	return nil
+}
+
+func (d *SqliteDB) CleanupExpiredSessions(ctx context.Context) error {
+	log.Debug("cleaning up expired sessions")
+
+	err := d.queries.DeleteExpiredSessions(ctx)
+	if err != nil {
+		log.Error("failed to cleanup expired sessions", "error", err)
+		return fmt.Errorf("failed to cleanup expired sessions: %w", err)
+	}
+
+	log.Debug("successfully cleaned up expired sessions")
+	return nil
+}
+
+func (d *SqliteDB) CreateUser(ctx context.Context, email, name, passwordHash string) (*models.User, error) {
+	log.Debug("creating new user", "email", email)
 
 	user, err := d.queries.CreateUser(ctx, sqlc.CreateUserParams{
 		Email:        email,
@@ -1563,6 +2571,8 @@ func (d *SqliteDB) CreateUser(ctx context.Context, email, name, passwordHash str
 		Admin:                 user.Admin,
 		CreatedAt:             user.CreatedAt,
 		PasswordResetRequired: user.PasswordResetRequired,
+		DeletionScheduledAt:   user.DeletionScheduledAt,
+		DisabledAt:            user.DisabledAt,
 	}, nil
 }
 
@@ -1584,6 +2594,8 @@ func (d *SqliteDB) GetUserByEmail(ctx context.Context, email string) (*models.Us
 		Admin:                 user.Admin,
 		CreatedAt:             user.CreatedAt,
 		PasswordResetRequired: user.PasswordResetRequired,
+		DeletionScheduledAt:   user.DeletionScheduledAt,
+		DisabledAt:            user.DisabledAt,
 	}, nil
 }
 
@@ -1605,6 +2617,8 @@ func (d *SqliteDB) GetUserByID(ctx context.Context, id int64) (*models.User, err
 		Admin:                 user.Admin,
 		CreatedAt:             user.CreatedAt,
 		PasswordResetRequired: user.PasswordResetRequired,
+		DeletionScheduledAt:   user.DeletionScheduledAt,
+		DisabledAt:            user.DisabledAt,
 	}, nil
 }
 
@@ -1660,6 +2674,19 @@ func (d *SqliteDB) SetAdmin(ctx context.Context, userID int64) error {
 	return nil
 }
 
+func (d *SqliteDB) UnsetAdmin(ctx context.Context, userID int64) error {
+	log.Debug("removing admin from user", "userID", userID)
+
+	err := d.queries.UnsetAdmin(ctx, userID)
+	if err != nil {
+		log.Error("failed to remove admin from user", "userID", userID, "error", err)
+		return fmt.Errorf("failed to remove admin from user: %w", err)
+	}
+
+	log.Debug("removed admin from user", "userID", userID)
+	return nil
+}
+
 func (d *SqliteDB) GetAllUsersWithStats(ctx context.Context) ([]models.UserWithStats, error) {
 	log.Debug("retrieving all users with stats")
 
@@ -1671,6 +2698,10 @@ func (d *SqliteDB) GetAllUsersWithStats(ctx context.Context) ([]models.UserWithS
 
 	users := make([]models.UserWithStats, len(rows))
 	for i, r := range rows {
+		var failedLogins int64
+		if r.FailedCount != nil {
+			failedLogins = *r.FailedCount
+		}
 		users[i] = models.UserWithStats{
 			User: models.User{
 				ID:           r.ID,
@@ -1679,9 +2710,14 @@ func (d *SqliteDB) GetAllUsersWithStats(ctx context.Context) ([]models.UserWithS
 				Admin:        r.Admin,
 				CreatedAt:    r.CreatedAt,
 				PasswordHash: "", // Don't expose this in stats
+				DisabledAt:   r.DisabledAt,
 			},
 			WatchedCount: r.WatchedCount,
 			ListCount:    r.ListCount,
+			TOTPEnabled:  r.TotpEnabled != 0,
+			SessionCount: r.SessionCount,
+			FailedLogins: failedLogins,
+			LockedUntil:  r.LockedUntil,
 		}
 	}
 
@@ -1734,6 +2770,81 @@ func (d *SqliteDB) UpdatePasswordResetRequired(ctx context.Context, userID int64
 	return nil
 }
 
+func (d *SqliteDB) UpdateUserProfile(ctx context.Context, userID int64, name, email string) error {
+	log.Debug("updating user profile", "userID", userID)
+
+	err := d.queries.UpdateUserProfile(ctx, sqlc.UpdateUserProfileParams{
+		Name:  name,
+		Email: email,
+		ID:    userID,
+	})
+	if err != nil {
+		log.Error("failed to update user profile", "userID", userID, "error", err)
+		return fmt.Errorf("failed to update profile for user %d: %w", userID, err)
+	}
+
+	log.Debug("successfully updated user profile", "userID", userID)
+	return nil
+}
+
+func (d *SqliteDB) CountAdmins(ctx context.Context) (int64, error) {
+	log.Debug("counting admins")
+
+	count, err := d.queries.CountAdmins(ctx)
+	if err != nil {
+		log.Error("failed to count admins", "error", err)
+		return 0, fmt.Errorf("failed to count admins: %w", err)
+	}
+
+	log.Debug("retrieved admin count", "count", count)
+	return count, nil
+}
+
+func (d *SqliteDB) SetUserDisabledAt(ctx context.Context, userID int64, at *time.Time) error {
+	log.Debug("setting account disabled time", "userID", userID, "at", at)
+
+	err := d.queries.SetUserDisabledAt(ctx, sqlc.SetUserDisabledAtParams{
+		DisabledAt: at,
+		ID:         userID,
+	})
+	if err != nil {
+		log.Error("failed to set account disabled time", "userID", userID, "error", err)
+		return fmt.Errorf("failed to set disabled time for user %d: %w", userID, err)
+	}
+
+	log.Debug("successfully set account disabled time", "userID", userID)
+	return nil
+}
+
+func (d *SqliteDB) SetUserDeletionScheduledAt(ctx context.Context, userID int64, at *time.Time) error {
+	log.Debug("setting account deletion time", "userID", userID, "at", at)
+
+	err := d.queries.SetUserDeletionScheduledAt(ctx, sqlc.SetUserDeletionScheduledAtParams{
+		DeletionScheduledAt: at,
+		ID:                  userID,
+	})
+	if err != nil {
+		log.Error("failed to set account deletion time", "userID", userID, "error", err)
+		return fmt.Errorf("failed to set deletion time for user %d: %w", userID, err)
+	}
+
+	log.Debug("successfully set account deletion time", "userID", userID)
+	return nil
+}
+
+func (d *SqliteDB) GetUsersDueForDeletion(ctx context.Context, now time.Time) ([]int64, error) {
+	log.Debug("retrieving accounts due for deletion")
+
+	ids, err := d.queries.GetUsersDueForDeletion(ctx, &now)
+	if err != nil {
+		log.Error("failed to retrieve accounts due for deletion", "error", err)
+		return nil, fmt.Errorf("failed to get users due for deletion: %w", err)
+	}
+
+	log.Debug("retrieved accounts due for deletion", "count", len(ids))
+	return ids, nil
+}
+
 func (d *SqliteDB) ExportLists(ctx context.Context, userID int64) ([]models.List, error) {
 	log.Debug("exporting all lists with movies", "userID", userID)
 
@@ -1764,6 +2875,10 @@ func (d *SqliteDB) ExportLists(ctx context.Context, userID int64) ([]models.List
 				CreationDate: creationDate,
 				Description:  result.List.Description,
 				IsWatchlist:  result.List.IsWatchlist,
+				Ranked:       result.List.Ranked,
+				ShareToken:   result.List.ShareToken,
+				ShareNotes:   result.List.ShareNotes,
+				Role:         models.ListRoleOwner,
 				Movies:       []models.MovieItem{},
 			})
 			idx = len(lists) - 1
@@ -1792,3 +2907,907 @@ func (d *SqliteDB) ExportLists(ctx context.Context, userID int64) ([]models.List
 	log.Info("successfully exported lists", "userID", userID, "listCount", len(lists))
 	return lists, nil
 }
+
+func (d *SqliteDB) InsertWebhook(ctx context.Context, userID int64, url, secret string, events []models.WebhookEvent) (*models.Webhook, error) {
+	log.Debug("inserting webhook", "userID", userID, "url", url)
+
+	webhook, err := d.queries.InsertWebhook(ctx, sqlc.InsertWebhookParams{
+		UserID: userID,
+		Url:    url,
+		Secret: secret,
+		Events: joinWebhookEvents(events),
+	})
+	if err != nil {
+		log.Error("failed to insert webhook", "userID", userID, "url", url, "error", err)
+		return nil, fmt.Errorf("failed to insert webhook: %w", err)
+	}
+
+	log.Debug("successfully inserted webhook", "webhookID", webhook.ID)
+	result := toModelsWebhook(webhook)
+	return &result, nil
+}
+
+func (d *SqliteDB) GetWebhooksByUser(ctx context.Context, userID int64) ([]models.Webhook, error) {
+	log.Debug("retrieving webhooks", "userID", userID)
+
+	webhooks, err := d.queries.GetWebhooksByUser(ctx, userID)
+	if err != nil {
+		log.Error("failed to retrieve webhooks", "userID", userID, "error", err)
+		return nil, fmt.Errorf("failed to retrieve webhooks for user %d: %w", userID, err)
+	}
+
+	result := make([]models.Webhook, len(webhooks))
+	for i, webhook := range webhooks {
+		result[i] = toModelsWebhook(webhook)
+	}
+
+	log.Debug("successfully retrieved webhooks", "userID", userID, "count", len(result))
+	return result, nil
+}
+
+func (d *SqliteDB) GetEnabledWebhooksByUser(ctx context.Context, userID int64) ([]models.Webhook, error) {
+	log.Debug("retrieving enabled webhooks", "userID", userID)
+
+	webhooks, err := d.queries.GetEnabledWebhooksByUser(ctx, userID)
+	if err != nil {
+		log.Error("failed to retrieve enabled webhooks", "userID", userID, "error", err)
+		return nil, fmt.Errorf("failed to retrieve enabled webhooks for user %d: %w", userID, err)
+	}
+
+	result := make([]models.Webhook, len(webhooks))
+	for i, webhook := range webhooks {
+		result[i] = toModelsWebhook(webhook)
+	}
+
+	log.Debug("successfully retrieved enabled webhooks", "userID", userID, "count", len(result))
+	return result, nil
+}
+
+func (d *SqliteDB) SetWebhookEnabled(ctx context.Context, userID, webhookID int64, enabled bool) (int64, error) {
+	log.Debug("setting webhook enabled", "userID", userID, "webhookID", webhookID, "enabled", enabled)
+
+	rows, err := d.queries.SetWebhookEnabled(ctx, sqlc.SetWebhookEnabledParams{
+		Enabled: enabled,
+		ID:      webhookID,
+		UserID:  userID,
+	})
+	if err != nil {
+		log.Error("failed to set webhook enabled", "webhookID", webhookID, "error", err)
+		return 0, fmt.Errorf("failed to update webhook %d: %w", webhookID, err)
+	}
+
+	log.Debug("successfully set webhook enabled", "webhookID", webhookID, "rows", rows)
+	return rows, nil
+}
+
+func (d *SqliteDB) DeleteWebhook(ctx context.Context, userID, webhookID int64) (int64, error) {
+	log.Debug("deleting webhook", "userID", userID, "webhookID", webhookID)
+
+	rows, err := d.queries.DeleteWebhook(ctx, sqlc.DeleteWebhookParams{
+		ID:     webhookID,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Error("failed to delete webhook", "webhookID", webhookID, "error", err)
+		return 0, fmt.Errorf("failed to delete webhook %d: %w", webhookID, err)
+	}
+
+	log.Debug("successfully deleted webhook", "webhookID", webhookID, "rows", rows)
+	return rows, nil
+}
+
+func (d *SqliteDB) InsertWebhookDelivery(ctx context.Context, delivery InsertWebhookDelivery) error {
+	log.Debug("queueing webhook delivery", "webhookID", delivery.WebhookID, "event", delivery.Event)
+
+	err := d.queries.InsertWebhookDelivery(ctx, sqlc.InsertWebhookDeliveryParams{
+		WebhookID:     delivery.WebhookID,
+		Event:         string(delivery.Event),
+		Payload:       delivery.Payload,
+		NextAttemptAt: delivery.NextAttemptAt,
+		CreatedAt:     delivery.CreatedAt,
+	})
+	if err != nil {
+		log.Error("failed to queue webhook delivery", "webhookID", delivery.WebhookID, "error", err)
+		return fmt.Errorf("failed to queue delivery for webhook %d: %w", delivery.WebhookID, err)
+	}
+
+	return nil
+}
+
+func (d *SqliteDB) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	log.Debug("retrieving due webhook deliveries", "limit", limit)
+
+	rows, err := d.queries.GetDueWebhookDeliveries(ctx, sqlc.GetDueWebhookDeliveriesParams{
+		NextAttemptAt: now,
+		Limit:         int64(limit),
+	})
+	if err != nil {
+		log.Error("failed to retrieve due webhook deliveries", "error", err)
+		return nil, fmt.Errorf("failed to retrieve due webhook deliveries: %w", err)
+	}
+
+	result := make([]models.WebhookDelivery, len(rows))
+	for i, row := range rows {
+		result[i] = toModelsWebhookDelivery(row.WebhookDelivery)
+		result[i].URL = row.Url
+		result[i].Secret = row.Secret
+	}
+
+	log.Debug("successfully retrieved due webhook deliveries", "count", len(result))
+	return result, nil
+}
+
+func (d *SqliteDB) UpdateWebhookDeliveryAttempt(ctx context.Context, attempt UpdateWebhookDeliveryAttempt) error {
+	log.Debug("recording webhook delivery attempt", "deliveryID", attempt.ID, "status", attempt.Status)
+
+	err := d.queries.UpdateWebhookDeliveryAttempt(ctx, sqlc.UpdateWebhookDeliveryAttemptParams{
+		Status:         attempt.Status,
+		Attempts:       attempt.Attempts,
+		NextAttemptAt:  attempt.NextAttemptAt,
+		LastAttemptAt:  &attempt.LastAttemptAt,
+		ResponseStatus: attempt.ResponseStatus,
+		LastError:      attempt.LastError,
+		ID:             attempt.ID,
+	})
+	if err != nil {
+		log.Error("failed to record webhook delivery attempt", "deliveryID", attempt.ID, "error", err)
+		return fmt.Errorf("failed to update webhook delivery %d: %w", attempt.ID, err)
+	}
+
+	return nil
+}
+
+func (d *SqliteDB) GetWebhookDeliveriesByUser(ctx context.Context, userID int64, limit int) ([]models.WebhookDelivery, error) {
+	log.Debug("retrieving webhook deliveries", "userID", userID, "limit", limit)
+
+	rows, err := d.queries.GetWebhookDeliveriesByUser(ctx, sqlc.GetWebhookDeliveriesByUserParams{
+		UserID: userID,
+		Limit:  int64(limit),
+	})
+	if err != nil {
+		log.Error("failed to retrieve webhook deliveries", "userID", userID, "error", err)
+		return nil, fmt.Errorf("failed to retrieve webhook deliveries for user %d: %w", userID, err)
+	}
+
+	result := make([]models.WebhookDelivery, len(rows))
+	for i, row := range rows {
+		result[i] = models.WebhookDelivery{
+			ID:             row.ID,
+			WebhookID:      row.WebhookID,
+			URL:            row.Url,
+			Event:          models.WebhookEvent(row.Event),
+			Status:         row.Status,
+			Attempts:       row.Attempts,
+			NextAttemptAt:  row.NextAttemptAt,
+			LastAttemptAt:  row.LastAttemptAt,
+			ResponseStatus: row.ResponseStatus,
+			LastError:      row.LastError,
+			CreatedAt:      row.CreatedAt,
+		}
+	}
+
+	log.Debug("successfully retrieved webhook deliveries", "userID", userID, "count", len(result))
+	return result, nil
+}
+
+func (d *SqliteDB) CreateInvite(ctx context.Context, tokenHash string, createdBy int64, createdAt, expiresAt time.Time) (*models.Invite, error) {
+	log.Debug("creating invite", "createdBy", createdBy, "expiresAt", expiresAt)
+
+	invite, err := d.queries.CreateInvite(ctx, sqlc.CreateInviteParams{
+		TokenHash: tokenHash,
+		CreatedBy: &createdBy,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.Error("failed to create invite", "createdBy", createdBy, "error", err)
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+
+	log.Debug("successfully created invite", "inviteID", invite.ID)
+	return &models.Invite{
+		ID:        invite.ID,
+		CreatedAt: invite.CreatedAt,
+		ExpiresAt: invite.ExpiresAt,
+		UsedAt:    invite.UsedAt,
+	}, nil
+}
+
+func (d *SqliteDB) GetInvites(ctx context.Context) ([]models.Invite, error) {
+	log.Debug("retrieving invites")
+
+	rows, err := d.queries.GetInvites(ctx)
+	if err != nil {
+		log.Error("failed to retrieve invites", "error", err)
+		return nil, fmt.Errorf("failed to retrieve invites: %w", err)
+	}
+
+	invites := make([]models.Invite, len(rows))
+	for i, row := range rows {
+		invites[i] = models.Invite{
+			ID:            row.ID,
+			CreatedAt:     row.CreatedAt,
+			ExpiresAt:     row.ExpiresAt,
+			UsedAt:        row.UsedAt,
+			CreatedByName: row.CreatedByName,
+			UsedByEmail:   row.UsedByEmail,
+		}
+	}
+
+	log.Debug("successfully retrieved invites", "count", len(invites))
+	return invites, nil
+}
+
+func (d *SqliteDB) GetInviteByTokenHash(ctx context.Context, tokenHash string) (*models.Invite, error) {
+	log.Debug("retrieving invite by token")
+
+	invite, err := d.queries.GetInviteByTokenHash(ctx, tokenHash)
+	if err != nil {
+		log.Debug("failed to retrieve invite by token", "error", err)
+		return nil, fmt.Errorf("failed to retrieve invite: %w", err)
+	}
+
+	return &models.Invite{
+		ID:        invite.ID,
+		CreatedAt: invite.CreatedAt,
+		ExpiresAt: invite.ExpiresAt,
+		UsedAt:    invite.UsedAt,
+	}, nil
+}
+
+// ClaimInvite atomically marks an usable invite as used and returns its ID.
+// Returns sql.ErrNoRows if the token hash does not match an usable invite.
+func (d *SqliteDB) ClaimInvite(ctx context.Context, tokenHash string, now time.Time) (int64, error) {
+	log.Debug("claiming invite")
+
+	inviteID, err := d.queries.ClaimInvite(ctx, sqlc.ClaimInviteParams{
+		Now:       &now,
+		TokenHash: tokenHash,
+	})
+	if err != nil {
+		log.Debug("failed to claim invite", "error", err)
+		return 0, fmt.Errorf("failed to claim invite: %w", err)
+	}
+
+	log.Debug("successfully claimed invite", "inviteID", inviteID)
+	return inviteID, nil
+}
+
+func (d *SqliteDB) ReleaseInvite(ctx context.Context, inviteID int64) error {
+	log.Debug("releasing invite", "inviteID", inviteID)
+
+	err := d.queries.ReleaseInvite(ctx, inviteID)
+	if err != nil {
+		log.Error("failed to release invite", "inviteID", inviteID, "error", err)
+		return fmt.Errorf("failed to release invite %d: %w", inviteID, err)
+	}
+
+	return nil
+}
+
+func (d *SqliteDB) SetInviteUsedBy(ctx context.Context, inviteID, userID int64) error {
+	log.Debug("setting invite redeemer", "inviteID", inviteID, "userID", userID)
+
+	err := d.queries.SetInviteUsedBy(ctx, sqlc.SetInviteUsedByParams{
+		UsedBy: &userID,
+		ID:     inviteID,
+	})
+	if err != nil {
+		log.Error("failed to set invite redeemer", "inviteID", inviteID, "userID", userID, "error", err)
+		return fmt.Errorf("failed to set redeemer of invite %d: %w", inviteID, err)
+	}
+
+	return nil
+}
+
+func (d *SqliteDB) DeleteInvite(ctx context.Context, inviteID int64) (int64, error) {
+	log.Debug("deleting invite", "inviteID", inviteID)
+
+	rows, err := d.queries.DeleteInvite(ctx, inviteID)
+	if err != nil {
+		log.Error("failed to delete invite", "inviteID", inviteID, "error", err)
+		return 0, fmt.Errorf("failed to delete invite %d: %w", inviteID, err)
+	}
+
+	log.Debug("successfully deleted invite", "inviteID", inviteID, "rows", rows)
+	return rows, nil
+}
+
+func (d *SqliteDB) GetUserByIdentity(ctx context.Context, issuer, subject string) (*models.User, error) {
+	log.Debug("retrieving user by external identity", "issuer", issuer, "subject", subject)
+
+	user, err := d.queries.GetUserByIdentity(ctx, sqlc.GetUserByIdentityParams{
+		Issuer:  issuer,
+		Subject: subject,
+	})
+	if err != nil {
+		log.Debug("failed to retrieve user by external identity", "issuer", issuer, "subject", subject, "error", err)
+		return nil, fmt.Errorf("failed to get user for identity %s: %w", subject, err)
+	}
+
+	return &models.User{
+		ID:                    user.ID,
+		Email:                 user.Email,
+		Name:                  user.Name,
+		PasswordHash:          user.PasswordHash,
+		Admin:                 user.Admin,
+		CreatedAt:             user.CreatedAt,
+		PasswordResetRequired: user.PasswordResetRequired,
+		DeletionScheduledAt:   user.DeletionScheduledAt,
+		DisabledAt:            user.DisabledAt,
+	}, nil
+}
+
+func (d *SqliteDB) CreateUserIdentity(ctx context.Context, userID int64, issuer, subject string, createdAt time.Time) error {
+	log.Debug("linking external identity", "userID", userID, "issuer", issuer, "subject", subject)
+
+	err := d.queries.CreateUserIdentity(ctx, sqlc.CreateUserIdentityParams{
+		UserID:    userID,
+		Issuer:    issuer,
+		Subject:   subject,
+		CreatedAt: createdAt,
+	})
+	if err != nil {
+		log.Error("failed to link external identity", "userID", userID, "issuer", issuer, "error", err)
+		return fmt.Errorf("failed to link identity to user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+func (d *SqliteDB) UpsertUserTOTP(ctx context.Context, userID int64, secret string, createdAt time.Time) error {
+	log.Debug("storing TOTP secret", "userID", userID)
+
+	err := d.queries.UpsertUserTOTP(ctx, sqlc.UpsertUserTOTPParams{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: createdAt,
+	})
+	if err != nil {
+		log.Error("failed to store TOTP secret", "userID", userID, "error", err)
+		return fmt.Errorf("failed to store TOTP secret for user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+func (d *SqliteDB) GetUserTOTP(ctx context.Context, userID int64) (*models.UserTOTP, error) {
+	log.Debug("retrieving TOTP secret", "userID", userID)
+
+	totp, err := d.queries.GetUserTOTP(ctx, userID)
+	if err != nil {
+		log.Debug("failed to retrieve TOTP secret", "userID", userID, "error", err)
+		return nil, fmt.Errorf("failed to get TOTP secret for user %d: %w", userID, err)
+	}
+
+	return &models.UserTOTP{
+		UserID:    totp.UserID,
+		Secret:    totp.Secret,
+		Enabled:   totp.Enabled,
+		LastStep:  totp.LastStep,
+		CreatedAt: totp.CreatedAt,
+	}, nil
+}
+
+func (d *SqliteDB) EnableUserTOTP(ctx context.Context, userID int64) error {
+	log.Debug("enabling TOTP", "userID", userID)
+
+	err := d.queries.EnableUserTOTP(ctx, userID)
+	if err != nil {
+		log.Error("failed to enable TOTP", "userID", userID, "error", err)
+		return fmt.Errorf("failed to enable TOTP for user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+func (d *SqliteDB) DeleteUserTOTP(ctx context.Context, userID int64) (int64, error) {
+	log.Debug("deleting TOTP secret", "userID", userID)
+
+	rows, err := d.queries.DeleteUserTOTP(ctx, userID)
+	if err != nil {
+		log.Error("failed to delete TOTP secret", "userID", userID, "error", err)
+		return 0, fmt.Errorf("failed to delete TOTP secret for user %d: %w", userID, err)
+	}
+
+	return rows, nil
+}
+
+// UseTOTPStep atomically records step as the last accepted time step.
+// Returns false if that step, or a later one, was already used.
+func (d *SqliteDB) UseTOTPStep(ctx context.Context, userID, step int64) (bool, error) {
+	log.Debug("recording TOTP step", "userID", userID, "step", step)
+
+	rows, err := d.queries.UseTOTPStep(ctx, sqlc.UseTOTPStepParams{
+		Step:   &step,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Error("failed to record TOTP step", "userID", userID, "error", err)
+		return false, fmt.Errorf("failed to record TOTP step for user %d: %w", userID, err)
+	}
+
+	return rows == 1, nil
+}
+
+// ReplaceRecoveryCodes deletes the recovery codes of an user and stores the given hashes instead
+func (d *SqliteDB) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	log.Debug("replacing recovery codes", "userID", userID, "count", len(codeHashes))
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error("failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	qtx := d.queries.WithTx(tx)
+	if err := qtx.DeleteRecoveryCodes(ctx, userID); err != nil {
+		log.Error("failed to delete recovery codes", "userID", userID, "error", err)
+		return fmt.Errorf("failed to delete recovery codes for user %d: %w", userID, err)
+	}
+	for _, hash := range codeHashes {
+		err := qtx.CreateRecoveryCode(ctx, sqlc.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: hash,
+		})
+		if err != nil {
+			log.Error("failed to create recovery code", "userID", userID, "error", err)
+			return fmt.Errorf("failed to create recovery code for user %d: %w", userID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit recovery codes", "userID", userID, "error", err)
+		return fmt.Errorf("failed to commit recovery codes for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (d *SqliteDB) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	log.Debug("deleting recovery codes", "userID", userID)
+
+	err := d.queries.DeleteRecoveryCodes(ctx, userID)
+	if err != nil {
+		log.Error("failed to delete recovery codes", "userID", userID, "error", err)
+		return fmt.Errorf("failed to delete recovery codes for user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode atomically marks an unused recovery code as used.
+// Returns false if no unused code matches the hash.
+func (d *SqliteDB) UseRecoveryCode(ctx context.Context, userID int64, codeHash string, usedAt time.Time) (bool, error) {
+	log.Debug("using recovery code", "userID", userID)
+
+	rows, err := d.queries.UseRecoveryCode(ctx, sqlc.UseRecoveryCodeParams{
+		UsedAt:   &usedAt,
+		UserID:   userID,
+		CodeHash: codeHash,
+	})
+	if err != nil {
+		log.Error("failed to use recovery code", "userID", userID, "error", err)
+		return false, fmt.Errorf("failed to use recovery code for user %d: %w", userID, err)
+	}
+
+	return rows == 1, nil
+}
+
+func (d *SqliteDB) CountUnusedRecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	log.Debug("counting unused recovery codes", "userID", userID)
+
+	count, err := d.queries.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		log.Error("failed to count recovery codes", "userID", userID, "error", err)
+		return 0, fmt.Errorf("failed to count recovery codes for user %d: %w", userID, err)
+	}
+
+	return count, nil
+}
+
+func (d *SqliteDB) CreateMFAChallenge(ctx context.Context, tokenHash string, userID int64, expiresAt time.Time) error {
+	log.Debug("creating login challenge", "userID", userID)
+
+	err := d.queries.CreateMFAChallenge(ctx, sqlc.CreateMFAChallengeParams{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.Error("failed to create login challenge", "userID", userID, "error", err)
+		return fmt.Errorf("failed to create login challenge for user %d: %w", userID, err)
+	}
+
+	return nil
+}
+
+// GetMFAChallenge returns the challenge if it has not expired at now
+func (d *SqliteDB) GetMFAChallenge(ctx context.Context, tokenHash string, now time.Time) (*models.MFAChallenge, error) {
+	log.Debug("retrieving login challenge")
+
+	challenge, err := d.queries.GetMFAChallenge(ctx, sqlc.GetMFAChallengeParams{
+		TokenHash: tokenHash,
+		ExpiresAt: now,
+	})
+	if err != nil {
+		log.Debug("failed to retrieve login challenge", "error", err)
+		return nil, fmt.Errorf("failed to get login challenge: %w", err)
+	}
+
+	return &models.MFAChallenge{
+		TokenHash: challenge.TokenHash,
+		UserID:    challenge.UserID,
+		ExpiresAt: challenge.ExpiresAt,
+		Attempts:  challenge.Attempts,
+	}, nil
+}
+
+// IncrementMFAChallengeAttempts counts a failed code and returns the new number of attempts
+func (d *SqliteDB) IncrementMFAChallengeAttempts(ctx context.Context, tokenHash string) (int64, error) {
+	log.Debug("counting failed login challenge attempt")
+
+	attempts, err := d.queries.IncrementMFAChallengeAttempts(ctx, tokenHash)
+	if err != nil {
+		log.Error("failed to count login challenge attempt", "error", err)
+		return 0, fmt.Errorf("failed to count login challenge attempt: %w", err)
+	}
+
+	return attempts, nil
+}
+
+func (d *SqliteDB) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	log.Debug("deleting login challenge")
+
+	err := d.queries.DeleteMFAChallenge(ctx, tokenHash)
+	if err != nil {
+		log.Error("failed to delete login challenge", "error", err)
+		return fmt.Errorf("failed to delete login challenge: %w", err)
+	}
+
+	return nil
+}
+
+func (d *SqliteDB) CleanupExpiredMFAChallenges(ctx context.Context, now time.Time) error {
+	log.Debug("cleaning up expired login challenges")
+
+	err := d.queries.DeleteExpiredMFAChallenges(ctx, now)
+	if err != nil {
+		log.Error("failed to cleanup expired login challenges", "error", err)
+		return fmt.Errorf("failed to cleanup expired login challenges: %w", err)
+	}
+
+	return nil
+}
+
+func (d *SqliteDB) CreatePasskey(ctx context.Context, passkey models.Passkey) (*models.Passkey, error) {
+	log.Debug("creating passkey", "userID", passkey.UserID, "name", passkey.Name)
+
+	created, err := d.queries.CreatePasskey(ctx, sqlc.CreatePasskeyParams{
+		UserID:         passkey.UserID,
+		CredentialID:   passkey.CredentialID,
+		PublicKey:      passkey.PublicKey,
+		SignCount:      passkey.SignCount,
+		BackupEligible: passkey.BackupEligible,
+		Name:           passkey.Name,
+		CreatedAt:      passkey.CreatedAt,
+	})
+	if err != nil {
+		log.Error("failed to create passkey", "userID", passkey.UserID, "error", err)
+		return nil, fmt.Errorf("failed to create passkey for user %d: %w", passkey.UserID, err)
+	}
+
+	result := toModelsPasskey(created)
+	log.Debug("successfully created passkey", "passkeyID", result.ID)
+	return &result, nil
+}
+
+func (d *SqliteDB) GetPasskeysByUser(ctx context.Context, userID int64) ([]models.Passkey, error) {
+	log.Debug("retrieving passkeys", "userID", userID)
+
+	rows, err := d.queries.GetPasskeysByUser(ctx, userID)
+	if err != nil {
+		log.Error("failed to retrieve passkeys", "userID", userID, "error", err)
+		return nil, fmt.Errorf("failed to get passkeys for user %d: %w", userID, err)
+	}
+
+	passkeys := make([]models.Passkey, len(rows))
+	for i, row := range rows {
+		passkeys[i] = toModelsPasskey(row)
+	}
+
+	log.Debug("successfully retrieved passkeys", "userID", userID, "count", len(passkeys))
+	return passkeys, nil
+}
+
+func (d *SqliteDB) GetPasskeyByCredentialID(ctx context.Context, credentialID string) (*models.Passkey, error) {
+	log.Debug("retrieving passkey by credential ID")
+
+	passkey, err := d.queries.GetPasskeyByCredentialID(ctx, credentialID)
+	if err != nil {
+		log.Debug("failed to retrieve passkey by credential ID", "error", err)
+		return nil, fmt.Errorf("failed to get passkey: %w", err)
+	}
+
+	result := toModelsPasskey(passkey)
+	return &result, nil
+}
+
+func (d *SqliteDB) UpdatePasskeyUsage(ctx context.Context, passkeyID, signCount int64, usedAt time.Time) error {
+	log.Debug("updating passkey usage", "passkeyID", passkeyID, "signCount", signCount)
+
+	err := d.queries.UpdatePasskeyUsage(ctx, sqlc.UpdatePasskeyUsageParams{
+		SignCount:  signCount,
+		LastUsedAt: &usedAt,
+		ID:         passkeyID,
+	})
+	if err != nil {
+		log.Error("failed to update passkey usage", "passkeyID", passkeyID, "error", err)
+		return fmt.Errorf("failed to update usage of passkey %d: %w", passkeyID, err)
+	}
+
+	return nil
+}
+
+func (d *SqliteDB) RenamePasskey(ctx context.Context, userID, passkeyID int64, name string) (int64, error) {
+	log.Debug("renaming passkey", "userID", userID, "passkeyID", passkeyID)
+
+	rows, err := d.queries.RenamePasskey(ctx, sqlc.RenamePasskeyParams{
+		Name:   name,
+		ID:     passkeyID,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Error("failed to rename passkey", "passkeyID", passkeyID, "error", err)
+		return 0, fmt.Errorf("failed to rename passkey %d: %w", passkeyID, err)
+	}
+
+	return rows, nil
+}
+
+func (d *SqliteDB) DeletePasskey(ctx context.Context, userID, passkeyID int64) (int64, error) {
+	log.Debug("deleting passkey", "userID", userID, "passkeyID", passkeyID)
+
+	rows, err := d.queries.DeletePasskey(ctx, sqlc.DeletePasskeyParams{
+		ID:     passkeyID,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Error("failed to delete passkey", "passkeyID", passkeyID, "error", err)
+		return 0, fmt.Errorf("failed to delete passkey %d: %w", passkeyID, err)
+	}
+
+	log.Debug("successfully deleted passkey", "passkeyID", passkeyID, "rows", rows)
+	return rows, nil
+}
+
+func (d *SqliteDB) CreateWebAuthnChallenge(ctx context.Context, challenge string, userID *int64, expiresAt time.Time) error {
+	log.Debug("creating WebAuthn challenge")
+
+	err := d.queries.CreateWebAuthnChallenge(ctx, sqlc.CreateWebAuthnChallengeParams{
+		Challenge: challenge,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.Error("failed to create WebAuthn challenge", "error", err)
+		return fmt.Errorf("failed to create WebAuthn challenge: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimWebAuthnChallenge atomically consumes an unexpired challenge and returns the user
+// it was issued to, nil for login challenges.
+// Returns sql.ErrNoRows if the challenge is unknown, expired or already used.
+func (d *SqliteDB) ClaimWebAuthnChallenge(ctx context.Context, challenge string, now time.Time) (*int64, error) {
+	log.Debug("claiming WebAuthn challenge")
+
+	userID, err := d.queries.ClaimWebAuthnChallenge(ctx, sqlc.ClaimWebAuthnChallengeParams{
+		Challenge: challenge,
+		ExpiresAt: now,
+	})
+	if err != nil {
+		log.Debug("failed to claim WebAuthn challenge", "error", err)
+		return nil, fmt.Errorf("failed to claim WebAuthn challenge: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (d *SqliteDB) CleanupExpiredWebAuthnChallenges(ctx context.Context, now time.Time) error {
+	log.Debug("cleaning up expired WebAuthn challenges")
+
+	err := d.queries.DeleteExpiredWebAuthnChallenges(ctx, now)
+	if err != nil {
+		log.Error("failed to cleanup expired WebAuthn challenges", "error", err)
+		return fmt.Errorf("failed to cleanup expired WebAuthn challenges: %w", err)
+	}
+
+	return nil
+}
+
+func (d *SqliteDB) RecordFailedLogin(ctx context.Context, userID int64, failedAt time.Time) (int64, error) {
+	log.Debug("recording failed login", "userID", userID)
+
+	count, err := d.queries.RecordFailedLogin(ctx, sqlc.RecordFailedLoginParams{
+		UserID:       userID,
+		LastFailedAt: failedAt,
+	})
+	if err != nil {
+		log.Error("failed to record failed login", "userID", userID, "error", err)
+		return 0, fmt.Errorf("failed to record failed login for user %d: %w", userID, err)
+	}
+
+	log.Debug("recorded failed login", "userID", userID, "count", count)
+	return count, nil
+}
+
+func (d *SqliteDB) LockUserLogin(ctx context.Context, userID int64, lockedUntil time.Time) error {
+	log.Debug("locking user login", "userID", userID, "lockedUntil", lockedUntil)
+
+	err := d.queries.LockUserLogin(ctx, sqlc.LockUserLoginParams{
+		LockedUntil: &lockedUntil,
+		UserID:      userID,
+	})
+	if err != nil {
+		log.Error("failed to lock user login", "userID", userID, "error", err)
+		return fmt.Errorf("failed to lock login of user %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (d *SqliteDB) GetLoginLockout(ctx context.Context, userID int64) (*models.LoginLockout, error) {
+	log.Debug("retrieving login lockout", "userID", userID)
+
+	lockout, err := d.queries.GetLoginLockout(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Debug("no login lockout found", "userID", userID)
+		} else {
+			log.Error("failed to get login lockout", "userID", userID, "error", err)
+		}
+		return nil, fmt.Errorf("failed to get login lockout of user %d: %w", userID, err)
+	}
+
+	result := toModelsLoginLockout(lockout)
+	return &result, nil
+}
+
+func (d *SqliteDB) ResetLoginLockout(ctx context.Context, userID int64) (int64, error) {
+	log.Debug("resetting login lockout", "userID", userID)
+
+	rows, err := d.queries.DeleteLoginLockout(ctx, userID)
+	if err != nil {
+		log.Error("failed to reset login lockout", "userID", userID, "error", err)
+		return 0, fmt.Errorf("failed to reset login lockout of user %d: %w", userID, err)
+	}
+	return rows, nil
+}
+
+func (d *SqliteDB) InsertAuditEntry(ctx context.Context, entry models.AuditEntry) error {
+	log.Debug("inserting audit entry", "action", entry.Action)
+
+	err := d.queries.InsertAuditEntry(ctx, sqlc.InsertAuditEntryParams{
+		Action:      string(entry.Action),
+		ActorID:     entry.ActorID,
+		ActorEmail:  entry.ActorEmail,
+		TargetID:    entry.TargetID,
+		TargetEmail: entry.TargetEmail,
+		Ip:          entry.IP,
+		Detail:      entry.Detail,
+		CreatedAt:   entry.CreatedAt,
+	})
+	if err != nil {
+		log.Error("failed to insert audit entry", "action", entry.Action, "error", err)
+		return fmt.Errorf("failed to insert audit entry %s: %w", entry.Action, err)
+	}
+	return nil
+}
+
+func (d *SqliteDB) ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	log.Debug("listing audit entries", "filter", filter)
+
+	rows, err := d.queries.ListAuditEntries(ctx, sqlc.ListAuditEntriesParams{
+		Action:     string(filter.Action),
+		UserID:     filter.UserID,
+		Search:     filter.Search,
+		Since:      filter.Since,
+		Until:      filter.Until,
+		MaxEntries: filter.Limit,
+	})
+	if err != nil {
+		log.Error("failed to list audit entries", "error", err)
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	entries := make([]models.AuditEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = toModelsAuditEntry(row)
+	}
+
+	log.Debug("listed audit entries", "count", len(entries))
+	return entries, nil
+}
+
+func (d *SqliteDB) CreatePasswordReset(ctx context.Context, tokenHash string, userID int64, createdAt, expiresAt time.Time) error {
+	log.Debug("creating password reset", "userID", userID)
+
+	err := d.queries.CreatePasswordReset(ctx, sqlc.CreatePasswordResetParams{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.Error("failed to create password reset", "userID", userID, "error", err)
+		return fmt.Errorf("failed to create password reset for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (d *SqliteDB) CountPasswordResetsSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	log.Debug("counting password resets", "userID", userID, "since", since)
+
+	count, err := d.queries.CountPasswordResetsSince(ctx, sqlc.CountPasswordResetsSinceParams{
+		UserID:    userID,
+		CreatedAt: since,
+	})
+	if err != nil {
+		log.Error("failed to count password resets", "userID", userID, "error", err)
+		return 0, fmt.Errorf("failed to count password resets of user %d: %w", userID, err)
+	}
+	return count, nil
+}
+
+func (d *SqliteDB) GetPasswordResetUserID(ctx context.Context, tokenHash string, now time.Time) (int64, error) {
+	log.Debug("retrieving password reset")
+
+	userID, err := d.queries.GetPasswordResetUserID(ctx, sqlc.GetPasswordResetUserIDParams{
+		TokenHash: tokenHash,
+		Now:       now,
+	})
+	if err != nil {
+		log.Debug("failed to get password reset", "error", err)
+		return 0, fmt.Errorf("failed to get password reset: %w", err)
+	}
+	return userID, nil
+}
+
+func (d *SqliteDB) ClaimPasswordReset(ctx context.Context, tokenHash string, now time.Time) (int64, error) {
+	log.Debug("claiming password reset")
+
+	userID, err := d.queries.ClaimPasswordReset(ctx, sqlc.ClaimPasswordResetParams{
+		Now:       &now,
+		TokenHash: tokenHash,
+	})
+	if err != nil {
+		log.Debug("failed to claim password reset", "error", err)
+		return 0, fmt.Errorf("failed to claim password reset: %w", err)
+	}
+
+	log.Debug("successfully claimed password reset", "userID", userID)
+	return userID, nil
+}
+
+func (d *SqliteDB) DeletePasswordResetsByUser(ctx context.Context, userID int64) error {
+	log.Debug("deleting password resets", "userID", userID)
+
+	err := d.queries.DeletePasswordResetsByUser(ctx, userID)
+	if err != nil {
+		log.Error("failed to delete password resets", "userID", userID, "error", err)
+		return fmt.Errorf("failed to delete password resets of user %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (d *SqliteDB) CleanupExpiredPasswordResets(ctx context.Context, now time.Time) error {
+	log.Debug("cleaning up expired password resets")
+
+	err := d.queries.DeleteExpiredPasswordResets(ctx, now)
+	if err != nil {
+		log.Error("failed to cleanup expired password resets", "error", err)
+		return fmt.Errorf("failed to cleanup expired password resets: %w", err)
+	}
+	return nil
+}
@@ -311,18 +311,34 @@ VALUES
 RETURNING
     id;
 
--- name: GetListJoinMovieByID :many
+-- name: GetListAccess :one
+SELECT
+    sqlc.embed(list),
+    owner.name AS owner_name,
+    list_member.role AS member_role
+FROM
+    list
+    JOIN user owner ON owner.id = list.user_id
+    LEFT JOIN list_member ON list_member.list_id = list.id
+    AND list_member.user_id = sqlc.arg(user_id)
+WHERE
+    list.id = sqlc.arg(id)
+    AND (
+        list.user_id = sqlc.arg(user_id)
+        OR list_member.user_id IS NOT NULL
+    );
+
+-- name: GetListMovies :many
 SELECT
     sqlc.embed(movie),
     sqlc.embed(list_movie),
-    sqlc.embed(list)
+    adder.name AS added_by_name
 FROM
-    list
-    JOIN list_movie ON list_movie.list_id = list.id
+    list_movie
     JOIN movie ON movie.id = list_movie.movie_id
+    LEFT JOIN user adder ON adder.id = list_movie.added_by
 WHERE
-    list.user_id = ?
-    AND list.id = ?;
+    list_movie.list_id = ?;
 
 -- name: AddMovieToList :exec
 INSERT INTO
@@ -331,19 +347,33 @@ INSERT INTO
         list_id,
         date_added,
         position,
-        note
+        note,
+        added_by
     )
 SELECT
-    ?,
-    ?,
-    ?,
-    ?,
-    ?
+    sqlc.arg(movie_id),
+    list.id,
+    sqlc.arg(date_added),
+    sqlc.arg(position),
+    sqlc.arg(note),
+    sqlc.arg(user_id)
 FROM
     list
 WHERE
-    list.id = ?
-    AND list.user_id = ?;
+    list.id = sqlc.arg(list_id)
+    AND (
+        list.user_id = sqlc.arg(user_id)
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                list_member
+            WHERE
+                list_member.list_id = list.id
+                AND list_member.user_id = sqlc.arg(user_id)
+                AND list_member.role = 'editor'
+        )
+    );
 
 -- name: UpsertMovieInList :exec
 INSERT INTO
@@ -352,14 +382,16 @@ INSERT INTO
         list_id,
         date_added,
         position,
-        note
+        note,
+        added_by
     )
 SELECT
     ?,
     ?,
     ?,
     ?,
-    ?
+    ?,
+    list.user_id
 FROM
     list
 WHERE
@@ -374,7 +406,24 @@ SET
 
 -- name: GetAllLists :many
 SELECT
-    *
+    sqlc.embed(list),
+    EXISTS (
+        SELECT
+            1
+        FROM
+            list_rule
+        WHERE
+            list_rule.list_id = list.id
+    ) AS smart,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            list_source
+        WHERE
+            list_source.list_id = list.id
+            AND list_source.sync = TRUE
+    ) AS synced
 FROM
     list
 WHERE
@@ -408,6 +457,68 @@ WHERE
     user_id = ?
     AND id = ?;
 
+-- name: GetListOwner :one
+SELECT
+    user_id
+FROM
+    list
+WHERE
+    id = ?;
+
+-- name: UpdateList :execrows
+UPDATE
+    list
+SET
+    name = ?,
+    description = ?
+WHERE
+    user_id = ?
+    AND id = ?;
+
+-- name: SetListRanked :execrows
+UPDATE
+    list
+SET
+    ranked = ?
+WHERE
+    user_id = ?
+    AND id = ?;
+
+-- name: SetListShare :execrows
+UPDATE
+    list
+SET
+    share_token = ?,
+    share_notes = ?
+WHERE
+    user_id = ?
+    AND id = ?;
+
+-- name: GetListByShareToken :one
+SELECT
+    *
+FROM
+    list
+WHERE
+    share_token = ?;
+
+-- name: SetListMoviePosition :execrows
+UPDATE
+    list_movie
+SET
+    position = ?
+WHERE
+    list_id = ?
+    AND movie_id = ?;
+
+-- name: CountListMovies :one
+SELECT
+    COUNT(*)
+FROM
+    list_movie
+WHERE
+    list_id = ?;
+
 -- name: DeleteListByID :exec
 DELETE FROM
     list
@@ -419,934 +530,2264 @@ WHERE
 DELETE FROM
     list_movie
 WHERE
-    list_id = ?
-    AND movie_id = ?
+    list_movie.list_id = sqlc.arg(list_id)
+    AND list_movie.movie_id = sqlc.arg(movie_id)
     AND EXISTS (
         SELECT
             1
         FROM
             list
+            LEFT JOIN list_member ON list_member.list_id = list.id
+            AND list_member.user_id = sqlc.arg(user_id)
         WHERE
-    list.id = list_id
-    AND list.user_id = ?
-);
-
--- Watched stats.
--- name: GetWatchedStatsPerMonthLastYear :many
+            list.id = list_movie.list_id
+            AND (
+                list.user_id = sqlc.arg(user_id)
+                OR list_member.role = 'editor'
+            )
+    );
+
+-- name: CopyListMovies :exec
+-- Copies movies of a list to another one keeping their date added, position and note.
+-- Movies already in the target keep their entry, taking the note of the copy when they
+-- have none.
+INSERT INTO
+    list_movie (
+        movie_id,
+        list_id,
+        date_added,
+        position,
+        note,
+        added_by
+    )
 SELECT
-    CAST(strftime('%Y-%m', watched_date) AS TEXT) AS month,
-    COUNT(*) AS count,
-    SUM(movie.runtime) AS total_runtime
+    source.movie_id,
+    sqlc.arg(to_list_id),
+    source.date_added,
+    source.position,
+    source.note,
+    sqlc.arg(user_id)
 FROM
-    watched
-    JOIN movie ON watched.movie_id = movie.id
+    list_movie source
 WHERE
-    watched_date >= date('now', 'start of month', '-12 months')
-    AND watched.user_id = ?
-GROUP BY
-    month
-ORDER BY
-    month;
+    source.list_id = sqlc.arg(from_list_id)
+    AND source.movie_id IN (sqlc.slice(movie_ids))
+ON CONFLICT(movie_id, list_id) DO
+UPDATE
+SET
+    note = COALESCE(list_movie.note, excluded.note);
 
--- name: GetWatchedPerYear :many
-SELECT
-    CAST(strftime('%Y', watched_date) AS TEXT) AS year,
-    COUNT(*) AS count
-FROM
-    watched
+-- name: DeleteListMovies :many
+DELETE FROM
+    list_movie
 WHERE
-    user_id = ?
-GROUP BY
-    year
-ORDER BY
-    year;
+    list_id = sqlc.arg(list_id)
+    AND movie_id IN (sqlc.slice(movie_ids))
+RETURNING
+    movie_id;
 
--- name: GetWeekdayDistribution :many
+-- name: GetMemberLists :many
 SELECT
-    CAST(strftime('%w', watched_date) AS INTEGER) AS weekday_index,
-    COUNT(*) AS count
+    list.id,
+    list.name,
+    owner.name AS owner_name,
+    list_member.role,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            list_rule
+        WHERE
+            list_rule.list_id = list.id
+    ) AS smart,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            list_source
+        WHERE
+            list_source.list_id = list.id
+            AND list_source.sync = TRUE
+    ) AS synced
 FROM
-    watched
+    list_member
+    JOIN list ON list.id = list_member.list_id
+    JOIN user owner ON owner.id = list.user_id
 WHERE
-    user_id = ?
-GROUP BY
-    weekday_index
+    list_member.user_id = ?
 ORDER BY
-    weekday_index;
+    list.id;
 
--- name: GetWatchedByGenre :many
+-- name: GetMovieLists :many
+-- The lists the user owns or is a member of that contain the movie, the watchlist first
+-- then their own lists before the ones shared with them. Looking list_movie up by movie_id
+-- uses its primary key, which starts with movie_id, so no list is loaded whole.
 SELECT
-    genre.name,
-    COUNT(*) AS count
+    list.id,
+    list.name,
+    list.is_watchlist,
+    owner.name AS owner_name,
+    list_member.role AS member_role,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            list_source
+        WHERE
+            list_source.list_id = list.id
+            AND list_source.sync = TRUE
+    ) AS synced,
+    list_movie.note,
+    list_movie.date_added
 FROM
-    watched
-    JOIN movie ON watched.movie_id = movie.id
-    JOIN genre_movie ON movie.id = genre_movie.movie_id
-    JOIN genre ON genre_movie.genre_id = genre.id
+    list_movie
+    JOIN list ON list.id = list_movie.list_id
+    JOIN user owner ON owner.id = list.user_id
+    LEFT JOIN list_member ON list_member.list_id = list.id
+    AND list_member.user_id = sqlc.arg(user_id)
 WHERE
-    watched.user_id = ?
-GROUP BY
-    genre.id,
-    genre.name
+    list_movie.movie_id = sqlc.arg(movie_id)
+    AND (
+        list.user_id = sqlc.arg(user_id)
+        OR list_member.user_id IS NOT NULL
+    )
 ORDER BY
-    count DESC;
+    list.is_watchlist DESC,
+    list_member.user_id IS NOT NULL,
+    list.id;
 
--- name: GetTheaterVsHomeCount :many
+-- name: GetVisibleSmartLists :many
+-- The smart lists the user owns or is a member of, their own before the ones shared with
+-- them. Their movies are not stored, GetSmartListMovies evaluates them.
 SELECT
-    watched_in_theater,
-    COUNT(*) AS count
+    list.id,
+    list.name,
+    owner.name AS owner_name,
+    list_member.role AS member_role
 FROM
-    watched
+    list
+    JOIN list_rule ON list_rule.list_id = list.id
+    JOIN user owner ON owner.id = list.user_id
+    LEFT JOIN list_member ON list_member.list_id = list.id
+    AND list_member.user_id = sqlc.arg(user_id)
 WHERE
-    user_id = ?
-GROUP BY
-    watched_in_theater;
+    list.user_id = sqlc.arg(user_id)
+    OR list_member.user_id IS NOT NULL
+ORDER BY
+    list_member.user_id IS NOT NULL,
+    list.id;
 
--- name: GetMostWatchedMovies :many
+-- name: GetListMembers :many
 SELECT
-    movie.title,
-    movie.id,
-    movie.poster_path,
-    COUNT(*) AS watch_count
+    user.id,
+    user.name,
+    user.email,
+    list_member.role,
+    list_member.added_at
 FROM
-    watched
-    JOIN movie ON watched.movie_id = movie.id
+    list_member
+    JOIN user ON user.id = list_member.user_id
 WHERE
-    watched.user_id = ?
-GROUP BY
-    movie.id,
-    movie.title,
-    movie.poster_path
+    list_member.list_id = ?
 ORDER BY
-    watch_count DESC
-LIMIT
-    ?;
+    list_member.added_at,
+    user.id;
 
--- name: GetMostWatchedDay :one
+-- name: CountListMembers :one
 SELECT
-    watched_date,
-    COUNT(*) AS count
+    COUNT(*)
 FROM
-    watched
+    list_member
 WHERE
-    user_id = ?
-GROUP BY
-    watched_date
-ORDER BY
-    count DESC
-LIMIT 1;
+    list_id = ?;
 
--- name: GetWatchedActors :many
-WITH watched_actors AS (
-    SELECT DISTINCT
-        watched.id AS watched_id,
-        person.id,
-        person.name,
-        person.profile_path,
-        person.gender
-    FROM
-        watched
-        JOIN "cast" ON watched.movie_id = "cast".movie_id
-        JOIN person ON "cast".person_id = person.id
-    WHERE
-        watched.user_id = ?
-        AND person.gender IN (1, 2)
-)
+-- name: UpsertListMember :execrows
+INSERT INTO
+    list_member (list_id, user_id, role, added_at)
 SELECT
-    watched_actors.name,
-    watched_actors.id,
-    watched_actors.profile_path,
-    watched_actors.gender,
-    COUNT(*) AS watch_count
+    list.id,
+    sqlc.arg(member_id),
+    sqlc.arg(role),
+    sqlc.arg(added_at)
 FROM
-    watched_actors
-GROUP BY
-    watched_actors.id,
-    watched_actors.name,
-    watched_actors.profile_path,
-    watched_actors.gender
-ORDER BY
-    watched_actors.gender ASC,
-    watch_count DESC,
-    watched_actors.name ASC;
+    list
+WHERE
+    list.id = sqlc.arg(list_id)
+    AND list.user_id = sqlc.arg(user_id)
+    AND list.is_watchlist = FALSE
+ON CONFLICT(list_id, user_id) DO
+UPDATE
+SET
+    role = excluded.role;
 
--- name: GetWatchedDateRange :one
-SELECT
-    MIN(watched_date) AS min_date,
-    MAX(watched_date) AS max_date
-FROM
-    watched
+-- name: DeleteListMember :execrows
+DELETE FROM
+    list_member
 WHERE
-    watched_date IS NOT NULL
-    AND user_id = ?;
+    list_member.list_id = sqlc.arg(list_id)
+    AND list_member.user_id = sqlc.arg(member_id)
+    AND (
+        list_member.user_id = sqlc.arg(user_id)
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                list
+            WHERE
+                list.id = list_member.list_id
+                AND list.user_id = sqlc.arg(user_id)
+        )
+    );
 
--- name: GetWatchedDates :many
+-- Smart lists.
+-- name: GetListRules :one
 SELECT
-    watched_date
+    *
 FROM
-    watched
+    list_rule
 WHERE
-    user_id = ?
-ORDER BY
-    watched_date;
+    list_id = ?;
 
--- name: GetRecentWatchedMovies :many
+-- name: UpsertListRules :execrows
+INSERT INTO
+    list_rule (
+        list_id,
+        watched,
+        in_theater,
+        watched_year,
+        min_rating,
+        min_runtime,
+        max_runtime,
+        release_year_from,
+        release_year_to,
+        genre_id,
+        in_watchlist,
+        director_min_rating,
+        person_name
+    )
 SELECT
-    sqlc.embed(movie),
-    sqlc.embed(watched)
+    list.id,
+    sqlc.narg(watched),
+    sqlc.narg(in_theater),
+    sqlc.narg(watched_year),
+    sqlc.narg(min_rating),
+    sqlc.narg(min_runtime),
+    sqlc.narg(max_runtime),
+    sqlc.narg(release_year_from),
+    sqlc.narg(release_year_to),
+    sqlc.narg(genre_id),
+    sqlc.narg(in_watchlist),
+    sqlc.narg(director_min_rating),
+    sqlc.narg(person_name)
 FROM
-    watched
-    JOIN movie ON watched.movie_id = movie.id
+    list
 WHERE
-    watched.user_id = ?
+    list.id = sqlc.arg(list_id)
+    AND list.user_id = sqlc.arg(user_id)
+    AND list.is_watchlist = FALSE
+ON CONFLICT(list_id) DO
+UPDATE
+SET
+    watched = excluded.watched,
+    in_theater = excluded.in_theater,
+    watched_year = excluded.watched_year,
+    min_rating = excluded.min_rating,
+    min_runtime = excluded.min_runtime,
+    max_runtime = excluded.max_runtime,
+    release_year_from = excluded.release_year_from,
+    release_year_to = excluded.release_year_to,
+    genre_id = excluded.genre_id,
+    in_watchlist = excluded.in_watchlist,
+    director_min_rating = excluded.director_min_rating,
+    person_name = excluded.person_name;
+
+-- name: GetSmartListMovies :many
+-- Evaluates the stored rules of a smart list over the library of its owner, the movies
+-- they watched or keep in one of their lists. The movie table caches every movie anyone
+-- looked at, so it is not a library on its own. A rule that is NULL matches every movie.
+-- Several lists can be evaluated at once, a movie_id only checks whether that movie
+-- matches them.
+SELECT
+    list_rule.list_id,
+    sqlc.embed(movie),
+    (
+        SELECT
+            MAX(last_watch.watched_date)
+        FROM
+            watched last_watch
+        WHERE
+            last_watch.movie_id = movie.id
+            AND last_watch.user_id = list.user_id
+    ) AS last_watched_date
+FROM
+    list_rule
+    JOIN list ON list.id = list_rule.list_id
+    JOIN movie
+WHERE
+    -- movie_id comes before the slice, its numbered parameter would shift once the slice
+    -- is expanded otherwise
+    (
+        movie.id = sqlc.narg(movie_id)
+        OR sqlc.narg(movie_id) IS NULL
+    )
+    AND list_rule.list_id IN (sqlc.slice(list_ids))
+    AND (
+        EXISTS (
+            SELECT
+                1
+            FROM
+                watched
+            WHERE
+                watched.movie_id = movie.id
+                AND watched.user_id = list.user_id
+        )
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                list_movie
+                JOIN list owned ON owned.id = list_movie.list_id
+            WHERE
+                list_movie.movie_id = movie.id
+                AND owned.user_id = list.user_id
+        )
+    )
+    AND (
+        list_rule.watched IS NULL
+        OR list_rule.watched = EXISTS (
+            SELECT
+                1
+            FROM
+                watched
+            WHERE
+                watched.movie_id = movie.id
+                AND watched.user_id = list.user_id
+        )
+    )
+    AND (
+        (
+            list_rule.in_theater IS NULL
+            AND list_rule.watched_year IS NULL
+            AND list_rule.min_rating IS NULL
+        )
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                watched
+            WHERE
+                watched.movie_id = movie.id
+                AND watched.user_id = list.user_id
+                AND (
+                    list_rule.in_theater IS NULL
+                    OR watched.watched_in_theater = list_rule.in_theater
+                )
+                AND (
+                    list_rule.watched_year IS NULL
+                    OR CAST(strftime('%Y', watched.watched_date) AS INTEGER) = list_rule.watched_year
+                )
+                AND (
+                    list_rule.min_rating IS NULL
+                    OR watched.rating >= list_rule.min_rating
+                )
+        )
+    )
+    AND (
+        list_rule.min_runtime IS NULL
+        OR movie.runtime >= list_rule.min_runtime
+    )
+    AND (
+        list_rule.max_runtime IS NULL
+        OR (
+            movie.runtime > 0
+            AND movie.runtime <= list_rule.max_runtime
+        )
+    )
+    AND (
+        list_rule.release_year_from IS NULL
+        OR CAST(strftime('%Y', movie.release_date) AS INTEGER) >= list_rule.release_year_from
+    )
+    AND (
+        list_rule.release_year_to IS NULL
+        OR CAST(strftime('%Y', movie.release_date) AS INTEGER) <= list_rule.release_year_to
+    )
+    AND (
+        list_rule.genre_id IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                genre_movie
+            WHERE
+                genre_movie.movie_id = movie.id
+                AND genre_movie.genre_id = list_rule.genre_id
+        )
+    )
+    AND (
+        list_rule.in_watchlist IS NULL
+        OR list_rule.in_watchlist = EXISTS (
+            SELECT
+                1
+            FROM
+                list_movie
+                JOIN list watchlist ON watchlist.id = list_movie.list_id
+            WHERE
+                list_movie.movie_id = movie.id
+                AND watchlist.user_id = list.user_id
+                AND watchlist.is_watchlist = TRUE
+        )
+    )
+    AND (
+        list_rule.director_min_rating IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                crew
+            WHERE
+                crew.movie_id = movie.id
+                AND crew.job = 'Director'
+                AND crew.person_id IN (
+                    SELECT
+                        rated.person_id
+                    FROM
+                        crew rated
+                        JOIN watched ON watched.movie_id = rated.movie_id
+                    WHERE
+                        rated.job = 'Director'
+                        AND watched.user_id = list.user_id
+                        AND watched.rating IS NOT NULL
+                    GROUP BY
+                        rated.person_id
+                    HAVING
+                        AVG(watched.rating) >= list_rule.director_min_rating
+                )
+        )
+    )
+    AND (
+        list_rule.person_name IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                "cast"
+                JOIN person ON person.id = "cast".person_id
+            WHERE
+                "cast".movie_id = movie.id
+                AND person.name = list_rule.person_name COLLATE NOCASE
+        )
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                crew
+                JOIN person ON person.id = crew.person_id
+            WHERE
+                crew.movie_id = movie.id
+                AND person.name = list_rule.person_name COLLATE NOCASE
+        )
+    )
 ORDER BY
-    watched.watched_date DESC
-LIMIT
-    ?;
+    last_watched_date IS NULL,
+    last_watched_date DESC,
+    movie.title;
 
--- name: GetTotalWatchedStats :one
+-- name: GetGenres :many
 SELECT
-    COUNT(*) AS count,
-    SUM(movie.runtime) AS total_runtime
+    id,
+    name
 FROM
-    watched
-    JOIN movie ON watched.movie_id = movie.id
+    genre
+ORDER BY
+    name;
+
+-- TMDB list sources.
+-- name: GetListSource :one
+SELECT
+    *
+FROM
+    list_source
 WHERE
-    watched.user_id = ?;
+    list_id = ?;
 
--- name: GetRewatchStats :one
-WITH movie_watch_counts AS (
-    SELECT
-        watched.movie_id,
-        COUNT(*) AS watch_count
-    FROM
-        watched
-    WHERE
-        watched.user_id = ?
-    GROUP BY
-        watched.movie_id
-)
+-- name: UpsertListSource :execrows
+INSERT INTO
+    list_source (list_id, kind, tmdb_id, sync, synced_at)
 SELECT
-    COUNT(*) AS unique_movie_count,
-    CAST(
-        COALESCE(
-            SUM(
-                CASE
-                    WHEN watch_count > 1 THEN 1
-                    ELSE 0
-                END
-            ),
-            0
-        ) AS INTEGER
-    ) AS rewatched_movie_count,
-    CAST(
-        COALESCE(
-            SUM(
-                CASE
-                    WHEN watch_count > 1 THEN watch_count - 1
-                    ELSE 0
-                END
-            ),
-            0
-        ) AS INTEGER
-    ) AS rewatch_count
+    list.id,
+    sqlc.arg(kind),
+    sqlc.arg(tmdb_id),
+    sqlc.arg(sync),
+    sqlc.arg(synced_at)
 FROM
-    movie_watch_counts;
+    list
+WHERE
+    list.id = sqlc.arg(list_id)
+    AND list.user_id = sqlc.arg(user_id)
+    AND list.is_watchlist = FALSE
+ON CONFLICT(list_id) DO
+UPDATE
+SET
+    kind = excluded.kind,
+    tmdb_id = excluded.tmdb_id,
+    sync = excluded.sync,
+    synced_at = excluded.synced_at;
 
--- name: GetDailyWatchCountsLastYear :many
+-- name: SetListSourceSync :execrows
+UPDATE
+    list_source
+SET
+    sync = sqlc.arg(sync)
+WHERE
+    list_id = sqlc.arg(list_id)
+    AND EXISTS (
+        SELECT
+            1
+        FROM
+            list
+        WHERE
+            list.id = list_source.list_id
+            AND list.user_id = sqlc.arg(user_id)
+    );
+
+-- name: SetListSourceSyncedAt :exec
+UPDATE
+    list_source
+SET
+    synced_at = ?
+WHERE
+    list_id = ?;
+
+-- name: GetSyncedListSources :many
+-- Returns the lists kept in sync with their source with their owner, least recently
+-- synced first.
 SELECT
-    watched.watched_date,
-    COUNT(*) AS count
+    list_source.list_id,
+    list.user_id
 FROM
-    watched
+    list_source
+    JOIN list ON list.id = list_source.list_id
 WHERE
-    watched.user_id = ?
-    AND watched.watched_date >= date('now', '-364 days')
-GROUP BY
-    watched.watched_date
+    list_source.sync = TRUE
 ORDER BY
-    watched.watched_date;
+    list_source.synced_at;
 
--- name: GetWatchedCrewMembers :many
-WITH normalized_crew AS (
-    SELECT DISTINCT
-        watched.id AS watched_id,
-        person.id,
-        person.name,
-        person.profile_path,
-        CASE
-            WHEN crew.job = 'Director' THEN 'director'
-            WHEN crew.job IN (
-                'Writer',
-                'Screenplay',
-                'Story',
-                'Novel',
-                'Original Story',
-                'Characters'
-            ) THEN 'writer'
-            WHEN crew.job IN ('Original Music Composer', 'Composer', 'Music') THEN 'composer'
-            WHEN crew.job IN ('Director of Photography', 'Cinematography') THEN 'cinematographer'
-            ELSE NULL
-        END AS role_key
-    FROM
-        watched
-        JOIN crew ON watched.movie_id = crew.movie_id
-        JOIN person ON crew.person_id = person.id
-    WHERE
-        watched.user_id = ?
-)
+-- Watchlist items.
+-- name: UpdateListMovieDetails :execrows
+UPDATE list_movie
+SET
+    priority = ?,
+    available_on = ?
+WHERE
+    list_id = ?
+    AND movie_id = ?;
+
+-- name: DeleteListMovieTags :exec
+DELETE FROM list_movie_tag
+WHERE
+    list_id = ?
+    AND movie_id = ?;
+
+-- name: InsertListMovieTag :exec
+INSERT INTO
+    list_movie_tag (list_id, movie_id, tag)
+VALUES
+    (?, ?, ?);
+
+-- name: GetListMovieTags :many
 SELECT
-    normalized_crew.role_key,
-    normalized_crew.id,
-    normalized_crew.name,
-    normalized_crew.profile_path,
-    COUNT(*) AS watch_count
+    movie_id,
+    tag
 FROM
-    normalized_crew
+    list_movie_tag
 WHERE
-    normalized_crew.role_key IS NOT NULL
-GROUP BY
-    normalized_crew.role_key,
-    normalized_crew.id,
-    normalized_crew.name,
-    normalized_crew.profile_path
+    list_id = ?
 ORDER BY
-    normalized_crew.role_key ASC,
-    watch_count DESC,
-    normalized_crew.name ASC;
+    tag;
 
--- name: GetTopLanguages :many
+-- name: GetWatchNextFilter :one
 SELECT
-    movie.original_language AS language,
-    COUNT(*) AS watch_count
+    *
 FROM
-    watched
-    JOIN movie ON watched.movie_id = movie.id
+    watch_next_filter
 WHERE
-    watched.user_id = ?
-    AND movie.original_language <> ''
-GROUP BY
-    movie.original_language
+    user_id = ?;
+
+-- name: UpsertWatchNextFilter :exec
+INSERT INTO
+    watch_next_filter (user_id, min_priority, tag, exclude_tag)
+VALUES
+    (?, ?, ?, ?)
+ON CONFLICT(user_id) DO
+UPDATE
+SET
+    min_priority = excluded.min_priority,
+    tag = excluded.tag,
+    exclude_tag = excluded.exclude_tag;
+
+-- List views.
+-- name: GetListView :one
+SELECT
+    *
+FROM
+    list_view
+WHERE
+    user_id = ?
+    AND list_id = ?;
+
+-- name: UpsertListView :exec
+INSERT INTO
+    list_view (
+        user_id,
+        list_id,
+        sort,
+        sort_desc,
+        genre_id,
+        watched,
+        decade,
+        layout
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(user_id, list_id) DO
+UPDATE
+SET
+    sort = excluded.sort,
+    sort_desc = excluded.sort_desc,
+    genre_id = excluded.genre_id,
+    watched = excluded.watched,
+    decade = excluded.decade,
+    layout = excluded.layout;
+
+-- name: GetMoviesGenres :many
+SELECT
+    genre_movie.movie_id,
+    sqlc.embed(genre)
+FROM
+    genre_movie
+    JOIN genre ON genre.id = genre_movie.genre_id
+WHERE
+    genre_movie.movie_id IN (sqlc.slice(movie_ids))
 ORDER BY
-    watch_count DESC,
-    language ASC
-LIMIT
-    ?;
+    genre.name;
 
--- name: GetReleaseYearDistribution :many
+-- name: GetMoviesWatchSummaries :many
+-- Returns how many times the user watched each of the movies they watched, with the
+-- rating of their latest rated watch.
 SELECT
-    CAST(strftime('%Y', movie.release_date) AS INTEGER) AS release_year,
-    COUNT(DISTINCT watched.movie_id) AS count
+    watched.movie_id,
+    COUNT(*) AS watch_count,
+    (
+        SELECT
+            rated.rating
+        FROM
+            watched rated
+        WHERE
+            rated.user_id = watched.user_id
+            AND rated.movie_id = watched.movie_id
+            AND rated.rating IS NOT NULL
+        ORDER BY
+            rated.watched_date DESC
+        LIMIT
+            1
+    ) AS rating
 FROM
     watched
-    JOIN movie ON watched.movie_id = movie.id
 WHERE
-    watched.user_id = ?
-    AND movie.release_date IS NOT NULL
+    watched.user_id = sqlc.arg(user_id)
+    AND watched.movie_id IN (sqlc.slice(movie_ids))
 GROUP BY
-    release_year
-ORDER BY
-    release_year;
+    watched.movie_id;
 
--- name: GetLongestWatchedMovie :one
+-- Watched stats.
+-- name: GetWatchedStatsPerMonthLastYear :many
 SELECT
-    movie.id,
-    movie.title,
-    movie.poster_path,
-    movie.runtime
+    CAST(strftime('%Y-%m', watched_date) AS TEXT) AS month,
+    COUNT(*) AS count,
+    SUM(movie.runtime) AS total_runtime
 FROM
     watched
     JOIN movie ON watched.movie_id = movie.id
 WHERE
-    watched.user_id = ?
-    AND movie.runtime > 0
+    watched_date >= date('now', 'start of month', '-12 months')
+    AND watched.user_id = ?
 GROUP BY
-    movie.id,
-    movie.title,
-    movie.poster_path,
-    movie.runtime
+    month
 ORDER BY
-    movie.runtime DESC,
-    movie.title ASC
-LIMIT
-    1;
+    month;
 
--- name: GetShortestWatchedMovie :one
+-- name: GetWatchedPerYear :many
 SELECT
-    movie.id,
-    movie.title,
-    movie.poster_path,
-    movie.runtime
+    CAST(strftime('%Y', watched_date) AS TEXT) AS year,
+    COUNT(*) AS count
 FROM
     watched
-    JOIN movie ON watched.movie_id = movie.id
 WHERE
-    watched.user_id = ?
-    AND movie.runtime > 0
+    user_id = ?
 GROUP BY
-    movie.id,
-    movie.title,
-    movie.poster_path,
-    movie.runtime
+    year
 ORDER BY
-    movie.runtime ASC,
-    movie.title ASC
-LIMIT
-    1;
+    year;
 
--- name: GetBudgetTierDistribution :many
-WITH watched_movies AS (
-    SELECT DISTINCT
-        watched.movie_id,
-        movie.budget
-    FROM
-        watched
-        JOIN movie ON watched.movie_id = movie.id
-    WHERE
-        watched.user_id = ?
-)
+-- name: GetWeekdayDistribution :many
 SELECT
-    CASE
-        WHEN watched_movies.budget <= 0 THEN 'unknown'
-        WHEN watched_movies.budget < 10000000 THEN 'indie'
-        WHEN watched_movies.budget <= 100000000 THEN 'mid'
-        ELSE 'blockbuster'
-    END AS tier,
+    CAST(strftime('%w', watched_date) AS INTEGER) AS weekday_index,
     COUNT(*) AS count
 FROM
-    watched_movies
+    watched
+WHERE
+    user_id = ?
 GROUP BY
-    tier
+    weekday_index
 ORDER BY
-    CASE
-        WHEN tier = 'indie' THEN 1
-        WHEN tier = 'mid' THEN 2
-        WHEN tier = 'blockbuster' THEN 3
-        ELSE 4
-    END;
+    weekday_index;
 
--- name: GetTopReturnOnInvestmentMovies :many
-WITH watched_movies AS (
-    SELECT DISTINCT
-        watched.movie_id
-    FROM
-        watched
-    WHERE
-        watched.user_id = ?
-)
+-- name: GetWatchedByGenre :many
 SELECT
-    movie.id,
-    movie.title,
-    movie.poster_path,
-    movie.budget,
-    movie.revenue,
-    CAST(
-        CAST(movie.revenue - movie.budget AS REAL) / CAST(movie.budget AS REAL) AS REAL
-    ) AS roi
+    genre.name,
+    COUNT(*) AS count
 FROM
-    watched_movies
-    JOIN movie ON watched_movies.movie_id = movie.id
+    watched
+    JOIN movie ON watched.movie_id = movie.id
+    JOIN genre_movie ON movie.id = genre_movie.movie_id
+    JOIN genre ON genre_movie.genre_id = genre.id
 WHERE
-    movie.budget > 0
-    AND movie.revenue > 0
+    watched.user_id = ?
+GROUP BY
+    genre.id,
+    genre.name
 ORDER BY
-    roi DESC,
-    movie.revenue DESC,
-    movie.title ASC
-LIMIT
-    ?;
+    count DESC;
 
--- name: GetBiggestBudgetMovies :many
-WITH watched_movies AS (
-    SELECT DISTINCT
-        watched.movie_id
-    FROM
-        watched
-    WHERE
-        watched.user_id = ?
-)
+-- name: GetTheaterVsHomeCount :many
+SELECT
+    watched_in_theater,
+    COUNT(*) AS count
+FROM
+    watched
+WHERE
+    user_id = ?
+GROUP BY
+    watched_in_theater;
+
+-- name: GetMostWatchedMovies :many
 SELECT
-    movie.id,
     movie.title,
+    movie.id,
     movie.poster_path,
-    movie.budget,
-    movie.revenue,
-    CAST(
-        CASE
-            WHEN movie.budget > 0 THEN CAST(movie.revenue - movie.budget AS REAL) / CAST(movie.budget AS REAL)
-            ELSE 0.0
-        END AS REAL
-    ) AS roi
+    COUNT(*) AS watch_count
 FROM
-    watched_movies
-    JOIN movie ON watched_movies.movie_id = movie.id
+    watched
+    JOIN movie ON watched.movie_id = movie.id
 WHERE
-    movie.budget > 0
+    watched.user_id = ?
+GROUP BY
+    movie.id,
+    movie.title,
+    movie.poster_path
 ORDER BY
-    movie.budget DESC,
-    movie.revenue DESC,
-    movie.title ASC
+    watch_count DESC
 LIMIT
     ?;
 
--- name: GetMonthlyGenreBreakdown :many
+-- name: GetMostWatchedDay :one
 SELECT
-    watched.watched_date,
-    genre.name AS genre_name,
-    COUNT(*) AS movie_count
+    watched_date,
+    COUNT(*) AS count
 FROM
     watched
-    JOIN movie ON watched.movie_id = movie.id
-    JOIN genre_movie ON movie.id = genre_movie.movie_id
-    JOIN genre ON genre_movie.genre_id = genre.id
 WHERE
-    watched.watched_date >= date('now', 'start of month', '-12 months')
-    AND watched.user_id = ?
+    user_id = ?
 GROUP BY
-    watched.watched_date,
-    genre_name
+    watched_date
 ORDER BY
-    watched.watched_date,
-    movie_count DESC;
+    count DESC
+LIMIT 1;
 
--- Rating stats.
--- name: GetRatingSummary :one
+-- name: GetWatchedActors :many
+WITH watched_actors AS (
+    SELECT DISTINCT
+        watched.id AS watched_id,
+        person.id,
+        person.name,
+        person.profile_path,
+        person.gender
+    FROM
+        watched
+        JOIN "cast" ON watched.movie_id = "cast".movie_id
+        JOIN person ON "cast".person_id = person.id
+    WHERE
+        watched.user_id = ?
+        AND person.gender IN (1, 2)
+)
 SELECT
-    CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
-    COUNT(watched.rating) AS rated_count
+    watched_actors.name,
+    watched_actors.id,
+    watched_actors.profile_path,
+    watched_actors.gender,
+    COUNT(*) AS watch_count
+FROM
+    watched_actors
+GROUP BY
+    watched_actors.id,
+    watched_actors.name,
+    watched_actors.profile_path,
+    watched_actors.gender
+ORDER BY
+    watched_actors.gender ASC,
+    watch_count DESC,
+    watched_actors.name ASC;
+
+-- name: GetWatchedDateRange :one
+SELECT
+    MIN(watched_date) AS min_date,
+    MAX(watched_date) AS max_date
 FROM
     watched
 WHERE
-    watched.user_id = ?
-    AND watched.rating IS NOT NULL;
+    watched_date IS NOT NULL
+    AND user_id = ?;
 
--- name: GetRatingDistribution :many
+-- name: GetWatchedDates :many
 SELECT
-    CAST(ROUND(CAST(watched.rating AS REAL) * 2.0, 0) / 2.0 AS REAL) AS rating_bucket,
-    COUNT(*) AS count
+    watched_date
 FROM
     watched
 WHERE
-    watched.user_id = ?
-    AND watched.rating IS NOT NULL
-GROUP BY
-    rating_bucket
+    user_id = ?
 ORDER BY
-    rating_bucket;
+    watched_date;
 
--- name: GetMonthlyAverageRatingLastYear :many
+-- name: GetRecentWatchedMovies :many
 SELECT
-    CAST(strftime('%Y-%m', watched.watched_date) AS TEXT) AS month,
-    CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
-    COUNT(watched.rating) AS rated_count
+    sqlc.embed(movie),
+    sqlc.embed(watched)
 FROM
     watched
+    JOIN movie ON watched.movie_id = movie.id
 WHERE
     watched.user_id = ?
-    AND watched.rating IS NOT NULL
-    AND watched.watched_date >= date('now', 'start of month', '-11 months')
-GROUP BY
-    month
 ORDER BY
-    month;
+    watched.watched_date DESC
+LIMIT
+    ?;
 
--- name: GetTheaterVsHomeAverageRating :many
+-- name: GetTotalWatchedStats :one
 SELECT
-    watched.watched_in_theater,
-    CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
-    COUNT(watched.rating) AS rated_count
+    COUNT(*) AS count,
+    SUM(movie.runtime) AS total_runtime
 FROM
     watched
+    JOIN movie ON watched.movie_id = movie.id
 WHERE
-    watched.user_id = ?
-    AND watched.rating IS NOT NULL
-GROUP BY
-    watched.watched_in_theater;
+    watched.user_id = ?;
 
--- name: GetHighestRatedMovies :many
-WITH rated_movies AS (
+-- name: GetRewatchStats :one
+WITH movie_watch_counts AS (
     SELECT
         watched.movie_id,
-        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
-        COUNT(watched.rating) AS rated_watch_count
+        COUNT(*) AS watch_count
     FROM
         watched
     WHERE
-        watched.user_id = ?1
-        AND watched.rating IS NOT NULL
+        watched.user_id = ?
     GROUP BY
         watched.movie_id
 )
 SELECT
-    movie.id,
-    movie.title,
-    movie.poster_path,
-    rated_movies.average_rating,
-    rated_movies.rated_watch_count
+    COUNT(*) AS unique_movie_count,
+    CAST(
+        COALESCE(
+            SUM(
+                CASE
+                    WHEN watch_count > 1 THEN 1
+                    ELSE 0
+                END
+            ),
+            0
+        ) AS INTEGER
+    ) AS rewatched_movie_count,
+    CAST(
+        COALESCE(
+            SUM(
+                CASE
+                    WHEN watch_count > 1 THEN watch_count - 1
+                    ELSE 0
+                END
+            ),
+            0
+        ) AS INTEGER
+    ) AS rewatch_count
 FROM
-    rated_movies
-    JOIN movie ON rated_movies.movie_id = movie.id
+    movie_watch_counts;
+
+-- name: GetDailyWatchCountsLastYear :many
+SELECT
+    watched.watched_date,
+    COUNT(*) AS count
+FROM
+    watched
+WHERE
+    watched.user_id = ?
+    AND watched.watched_date >= date('now', '-364 days')
+GROUP BY
+    watched.watched_date
 ORDER BY
-    rated_movies.average_rating DESC,
-    rated_movies.rated_watch_count DESC,
-    movie.title ASC
-LIMIT
-    ?2;
+    watched.watched_date;
 
--- name: GetRatingVsTMDB :one
-WITH rated_movies AS (
-    SELECT
-        watched.movie_id,
-        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating
+-- name: GetWatchedCrewMembers :many
+WITH normalized_crew AS (
+    SELECT DISTINCT
+        watched.id AS watched_id,
+        person.id,
+        person.name,
+        person.profile_path,
+        CASE
+            WHEN crew.job = 'Director' THEN 'director'
+            WHEN crew.job IN (
+                'Writer',
+                'Screenplay',
+                'Story',
+                'Novel',
+                'Original Story',
+                'Characters'
+            ) THEN 'writer'
+            WHEN crew.job IN ('Original Music Composer', 'Composer', 'Music') THEN 'composer'
+            WHEN crew.job IN ('Director of Photography', 'Cinematography') THEN 'cinematographer'
+            ELSE NULL
+        END AS role_key
     FROM
         watched
+        JOIN crew ON watched.movie_id = crew.movie_id
+        JOIN person ON crew.person_id = person.id
     WHERE
-        watched.user_id = ?1
-        AND watched.rating IS NOT NULL
-    GROUP BY
-        watched.movie_id
+        watched.user_id = ?
 )
 SELECT
-    CAST(COALESCE(AVG(rated_movies.average_rating), 0.0) AS REAL) AS average_user_rating,
-    CAST(COALESCE(AVG(CAST(movie.vote_average AS REAL) / 2.0), 0.0) AS REAL) AS average_tmdb_rating,
-    CAST(COALESCE(AVG(rated_movies.average_rating - (CAST(movie.vote_average AS REAL) / 2.0)), 0.0) AS REAL) AS average_difference,
-    COUNT(*) AS compared_movie_count
+    normalized_crew.role_key,
+    normalized_crew.id,
+    normalized_crew.name,
+    normalized_crew.profile_path,
+    COUNT(*) AS watch_count
 FROM
-    rated_movies
-    JOIN movie ON rated_movies.movie_id = movie.id
+    normalized_crew
 WHERE
-    movie.vote_average > 0
-    AND movie.vote_count >= ?2;
+    normalized_crew.role_key IS NOT NULL
+GROUP BY
+    normalized_crew.role_key,
+    normalized_crew.id,
+    normalized_crew.name,
+    normalized_crew.profile_path
+ORDER BY
+    normalized_crew.role_key ASC,
+    watch_count DESC,
+    normalized_crew.name ASC;
 
--- name: GetRatingByReleaseDecade :many
-WITH rated_movies AS (
-    SELECT
-        watched.movie_id,
-        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating
-    FROM
-        watched
-    WHERE
-        watched.user_id = ?1
-        AND watched.rating IS NOT NULL
-    GROUP BY
-        watched.movie_id
-)
+-- name: GetTopLanguages :many
 SELECT
-    (CAST(strftime('%Y', movie.release_date) AS INTEGER) / 10) * 10 AS decade,
-    CAST(COALESCE(AVG(rated_movies.average_rating), 0.0) AS REAL) AS average_rating,
-    COUNT(*) AS rated_movie_count
+    movie.original_language AS language,
+    COUNT(*) AS watch_count
 FROM
-    rated_movies
-    JOIN movie ON rated_movies.movie_id = movie.id
+    watched
+    JOIN movie ON watched.movie_id = movie.id
 WHERE
-    movie.release_date IS NOT NULL
+    watched.user_id = ?
+    AND movie.original_language <> ''
 GROUP BY
-    decade
+    movie.original_language
 ORDER BY
-    decade;
+    watch_count DESC,
+    language ASC
+LIMIT
+    ?;
 
--- name: GetFavoriteDirectorsByRating :many
-WITH rated_movies AS (
-    SELECT
-        watched.movie_id,
-        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating
-    FROM
-        watched
-    WHERE
-        watched.user_id = ?1
-        AND watched.rating IS NOT NULL
-    GROUP BY
-        watched.movie_id
-),
-directors AS (
-    SELECT DISTINCT
-        crew.movie_id,
-        crew.person_id
-    FROM
-        crew
-    WHERE
-        crew.job = 'Director'
-)
+-- name: GetReleaseYearDistribution :many
 SELECT
-    person.id,
-    person.name,
-    person.profile_path,
-    CAST(COALESCE(AVG(rated_movies.average_rating), 0.0) AS REAL) AS average_rating,
-    COUNT(*) AS rated_movie_count
+    CAST(strftime('%Y', movie.release_date) AS INTEGER) AS release_year,
+    COUNT(DISTINCT watched.movie_id) AS count
 FROM
-    rated_movies
-    JOIN directors ON rated_movies.movie_id = directors.movie_id
-    JOIN person ON directors.person_id = person.id
+    watched
+    JOIN movie ON watched.movie_id = movie.id
+WHERE
+    watched.user_id = ?
+    AND movie.release_date IS NOT NULL
 GROUP BY
-    person.id,
-    person.name,
-    person.profile_path
+    release_year
 ORDER BY
-    average_rating DESC,
-    rated_movie_count DESC,
-    person.name ASC;
+    release_year;
 
--- name: GetFavoriteActorsByRating :many
-WITH rated_movies AS (
-    SELECT
-        watched.movie_id,
-        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating
-    FROM
-        watched
-    WHERE
-        watched.user_id = ?1
-        AND watched.rating IS NOT NULL
-    GROUP BY
-        watched.movie_id
-),
-cast_members AS (
-    SELECT DISTINCT
-        "cast".movie_id,
-        "cast".person_id
-    FROM
-        "cast"
-)
+-- name: GetLongestWatchedMovie :one
 SELECT
-    person.id,
-    person.name,
-    person.profile_path,
-    person.gender,
-    CAST(COALESCE(AVG(rated_movies.average_rating), 0.0) AS REAL) AS average_rating,
-    COUNT(*) AS rated_movie_count
+    movie.id,
+    movie.title,
+    movie.poster_path,
+    movie.runtime
 FROM
-    rated_movies
-    JOIN cast_members ON rated_movies.movie_id = cast_members.movie_id
-    JOIN person ON cast_members.person_id = person.id
+    watched
+    JOIN movie ON watched.movie_id = movie.id
+WHERE
+    watched.user_id = ?
+    AND movie.runtime > 0
 GROUP BY
-    person.id,
-    person.name,
-    person.profile_path,
-    person.gender
+    movie.id,
+    movie.title,
+    movie.poster_path,
+    movie.runtime
 ORDER BY
-    average_rating DESC,
-    rated_movie_count DESC,
-    person.name ASC;
+    movie.runtime DESC,
+    movie.title ASC
+LIMIT
+    1;
 
--- name: GetRewatchRatingDrift :many
-WITH rated_movies AS (
-    SELECT
-        movie.id,
-        movie.title,
-        movie.poster_path,
-        COUNT(*) AS rated_watch_count,
-        MIN(watched.watched_date) AS first_watched_date,
-        MAX(watched.watched_date) AS last_watched_date,
-        (
-            SELECT
-                CAST(first_watch.rating AS REAL)
-            FROM
-                watched AS first_watch
-            WHERE
-                first_watch.user_id = ?1
-                AND first_watch.movie_id = movie.id
-                AND first_watch.rating IS NOT NULL
-            ORDER BY
-                first_watch.watched_date ASC,
-                first_watch.id ASC
-            LIMIT
-                1
-        ) AS first_rating,
-        (
-            SELECT
-                CAST(last_watch.rating AS REAL)
-            FROM
-                watched AS last_watch
-            WHERE
-                last_watch.user_id = ?1
-                AND last_watch.movie_id = movie.id
-                AND last_watch.rating IS NOT NULL
-            ORDER BY
-                last_watch.watched_date DESC,
-                last_watch.id DESC
-            LIMIT
-                1
-        ) AS last_rating
+-- name: GetShortestWatchedMovie :one
+SELECT
+    movie.id,
+    movie.title,
+    movie.poster_path,
+    movie.runtime
+FROM
+    watched
+    JOIN movie ON watched.movie_id = movie.id
+WHERE
+    watched.user_id = ?
+    AND movie.runtime > 0
+GROUP BY
+    movie.id,
+    movie.title,
+    movie.poster_path,
+    movie.runtime
+ORDER BY
+    movie.runtime ASC,
+    movie.title ASC
+LIMIT
+    1;
+
+-- name: GetBudgetTierDistribution :many
+WITH watched_movies AS (
+    SELECT DISTINCT
+        watched.movie_id,
+        movie.budget
     FROM
         watched
         JOIN movie ON watched.movie_id = movie.id
+    WHERE
+        watched.user_id = ?
+)
+SELECT
+    CASE
+        WHEN watched_movies.budget <= 0 THEN 'unknown'
+        WHEN watched_movies.budget < 10000000 THEN 'indie'
+        WHEN watched_movies.budget <= 100000000 THEN 'mid'
+        ELSE 'blockbuster'
+    END AS tier,
+    COUNT(*) AS count
+FROM
+    watched_movies
+GROUP BY
+    tier
+ORDER BY
+    CASE
+        WHEN tier = 'indie' THEN 1
+        WHEN tier = 'mid' THEN 2
+        WHEN tier = 'blockbuster' THEN 3
+        ELSE 4
+    END;
+
+-- name: GetTopReturnOnInvestmentMovies :many
+WITH watched_movies AS (
+    SELECT DISTINCT
+        watched.movie_id
+    FROM
+        watched
+    WHERE
+        watched.user_id = ?
+)
+SELECT
+    movie.id,
+    movie.title,
+    movie.poster_path,
+    movie.budget,
+    movie.revenue,
+    CAST(
+        CAST(movie.revenue - movie.budget AS REAL) / CAST(movie.budget AS REAL) AS REAL
+    ) AS roi
+FROM
+    watched_movies
+    JOIN movie ON watched_movies.movie_id = movie.id
+WHERE
+    movie.budget > 0
+    AND movie.revenue > 0
+ORDER BY
+    roi DESC,
+    movie.revenue DESC,
+    movie.title ASC
+LIMIT
+    ?;
+
+-- name: GetBiggestBudgetMovies :many
+WITH watched_movies AS (
+    SELECT DISTINCT
+        watched.movie_id
+    FROM
+        watched
+    WHERE
+        watched.user_id = ?
+)
+SELECT
+    movie.id,
+    movie.title,
+    movie.poster_path,
+    movie.budget,
+    movie.revenue,
+    CAST(
+        CASE
+            WHEN movie.budget > 0 THEN CAST(movie.revenue - movie.budget AS REAL) / CAST(movie.budget AS REAL)
+            ELSE 0.0
+        END AS REAL
+    ) AS roi
+FROM
+    watched_movies
+    JOIN movie ON watched_movies.movie_id = movie.id
+WHERE
+    movie.budget > 0
+ORDER BY
+    movie.budget DESC,
+    movie.revenue DESC,
+    movie.title ASC
+LIMIT
+    ?;
+
+-- name: GetMonthlyGenreBreakdown :many
+SELECT
+    watched.watched_date,
+    genre.name AS genre_name,
+    COUNT(*) AS movie_count
+FROM
+    watched
+    JOIN movie ON watched.movie_id = movie.id
+    JOIN genre_movie ON movie.id = genre_movie.movie_id
+    JOIN genre ON genre_movie.genre_id = genre.id
+WHERE
+    watched.watched_date >= date('now', 'start of month', '-12 months')
+    AND watched.user_id = ?
+GROUP BY
+    watched.watched_date,
+    genre_name
+ORDER BY
+    watched.watched_date,
+    movie_count DESC;
+
+-- Rating stats.
+-- name: GetRatingSummary :one
+SELECT
+    CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
+    COUNT(watched.rating) AS rated_count
+FROM
+    watched
+WHERE
+    watched.user_id = ?
+    AND watched.rating IS NOT NULL;
+
+-- name: GetRatingDistribution :many
+SELECT
+    CAST(ROUND(CAST(watched.rating AS REAL) * 2.0, 0) / 2.0 AS REAL) AS rating_bucket,
+    COUNT(*) AS count
+FROM
+    watched
+WHERE
+    watched.user_id = ?
+    AND watched.rating IS NOT NULL
+GROUP BY
+    rating_bucket
+ORDER BY
+    rating_bucket;
+
+-- name: GetMonthlyAverageRatingLastYear :many
+SELECT
+    CAST(strftime('%Y-%m', watched.watched_date) AS TEXT) AS month,
+    CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
+    COUNT(watched.rating) AS rated_count
+FROM
+    watched
+WHERE
+    watched.user_id = ?
+    AND watched.rating IS NOT NULL
+    AND watched.watched_date >= date('now', 'start of month', '-11 months')
+GROUP BY
+    month
+ORDER BY
+    month;
+
+-- name: GetTheaterVsHomeAverageRating :many
+SELECT
+    watched.watched_in_theater,
+    CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
+    COUNT(watched.rating) AS rated_count
+FROM
+    watched
+WHERE
+    watched.user_id = ?
+    AND watched.rating IS NOT NULL
+GROUP BY
+    watched.watched_in_theater;
+
+-- name: GetHighestRatedMovies :many
+WITH rated_movies AS (
+    SELECT
+        watched.movie_id,
+        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
+        COUNT(watched.rating) AS rated_watch_count
+    FROM
+        watched
     WHERE
         watched.user_id = ?1
         AND watched.rating IS NOT NULL
     GROUP BY
-        movie.id,
-        movie.title,
-        movie.poster_path
-    HAVING
-        COUNT(*) >= CAST(?2 AS INTEGER)
+        watched.movie_id
 )
 SELECT
-    rated_movies.id,
-    rated_movies.title,
-    rated_movies.poster_path,
-    rated_movies.first_rating,
-    rated_movies.last_rating,
-    CAST(rated_movies.last_rating - rated_movies.first_rating AS REAL) AS rating_change,
-    rated_movies.rated_watch_count,
-    rated_movies.first_watched_date,
-    rated_movies.last_watched_date
+    movie.id,
+    movie.title,
+    movie.poster_path,
+    rated_movies.average_rating,
+    rated_movies.rated_watch_count
 FROM
     rated_movies
-WHERE
-    ABS(rated_movies.last_rating - rated_movies.first_rating) > 0
+    JOIN movie ON rated_movies.movie_id = movie.id
+ORDER BY
+    rated_movies.average_rating DESC,
+    rated_movies.rated_watch_count DESC,
+    movie.title ASC
+LIMIT
+    ?2;
+
+-- name: GetRatingVsTMDB :one
+WITH rated_movies AS (
+    SELECT
+        watched.movie_id,
+        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating
+    FROM
+        watched
+    WHERE
+        watched.user_id = ?1
+        AND watched.rating IS NOT NULL
+    GROUP BY
+        watched.movie_id
+)
+SELECT
+    CAST(COALESCE(AVG(rated_movies.average_rating), 0.0) AS REAL) AS average_user_rating,
+    CAST(COALESCE(AVG(CAST(movie.vote_average AS REAL) / 2.0), 0.0) AS REAL) AS average_tmdb_rating,
+    CAST(COALESCE(AVG(rated_movies.average_rating - (CAST(movie.vote_average AS REAL) / 2.0)), 0.0) AS REAL) AS average_difference,
+    COUNT(*) AS compared_movie_count
+FROM
+    rated_movies
+    JOIN movie ON rated_movies.movie_id = movie.id
+WHERE
+    movie.vote_average > 0
+    AND movie.vote_count >= ?2;
+
+-- name: GetRatingByReleaseDecade :many
+WITH rated_movies AS (
+    SELECT
+        watched.movie_id,
+        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating
+    FROM
+        watched
+    WHERE
+        watched.user_id = ?1
+        AND watched.rating IS NOT NULL
+    GROUP BY
+        watched.movie_id
+)
+SELECT
+    (CAST(strftime('%Y', movie.release_date) AS INTEGER) / 10) * 10 AS decade,
+    CAST(COALESCE(AVG(rated_movies.average_rating), 0.0) AS REAL) AS average_rating,
+    COUNT(*) AS rated_movie_count
+FROM
+    rated_movies
+    JOIN movie ON rated_movies.movie_id = movie.id
+WHERE
+    movie.release_date IS NOT NULL
+GROUP BY
+    decade
+ORDER BY
+    decade;
+
+-- name: GetFavoriteDirectorsByRating :many
+WITH rated_movies AS (
+    SELECT
+        watched.movie_id,
+        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating
+    FROM
+        watched
+    WHERE
+        watched.user_id = ?1
+        AND watched.rating IS NOT NULL
+    GROUP BY
+        watched.movie_id
+),
+directors AS (
+    SELECT DISTINCT
+        crew.movie_id,
+        crew.person_id
+    FROM
+        crew
+    WHERE
+        crew.job = 'Director'
+)
+SELECT
+    person.id,
+    person.name,
+    person.profile_path,
+    CAST(COALESCE(AVG(rated_movies.average_rating), 0.0) AS REAL) AS average_rating,
+    COUNT(*) AS rated_movie_count
+FROM
+    rated_movies
+    JOIN directors ON rated_movies.movie_id = directors.movie_id
+    JOIN person ON directors.person_id = person.id
+GROUP BY
+    person.id,
+    person.name,
+    person.profile_path
+ORDER BY
+    average_rating DESC,
+    rated_movie_count DESC,
+    person.name ASC;
+
+-- name: GetFavoriteActorsByRating :many
+WITH rated_movies AS (
+    SELECT
+        watched.movie_id,
+        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating
+    FROM
+        watched
+    WHERE
+        watched.user_id = ?1
+        AND watched.rating IS NOT NULL
+    GROUP BY
+        watched.movie_id
+),
+cast_members AS (
+    SELECT DISTINCT
+        "cast".movie_id,
+        "cast".person_id
+    FROM
+        "cast"
+)
+SELECT
+    person.id,
+    person.name,
+    person.profile_path,
+    person.gender,
+    CAST(COALESCE(AVG(rated_movies.average_rating), 0.0) AS REAL) AS average_rating,
+    COUNT(*) AS rated_movie_count
+FROM
+    rated_movies
+    JOIN cast_members ON rated_movies.movie_id = cast_members.movie_id
+    JOIN person ON cast_members.person_id = person.id
+GROUP BY
+    person.id,
+    person.name,
+    person.profile_path,
+    person.gender
+ORDER BY
+    average_rating DESC,
+    rated_movie_count DESC,
+    person.name ASC;
+
+-- name: GetRewatchRatingDrift :many
+WITH rated_movies AS (
+    SELECT
+        movie.id,
+        movie.title,
+        movie.poster_path,
+        COUNT(*) AS rated_watch_count,
+        MIN(watched.watched_date) AS first_watched_date,
+        MAX(watched.watched_date) AS last_watched_date,
+        (
+            SELECT
+                CAST(first_watch.rating AS REAL)
+            FROM
+                watched AS first_watch
+            WHERE
+                first_watch.user_id = ?1
+                AND first_watch.movie_id = movie.id
+                AND first_watch.rating IS NOT NULL
+            ORDER BY
+                first_watch.watched_date ASC,
+                first_watch.id ASC
+            LIMIT
+                1
+        ) AS first_rating,
+        (
+            SELECT
+                CAST(last_watch.rating AS REAL)
+            FROM
+                watched AS last_watch
+            WHERE
+                last_watch.user_id = ?1
+                AND last_watch.movie_id = movie.id
+                AND last_watch.rating IS NOT NULL
+            ORDER BY
+                last_watch.watched_date DESC,
+                last_watch.id DESC
+            LIMIT
+                1
+        ) AS last_rating
+    FROM
+        watched
+        JOIN movie ON watched.movie_id = movie.id
+    WHERE
+        watched.user_id = ?1
+        AND watched.rating IS NOT NULL
+    GROUP BY
+        movie.id,
+        movie.title,
+        movie.poster_path
+    HAVING
+        COUNT(*) >= CAST(?2 AS INTEGER)
+)
+SELECT
+    rated_movies.id,
+    rated_movies.title,
+    rated_movies.poster_path,
+    rated_movies.first_rating,
+    rated_movies.last_rating,
+    CAST(rated_movies.last_rating - rated_movies.first_rating AS REAL) AS rating_change,
+    rated_movies.rated_watch_count,
+    rated_movies.first_watched_date,
+    rated_movies.last_watched_date
+FROM
+    rated_movies
+WHERE
+    ABS(rated_movies.last_rating - rated_movies.first_rating) > 0
+ORDER BY
+    ABS(rated_movies.last_rating - rated_movies.first_rating) DESC,
+    rated_movies.rated_watch_count DESC,
+    rated_movies.title ASC
+LIMIT
+    ?3;
+
+-- Sessions.
+-- name: CreateSession :exec
+INSERT INTO
+    session (
+        id,
+        user_id,
+        expires_at,
+        max_expires_at,
+        user_agent,
+        ip,
+        last_seen_at
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetSession :one
+SELECT
+    user_id,
+    expires_at,
+    max_expires_at,
+    last_seen_at,
+    impersonated_user_id
+FROM
+    session
+WHERE
+    id = ?
+    AND expires_at > datetime('now');
+
+-- SetSessionImpersonation sets the user an admin session views gowatch as, NULL ends it.
+-- name: SetSessionImpersonation :execrows
+UPDATE
+    session
+SET
+    impersonated_user_id = ?
+WHERE
+    id = ?;
+
+-- name: GetSessionsByUser :many
+SELECT
+    id,
+    user_id,
+    expires_at,
+    created_at,
+    user_agent,
+    ip,
+    last_seen_at,
+    max_expires_at,
+    impersonated_user_id
+FROM
+    session
+WHERE
+    user_id = ?
+    AND expires_at > datetime('now')
+ORDER BY
+    last_seen_at DESC,
+    created_at DESC;
+
+-- name: TouchSession :exec
+UPDATE
+    session
+SET
+    last_seen_at = ?,
+    expires_at = ?,
+    user_agent = ?,
+    ip = ?
+WHERE
+    id = ?;
+
+-- name: DeleteUserSession :execrows
+DELETE FROM
+    session
+WHERE
+    id = ?
+    AND user_id = ?;
+
+-- name: DeleteSessionsByUser :execrows
+DELETE FROM
+    session
+WHERE
+    user_id = ?;
+
+-- name: DeleteSession :exec
+DELETE FROM
+    session
+WHERE
+    id = ?;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM
+    session
+WHERE
+    expires_at <= datetime('now');
+
+-- Login lockout.
+-- name: RecordFailedLogin :one
+INSERT INTO
+    login_lockout (user_id, failed_count, last_failed_at)
+VALUES
+    (?, 1, ?) ON CONFLICT(user_id) DO
+UPDATE
+SET
+    failed_count = failed_count + 1,
+    last_failed_at = excluded.last_failed_at
+RETURNING
+    failed_count;
+
+-- name: LockUserLogin :exec
+UPDATE
+    login_lockout
+SET
+    locked_until = ?
+WHERE
+    user_id = ?;
+
+-- name: GetLoginLockout :one
+SELECT
+    *
+FROM
+    login_lockout
+WHERE
+    user_id = ?;
+
+-- name: DeleteLoginLockout :execrows
+DELETE FROM
+    login_lockout
+WHERE
+    user_id = ?;
+
+-- Users and authentication.
+-- name: CreateUser :one
+INSERT INTO
+    user (email, name, password_hash, created_at)
+VALUES
+    (?, ?, ?, datetime('now'))
+RETURNING
+    *;
+
+-- name: GetUserByEmail :one
+SELECT
+    *
+FROM
+    user
+WHERE
+    email = ?;
+
+-- name: GetUserByID :one
+SELECT
+    *
+FROM
+    user
+WHERE
+    id = ?;
+
+-- name: CountUsers :one
+SELECT
+    COUNT(*)
+FROM
+    user;
+
+-- Admin and maintenance.
+-- name: AssignNilUserWatched :exec
+UPDATE
+    watched
+SET
+    user_id = ?
+WHERE
+    user_id IS NULL;
+
+-- name: AssignNilUserLists :exec
+UPDATE
+    list
+SET
+    user_id = ?
+WHERE
+    user_id IS NULL;
+
+-- name: SetAdmin :exec
+UPDATE
+    user
+SET
+    admin = TRUE
+WHERE
+    id = ?;
+
+-- name: UnsetAdmin :exec
+UPDATE
+    user
+SET
+    admin = FALSE
+WHERE
+    id = ?;
+
+-- name: GetAllUsersWithStats :many
+SELECT
+    u.id,
+    u.email,
+    u.name,
+    u.created_at,
+    u.admin,
+    u.disabled_at,
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            watched w
+        WHERE
+            w.user_id = u.id
+    ) AS watched_count,
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            list l
+        WHERE
+            l.user_id = u.id
+    ) AS list_count,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            user_totp t
+        WHERE
+            t.user_id = u.id
+            AND t.enabled
+    ) AS totp_enabled,
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            session s
+        WHERE
+            s.user_id = u.id
+            AND s.expires_at > datetime('now')
+    ) AS session_count,
+    ll.failed_count,
+    ll.locked_until
+FROM
+    user u
+    LEFT JOIN login_lockout ll ON ll.user_id = u.id
+ORDER BY
+    u.created_at DESC;
+
+-- name: DeleteUser :exec
+DELETE FROM
+    user
+WHERE
+    id = ?;
+
+-- name: UpdateUserPassword :exec
+UPDATE
+    user
+SET
+    password_hash = ?
+WHERE
+    id = ?;
+
+-- name: UpdatePasswordResetRequired :exec
+UPDATE
+    user
+SET
+    password_reset_required = ?
+WHERE
+    id = ?;
+
+-- name: UpdateUserProfile :exec
+UPDATE
+    user
+SET
+    name = ?,
+    email = ?
+WHERE
+    id = ?;
+
+-- CountAdmins counts the admins that can still sign in.
+-- name: CountAdmins :one
+SELECT
+    COUNT(*)
+FROM
+    user
+WHERE
+    admin
+    AND disabled_at IS NULL;
+
+-- name: SetUserDisabledAt :exec
+UPDATE
+    user
+SET
+    disabled_at = ?
+WHERE
+    id = ?;
+
+-- Self-service account deletion.
+-- name: SetUserDeletionScheduledAt :exec
+UPDATE
+    user
+SET
+    deletion_scheduled_at = ?
+WHERE
+    id = ?;
+
+-- name: GetUsersDueForDeletion :many
+SELECT
+    id
+FROM
+    user
+WHERE
+    deletion_scheduled_at IS NOT NULL
+    AND deletion_scheduled_at <= sqlc.arg(now);
+
+-- name: GetAllListsWithMovies :many
+SELECT
+    sqlc.embed(list),
+    sqlc.embed(movie),
+    sqlc.embed(list_movie)
+FROM
+    list
+    LEFT JOIN list_movie ON list_movie.list_id = list.id
+    LEFT JOIN movie ON movie.id = list_movie.movie_id
+WHERE
+    list.user_id = ?
+ORDER BY
+    list.id,
+    list_movie.date_added,
+    list_movie.movie_id;
+
+-- Webhooks.
+-- name: InsertWebhook :one
+INSERT INTO
+    webhook (user_id, url, secret, events, enabled, created_at)
+VALUES
+    (?, ?, ?, ?, TRUE, datetime('now'))
+RETURNING
+    *;
+
+-- name: GetWebhooksByUser :many
+SELECT
+    *
+FROM
+    webhook
+WHERE
+    user_id = ?
+ORDER BY
+    id;
+
+-- name: GetEnabledWebhooksByUser :many
+SELECT
+    *
+FROM
+    webhook
+WHERE
+    user_id = ?
+    AND enabled = TRUE
 ORDER BY
-    ABS(rated_movies.last_rating - rated_movies.first_rating) DESC,
-    rated_movies.rated_watch_count DESC,
-    rated_movies.title ASC
-LIMIT
-    ?3;
+    id;
 
--- Sessions.
--- name: CreateSession :exec
+-- name: SetWebhookEnabled :execrows
+UPDATE
+    webhook
+SET
+    enabled = ?
+WHERE
+    id = ?
+    AND user_id = ?;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM
+    webhook
+WHERE
+    id = ?
+    AND user_id = ?;
+
+-- name: InsertWebhookDelivery :exec
 INSERT INTO
-    session (id, user_id, expires_at)
+    webhook_delivery (
+        webhook_id,
+        event,
+        payload,
+        status,
+        attempts,
+        next_attempt_at,
+        created_at
+    )
 VALUES
-    (?, ?, ?);
+    (?, ?, ?, 'pending', 0, ?, ?);
 
--- name: GetSession :one
+-- name: GetDueWebhookDeliveries :many
 SELECT
-    user_id,
-    expires_at
+    sqlc.embed(webhook_delivery),
+    webhook.url,
+    webhook.secret
 FROM
-    session
+    webhook_delivery
+    JOIN webhook ON webhook.id = webhook_delivery.webhook_id
 WHERE
-    id = ?
-    AND expires_at > datetime('now');
+    webhook_delivery.status = 'pending'
+    AND webhook_delivery.next_attempt_at <= ?
+ORDER BY
+    webhook_delivery.next_attempt_at,
+    webhook_delivery.id
+LIMIT
+    ?;
 
--- name: DeleteSession :exec
-DELETE FROM
-    session
+-- name: UpdateWebhookDeliveryAttempt :exec
+UPDATE
+    webhook_delivery
+SET
+    status = ?,
+    attempts = ?,
+    next_attempt_at = ?,
+    last_attempt_at = ?,
+    response_status = ?,
+    last_error = ?
 WHERE
     id = ?;
 
--- name: DeleteExpiredSessions :exec
-DELETE FROM
-    session
+-- name: GetWebhookDeliveriesByUser :many
+SELECT
+    webhook_delivery.id,
+    webhook_delivery.webhook_id,
+    webhook.url,
+    webhook_delivery.event,
+    webhook_delivery.status,
+    webhook_delivery.attempts,
+    webhook_delivery.next_attempt_at,
+    webhook_delivery.last_attempt_at,
+    webhook_delivery.response_status,
+    webhook_delivery.last_error,
+    webhook_delivery.created_at
+FROM
+    webhook_delivery
+    JOIN webhook ON webhook.id = webhook_delivery.webhook_id
 WHERE
-    expires_at <= datetime('now');
+    webhook.user_id = ?
+ORDER BY
+    webhook_delivery.created_at DESC,
+    webhook_delivery.id DESC
+LIMIT
+    ?;
 
--- Users and authentication.
--- name: CreateUser :one
+-- Registration invites.
+-- name: CreateInvite :one
 INSERT INTO
-    user (email, name, password_hash, created_at)
+    invite (token_hash, created_by, created_at, expires_at)
 VALUES
-    (?, ?, ?, datetime('now'))
+    (?, ?, ?, ?)
 RETURNING
     *;
 
--- name: GetUserByEmail :one
+-- name: GetInvites :many
 SELECT
-    *
+    invite.id,
+    invite.created_at,
+    invite.expires_at,
+    invite.used_at,
+    creator.name AS created_by_name,
+    redeemer.email AS used_by_email
 FROM
-    user
-WHERE
-    email = ?;
+    invite
+    LEFT JOIN user creator ON creator.id = invite.created_by
+    LEFT JOIN user redeemer ON redeemer.id = invite.used_by
+ORDER BY
+    invite.created_at DESC,
+    invite.id DESC;
 
--- name: GetUserByID :one
+-- name: GetInviteByTokenHash :one
 SELECT
     *
 FROM
-    user
+    invite
+WHERE
+    token_hash = ?;
+
+-- ClaimInvite marks an unused, unexpired invite as used so it cannot be redeemed twice.
+-- name: ClaimInvite :one
+UPDATE
+    invite
+SET
+    used_at = sqlc.arg(now)
+WHERE
+    token_hash = sqlc.arg(token_hash)
+    AND used_at IS NULL
+    AND expires_at > sqlc.arg(now)
+RETURNING
+    id;
+
+-- name: ReleaseInvite :exec
+UPDATE
+    invite
+SET
+    used_at = NULL
+WHERE
+    id = ?
+    AND used_by IS NULL;
+
+-- name: SetInviteUsedBy :exec
+UPDATE
+    invite
+SET
+    used_by = ?
 WHERE
     id = ?;
 
--- name: CountUsers :one
+-- name: DeleteInvite :execrows
+DELETE FROM
+    invite
+WHERE
+    id = ?;
+
+-- External identities.
+-- name: GetUserByIdentity :one
 SELECT
-    COUNT(*)
+    u.*
 FROM
-    user;
+    user u
+    JOIN user_identity ui ON ui.user_id = u.id
+WHERE
+    ui.issuer = ?
+    AND ui.subject = ?;
 
--- Admin and maintenance.
--- name: AssignNilUserWatched :exec
+-- name: CreateUserIdentity :exec
+INSERT INTO
+    user_identity (user_id, issuer, subject, created_at)
+VALUES
+    (?, ?, ?, ?);
+
+-- Two-factor authentication.
+-- UpsertUserTOTP stores a new, not yet confirmed secret, replacing any previous one.
+-- name: UpsertUserTOTP :exec
+INSERT INTO
+    user_totp (user_id, secret, enabled, created_at)
+VALUES
+    (?, ?, FALSE, ?) ON CONFLICT(user_id) DO
 UPDATE
-    watched
 SET
-    user_id = ?
+    secret = excluded.secret,
+    enabled = FALSE,
+    last_step = NULL,
+    created_at = excluded.created_at;
+
+-- name: GetUserTOTP :one
+SELECT
+    *
+FROM
+    user_totp
 WHERE
-    user_id IS NULL;
+    user_id = ?;
 
--- name: AssignNilUserLists :exec
+-- name: EnableUserTOTP :exec
 UPDATE
-    list
+    user_totp
+SET
+    enabled = TRUE
+WHERE
+    user_id = ?;
+
+-- name: DeleteUserTOTP :execrows
+DELETE FROM
+    user_totp
+WHERE
+    user_id = ?;
+
+-- UseTOTPStep records an accepted time step, affecting no rows if it (or a later one) was already used.
+-- name: UseTOTPStep :execrows
+UPDATE
+    user_totp
+SET
+    last_step = sqlc.arg(step)
+WHERE
+    user_id = sqlc.arg(user_id)
+    AND (
+        last_step IS NULL
+        OR last_step < sqlc.arg(step)
+    );
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO
+    recovery_code (user_id, code_hash)
+VALUES
+    (?, ?);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM
+    recovery_code
+WHERE
+    user_id = ?;
+
+-- name: UseRecoveryCode :execrows
+UPDATE
+    recovery_code
 SET
+    used_at = ?
+WHERE
     user_id = ?
+    AND code_hash = ?
+    AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT
+    COUNT(*)
+FROM
+    recovery_code
 WHERE
-    user_id IS NULL;
+    user_id = ?
+    AND used_at IS NULL;
 
--- name: SetAdmin :exec
+-- name: CreateMFAChallenge :exec
+INSERT INTO
+    mfa_challenge (token_hash, user_id, expires_at)
+VALUES
+    (?, ?, ?);
+
+-- name: GetMFAChallenge :one
+SELECT
+    *
+FROM
+    mfa_challenge
+WHERE
+    token_hash = ?
+    AND expires_at > ?;
+
+-- name: IncrementMFAChallengeAttempts :one
 UPDATE
-    user
+    mfa_challenge
 SET
-    admin = TRUE
+    attempts = attempts + 1
 WHERE
-    id = ?;
+    token_hash = ?
+RETURNING
+    attempts;
 
--- name: GetAllUsersWithStats :many
+-- name: DeleteMFAChallenge :exec
+DELETE FROM
+    mfa_challenge
+WHERE
+    token_hash = ?;
+
+-- name: DeleteExpiredMFAChallenges :exec
+DELETE FROM
+    mfa_challenge
+WHERE
+    expires_at <= ?;
+
+-- Passkeys.
+-- name: CreatePasskey :one
+INSERT INTO
+    passkey (
+        user_id,
+        credential_id,
+        public_key,
+        sign_count,
+        backup_eligible,
+        name,
+        created_at
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?)
+RETURNING
+    *;
+
+-- name: GetPasskeysByUser :many
 SELECT
-    u.id,
-    u.email,
-    u.name,
-    u.created_at,
-    u.admin,
-    (
-        SELECT
-            COUNT(*)
-        FROM
-            watched w
-        WHERE
-            w.user_id = u.id
-    ) AS watched_count,
-    (
-        SELECT
-            COUNT(*)
-        FROM
-            list l
-        WHERE
-            l.user_id = u.id
-    ) AS list_count
+    *
 FROM
-    user u
+    passkey
+WHERE
+    user_id = ?
 ORDER BY
-    u.created_at DESC;
+    created_at DESC,
+    id DESC;
 
--- name: DeleteUser :exec
-DELETE FROM
-    user
+-- name: GetPasskeyByCredentialID :one
+SELECT
+    *
+FROM
+    passkey
 WHERE
-    id = ?;
+    credential_id = ?;
 
--- name: UpdateUserPassword :exec
+-- name: UpdatePasskeyUsage :exec
 UPDATE
-    user
+    passkey
 SET
-    password_hash = ?
+    sign_count = ?,
+    last_used_at = ?
 WHERE
     id = ?;
 
--- name: UpdatePasswordResetRequired :exec
+-- name: RenamePasskey :execrows
 UPDATE
-    user
+    passkey
 SET
-    password_reset_required = ?
+    name = ?
 WHERE
-    id = ?;
+    id = ?
+    AND user_id = ?;
 
--- name: GetAllListsWithMovies :many
+-- name: DeletePasskey :execrows
+DELETE FROM
+    passkey
+WHERE
+    id = ?
+    AND user_id = ?;
+
+-- name: CreateWebAuthnChallenge :exec
+INSERT INTO
+    webauthn_challenge (challenge, user_id, expires_at)
+VALUES
+    (?, ?, ?);
+
+-- ClaimWebAuthnChallenge consumes an unexpired challenge, so each ceremony can only complete once.
+-- name: ClaimWebAuthnChallenge :one
+DELETE FROM
+    webauthn_challenge
+WHERE
+    challenge = ?
+    AND expires_at > ?
+RETURNING
+    user_id;
+
+-- name: DeleteExpiredWebAuthnChallenges :exec
+DELETE FROM
+    webauthn_challenge
+WHERE
+    expires_at <= ?;
+
+-- Audit log.
+-- name: InsertAuditEntry :exec
+INSERT INTO
+    audit_log (
+        action,
+        actor_id,
+        actor_email,
+        target_id,
+        target_email,
+        ip,
+        detail,
+        created_at
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- ListAuditEntries returns the newest entries first. Empty filters match everything, user
+-- matches the actor or the target and search matches emails, IP and detail.
+-- name: ListAuditEntries :many
 SELECT
-    sqlc.embed(list),
-    sqlc.embed(movie),
-    sqlc.embed(list_movie)
+    *
 FROM
-    list
-    LEFT JOIN list_movie ON list_movie.list_id = list.id
-    LEFT JOIN movie ON movie.id = list_movie.movie_id
+    audit_log
 WHERE
-    list.user_id = ?
+    (
+        CAST(sqlc.arg(action) AS TEXT) = ''
+        OR action = sqlc.arg(action)
+    )
+    AND (
+        CAST(sqlc.arg(user_id) AS INTEGER) = 0
+        OR actor_id = sqlc.arg(user_id)
+        OR target_id = sqlc.arg(user_id)
+    )
+    AND (
+        CAST(sqlc.arg(search) AS TEXT) = ''
+        OR actor_email LIKE '%' || sqlc.arg(search) || '%'
+        OR target_email LIKE '%' || sqlc.arg(search) || '%'
+        OR ip LIKE '%' || sqlc.arg(search) || '%'
+        OR detail LIKE '%' || sqlc.arg(search) || '%'
+    )
+    AND created_at >= sqlc.arg(since)
+    AND created_at < sqlc.arg(until)
 ORDER BY
-    list.id,
-    list_movie.date_added,
-    list_movie.movie_id;
+    created_at DESC,
+    id DESC
+LIMIT
+    sqlc.arg(max_entries);
+
+-- Password reset links.
+-- name: CreatePasswordReset :exec
+INSERT INTO
+    password_reset (token_hash, user_id, created_at, expires_at)
+VALUES
+    (?, ?, ?, ?);
+
+-- name: CountPasswordResetsSince :one
+SELECT
+    COUNT(*)
+FROM
+    password_reset
+WHERE
+    user_id = ?
+    AND created_at > ?;
+
+-- name: GetPasswordResetUserID :one
+SELECT
+    user_id
+FROM
+    password_reset
+WHERE
+    token_hash = sqlc.arg(token_hash)
+    AND used_at IS NULL
+    AND expires_at > sqlc.arg(now);
+
+-- ClaimPasswordReset marks an unused, unexpired reset link as used so it cannot be used twice.
+-- name: ClaimPasswordReset :one
+UPDATE
+    password_reset
+SET
+    used_at = sqlc.arg(now)
+WHERE
+    token_hash = sqlc.arg(token_hash)
+    AND used_at IS NULL
+    AND expires_at > sqlc.arg(now)
+RETURNING
+    user_id;
+
+-- name: DeletePasswordResetsByUser :exec
+DELETE FROM
+    password_reset
+WHERE
+    user_id = ?;
+
+-- name: DeleteExpiredPasswordResets :exec
+DELETE FROM
+    password_reset
+WHERE
+    expires_at <= ?;
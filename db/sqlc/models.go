@@ -10,6 +10,18 @@ import (
 	"github.com/marcosalvi-01/gowatch/db/types/date"
 )
 
+type AuditLog struct {
+	ID          int64
+	Action      string
+	ActorID     *int64
+	ActorEmail  string
+	TargetID    *int64
+	TargetEmail string
+	Ip          string
+	Detail      string
+	CreatedAt   time.Time
+}
+
 type Cast struct {
 	MovieID   int64
 	PersonID  int64
@@ -41,6 +53,16 @@ type GenreMovie struct {
 	UpdatedAt *time.Time
 }
 
+type Invite struct {
+	ID        int64
+	TokenHash string
+	CreatedBy *int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	UsedBy    *int64
+}
+
 type List struct {
 	ID           int64
 	Name         string
@@ -48,14 +70,82 @@ type List struct {
 	Description  *string
 	UserID       *int64
 	IsWatchlist  bool
+	Ranked       bool
+	ShareToken   *string
+	ShareNotes   bool
+}
+
+type ListMember struct {
+	ListID  int64
+	UserID  int64
+	Role    string
+	AddedAt time.Time
 }
 
 type ListMovie struct {
-	MovieID   int64
-	ListID    int64
-	DateAdded string
-	Position  *int64
-	Note      *string
+	MovieID     int64
+	ListID      int64
+	DateAdded   string
+	Position    *int64
+	Note        *string
+	AddedBy     *int64
+	Priority    string
+	AvailableOn *string
+}
+
+type ListMovieTag struct {
+	MovieID int64
+	ListID  int64
+	Tag     string
+}
+
+type ListRule struct {
+	ListID            int64
+	Watched           *bool
+	InTheater         *bool
+	WatchedYear       *int64
+	MinRating         *float64
+	MinRuntime        *int64
+	MaxRuntime        *int64
+	ReleaseYearFrom   *int64
+	ReleaseYearTo     *int64
+	GenreID           *int64
+	InWatchlist       *bool
+	DirectorMinRating *float64
+	PersonName        *string
+}
+
+type ListSource struct {
+	ListID   int64
+	Kind     string
+	TmdbID   int64
+	Sync     bool
+	SyncedAt time.Time
+}
+
+type ListView struct {
+	UserID   int64
+	ListID   int64
+	Sort     string
+	SortDesc bool
+	GenreID  *int64
+	Watched  *bool
+	Decade   *int64
+	Layout   string
+}
+
+type LoginLockout struct {
+	UserID       int64
+	FailedCount  int64
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+}
+
+type MfaChallenge struct {
+	TokenHash string
+	UserID    int64
+	ExpiresAt time.Time
+	Attempts  int64
 }
 
 type Movie struct {
@@ -80,6 +170,26 @@ type Movie struct {
 	UpdatedAt        *time.Time
 }
 
+type Passkey struct {
+	ID             int64
+	UserID         int64
+	CredentialID   string
+	PublicKey      []byte
+	SignCount      int64
+	BackupEligible bool
+	Name           string
+	CreatedAt      time.Time
+	LastUsedAt     *time.Time
+}
+
+type PasswordReset struct {
+	TokenHash string
+	UserID    int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
 type Person struct {
 	ID                 int64
 	Name               string
@@ -92,11 +202,23 @@ type Person struct {
 	UpdatedAt          *time.Time
 }
 
+type RecoveryCode struct {
+	ID       int64
+	UserID   int64
+	CodeHash string
+	UsedAt   *time.Time
+}
+
 type Session struct {
-	ID        string
-	UserID    int64
-	ExpiresAt time.Time
-	CreatedAt *time.Time
+	ID                 string
+	UserID             int64
+	ExpiresAt          time.Time
+	CreatedAt          *time.Time
+	UserAgent          string
+	Ip                 string
+	LastSeenAt         *time.Time
+	MaxExpiresAt       time.Time
+	ImpersonatedUserID *int64
 }
 
 type User struct {
@@ -107,6 +229,31 @@ type User struct {
 	CreatedAt             *time.Time
 	Admin                 bool
 	PasswordResetRequired bool
+	DeletionScheduledAt   *time.Time
+	DisabledAt            *time.Time
+}
+
+type UserIdentity struct {
+	ID        int64
+	UserID    int64
+	Issuer    string
+	Subject   string
+	CreatedAt time.Time
+}
+
+type UserTotp struct {
+	UserID    int64
+	Secret    string
+	Enabled   bool
+	LastStep  *int64
+	CreatedAt time.Time
+}
+
+type WatchNextFilter struct {
+	UserID      int64
+	MinPriority string
+	Tag         *string
+	ExcludeTag  *string
 }
 
 type Watched struct {
@@ -117,3 +264,33 @@ type Watched struct {
 	WatchedInTheater bool
 	Rating           *float64
 }
+
+type WebauthnChallenge struct {
+	Challenge string
+	UserID    *int64
+	ExpiresAt time.Time
+}
+
+type Webhook struct {
+	ID        int64
+	UserID    int64
+	Url       string
+	Secret    string
+	Events    string
+	Enabled   bool
+	CreatedAt *time.Time
+}
+
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int64
+	Event          string
+	Payload        string
+	Status         string
+	Attempts       int64
+	NextAttemptAt  time.Time
+	LastAttemptAt  *time.Time
+	ResponseStatus *int64
+	LastError      *string
+	CreatedAt      time.Time
+}
@@ -7,6 +7,7 @@ package sqlc
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/marcosalvi-01/gowatch/db/types/date"
@@ -19,40 +20,52 @@ INSERT INTO
         list_id,
         date_added,
         position,
-        note
+        note,
+        added_by
     )
 SELECT
-    ?,
-    ?,
-    ?,
-    ?,
-    ?
+    ?1,
+    list.id,
+    ?2,
+    ?3,
+    ?4,
+    ?5
 FROM
     list
 WHERE
-    list.id = ?
-    AND list.user_id = ?
+    list.id = ?6
+    AND (
+        list.user_id = ?5
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                list_member
+            WHERE
+                list_member.list_id = list.id
+                AND list_member.user_id = ?5
+                AND list_member.role = 'editor'
+        )
+    )
 `
 
 type AddMovieToListParams struct {
 	MovieID   int64
-	ListID    int64
 	DateAdded string
 	Position  *int64
 	Note      *string
-	ID        int64
 	UserID    *int64
+	ListID    int64
 }
 
 func (q *Queries) AddMovieToList(ctx context.Context, arg AddMovieToListParams) error {
 	_, err := q.db.ExecContext(ctx, addMovieToList,
 		arg.MovieID,
-		arg.ListID,
 		arg.DateAdded,
 		arg.Position,
 		arg.Note,
-		arg.ID,
 		arg.UserID,
+		arg.ListID,
 	)
 	return err
 }
@@ -86,6 +99,226 @@ func (q *Queries) AssignNilUserWatched(ctx context.Context, userID *int64) error
 	return err
 }
 
+const claimInvite = `-- name: ClaimInvite :one
+UPDATE
+    invite
+SET
+    used_at = ?1
+WHERE
+    token_hash = ?2
+    AND used_at IS NULL
+    AND expires_at > ?1
+RETURNING
+    id
+`
+
+type ClaimInviteParams struct {
+	Now       *time.Time
+	TokenHash string
+}
+
+// ClaimInvite marks an unused, unexpired invite as used so it cannot be redeemed twice.
+func (q *Queries) ClaimInvite(ctx context.Context, arg ClaimInviteParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, claimInvite, arg.Now, arg.TokenHash)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const claimPasswordReset = `-- name: ClaimPasswordReset :one
+UPDATE
+    password_reset
+SET
+    used_at = ?1
+WHERE
+    token_hash = ?2
+    AND used_at IS NULL
+    AND expires_at > ?1
+RETURNING
+    user_id
+`
+
+type ClaimPasswordResetParams struct {
+	Now       *time.Time
+	TokenHash string
+}
+
+// ClaimPasswordReset marks an unused, unexpired reset link as used so it cannot be used twice.
+func (q *Queries) ClaimPasswordReset(ctx context.Context, arg ClaimPasswordResetParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, claimPasswordReset, arg.Now, arg.TokenHash)
+	var user_id int64
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const claimWebAuthnChallenge = `-- name: ClaimWebAuthnChallenge :one
+DELETE FROM
+    webauthn_challenge
+WHERE
+    challenge = ?
+    AND expires_at > ?
+RETURNING
+    user_id
+`
+
+type ClaimWebAuthnChallengeParams struct {
+	Challenge string
+	ExpiresAt time.Time
+}
+
+// ClaimWebAuthnChallenge consumes an unexpired challenge, so each ceremony can only complete once.
+func (q *Queries) ClaimWebAuthnChallenge(ctx context.Context, arg ClaimWebAuthnChallengeParams) (*int64, error) {
+	row := q.db.QueryRowContext(ctx, claimWebAuthnChallenge, arg.Challenge, arg.ExpiresAt)
+	var user_id *int64
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const copyListMovies = `-- name: CopyListMovies :exec
+INSERT INTO
+    list_movie (
+        movie_id,
+        list_id,
+        date_added,
+        position,
+        note,
+        added_by
+    )
+SELECT
+    source.movie_id,
+    ?1,
+    source.date_added,
+    source.position,
+    source.note,
+    ?2
+FROM
+    list_movie source
+WHERE
+    source.list_id = ?3
+    AND source.movie_id IN (/*SLICE:movie_ids*/?)
+ON CONFLICT(movie_id, list_id) DO
+UPDATE
+SET
+    note = COALESCE(list_movie.note, excluded.note)
+`
+
+type CopyListMoviesParams struct {
+	ToListID   int64
+	UserID     *int64
+	FromListID int64
+	MovieIds   []int64
+}
+
+// Copies movies of a list to another one keeping their date added, position and note.
+// Movies already in the target keep their entry, taking the note of the copy when they
+// have none.
+func (q *Queries) CopyListMovies(ctx context.Context, arg CopyListMoviesParams) error {
+	query := copyListMovies
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.ToListID)
+	queryParams = append(queryParams, arg.UserID)
+	queryParams = append(queryParams, arg.FromListID)
+	if len(arg.MovieIds) > 0 {
+		for _, v := range arg.MovieIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:movie_ids*/?", strings.Repeat(",?", len(arg.MovieIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:movie_ids*/?", "NULL", 1)
+	}
+	_, err := q.db.ExecContext(ctx, query, queryParams...)
+	return err
+}
+
+const countAdmins = `-- name: CountAdmins :one
+SELECT
+    COUNT(*)
+FROM
+    user
+WHERE
+    admin
+    AND disabled_at IS NULL
+`
+
+// CountAdmins counts the admins that can still sign in.
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countListMembers = `-- name: CountListMembers :one
+SELECT
+    COUNT(*)
+FROM
+    list_member
+WHERE
+    list_id = ?
+`
+
+func (q *Queries) CountListMembers(ctx context.Context, listID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countListMembers, listID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countListMovies = `-- name: CountListMovies :one
+SELECT
+    COUNT(*)
+FROM
+    list_movie
+WHERE
+    list_id = ?
+`
+
+func (q *Queries) CountListMovies(ctx context.Context, listID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countListMovies, listID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countPasswordResetsSince = `-- name: CountPasswordResetsSince :one
+SELECT
+    COUNT(*)
+FROM
+    password_reset
+WHERE
+    user_id = ?
+    AND created_at > ?
+`
+
+type CountPasswordResetsSinceParams struct {
+	UserID    int64
+	CreatedAt time.Time
+}
+
+func (q *Queries) CountPasswordResetsSince(ctx context.Context, arg CountPasswordResetsSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPasswordResetsSince, arg.UserID, arg.CreatedAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUnusedRecoveryCodes = `-- name: CountUnusedRecoveryCodes :one
+SELECT
+    COUNT(*)
+FROM
+    recovery_code
+WHERE
+    user_id = ?
+    AND used_at IS NULL
+`
+
+func (q *Queries) CountUnusedRecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnusedRecoveryCodes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countUsers = `-- name: CountUsers :one
 SELECT
     COUNT(*)
@@ -100,22 +333,192 @@ func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
 	return count, err
 }
 
-const createSession = `-- name: CreateSession :exec
+const createInvite = `-- name: CreateInvite :one
+INSERT INTO
+    invite (token_hash, created_by, created_at, expires_at)
+VALUES
+    (?, ?, ?, ?)
+RETURNING
+    id, token_hash, created_by, created_at, expires_at, used_at, used_by
+`
+
+type CreateInviteParams struct {
+	TokenHash string
+	CreatedBy *int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Registration invites.
+func (q *Queries) CreateInvite(ctx context.Context, arg CreateInviteParams) (Invite, error) {
+	row := q.db.QueryRowContext(ctx, createInvite,
+		arg.TokenHash,
+		arg.CreatedBy,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	var i Invite
+	err := row.Scan(
+		&i.ID,
+		&i.TokenHash,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.UsedBy,
+	)
+	return i, err
+}
+
+const createMFAChallenge = `-- name: CreateMFAChallenge :exec
 INSERT INTO
-    session (id, user_id, expires_at)
+    mfa_challenge (token_hash, user_id, expires_at)
 VALUES
     (?, ?, ?)
 `
 
-type CreateSessionParams struct {
-	ID        string
+type CreateMFAChallengeParams struct {
+	TokenHash string
+	UserID    int64
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) error {
+	_, err := q.db.ExecContext(ctx, createMFAChallenge, arg.TokenHash, arg.UserID, arg.ExpiresAt)
+	return err
+}
+
+const createPasskey = `-- name: CreatePasskey :one
+INSERT INTO
+    passkey (
+        user_id,
+        credential_id,
+        public_key,
+        sign_count,
+        backup_eligible,
+        name,
+        created_at
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?)
+RETURNING
+    id, user_id, credential_id, public_key, sign_count, backup_eligible, name, created_at, last_used_at
+`
+
+type CreatePasskeyParams struct {
+	UserID         int64
+	CredentialID   string
+	PublicKey      []byte
+	SignCount      int64
+	BackupEligible bool
+	Name           string
+	CreatedAt      time.Time
+}
+
+// Passkeys.
+func (q *Queries) CreatePasskey(ctx context.Context, arg CreatePasskeyParams) (Passkey, error) {
+	row := q.db.QueryRowContext(ctx, createPasskey,
+		arg.UserID,
+		arg.CredentialID,
+		arg.PublicKey,
+		arg.SignCount,
+		arg.BackupEligible,
+		arg.Name,
+		arg.CreatedAt,
+	)
+	var i Passkey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.SignCount,
+		&i.BackupEligible,
+		&i.Name,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const createPasswordReset = `-- name: CreatePasswordReset :exec
+INSERT INTO
+    password_reset (token_hash, user_id, created_at, expires_at)
+VALUES
+    (?, ?, ?, ?)
+`
+
+type CreatePasswordResetParams struct {
+	TokenHash string
 	UserID    int64
+	CreatedAt time.Time
 	ExpiresAt time.Time
 }
 
+// Password reset links.
+func (q *Queries) CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) error {
+	_, err := q.db.ExecContext(ctx, createPasswordReset,
+		arg.TokenHash,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO
+    recovery_code (user_id, code_hash)
+VALUES
+    (?, ?)
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID   int64
+	CodeHash string
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
+const createSession = `-- name: CreateSession :exec
+INSERT INTO
+    session (
+        id,
+        user_id,
+        expires_at,
+        max_expires_at,
+        user_agent,
+        ip,
+        last_seen_at
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateSessionParams struct {
+	ID           string
+	UserID       int64
+	ExpiresAt    time.Time
+	MaxExpiresAt time.Time
+	UserAgent    string
+	Ip           string
+	LastSeenAt   *time.Time
+}
+
 // Sessions.
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) error {
-	_, err := q.db.ExecContext(ctx, createSession, arg.ID, arg.UserID, arg.ExpiresAt)
+	_, err := q.db.ExecContext(ctx, createSession,
+		arg.ID,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.MaxExpiresAt,
+		arg.UserAgent,
+		arg.Ip,
+		arg.LastSeenAt,
+	)
 	return err
 }
 
@@ -125,7 +528,7 @@ INSERT INTO
 VALUES
     (?, ?, ?, datetime('now'))
 RETURNING
-    id, email, password_hash, name, created_at, admin, password_reset_required
+    id, email, password_hash, name, created_at, admin, password_reset_required, deletion_scheduled_at, disabled_at
 `
 
 type CreateUserParams struct {
@@ -146,61 +549,288 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.Admin,
 		&i.PasswordResetRequired,
+		&i.DeletionScheduledAt,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
-const deleteExpiredSessions = `-- name: DeleteExpiredSessions :exec
-DELETE FROM
-    session
-WHERE
-    expires_at <= datetime('now')
+const createUserIdentity = `-- name: CreateUserIdentity :exec
+INSERT INTO
+    user_identity (user_id, issuer, subject, created_at)
+VALUES
+    (?, ?, ?, ?)
 `
 
-func (q *Queries) DeleteExpiredSessions(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, deleteExpiredSessions)
+type CreateUserIdentityParams struct {
+	UserID    int64
+	Issuer    string
+	Subject   string
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, createUserIdentity,
+		arg.UserID,
+		arg.Issuer,
+		arg.Subject,
+		arg.CreatedAt,
+	)
 	return err
 }
 
-const deleteListByID = `-- name: DeleteListByID :exec
-DELETE FROM
-    list
-WHERE
-    user_id = ?
-    AND id = ?
+const createWebAuthnChallenge = `-- name: CreateWebAuthnChallenge :exec
+INSERT INTO
+    webauthn_challenge (challenge, user_id, expires_at)
+VALUES
+    (?, ?, ?)
 `
 
-type DeleteListByIDParams struct {
-	UserID *int64
-	ID     int64
+type CreateWebAuthnChallengeParams struct {
+	Challenge string
+	UserID    *int64
+	ExpiresAt time.Time
 }
 
-func (q *Queries) DeleteListByID(ctx context.Context, arg DeleteListByIDParams) error {
-	_, err := q.db.ExecContext(ctx, deleteListByID, arg.UserID, arg.ID)
+func (q *Queries) CreateWebAuthnChallenge(ctx context.Context, arg CreateWebAuthnChallengeParams) error {
+	_, err := q.db.ExecContext(ctx, createWebAuthnChallenge, arg.Challenge, arg.UserID, arg.ExpiresAt)
 	return err
 }
 
-const deleteMovieFromList = `-- name: DeleteMovieFromList :exec
+const deleteExpiredMFAChallenges = `-- name: DeleteExpiredMFAChallenges :exec
 DELETE FROM
-    list_movie
+    mfa_challenge
 WHERE
-    list_id = ?
-    AND movie_id = ?
-    AND EXISTS (
-        SELECT
-            1
-        FROM
-            list
+    expires_at <= ?
+`
+
+func (q *Queries) DeleteExpiredMFAChallenges(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredMFAChallenges, expiresAt)
+	return err
+}
+
+const deleteExpiredPasswordResets = `-- name: DeleteExpiredPasswordResets :exec
+DELETE FROM
+    password_reset
+WHERE
+    expires_at <= ?
+`
+
+func (q *Queries) DeleteExpiredPasswordResets(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredPasswordResets, expiresAt)
+	return err
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :exec
+DELETE FROM
+    session
+WHERE
+    expires_at <= datetime('now')
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredSessions)
+	return err
+}
+
+const deleteExpiredWebAuthnChallenges = `-- name: DeleteExpiredWebAuthnChallenges :exec
+DELETE FROM
+    webauthn_challenge
+WHERE
+    expires_at <= ?
+`
+
+func (q *Queries) DeleteExpiredWebAuthnChallenges(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredWebAuthnChallenges, expiresAt)
+	return err
+}
+
+const deleteInvite = `-- name: DeleteInvite :execrows
+DELETE FROM
+    invite
+WHERE
+    id = ?
+`
+
+func (q *Queries) DeleteInvite(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteInvite, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteListByID = `-- name: DeleteListByID :exec
+DELETE FROM
+    list
+WHERE
+    user_id = ?
+    AND id = ?
+`
+
+type DeleteListByIDParams struct {
+	UserID *int64
+	ID     int64
+}
+
+func (q *Queries) DeleteListByID(ctx context.Context, arg DeleteListByIDParams) error {
+	_, err := q.db.ExecContext(ctx, deleteListByID, arg.UserID, arg.ID)
+	return err
+}
+
+const deleteListMember = `-- name: DeleteListMember :execrows
+DELETE FROM
+    list_member
+WHERE
+    list_member.list_id = ?1
+    AND list_member.user_id = ?2
+    AND (
+        list_member.user_id = ?3
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                list
+            WHERE
+                list.id = list_member.list_id
+                AND list.user_id = ?3
+        )
+    )
+`
+
+type DeleteListMemberParams struct {
+	ListID   int64
+	MemberID int64
+	UserID   int64
+}
+
+func (q *Queries) DeleteListMember(ctx context.Context, arg DeleteListMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteListMember, arg.ListID, arg.MemberID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteListMovieTags = `-- name: DeleteListMovieTags :exec
+DELETE FROM list_movie_tag
+WHERE
+    list_id = ?
+    AND movie_id = ?
+`
+
+type DeleteListMovieTagsParams struct {
+	ListID  int64
+	MovieID int64
+}
+
+func (q *Queries) DeleteListMovieTags(ctx context.Context, arg DeleteListMovieTagsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteListMovieTags, arg.ListID, arg.MovieID)
+	return err
+}
+
+const deleteListMovies = `-- name: DeleteListMovies :many
+DELETE FROM
+    list_movie
+WHERE
+    list_id = ?1
+    AND movie_id IN (/*SLICE:movie_ids*/?)
+RETURNING
+    movie_id
+`
+
+type DeleteListMoviesParams struct {
+	ListID   int64
+	MovieIds []int64
+}
+
+func (q *Queries) DeleteListMovies(ctx context.Context, arg DeleteListMoviesParams) ([]int64, error) {
+	query := deleteListMovies
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.ListID)
+	if len(arg.MovieIds) > 0 {
+		for _, v := range arg.MovieIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:movie_ids*/?", strings.Repeat(",?", len(arg.MovieIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:movie_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var movie_id int64
+		if err := rows.Scan(&movie_id); err != nil {
+			return nil, err
+		}
+		items = append(items, movie_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteLoginLockout = `-- name: DeleteLoginLockout :execrows
+DELETE FROM
+    login_lockout
+WHERE
+    user_id = ?
+`
+
+func (q *Queries) DeleteLoginLockout(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteLoginLockout, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteMFAChallenge = `-- name: DeleteMFAChallenge :exec
+DELETE FROM
+    mfa_challenge
+WHERE
+    token_hash = ?
+`
+
+func (q *Queries) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, deleteMFAChallenge, tokenHash)
+	return err
+}
+
+const deleteMovieFromList = `-- name: DeleteMovieFromList :exec
+DELETE FROM
+    list_movie
+WHERE
+    list_movie.list_id = ?1
+    AND list_movie.movie_id = ?2
+    AND EXISTS (
+        SELECT
+            1
+        FROM
+            list
+            LEFT JOIN list_member ON list_member.list_id = list.id
+            AND list_member.user_id = ?3
         WHERE
-    list.id = list_id
-    AND list.user_id = ?
-)
+            list.id = list_movie.list_id
+            AND (
+                list.user_id = ?3
+                OR list_member.role = 'editor'
+            )
+    )
 `
 
 type DeleteMovieFromListParams struct {
 	ListID  int64
 	MovieID int64
-	UserID  *int64
+	UserID  int64
 }
 
 func (q *Queries) DeleteMovieFromList(ctx context.Context, arg DeleteMovieFromListParams) error {
@@ -208,6 +838,51 @@ func (q *Queries) DeleteMovieFromList(ctx context.Context, arg DeleteMovieFromLi
 	return err
 }
 
+const deletePasskey = `-- name: DeletePasskey :execrows
+DELETE FROM
+    passkey
+WHERE
+    id = ?
+    AND user_id = ?
+`
+
+type DeletePasskeyParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) DeletePasskey(ctx context.Context, arg DeletePasskeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePasskey, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePasswordResetsByUser = `-- name: DeletePasswordResetsByUser :exec
+DELETE FROM
+    password_reset
+WHERE
+    user_id = ?
+`
+
+func (q *Queries) DeletePasswordResetsByUser(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deletePasswordResetsByUser, userID)
+	return err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM
+    recovery_code
+WHERE
+    user_id = ?
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
 const deleteSession = `-- name: DeleteSession :exec
 DELETE FROM
     session
@@ -220,6 +895,21 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteSessionsByUser = `-- name: DeleteSessionsByUser :execrows
+DELETE FROM
+    session
+WHERE
+    user_id = ?
+`
+
+func (q *Queries) DeleteSessionsByUser(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSessionsByUser, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteUser = `-- name: DeleteUser :exec
 DELETE FROM
     user
@@ -232,6 +922,42 @@ func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteUserSession = `-- name: DeleteUserSession :execrows
+DELETE FROM
+    session
+WHERE
+    id = ?
+    AND user_id = ?
+`
+
+type DeleteUserSessionParams struct {
+	ID     string
+	UserID int64
+}
+
+func (q *Queries) DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserTOTP = `-- name: DeleteUserTOTP :execrows
+DELETE FROM
+    user_totp
+WHERE
+    user_id = ?
+`
+
+func (q *Queries) DeleteUserTOTP(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserTOTP, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteWatched = `-- name: DeleteWatched :one
 DELETE FROM
     watched
@@ -254,9 +980,61 @@ func (q *Queries) DeleteWatched(ctx context.Context, arg DeleteWatchedParams) (i
 	return movie_id, err
 }
 
+const deleteWebhook = `-- name: DeleteWebhook :execrows
+DELETE FROM
+    webhook
+WHERE
+    id = ?
+    AND user_id = ?
+`
+
+type DeleteWebhookParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWebhook, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :exec
+UPDATE
+    user_totp
+SET
+    enabled = TRUE
+WHERE
+    user_id = ?
+`
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, enableUserTOTP, userID)
+	return err
+}
+
 const getAllLists = `-- name: GetAllLists :many
 SELECT
-    id, name, creation_date, description, user_id, is_watchlist
+    list.id, list.name, list.creation_date, list.description, list.user_id, list.is_watchlist, list.ranked, list.share_token, list.share_notes,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            list_rule
+        WHERE
+            list_rule.list_id = list.id
+    ) AS smart,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            list_source
+        WHERE
+            list_source.list_id = list.id
+            AND list_source.sync = TRUE
+    ) AS synced
 FROM
     list
 WHERE
@@ -265,22 +1043,33 @@ ORDER BY
     id
 `
 
-func (q *Queries) GetAllLists(ctx context.Context, userID *int64) ([]List, error) {
+type GetAllListsRow struct {
+	List   List
+	Smart  int64
+	Synced int64
+}
+
+func (q *Queries) GetAllLists(ctx context.Context, userID *int64) ([]GetAllListsRow, error) {
 	rows, err := q.db.QueryContext(ctx, getAllLists, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []List
+	var items []GetAllListsRow
 	for rows.Next() {
-		var i List
+		var i GetAllListsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.CreationDate,
-			&i.Description,
-			&i.UserID,
-			&i.IsWatchlist,
+			&i.List.ID,
+			&i.List.Name,
+			&i.List.CreationDate,
+			&i.List.Description,
+			&i.List.UserID,
+			&i.List.IsWatchlist,
+			&i.List.Ranked,
+			&i.List.ShareToken,
+			&i.List.ShareNotes,
+			&i.Smart,
+			&i.Synced,
 		); err != nil {
 			return nil, err
 		}
@@ -297,9 +1086,9 @@ func (q *Queries) GetAllLists(ctx context.Context, userID *int64) ([]List, error
 
 const getAllListsWithMovies = `-- name: GetAllListsWithMovies :many
 SELECT
-    list.id, list.name, list.creation_date, list.description, list.user_id, list.is_watchlist,
+    list.id, list.name, list.creation_date, list.description, list.user_id, list.is_watchlist, list.ranked, list.share_token, list.share_notes,
     movie.id, movie.title, movie.original_title, movie.original_language, movie.overview, movie.release_date, movie.poster_path, movie.backdrop_path, movie.popularity, movie.vote_count, movie.vote_average, movie.budget, movie.homepage, movie.imdb_id, movie.revenue, movie.runtime, movie.status, movie.tagline, movie.updated_at,
-    list_movie.movie_id, list_movie.list_id, list_movie.date_added, list_movie.position, list_movie.note
+    list_movie.movie_id, list_movie.list_id, list_movie.date_added, list_movie.position, list_movie.note, list_movie.added_by, list_movie.priority, list_movie.available_on
 FROM
     list
     LEFT JOIN list_movie ON list_movie.list_id = list.id
@@ -334,6 +1123,9 @@ func (q *Queries) GetAllListsWithMovies(ctx context.Context, userID *int64) ([]G
 			&i.List.Description,
 			&i.List.UserID,
 			&i.List.IsWatchlist,
+			&i.List.Ranked,
+			&i.List.ShareToken,
+			&i.List.ShareNotes,
 			&i.Movie.ID,
 			&i.Movie.Title,
 			&i.Movie.OriginalTitle,
@@ -358,6 +1150,9 @@ func (q *Queries) GetAllListsWithMovies(ctx context.Context, userID *int64) ([]G
 			&i.ListMovie.DateAdded,
 			&i.ListMovie.Position,
 			&i.ListMovie.Note,
+			&i.ListMovie.AddedBy,
+			&i.ListMovie.Priority,
+			&i.ListMovie.AvailableOn,
 		); err != nil {
 			return nil, err
 		}
@@ -379,6 +1174,7 @@ SELECT
     u.name,
     u.created_at,
     u.admin,
+    u.disabled_at,
     (
         SELECT
             COUNT(*)
@@ -394,9 +1190,30 @@ SELECT
             list l
         WHERE
             l.user_id = u.id
-    ) AS list_count
+    ) AS list_count,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            user_totp t
+        WHERE
+            t.user_id = u.id
+            AND t.enabled
+    ) AS totp_enabled,
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            session s
+        WHERE
+            s.user_id = u.id
+            AND s.expires_at > datetime('now')
+    ) AS session_count,
+    ll.failed_count,
+    ll.locked_until
 FROM
     user u
+    LEFT JOIN login_lockout ll ON ll.user_id = u.id
 ORDER BY
     u.created_at DESC
 `
@@ -407,8 +1224,13 @@ type GetAllUsersWithStatsRow struct {
 	Name         string
 	CreatedAt    *time.Time
 	Admin        bool
+	DisabledAt   *time.Time
 	WatchedCount int64
 	ListCount    int64
+	TotpEnabled  int64
+	SessionCount int64
+	FailedCount  *int64
+	LockedUntil  *time.Time
 }
 
 func (q *Queries) GetAllUsersWithStats(ctx context.Context) ([]GetAllUsersWithStatsRow, error) {
@@ -426,8 +1248,13 @@ func (q *Queries) GetAllUsersWithStats(ctx context.Context) ([]GetAllUsersWithSt
 			&i.Name,
 			&i.CreatedAt,
 			&i.Admin,
+			&i.DisabledAt,
 			&i.WatchedCount,
 			&i.ListCount,
+			&i.TotpEnabled,
+			&i.SessionCount,
+			&i.FailedCount,
+			&i.LockedUntil,
 		); err != nil {
 			return nil, err
 		}
@@ -702,8 +1529,117 @@ func (q *Queries) GetDailyWatchCountsLastYear(ctx context.Context, userID *int64
 	return items, nil
 }
 
-const getFavoriteActorsByRating = `-- name: GetFavoriteActorsByRating :many
-WITH rated_movies AS (
+const getDueWebhookDeliveries = `-- name: GetDueWebhookDeliveries :many
+SELECT
+    webhook_delivery.id, webhook_delivery.webhook_id, webhook_delivery.event, webhook_delivery.payload, webhook_delivery.status, webhook_delivery.attempts, webhook_delivery.next_attempt_at, webhook_delivery.last_attempt_at, webhook_delivery.response_status, webhook_delivery.last_error, webhook_delivery.created_at,
+    webhook.url,
+    webhook.secret
+FROM
+    webhook_delivery
+    JOIN webhook ON webhook.id = webhook_delivery.webhook_id
+WHERE
+    webhook_delivery.status = 'pending'
+    AND webhook_delivery.next_attempt_at <= ?
+ORDER BY
+    webhook_delivery.next_attempt_at,
+    webhook_delivery.id
+LIMIT
+    ?
+`
+
+type GetDueWebhookDeliveriesParams struct {
+	NextAttemptAt time.Time
+	Limit         int64
+}
+
+type GetDueWebhookDeliveriesRow struct {
+	WebhookDelivery WebhookDelivery
+	Url             string
+	Secret          string
+}
+
+func (q *Queries) GetDueWebhookDeliveries(ctx context.Context, arg GetDueWebhookDeliveriesParams) ([]GetDueWebhookDeliveriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDueWebhookDeliveries, arg.NextAttemptAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDueWebhookDeliveriesRow
+	for rows.Next() {
+		var i GetDueWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.WebhookDelivery.ID,
+			&i.WebhookDelivery.WebhookID,
+			&i.WebhookDelivery.Event,
+			&i.WebhookDelivery.Payload,
+			&i.WebhookDelivery.Status,
+			&i.WebhookDelivery.Attempts,
+			&i.WebhookDelivery.NextAttemptAt,
+			&i.WebhookDelivery.LastAttemptAt,
+			&i.WebhookDelivery.ResponseStatus,
+			&i.WebhookDelivery.LastError,
+			&i.WebhookDelivery.CreatedAt,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEnabledWebhooksByUser = `-- name: GetEnabledWebhooksByUser :many
+SELECT
+    id, user_id, url, secret, events, enabled, created_at
+FROM
+    webhook
+WHERE
+    user_id = ?
+    AND enabled = TRUE
+ORDER BY
+    id
+`
+
+func (q *Queries) GetEnabledWebhooksByUser(ctx context.Context, userID int64) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, getEnabledWebhooksByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.Enabled,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFavoriteActorsByRating = `-- name: GetFavoriteActorsByRating :many
+WITH rated_movies AS (
     SELECT
         watched.movie_id,
         CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating
@@ -862,6 +1798,44 @@ func (q *Queries) GetFavoriteDirectorsByRating(ctx context.Context, userID *int6
 	return items, nil
 }
 
+const getGenres = `-- name: GetGenres :many
+SELECT
+    id,
+    name
+FROM
+    genre
+ORDER BY
+    name
+`
+
+type GetGenresRow struct {
+	ID   int64
+	Name string
+}
+
+func (q *Queries) GetGenres(ctx context.Context) ([]GetGenresRow, error) {
+	rows, err := q.db.QueryContext(ctx, getGenres)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGenresRow
+	for rows.Next() {
+		var i GetGenresRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getHighestRatedMovies = `-- name: GetHighestRatedMovies :many
 WITH rated_movies AS (
     SELECT
@@ -935,9 +1909,137 @@ func (q *Queries) GetHighestRatedMovies(ctx context.Context, arg GetHighestRated
 	return items, nil
 }
 
+const getInviteByTokenHash = `-- name: GetInviteByTokenHash :one
+SELECT
+    id, token_hash, created_by, created_at, expires_at, used_at, used_by
+FROM
+    invite
+WHERE
+    token_hash = ?
+`
+
+func (q *Queries) GetInviteByTokenHash(ctx context.Context, tokenHash string) (Invite, error) {
+	row := q.db.QueryRowContext(ctx, getInviteByTokenHash, tokenHash)
+	var i Invite
+	err := row.Scan(
+		&i.ID,
+		&i.TokenHash,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.UsedBy,
+	)
+	return i, err
+}
+
+const getInvites = `-- name: GetInvites :many
+SELECT
+    invite.id,
+    invite.created_at,
+    invite.expires_at,
+    invite.used_at,
+    creator.name AS created_by_name,
+    redeemer.email AS used_by_email
+FROM
+    invite
+    LEFT JOIN user creator ON creator.id = invite.created_by
+    LEFT JOIN user redeemer ON redeemer.id = invite.used_by
+ORDER BY
+    invite.created_at DESC,
+    invite.id DESC
+`
+
+type GetInvitesRow struct {
+	ID            int64
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	CreatedByName *string
+	UsedByEmail   *string
+}
+
+func (q *Queries) GetInvites(ctx context.Context) ([]GetInvitesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getInvites)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetInvitesRow
+	for rows.Next() {
+		var i GetInvitesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.UsedAt,
+			&i.CreatedByName,
+			&i.UsedByEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListAccess = `-- name: GetListAccess :one
+SELECT
+    list.id, list.name, list.creation_date, list.description, list.user_id, list.is_watchlist, list.ranked, list.share_token, list.share_notes,
+    owner.name AS owner_name,
+    list_member.role AS member_role
+FROM
+    list
+    JOIN user owner ON owner.id = list.user_id
+    LEFT JOIN list_member ON list_member.list_id = list.id
+    AND list_member.user_id = ?1
+WHERE
+    list.id = ?2
+    AND (
+        list.user_id = ?1
+        OR list_member.user_id IS NOT NULL
+    )
+`
+
+type GetListAccessParams struct {
+	UserID int64
+	ID     int64
+}
+
+type GetListAccessRow struct {
+	List       List
+	OwnerName  string
+	MemberRole *string
+}
+
+func (q *Queries) GetListAccess(ctx context.Context, arg GetListAccessParams) (GetListAccessRow, error) {
+	row := q.db.QueryRowContext(ctx, getListAccess, arg.UserID, arg.ID)
+	var i GetListAccessRow
+	err := row.Scan(
+		&i.List.ID,
+		&i.List.Name,
+		&i.List.CreationDate,
+		&i.List.Description,
+		&i.List.UserID,
+		&i.List.IsWatchlist,
+		&i.List.Ranked,
+		&i.List.ShareToken,
+		&i.List.ShareNotes,
+		&i.OwnerName,
+		&i.MemberRole,
+	)
+	return i, err
+}
+
 const getListByID = `-- name: GetListByID :one
 SELECT
-    id, name, creation_date, description, user_id, is_watchlist
+    id, name, creation_date, description, user_id, is_watchlist, ranked, share_token, share_notes
 FROM
     list
 WHERE
@@ -960,44 +2062,161 @@ func (q *Queries) GetListByID(ctx context.Context, arg GetListByIDParams) (List,
 		&i.Description,
 		&i.UserID,
 		&i.IsWatchlist,
+		&i.Ranked,
+		&i.ShareToken,
+		&i.ShareNotes,
 	)
 	return i, err
 }
 
-const getListJoinMovieByID = `-- name: GetListJoinMovieByID :many
+const getListByShareToken = `-- name: GetListByShareToken :one
 SELECT
-    movie.id, movie.title, movie.original_title, movie.original_language, movie.overview, movie.release_date, movie.poster_path, movie.backdrop_path, movie.popularity, movie.vote_count, movie.vote_average, movie.budget, movie.homepage, movie.imdb_id, movie.revenue, movie.runtime, movie.status, movie.tagline, movie.updated_at,
-    list_movie.movie_id, list_movie.list_id, list_movie.date_added, list_movie.position, list_movie.note,
-    list.id, list.name, list.creation_date, list.description, list.user_id, list.is_watchlist
+    id, name, creation_date, description, user_id, is_watchlist, ranked, share_token, share_notes
 FROM
     list
-    JOIN list_movie ON list_movie.list_id = list.id
-    JOIN movie ON movie.id = list_movie.movie_id
 WHERE
-    list.user_id = ?
-    AND list.id = ?
+    share_token = ?
 `
 
-type GetListJoinMovieByIDParams struct {
-	UserID *int64
-	ID     int64
+func (q *Queries) GetListByShareToken(ctx context.Context, shareToken *string) (List, error) {
+	row := q.db.QueryRowContext(ctx, getListByShareToken, shareToken)
+	var i List
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreationDate,
+		&i.Description,
+		&i.UserID,
+		&i.IsWatchlist,
+		&i.Ranked,
+		&i.ShareToken,
+		&i.ShareNotes,
+	)
+	return i, err
 }
 
-type GetListJoinMovieByIDRow struct {
-	Movie     Movie
-	ListMovie ListMovie
-	List      List
+const getListMembers = `-- name: GetListMembers :many
+SELECT
+    user.id,
+    user.name,
+    user.email,
+    list_member.role,
+    list_member.added_at
+FROM
+    list_member
+    JOIN user ON user.id = list_member.user_id
+WHERE
+    list_member.list_id = ?
+ORDER BY
+    list_member.added_at,
+    user.id
+`
+
+type GetListMembersRow struct {
+	ID      int64
+	Name    string
+	Email   string
+	Role    string
+	AddedAt time.Time
+}
+
+func (q *Queries) GetListMembers(ctx context.Context, listID int64) ([]GetListMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getListMembers, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetListMembersRow
+	for rows.Next() {
+		var i GetListMembersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.Role,
+			&i.AddedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListMovieTags = `-- name: GetListMovieTags :many
+SELECT
+    movie_id,
+    tag
+FROM
+    list_movie_tag
+WHERE
+    list_id = ?
+ORDER BY
+    tag
+`
+
+type GetListMovieTagsRow struct {
+	MovieID int64
+	Tag     string
+}
+
+func (q *Queries) GetListMovieTags(ctx context.Context, listID int64) ([]GetListMovieTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getListMovieTags, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetListMovieTagsRow
+	for rows.Next() {
+		var i GetListMovieTagsRow
+		if err := rows.Scan(&i.MovieID, &i.Tag); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListMovies = `-- name: GetListMovies :many
+SELECT
+    movie.id, movie.title, movie.original_title, movie.original_language, movie.overview, movie.release_date, movie.poster_path, movie.backdrop_path, movie.popularity, movie.vote_count, movie.vote_average, movie.budget, movie.homepage, movie.imdb_id, movie.revenue, movie.runtime, movie.status, movie.tagline, movie.updated_at,
+    list_movie.movie_id, list_movie.list_id, list_movie.date_added, list_movie.position, list_movie.note, list_movie.added_by, list_movie.priority, list_movie.available_on,
+    adder.name AS added_by_name
+FROM
+    list_movie
+    JOIN movie ON movie.id = list_movie.movie_id
+    LEFT JOIN user adder ON adder.id = list_movie.added_by
+WHERE
+    list_movie.list_id = ?
+`
+
+type GetListMoviesRow struct {
+	Movie       Movie
+	ListMovie   ListMovie
+	AddedByName *string
 }
 
-func (q *Queries) GetListJoinMovieByID(ctx context.Context, arg GetListJoinMovieByIDParams) ([]GetListJoinMovieByIDRow, error) {
-	rows, err := q.db.QueryContext(ctx, getListJoinMovieByID, arg.UserID, arg.ID)
+func (q *Queries) GetListMovies(ctx context.Context, listID int64) ([]GetListMoviesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getListMovies, listID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetListJoinMovieByIDRow
+	var items []GetListMoviesRow
 	for rows.Next() {
-		var i GetListJoinMovieByIDRow
+		var i GetListMoviesRow
 		if err := rows.Scan(
 			&i.Movie.ID,
 			&i.Movie.Title,
@@ -1023,12 +2242,10 @@ func (q *Queries) GetListJoinMovieByID(ctx context.Context, arg GetListJoinMovie
 			&i.ListMovie.DateAdded,
 			&i.ListMovie.Position,
 			&i.ListMovie.Note,
-			&i.List.ID,
-			&i.List.Name,
-			&i.List.CreationDate,
-			&i.List.Description,
-			&i.List.UserID,
-			&i.List.IsWatchlist,
+			&i.ListMovie.AddedBy,
+			&i.ListMovie.Priority,
+			&i.ListMovie.AvailableOn,
+			&i.AddedByName,
 		); err != nil {
 			return nil, err
 		}
@@ -1043,22 +2260,145 @@ func (q *Queries) GetListJoinMovieByID(ctx context.Context, arg GetListJoinMovie
 	return items, nil
 }
 
-const getLongestWatchedMovie = `-- name: GetLongestWatchedMovie :one
+const getListOwner = `-- name: GetListOwner :one
 SELECT
-    movie.id,
-    movie.title,
-    movie.poster_path,
-    movie.runtime
+    user_id
 FROM
-    watched
-    JOIN movie ON watched.movie_id = movie.id
+    list
 WHERE
-    watched.user_id = ?
-    AND movie.runtime > 0
-GROUP BY
-    movie.id,
-    movie.title,
-    movie.poster_path,
+    id = ?
+`
+
+func (q *Queries) GetListOwner(ctx context.Context, id int64) (*int64, error) {
+	row := q.db.QueryRowContext(ctx, getListOwner, id)
+	var user_id *int64
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const getListRules = `-- name: GetListRules :one
+SELECT
+    list_id, watched, in_theater, watched_year, min_rating, min_runtime, max_runtime, release_year_from, release_year_to, genre_id, in_watchlist, director_min_rating, person_name
+FROM
+    list_rule
+WHERE
+    list_id = ?
+`
+
+// Smart lists.
+func (q *Queries) GetListRules(ctx context.Context, listID int64) (ListRule, error) {
+	row := q.db.QueryRowContext(ctx, getListRules, listID)
+	var i ListRule
+	err := row.Scan(
+		&i.ListID,
+		&i.Watched,
+		&i.InTheater,
+		&i.WatchedYear,
+		&i.MinRating,
+		&i.MinRuntime,
+		&i.MaxRuntime,
+		&i.ReleaseYearFrom,
+		&i.ReleaseYearTo,
+		&i.GenreID,
+		&i.InWatchlist,
+		&i.DirectorMinRating,
+		&i.PersonName,
+	)
+	return i, err
+}
+
+const getListSource = `-- name: GetListSource :one
+SELECT
+    list_id, kind, tmdb_id, sync, synced_at
+FROM
+    list_source
+WHERE
+    list_id = ?
+`
+
+// TMDB list sources.
+func (q *Queries) GetListSource(ctx context.Context, listID int64) (ListSource, error) {
+	row := q.db.QueryRowContext(ctx, getListSource, listID)
+	var i ListSource
+	err := row.Scan(
+		&i.ListID,
+		&i.Kind,
+		&i.TmdbID,
+		&i.Sync,
+		&i.SyncedAt,
+	)
+	return i, err
+}
+
+const getListView = `-- name: GetListView :one
+SELECT
+    user_id, list_id, sort, sort_desc, genre_id, watched, decade, layout
+FROM
+    list_view
+WHERE
+    user_id = ?
+    AND list_id = ?
+`
+
+type GetListViewParams struct {
+	UserID int64
+	ListID int64
+}
+
+// List views.
+func (q *Queries) GetListView(ctx context.Context, arg GetListViewParams) (ListView, error) {
+	row := q.db.QueryRowContext(ctx, getListView, arg.UserID, arg.ListID)
+	var i ListView
+	err := row.Scan(
+		&i.UserID,
+		&i.ListID,
+		&i.Sort,
+		&i.SortDesc,
+		&i.GenreID,
+		&i.Watched,
+		&i.Decade,
+		&i.Layout,
+	)
+	return i, err
+}
+
+const getLoginLockout = `-- name: GetLoginLockout :one
+SELECT
+    user_id, failed_count, last_failed_at, locked_until
+FROM
+    login_lockout
+WHERE
+    user_id = ?
+`
+
+func (q *Queries) GetLoginLockout(ctx context.Context, userID int64) (LoginLockout, error) {
+	row := q.db.QueryRowContext(ctx, getLoginLockout, userID)
+	var i LoginLockout
+	err := row.Scan(
+		&i.UserID,
+		&i.FailedCount,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const getLongestWatchedMovie = `-- name: GetLongestWatchedMovie :one
+SELECT
+    movie.id,
+    movie.title,
+    movie.poster_path,
+    movie.runtime
+FROM
+    watched
+    JOIN movie ON watched.movie_id = movie.id
+WHERE
+    watched.user_id = ?
+    AND movie.runtime > 0
+GROUP BY
+    movie.id,
+    movie.title,
+    movie.poster_path,
     movie.runtime
 ORDER BY
     movie.runtime DESC,
@@ -1086,6 +2426,105 @@ func (q *Queries) GetLongestWatchedMovie(ctx context.Context, userID *int64) (Ge
 	return i, err
 }
 
+const getMFAChallenge = `-- name: GetMFAChallenge :one
+SELECT
+    token_hash, user_id, expires_at, attempts
+FROM
+    mfa_challenge
+WHERE
+    token_hash = ?
+    AND expires_at > ?
+`
+
+type GetMFAChallengeParams struct {
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) GetMFAChallenge(ctx context.Context, arg GetMFAChallengeParams) (MfaChallenge, error) {
+	row := q.db.QueryRowContext(ctx, getMFAChallenge, arg.TokenHash, arg.ExpiresAt)
+	var i MfaChallenge
+	err := row.Scan(
+		&i.TokenHash,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.Attempts,
+	)
+	return i, err
+}
+
+const getMemberLists = `-- name: GetMemberLists :many
+SELECT
+    list.id,
+    list.name,
+    owner.name AS owner_name,
+    list_member.role,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            list_rule
+        WHERE
+            list_rule.list_id = list.id
+    ) AS smart,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            list_source
+        WHERE
+            list_source.list_id = list.id
+            AND list_source.sync = TRUE
+    ) AS synced
+FROM
+    list_member
+    JOIN list ON list.id = list_member.list_id
+    JOIN user owner ON owner.id = list.user_id
+WHERE
+    list_member.user_id = ?
+ORDER BY
+    list.id
+`
+
+type GetMemberListsRow struct {
+	ID        int64
+	Name      string
+	OwnerName string
+	Role      string
+	Smart     int64
+	Synced    int64
+}
+
+func (q *Queries) GetMemberLists(ctx context.Context, userID int64) ([]GetMemberListsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMemberLists, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMemberListsRow
+	for rows.Next() {
+		var i GetMemberListsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.OwnerName,
+			&i.Role,
+			&i.Smart,
+			&i.Synced,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getMonthlyAverageRatingLastYear = `-- name: GetMonthlyAverageRatingLastYear :many
 SELECT
     CAST(strftime('%Y-%m', watched.watched_date) AS TEXT) AS month,
@@ -1355,76 +2794,136 @@ func (q *Queries) GetMovieGenre(ctx context.Context, movieID int64) ([]GetMovieG
 	return items, nil
 }
 
-const getPerson = `-- name: GetPerson :one
+const getMovieLists = `-- name: GetMovieLists :many
 SELECT
-    id, name, original_name, profile_path, known_for_department, popularity, gender, adult, updated_at
+    list.id,
+    list.name,
+    list.is_watchlist,
+    owner.name AS owner_name,
+    list_member.role AS member_role,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            list_source
+        WHERE
+            list_source.list_id = list.id
+            AND list_source.sync = TRUE
+    ) AS synced,
+    list_movie.note,
+    list_movie.date_added
 FROM
-    person
+    list_movie
+    JOIN list ON list.id = list_movie.list_id
+    JOIN user owner ON owner.id = list.user_id
+    LEFT JOIN list_member ON list_member.list_id = list.id
+    AND list_member.user_id = ?1
 WHERE
-    id = ?
+    list_movie.movie_id = ?2
+    AND (
+        list.user_id = ?1
+        OR list_member.user_id IS NOT NULL
+    )
+ORDER BY
+    list.is_watchlist DESC,
+    list_member.user_id IS NOT NULL,
+    list.id
 `
 
-func (q *Queries) GetPerson(ctx context.Context, id int64) (Person, error) {
-	row := q.db.QueryRowContext(ctx, getPerson, id)
-	var i Person
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.OriginalName,
-		&i.ProfilePath,
-		&i.KnownForDepartment,
-		&i.Popularity,
-		&i.Gender,
-		&i.Adult,
-		&i.UpdatedAt,
-	)
-	return i, err
+type GetMovieListsParams struct {
+	UserID  int64
+	MovieID int64
 }
 
-const getRatingByReleaseDecade = `-- name: GetRatingByReleaseDecade :many
-WITH rated_movies AS (
-    SELECT
-        watched.movie_id,
-        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating
-    FROM
-        watched
-    WHERE
-        watched.user_id = ?1
-        AND watched.rating IS NOT NULL
-    GROUP BY
-        watched.movie_id
-)
+type GetMovieListsRow struct {
+	ID          int64
+	Name        string
+	IsWatchlist bool
+	OwnerName   string
+	MemberRole  *string
+	Synced      int64
+	Note        *string
+	DateAdded   string
+}
+
+// The lists the user owns or is a member of that contain the movie, the watchlist first
+// then their own lists before the ones shared with them. Looking list_movie up by movie_id
+// uses its primary key, which starts with movie_id, so no list is loaded whole.
+func (q *Queries) GetMovieLists(ctx context.Context, arg GetMovieListsParams) ([]GetMovieListsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMovieLists, arg.UserID, arg.MovieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMovieListsRow
+	for rows.Next() {
+		var i GetMovieListsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.IsWatchlist,
+			&i.OwnerName,
+			&i.MemberRole,
+			&i.Synced,
+			&i.Note,
+			&i.DateAdded,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMoviesGenres = `-- name: GetMoviesGenres :many
 SELECT
-    (CAST(strftime('%Y', movie.release_date) AS INTEGER) / 10) * 10 AS decade,
-    CAST(COALESCE(AVG(rated_movies.average_rating), 0.0) AS REAL) AS average_rating,
-    COUNT(*) AS rated_movie_count
+    genre_movie.movie_id,
+    genre.id, genre.name, genre.updated_at
 FROM
-    rated_movies
-    JOIN movie ON rated_movies.movie_id = movie.id
+    genre_movie
+    JOIN genre ON genre.id = genre_movie.genre_id
 WHERE
-    movie.release_date IS NOT NULL
-GROUP BY
-    decade
+    genre_movie.movie_id IN (/*SLICE:movie_ids*/?)
 ORDER BY
-    decade
+    genre.name
 `
 
-type GetRatingByReleaseDecadeRow struct {
-	Decade          int64
-	AverageRating   float64
-	RatedMovieCount int64
+type GetMoviesGenresRow struct {
+	MovieID int64
+	Genre   Genre
 }
 
-func (q *Queries) GetRatingByReleaseDecade(ctx context.Context, userID *int64) ([]GetRatingByReleaseDecadeRow, error) {
-	rows, err := q.db.QueryContext(ctx, getRatingByReleaseDecade, userID)
+func (q *Queries) GetMoviesGenres(ctx context.Context, movieIds []int64) ([]GetMoviesGenresRow, error) {
+	query := getMoviesGenres
+	var queryParams []interface{}
+	if len(movieIds) > 0 {
+		for _, v := range movieIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:movie_ids*/?", strings.Repeat(",?", len(movieIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:movie_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetRatingByReleaseDecadeRow
+	var items []GetMoviesGenresRow
 	for rows.Next() {
-		var i GetRatingByReleaseDecadeRow
-		if err := rows.Scan(&i.Decade, &i.AverageRating, &i.RatedMovieCount); err != nil {
+		var i GetMoviesGenresRow
+		if err := rows.Scan(
+			&i.MovieID,
+			&i.Genre.ID,
+			&i.Genre.Name,
+			&i.Genre.UpdatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -1438,36 +2937,67 @@ func (q *Queries) GetRatingByReleaseDecade(ctx context.Context, userID *int64) (
 	return items, nil
 }
 
-const getRatingDistribution = `-- name: GetRatingDistribution :many
+const getMoviesWatchSummaries = `-- name: GetMoviesWatchSummaries :many
 SELECT
-    CAST(ROUND(CAST(watched.rating AS REAL) * 2.0, 0) / 2.0 AS REAL) AS rating_bucket,
-    COUNT(*) AS count
+    watched.movie_id,
+    COUNT(*) AS watch_count,
+    (
+        SELECT
+            rated.rating
+        FROM
+            watched rated
+        WHERE
+            rated.user_id = watched.user_id
+            AND rated.movie_id = watched.movie_id
+            AND rated.rating IS NOT NULL
+        ORDER BY
+            rated.watched_date DESC
+        LIMIT
+            1
+    ) AS rating
 FROM
     watched
 WHERE
-    watched.user_id = ?
-    AND watched.rating IS NOT NULL
+    watched.user_id = ?1
+    AND watched.movie_id IN (/*SLICE:movie_ids*/?)
 GROUP BY
-    rating_bucket
-ORDER BY
-    rating_bucket
+    watched.movie_id
 `
 
-type GetRatingDistributionRow struct {
-	RatingBucket float64
-	Count        int64
+type GetMoviesWatchSummariesParams struct {
+	UserID   *int64
+	MovieIds []int64
 }
 
-func (q *Queries) GetRatingDistribution(ctx context.Context, userID *int64) ([]GetRatingDistributionRow, error) {
-	rows, err := q.db.QueryContext(ctx, getRatingDistribution, userID)
+type GetMoviesWatchSummariesRow struct {
+	MovieID    int64
+	WatchCount int64
+	Rating     *float64
+}
+
+// Returns how many times the user watched each of the movies they watched, with the
+// rating of their latest rated watch.
+func (q *Queries) GetMoviesWatchSummaries(ctx context.Context, arg GetMoviesWatchSummariesParams) ([]GetMoviesWatchSummariesRow, error) {
+	query := getMoviesWatchSummaries
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.UserID)
+	if len(arg.MovieIds) > 0 {
+		for _, v := range arg.MovieIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:movie_ids*/?", strings.Repeat(",?", len(arg.MovieIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:movie_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetRatingDistributionRow
+	var items []GetMoviesWatchSummariesRow
 	for rows.Next() {
-		var i GetRatingDistributionRow
-		if err := rows.Scan(&i.RatingBucket, &i.Count); err != nil {
+		var i GetMoviesWatchSummariesRow
+		if err := rows.Scan(&i.MovieID, &i.WatchCount, &i.Rating); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -1481,20 +3011,240 @@ func (q *Queries) GetRatingDistribution(ctx context.Context, userID *int64) ([]G
 	return items, nil
 }
 
-const getRatingSummary = `-- name: GetRatingSummary :one
+const getPasskeyByCredentialID = `-- name: GetPasskeyByCredentialID :one
 SELECT
-    CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
-    COUNT(watched.rating) AS rated_count
+    id, user_id, credential_id, public_key, sign_count, backup_eligible, name, created_at, last_used_at
 FROM
-    watched
+    passkey
 WHERE
-    watched.user_id = ?
-    AND watched.rating IS NOT NULL
+    credential_id = ?
 `
 
-type GetRatingSummaryRow struct {
-	AverageRating float64
-	RatedCount    int64
+func (q *Queries) GetPasskeyByCredentialID(ctx context.Context, credentialID string) (Passkey, error) {
+	row := q.db.QueryRowContext(ctx, getPasskeyByCredentialID, credentialID)
+	var i Passkey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.SignCount,
+		&i.BackupEligible,
+		&i.Name,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const getPasskeysByUser = `-- name: GetPasskeysByUser :many
+SELECT
+    id, user_id, credential_id, public_key, sign_count, backup_eligible, name, created_at, last_used_at
+FROM
+    passkey
+WHERE
+    user_id = ?
+ORDER BY
+    created_at DESC,
+    id DESC
+`
+
+func (q *Queries) GetPasskeysByUser(ctx context.Context, userID int64) ([]Passkey, error) {
+	rows, err := q.db.QueryContext(ctx, getPasskeysByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Passkey
+	for rows.Next() {
+		var i Passkey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CredentialID,
+			&i.PublicKey,
+			&i.SignCount,
+			&i.BackupEligible,
+			&i.Name,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPasswordResetUserID = `-- name: GetPasswordResetUserID :one
+SELECT
+    user_id
+FROM
+    password_reset
+WHERE
+    token_hash = ?1
+    AND used_at IS NULL
+    AND expires_at > ?2
+`
+
+type GetPasswordResetUserIDParams struct {
+	TokenHash string
+	Now       time.Time
+}
+
+func (q *Queries) GetPasswordResetUserID(ctx context.Context, arg GetPasswordResetUserIDParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getPasswordResetUserID, arg.TokenHash, arg.Now)
+	var user_id int64
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const getPerson = `-- name: GetPerson :one
+SELECT
+    id, name, original_name, profile_path, known_for_department, popularity, gender, adult, updated_at
+FROM
+    person
+WHERE
+    id = ?
+`
+
+func (q *Queries) GetPerson(ctx context.Context, id int64) (Person, error) {
+	row := q.db.QueryRowContext(ctx, getPerson, id)
+	var i Person
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.OriginalName,
+		&i.ProfilePath,
+		&i.KnownForDepartment,
+		&i.Popularity,
+		&i.Gender,
+		&i.Adult,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getRatingByReleaseDecade = `-- name: GetRatingByReleaseDecade :many
+WITH rated_movies AS (
+    SELECT
+        watched.movie_id,
+        CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating
+    FROM
+        watched
+    WHERE
+        watched.user_id = ?1
+        AND watched.rating IS NOT NULL
+    GROUP BY
+        watched.movie_id
+)
+SELECT
+    (CAST(strftime('%Y', movie.release_date) AS INTEGER) / 10) * 10 AS decade,
+    CAST(COALESCE(AVG(rated_movies.average_rating), 0.0) AS REAL) AS average_rating,
+    COUNT(*) AS rated_movie_count
+FROM
+    rated_movies
+    JOIN movie ON rated_movies.movie_id = movie.id
+WHERE
+    movie.release_date IS NOT NULL
+GROUP BY
+    decade
+ORDER BY
+    decade
+`
+
+type GetRatingByReleaseDecadeRow struct {
+	Decade          int64
+	AverageRating   float64
+	RatedMovieCount int64
+}
+
+func (q *Queries) GetRatingByReleaseDecade(ctx context.Context, userID *int64) ([]GetRatingByReleaseDecadeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRatingByReleaseDecade, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRatingByReleaseDecadeRow
+	for rows.Next() {
+		var i GetRatingByReleaseDecadeRow
+		if err := rows.Scan(&i.Decade, &i.AverageRating, &i.RatedMovieCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRatingDistribution = `-- name: GetRatingDistribution :many
+SELECT
+    CAST(ROUND(CAST(watched.rating AS REAL) * 2.0, 0) / 2.0 AS REAL) AS rating_bucket,
+    COUNT(*) AS count
+FROM
+    watched
+WHERE
+    watched.user_id = ?
+    AND watched.rating IS NOT NULL
+GROUP BY
+    rating_bucket
+ORDER BY
+    rating_bucket
+`
+
+type GetRatingDistributionRow struct {
+	RatingBucket float64
+	Count        int64
+}
+
+func (q *Queries) GetRatingDistribution(ctx context.Context, userID *int64) ([]GetRatingDistributionRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRatingDistribution, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRatingDistributionRow
+	for rows.Next() {
+		var i GetRatingDistributionRow
+		if err := rows.Scan(&i.RatingBucket, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRatingSummary = `-- name: GetRatingSummary :one
+SELECT
+    CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
+    COUNT(watched.rating) AS rated_count
+FROM
+    watched
+WHERE
+    watched.user_id = ?
+    AND watched.rating IS NOT NULL
+`
+
+type GetRatingSummaryRow struct {
+	AverageRating float64
+	RatedCount    int64
 }
 
 // Rating stats.
@@ -1854,7 +3604,10 @@ func (q *Queries) GetRewatchStats(ctx context.Context, userID *int64) (GetRewatc
 const getSession = `-- name: GetSession :one
 SELECT
     user_id,
-    expires_at
+    expires_at,
+    max_expires_at,
+    last_seen_at,
+    impersonated_user_id
 FROM
     session
 WHERE
@@ -1863,17 +3616,80 @@ WHERE
 `
 
 type GetSessionRow struct {
-	UserID    int64
-	ExpiresAt time.Time
+	UserID             int64
+	ExpiresAt          time.Time
+	MaxExpiresAt       time.Time
+	LastSeenAt         *time.Time
+	ImpersonatedUserID *int64
 }
 
 func (q *Queries) GetSession(ctx context.Context, id string) (GetSessionRow, error) {
 	row := q.db.QueryRowContext(ctx, getSession, id)
 	var i GetSessionRow
-	err := row.Scan(&i.UserID, &i.ExpiresAt)
+	err := row.Scan(
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.MaxExpiresAt,
+		&i.LastSeenAt,
+		&i.ImpersonatedUserID,
+	)
 	return i, err
 }
 
+const getSessionsByUser = `-- name: GetSessionsByUser :many
+SELECT
+    id,
+    user_id,
+    expires_at,
+    created_at,
+    user_agent,
+    ip,
+    last_seen_at,
+    max_expires_at,
+    impersonated_user_id
+FROM
+    session
+WHERE
+    user_id = ?
+    AND expires_at > datetime('now')
+ORDER BY
+    last_seen_at DESC,
+    created_at DESC
+`
+
+func (q *Queries) GetSessionsByUser(ctx context.Context, userID int64) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, getSessionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UserAgent,
+			&i.Ip,
+			&i.LastSeenAt,
+			&i.MaxExpiresAt,
+			&i.ImpersonatedUserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getShortestWatchedMovie = `-- name: GetShortestWatchedMovie :one
 SELECT
     movie.id,
@@ -1917,40 +3733,342 @@ func (q *Queries) GetShortestWatchedMovie(ctx context.Context, userID *int64) (G
 	return i, err
 }
 
-const getTheaterVsHomeAverageRating = `-- name: GetTheaterVsHomeAverageRating :many
+const getSmartListMovies = `-- name: GetSmartListMovies :many
 SELECT
-    watched.watched_in_theater,
-    CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
-    COUNT(watched.rating) AS rated_count
+    list_rule.list_id,
+    movie.id, movie.title, movie.original_title, movie.original_language, movie.overview, movie.release_date, movie.poster_path, movie.backdrop_path, movie.popularity, movie.vote_count, movie.vote_average, movie.budget, movie.homepage, movie.imdb_id, movie.revenue, movie.runtime, movie.status, movie.tagline, movie.updated_at,
+    (
+        SELECT
+            MAX(last_watch.watched_date)
+        FROM
+            watched last_watch
+        WHERE
+            last_watch.movie_id = movie.id
+            AND last_watch.user_id = list.user_id
+    ) AS last_watched_date
 FROM
-    watched
+    list_rule
+    JOIN list ON list.id = list_rule.list_id
+    JOIN movie
 WHERE
-    watched.user_id = ?
-    AND watched.rating IS NOT NULL
-GROUP BY
-    watched.watched_in_theater
-`
-
-type GetTheaterVsHomeAverageRatingRow struct {
-	WatchedInTheater bool
-	AverageRating    float64
-	RatedCount       int64
-}
-
-func (q *Queries) GetTheaterVsHomeAverageRating(ctx context.Context, userID *int64) ([]GetTheaterVsHomeAverageRatingRow, error) {
-	rows, err := q.db.QueryContext(ctx, getTheaterVsHomeAverageRating, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetTheaterVsHomeAverageRatingRow
-	for rows.Next() {
-		var i GetTheaterVsHomeAverageRatingRow
-		if err := rows.Scan(&i.WatchedInTheater, &i.AverageRating, &i.RatedCount); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
+    -- movie_id comes before the slice, its numbered parameter would shift once the slice
+    -- is expanded otherwise
+    (
+        movie.id = ?1
+        OR ?1 IS NULL
+    )
+    AND list_rule.list_id IN (/*SLICE:list_ids*/?)
+    AND (
+        EXISTS (
+            SELECT
+                1
+            FROM
+                watched
+            WHERE
+                watched.movie_id = movie.id
+                AND watched.user_id = list.user_id
+        )
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                list_movie
+                JOIN list owned ON owned.id = list_movie.list_id
+            WHERE
+                list_movie.movie_id = movie.id
+                AND owned.user_id = list.user_id
+        )
+    )
+    AND (
+        list_rule.watched IS NULL
+        OR list_rule.watched = EXISTS (
+            SELECT
+                1
+            FROM
+                watched
+            WHERE
+                watched.movie_id = movie.id
+                AND watched.user_id = list.user_id
+        )
+    )
+    AND (
+        (
+            list_rule.in_theater IS NULL
+            AND list_rule.watched_year IS NULL
+            AND list_rule.min_rating IS NULL
+        )
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                watched
+            WHERE
+                watched.movie_id = movie.id
+                AND watched.user_id = list.user_id
+                AND (
+                    list_rule.in_theater IS NULL
+                    OR watched.watched_in_theater = list_rule.in_theater
+                )
+                AND (
+                    list_rule.watched_year IS NULL
+                    OR CAST(strftime('%Y', watched.watched_date) AS INTEGER) = list_rule.watched_year
+                )
+                AND (
+                    list_rule.min_rating IS NULL
+                    OR watched.rating >= list_rule.min_rating
+                )
+        )
+    )
+    AND (
+        list_rule.min_runtime IS NULL
+        OR movie.runtime >= list_rule.min_runtime
+    )
+    AND (
+        list_rule.max_runtime IS NULL
+        OR (
+            movie.runtime > 0
+            AND movie.runtime <= list_rule.max_runtime
+        )
+    )
+    AND (
+        list_rule.release_year_from IS NULL
+        OR CAST(strftime('%Y', movie.release_date) AS INTEGER) >= list_rule.release_year_from
+    )
+    AND (
+        list_rule.release_year_to IS NULL
+        OR CAST(strftime('%Y', movie.release_date) AS INTEGER) <= list_rule.release_year_to
+    )
+    AND (
+        list_rule.genre_id IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                genre_movie
+            WHERE
+                genre_movie.movie_id = movie.id
+                AND genre_movie.genre_id = list_rule.genre_id
+        )
+    )
+    AND (
+        list_rule.in_watchlist IS NULL
+        OR list_rule.in_watchlist = EXISTS (
+            SELECT
+                1
+            FROM
+                list_movie
+                JOIN list watchlist ON watchlist.id = list_movie.list_id
+            WHERE
+                list_movie.movie_id = movie.id
+                AND watchlist.user_id = list.user_id
+                AND watchlist.is_watchlist = TRUE
+        )
+    )
+    AND (
+        list_rule.director_min_rating IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                crew
+            WHERE
+                crew.movie_id = movie.id
+                AND crew.job = 'Director'
+                AND crew.person_id IN (
+                    SELECT
+                        rated.person_id
+                    FROM
+                        crew rated
+                        JOIN watched ON watched.movie_id = rated.movie_id
+                    WHERE
+                        rated.job = 'Director'
+                        AND watched.user_id = list.user_id
+                        AND watched.rating IS NOT NULL
+                    GROUP BY
+                        rated.person_id
+                    HAVING
+                        AVG(watched.rating) >= list_rule.director_min_rating
+                )
+        )
+    )
+    AND (
+        list_rule.person_name IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                "cast"
+                JOIN person ON person.id = "cast".person_id
+            WHERE
+                "cast".movie_id = movie.id
+                AND person.name = list_rule.person_name COLLATE NOCASE
+        )
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                crew
+                JOIN person ON person.id = crew.person_id
+            WHERE
+                crew.movie_id = movie.id
+                AND person.name = list_rule.person_name COLLATE NOCASE
+        )
+    )
+ORDER BY
+    last_watched_date IS NULL,
+    last_watched_date DESC,
+    movie.title
+`
+
+type GetSmartListMoviesParams struct {
+	MovieID *int64
+	ListIds []int64
+}
+
+type GetSmartListMoviesRow struct {
+	ListID          int64
+	Movie           Movie
+	LastWatchedDate interface{}
+}
+
+// Evaluates the stored rules of a smart list over the library of its owner, the movies
+// they watched or keep in one of their lists. The movie table caches every movie anyone
+// looked at, so it is not a library on its own. A rule that is NULL matches every movie.
+// Several lists can be evaluated at once, a movie_id only checks whether that movie
+// matches them.
+func (q *Queries) GetSmartListMovies(ctx context.Context, arg GetSmartListMoviesParams) ([]GetSmartListMoviesRow, error) {
+	query := getSmartListMovies
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.MovieID)
+	if len(arg.ListIds) > 0 {
+		for _, v := range arg.ListIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:list_ids*/?", strings.Repeat(",?", len(arg.ListIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:list_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSmartListMoviesRow
+	for rows.Next() {
+		var i GetSmartListMoviesRow
+		if err := rows.Scan(
+			&i.ListID,
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.OriginalTitle,
+			&i.Movie.OriginalLanguage,
+			&i.Movie.Overview,
+			&i.Movie.ReleaseDate,
+			&i.Movie.PosterPath,
+			&i.Movie.BackdropPath,
+			&i.Movie.Popularity,
+			&i.Movie.VoteCount,
+			&i.Movie.VoteAverage,
+			&i.Movie.Budget,
+			&i.Movie.Homepage,
+			&i.Movie.ImdbID,
+			&i.Movie.Revenue,
+			&i.Movie.Runtime,
+			&i.Movie.Status,
+			&i.Movie.Tagline,
+			&i.Movie.UpdatedAt,
+			&i.LastWatchedDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSyncedListSources = `-- name: GetSyncedListSources :many
+SELECT
+    list_source.list_id,
+    list.user_id
+FROM
+    list_source
+    JOIN list ON list.id = list_source.list_id
+WHERE
+    list_source.sync = TRUE
+ORDER BY
+    list_source.synced_at
+`
+
+type GetSyncedListSourcesRow struct {
+	ListID int64
+	UserID *int64
+}
+
+// Returns the lists kept in sync with their source with their owner, least recently
+// synced first.
+func (q *Queries) GetSyncedListSources(ctx context.Context) ([]GetSyncedListSourcesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSyncedListSources)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSyncedListSourcesRow
+	for rows.Next() {
+		var i GetSyncedListSourcesRow
+		if err := rows.Scan(&i.ListID, &i.UserID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTheaterVsHomeAverageRating = `-- name: GetTheaterVsHomeAverageRating :many
+SELECT
+    watched.watched_in_theater,
+    CAST(COALESCE(AVG(CAST(watched.rating AS REAL)), 0.0) AS REAL) AS average_rating,
+    COUNT(watched.rating) AS rated_count
+FROM
+    watched
+WHERE
+    watched.user_id = ?
+    AND watched.rating IS NOT NULL
+GROUP BY
+    watched.watched_in_theater
+`
+
+type GetTheaterVsHomeAverageRatingRow struct {
+	WatchedInTheater bool
+	AverageRating    float64
+	RatedCount       int64
+}
+
+func (q *Queries) GetTheaterVsHomeAverageRating(ctx context.Context, userID *int64) ([]GetTheaterVsHomeAverageRatingRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTheaterVsHomeAverageRating, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTheaterVsHomeAverageRatingRow
+	for rows.Next() {
+		var i GetTheaterVsHomeAverageRatingRow
+		if err := rows.Scan(&i.WatchedInTheater, &i.AverageRating, &i.RatedCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
 	if err := rows.Close(); err != nil {
 		return nil, err
 	}
@@ -2153,7 +4271,7 @@ func (q *Queries) GetTotalWatchedStats(ctx context.Context, userID *int64) (GetT
 
 const getUserByEmail = `-- name: GetUserByEmail :one
 SELECT
-    id, email, password_hash, name, created_at, admin, password_reset_required
+    id, email, password_hash, name, created_at, admin, password_reset_required, deletion_scheduled_at, disabled_at
 FROM
     user
 WHERE
@@ -2171,13 +4289,15 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.CreatedAt,
 		&i.Admin,
 		&i.PasswordResetRequired,
+		&i.DeletionScheduledAt,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
 SELECT
-    id, email, password_hash, name, created_at, admin, password_reset_required
+    id, email, password_hash, name, created_at, admin, password_reset_required, deletion_scheduled_at, disabled_at
 FROM
     user
 WHERE
@@ -2195,72 +4315,91 @@ func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 		&i.CreatedAt,
 		&i.Admin,
 		&i.PasswordResetRequired,
+		&i.DeletionScheduledAt,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
-const getWatchedActors = `-- name: GetWatchedActors :many
-WITH watched_actors AS (
-    SELECT DISTINCT
-        watched.id AS watched_id,
-        person.id,
-        person.name,
-        person.profile_path,
-        person.gender
-    FROM
-        watched
-        JOIN "cast" ON watched.movie_id = "cast".movie_id
-        JOIN person ON "cast".person_id = person.id
-    WHERE
-        watched.user_id = ?
-        AND person.gender IN (1, 2)
-)
+const getUserByIdentity = `-- name: GetUserByIdentity :one
 SELECT
-    watched_actors.name,
-    watched_actors.id,
-    watched_actors.profile_path,
-    watched_actors.gender,
-    COUNT(*) AS watch_count
+    u.id, u.email, u.password_hash, u.name, u.created_at, u.admin, u.password_reset_required, u.deletion_scheduled_at, u.disabled_at
 FROM
-    watched_actors
-GROUP BY
-    watched_actors.id,
-    watched_actors.name,
-    watched_actors.profile_path,
-    watched_actors.gender
-ORDER BY
-    watched_actors.gender ASC,
-    watch_count DESC,
-    watched_actors.name ASC
+    user u
+    JOIN user_identity ui ON ui.user_id = u.id
+WHERE
+    ui.issuer = ?
+    AND ui.subject = ?
 `
 
-type GetWatchedActorsRow struct {
-	Name        string
-	ID          int64
-	ProfilePath string
-	Gender      int64
-	WatchCount  int64
+type GetUserByIdentityParams struct {
+	Issuer  string
+	Subject string
 }
 
-func (q *Queries) GetWatchedActors(ctx context.Context, userID *int64) ([]GetWatchedActorsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getWatchedActors, userID)
+// External identities.
+func (q *Queries) GetUserByIdentity(ctx context.Context, arg GetUserByIdentityParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIdentity, arg.Issuer, arg.Subject)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.CreatedAt,
+		&i.Admin,
+		&i.PasswordResetRequired,
+		&i.DeletionScheduledAt,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const getUserTOTP = `-- name: GetUserTOTP :one
+SELECT
+    user_id, secret, enabled, last_step, created_at
+FROM
+    user_totp
+WHERE
+    user_id = ?
+`
+
+func (q *Queries) GetUserTOTP(ctx context.Context, userID int64) (UserTotp, error) {
+	row := q.db.QueryRowContext(ctx, getUserTOTP, userID)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.Enabled,
+		&i.LastStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUsersDueForDeletion = `-- name: GetUsersDueForDeletion :many
+SELECT
+    id
+FROM
+    user
+WHERE
+    deletion_scheduled_at IS NOT NULL
+    AND deletion_scheduled_at <= ?1
+`
+
+func (q *Queries) GetUsersDueForDeletion(ctx context.Context, now *time.Time) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersDueForDeletion, now)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetWatchedActorsRow
+	var items []int64
 	for rows.Next() {
-		var i GetWatchedActorsRow
-		if err := rows.Scan(
-			&i.Name,
-			&i.ID,
-			&i.ProfilePath,
-			&i.Gender,
-			&i.WatchCount,
-		); err != nil {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -2271,18 +4410,168 @@ func (q *Queries) GetWatchedActors(ctx context.Context, userID *int64) ([]GetWat
 	return items, nil
 }
 
-const getWatchedByGenre = `-- name: GetWatchedByGenre :many
+const getVisibleSmartLists = `-- name: GetVisibleSmartLists :many
 SELECT
-    genre.name,
-    COUNT(*) AS count
+    list.id,
+    list.name,
+    owner.name AS owner_name,
+    list_member.role AS member_role
 FROM
-    watched
-    JOIN movie ON watched.movie_id = movie.id
-    JOIN genre_movie ON movie.id = genre_movie.movie_id
-    JOIN genre ON genre_movie.genre_id = genre.id
+    list
+    JOIN list_rule ON list_rule.list_id = list.id
+    JOIN user owner ON owner.id = list.user_id
+    LEFT JOIN list_member ON list_member.list_id = list.id
+    AND list_member.user_id = ?1
 WHERE
-    watched.user_id = ?
-GROUP BY
+    list.user_id = ?1
+    OR list_member.user_id IS NOT NULL
+ORDER BY
+    list_member.user_id IS NOT NULL,
+    list.id
+`
+
+type GetVisibleSmartListsRow struct {
+	ID         int64
+	Name       string
+	OwnerName  string
+	MemberRole *string
+}
+
+// The smart lists the user owns or is a member of, their own before the ones shared with
+// them. Their movies are not stored, GetSmartListMovies evaluates them.
+func (q *Queries) GetVisibleSmartLists(ctx context.Context, userID int64) ([]GetVisibleSmartListsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getVisibleSmartLists, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetVisibleSmartListsRow
+	for rows.Next() {
+		var i GetVisibleSmartListsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.OwnerName,
+			&i.MemberRole,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWatchNextFilter = `-- name: GetWatchNextFilter :one
+SELECT
+    user_id, min_priority, tag, exclude_tag
+FROM
+    watch_next_filter
+WHERE
+    user_id = ?
+`
+
+func (q *Queries) GetWatchNextFilter(ctx context.Context, userID int64) (WatchNextFilter, error) {
+	row := q.db.QueryRowContext(ctx, getWatchNextFilter, userID)
+	var i WatchNextFilter
+	err := row.Scan(
+		&i.UserID,
+		&i.MinPriority,
+		&i.Tag,
+		&i.ExcludeTag,
+	)
+	return i, err
+}
+
+const getWatchedActors = `-- name: GetWatchedActors :many
+WITH watched_actors AS (
+    SELECT DISTINCT
+        watched.id AS watched_id,
+        person.id,
+        person.name,
+        person.profile_path,
+        person.gender
+    FROM
+        watched
+        JOIN "cast" ON watched.movie_id = "cast".movie_id
+        JOIN person ON "cast".person_id = person.id
+    WHERE
+        watched.user_id = ?
+        AND person.gender IN (1, 2)
+)
+SELECT
+    watched_actors.name,
+    watched_actors.id,
+    watched_actors.profile_path,
+    watched_actors.gender,
+    COUNT(*) AS watch_count
+FROM
+    watched_actors
+GROUP BY
+    watched_actors.id,
+    watched_actors.name,
+    watched_actors.profile_path,
+    watched_actors.gender
+ORDER BY
+    watched_actors.gender ASC,
+    watch_count DESC,
+    watched_actors.name ASC
+`
+
+type GetWatchedActorsRow struct {
+	Name        string
+	ID          int64
+	ProfilePath string
+	Gender      int64
+	WatchCount  int64
+}
+
+func (q *Queries) GetWatchedActors(ctx context.Context, userID *int64) ([]GetWatchedActorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWatchedActors, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWatchedActorsRow
+	for rows.Next() {
+		var i GetWatchedActorsRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.ID,
+			&i.ProfilePath,
+			&i.Gender,
+			&i.WatchCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWatchedByGenre = `-- name: GetWatchedByGenre :many
+SELECT
+    genre.name,
+    COUNT(*) AS count
+FROM
+    watched
+    JOIN movie ON watched.movie_id = movie.id
+    JOIN genre_movie ON movie.id = genre_movie.movie_id
+    JOIN genre ON genre_movie.genre_id = genre.id
+WHERE
+    watched.user_id = ?
+GROUP BY
     genre.id,
     genre.name
 ORDER BY
@@ -2819,6 +5108,127 @@ func (q *Queries) GetWatchlistID(ctx context.Context, userID *int64) (int64, err
 	return id, err
 }
 
+const getWebhookDeliveriesByUser = `-- name: GetWebhookDeliveriesByUser :many
+SELECT
+    webhook_delivery.id,
+    webhook_delivery.webhook_id,
+    webhook.url,
+    webhook_delivery.event,
+    webhook_delivery.status,
+    webhook_delivery.attempts,
+    webhook_delivery.next_attempt_at,
+    webhook_delivery.last_attempt_at,
+    webhook_delivery.response_status,
+    webhook_delivery.last_error,
+    webhook_delivery.created_at
+FROM
+    webhook_delivery
+    JOIN webhook ON webhook.id = webhook_delivery.webhook_id
+WHERE
+    webhook.user_id = ?
+ORDER BY
+    webhook_delivery.created_at DESC,
+    webhook_delivery.id DESC
+LIMIT
+    ?
+`
+
+type GetWebhookDeliveriesByUserParams struct {
+	UserID int64
+	Limit  int64
+}
+
+type GetWebhookDeliveriesByUserRow struct {
+	ID             int64
+	WebhookID      int64
+	Url            string
+	Event          string
+	Status         string
+	Attempts       int64
+	NextAttemptAt  time.Time
+	LastAttemptAt  *time.Time
+	ResponseStatus *int64
+	LastError      *string
+	CreatedAt      time.Time
+}
+
+func (q *Queries) GetWebhookDeliveriesByUser(ctx context.Context, arg GetWebhookDeliveriesByUserParams) ([]GetWebhookDeliveriesByUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWebhookDeliveriesByUser, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWebhookDeliveriesByUserRow
+	for rows.Next() {
+		var i GetWebhookDeliveriesByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Url,
+			&i.Event,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastAttemptAt,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhooksByUser = `-- name: GetWebhooksByUser :many
+SELECT
+    id, user_id, url, secret, events, enabled, created_at
+FROM
+    webhook
+WHERE
+    user_id = ?
+ORDER BY
+    id
+`
+
+func (q *Queries) GetWebhooksByUser(ctx context.Context, userID int64) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, getWebhooksByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.Enabled,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWeekdayDistribution = `-- name: GetWeekdayDistribution :many
 SELECT
     CAST(strftime('%w', watched_date) AS INTEGER) AS weekday_index,
@@ -2861,92 +5271,770 @@ func (q *Queries) GetWeekdayDistribution(ctx context.Context, userID *int64) ([]
 	return items, nil
 }
 
-const insertList = `-- name: InsertList :one
-INSERT INTO
-    list (
-        name,
-        creation_date,
-        description,
-        user_id,
-		is_watchlist
-    )
-VALUES
-    (?, ?, ?, ?, ?)
-RETURNING
-    id
+const incrementMFAChallengeAttempts = `-- name: IncrementMFAChallengeAttempts :one
+UPDATE
+    mfa_challenge
+SET
+    attempts = attempts + 1
+WHERE
+    token_hash = ?
+RETURNING
+    attempts
+`
+
+func (q *Queries) IncrementMFAChallengeAttempts(ctx context.Context, tokenHash string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, incrementMFAChallengeAttempts, tokenHash)
+	var attempts int64
+	err := row.Scan(&attempts)
+	return attempts, err
+}
+
+const insertAuditEntry = `-- name: InsertAuditEntry :exec
+INSERT INTO
+    audit_log (
+        action,
+        actor_id,
+        actor_email,
+        target_id,
+        target_email,
+        ip,
+        detail,
+        created_at
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type InsertAuditEntryParams struct {
+	Action      string
+	ActorID     *int64
+	ActorEmail  string
+	TargetID    *int64
+	TargetEmail string
+	Ip          string
+	Detail      string
+	CreatedAt   time.Time
+}
+
+// Audit log.
+func (q *Queries) InsertAuditEntry(ctx context.Context, arg InsertAuditEntryParams) error {
+	_, err := q.db.ExecContext(ctx, insertAuditEntry,
+		arg.Action,
+		arg.ActorID,
+		arg.ActorEmail,
+		arg.TargetID,
+		arg.TargetEmail,
+		arg.Ip,
+		arg.Detail,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const insertList = `-- name: InsertList :one
+INSERT INTO
+    list (
+        name,
+        creation_date,
+        description,
+        user_id,
+		is_watchlist
+    )
+VALUES
+    (?, ?, ?, ?, ?)
+RETURNING
+    id
+`
+
+type InsertListParams struct {
+	Name         string
+	CreationDate string
+	Description  *string
+	UserID       *int64
+	IsWatchlist  bool
+}
+
+// Lists and watchlist.
+func (q *Queries) InsertList(ctx context.Context, arg InsertListParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, insertList,
+		arg.Name,
+		arg.CreationDate,
+		arg.Description,
+		arg.UserID,
+		arg.IsWatchlist,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const insertListMovieTag = `-- name: InsertListMovieTag :exec
+INSERT INTO
+    list_movie_tag (list_id, movie_id, tag)
+VALUES
+    (?, ?, ?)
+`
+
+type InsertListMovieTagParams struct {
+	ListID  int64
+	MovieID int64
+	Tag     string
+}
+
+func (q *Queries) InsertListMovieTag(ctx context.Context, arg InsertListMovieTagParams) error {
+	_, err := q.db.ExecContext(ctx, insertListMovieTag, arg.ListID, arg.MovieID, arg.Tag)
+	return err
+}
+
+const insertWatched = `-- name: InsertWatched :one
+INSERT INTO
+    watched (movie_id, watched_date, watched_in_theater, user_id, rating)
+VALUES
+    (?, ?, ?, ?, ?)
+RETURNING
+    id, movie_id, user_id, watched_date, watched_in_theater, rating
+`
+
+type InsertWatchedParams struct {
+	MovieID          int64
+	WatchedDate      date.Date
+	WatchedInTheater bool
+	UserID           *int64
+	Rating           *float64
+}
+
+// Watched history and activity.
+func (q *Queries) InsertWatched(ctx context.Context, arg InsertWatchedParams) (Watched, error) {
+	row := q.db.QueryRowContext(ctx, insertWatched,
+		arg.MovieID,
+		arg.WatchedDate,
+		arg.WatchedInTheater,
+		arg.UserID,
+		arg.Rating,
+	)
+	var i Watched
+	err := row.Scan(
+		&i.ID,
+		&i.MovieID,
+		&i.UserID,
+		&i.WatchedDate,
+		&i.WatchedInTheater,
+		&i.Rating,
+	)
+	return i, err
+}
+
+const insertWebhook = `-- name: InsertWebhook :one
+INSERT INTO
+    webhook (user_id, url, secret, events, enabled, created_at)
+VALUES
+    (?, ?, ?, ?, TRUE, datetime('now'))
+RETURNING
+    id, user_id, url, secret, events, enabled, created_at
+`
+
+type InsertWebhookParams struct {
+	UserID int64
+	Url    string
+	Secret string
+	Events string
+}
+
+// Webhooks.
+func (q *Queries) InsertWebhook(ctx context.Context, arg InsertWebhookParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, insertWebhook,
+		arg.UserID,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Enabled,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertWebhookDelivery = `-- name: InsertWebhookDelivery :exec
+INSERT INTO
+    webhook_delivery (
+        webhook_id,
+        event,
+        payload,
+        status,
+        attempts,
+        next_attempt_at,
+        created_at
+    )
+VALUES
+    (?, ?, ?, 'pending', 0, ?, ?)
+`
+
+type InsertWebhookDeliveryParams struct {
+	WebhookID     int64
+	Event         string
+	Payload       string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+}
+
+func (q *Queries) InsertWebhookDelivery(ctx context.Context, arg InsertWebhookDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, insertWebhookDelivery,
+		arg.WebhookID,
+		arg.Event,
+		arg.Payload,
+		arg.NextAttemptAt,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const listAuditEntries = `-- name: ListAuditEntries :many
+SELECT
+    id, "action", actor_id, actor_email, target_id, target_email, ip, detail, created_at
+FROM
+    audit_log
+WHERE
+    (
+        CAST(?1 AS TEXT) = ''
+        OR action = ?1
+    )
+    AND (
+        CAST(?2 AS INTEGER) = 0
+        OR actor_id = ?2
+        OR target_id = ?2
+    )
+    AND (
+        CAST(?3 AS TEXT) = ''
+        OR actor_email LIKE '%' || ?3 || '%'
+        OR target_email LIKE '%' || ?3 || '%'
+        OR ip LIKE '%' || ?3 || '%'
+        OR detail LIKE '%' || ?3 || '%'
+    )
+    AND created_at >= ?4
+    AND created_at < ?5
+ORDER BY
+    created_at DESC,
+    id DESC
+LIMIT
+    ?6
+`
+
+type ListAuditEntriesParams struct {
+	Action     string
+	UserID     int64
+	Search     string
+	Since      time.Time
+	Until      time.Time
+	MaxEntries int64
+}
+
+// ListAuditEntries returns the newest entries first. Empty filters match everything, user
+// matches the actor or the target and search matches emails, IP and detail.
+func (q *Queries) ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEntries,
+		arg.Action,
+		arg.UserID,
+		arg.Search,
+		arg.Since,
+		arg.Until,
+		arg.MaxEntries,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.ActorID,
+			&i.ActorEmail,
+			&i.TargetID,
+			&i.TargetEmail,
+			&i.Ip,
+			&i.Detail,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUserLogin = `-- name: LockUserLogin :exec
+UPDATE
+    login_lockout
+SET
+    locked_until = ?
+WHERE
+    user_id = ?
+`
+
+type LockUserLoginParams struct {
+	LockedUntil *time.Time
+	UserID      int64
+}
+
+func (q *Queries) LockUserLogin(ctx context.Context, arg LockUserLoginParams) error {
+	_, err := q.db.ExecContext(ctx, lockUserLogin, arg.LockedUntil, arg.UserID)
+	return err
+}
+
+const recordFailedLogin = `-- name: RecordFailedLogin :one
+INSERT INTO
+    login_lockout (user_id, failed_count, last_failed_at)
+VALUES
+    (?, 1, ?) ON CONFLICT(user_id) DO
+UPDATE
+SET
+    failed_count = failed_count + 1,
+    last_failed_at = excluded.last_failed_at
+RETURNING
+    failed_count
+`
+
+type RecordFailedLoginParams struct {
+	UserID       int64
+	LastFailedAt time.Time
+}
+
+// Login lockout.
+func (q *Queries) RecordFailedLogin(ctx context.Context, arg RecordFailedLoginParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, recordFailedLogin, arg.UserID, arg.LastFailedAt)
+	var failed_count int64
+	err := row.Scan(&failed_count)
+	return failed_count, err
+}
+
+const releaseInvite = `-- name: ReleaseInvite :exec
+UPDATE
+    invite
+SET
+    used_at = NULL
+WHERE
+    id = ?
+    AND used_by IS NULL
+`
+
+func (q *Queries) ReleaseInvite(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, releaseInvite, id)
+	return err
+}
+
+const renamePasskey = `-- name: RenamePasskey :execrows
+UPDATE
+    passkey
+SET
+    name = ?
+WHERE
+    id = ?
+    AND user_id = ?
+`
+
+type RenamePasskeyParams struct {
+	Name   string
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) RenamePasskey(ctx context.Context, arg RenamePasskeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renamePasskey, arg.Name, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setAdmin = `-- name: SetAdmin :exec
+UPDATE
+    user
+SET
+    admin = TRUE
+WHERE
+    id = ?
+`
+
+func (q *Queries) SetAdmin(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, setAdmin, id)
+	return err
+}
+
+const setInviteUsedBy = `-- name: SetInviteUsedBy :exec
+UPDATE
+    invite
+SET
+    used_by = ?
+WHERE
+    id = ?
+`
+
+type SetInviteUsedByParams struct {
+	UsedBy *int64
+	ID     int64
+}
+
+func (q *Queries) SetInviteUsedBy(ctx context.Context, arg SetInviteUsedByParams) error {
+	_, err := q.db.ExecContext(ctx, setInviteUsedBy, arg.UsedBy, arg.ID)
+	return err
+}
+
+const setListMoviePosition = `-- name: SetListMoviePosition :execrows
+UPDATE
+    list_movie
+SET
+    position = ?
+WHERE
+    list_id = ?
+    AND movie_id = ?
+`
+
+type SetListMoviePositionParams struct {
+	Position *int64
+	ListID   int64
+	MovieID  int64
+}
+
+func (q *Queries) SetListMoviePosition(ctx context.Context, arg SetListMoviePositionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setListMoviePosition, arg.Position, arg.ListID, arg.MovieID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setListRanked = `-- name: SetListRanked :execrows
+UPDATE
+    list
+SET
+    ranked = ?
+WHERE
+    user_id = ?
+    AND id = ?
+`
+
+type SetListRankedParams struct {
+	Ranked bool
+	UserID *int64
+	ID     int64
+}
+
+func (q *Queries) SetListRanked(ctx context.Context, arg SetListRankedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setListRanked, arg.Ranked, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setListShare = `-- name: SetListShare :execrows
+UPDATE
+    list
+SET
+    share_token = ?,
+    share_notes = ?
+WHERE
+    user_id = ?
+    AND id = ?
+`
+
+type SetListShareParams struct {
+	ShareToken *string
+	ShareNotes bool
+	UserID     *int64
+	ID         int64
+}
+
+func (q *Queries) SetListShare(ctx context.Context, arg SetListShareParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setListShare,
+		arg.ShareToken,
+		arg.ShareNotes,
+		arg.UserID,
+		arg.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setListSourceSync = `-- name: SetListSourceSync :execrows
+UPDATE
+    list_source
+SET
+    sync = ?1
+WHERE
+    list_id = ?2
+    AND EXISTS (
+        SELECT
+            1
+        FROM
+            list
+        WHERE
+            list.id = list_source.list_id
+            AND list.user_id = ?3
+    )
+`
+
+type SetListSourceSyncParams struct {
+	Sync   bool
+	ListID int64
+	UserID *int64
+}
+
+func (q *Queries) SetListSourceSync(ctx context.Context, arg SetListSourceSyncParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setListSourceSync, arg.Sync, arg.ListID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setListSourceSyncedAt = `-- name: SetListSourceSyncedAt :exec
+UPDATE
+    list_source
+SET
+    synced_at = ?
+WHERE
+    list_id = ?
+`
+
+type SetListSourceSyncedAtParams struct {
+	SyncedAt time.Time
+	ListID   int64
+}
+
+func (q *Queries) SetListSourceSyncedAt(ctx context.Context, arg SetListSourceSyncedAtParams) error {
+	_, err := q.db.ExecContext(ctx, setListSourceSyncedAt, arg.SyncedAt, arg.ListID)
+	return err
+}
+
+const setSessionImpersonation = `-- name: SetSessionImpersonation :execrows
+UPDATE
+    session
+SET
+    impersonated_user_id = ?
+WHERE
+    id = ?
+`
+
+type SetSessionImpersonationParams struct {
+	ImpersonatedUserID *int64
+	ID                 string
+}
+
+// SetSessionImpersonation sets the user an admin session views gowatch as, NULL ends it.
+func (q *Queries) SetSessionImpersonation(ctx context.Context, arg SetSessionImpersonationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setSessionImpersonation, arg.ImpersonatedUserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserDeletionScheduledAt = `-- name: SetUserDeletionScheduledAt :exec
+UPDATE
+    user
+SET
+    deletion_scheduled_at = ?
+WHERE
+    id = ?
+`
+
+type SetUserDeletionScheduledAtParams struct {
+	DeletionScheduledAt *time.Time
+	ID                  int64
+}
+
+// Self-service account deletion.
+func (q *Queries) SetUserDeletionScheduledAt(ctx context.Context, arg SetUserDeletionScheduledAtParams) error {
+	_, err := q.db.ExecContext(ctx, setUserDeletionScheduledAt, arg.DeletionScheduledAt, arg.ID)
+	return err
+}
+
+const setUserDisabledAt = `-- name: SetUserDisabledAt :exec
+UPDATE
+    user
+SET
+    disabled_at = ?
+WHERE
+    id = ?
+`
+
+type SetUserDisabledAtParams struct {
+	DisabledAt *time.Time
+	ID         int64
+}
+
+func (q *Queries) SetUserDisabledAt(ctx context.Context, arg SetUserDisabledAtParams) error {
+	_, err := q.db.ExecContext(ctx, setUserDisabledAt, arg.DisabledAt, arg.ID)
+	return err
+}
+
+const setWebhookEnabled = `-- name: SetWebhookEnabled :execrows
+UPDATE
+    webhook
+SET
+    enabled = ?
+WHERE
+    id = ?
+    AND user_id = ?
+`
+
+type SetWebhookEnabledParams struct {
+	Enabled bool
+	ID      int64
+	UserID  int64
+}
+
+func (q *Queries) SetWebhookEnabled(ctx context.Context, arg SetWebhookEnabledParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setWebhookEnabled, arg.Enabled, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const touchSession = `-- name: TouchSession :exec
+UPDATE
+    session
+SET
+    last_seen_at = ?,
+    expires_at = ?,
+    user_agent = ?,
+    ip = ?
+WHERE
+    id = ?
+`
+
+type TouchSessionParams struct {
+	LastSeenAt *time.Time
+	ExpiresAt  time.Time
+	UserAgent  string
+	Ip         string
+	ID         string
+}
+
+func (q *Queries) TouchSession(ctx context.Context, arg TouchSessionParams) error {
+	_, err := q.db.ExecContext(ctx, touchSession,
+		arg.LastSeenAt,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.Ip,
+		arg.ID,
+	)
+	return err
+}
+
+const unsetAdmin = `-- name: UnsetAdmin :exec
+UPDATE
+    user
+SET
+    admin = FALSE
+WHERE
+    id = ?
 `
 
-type InsertListParams struct {
-	Name         string
-	CreationDate string
-	Description  *string
-	UserID       *int64
-	IsWatchlist  bool
+func (q *Queries) UnsetAdmin(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, unsetAdmin, id)
+	return err
 }
 
-// Lists and watchlist.
-func (q *Queries) InsertList(ctx context.Context, arg InsertListParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, insertList,
+const updateList = `-- name: UpdateList :execrows
+UPDATE
+    list
+SET
+    name = ?,
+    description = ?
+WHERE
+    user_id = ?
+    AND id = ?
+`
+
+type UpdateListParams struct {
+	Name        string
+	Description *string
+	UserID      *int64
+	ID          int64
+}
+
+func (q *Queries) UpdateList(ctx context.Context, arg UpdateListParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateList,
 		arg.Name,
-		arg.CreationDate,
 		arg.Description,
 		arg.UserID,
-		arg.IsWatchlist,
+		arg.ID,
 	)
-	var id int64
-	err := row.Scan(&id)
-	return id, err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const insertWatched = `-- name: InsertWatched :one
-INSERT INTO
-    watched (movie_id, watched_date, watched_in_theater, user_id, rating)
-VALUES
-    (?, ?, ?, ?, ?)
-RETURNING
-    id, movie_id, user_id, watched_date, watched_in_theater, rating
+const updateListMovieDetails = `-- name: UpdateListMovieDetails :execrows
+UPDATE list_movie
+SET
+    priority = ?,
+    available_on = ?
+WHERE
+    list_id = ?
+    AND movie_id = ?
 `
 
-type InsertWatchedParams struct {
-	MovieID          int64
-	WatchedDate      date.Date
-	WatchedInTheater bool
-	UserID           *int64
-	Rating           *float64
+type UpdateListMovieDetailsParams struct {
+	Priority    string
+	AvailableOn *string
+	ListID      int64
+	MovieID     int64
 }
 
-// Watched history and activity.
-func (q *Queries) InsertWatched(ctx context.Context, arg InsertWatchedParams) (Watched, error) {
-	row := q.db.QueryRowContext(ctx, insertWatched,
+// Watchlist items.
+func (q *Queries) UpdateListMovieDetails(ctx context.Context, arg UpdateListMovieDetailsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateListMovieDetails,
+		arg.Priority,
+		arg.AvailableOn,
+		arg.ListID,
 		arg.MovieID,
-		arg.WatchedDate,
-		arg.WatchedInTheater,
-		arg.UserID,
-		arg.Rating,
 	)
-	var i Watched
-	err := row.Scan(
-		&i.ID,
-		&i.MovieID,
-		&i.UserID,
-		&i.WatchedDate,
-		&i.WatchedInTheater,
-		&i.Rating,
-	)
-	return i, err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const setAdmin = `-- name: SetAdmin :exec
+const updatePasskeyUsage = `-- name: UpdatePasskeyUsage :exec
 UPDATE
-    user
+    passkey
 SET
-    admin = TRUE
+    sign_count = ?,
+    last_used_at = ?
 WHERE
     id = ?
 `
 
-func (q *Queries) SetAdmin(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, setAdmin, id)
+type UpdatePasskeyUsageParams struct {
+	SignCount  int64
+	LastUsedAt *time.Time
+	ID         int64
+}
+
+func (q *Queries) UpdatePasskeyUsage(ctx context.Context, arg UpdatePasskeyUsageParams) error {
+	_, err := q.db.ExecContext(ctx, updatePasskeyUsage, arg.SignCount, arg.LastUsedAt, arg.ID)
 	return err
 }
 
@@ -2988,6 +6076,27 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 	return err
 }
 
+const updateUserProfile = `-- name: UpdateUserProfile :exec
+UPDATE
+    user
+SET
+    name = ?,
+    email = ?
+WHERE
+    id = ?
+`
+
+type UpdateUserProfileParams struct {
+	Name  string
+	Email string
+	ID    int64
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserProfile, arg.Name, arg.Email, arg.ID)
+	return err
+}
+
 const updateWatched = `-- name: UpdateWatched :one
 UPDATE
     watched
@@ -3023,6 +6132,43 @@ func (q *Queries) UpdateWatched(ctx context.Context, arg UpdateWatchedParams) (i
 	return movie_id, err
 }
 
+const updateWebhookDeliveryAttempt = `-- name: UpdateWebhookDeliveryAttempt :exec
+UPDATE
+    webhook_delivery
+SET
+    status = ?,
+    attempts = ?,
+    next_attempt_at = ?,
+    last_attempt_at = ?,
+    response_status = ?,
+    last_error = ?
+WHERE
+    id = ?
+`
+
+type UpdateWebhookDeliveryAttemptParams struct {
+	Status         string
+	Attempts       int64
+	NextAttemptAt  time.Time
+	LastAttemptAt  *time.Time
+	ResponseStatus *int64
+	LastError      *string
+	ID             int64
+}
+
+func (q *Queries) UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebhookDeliveryAttempt,
+		arg.Status,
+		arg.Attempts,
+		arg.NextAttemptAt,
+		arg.LastAttemptAt,
+		arg.ResponseStatus,
+		arg.LastError,
+		arg.ID,
+	)
+	return err
+}
+
 const upsertCast = `-- name: UpsertCast :exec
 INSERT INTO
     cast (
@@ -3144,6 +6290,240 @@ func (q *Queries) UpsertGenreMovie(ctx context.Context, arg UpsertGenreMoviePara
 	return err
 }
 
+const upsertListMember = `-- name: UpsertListMember :execrows
+INSERT INTO
+    list_member (list_id, user_id, role, added_at)
+SELECT
+    list.id,
+    ?1,
+    ?2,
+    ?3
+FROM
+    list
+WHERE
+    list.id = ?4
+    AND list.user_id = ?5
+    AND list.is_watchlist = FALSE
+ON CONFLICT(list_id, user_id) DO
+UPDATE
+SET
+    role = excluded.role
+`
+
+type UpsertListMemberParams struct {
+	MemberID int64
+	Role     string
+	AddedAt  time.Time
+	ListID   int64
+	UserID   *int64
+}
+
+func (q *Queries) UpsertListMember(ctx context.Context, arg UpsertListMemberParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upsertListMember,
+		arg.MemberID,
+		arg.Role,
+		arg.AddedAt,
+		arg.ListID,
+		arg.UserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertListRules = `-- name: UpsertListRules :execrows
+INSERT INTO
+    list_rule (
+        list_id,
+        watched,
+        in_theater,
+        watched_year,
+        min_rating,
+        min_runtime,
+        max_runtime,
+        release_year_from,
+        release_year_to,
+        genre_id,
+        in_watchlist,
+        director_min_rating,
+        person_name
+    )
+SELECT
+    list.id,
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6,
+    ?7,
+    ?8,
+    ?9,
+    ?10,
+    ?11,
+    ?12
+FROM
+    list
+WHERE
+    list.id = ?13
+    AND list.user_id = ?14
+    AND list.is_watchlist = FALSE
+ON CONFLICT(list_id) DO
+UPDATE
+SET
+    watched = excluded.watched,
+    in_theater = excluded.in_theater,
+    watched_year = excluded.watched_year,
+    min_rating = excluded.min_rating,
+    min_runtime = excluded.min_runtime,
+    max_runtime = excluded.max_runtime,
+    release_year_from = excluded.release_year_from,
+    release_year_to = excluded.release_year_to,
+    genre_id = excluded.genre_id,
+    in_watchlist = excluded.in_watchlist,
+    director_min_rating = excluded.director_min_rating,
+    person_name = excluded.person_name
+`
+
+type UpsertListRulesParams struct {
+	Watched           *bool
+	InTheater         *bool
+	WatchedYear       *int64
+	MinRating         *float64
+	MinRuntime        *int64
+	MaxRuntime        *int64
+	ReleaseYearFrom   *int64
+	ReleaseYearTo     *int64
+	GenreID           *int64
+	InWatchlist       *bool
+	DirectorMinRating *float64
+	PersonName        *string
+	ListID            int64
+	UserID            *int64
+}
+
+func (q *Queries) UpsertListRules(ctx context.Context, arg UpsertListRulesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upsertListRules,
+		arg.Watched,
+		arg.InTheater,
+		arg.WatchedYear,
+		arg.MinRating,
+		arg.MinRuntime,
+		arg.MaxRuntime,
+		arg.ReleaseYearFrom,
+		arg.ReleaseYearTo,
+		arg.GenreID,
+		arg.InWatchlist,
+		arg.DirectorMinRating,
+		arg.PersonName,
+		arg.ListID,
+		arg.UserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertListSource = `-- name: UpsertListSource :execrows
+INSERT INTO
+    list_source (list_id, kind, tmdb_id, sync, synced_at)
+SELECT
+    list.id,
+    ?1,
+    ?2,
+    ?3,
+    ?4
+FROM
+    list
+WHERE
+    list.id = ?5
+    AND list.user_id = ?6
+    AND list.is_watchlist = FALSE
+ON CONFLICT(list_id) DO
+UPDATE
+SET
+    kind = excluded.kind,
+    tmdb_id = excluded.tmdb_id,
+    sync = excluded.sync,
+    synced_at = excluded.synced_at
+`
+
+type UpsertListSourceParams struct {
+	Kind     string
+	TmdbID   int64
+	Sync     bool
+	SyncedAt time.Time
+	ListID   int64
+	UserID   *int64
+}
+
+func (q *Queries) UpsertListSource(ctx context.Context, arg UpsertListSourceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upsertListSource,
+		arg.Kind,
+		arg.TmdbID,
+		arg.Sync,
+		arg.SyncedAt,
+		arg.ListID,
+		arg.UserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertListView = `-- name: UpsertListView :exec
+INSERT INTO
+    list_view (
+        user_id,
+        list_id,
+        sort,
+        sort_desc,
+        genre_id,
+        watched,
+        decade,
+        layout
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(user_id, list_id) DO
+UPDATE
+SET
+    sort = excluded.sort,
+    sort_desc = excluded.sort_desc,
+    genre_id = excluded.genre_id,
+    watched = excluded.watched,
+    decade = excluded.decade,
+    layout = excluded.layout
+`
+
+type UpsertListViewParams struct {
+	UserID   int64
+	ListID   int64
+	Sort     string
+	SortDesc bool
+	GenreID  *int64
+	Watched  *bool
+	Decade   *int64
+	Layout   string
+}
+
+func (q *Queries) UpsertListView(ctx context.Context, arg UpsertListViewParams) error {
+	_, err := q.db.ExecContext(ctx, upsertListView,
+		arg.UserID,
+		arg.ListID,
+		arg.Sort,
+		arg.SortDesc,
+		arg.GenreID,
+		arg.Watched,
+		arg.Decade,
+		arg.Layout,
+	)
+	return err
+}
+
 const upsertMovie = `-- name: UpsertMovie :exec
 INSERT INTO
     movie (
@@ -3264,14 +6644,16 @@ INSERT INTO
         list_id,
         date_added,
         position,
-        note
+        note,
+        added_by
     )
 SELECT
     ?,
     ?,
     ?,
     ?,
-    ?
+    ?,
+    list.user_id
 FROM
     list
 WHERE
@@ -3360,3 +6742,111 @@ func (q *Queries) UpsertPerson(ctx context.Context, arg UpsertPersonParams) erro
 	)
 	return err
 }
+
+const upsertUserTOTP = `-- name: UpsertUserTOTP :exec
+INSERT INTO
+    user_totp (user_id, secret, enabled, created_at)
+VALUES
+    (?, ?, FALSE, ?) ON CONFLICT(user_id) DO
+UPDATE
+SET
+    secret = excluded.secret,
+    enabled = FALSE,
+    last_step = NULL,
+    created_at = excluded.created_at
+`
+
+type UpsertUserTOTPParams struct {
+	UserID    int64
+	Secret    string
+	CreatedAt time.Time
+}
+
+// Two-factor authentication.
+// UpsertUserTOTP stores a new, not yet confirmed secret, replacing any previous one.
+func (q *Queries) UpsertUserTOTP(ctx context.Context, arg UpsertUserTOTPParams) error {
+	_, err := q.db.ExecContext(ctx, upsertUserTOTP, arg.UserID, arg.Secret, arg.CreatedAt)
+	return err
+}
+
+const upsertWatchNextFilter = `-- name: UpsertWatchNextFilter :exec
+INSERT INTO
+    watch_next_filter (user_id, min_priority, tag, exclude_tag)
+VALUES
+    (?, ?, ?, ?)
+ON CONFLICT(user_id) DO
+UPDATE
+SET
+    min_priority = excluded.min_priority,
+    tag = excluded.tag,
+    exclude_tag = excluded.exclude_tag
+`
+
+type UpsertWatchNextFilterParams struct {
+	UserID      int64
+	MinPriority string
+	Tag         *string
+	ExcludeTag  *string
+}
+
+func (q *Queries) UpsertWatchNextFilter(ctx context.Context, arg UpsertWatchNextFilterParams) error {
+	_, err := q.db.ExecContext(ctx, upsertWatchNextFilter,
+		arg.UserID,
+		arg.MinPriority,
+		arg.Tag,
+		arg.ExcludeTag,
+	)
+	return err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE
+    recovery_code
+SET
+    used_at = ?
+WHERE
+    user_id = ?
+    AND code_hash = ?
+    AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	UsedAt   *time.Time
+	UserID   int64
+	CodeHash string
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useRecoveryCode, arg.UsedAt, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE
+    user_totp
+SET
+    last_step = ?1
+WHERE
+    user_id = ?2
+    AND (
+        last_step IS NULL
+        OR last_step < ?1
+    )
+`
+
+type UseTOTPStepParams struct {
+	Step   *int64
+	UserID int64
+}
+
+// UseTOTPStep records an accepted time step, affecting no rows if it (or a later one) was already used.
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTOTPStep, arg.Step, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
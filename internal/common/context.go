@@ -9,7 +9,16 @@ import (
 
 type ContextKey string
 
-const UserKey ContextKey = "user"
+const (
+	UserKey      ContextKey = "user"
+	CSRFTokenKey ContextKey = "csrf_token"
+	ClientIPKey  ContextKey = "client_ip"
+	// ImpersonatorKey holds the admin viewing gowatch as the user in UserKey
+	ImpersonatorKey ContextKey = "impersonator"
+)
+
+// CSRFHeader is the request header HTMX uses to send the CSRF token
+const CSRFHeader = "X-CSRF-Token"
 
 // GetUser extracts userID from context
 func GetUser(ctx context.Context) (*models.User, error) {
@@ -19,3 +28,21 @@ func GetUser(ctx context.Context) (*models.User, error) {
 	}
 	return userID, nil
 }
+
+// GetCSRFToken extracts the CSRF token from context, or "" if there is none
+func GetCSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(CSRFTokenKey).(string)
+	return token
+}
+
+// GetClientIP extracts the client IP address from context, or "" if there is none
+func GetClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(ClientIPKey).(string)
+	return ip
+}
+
+// GetImpersonator extracts the admin impersonating the user in context, or nil if there is none
+func GetImpersonator(ctx context.Context) *models.User {
+	admin, _ := ctx.Value(ImpersonatorKey).(*models.User)
+	return admin
+}
@@ -6,8 +6,11 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/marcosalvi-01/gowatch/db"
 	"github.com/marcosalvi-01/gowatch/internal/models"
@@ -23,13 +26,15 @@ type Handlers struct {
 	db             db.DB
 	watchedService *services.WatchedService
 	listService    *services.ListService
+	auditService   *services.AuditService
 }
 
-func NewHandlers(db db.DB, watchedService *services.WatchedService, listService *services.ListService) *Handlers {
+func NewHandlers(db db.DB, watchedService *services.WatchedService, listService *services.ListService, auditService *services.AuditService) *Handlers {
 	return &Handlers{
 		db:             db,
 		watchedService: watchedService,
 		listService:    listService,
+		auditService:   auditService,
 	}
 }
 
@@ -37,6 +42,7 @@ func (h *Handlers) RegisterRoutes(r chi.Router) {
 	r.Get("/health", h.healthCheck)
 	r.Get("/export", h.exportData)
 	r.Post("/import", h.importData)
+	r.Patch("/lists/{id}", h.updateList)
 }
 
 func (h *Handlers) exportData(w http.ResponseWriter, r *http.Request) {
@@ -61,6 +67,7 @@ func (h *Handlers) exportData(w http.ResponseWriter, r *http.Request) {
 		Lists:   listsExport,
 	}
 
+	h.auditService.Record(r.Context(), models.AuditDataExported, nil, fmt.Sprintf("%d watched days, %d lists", len(watchedExport), len(listsExport)))
 	log.Info("successfully exported all data")
 	jsonResponse(w, http.StatusOK, export)
 }
@@ -98,6 +105,7 @@ func (h *Handlers) importData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Info("import request received", "totalDays", len(allData.Watched), "totalLists", totalLists, "totalMovies", totalMovies)
+	h.auditService.Record(r.Context(), models.AuditDataImported, nil, fmt.Sprintf("%d watched days, %d lists, %d movies", len(allData.Watched), totalLists, totalMovies))
 
 	ctx := context.WithoutCancel(r.Context())
 
@@ -113,6 +121,48 @@ func (h *Handlers) importData(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusAccepted, "import started")
 }
 
+func (h *Handlers) updateList(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid list ID", http.StatusBadRequest)
+		return
+	}
+
+	var update models.ListInfo
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		log.Error("failed to decode list update", "listID", listID, "error", err)
+		http.Error(w, "failed to decode request payload", http.StatusBadRequest)
+		return
+	}
+	description := ""
+	if update.Description != nil {
+		description = *update.Description
+	}
+
+	list, err := h.listService.UpdateList(r.Context(), listID, update.Name, description)
+	if err != nil {
+		log.Error("failed to update list", "listID", listID, "error", err)
+		switch {
+		case errors.Is(err, services.ErrInvalidListName), errors.Is(err, services.ErrInvalidListDescription), errors.Is(err, services.ErrWatchlistNotEditable):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrListNameTaken):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, services.ErrListNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to update the list due to an internal error.", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	log.Info("successfully updated list", "listID", listID)
+	jsonResponse(w, http.StatusOK, models.ListInfo{
+		ID:          list.ID,
+		Name:        list.Name,
+		Description: list.Description,
+	})
+}
+
 func (h *Handlers) healthCheck(w http.ResponseWriter, r *http.Request) {
 	log.Debug("checking health")
 
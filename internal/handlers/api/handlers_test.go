@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,6 +14,8 @@ import (
 	"github.com/marcosalvi-01/gowatch/internal/common"
 	"github.com/marcosalvi-01/gowatch/internal/models"
 	"github.com/marcosalvi-01/gowatch/internal/services"
+
+	"github.com/go-chi/chi/v5"
 )
 
 func getTestCtx() context.Context {
@@ -48,9 +51,9 @@ func TestHandlers_HealthCheck(t *testing.T) {
 	}
 	defer func() { _ = testDB.Close() }()
 
-	listService := services.NewListService(testDB, nil)
-	watchedService := services.NewWatchedService(testDB, listService, nil)
-	handlers := NewHandlers(testDB, watchedService, listService)
+	listService := services.NewListService(testDB, nil, nil)
+	watchedService := services.NewWatchedService(testDB, listService, nil, nil)
+	handlers := NewHandlers(testDB, watchedService, listService, nil)
 
 	req := httptest.NewRequest("GET", "/health", nil)
 	w := httptest.NewRecorder()
@@ -77,9 +80,9 @@ func TestHandlers_Import_InvalidJSON(t *testing.T) {
 	}
 	defer func() { _ = testDB.Close() }()
 
-	listService := services.NewListService(testDB, nil)
-	watchedService := services.NewWatchedService(testDB, listService, nil)
-	handlers := NewHandlers(testDB, watchedService, listService)
+	listService := services.NewListService(testDB, nil, nil)
+	watchedService := services.NewWatchedService(testDB, listService, nil, nil)
+	handlers := NewHandlers(testDB, watchedService, listService, nil)
 
 	req := httptest.NewRequest("POST", "/import", bytes.NewReader([]byte("invalid json")))
 	req.Header.Set("Content-Type", "application/json")
@@ -101,9 +104,9 @@ func TestHandlers_Export(t *testing.T) {
 	setupTestUser(t, testDB)
 
 	movieService := services.NewMovieService(testDB, nil, time.Hour)
-	listService := services.NewListService(testDB, movieService)
-	watchedService := services.NewWatchedService(testDB, listService, movieService)
-	handlers := NewHandlers(testDB, watchedService, listService)
+	listService := services.NewListService(testDB, movieService, nil)
+	watchedService := services.NewWatchedService(testDB, listService, movieService, nil)
+	handlers := NewHandlers(testDB, watchedService, listService, nil)
 
 	ctx := getTestCtx()
 	movie := &models.MovieDetails{Movie: models.Movie{ID: 1, Title: "Test Movie"}}
@@ -157,9 +160,9 @@ func TestHandlers_Import_LegacyPayloadRejected(t *testing.T) {
 	}
 	defer func() { _ = testDB.Close() }()
 
-	listService := services.NewListService(testDB, nil)
-	watchedService := services.NewWatchedService(testDB, listService, nil)
-	handlers := NewHandlers(testDB, watchedService, listService)
+	listService := services.NewListService(testDB, nil, nil)
+	watchedService := services.NewWatchedService(testDB, listService, nil, nil)
+	handlers := NewHandlers(testDB, watchedService, listService, nil)
 
 	payload := models.ImportWatchedMoviesLog{
 		{
@@ -195,9 +198,9 @@ func TestHandlers_Import_CombinedPayloadAccepted(t *testing.T) {
 	setupTestUser(t, testDB)
 
 	movieService := services.NewMovieService(testDB, nil, time.Hour)
-	listService := services.NewListService(testDB, movieService)
-	watchedService := services.NewWatchedService(testDB, listService, movieService)
-	handlers := NewHandlers(testDB, watchedService, listService)
+	listService := services.NewListService(testDB, movieService, nil)
+	watchedService := services.NewWatchedService(testDB, listService, movieService, nil)
+	handlers := NewHandlers(testDB, watchedService, listService, nil)
 
 	ctx := getTestCtx()
 	movie := &models.MovieDetails{Movie: models.Movie{ID: 1, Title: "Test Movie"}}
@@ -258,9 +261,9 @@ func TestHandlers_Import_RejectsPayloadWithoutMovies(t *testing.T) {
 	defer func() { _ = testDB.Close() }()
 
 	movieService := services.NewMovieService(testDB, nil, time.Hour)
-	listService := services.NewListService(testDB, movieService)
-	watchedService := services.NewWatchedService(testDB, listService, movieService)
-	handlers := NewHandlers(testDB, watchedService, listService)
+	listService := services.NewListService(testDB, movieService, nil)
+	watchedService := services.NewWatchedService(testDB, listService, movieService, nil)
+	handlers := NewHandlers(testDB, watchedService, listService, nil)
 
 	payload := models.ImportAllData{
 		Watched: models.ImportWatchedMoviesLog{
@@ -286,3 +289,53 @@ func TestHandlers_Import_RejectsPayloadWithoutMovies(t *testing.T) {
 		t.Fatalf("expected status 400, got %d", w.Code)
 	}
 }
+
+func TestHandlers_UpdateList(t *testing.T) {
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = testDB.Close() }()
+	setupTestUser(t, testDB)
+
+	listService := services.NewListService(testDB, nil, nil)
+	handlers := NewHandlers(testDB, nil, listService, nil)
+	router := chi.NewRouter()
+	handlers.RegisterRoutes(router)
+
+	ctx := getTestCtx()
+	list, err := listService.CreateList(ctx, "Favorites", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listService.CreateList(ctx, "Horror", nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/lists/%d", list.ID), bytes.NewBufferString(body))
+		req = req.WithContext(ctx)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch(`{"name": "Classics", "description": "Old but gold"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var updated models.ListInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.ID != list.ID || updated.Name != "Classics" || updated.Description == nil || *updated.Description != "Old but gold" {
+		t.Errorf("unexpected response %+v", updated)
+	}
+
+	if w := patch(`{"name": "Horror"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for a taken name, got %d", w.Code)
+	}
+	if w := patch(`{"name": ""}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an empty name, got %d", w.Code)
+	}
+}
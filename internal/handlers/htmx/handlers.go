@@ -50,36 +50,75 @@ type Handlers struct {
 	listService    *services.ListService
 	homeService    *services.HomeService
 	authService    *services.AuthService
+	webhookService *services.WebhookService
 }
 
-func NewHandlers(watchedService *services.WatchedService, listService *services.ListService, homeService *services.HomeService, authService *services.AuthService) *Handlers {
+func NewHandlers(watchedService *services.WatchedService, listService *services.ListService, homeService *services.HomeService, authService *services.AuthService, webhookService *services.WebhookService) *Handlers {
 	return &Handlers{
 		watchedService: watchedService,
 		listService:    listService,
 		homeService:    homeService,
 		authService:    authService,
+		webhookService: webhookService,
 	}
 }
 
 func (h *Handlers) RegisterRoutes(r chi.Router) {
 	r.Post("/movies/watched", h.AddWatchedMovie)
 	r.Patch("/movies/watched/{id}", h.UpdateWatchedMovie)
+	r.Get("/movies/{id}/lists", h.MovieLists)
 	r.Delete("/movies/watched/{id}", h.DeleteWatchedMovie)
 	r.Post("/import", h.ImportData)
 	r.Post("/lists", h.CreateList)
 	r.Delete("/lists", h.DeleteList)
+	r.Patch("/lists/{id}", h.UpdateList)
+	r.Post("/lists/{id}/ranked", h.SetListRanked)
+	r.Post("/lists/{id}/order", h.ReorderList)
+	r.Post("/lists/{id}/move", h.MoveListMovie)
+	r.Post("/lists/{id}/share", h.ShareList)
+	r.Post("/lists/{id}/share/regenerate", h.RegenerateShareLink)
+	r.Delete("/lists/{id}/share", h.UnshareList)
+	r.Post("/lists/{id}/members", h.AddListMember)
+	r.Patch("/lists/{id}/members/{userID}", h.SetListMemberRole)
+	r.Delete("/lists/{id}/members/{userID}", h.RemoveListMember)
+	r.Post("/lists/{id}/leave", h.LeaveList)
+	r.Post("/lists/smart", h.CreateSmartList)
+	r.Put("/lists/{id}/rules", h.SetListRules)
+	r.Post("/lists/{id}/items/remove", h.RemoveListMovies)
+	r.Post("/lists/{id}/items/copy", h.CopyListMovies)
+	r.Post("/lists/{id}/items/move", h.MoveListMovies)
+	r.Post("/lists/{id}/items/watched", h.MarkListMoviesWatched)
+	r.Post("/lists/{id}/duplicate", h.DuplicateList)
+	r.Post("/lists/{id}/merge", h.MergeList)
+	r.Post("/lists/import", h.ImportTMDBList)
+	r.Post("/lists/{id}/sync", h.SyncList)
+	r.Patch("/lists/{id}/sync", h.SetListSync)
 
 	r.Post("/lists/items", h.AddMovieToList)
 	r.Delete("/lists/items", h.DeleteMovieFromList)
 
 	r.Post("/watchlist/add", h.AddToWatchlist)
 	r.Get("/watchlist/{id}", h.RenderAddToWatchlistButton)
+	r.Patch("/watchlist/items/{id}", h.UpdateWatchlistItem)
+
+	r.Put("/home/watch-next", h.SetWatchNextFilter)
 
 	r.Get("/sidebar", h.GetSidebar)
 	r.Get("/lists/add-movie-dialog", h.RenderAddToListDialogContent)
 	r.Get("/lists/home-lists", h.HomeLists)
 	r.Get("/lists/{id}/movie-grid", h.ListMovieGrid)
 	r.Get("/lists/{id}/stats", h.ListStats)
+	r.Get("/lists/{id}/share", h.ListSharePanel)
+	r.Get("/lists/{id}/members", h.ListMembersPanel)
+	r.Get("/lists/smart/form", h.SmartListForm)
+	r.Get("/lists/{id}/rules", h.ListRulesForm)
+	r.Get("/lists/{id}/targets", h.TargetLists)
+	r.Get("/lists/import/collections", h.TMDBCollections)
+
+	r.Get("/webhooks", h.WebhooksContent)
+	r.Post("/webhooks", h.CreateWebhook)
+	r.Patch("/webhooks/{id}", h.UpdateWebhook)
+	r.Delete("/webhooks/{id}", h.DeleteWebhook)
 }
 
 func (h *Handlers) RenderAddToListDialogContent(w http.ResponseWriter, r *http.Request) {
@@ -364,32 +403,204 @@ func (h *Handlers) CreateList(w http.ResponseWriter, r *http.Request) {
 	title := r.FormValue("title")
 	description := r.FormValue("description")
 
-	sanitizedTitle, err := utils.TrimAndValidateString(title, 100)
+	log.Debug("creating new list", "title", title, "descriptionLength", len(description))
+
+	list, err := h.listService.CreateList(r.Context(), title, &description, false)
 	if err != nil {
-		log.Error("invalid list title", "title", title, "error", err)
-		RenderErrorToast(w, r, "Invalid Title", "Please provide a valid title for your list.", 4000)
+		log.Error("failed to create list", "title", title, "error", err)
+		switch {
+		case errors.Is(err, services.ErrInvalidListName):
+			RenderErrorToast(w, r, "Invalid Title", "Please provide a title of at most 100 characters.", 4000)
+		case errors.Is(err, services.ErrInvalidListDescription):
+			RenderErrorToast(w, r, "Description Too Long", "Please keep the description under 500 characters.", 4000)
+		case errors.Is(err, services.ErrListNameTaken):
+			RenderErrorToast(w, r, "Name Already Used", fmt.Sprintf("You already have a list called \"%s\".", strings.TrimSpace(title)), 0)
+		default:
+			RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		}
 		return
 	}
 
-	sanitizedDescription, err := utils.TrimAndValidateString(description, 500)
-	var descPtr *string
-	if err == nil && sanitizedDescription != "" {
-		descPtr = &sanitizedDescription
+	log.Info("successfully created list", "title", list.Name)
+
+	w.Header().Add("HX-Trigger", "refreshLists, refreshSidebar")
+	RenderSuccessToast(w, r, "List Created Successfully", fmt.Sprintf("List \"%s\" has been created.", list.Name), 0)
+}
+
+func (h *Handlers) UpdateList(w http.ResponseWriter, r *http.Request) {
+	listIDStr := chi.URLParam(r, "id")
+	listID, err := strconv.ParseInt(listIDStr, 10, 64)
+	if err != nil {
+		log.Error("invalid list ID", "listID", listIDStr, "error", err)
+		RenderErrorToast(w, r, "Invalid List", "Please select a valid list", 0)
+		return
 	}
 
-	log.Debug("creating new list", "title", sanitizedTitle, "descriptionLength", len(sanitizedDescription))
+	log.Debug("updating list", "listID", listID)
 
-	_, err = h.listService.CreateList(r.Context(), sanitizedTitle, descPtr, false)
+	list, err := h.listService.UpdateList(r.Context(), listID, r.FormValue("title"), r.FormValue("description"))
 	if err != nil {
-		log.Error("failed to create list", "title", sanitizedTitle, "description", sanitizedDescription, "error", err)
-		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		log.Error("failed to update list", "listID", listID, "error", err)
+		switch {
+		case errors.Is(err, services.ErrInvalidListName):
+			RenderErrorToast(w, r, "Invalid Title", "Please provide a title of at most 100 characters.", 4000)
+		case errors.Is(err, services.ErrInvalidListDescription):
+			RenderErrorToast(w, r, "Description Too Long", "Please keep the description under 500 characters.", 4000)
+		case errors.Is(err, services.ErrListNameTaken):
+			RenderErrorToast(w, r, "Name Already Used", "You already have another list with this name.", 0)
+		case errors.Is(err, services.ErrListNotFound), errors.Is(err, services.ErrWatchlistNotEditable):
+			RenderErrorToast(w, r, "Invalid List", "This list cannot be edited.", 0)
+		default:
+			RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		}
 		return
 	}
 
-	log.Info("successfully created list", "title", sanitizedTitle)
+	log.Info("successfully updated list", "listID", listID)
 
 	w.Header().Add("HX-Trigger", "refreshLists, refreshSidebar")
-	RenderSuccessToast(w, r, "List Created Successfully", fmt.Sprintf("List \"%s\" has been created.", sanitizedTitle), 0)
+
+	oobCtx := templ.WithChildren(r.Context(), pages.ListHeader(list))
+	if err := oobwrapper.OOBWrapper("outerHTML:#list-header").Render(oobCtx, w); err != nil {
+		log.Error("failed to render list header", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "The list was saved, reload the page to see the changes.", 0)
+		return
+	}
+
+	RenderSuccessToast(w, r, "List Updated", fmt.Sprintf("List \"%s\" has been saved.", list.Name), 0)
+}
+
+func (h *Handlers) SetListRanked(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+	ranked := r.FormValue("ranked") == "true"
+
+	err := h.listService.SetListRanked(r.Context(), listID, ranked)
+	if err != nil {
+		log.Error("failed to set list ranking", "listID", listID, "ranked", ranked, "error", err)
+		renderListRankingError(w, r, err)
+		return
+	}
+
+	log.Info("successfully set list ranking", "listID", listID, "ranked", ranked)
+
+	w.Header().Add("HX-Trigger", "refreshListGrid")
+	if ranked {
+		RenderSuccessToast(w, r, "List Ranked", "Drag movies to reorder them.", 0)
+	} else {
+		RenderSuccessToast(w, r, "Ranking Removed", "The list is no longer ranked.", 0)
+	}
+}
+
+func (h *Handlers) ReorderList(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		log.Error("failed to parse list order", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Invalid Order", "The new order could not be read.", 0)
+		return
+	}
+	movieIDs := make([]int64, 0, len(r.Form["order"]))
+	for _, value := range r.Form["order"] {
+		movieID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Error("invalid movie ID in list order", "listID", listID, "movieID", value, "error", err)
+			RenderErrorToast(w, r, "Invalid Order", "The new order could not be read.", 0)
+			return
+		}
+		movieIDs = append(movieIDs, movieID)
+	}
+
+	err := h.listService.ReorderList(r.Context(), listID, movieIDs)
+	// the grid is redrawn either way, to renumber it or to undo the drag
+	w.Header().Add("HX-Trigger", "refreshListGrid")
+	if err != nil {
+		log.Error("failed to reorder list", "listID", listID, "error", err)
+		renderListRankingError(w, r, err)
+		return
+	}
+
+	log.Info("successfully reordered list", "listID", listID)
+	RenderSuccessToast(w, r, "Ranking Saved", "The new order has been saved.", 0)
+}
+
+func (h *Handlers) MoveListMovie(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	movieID, err := strconv.ParseInt(r.FormValue("movie_id"), 10, 64)
+	if err != nil {
+		log.Error("invalid movie ID", "movieID", r.FormValue("movie_id"), "error", err)
+		RenderErrorToast(w, r, "Invalid Movie", "Movie not found", 0)
+		return
+	}
+	position, err := strconv.Atoi(r.FormValue("position"))
+	if err != nil {
+		RenderErrorToast(w, r, "Invalid Position", "Please enter the position as a number.", 0)
+		return
+	}
+
+	err = h.listService.MoveListMovie(r.Context(), listID, movieID, position)
+	if err != nil {
+		log.Error("failed to move movie in list", "listID", listID, "movieID", movieID, "position", position, "error", err)
+		renderListRankingError(w, r, err)
+		return
+	}
+
+	log.Info("successfully moved movie in list", "listID", listID, "movieID", movieID, "position", position)
+
+	w.Header().Add("HX-Trigger", "refreshListGrid")
+	RenderSuccessToast(w, r, "Movie Moved", "The ranking has been updated.", 0)
+}
+
+func parseListID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	listIDStr := chi.URLParam(r, "id")
+	listID, err := strconv.ParseInt(listIDStr, 10, 64)
+	if err != nil {
+		log.Error("invalid list ID", "listID", listIDStr, "error", err)
+		RenderErrorToast(w, r, "Invalid List", "Please select a valid list", 0)
+		return 0, false
+	}
+	return listID, true
+}
+
+func renderListRankingError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrListNotRanked):
+		RenderErrorToast(w, r, "List Not Ranked", "Rank the list before reordering it.", 0)
+	case errors.Is(err, services.ErrInvalidListOrder), errors.Is(err, services.ErrMovieNotInList):
+		RenderErrorToast(w, r, "List Changed", "The list changed meanwhile, please try again.", 0)
+	case errors.Is(err, services.ErrInvalidListPosition):
+		RenderErrorToast(w, r, "Invalid Position", "Positions start from 1.", 0)
+	case renderListReadOnlyError(w, r, err):
+	case errors.Is(err, services.ErrListNotFound), errors.Is(err, services.ErrWatchlistNotEditable):
+		RenderErrorToast(w, r, "Invalid List", "This list cannot be ranked.", 0)
+	default:
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+	}
+}
+
+// renderListReadOnlyError explains why the user cannot change the movies of a list, it
+// reports whether err was one of those reasons
+func renderListReadOnlyError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrListReadOnly):
+		RenderErrorToast(w, r, "View Only", "This list is shared with you to view, ask its owner to make you an editor.", 0)
+	case errors.Is(err, services.ErrSmartListReadOnly):
+		RenderErrorToast(w, r, "Smart List", "The movies of this list come from its rules, change the rules instead.", 0)
+	case errors.Is(err, services.ErrSyncedListReadOnly):
+		RenderErrorToast(w, r, "Synced List", "The movies of this list come from TMDB, stop syncing it to change them.", 0)
+	default:
+		return false
+	}
+	return true
 }
 
 func (h *Handlers) AddMovieToList(w http.ResponseWriter, r *http.Request) {
@@ -431,6 +642,9 @@ func (h *Handlers) AddMovieToList(w http.ResponseWriter, r *http.Request) {
 			RenderWarningToast(w, r, "Movie Already in List", "This movie is already in this list", 4000)
 			return
 		}
+		if renderListReadOnlyError(w, r, err) {
+			return
+		}
 
 		RenderErrorToast(w, r, "Failed to Add Movie", "An unexpected error occurred while adding the movie to your list", 0)
 		return
@@ -438,6 +652,7 @@ func (h *Handlers) AddMovieToList(w http.ResponseWriter, r *http.Request) {
 
 	log.Info("successfully added movie to list", "listID", listID, "movieID", movieID)
 
+	w.Header().Add("HX-Trigger", "refreshMovieLists")
 	RenderSuccessToast(w, r, "Added to List", "Movie has been added to the list", 0)
 }
 
@@ -461,6 +676,14 @@ func (h *Handlers) DeleteList(w http.ResponseWriter, r *http.Request) {
 
 	log.Info("successfully deleted list", "listID", id)
 
+	if h.renderHomeOOB(w, r) {
+		RenderSuccessToast(w, r, "List Deleted Successfully", "The list has been deleted.", 2000)
+	}
+}
+
+// renderHomeOOB replaces the page with the home page after the list on it went away. It
+// reports false after rendering an error toast instead.
+func (h *Handlers) renderHomeOOB(w http.ResponseWriter, r *http.Request) bool {
 	// Fetch home data
 	ctx := r.Context()
 
@@ -468,7 +691,7 @@ func (h *Handlers) DeleteList(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Error("failed to retrieve home data", "error", err)
 		RenderErrorToast(w, r, "Unexpected error", "An unexpected error occurred, please try again", 0)
-		return
+		return false
 	}
 
 	// add the headers before rendering the components
@@ -479,7 +702,7 @@ func (h *Handlers) DeleteList(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Error("failed to retrieve user from context", "error", err)
 		RenderErrorToast(w, r, "Unexpected error", "An unexpected error occurred, please try again", 0)
-		return
+		return false
 	}
 
 	var buf bytes.Buffer
@@ -487,17 +710,16 @@ func (h *Handlers) DeleteList(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Error("failed to render home fragment", "error", err)
 		RenderErrorToast(w, r, "Unexpected error", "An unexpected error occurred, please try again", 0)
-		return
+		return false
 	}
 
 	oobCtx := templ.WithChildren(r.Context(), templ.Raw(buf.String()))
 	if err := oobwrapper.OOBWrapper("innerHTML:#main-content").Render(oobCtx, w); err != nil {
 		log.Error("failed to render OOB wrapper", "error", err)
 		RenderErrorToast(w, r, "Unexpected error", "An unexpected error occurred, please try again", 0)
-		return
+		return false
 	}
-
-	RenderSuccessToast(w, r, "List Deleted Successfully", "The list has been deleted.", 2000)
+	return true
 }
 
 func (h *Handlers) AddToWatchlist(w http.ResponseWriter, r *http.Request) {
@@ -557,13 +779,16 @@ func (h *Handlers) DeleteMovieFromList(w http.ResponseWriter, r *http.Request) {
 	err = h.listService.DeleteMovieFromList(r.Context(), listID, movieID)
 	if err != nil {
 		log.Error("failed to delete movie from list", "listID", listID, "movieID", movieID, "error", err)
+		if renderListReadOnlyError(w, r, err) {
+			return
+		}
 		RenderErrorToast(w, r, "Failed to Remove Movie", "An unexpected error occurred while removing the movie from the list", 0)
 		return
 	}
 
 	log.Info("successfully removed movie from list", "listID", listID, "movieID", movieID)
 
-	w.Header().Add("HX-Trigger", "refreshSidebar, refreshListGrid, refreshListStats")
+	w.Header().Add("HX-Trigger", "refreshSidebar, refreshListGrid, refreshListStats, refreshMovieLists, refreshWatchlist")
 
 	RenderSuccessToast(w, r, "Removed from List", "Movie has been removed from the list", 0)
 }
@@ -630,6 +855,8 @@ func (h *Handlers) ImportData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.authService.Audit.Record(r.Context(), models.AuditDataImported, nil, importAuditDetail(allData, totalMovies))
+
 	ctx := context.WithoutCancel(r.Context())
 	go func() {
 		log.Info("HTMX import job started")
@@ -645,6 +872,11 @@ func (h *Handlers) ImportData(w http.ResponseWriter, r *http.Request) {
 	RenderSuccessToast(w, r, "Import Started", "Your data is being imported. This may take a few moments.", 0)
 }
 
+// importAuditDetail summarizes an import for the audit log
+func importAuditDetail(data models.ImportAllData, totalMovies int) string {
+	return fmt.Sprintf("%d watched days, %d lists, %d movies", len(data.Watched), len(data.Lists), totalMovies)
+}
+
 func (h *Handlers) RenderAddToWatchlistButton(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -680,15 +912,13 @@ func (h *Handlers) ListMovieGrid(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := h.listService.GetListDetails(ctx, listID)
+	gridData, err := h.listService.GetListGridData(ctx, listID, time.Now())
 	if err != nil {
-		log.Error("failed to get list details", "listID", listIDStr, "error", err)
+		log.Error("failed to get list grid data", "listID", listIDStr, "error", err)
 		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
 		return
 	}
 
-	gridData := h.listService.BuildListGridData(list, time.Now())
-
 	if err := listgrid.ListGrid(gridData).Render(ctx, w); err != nil {
 		log.Error("failed to render list grid", "listID", listIDStr, "error", err)
 		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
@@ -0,0 +1,233 @@
+package htmx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/components/addtolistdialog"
+	"github.com/marcosalvi-01/gowatch/internal/utils"
+)
+
+// TargetLists renders the lists the movies of a list can be moved, copied or merged into.
+// The for query keeps the inputs of the merge and selection dialogs apart.
+func (h *Handlers) TargetLists(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	lists, err := h.listService.GetTargetLists(r.Context(), listID)
+	if err != nil {
+		log.Error("failed to get target lists", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Failed to Load Lists", "An unexpected error occurred while fetching your lists.", 0)
+		return
+	}
+
+	prefix := "bulk-list"
+	if r.URL.Query().Get("for") == "merge" {
+		prefix = "merge-list"
+	}
+	if err := addtolistdialog.TargetLists(prefix, lists).Render(r.Context(), w); err != nil {
+		log.Error("failed to render target lists", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+}
+
+func (h *Handlers) RemoveListMovies(w http.ResponseWriter, r *http.Request) {
+	listID, movieIDs, ok := parseSelectedMovies(w, r)
+	if !ok {
+		return
+	}
+
+	removed, err := h.listService.RemoveMoviesFromList(r.Context(), listID, movieIDs)
+	if err != nil {
+		log.Error("failed to remove movies from list", "listID", listID, "error", err)
+		renderListBulkError(w, r, err)
+		return
+	}
+
+	log.Info("successfully removed movies from list", "listID", listID, "count", removed)
+
+	w.Header().Add("HX-Trigger", "refreshSidebar, refreshListGrid, refreshListStats")
+	RenderSuccessToast(w, r, "Removed from List", fmt.Sprintf("Removed %s from the list.", movieCount(removed)), 0)
+}
+
+func (h *Handlers) CopyListMovies(w http.ResponseWriter, r *http.Request) {
+	h.copyListMovies(w, r, false)
+}
+
+func (h *Handlers) MoveListMovies(w http.ResponseWriter, r *http.Request) {
+	h.copyListMovies(w, r, true)
+}
+
+func (h *Handlers) copyListMovies(w http.ResponseWriter, r *http.Request, move bool) {
+	listID, movieIDs, ok := parseSelectedMovies(w, r)
+	if !ok {
+		return
+	}
+	targetID, ok := parseTargetList(w, r)
+	if !ok {
+		return
+	}
+
+	var added int
+	var err error
+	if move {
+		added, err = h.listService.MoveMoviesToList(r.Context(), listID, targetID, movieIDs)
+	} else {
+		added, err = h.listService.CopyMoviesToList(r.Context(), listID, targetID, movieIDs)
+	}
+	if err != nil {
+		log.Error("failed to copy movies to list", "listID", listID, "targetID", targetID, "move", move, "error", err)
+		renderListBulkError(w, r, err)
+		return
+	}
+
+	log.Info("successfully copied movies to list", "listID", listID, "targetID", targetID, "added", added, "move", move)
+
+	if move {
+		w.Header().Add("HX-Trigger", "refreshSidebar, refreshListGrid, refreshListStats")
+		RenderSuccessToast(w, r, "Movies Moved", fmt.Sprintf("Moved the selected movies, %s new to the other list.", movieCount(added)), 0)
+		return
+	}
+	w.Header().Add("HX-Trigger", "refreshSidebar")
+	RenderSuccessToast(w, r, "Movies Copied", fmt.Sprintf("Copied the selected movies, %s new to the other list.", movieCount(added)), 0)
+}
+
+func (h *Handlers) MarkListMoviesWatched(w http.ResponseWriter, r *http.Request) {
+	listID, movieIDs, ok := parseSelectedMovies(w, r)
+	if !ok {
+		return
+	}
+
+	marked, err := h.watchedService.AddWatchedListMovies(r.Context(), listID, movieIDs, time.Now())
+	if err != nil {
+		log.Error("failed to mark list movies as watched", "listID", listID, "error", err)
+		renderListBulkError(w, r, err)
+		return
+	}
+
+	log.Info("successfully marked list movies as watched", "listID", listID, "count", marked)
+	w.Header().Add("HX-Trigger", "refreshSidebar, refreshListGrid, refreshListStats")
+	RenderSuccessToast(w, r, "Marked as Watched", fmt.Sprintf("Marked %s as watched today.", movieCount(marked)), 0)
+}
+
+func (h *Handlers) DuplicateList(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	title := r.FormValue("title")
+	sanitizedTitle, err := utils.TrimAndValidateString(title, 100)
+	if err != nil {
+		log.Error("invalid list title", "title", title, "error", err)
+		RenderErrorToast(w, r, "Invalid Title", "Please provide a valid title for your list.", 4000)
+		return
+	}
+
+	list, err := h.listService.DuplicateList(r.Context(), listID, sanitizedTitle)
+	if errors.Is(err, services.ErrListNameTaken) {
+		RenderErrorToast(w, r, "Name Already Used", fmt.Sprintf("You already have a list called \"%s\".", sanitizedTitle), 0)
+		return
+	}
+	if err != nil {
+		log.Error("failed to duplicate list", "listID", listID, "error", err)
+		renderListBulkError(w, r, err)
+		return
+	}
+
+	log.Info("successfully duplicated list", "listID", listID, "copyID", list.ID)
+
+	w.Header().Add("HX-Trigger", "refreshLists, refreshSidebar")
+	setListLocation(w, list.ID)
+	RenderSuccessToast(w, r, "List Duplicated", fmt.Sprintf("\"%s\" has been created.", list.Name), 0)
+}
+
+func (h *Handlers) MergeList(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+	targetID, ok := parseTargetList(w, r)
+	if !ok {
+		return
+	}
+
+	added, err := h.listService.MergeList(r.Context(), listID, targetID)
+	if err != nil {
+		log.Error("failed to merge list", "listID", listID, "targetID", targetID, "error", err)
+		renderListBulkError(w, r, err)
+		return
+	}
+
+	log.Info("successfully merged list", "listID", listID, "targetID", targetID, "added", added)
+
+	w.Header().Add("HX-Trigger", "refreshLists, refreshSidebar")
+	setListLocation(w, targetID)
+	RenderSuccessToast(w, r, "Lists Merged", fmt.Sprintf("Added %s to the list.", movieCount(added)), 0)
+}
+
+// setListLocation opens the page of listID once the response is handled
+func setListLocation(w http.ResponseWriter, listID int64) {
+	w.Header().Set("HX-Location", fmt.Sprintf(
+		`{"path": "/list/%d", "target": "#main-content", "swap": "innerHTML show:#main-scroll-container:top"}`,
+		listID,
+	))
+}
+
+// parseSelectedMovies reads the list in the URL and the movies selected in its grid
+func parseSelectedMovies(w http.ResponseWriter, r *http.Request) (int64, []int64, bool) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return 0, nil, false
+	}
+
+	if err := r.ParseForm(); err != nil {
+		log.Error("failed to parse selected movies", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Invalid Selection", "The selected movies could not be read.", 0)
+		return 0, nil, false
+	}
+	movieIDs := make([]int64, 0, len(r.Form["selected"]))
+	for _, value := range r.Form["selected"] {
+		movieID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Error("invalid selected movie ID", "listID", listID, "movieID", value, "error", err)
+			RenderErrorToast(w, r, "Invalid Selection", "The selected movies could not be read.", 0)
+			return 0, nil, false
+		}
+		movieIDs = append(movieIDs, movieID)
+	}
+	return listID, movieIDs, true
+}
+
+func parseTargetList(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	targetID, err := strconv.ParseInt(r.FormValue("selected_list"), 10, 64)
+	if err != nil {
+		log.Error("invalid target list ID", "listID", r.FormValue("selected_list"), "error", err)
+		RenderErrorToast(w, r, "No List Picked", "Please pick the list the movies go to.", 4000)
+		return 0, false
+	}
+	return targetID, true
+}
+
+func renderListBulkError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrNoMoviesSelected):
+		RenderWarningToast(w, r, "No Movies Selected", "Select some movies first.", 4000)
+	case errors.Is(err, services.ErrSameList):
+		RenderWarningToast(w, r, "Same List", "Please pick another list.", 4000)
+	case errors.Is(err, services.ErrMovieNotInList):
+		RenderErrorToast(w, r, "List Changed", "The list changed meanwhile, please try again.", 0)
+	case renderListReadOnlyError(w, r, err):
+	case errors.Is(err, services.ErrListNotFound), errors.Is(err, services.ErrWatchlistNotEditable):
+		RenderErrorToast(w, r, "Invalid List", "This list cannot be changed.", 0)
+	default:
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+	}
+}
@@ -0,0 +1,182 @@
+package htmx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/components/oobwrapper"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handlers) ListMembersPanel(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	list, members, err := h.listMembers(r, listID)
+	if err != nil {
+		log.Error("failed to get list members", "listID", listID, "error", err)
+		renderListMemberError(w, r, err)
+		return
+	}
+
+	if err := pages.ListMembersPanel(list, members).Render(r.Context(), w); err != nil {
+		log.Error("failed to render list members panel", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+}
+
+func (h *Handlers) AddListMember(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+	role := models.ListRole(r.FormValue("role"))
+
+	member, err := h.listService.AddListMember(r.Context(), listID, r.FormValue("email"), role)
+	if err != nil {
+		log.Error("failed to add list member", "listID", listID, "error", err)
+		renderListMemberError(w, r, err)
+		return
+	}
+
+	log.Info("successfully added list member", "listID", listID, "memberID", member.ID, "role", role)
+	h.renderListMembersUpdate(w, r, listID, "List Shared", fmt.Sprintf("%s can now %s this list.", member.Name, listRoleAction(role)))
+}
+
+func (h *Handlers) SetListMemberRole(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+	memberID, ok := parseMemberID(w, r)
+	if !ok {
+		return
+	}
+	role := models.ListRole(r.FormValue("role"))
+
+	if err := h.listService.SetListMemberRole(r.Context(), listID, memberID, role); err != nil {
+		log.Error("failed to change list member role", "listID", listID, "memberID", memberID, "error", err)
+		renderListMemberError(w, r, err)
+		return
+	}
+
+	log.Info("successfully changed list member role", "listID", listID, "memberID", memberID, "role", role)
+	h.renderListMembersUpdate(w, r, listID, "Role Changed", fmt.Sprintf("The member can now %s this list.", listRoleAction(role)))
+}
+
+func (h *Handlers) RemoveListMember(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+	memberID, ok := parseMemberID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.listService.RemoveListMember(r.Context(), listID, memberID); err != nil {
+		log.Error("failed to remove list member", "listID", listID, "memberID", memberID, "error", err)
+		renderListMemberError(w, r, err)
+		return
+	}
+
+	log.Info("successfully removed list member", "listID", listID, "memberID", memberID)
+	h.renderListMembersUpdate(w, r, listID, "Member Removed", "The list is no longer shared with them.")
+}
+
+func (h *Handlers) LeaveList(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.listService.LeaveList(r.Context(), listID); err != nil {
+		log.Error("failed to leave list", "listID", listID, "error", err)
+		renderListMemberError(w, r, err)
+		return
+	}
+
+	log.Info("successfully left list", "listID", listID)
+
+	if h.renderHomeOOB(w, r) {
+		RenderSuccessToast(w, r, "Left List", "The list is no longer shared with you.", 2000)
+	}
+}
+
+// renderListMembersUpdate swaps the members panel of listID out of band and confirms the
+// change. The grid is refreshed too, it names who added each movie once a list is shared.
+func (h *Handlers) renderListMembersUpdate(w http.ResponseWriter, r *http.Request, listID int64, title, description string) {
+	list, members, err := h.listMembers(r, listID)
+	if err != nil {
+		log.Error("failed to get list members", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "The change was saved, reload the page to see it.", 0)
+		return
+	}
+
+	w.Header().Add("HX-Trigger", "refreshListGrid")
+
+	oobCtx := templ.WithChildren(r.Context(), pages.ListMembersPanel(list, members))
+	if err := oobwrapper.OOBWrapper("outerHTML:#list-members-panel").Render(oobCtx, w); err != nil {
+		log.Error("failed to render list members panel", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "The change was saved, reload the page to see it.", 0)
+		return
+	}
+
+	RenderSuccessToast(w, r, title, description, 0)
+}
+
+func (h *Handlers) listMembers(r *http.Request, listID int64) (*models.List, []models.ListMember, error) {
+	members, err := h.listService.GetListMembers(r.Context(), listID)
+	if err != nil {
+		return nil, nil, err
+	}
+	list, err := h.listService.GetListDetails(r.Context(), listID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, members, nil
+}
+
+func parseMemberID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	memberIDStr := chi.URLParam(r, "userID")
+	memberID, err := strconv.ParseInt(memberIDStr, 10, 64)
+	if err != nil {
+		log.Error("invalid member ID", "memberID", memberIDStr, "error", err)
+		RenderErrorToast(w, r, "Invalid Member", "Please select a valid member", 0)
+		return 0, false
+	}
+	return memberID, true
+}
+
+// listRoleAction is what a member with role can do, to complete "can now ... this list"
+func listRoleAction(role models.ListRole) string {
+	if role == models.ListRoleEditor {
+		return "edit"
+	}
+	return "view"
+}
+
+func renderListMemberError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidListRole):
+		RenderErrorToast(w, r, "Invalid Role", "Members can be viewers or editors.", 0)
+	case errors.Is(err, services.ErrListMemberNotFound):
+		RenderErrorToast(w, r, "User Not Found", "No user of this instance has this email.", 0)
+	case errors.Is(err, services.ErrListMemberIsOwner):
+		RenderErrorToast(w, r, "Already the Owner", "You own this list, share it with someone else.", 0)
+	case errors.Is(err, services.ErrListNotFound), errors.Is(err, services.ErrWatchlistNotEditable):
+		RenderErrorToast(w, r, "Invalid List", "Only the owner of a list can share it.", 0)
+	default:
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+	}
+}
@@ -0,0 +1,113 @@
+package htmx
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/components/oobwrapper"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+
+	"github.com/a-h/templ"
+)
+
+func (h *Handlers) ListSharePanel(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.listService.GetListDetails(r.Context(), listID)
+	if err != nil {
+		log.Error("failed to get list details", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+
+	if err := pages.ListSharePanel(list, h.listShareURL(r, list)).Render(r.Context(), w); err != nil {
+		log.Error("failed to render list share panel", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+}
+
+func (h *Handlers) ShareList(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+	includeNotes := r.FormValue("include_notes") == "true"
+
+	list, err := h.listService.ShareList(r.Context(), listID, includeNotes)
+	if err != nil {
+		log.Error("failed to share list", "listID", listID, "error", err)
+		renderListShareError(w, r, err)
+		return
+	}
+
+	log.Info("successfully shared list", "listID", listID, "includeNotes", includeNotes)
+	h.renderListShareUpdate(w, r, list, "Link Ready", "Anyone with the link can view this list.")
+}
+
+func (h *Handlers) RegenerateShareLink(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.listService.RegenerateShareLink(r.Context(), listID)
+	if err != nil {
+		log.Error("failed to regenerate list share link", "listID", listID, "error", err)
+		renderListShareError(w, r, err)
+		return
+	}
+
+	log.Info("successfully regenerated list share link", "listID", listID)
+	h.renderListShareUpdate(w, r, list, "New Link Created", "The previous link no longer works.")
+}
+
+func (h *Handlers) UnshareList(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.listService.UnshareList(r.Context(), listID)
+	if err != nil {
+		log.Error("failed to revoke list share link", "listID", listID, "error", err)
+		renderListShareError(w, r, err)
+		return
+	}
+
+	log.Info("successfully revoked list share link", "listID", listID)
+	h.renderListShareUpdate(w, r, list, "Link Revoked", "The list is private again.")
+}
+
+// renderListShareUpdate swaps the share panel of list out of band and confirms the change
+func (h *Handlers) renderListShareUpdate(w http.ResponseWriter, r *http.Request, list *models.List, title, description string) {
+	oobCtx := templ.WithChildren(r.Context(), pages.ListSharePanel(list, h.listShareURL(r, list)))
+	if err := oobwrapper.OOBWrapper("outerHTML:#list-share-panel").Render(oobCtx, w); err != nil {
+		log.Error("failed to render list share panel", "listID", list.ID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "The change was saved, reload the page to see it.", 0)
+		return
+	}
+
+	RenderSuccessToast(w, r, title, description, 0)
+}
+
+// listShareURL is the public link of list, empty while it is not shared
+func (h *Handlers) listShareURL(r *http.Request, list *models.List) string {
+	if list.ShareToken == nil {
+		return ""
+	}
+	return h.authService.BaseURL(r.Host, r.TLS != nil) + pages.SharedListPath(*list.ShareToken)
+}
+
+func renderListShareError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, services.ErrListNotFound) {
+		RenderErrorToast(w, r, "Invalid List", "This list cannot be shared.", 0)
+		return
+	}
+	RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+}
@@ -0,0 +1,201 @@
+package htmx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/components/listrules"
+	"github.com/marcosalvi-01/gowatch/internal/utils"
+)
+
+func (h *Handlers) SmartListForm(w http.ResponseWriter, r *http.Request) {
+	genres, err := h.listService.GetGenres(r.Context())
+	if err != nil {
+		log.Error("failed to get genres for smart list form", "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+
+	if err := listrules.CreateForm(genres).Render(r.Context(), w); err != nil {
+		log.Error("failed to render smart list form", "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+}
+
+func (h *Handlers) ListRulesForm(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.listService.GetListDetails(r.Context(), listID)
+	if err != nil || !list.Smart() {
+		log.Error("failed to get smart list", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Invalid List", "This list has no rules.", 0)
+		return
+	}
+	genres, err := h.listService.GetGenres(r.Context())
+	if err != nil {
+		log.Error("failed to get genres for list rules form", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+
+	if err := listrules.EditForm(list, genres).Render(r.Context(), w); err != nil {
+		log.Error("failed to render list rules form", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+}
+
+func (h *Handlers) CreateSmartList(w http.ResponseWriter, r *http.Request) {
+	title := r.FormValue("title")
+	sanitizedTitle, err := utils.TrimAndValidateString(title, 100)
+	if err != nil {
+		log.Error("invalid list title", "title", title, "error", err)
+		RenderErrorToast(w, r, "Invalid Title", "Please provide a valid title for your list.", 4000)
+		return
+	}
+
+	rules, err := parseListRules(r)
+	if err != nil {
+		log.Error("invalid smart list rules", "error", err)
+		renderListRulesError(w, r, err)
+		return
+	}
+
+	list, err := h.listService.CreateSmartList(r.Context(), sanitizedTitle, nil, rules)
+	if errors.Is(err, services.ErrListNameTaken) {
+		RenderErrorToast(w, r, "Name Already Used", fmt.Sprintf("You already have a list called \"%s\".", sanitizedTitle), 0)
+		return
+	}
+	if err != nil {
+		log.Error("failed to create smart list", "title", sanitizedTitle, "error", err)
+		renderListRulesError(w, r, err)
+		return
+	}
+
+	log.Info("successfully created smart list", "listID", list.ID, "movieCount", len(list.Movies))
+
+	w.Header().Add("HX-Trigger", "refreshLists, refreshSidebar")
+	RenderSuccessToast(w, r, "Smart List Created", fmt.Sprintf("\"%s\" holds %s for now.", sanitizedTitle, movieCount(len(list.Movies))), 0)
+}
+
+func (h *Handlers) SetListRules(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := parseListRules(r)
+	if err != nil {
+		log.Error("invalid smart list rules", "listID", listID, "error", err)
+		renderListRulesError(w, r, err)
+		return
+	}
+
+	list, err := h.listService.SetListRules(r.Context(), listID, rules)
+	if err != nil {
+		log.Error("failed to set list rules", "listID", listID, "error", err)
+		renderListRulesError(w, r, err)
+		return
+	}
+
+	log.Info("successfully set list rules", "listID", listID, "movieCount", len(list.Movies))
+
+	w.Header().Add("HX-Trigger", "refreshSidebar, refreshListGrid, refreshListStats")
+	RenderSuccessToast(w, r, "Rules Saved", fmt.Sprintf("The list now holds %s.", movieCount(len(list.Movies))), 0)
+}
+
+// parseListRules reads the rules of a smart list from its form, empty fields leave the
+// rule unset
+func parseListRules(r *http.Request) (models.ListRules, error) {
+	var rules models.ListRules
+	var err error
+
+	bools := map[string]**bool{
+		"watched":      &rules.Watched,
+		"in_theater":   &rules.InTheater,
+		"in_watchlist": &rules.InWatchlist,
+	}
+	for name, rule := range bools {
+		if *rule, err = parseOptional(r.FormValue(name), strconv.ParseBool); err != nil {
+			return rules, invalidListRule(name)
+		}
+	}
+
+	ints := map[string]**int64{
+		"watched_year":      &rules.WatchedYear,
+		"min_runtime":       &rules.MinRuntime,
+		"max_runtime":       &rules.MaxRuntime,
+		"release_year_from": &rules.ReleaseYearFrom,
+		"release_year_to":   &rules.ReleaseYearTo,
+		"genre_id":          &rules.GenreID,
+	}
+	parseInt := func(value string) (int64, error) { return strconv.ParseInt(value, 10, 64) }
+	for name, rule := range ints {
+		if *rule, err = parseOptional(r.FormValue(name), parseInt); err != nil {
+			return rules, invalidListRule(name)
+		}
+	}
+
+	floats := map[string]**float64{
+		"min_rating":          &rules.MinRating,
+		"director_min_rating": &rules.DirectorMinRating,
+	}
+	parseFloat := func(value string) (float64, error) { return strconv.ParseFloat(value, 64) }
+	for name, rule := range floats {
+		if *rule, err = parseOptional(r.FormValue(name), parseFloat); err != nil {
+			return rules, invalidListRule(name)
+		}
+	}
+
+	if name := strings.TrimSpace(r.FormValue("person_name")); name != "" {
+		rules.PersonName = &name
+	}
+	return rules, nil
+}
+
+// invalidListRule names the rule of field name that could not be read
+func invalidListRule(name string) error {
+	return fmt.Errorf("%w: %s is not valid", services.ErrInvalidListRules, strings.ReplaceAll(name, "_", " "))
+}
+
+// parseOptional parses value with parse, an empty value is nil
+func parseOptional[T any](value string, parse func(string) (T, error)) (*T, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func movieCount(count int) string {
+	if count == 1 {
+		return "1 movie"
+	}
+	return fmt.Sprintf("%d movies", count)
+}
+
+func renderListRulesError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidListRules):
+		// the error says which rule is wrong, after the sentinel
+		reason := strings.TrimPrefix(err.Error(), services.ErrInvalidListRules.Error()+": ")
+		RenderErrorToast(w, r, "Invalid Rules", strings.ToUpper(reason[:1])+reason[1:]+".", 0)
+	case errors.Is(err, services.ErrListNotFound), errors.Is(err, services.ErrWatchlistNotEditable):
+		RenderErrorToast(w, r, "Invalid List", "Only the owner of a list can change its rules.", 0)
+	default:
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+	}
+}
@@ -0,0 +1,167 @@
+package htmx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/components/oobwrapper"
+	"github.com/marcosalvi-01/gowatch/internal/ui/components/sidebar"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+	"github.com/marcosalvi-01/gowatch/internal/utils"
+
+	"github.com/a-h/templ"
+)
+
+// TMDBCollections renders the TMDB collections matching the query of the import dialog
+func (h *Handlers) TMDBCollections(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("query"))
+	if query == "" {
+		if err := sidebar.TMDBCollections(nil, false).Render(r.Context(), w); err != nil {
+			log.Error("failed to render TMDB collections", "error", err)
+		}
+		return
+	}
+
+	collections, err := h.listService.SearchTMDBCollections(r.Context(), query)
+	if err != nil {
+		log.Error("failed to search TMDB collections", "query", query, "error", err)
+		RenderErrorToast(w, r, "Search Failed", "TMDB could not be reached, please try again.", 0)
+		return
+	}
+
+	if err := sidebar.TMDBCollections(collections, true).Render(r.Context(), w); err != nil {
+		log.Error("failed to render TMDB collections", "query", query, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+}
+
+// ImportTMDBList imports the TMDB list linked in source, or the collection picked in the
+// dialog when there is no link
+func (h *Handlers) ImportTMDBList(w http.ResponseWriter, r *http.Request) {
+	var kind models.ListSourceKind
+	var tmdbID int64
+	var err error
+	if source := r.FormValue("source"); strings.TrimSpace(source) != "" {
+		kind, tmdbID, err = services.ParseTMDBSource(source)
+		if err != nil {
+			log.Error("invalid TMDB list link", "source", source, "error", err)
+			RenderErrorToast(w, r, "Invalid Link", "Please paste the link or ID of a TMDB list, or the link of a collection.", 4000)
+			return
+		}
+	} else {
+		kind = models.ListSourceTMDBCollection
+		tmdbID, err = strconv.ParseInt(r.FormValue("collection_id"), 10, 64)
+		if err != nil {
+			log.Error("invalid TMDB collection ID", "collectionID", r.FormValue("collection_id"), "error", err)
+			RenderErrorToast(w, r, "Nothing to Import", "Paste the link of a TMDB list, or pick a collection.", 4000)
+			return
+		}
+	}
+
+	title := r.FormValue("title")
+	if strings.TrimSpace(title) != "" {
+		title, err = utils.TrimAndValidateString(title, 100)
+		if err != nil {
+			log.Error("invalid list title", "title", title, "error", err)
+			RenderErrorToast(w, r, "Invalid Title", "Please provide a valid title for your list.", 4000)
+			return
+		}
+	}
+	sync := r.FormValue("sync") == "true"
+
+	list, err := h.listService.ImportTMDBList(r.Context(), kind, tmdbID, title, sync)
+	if errors.Is(err, services.ErrListNameTaken) {
+		RenderErrorToast(w, r, "Name Already Used", "You already have a list with this name, give the import another one.", 0)
+		return
+	}
+	if err != nil {
+		log.Error("failed to import TMDB list", "kind", kind, "tmdbID", tmdbID, "error", err)
+		RenderErrorToast(w, r, "Import Failed", fmt.Sprintf("The TMDB %s could not be imported, check that it exists and is public.", kind), 0)
+		return
+	}
+
+	log.Info("successfully imported TMDB list", "listID", list.ID, "kind", kind, "tmdbID", tmdbID, "sync", sync)
+
+	w.Header().Add("HX-Trigger", "refreshLists, refreshSidebar")
+	setListLocation(w, list.ID)
+	RenderSuccessToast(w, r, "List Imported", fmt.Sprintf("\"%s\" holds %s.", list.Name, movieCount(len(list.Movies))), 0)
+}
+
+func (h *Handlers) SyncList(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	added, removed, err := h.listService.SyncList(r.Context(), listID)
+	if err != nil {
+		log.Error("failed to sync list with TMDB", "listID", listID, "error", err)
+		renderListSyncError(w, r, err)
+		return
+	}
+
+	log.Info("successfully synced list with TMDB", "listID", listID, "added", added, "removed", removed)
+
+	list, err := h.listService.GetListDetails(r.Context(), listID)
+	if err != nil {
+		log.Error("failed to get synced list", "listID", listID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "The list was synced, reload the page to see the changes.", 0)
+		return
+	}
+	h.renderListSyncUpdate(w, r, list, "List Synced", fmt.Sprintf("Added %s and removed %d.", movieCount(added), removed))
+}
+
+func (h *Handlers) SetListSync(w http.ResponseWriter, r *http.Request) {
+	listID, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	sync := r.FormValue("sync") == "true"
+	list, err := h.listService.SetListSync(r.Context(), listID, sync)
+	if err != nil {
+		log.Error("failed to set list sync", "listID", listID, "sync", sync, "error", err)
+		renderListSyncError(w, r, err)
+		return
+	}
+
+	log.Info("successfully set list sync", "listID", listID, "sync", sync)
+
+	if sync {
+		h.renderListSyncUpdate(w, r, list, "Sync Started", "The list now follows its TMDB source.")
+		return
+	}
+	h.renderListSyncUpdate(w, r, list, "Sync Stopped", "The list is yours to change again.")
+}
+
+// renderListSyncUpdate swaps the header of list out of band, its badge and actions depend
+// on the sync, and refreshes its movies
+func (h *Handlers) renderListSyncUpdate(w http.ResponseWriter, r *http.Request, list *models.List, title, description string) {
+	w.Header().Add("HX-Trigger", "refreshSidebar, refreshListGrid, refreshListStats")
+
+	oobCtx := templ.WithChildren(r.Context(), pages.ListHeader(list))
+	if err := oobwrapper.OOBWrapper("outerHTML:#list-header").Render(oobCtx, w); err != nil {
+		log.Error("failed to render list header", "listID", list.ID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "The change was saved, reload the page to see it.", 0)
+		return
+	}
+
+	RenderSuccessToast(w, r, title, description, 0)
+}
+
+func renderListSyncError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrListNotImported), errors.Is(err, services.ErrListNotSynced):
+		RenderErrorToast(w, r, "Not Synced", "This list does not follow a TMDB list or collection.", 0)
+	case errors.Is(err, services.ErrListNotFound):
+		RenderErrorToast(w, r, "Invalid List", "Only the owner of this list can sync it.", 0)
+	default:
+		RenderErrorToast(w, r, "Sync Failed", "TMDB could not be reached, please try again later.", 0)
+	}
+}
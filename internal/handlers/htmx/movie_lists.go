@@ -0,0 +1,33 @@
+package htmx
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+)
+
+// MovieLists renders the lists of the user containing a movie, for the movie page
+func (h *Handlers) MovieLists(w http.ResponseWriter, r *http.Request) {
+	movieIDStr := chi.URLParam(r, "id")
+	movieID, err := strconv.ParseInt(movieIDStr, 10, 64)
+	if err != nil {
+		log.Error("failed to parse movie id", "movieID", movieIDStr, "error", err)
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	lists, err := h.listService.GetMovieLists(r.Context(), movieID)
+	if err != nil {
+		log.Error("failed to get lists containing movie", "movieID", movieID, "error", err)
+		RenderErrorToast(w, r, "Failed to Load Lists", "An unexpected error occurred while fetching the lists of this movie.", 0)
+		return
+	}
+
+	if err := pages.MovieLists(movieID, lists).Render(r.Context(), w); err != nil {
+		log.Error("failed to render movie lists", "movieID", movieID, "error", err)
+		http.Error(w, "Failed to render movie lists", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,85 @@
+package htmx
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+)
+
+// UpdateWatchlistItem saves the priority, tags and availability of a movie of the
+// watchlist. Tags are comma separated.
+func (h *Handlers) UpdateWatchlistItem(w http.ResponseWriter, r *http.Request) {
+	movieIDStr := chi.URLParam(r, "id")
+	movieID, err := strconv.ParseInt(movieIDStr, 10, 64)
+	if err != nil {
+		log.Error("failed to parse movie id", "movieID", movieIDStr, "error", err)
+		RenderErrorToast(w, r, "Invalid Movie", "The movie could not be found.", 0)
+		return
+	}
+
+	availableOn := r.FormValue("available_on")
+	item := models.WatchlistItem{
+		Priority:    models.WatchlistPriority(r.FormValue("priority")),
+		Tags:        strings.Split(r.FormValue("tags"), ","),
+		AvailableOn: &availableOn,
+	}
+
+	if err := h.listService.SetWatchlistItem(r.Context(), movieID, item); err != nil {
+		log.Error("failed to update watchlist item", "movieID", movieID, "error", err)
+		switch {
+		case errors.Is(err, services.ErrInvalidWatchlistItem):
+			RenderErrorToast(w, r, "Invalid Details", strings.TrimPrefix(err.Error(), services.ErrInvalidWatchlistItem.Error()+": "), 0)
+		case errors.Is(err, services.ErrMovieNotInList):
+			RenderErrorToast(w, r, "Not in Watchlist", "This movie is no longer in your watchlist.", 0)
+		default:
+			RenderErrorToast(w, r, "Failed to Save", "An unexpected error occurred, please try again.", 0)
+		}
+		return
+	}
+
+	log.Info("successfully updated watchlist item", "movieID", movieID)
+
+	w.Header().Add("HX-Trigger", "refreshListGrid")
+	RenderSuccessToast(w, r, "Watchlist Updated", "The movie details have been saved.", 0)
+}
+
+// SetWatchNextFilter saves which watchlist movies the watch next panel of the home page
+// suggests and renders the panel again
+func (h *Handlers) SetWatchNextFilter(w http.ResponseWriter, r *http.Request) {
+	tag := r.FormValue("tag")
+	excludeTag := r.FormValue("exclude_tag")
+	filter := models.WatchNextFilter{
+		MinPriority: models.WatchlistPriority(r.FormValue("min_priority")),
+		Tag:         &tag,
+		ExcludeTag:  &excludeTag,
+	}
+
+	if _, err := h.listService.SetWatchNextFilter(r.Context(), filter); err != nil {
+		log.Error("failed to set watch next filter", "error", err)
+		if errors.Is(err, services.ErrInvalidWatchNextFilter) {
+			RenderErrorToast(w, r, "Invalid Filter", "Pick one of the offered priorities.", 0)
+			return
+		}
+		RenderErrorToast(w, r, "Failed to Save Filter", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+
+	watchNext, err := h.homeService.GetWatchNext(r.Context())
+	if err != nil {
+		log.Error("failed to get watch next movies", "error", err)
+		RenderErrorToast(w, r, "Failed to Load Watchlist", "The filter was saved, reload the page to see it.", 0)
+		return
+	}
+
+	if err := pages.HomeWatchNext(*watchNext).Render(r.Context(), w); err != nil {
+		log.Error("failed to render watch next", "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "The filter was saved, reload the page to see it.", 0)
+		return
+	}
+}
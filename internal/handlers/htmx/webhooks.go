@@ -0,0 +1,139 @@
+package htmx
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const maxWebhookFieldLength = 2048
+
+func (h *Handlers) WebhooksContent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhooks, err := h.webhookService.GetWebhooks(ctx)
+	if err != nil {
+		log.Error("failed to get webhooks", "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred while loading your webhooks.", 0)
+		return
+	}
+
+	deliveries, err := h.webhookService.GetRecentDeliveries(ctx)
+	if err != nil {
+		log.Error("failed to get webhook deliveries", "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred while loading the delivery log.", 0)
+		return
+	}
+
+	data := models.WebhooksPageData{
+		Webhooks:   webhooks,
+		Deliveries: deliveries,
+	}
+	if err := pages.WebhooksContent(data).Render(ctx, w); err != nil {
+		log.Error("failed to render webhooks", "error", err)
+		http.Error(w, "Failed to render webhooks", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handlers) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		log.Error("failed to parse webhook form", "error", err)
+		RenderErrorToast(w, r, "Invalid Request", "Could not read the submitted form.", 0)
+		return
+	}
+
+	url := r.FormValue("url")
+	secret := r.FormValue("secret")
+	if len(url) > maxWebhookFieldLength || len(secret) > maxWebhookFieldLength {
+		RenderErrorToast(w, r, "Invalid Webhook", "URL and secret must be shorter than 2048 characters.", 0)
+		return
+	}
+
+	events := make([]models.WebhookEvent, 0, len(r.Form["events"]))
+	for _, event := range r.Form["events"] {
+		events = append(events, models.WebhookEvent(event))
+	}
+
+	_, err := h.webhookService.CreateWebhook(r.Context(), url, secret, events)
+	switch {
+	case errors.Is(err, services.ErrInvalidWebhookURL):
+		RenderErrorToast(w, r, "Invalid URL", "Please provide an absolute http or https URL.", 0)
+		return
+	case errors.Is(err, services.ErrWebhookDestinationNotAllowed):
+		RenderErrorToast(w, r, "Destination Not Allowed", "Webhooks cannot be delivered to local or private network addresses.", 0)
+		return
+	case errors.Is(err, services.ErrNoWebhookEvents):
+		RenderErrorToast(w, r, "No Events Selected", "Select at least one event to deliver.", 0)
+		return
+	case errors.Is(err, services.ErrUnknownWebhookEvent):
+		RenderErrorToast(w, r, "Invalid Event", "One of the selected events is not supported.", 0)
+		return
+	case err != nil:
+		log.Error("failed to create webhook", "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+
+	w.Header().Add("HX-Trigger", "refreshWebhooks")
+	RenderSuccessToast(w, r, "Webhook Created", "Events will be delivered to "+url, 0)
+}
+
+func (h *Handlers) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		RenderErrorToast(w, r, "Invalid Webhook", "Invalid webhook ID.", 0)
+		return
+	}
+
+	enabled, err := strconv.ParseBool(r.FormValue("enabled"))
+	if err != nil {
+		RenderErrorToast(w, r, "Invalid Request", "Invalid enabled value.", 0)
+		return
+	}
+
+	err = h.webhookService.SetWebhookEnabled(r.Context(), webhookID, enabled)
+	if errors.Is(err, services.ErrWebhookNotFound) {
+		RenderErrorToast(w, r, "Webhook Not Found", "This webhook no longer exists.", 0)
+		return
+	}
+	if err != nil {
+		log.Error("failed to update webhook", "webhookID", webhookID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+
+	w.Header().Add("HX-Trigger", "refreshWebhooks")
+	if enabled {
+		RenderSuccessToast(w, r, "Webhook Enabled", "New events will be delivered again.", 0)
+	} else {
+		RenderSuccessToast(w, r, "Webhook Disabled", "New events will not be delivered.", 0)
+	}
+}
+
+func (h *Handlers) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		RenderErrorToast(w, r, "Invalid Webhook", "Invalid webhook ID.", 0)
+		return
+	}
+
+	err = h.webhookService.DeleteWebhook(r.Context(), webhookID)
+	if errors.Is(err, services.ErrWebhookNotFound) {
+		RenderErrorToast(w, r, "Webhook Not Found", "This webhook no longer exists.", 0)
+		return
+	}
+	if err != nil {
+		log.Error("failed to delete webhook", "webhookID", webhookID, "error", err)
+		RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+
+	w.Header().Add("HX-Trigger", "refreshWebhooks")
+	RenderSuccessToast(w, r, "Webhook Deleted", "The webhook and its delivery log have been removed.", 0)
+}
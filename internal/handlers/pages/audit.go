@@ -0,0 +1,145 @@
+package pages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+
+	"github.com/a-h/templ"
+)
+
+// auditDateLayout is the format of the since and until filters of the audit log
+const auditDateLayout = "2006-01-02"
+
+var errInvalidAuditFilter = errors.New("invalid audit log filter")
+
+func (h *Handlers) AdminAuditPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		log.Warn("unauthorized access attempt to audit log")
+		http.Redirect(w, r, "/home", http.StatusFound)
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.authService.Audit.List(ctx, filter)
+	if err != nil {
+		log.Error("failed to retrieve audit log", "error", err)
+		render500Error(w, r)
+		return
+	}
+
+	users, err := h.authService.GetAllUsersWithStats(ctx)
+	if err != nil {
+		log.Error("failed to retrieve users for audit log", "error", err)
+		render500Error(w, r)
+		return
+	}
+
+	data := models.AdminAuditLogData{
+		Entries: entries,
+		Filter:  filter,
+	}
+	for _, u := range users {
+		data.Users = append(data.Users, u.User)
+	}
+
+	if r.Header.Get("HX-Request") == htmxRequestHeaderValue {
+		templ.Handler(pages.AdminAuditLog(data), templ.WithFragments("content")).ServeHTTP(w, r)
+	} else {
+		templ.Handler(pages.AdminAuditLog(data)).ServeHTTP(w, r)
+	}
+}
+
+func (h *Handlers) AdminAuditExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.authService.Audit.Export(ctx, filter)
+	if err != nil {
+		log.Error("failed to export audit log", "error", err)
+		http.Error(w, "Failed to export audit log", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		log.Error("failed to marshal audit log", "error", err)
+		http.Error(w, "Failed to encode audit log", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info("admin exported audit log", "adminID", admin.ID, "entries", len(entries))
+
+	filename := fmt.Sprintf("gowatch-audit-%s.json", time.Now().Format(auditDateLayout))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(data); err != nil { // #nosec G705 -- data is trusted output from json.Marshal
+		log.Error("failed to write audit log export", "error", err)
+	}
+}
+
+// parseAuditFilter reads the audit log filter from the query string. Dates are whole
+// days in local time and until includes its own day.
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		Action: models.AuditAction(query.Get("action")),
+		Search: strings.TrimSpace(query.Get("q")),
+	}
+
+	if filter.Action != "" && !filter.Action.Valid() {
+		return filter, fmt.Errorf("%w: unknown action", errInvalidAuditFilter)
+	}
+
+	if user := query.Get("user"); user != "" {
+		userID, err := strconv.ParseInt(user, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid user", errInvalidAuditFilter)
+		}
+		filter.UserID = userID
+	}
+
+	if since := query.Get("since"); since != "" {
+		day, err := time.ParseInLocation(auditDateLayout, since, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid since date", errInvalidAuditFilter)
+		}
+		filter.Since = day
+	}
+
+	if until := query.Get("until"); until != "" {
+		day, err := time.ParseInLocation(auditDateLayout, until, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid until date", errInvalidAuditFilter)
+		}
+		filter.Until = day.AddDate(0, 0, 1)
+	}
+
+	return filter, nil
+}
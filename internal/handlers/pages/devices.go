@@ -0,0 +1,107 @@
+package pages
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/handlers/htmx"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handlers) DevicesPage(w http.ResponseWriter, r *http.Request) {
+	log.Debug("serving devices page")
+
+	if r.Header.Get("HX-Request") == htmxRequestHeaderValue {
+		templ.Handler(pages.Devices(), templ.WithFragments("content")).ServeHTTP(w, r)
+	} else {
+		templ.Handler(pages.Devices()).ServeHTTP(w, r)
+	}
+}
+
+func (h *Handlers) DevicesContent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	currentSessionID := ""
+	if cookie, err := r.Cookie("session_id"); err == nil {
+		currentSessionID = cookie.Value
+	}
+
+	devices, err := h.authService.GetDevices(ctx, currentSessionID)
+	if err != nil {
+		log.Error("failed to get devices", "error", err)
+		htmx.RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred while loading your devices.", 0)
+		return
+	}
+
+	if err := pages.DevicesContent(devices).Render(ctx, w); err != nil {
+		log.Error("failed to render devices", "error", err)
+		http.Error(w, "Failed to render devices", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handlers) RevokeDevice(w http.ResponseWriter, r *http.Request) {
+	err := h.authService.RevokeDevice(r.Context(), chi.URLParam(r, "handle"))
+	if errors.Is(err, services.ErrSessionNotFound) {
+		htmx.RenderErrorToast(w, r, "Device not found", "This device is already signed out", 0)
+		return
+	}
+	if err != nil {
+		log.Error("failed to revoke device", "error", err)
+		htmx.RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+
+	w.Header().Add("HX-Trigger", "refreshDevices")
+	htmx.RenderSuccessToast(w, r, "Device signed out", "It will have to sign in again", 0)
+}
+
+func (h *Handlers) SignOutEverywhere(w http.ResponseWriter, r *http.Request) {
+	_, err := h.authService.SignOutEverywhere(r.Context())
+	if err != nil {
+		log.Error("failed to sign out everywhere", "error", err)
+		htmx.RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+
+	h.clearSessionCookie(w)
+	w.Header().Add("HX-Redirect", "/login")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handlers) AdminSignOutUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	targetUserID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.authService.RevokeUserSessions(ctx, targetUserID)
+	if err != nil {
+		log.Error("failed to sign out user", "userID", targetUserID, "error", err)
+		htmx.RenderErrorToast(w, r, "Sign out failed", "Could not end the user's sessions", 0)
+		return
+	}
+
+	log.Info("admin signed out user everywhere", "adminID", admin.ID, "userID", targetUserID, "count", count)
+	if targetUserID == admin.ID {
+		h.clearSessionCookie(w)
+		w.Header().Add("HX-Redirect", "/login")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	htmx.RenderSuccessToast(w, r, "User signed out", fmt.Sprintf("Ended %d active sessions", count), 0)
+}
@@ -2,16 +2,22 @@
 package pages
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/marcosalvi-01/gowatch/internal/common"
 	"github.com/marcosalvi-01/gowatch/internal/handlers/htmx"
 	"github.com/marcosalvi-01/gowatch/internal/middleware"
+	"github.com/marcosalvi-01/gowatch/internal/models"
 	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/components/oobwrapper"
 	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
 	"github.com/marcosalvi-01/gowatch/logging"
 
@@ -22,6 +28,11 @@ import (
 const (
 	htmxRequestHeaderValue = "true"
 	maxSearchQueryLength   = 255
+
+	oidcFlowCookie  = "oidc_flow"
+	oidcFlowTimeout = 10 * time.Minute
+
+	mfaChallengeCookie = "mfa_challenge"
 )
 
 var log = logging.Get("pages")
@@ -61,6 +72,17 @@ func (h *Handlers) RegisterRoutes(r chi.Router) {
 	r.Get("/register", h.RegisterPage)
 	r.Post("/register", h.RegisterPost)
 	r.Post("/login", h.LoginPost)
+	r.Get("/login/2fa", h.TwoFactorLoginPage)
+	r.Post("/login/2fa", h.TwoFactorLoginPost)
+	r.Post("/login/passkey/options", h.PasskeyLoginOptions)
+	r.Post("/login/passkey", h.PasskeyLoginPost)
+	r.Get("/forgot-password", h.ForgotPasswordPage)
+	r.Post("/forgot-password", h.ForgotPasswordPost)
+	r.Get("/reset-password", h.ResetPasswordPage)
+	r.Post("/reset-password", h.ResetPasswordPost)
+	r.Get("/auth/oidc/login", h.OIDCLogin)
+	r.Get("/auth/oidc/callback", h.OIDCCallback)
+	r.Get(pages.SharedListPath("{token}"), h.SharedListPage)
 
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.AuthMiddleware(*h.authService))
@@ -73,14 +95,52 @@ func (h *Handlers) RegisterRoutes(r chi.Router) {
 		r.Get("/list/{id}", h.ListPage)
 		r.Get("/watchlist", h.Watchlist)
 		r.Get("/stats", h.StatsPage)
+		r.Get("/webhooks", h.WebhooksPage)
 		r.Post("/logout", h.LogoutPost)
+		r.Post(middleware.ImpersonationStopPath, h.StopImpersonation)
 		r.Get("/change-password", h.ChangePasswordPage)
 		r.Post("/change-password", h.ChangePasswordPost)
+		r.Get("/account/profile", h.ProfilePage)
+		r.Post("/account/profile", h.ProfileUpdate)
+		r.Post("/account/password", h.ProfileChangePassword)
+		r.Post("/account/delete", h.ProfileDeleteAccount)
+		r.Post("/account/delete/cancel", h.ProfileCancelDeletion)
+		r.Get("/account/2fa", h.TwoFactorPage)
+		r.Post("/account/2fa/enroll", h.TwoFactorEnroll)
+		r.Post("/account/2fa/confirm", h.TwoFactorConfirm)
+		r.Post("/account/2fa/recovery-codes", h.TwoFactorRecoveryCodes)
+		r.Post("/account/2fa/disable", h.TwoFactorDisable)
+		r.Get("/account/passkeys", h.PasskeysPage)
+		r.Get("/account/passkeys/list", h.PasskeysContent)
+		r.Post("/account/passkeys/options", h.PasskeyRegistrationOptions)
+		r.Post("/account/passkeys", h.PasskeyRegister)
+		r.Patch("/account/passkeys/{id}", h.PasskeyRename)
+		r.Delete("/account/passkeys/{id}", h.PasskeyDelete)
+		r.Get("/account/devices", h.DevicesPage)
+		r.Get("/account/devices/list", h.DevicesContent)
+		r.Delete("/account/devices/{handle}", h.RevokeDevice)
+		r.Post("/account/devices/sign-out-everywhere", h.SignOutEverywhere)
 
 		r.Route("/admin", func(r chi.Router) {
 			r.Get("/users", h.AdminUsersPage)
+			r.Post("/users", h.AdminCreateUser)
+			r.Get("/users/list", h.AdminUsersList)
+			r.Post("/users/{id}/grant-admin", h.AdminGrantAdmin)
+			r.Post("/users/{id}/revoke-admin", h.AdminRevokeAdmin)
+			r.Post("/users/{id}/disable", h.AdminDisableUser)
+			r.Post("/users/{id}/enable", h.AdminEnableUser)
 			r.Delete("/users/{id}", h.AdminDeleteUser)
 			r.Post("/users/{id}/reset-password", h.AdminResetPassword)
+			r.Post("/users/{id}/email-reset", h.AdminEmailPasswordReset)
+			r.Post("/users/{id}/reset-2fa", h.AdminResetTwoFactor)
+			r.Post("/users/{id}/sign-out", h.AdminSignOutUser)
+			r.Post("/users/{id}/unlock", h.AdminUnlockUser)
+			r.Post("/users/{id}/impersonate", h.AdminImpersonate)
+			r.Get("/invites", h.AdminInvites)
+			r.Post("/invites", h.AdminCreateInvite)
+			r.Delete("/invites/{id}", h.AdminRevokeInvite)
+			r.Get("/audit", h.AdminAuditPage)
+			r.Get("/audit/export", h.AdminAuditExport)
 		})
 	})
 }
@@ -278,6 +338,7 @@ func (h *Handlers) ListPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.Debug("fetched list details", "listID", id, "movieCount", len(list.Movies))
+	h.setListView(r, list.ID)
 
 	if r.Header.Get("HX-Request") == htmxRequestHeaderValue {
 		templ.Handler(pages.List(list), templ.WithFragments("content")).ServeHTTP(w, r)
@@ -288,6 +349,45 @@ func (h *Handlers) ListPage(w http.ResponseWriter, r *http.Request) {
 	log.Info("list page served successfully", "listID", id, "name", list.Name, "movieCount", len(list.Movies))
 }
 
+// setListView saves the view set by the query of a list page, which its grid then loads
+// in. This way links to a list can carry a view, see models.ListView.Query.
+func (h *Handlers) setListView(r *http.Request, listID int64) {
+	view, ok, err := services.ParseListView(r.URL.Query())
+	if !ok {
+		return
+	}
+	if err == nil {
+		err = h.listService.SetListView(r.Context(), listID, view)
+	}
+	if err != nil {
+		log.Warn("ignoring list view of page query", "listID", listID, "error", err)
+	}
+}
+
+// SharedListPage serves a list shared by link to anyone, signed in or not
+func (h *Handlers) SharedListPage(w http.ResponseWriter, r *http.Request) {
+	log.Debug("serving shared list page")
+
+	list, err := h.listService.GetSharedList(r.Context(), chi.URLParam(r, "token"))
+	if errors.Is(err, services.ErrListNotFound) {
+		log.Info("shared list not found")
+		w.WriteHeader(http.StatusNotFound)
+		h.Error404Page(w, r)
+		return
+	}
+	if err != nil {
+		log.Error("failed to get shared list", "error", err)
+		render500Error(w, r)
+		return
+	}
+
+	baseURL := h.authService.BaseURL(r.Host, r.TLS != nil)
+	shareURL := baseURL + r.URL.EscapedPath()
+	templ.Handler(pages.SharedList(list, shareURL, baseURL)).ServeHTTP(w, r)
+
+	log.Info("shared list page served successfully", "listID", list.ID, "movieCount", len(list.Movies))
+}
+
 func (h *Handlers) StatsPage(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	const maxLimit = 100
@@ -323,13 +423,52 @@ func (h *Handlers) StatsPage(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) LoginPage(w http.ResponseWriter, r *http.Request) {
 	log.Debug("serving login page")
 
-	templ.Handler(pages.Login()).ServeHTTP(w, r)
+	data := h.authService.LoginPageData()
+	data.Error = ssoErrorMessages[r.URL.Query().Get("sso_error")]
+	if r.URL.Query().Get("password_reset") == "done" {
+		data.Notice = "Your password has been changed, sign in with the new one."
+	}
+
+	templ.Handler(pages.Login(data)).ServeHTTP(w, r)
 }
 
 func (h *Handlers) RegisterPage(w http.ResponseWriter, r *http.Request) {
 	log.Debug("serving registration page")
 
-	templ.Handler(pages.Register()).ServeHTTP(w, r)
+	inviteToken := r.URL.Query().Get("invite")
+
+	unavailable := ""
+	if err := h.authService.CheckRegistration(r.Context(), inviteToken); err != nil {
+		log.Info("registration not available", "error", err)
+		_, unavailable = registrationErrorMessage(err)
+	}
+
+	templ.Handler(pages.Register(inviteToken, unavailable)).ServeHTTP(w, r)
+}
+
+// throttleErrorMessage describes a held back login or registration attempt
+func throttleErrorMessage(err *services.ThrottleError) (string, string) {
+	wait := max(err.RetryAfter.Round(time.Second), time.Second)
+	if errors.Is(err, services.ErrAccountLocked) {
+		return "Account locked", fmt.Sprintf("Too many failed logins, try again in %s or ask an administrator to unlock it", wait)
+	}
+	return "Too many attempts", fmt.Sprintf("Please wait %s before trying again", wait)
+}
+
+// registrationErrorMessage maps a registration error to a user facing title and description
+func registrationErrorMessage(err error) (string, string) {
+	switch {
+	case errors.Is(err, services.ErrRegistrationClosed):
+		return "Registration closed", "New accounts can only be created by an administrator"
+	case errors.Is(err, services.ErrInviteRequired):
+		return "Invite required", "Registration is invite-only, ask an administrator for an invite link"
+	case errors.Is(err, services.ErrInvalidInvite):
+		return "Invalid invite", "This invite link is invalid, expired or has already been used"
+	case errors.Is(err, services.ErrPasswordLoginDisabled):
+		return "Registration disabled", "Accounts are created by signing in with single sign-on"
+	default:
+		return "Registration failed", "This email might already be registered"
+	}
 }
 
 func (h *Handlers) LoginPost(w http.ResponseWriter, r *http.Request) {
@@ -342,33 +481,75 @@ func (h *Handlers) LoginPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.authService.AuthenticateUser(r.Context(), email, password)
+	clientIP := middleware.SessionClient(r, h.authService).IP
+	user, err := h.authService.AuthenticateUser(r.Context(), email, password, clientIP)
 	if err != nil {
-		log.Error("Authentication failed", "error", err)
-		htmx.RenderErrorToast(w, r, "Login failed", "Invalid email or password", 0)
+		log.Error("Authentication failed", "clientIP", clientIP, "error", err)
+		var throttled *services.ThrottleError
+		switch {
+		case errors.Is(err, services.ErrPasswordLoginDisabled):
+			htmx.RenderErrorToast(w, r, "Login failed", "Password login is disabled, use single sign-on", 0)
+		case errors.Is(err, services.ErrAccountDisabled):
+			htmx.RenderErrorToast(w, r, "Account disabled", "Your account has been disabled, ask an administrator", 0)
+		case errors.As(err, &throttled):
+			title, description := throttleErrorMessage(throttled)
+			htmx.RenderErrorToast(w, r, title, description, 0)
+		default:
+			htmx.RenderErrorToast(w, r, "Login failed", "Invalid email or password", 0)
+		}
 		return
 	}
 
 	log.Info("user authenticated successfully", "userID", user.ID)
 
-	sessionID, err := h.authService.CreateSession(r.Context(), user.ID)
+	totpEnabled, err := h.authService.TOTPEnabled(r.Context(), user.ID)
+	if err != nil {
+		log.Error("failed to check two-factor state", "userID", user.ID, "error", err)
+		htmx.RenderErrorToast(w, r, "Login failed", "Please try logging in manually", 0)
+		return
+	}
+
+	if totpEnabled {
+		token, err := h.authService.CreateMFAChallenge(r.Context(), user.ID)
+		if err != nil {
+			log.Error("failed to create login challenge", "userID", user.ID, "error", err)
+			htmx.RenderErrorToast(w, r, "Login failed", "Please try logging in manually", 0)
+			return
+		}
+
+		h.setMFAChallengeCookie(w, token, int(services.MFAChallengeExpiry.Seconds()))
+
+		redirect := "/login/2fa"
+		if rememberMe(r) {
+			redirect += "?remember=on"
+		}
+		w.Header().Add("HX-Redirect", redirect)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	h.completeLogin(w, r, user, rememberMe(r), "password")
+}
+
+// rememberMe reports whether the "remember me" box of a login form was checked
+func rememberMe(r *http.Request) bool {
+	return r.FormValue("remember") == "on"
+}
+
+// completeLogin starts the session of an user who passed every login step, remember
+// keeps the session cookie after the browser is closed and method is recorded in the audit log
+func (h *Handlers) completeLogin(w http.ResponseWriter, r *http.Request, user *models.User, remember bool, method string) {
+	sessionID, err := h.authService.CreateSession(r.Context(), user.ID, middleware.SessionClient(r, h.authService))
 	if err != nil {
 		log.Error("Failed to create session", "error", err)
 		htmx.RenderErrorToast(w, r, "Login failed", "Please try logging in manually", 0)
 		return
 	}
 
-	log.Info("login session created", "userID", user.ID)
+	log.Info("login session created", "userID", user.ID, "remember", remember)
+	h.authService.RecordLogin(r.Context(), user, method)
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_id",
-		Value:    sessionID,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   h.authService.HTTPS,
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   int(h.authService.SessionExpiry),
-	})
+	h.setSessionCookie(w, sessionID, remember)
 
 	if user.PasswordResetRequired {
 		w.Header().Add("HX-Redirect", "/change-password")
@@ -376,6 +557,13 @@ func (h *Handlers) LoginPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the profile page offers to keep an account that is about to be deleted
+	if user.DeletionScheduledAt != nil {
+		w.Header().Add("HX-Redirect", "/account/profile")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	w.Header().Add("HX-Redirect", "/home")
 	w.WriteHeader(http.StatusOK)
 }
@@ -402,10 +590,20 @@ func (h *Handlers) RegisterPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := h.authService.CreateUser(r.Context(), email, name, password)
+	clientIP := middleware.SessionClient(r, h.authService).IP
+	var throttled *services.ThrottleError
+	if err := h.authService.CheckClientThrottle(clientIP); errors.As(err, &throttled) {
+		title, description := throttleErrorMessage(throttled)
+		htmx.RenderErrorToast(w, r, title, description, 0)
+		return
+	}
+
+	userID, err := h.authService.RegisterUser(r.Context(), email, name, password, r.FormValue("invite"))
 	if err != nil {
-		log.Error("Failed to create user", "error", err)
-		htmx.RenderErrorToast(w, r, "Registration failed", "This email might already be registered", 0)
+		log.Error("Failed to create user", "clientIP", clientIP, "error", err)
+		h.authService.RecordClientFailure(clientIP)
+		title, description := registrationErrorMessage(err)
+		htmx.RenderErrorToast(w, r, title, description, 0)
 		return
 	}
 
@@ -444,7 +642,7 @@ func (h *Handlers) RegisterPost(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	sessionID, err := h.authService.CreateSession(r.Context(), userID)
+	sessionID, err := h.authService.CreateSession(r.Context(), userID, middleware.SessionClient(r, h.authService))
 	if err != nil {
 		log.Error("Failed to create session", "error", err)
 		htmx.RenderErrorToast(w, r, "Login failed", "Please try logging in manually", 0)
@@ -453,15 +651,7 @@ func (h *Handlers) RegisterPost(w http.ResponseWriter, r *http.Request) {
 
 	log.Info("registration session created", "userID", userID)
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_id",
-		Value:    sessionID,
-		Path:     "/",
-		MaxAge:   int(h.authService.SessionExpiry),
-		HttpOnly: true,
-		Secure:   h.authService.HTTPS,
-		SameSite: http.SameSiteLaxMode,
-	})
+	h.setSessionCookie(w, sessionID, true)
 
 	w.Header().Add("HX-Redirect", "/home")
 	w.WriteHeader(http.StatusOK)
@@ -518,21 +708,22 @@ func (h *Handlers) LogoutPost(w http.ResponseWriter, r *http.Request) {
 		// Continue with logout even if deletion fails
 	}
 
-	// Clear the session cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_id",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-		Secure:   h.authService.HTTPS,
-		SameSite: http.SameSiteLaxMode,
-	})
+	h.clearSessionCookie(w)
 
 	w.Header().Add("HX-Redirect", "/login")
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *Handlers) WebhooksPage(w http.ResponseWriter, r *http.Request) {
+	log.Debug("serving webhooks page")
+
+	if r.Header.Get("HX-Request") == htmxRequestHeaderValue {
+		templ.Handler(pages.Webhooks(), templ.WithFragments("content")).ServeHTTP(w, r)
+	} else {
+		templ.Handler(pages.Webhooks()).ServeHTTP(w, r)
+	}
+}
+
 func (h *Handlers) AdminUsersPage(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user, err := common.GetUser(ctx)
@@ -549,11 +740,117 @@ func (h *Handlers) AdminUsersPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	data := models.AdminUsersData{
+		Users:                users,
+		RegistrationMode:     h.authService.RegistrationMode,
+		CurrentUserID:        user.ID,
+		PasswordResetByEmail: h.authService.PasswordResetByEmail(),
+	}
 	if r.Header.Get("HX-Request") == htmxRequestHeaderValue {
-		templ.Handler(pages.AdminUsers(users), templ.WithFragments("content")).ServeHTTP(w, r)
+		templ.Handler(pages.AdminUsers(data), templ.WithFragments("content")).ServeHTTP(w, r)
 	} else {
-		templ.Handler(pages.AdminUsers(users)).ServeHTTP(w, r)
+		templ.Handler(pages.AdminUsers(data)).ServeHTTP(w, r)
+	}
+}
+
+func (h *Handlers) AdminUsersList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	users, err := h.authService.GetAllUsersWithStats(ctx)
+	if err != nil {
+		log.Error("failed to retrieve users", "error", err)
+		htmx.RenderErrorToast(w, r, "Error", "Could not load users", 0)
+		return
+	}
+
+	data := models.AdminUsersData{
+		Users:                users,
+		RegistrationMode:     h.authService.RegistrationMode,
+		CurrentUserID:        admin.ID,
+		PasswordResetByEmail: h.authService.PasswordResetByEmail(),
+	}
+	templ.Handler(pages.AdminUsersTable(data)).ServeHTTP(w, r)
+}
+
+func (h *Handlers) AdminCreateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	user, password, err := h.authService.AdminCreateUser(ctx, r.FormValue("name"), r.FormValue("email"), r.FormValue("admin") == "on")
+	if err != nil {
+		log.Info("failed to create user", "error", err)
+		renderProfileErrorToast(w, r, err)
+		return
+	}
+
+	log.Info("admin created user", "adminID", admin.ID, "userID", user.ID, "admin", user.Admin)
+
+	w.Header().Set("HX-Trigger", "refreshUsers")
+	// long enough to copy the password, it is not shown again
+	htmx.RenderSuccessToast(w, r, "User created", fmt.Sprintf("Temporary password: %s", password), 30000)
+}
+
+func (h *Handlers) AdminGrantAdmin(w http.ResponseWriter, r *http.Request) {
+	h.adminUserAction(w, r, h.authService.SetUserAsAdmin, "Admin granted", "The user can now manage every account")
+}
+
+func (h *Handlers) AdminRevokeAdmin(w http.ResponseWriter, r *http.Request) {
+	h.adminUserAction(w, r, h.authService.RevokeAdmin, "Admin revoked", "The user is no longer an admin")
+}
+
+func (h *Handlers) AdminDisableUser(w http.ResponseWriter, r *http.Request) {
+	h.adminUserAction(w, r, h.authService.DisableUser, "User disabled", "The user has been signed out and cannot sign in")
+}
+
+func (h *Handlers) AdminEnableUser(w http.ResponseWriter, r *http.Request) {
+	h.adminUserAction(w, r, h.authService.EnableUser, "User enabled", "The user can sign in again")
+}
+
+// adminUserAction runs action on the user in the URL and refreshes the users table. Admins
+// cannot act on themselves, they would lock themselves out.
+func (h *Handlers) adminUserAction(w http.ResponseWriter, r *http.Request, action func(context.Context, int64) error, title, description string) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	targetUserID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if targetUserID == admin.ID {
+		htmx.RenderErrorToast(w, r, "Action denied", "You cannot change your own account from here", 0)
+		return
+	}
+
+	err = action(ctx, targetUserID)
+	if errors.Is(err, services.ErrLastAdmin) {
+		htmx.RenderErrorToast(w, r, "Last admin", "Make another user an admin first", 0)
+		return
 	}
+	if err != nil {
+		log.Error("admin user action failed", "userID", targetUserID, "action", title, "error", err)
+		htmx.RenderErrorToast(w, r, "Action failed", "Could not update the user", 0)
+		return
+	}
+
+	log.Info("admin updated user", "adminID", admin.ID, "userID", targetUserID, "action", title)
+
+	w.Header().Set("HX-Trigger", "refreshUsers")
+	htmx.RenderSuccessToast(w, r, title, description, 0)
 }
 
 func (h *Handlers) AdminDeleteUser(w http.ResponseWriter, r *http.Request) {
@@ -612,6 +909,108 @@ func (h *Handlers) AdminResetPassword(w http.ResponseWriter, r *http.Request) {
 	htmx.RenderSuccessToast(w, r, "Password reset", fmt.Sprintf("Password has been reset to: %s", newPass), 0)
 }
 
+func (h *Handlers) AdminUnlockUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	targetUserID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.UnlockUser(ctx, targetUserID); err != nil {
+		log.Error("failed to unlock user", "userID", targetUserID, "error", err)
+		htmx.RenderErrorToast(w, r, "Unlock failed", "Could not clear the failed logins of the user", 0)
+		return
+	}
+
+	log.Info("admin unlocked user", "adminID", admin.ID, "userID", targetUserID)
+	htmx.RenderSuccessToast(w, r, "User unlocked", "Failed logins have been cleared", 0)
+}
+
+func (h *Handlers) AdminInvites(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	invites, err := h.authService.GetInvites(ctx)
+	if err != nil {
+		log.Error("failed to retrieve invites", "error", err)
+		htmx.RenderErrorToast(w, r, "Error", "Could not load invites", 0)
+		return
+	}
+
+	data := models.AdminInvitesData{
+		Invites: invites,
+		Now:     time.Now(),
+	}
+	templ.Handler(pages.AdminInvites(data)).ServeHTTP(w, r)
+}
+
+func (h *Handlers) AdminCreateInvite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	invite, err := h.authService.CreateInvite(ctx)
+	if err != nil {
+		log.Error("failed to create invite", "error", err)
+		htmx.RenderErrorToast(w, r, "Invite failed", "Could not create invite link", 0)
+		return
+	}
+
+	log.Info("admin created invite", "adminID", admin.ID, "inviteID", invite.ID)
+
+	w.Header().Set("HX-Trigger", "refreshInvites")
+
+	// the token is only stored hashed, this response is the only chance to show the link
+	link := h.authService.BaseURL(r.Host, r.TLS != nil) + "/register?invite=" + invite.Token
+	oobCtx := templ.WithChildren(ctx, pages.NewInviteLink(link))
+	if err := oobwrapper.OOBWrapper("innerHTML:#new-invite").Render(oobCtx, w); err != nil {
+		log.Error("failed to render invite link", "inviteID", invite.ID, "error", err)
+		htmx.RenderErrorToast(w, r, "Unexpected Error", "The invite was created but its link could not be shown, revoke it and try again", 0)
+		return
+	}
+
+	htmx.RenderSuccessToast(w, r, "Invite created", "Copy the link now, it will not be shown again", 0)
+}
+
+func (h *Handlers) AdminRevokeInvite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	inviteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid invite ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.authService.RevokeInvite(ctx, inviteID)
+	if err != nil {
+		log.Error("failed to revoke invite", "inviteID", inviteID, "error", err)
+		htmx.RenderErrorToast(w, r, "Revoke failed", "Could not revoke invite", 0)
+		return
+	}
+
+	w.Header().Set("HX-Trigger", "refreshInvites")
+	htmx.RenderSuccessToast(w, r, "Invite revoked", "The link can no longer be used", 0)
+}
+
 func (h *Handlers) ChangePasswordPage(w http.ResponseWriter, r *http.Request) {
 	log.Debug("serving change password page")
 
@@ -675,7 +1074,7 @@ func (h *Handlers) ChangePasswordPost(w http.ResponseWriter, r *http.Request) {
 
 	log.Info("user password changed successfully", "userID", user.ID)
 
-	sessionID, err := h.authService.CreateSession(r.Context(), user.ID)
+	sessionID, err := h.authService.CreateSession(r.Context(), user.ID, middleware.SessionClient(r, h.authService))
 	if err != nil {
 		log.Error("Failed to create session", "error", err)
 		htmx.RenderErrorToast(w, r, "Login failed", "Please try logging in manually", 0)
@@ -684,15 +1083,7 @@ func (h *Handlers) ChangePasswordPost(w http.ResponseWriter, r *http.Request) {
 
 	log.Info("registration session created", "userID", user.ID)
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_id",
-		Value:    sessionID,
-		Path:     "/",
-		MaxAge:   int(h.authService.SessionExpiry),
-		HttpOnly: true,
-		Secure:   h.authService.HTTPS,
-		SameSite: http.SameSiteLaxMode,
-	})
+	h.setSessionCookie(w, sessionID, true)
 
 	w.Header().Add("HX-Redirect", "/home")
 	w.WriteHeader(http.StatusOK)
@@ -717,6 +1108,7 @@ func (h *Handlers) Watchlist(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.Debug("fetched watchlist details", "userID", userID, "movieCount", len(list.Movies))
+	h.setListView(r, list.ID)
 
 	if r.Header.Get("HX-Request") == htmxRequestHeaderValue {
 		templ.Handler(pages.Watchlist(list), templ.WithFragments("content")).ServeHTTP(w, r)
@@ -726,3 +1118,133 @@ func (h *Handlers) Watchlist(w http.ResponseWriter, r *http.Request) {
 
 	log.Info("watchlist page served successfully", "userID", userID, "movieCount", len(list.Movies))
 }
+
+// ssoErrorMessages maps the sso_error codes set by OIDCCallback to login page messages
+var ssoErrorMessages = map[string]string{
+	"failed":     "Single sign-on failed, please try again",
+	"not_linked": "No account matches this identity, ask an administrator to create one",
+	"unverified": "Your identity provider does not report your email address as verified",
+	"disabled":   "Your account has been disabled, ask an administrator",
+}
+
+// OIDCLogin starts single sign-on by redirecting to the identity provider.
+// The flow secrets are kept in a short lived cookie scoped to the callback.
+func (h *Handlers) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	log.Debug("starting OIDC login")
+
+	authURL, flow, err := h.authService.BeginOIDCLogin(r.Context())
+	if err != nil {
+		log.Error("failed to start OIDC login", "error", err)
+		http.Redirect(w, r, "/login?sso_error=failed", http.StatusFound)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    strings.Join([]string{flow.State, flow.Nonce, flow.Verifier}, "."),
+		Path:     "/auth/oidc",
+		MaxAge:   int(oidcFlowTimeout.Seconds()),
+		HttpOnly: true,
+		Secure:   h.authService.HTTPS,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback completes single sign-on and starts a session for the returned identity
+func (h *Handlers) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	log.Debug("processing OIDC callback")
+
+	cookie, err := r.Cookie(oidcFlowCookie)
+	// the flow is single use
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Path:     "/auth/oidc",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.authService.HTTPS,
+		SameSite: http.SameSiteLaxMode,
+	})
+	if err != nil {
+		log.Warn("OIDC callback without a flow cookie")
+		http.Redirect(w, r, "/login?sso_error=failed", http.StatusFound)
+		return
+	}
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		log.Warn("identity provider returned an error", "error", providerErr, "description", query.Get("error_description"))
+		http.Redirect(w, r, "/login?sso_error=failed", http.StatusFound)
+		return
+	}
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 || subtle.ConstantTimeCompare([]byte(parts[0]), []byte(query.Get("state"))) != 1 {
+		log.Warn("OIDC callback state mismatch")
+		http.Redirect(w, r, "/login?sso_error=failed", http.StatusFound)
+		return
+	}
+	flow := models.OIDCFlow{State: parts[0], Nonce: parts[1], Verifier: parts[2]}
+
+	user, err := h.authService.CompleteOIDCLogin(r.Context(), query.Get("code"), flow)
+	if err != nil {
+		log.Error("OIDC login failed", "error", err)
+		code := "failed"
+		switch {
+		case errors.Is(err, services.ErrOIDCUserNotFound):
+			code = "not_linked"
+		case errors.Is(err, services.ErrOIDCEmailNotVerified):
+			code = "unverified"
+		case errors.Is(err, services.ErrAccountDisabled):
+			code = "disabled"
+		}
+		http.Redirect(w, r, "/login?sso_error="+code, http.StatusFound)
+		return
+	}
+
+	sessionID, err := h.authService.CreateSession(r.Context(), user.ID, middleware.SessionClient(r, h.authService))
+	if err != nil {
+		log.Error("Failed to create session", "error", err)
+		http.Redirect(w, r, "/login?sso_error=failed", http.StatusFound)
+		return
+	}
+
+	log.Info("OIDC login session created", "userID", user.ID)
+	h.authService.RecordLogin(r.Context(), user, "single sign-on")
+	h.setSessionCookie(w, sessionID, true)
+
+	http.Redirect(w, r, "/home", http.StatusFound)
+}
+
+// setSessionCookie stores a new session and rotates the CSRF token so it is not shared across sessions.
+// A persistent cookie lives as long as the session can, otherwise it ends with the browser.
+func (h *Handlers) setSessionCookie(w http.ResponseWriter, sessionID string, persistent bool) {
+	cookie := &http.Cookie{
+		Name:     "session_id",
+		Value:    sessionID,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   h.authService.HTTPS,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if persistent {
+		cookie.MaxAge = int(h.authService.SessionMaxLifetime.Seconds())
+	}
+	http.SetCookie(w, cookie)
+	middleware.ResetCSRFToken(w, h.authService.HTTPS)
+}
+
+// clearSessionCookie removes the session cookie and rotates the CSRF token
+func (h *Handlers) clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_id",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.authService.HTTPS,
+		SameSite: http.SameSiteLaxMode,
+	})
+	middleware.ResetCSRFToken(w, h.authService.HTTPS)
+}
@@ -9,6 +9,9 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
 	"github.com/marcosalvi-01/gowatch/internal/services"
 )
 
@@ -88,3 +91,66 @@ func withRouteParam(ctx context.Context, key, value string) context.Context {
 
 	return context.WithValue(ctx, chi.RouteCtxKey, rctx)
 }
+
+func TestHandlers_TwoFactorLoginPage_WithoutChallengeRedirectsToLogin(t *testing.T) {
+	h := &Handlers{}
+
+	req := httptest.NewRequest(http.MethodGet, "/login/2fa", nil)
+	res := httptest.NewRecorder()
+
+	h.TwoFactorLoginPage(res, req)
+
+	if res.Code != http.StatusFound || res.Header().Get("Location") != "/login" {
+		t.Fatalf("expected redirect to /login, got %d %q", res.Code, res.Header().Get("Location"))
+	}
+}
+
+func TestHandlers_SharedListPage_ViewableWithoutLogin(t *testing.T) {
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = testDB.Close() }()
+
+	listService := services.NewListService(testDB, nil, nil)
+	authService := services.NewAuthService(testDB, listService, time.Hour, false, "", models.RegistrationOpen, time.Hour)
+	authService.PublicURL = "https://gowatch.example.com"
+	h := &Handlers{listService: listService, authService: authService}
+	router := chi.NewRouter()
+	h.RegisterRoutes(router)
+
+	user, err := testDB.CreateUser(context.Background(), "owner@example.com", "Owner", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), common.UserKey, user)
+	list, err := listService.CreateList(ctx, "Movie night", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err = listService.ShareList(ctx, list.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/shared/"+*list.ShareToken, nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.Code)
+	}
+	body := res.Body.String()
+	for _, tag := range []string{
+		`<meta property="og:title" content="Movie night">`,
+		`<meta property="og:url" content="https://gowatch.example.com/shared/` + *list.ShareToken + `">`,
+	} {
+		if !strings.Contains(body, tag) {
+			t.Errorf("expected the page to contain %s", tag)
+		}
+	}
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/shared/unknown", nil))
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for an unknown link, got %d", http.StatusNotFound, res.Code)
+	}
+}
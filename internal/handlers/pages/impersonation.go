@@ -0,0 +1,80 @@
+package pages
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/handlers/htmx"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handlers) AdminImpersonate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	targetUserID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	cookie, err := r.Cookie("session_id")
+	if err != nil {
+		htmx.RenderErrorToast(w, r, "No session", "Sign in again to view as another user", 0)
+		return
+	}
+
+	target, err := h.authService.StartImpersonation(ctx, cookie.Value, targetUserID)
+	if err != nil {
+		log.Info("failed to start impersonation", "adminID", admin.ID, "userID", targetUserID, "error", err)
+		switch {
+		case errors.Is(err, services.ErrImpersonateSelf):
+			htmx.RenderErrorToast(w, r, "Action denied", "You are already viewing gowatch as yourself", 0)
+		case errors.Is(err, services.ErrImpersonateAdmin):
+			htmx.RenderErrorToast(w, r, "Action denied", "Admins cannot be viewed as, revoke their admin role first", 0)
+		default:
+			htmx.RenderErrorToast(w, r, "Action failed", "Could not view as this user", 0)
+		}
+		return
+	}
+
+	log.Info("admin started impersonating user", "adminID", admin.ID, "userID", target.ID)
+
+	w.Header().Add("HX-Redirect", "/home")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handlers) StopImpersonation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cookie, err := r.Cookie("session_id")
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	err = h.authService.StopImpersonation(ctx, cookie.Value)
+	if errors.Is(err, services.ErrNotImpersonating) {
+		w.Header().Add("HX-Redirect", "/home")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil {
+		log.Error("failed to stop impersonation", "error", err)
+		htmx.RenderErrorToast(w, r, "Action failed", "Could not return to your account, please try again", 0)
+		return
+	}
+
+	log.Info("admin stopped impersonating user", "adminID", common.GetImpersonator(ctx).ID)
+
+	w.Header().Add("HX-Redirect", "/admin/users")
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,195 @@
+package pages
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/marcosalvi-01/gowatch/internal/handlers/htmx"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+)
+
+// PasskeyLoginOptions starts a passkey sign-in, the browser passes the options to navigator.credentials.get
+func (h *Handlers) PasskeyLoginOptions(w http.ResponseWriter, r *http.Request) {
+	options, err := h.authService.BeginPasskeyLogin(r.Context(), h.webAuthnOrigin(r))
+	if err != nil {
+		log.Error("failed to start passkey login", "error", err)
+		http.Error(w, "Failed to start passkey sign-in", http.StatusInternalServerError)
+		return
+	}
+
+	writePasskeyOptions(w, options)
+}
+
+func (h *Handlers) PasskeyLoginPost(w http.ResponseWriter, r *http.Request) {
+	log.Debug("processing passkey login request")
+
+	credential := r.FormValue("credential")
+	if credential == "" {
+		htmx.RenderErrorToast(w, r, "Missing passkey", "Your browser did not return a passkey", 0)
+		return
+	}
+
+	user, err := h.authService.FinishPasskeyLogin(r.Context(), h.webAuthnOrigin(r), []byte(credential))
+	switch {
+	case errors.Is(err, services.ErrPasskeyNotFound):
+		htmx.RenderErrorToast(w, r, "Unknown passkey", "This passkey is not registered, it may have been revoked", 0)
+		return
+	case errors.Is(err, services.ErrPasskeyChallengeInvalid):
+		htmx.RenderErrorToast(w, r, "Sign-in expired", "Please try again", 0)
+		return
+	case errors.Is(err, services.ErrAccountDisabled):
+		htmx.RenderErrorToast(w, r, "Account disabled", "Your account has been disabled, ask an administrator", 0)
+		return
+	case errors.Is(err, services.ErrInvalidPasskey):
+		log.Warn("passkey login rejected", "error", err)
+		htmx.RenderErrorToast(w, r, "Sign-in failed", "The passkey could not be verified", 0)
+		return
+	case err != nil:
+		log.Error("passkey login failed", "error", err)
+		htmx.RenderErrorToast(w, r, "Login failed", "Please try logging in manually", 0)
+		return
+	}
+
+	h.completeLogin(w, r, user, rememberMe(r), "passkey")
+}
+
+func (h *Handlers) PasskeysPage(w http.ResponseWriter, r *http.Request) {
+	log.Debug("serving passkeys page")
+
+	if r.Header.Get("HX-Request") == htmxRequestHeaderValue {
+		templ.Handler(pages.Passkeys(), templ.WithFragments("content")).ServeHTTP(w, r)
+	} else {
+		templ.Handler(pages.Passkeys()).ServeHTTP(w, r)
+	}
+}
+
+func (h *Handlers) PasskeysContent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	passkeys, err := h.authService.GetPasskeys(ctx)
+	if err != nil {
+		log.Error("failed to get passkeys", "error", err)
+		htmx.RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred while loading your passkeys.", 0)
+		return
+	}
+
+	if err := pages.PasskeysContent(passkeys).Render(ctx, w); err != nil {
+		log.Error("failed to render passkeys", "error", err)
+		http.Error(w, "Failed to render passkeys", http.StatusInternalServerError)
+	}
+}
+
+// PasskeyRegistrationOptions starts adding a passkey, the browser passes the options to navigator.credentials.create
+func (h *Handlers) PasskeyRegistrationOptions(w http.ResponseWriter, r *http.Request) {
+	options, err := h.authService.BeginPasskeyRegistration(r.Context(), h.webAuthnOrigin(r))
+	if err != nil {
+		log.Error("failed to start passkey registration", "error", err)
+		http.Error(w, "Failed to start passkey registration", http.StatusInternalServerError)
+		return
+	}
+
+	writePasskeyOptions(w, options)
+}
+
+func (h *Handlers) PasskeyRegister(w http.ResponseWriter, r *http.Request) {
+	credential := r.FormValue("credential")
+	if credential == "" {
+		htmx.RenderErrorToast(w, r, "Missing passkey", "Your browser did not return a passkey", 0)
+		return
+	}
+
+	passkey, err := h.authService.FinishPasskeyRegistration(r.Context(), h.webAuthnOrigin(r), r.FormValue("name"), []byte(credential))
+	if err != nil {
+		log.Info("failed to register passkey", "error", err)
+		renderPasskeyErrorToast(w, r, err)
+		return
+	}
+
+	w.Header().Add("HX-Trigger", "refreshPasskeys")
+	htmx.RenderSuccessToast(w, r, "Passkey added", "You can now sign in with "+passkey.Name, 0)
+}
+
+func (h *Handlers) PasskeyRename(w http.ResponseWriter, r *http.Request) {
+	passkeyID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		htmx.RenderErrorToast(w, r, "Invalid Passkey", "Invalid passkey ID.", 0)
+		return
+	}
+
+	err = h.authService.RenamePasskey(r.Context(), passkeyID, r.FormValue("name"))
+	if err != nil {
+		log.Info("failed to rename passkey", "passkeyID", passkeyID, "error", err)
+		renderPasskeyErrorToast(w, r, err)
+		return
+	}
+
+	w.Header().Add("HX-Trigger", "refreshPasskeys")
+	htmx.RenderSuccessToast(w, r, "Passkey renamed", "", 0)
+}
+
+func (h *Handlers) PasskeyDelete(w http.ResponseWriter, r *http.Request) {
+	passkeyID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		htmx.RenderErrorToast(w, r, "Invalid Passkey", "Invalid passkey ID.", 0)
+		return
+	}
+
+	err = h.authService.DeletePasskey(r.Context(), passkeyID)
+	if err != nil {
+		log.Info("failed to revoke passkey", "passkeyID", passkeyID, "error", err)
+		renderPasskeyErrorToast(w, r, err)
+		return
+	}
+
+	w.Header().Add("HX-Trigger", "refreshPasskeys")
+	htmx.RenderSuccessToast(w, r, "Passkey revoked", "It can no longer be used to sign in", 0)
+}
+
+// webAuthnOrigin is the origin passkeys are bound to, the configured one or the one the request was sent to
+func (h *Handlers) webAuthnOrigin(r *http.Request) string {
+	if h.authService.WebAuthnOrigin != "" {
+		return h.authService.WebAuthnOrigin
+	}
+
+	scheme := "http"
+	if h.authService.HTTPS {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func writePasskeyOptions(w http.ResponseWriter, options any) {
+	data, err := json.Marshal(options)
+	if err != nil {
+		log.Error("failed to marshal passkey options", "error", err)
+		http.Error(w, "Failed to encode passkey options", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(data); err != nil { // #nosec G705 -- data is trusted output from json.Marshal
+		log.Error("failed to write passkey options", "error", err)
+	}
+}
+
+func renderPasskeyErrorToast(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPasskeyName):
+		htmx.RenderErrorToast(w, r, "Invalid name", "Passkey names must be between 1 and 64 characters", 0)
+	case errors.Is(err, services.ErrPasskeyNotFound):
+		htmx.RenderErrorToast(w, r, "Passkey not found", "This passkey no longer exists", 0)
+	case errors.Is(err, services.ErrPasskeyChallengeInvalid):
+		htmx.RenderErrorToast(w, r, "Request expired", "Please try again", 0)
+	case errors.Is(err, services.ErrInvalidPasskey):
+		htmx.RenderErrorToast(w, r, "Invalid passkey", "The passkey could not be verified", 0)
+	default:
+		htmx.RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+	}
+}
@@ -0,0 +1,131 @@
+package pages
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/handlers/htmx"
+	"github.com/marcosalvi-01/gowatch/internal/middleware"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handlers) ForgotPasswordPage(w http.ResponseWriter, r *http.Request) {
+	log.Debug("serving forgot password page")
+
+	if !h.authService.PasswordResetByEmail() {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	templ.Handler(pages.ForgotPassword()).ServeHTTP(w, r)
+}
+
+func (h *Handlers) ForgotPasswordPost(w http.ResponseWriter, r *http.Request) {
+	email := r.FormValue("email")
+	if email == "" {
+		htmx.RenderErrorToast(w, r, "Missing fields", "Please enter your email address", 0)
+		return
+	}
+
+	clientIP := middleware.SessionClient(r, h.authService).IP
+	var throttled *services.ThrottleError
+	if err := h.authService.CheckClientThrottle(clientIP); errors.As(err, &throttled) {
+		title, description := throttleErrorMessage(throttled)
+		htmx.RenderErrorToast(w, r, title, description, 0)
+		return
+	}
+	// every request may send an email, so each one slows the client down like a failed login
+	h.authService.RecordClientFailure(clientIP)
+
+	err := h.authService.RequestPasswordReset(r.Context(), email)
+	if errors.Is(err, services.ErrPasswordResetUnavailable) {
+		htmx.RenderErrorToast(w, r, "Reset unavailable", "Ask an administrator to reset your password", 0)
+		return
+	}
+	if err != nil {
+		log.Error("failed to send password reset link", "clientIP", clientIP, "error", err)
+		htmx.RenderErrorToast(w, r, "Email not sent", "The reset link could not be sent, please try again later", 0)
+		return
+	}
+
+	htmx.RenderSuccessToast(w, r, "Check your email", "If an account uses this address, a reset link is on its way", 6000)
+}
+
+func (h *Handlers) ResetPasswordPage(w http.ResponseWriter, r *http.Request) {
+	log.Debug("serving reset password page")
+
+	token := r.URL.Query().Get("token")
+	unavailable := ""
+	if err := h.authService.CheckPasswordReset(r.Context(), token); err != nil {
+		log.Info("password reset link not usable", "error", err)
+		unavailable = "This reset link is invalid, expired or has already been used."
+	}
+
+	templ.Handler(pages.ResetPassword(token, unavailable)).ServeHTTP(w, r)
+}
+
+func (h *Handlers) ResetPasswordPost(w http.ResponseWriter, r *http.Request) {
+	password := r.FormValue("password")
+	if password == "" || r.FormValue("confirm_password") == "" {
+		htmx.RenderErrorToast(w, r, "Missing fields", "Please fill in all fields", 0)
+		return
+	}
+	if password != r.FormValue("confirm_password") {
+		htmx.RenderErrorToast(w, r, "Passwords don't match", "Please make sure both passwords are the same", 0)
+		return
+	}
+	if ok, why := ValidatePassword(password); !ok {
+		htmx.RenderErrorToast(w, r, "Password is too weak", why, 0)
+		return
+	}
+
+	user, err := h.authService.ResetPassword(r.Context(), r.FormValue("token"), password)
+	if err != nil {
+		log.Info("failed to reset password", "error", err)
+		switch {
+		case errors.Is(err, services.ErrInvalidPasswordReset):
+			htmx.RenderErrorToast(w, r, "Link expired", "This reset link is invalid, expired or has already been used", 0)
+		case errors.Is(err, services.ErrAccountDisabled):
+			htmx.RenderErrorToast(w, r, "Account disabled", "Your account has been disabled, ask an administrator", 0)
+		default:
+			htmx.RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		}
+		return
+	}
+
+	log.Info("user reset their password with an emailed link", "userID", user.ID)
+
+	// signing in still goes through two-factor authentication, so the link alone is not enough
+	w.Header().Add("HX-Redirect", "/login?password_reset=done")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handlers) AdminEmailPasswordReset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	targetUserID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authService.EmailPasswordReset(ctx, targetUserID); err != nil {
+		log.Error("failed to email password reset link", "userID", targetUserID, "error", err)
+		htmx.RenderErrorToast(w, r, "Email not sent", "Could not email the reset link, check the SMTP settings", 0)
+		return
+	}
+
+	log.Info("admin emailed password reset link", "adminID", admin.ID, "userID", targetUserID)
+	htmx.RenderSuccessToast(w, r, "Reset link sent", "The user can choose a new password from the email", 0)
+}
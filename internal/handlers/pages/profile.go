@@ -0,0 +1,173 @@
+package pages
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/handlers/htmx"
+	"github.com/marcosalvi-01/gowatch/internal/middleware"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/components/oobwrapper"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+
+	"github.com/a-h/templ"
+)
+
+func (h *Handlers) ProfilePage(w http.ResponseWriter, r *http.Request) {
+	log.Debug("serving profile page")
+
+	data, err := h.profilePageData(r)
+	if err != nil {
+		log.Error("failed to load profile", "error", err)
+		render500Error(w, r)
+		return
+	}
+
+	if r.Header.Get("HX-Request") == htmxRequestHeaderValue {
+		templ.Handler(pages.Profile(data), templ.WithFragments("content")).ServeHTTP(w, r)
+	} else {
+		templ.Handler(pages.Profile(data)).ServeHTTP(w, r)
+	}
+}
+
+func (h *Handlers) ProfileUpdate(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authService.UpdateProfile(r.Context(), r.FormValue("name"), r.FormValue("email"), r.FormValue("password"))
+	if err != nil {
+		log.Info("failed to update profile", "error", err)
+		renderProfileErrorToast(w, r, err)
+		return
+	}
+
+	log.Info("user updated their profile", "userID", user.ID)
+	htmx.RenderSuccessToast(w, r, "Profile updated", "Your name and email have been saved", 0)
+}
+
+func (h *Handlers) ProfileChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		log.Error("failed to get user from context", "error", err)
+		htmx.RenderErrorToast(w, r, "Authentication Error", "Please log in again", 0)
+		return
+	}
+
+	password := r.FormValue("new_password")
+	if password != r.FormValue("confirm_password") {
+		htmx.RenderErrorToast(w, r, "Passwords don't match", "Please make sure both passwords are the same", 0)
+		return
+	}
+	if ok, why := ValidatePassword(password); !ok {
+		htmx.RenderErrorToast(w, r, "Password is too weak", why, 0)
+		return
+	}
+
+	if err := h.authService.ChangeOwnPassword(ctx, r.FormValue("current_password"), password); err != nil {
+		log.Info("failed to change password", "userID", user.ID, "error", err)
+		renderProfileErrorToast(w, r, err)
+		return
+	}
+
+	log.Info("user changed their password", "userID", user.ID)
+
+	// changing the password signed every device out, this one included
+	sessionID, err := h.authService.CreateSession(ctx, user.ID, middleware.SessionClient(r, h.authService))
+	if err != nil {
+		log.Error("failed to create session after password change", "userID", user.ID, "error", err)
+		h.clearSessionCookie(w)
+		w.Header().Add("HX-Redirect", "/login")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	h.setSessionCookie(w, sessionID, true)
+
+	// the CSRF token is bound to the session, so reload to pick up the new one
+	w.Header().Add("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handlers) ProfileDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	deleteAt, err := h.authService.ScheduleAccountDeletion(r.Context(), r.FormValue("password"))
+	if err != nil {
+		log.Info("failed to schedule account deletion", "error", err)
+		renderProfileErrorToast(w, r, err)
+		return
+	}
+
+	log.Info("user scheduled their account for deletion", "deleteAt", deleteAt)
+
+	h.clearSessionCookie(w)
+	w.Header().Add("HX-Redirect", "/login")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handlers) ProfileCancelDeletion(w http.ResponseWriter, r *http.Request) {
+	err := h.authService.CancelAccountDeletion(r.Context())
+	if err != nil && !errors.Is(err, services.ErrDeletionNotScheduled) {
+		log.Error("failed to cancel account deletion", "error", err)
+		htmx.RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+		return
+	}
+
+	data, err := h.profilePageData(r)
+	if err != nil {
+		log.Error("failed to load profile", "error", err)
+		htmx.RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please reload the page.", 0)
+		return
+	}
+
+	htmx.RenderSuccessToast(w, r, "Deletion canceled", "Your account will be kept", 0)
+
+	var buf bytes.Buffer
+	if err := pages.ProfileDeletion(data).Render(r.Context(), &buf); err != nil {
+		log.Error("failed to render account deletion", "error", err)
+		return
+	}
+	oobCtx := templ.WithChildren(r.Context(), templ.Raw(buf.String()))
+	if err := oobwrapper.OOBWrapper("innerHTML:#profile-deletion").Render(oobCtx, w); err != nil {
+		log.Error("failed to render account deletion oob wrapper", "error", err)
+	}
+}
+
+// profilePageData reads the signed-in user again, the one in the context was loaded
+// before any change made by the request
+func (h *Handlers) profilePageData(r *http.Request) (models.ProfilePageData, error) {
+	ctx := r.Context()
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return models.ProfilePageData{}, err
+	}
+
+	current, err := h.authService.GetUserByID(ctx, user.ID)
+	if err != nil {
+		return models.ProfilePageData{}, err
+	}
+
+	return models.ProfilePageData{
+		User:          *current,
+		DeletionGrace: h.authService.AccountDeletionGrace,
+	}, nil
+}
+
+func renderProfileErrorToast(w http.ResponseWriter, r *http.Request, err error) {
+	var throttled *services.ThrottleError
+	switch {
+	case errors.As(err, &throttled):
+		title, description := throttleErrorMessage(throttled)
+		htmx.RenderErrorToast(w, r, title, description, 0)
+	case errors.Is(err, services.ErrWrongPassword):
+		htmx.RenderErrorToast(w, r, "Wrong password", "Your current password is not correct", 0)
+	case errors.Is(err, services.ErrInvalidProfileName):
+		htmx.RenderErrorToast(w, r, "Invalid name", "Names must be between 1 and 64 characters", 0)
+	case errors.Is(err, services.ErrInvalidEmail):
+		htmx.RenderErrorToast(w, r, "Invalid email", "Please enter a valid email address", 0)
+	case errors.Is(err, services.ErrEmailTaken):
+		htmx.RenderErrorToast(w, r, "Email in use", "Another account already uses this email address", 0)
+	case errors.Is(err, services.ErrLastAdmin):
+		htmx.RenderErrorToast(w, r, "Last admin", "Make another user an admin before deleting your account", 0)
+	default:
+		htmx.RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+	}
+}
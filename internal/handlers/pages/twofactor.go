@@ -0,0 +1,211 @@
+package pages
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/handlers/htmx"
+	"github.com/marcosalvi-01/gowatch/internal/middleware"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+	"github.com/marcosalvi-01/gowatch/internal/ui/components/oobwrapper"
+	"github.com/marcosalvi-01/gowatch/internal/ui/pages"
+
+	"github.com/a-h/templ"
+	"github.com/go-chi/chi/v5"
+)
+
+// TwoFactorLoginPage asks for the second factor of a login whose password was verified
+func (h *Handlers) TwoFactorLoginPage(w http.ResponseWriter, r *http.Request) {
+	log.Debug("serving two-factor login page")
+
+	if _, err := r.Cookie(mfaChallengeCookie); err != nil {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	templ.Handler(pages.TwoFactorLogin(r.URL.Query().Get("remember") == "on")).ServeHTTP(w, r)
+}
+
+func (h *Handlers) TwoFactorLoginPost(w http.ResponseWriter, r *http.Request) {
+	log.Debug("processing two-factor login request")
+
+	cookie, err := r.Cookie(mfaChallengeCookie)
+	if err != nil {
+		htmx.RenderErrorToast(w, r, "Sign-in expired", "Please sign in again", 0)
+		return
+	}
+
+	code := r.FormValue("code")
+	if code == "" {
+		htmx.RenderErrorToast(w, r, "Missing code", "Please enter your authentication code", 0)
+		return
+	}
+
+	clientIP := middleware.SessionClient(r, h.authService).IP
+	user, err := h.authService.VerifyMFAChallenge(r.Context(), cookie.Value, code, clientIP)
+	var throttled *services.ThrottleError
+	switch {
+	case errors.As(err, &throttled):
+		log.Warn("two-factor login throttled", "clientIP", clientIP, "error", err)
+		title, description := throttleErrorMessage(throttled)
+		htmx.RenderErrorToast(w, r, title, description, 0)
+		return
+	case errors.Is(err, services.ErrInvalidTOTPCode):
+		htmx.RenderErrorToast(w, r, "Invalid code", "The code is wrong, expired or was already used", 0)
+		return
+	case errors.Is(err, services.ErrMFAChallengeInvalid), errors.Is(err, services.ErrMFATooManyAttempts):
+		log.Warn("two-factor login failed", "error", err)
+		h.setMFAChallengeCookie(w, "", -1)
+		htmx.RenderErrorToast(w, r, "Sign-in expired", "Please sign in again", 0)
+		return
+	case err != nil:
+		log.Error("two-factor login failed", "error", err)
+		htmx.RenderErrorToast(w, r, "Login failed", "Please try logging in manually", 0)
+		return
+	}
+
+	log.Info("user passed two-factor authentication", "userID", user.ID)
+
+	h.setMFAChallengeCookie(w, "", -1)
+	h.completeLogin(w, r, user, rememberMe(r), "password and two-factor code")
+}
+
+func (h *Handlers) TwoFactorPage(w http.ResponseWriter, r *http.Request) {
+	log.Debug("serving two-factor settings page")
+
+	data, err := h.authService.TwoFactorPageData(r.Context())
+	if err != nil {
+		log.Error("failed to get two-factor state", "error", err)
+		render500Error(w, r)
+		return
+	}
+
+	if r.Header.Get("HX-Request") == htmxRequestHeaderValue {
+		templ.Handler(pages.TwoFactor(data), templ.WithFragments("content")).ServeHTTP(w, r)
+	} else {
+		templ.Handler(pages.TwoFactor(data)).ServeHTTP(w, r)
+	}
+}
+
+func (h *Handlers) TwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	enrollment, err := h.authService.BeginTOTPEnrollment(r.Context())
+	if err != nil {
+		log.Error("failed to start two-factor enrollment", "error", err)
+		renderTwoFactorErrorToast(w, r, err)
+		return
+	}
+
+	htmx.RenderSuccessToast(w, r, "Scan the QR code", "Then confirm with a code from your app", 0)
+	h.renderTwoFactorOOB(w, r, models.TwoFactorPageData{Enrollment: enrollment}, nil)
+}
+
+func (h *Handlers) TwoFactorConfirm(w http.ResponseWriter, r *http.Request) {
+	codes, err := h.authService.ConfirmTOTPEnrollment(r.Context(), r.FormValue("code"))
+	if err != nil {
+		log.Info("failed to confirm two-factor enrollment", "error", err)
+		renderTwoFactorErrorToast(w, r, err)
+		return
+	}
+
+	htmx.RenderSuccessToast(w, r, "Two-factor enabled", "Save your recovery codes now", 0)
+	h.renderTwoFactorOOB(w, r, models.TwoFactorPageData{Enabled: true, RecoveryCodesLeft: int64(len(codes))}, codes)
+}
+
+func (h *Handlers) TwoFactorRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	codes, err := h.authService.RegenerateRecoveryCodes(r.Context(), r.FormValue("code"))
+	if err != nil {
+		log.Info("failed to regenerate recovery codes", "error", err)
+		renderTwoFactorErrorToast(w, r, err)
+		return
+	}
+
+	htmx.RenderSuccessToast(w, r, "Recovery codes replaced", "Your previous codes no longer work", 0)
+	h.renderTwoFactorOOB(w, r, models.TwoFactorPageData{Enabled: true, RecoveryCodesLeft: int64(len(codes))}, codes)
+}
+
+func (h *Handlers) TwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	err := h.authService.DisableTOTP(r.Context(), r.FormValue("code"))
+	if err != nil {
+		log.Info("failed to disable two-factor authentication", "error", err)
+		renderTwoFactorErrorToast(w, r, err)
+		return
+	}
+
+	htmx.RenderSuccessToast(w, r, "Two-factor disabled", "Signing in only requires your password", 0)
+	h.renderTwoFactorOOB(w, r, models.TwoFactorPageData{}, nil)
+}
+
+func (h *Handlers) AdminResetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	admin, err := common.GetUser(ctx)
+	if err != nil || !admin.Admin {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	targetUserID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.authService.ResetTOTP(ctx, targetUserID)
+	if errors.Is(err, services.ErrTOTPNotEnabled) {
+		htmx.RenderErrorToast(w, r, "Reset failed", "Two-factor authentication is not enabled for this user", 0)
+		return
+	}
+	if err != nil {
+		log.Error("failed to reset two-factor authentication", "userID", targetUserID, "error", err)
+		htmx.RenderErrorToast(w, r, "Reset failed", "Could not reset two-factor authentication", 0)
+		return
+	}
+
+	log.Info("admin reset two-factor authentication", "adminID", admin.ID, "userID", targetUserID)
+	htmx.RenderSuccessToast(w, r, "Two-factor reset", "The user can now sign in with only their password", 0)
+}
+
+// renderTwoFactorOOB swaps the two-factor settings shown on the page, next to the toast
+func (h *Handlers) renderTwoFactorOOB(w http.ResponseWriter, r *http.Request, data models.TwoFactorPageData, recoveryCodes []string) {
+	ctx := r.Context()
+
+	var buf bytes.Buffer
+	if err := pages.TwoFactorContent(data, recoveryCodes).Render(ctx, &buf); err != nil {
+		log.Error("failed to render two-factor settings", "error", err)
+		return
+	}
+
+	oobCtx := templ.WithChildren(ctx, templ.Raw(buf.String()))
+	if err := oobwrapper.OOBWrapper("innerHTML:#two-factor").Render(oobCtx, w); err != nil {
+		log.Error("failed to render two-factor settings oob wrapper", "error", err)
+	}
+}
+
+func renderTwoFactorErrorToast(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTOTPCode):
+		htmx.RenderErrorToast(w, r, "Invalid code", "The code is wrong, expired or was already used", 0)
+	case errors.Is(err, services.ErrTOTPAlreadyEnabled):
+		htmx.RenderErrorToast(w, r, "Already enabled", "Two-factor authentication is already enabled", 0)
+	case errors.Is(err, services.ErrTOTPNotEnrolled), errors.Is(err, services.ErrTOTPNotEnabled):
+		htmx.RenderErrorToast(w, r, "Not enabled", "Reload the page and set up two-factor authentication first", 0)
+	default:
+		htmx.RenderErrorToast(w, r, "Unexpected Error", "An unexpected error occurred, please try again.", 0)
+	}
+}
+
+// setMFAChallengeCookie stores the pending login challenge, a negative maxAge clears it
+func (h *Handlers) setMFAChallengeCookie(w http.ResponseWriter, token string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     mfaChallengeCookie,
+		Value:    token,
+		Path:     "/login",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.authService.HTTPS,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
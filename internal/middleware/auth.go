@@ -3,15 +3,47 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/handlers/htmx"
+	"github.com/marcosalvi-01/gowatch/internal/models"
 	"github.com/marcosalvi-01/gowatch/internal/services"
 )
 
+const (
+	// maxUserAgentLength caps the user agent stored with a session
+	maxUserAgentLength = 512
+
+	// ImpersonationStopPath ends an impersonation, the only change allowed while impersonating
+	ImpersonationStopPath = "/impersonation/stop"
+)
+
 func AuthMiddleware(authService services.AuthService) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			cookie, err := r.Cookie("session_id")
+			if proxy := authService.ProxyAuth; proxy != nil {
+				username, email, name, ok := proxy.ProxyIdentity(r.Header.Get)
+				if ok {
+					if !proxy.Trusts(r.RemoteAddr) {
+						log.Warn("rejected proxy auth headers from untrusted peer", "remoteAddr", r.RemoteAddr, "path", r.URL.Path)
+						http.Error(w, "Forbidden", http.StatusForbidden)
+						return
+					}
+
+					r, user, session, err := proxyUser(w, r, &authService, username, email, name)
+					if err != nil {
+						log.Error("trusted proxy authentication failed", "username", username, "email", email, "error", err)
+						http.Error(w, "Forbidden", http.StatusForbidden)
+						return
+					}
+
+					serveAs(w, r, next, &authService, session, user)
+					return
+				}
+			}
+
+			cookie, err := r.Cookie(sessionCookieName)
 			if err != nil {
 				http.Redirect(w, r, "/login", http.StatusFound)
 				return
@@ -29,8 +61,131 @@ func AuthMiddleware(authService services.AuthService) func(next http.Handler) ht
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), common.UserKey, user)
+			// disabling an account ends its sessions, this catches requests already in flight
+			if user.Disabled() {
+				log.Warn("rejected session of disabled user", "userID", user.ID)
+				http.Redirect(w, r, "/login", http.StatusFound)
+				return
+			}
+
+			if err := authService.TouchSession(r.Context(), session, SessionClient(r, &authService)); err != nil {
+				log.Warn("failed to record session activity", "userID", user.ID, "error", err)
+			}
+
+			serveAs(w, r, next, &authService, session, user)
+		})
+	}
+}
+
+// serveAs runs next as user, or as the user the session of an admin impersonates. While
+// impersonating only reads go through, besides ending the impersonation and signing out.
+func serveAs(w http.ResponseWriter, r *http.Request, next http.Handler, authService *services.AuthService, session *models.Session, user *models.User) {
+	ctx := context.WithValue(r.Context(), common.UserKey, user)
+
+	if session != nil {
+		target, err := authService.ImpersonatedUser(ctx, session, user)
+		if err != nil {
+			log.Warn("failed to load impersonated user, serving the admin", "userID", user.ID, "error", err)
+		}
+		if target != nil {
+			if !impersonationAllows(r) {
+				log.Warn("blocked change while impersonating", "adminID", user.ID, "userID", target.ID, "method", r.Method, "path", r.URL.Path)
+				rejectImpersonatedChange(w, r)
+				return
+			}
+			ctx = context.WithValue(ctx, common.ImpersonatorKey, user)
+			ctx = context.WithValue(ctx, common.UserKey, target)
+		}
+	}
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func impersonationAllows(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return r.URL.Path == ImpersonationStopPath || r.URL.Path == "/logout"
+}
+
+// rejectImpersonatedChange answers HTMX requests with a toast, whatever element they target
+func rejectImpersonatedChange(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("HX-Request") != "true" {
+		http.Error(w, "Read-only while impersonating a user", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("HX-Retarget", "#toast")
+	w.Header().Set("HX-Reswap", "innerHTML")
+	htmx.RenderErrorToast(w, r, "Read-only", "Nothing can be changed while viewing as another user", 0)
+}
+
+// proxyUser resolves the user asserted by a trusted proxy. The existing session is
+// reused while it belongs to that user, otherwise a new one is started and no session
+// is returned. The request is returned with the CSRF token of the new session, which the
+// page being served must already use.
+func proxyUser(w http.ResponseWriter, r *http.Request, authService *services.AuthService, username, email, name string) (*http.Request, *models.User, *models.Session, error) {
+	ctx := r.Context()
+
+	user, err := authService.AuthenticateProxyUser(ctx, username, email, name)
+	if err != nil {
+		return r, nil, nil, err
+	}
+
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		session, err := authService.GetSession(ctx, cookie.Value)
+		if err == nil && session.UserID == user.ID {
+			return r, user, session, nil
+		}
+	}
+
+	sessionID, err := authService.CreateSession(ctx, user.ID, SessionClient(r, authService))
+	if err != nil {
+		return r, nil, nil, err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    sessionID,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   authService.HTTPS,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(authService.SessionMaxLifetime.Seconds()),
+	})
+
+	log.Info("started session from trusted proxy headers", "userID", user.ID, "email", user.Email)
+	authService.RecordLogin(ctx, user, "trusted proxy headers")
+	return r.WithContext(context.WithValue(ctx, common.CSRFTokenKey, services.SessionCSRFToken(sessionID))), user, nil, nil
+}
+
+// ClientIPMiddleware stores the client IP address of every request in its context, so
+// services can record where an action came from
+func ClientIPMiddleware(authService *services.AuthService) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := authService.ClientIP(r.RemoteAddr, forwardedFor(r))
+			ctx := context.WithValue(r.Context(), common.ClientIPKey, ip)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// SessionClient describes the device behind r, see AuthService.ClientIP for how the address is resolved
+func SessionClient(r *http.Request, authService *services.AuthService) models.SessionClient {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	return models.SessionClient{
+		UserAgent: userAgent,
+		IP:        authService.ClientIP(r.RemoteAddr, forwardedFor(r)),
+	}
+}
+
+// forwardedFor joins every X-Forwarded-For line of r. Proxies may append a line of their
+// own instead of extending the first one, reading only the first would hand the client
+// the entry the trusted proxies are walked back to.
+func forwardedFor(r *http.Request) string {
+	return strings.Join(r.Header.Values("X-Forwarded-For"), ",")
+}
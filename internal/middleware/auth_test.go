@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+)
+
+func newProxyAuthTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+
+	trusted, err := services.ParseTrustedProxies([]string{"10.0.0.0/8, 192.168.1.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listService := services.NewListService(testDB, nil, nil)
+	authService := services.NewAuthService(testDB, listService, time.Hour, false, "Welcome123!", models.RegistrationClosed, time.Hour)
+	authService.ProxyAuth = &services.ProxyAuthConfig{
+		UserHeader:     "Remote-User",
+		EmailHeader:    "Remote-Email",
+		NameHeader:     "Remote-Name",
+		TrustedProxies: trusted,
+	}
+
+	return AuthMiddleware(*authService)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := common.GetUser(r.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(user.Email))
+	}))
+}
+
+func proxyRequest(remoteAddr, user, email string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/home", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("Remote-User", user)
+	req.Header.Set("Remote-Email", email)
+	return req
+}
+
+func sessionCookie(rec *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "session_id" {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestAuthMiddleware_TrustedProxyCreatesUserAndSession(t *testing.T) {
+	handler := newProxyAuthTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, proxyRequest("10.1.2.3:4567", "alice", "alice@example.com"))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "alice@example.com" {
+		t.Fatalf("expected alice to be signed in, got %d %q", rec.Code, rec.Body.String())
+	}
+	session := sessionCookie(rec)
+	if session == nil {
+		t.Fatal("expected a session cookie")
+	}
+
+	// the session is reused while it belongs to the asserted user
+	req := proxyRequest("192.168.1.10:4567", "alice", "alice@example.com")
+	req.AddCookie(session)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || sessionCookie(rec) != nil {
+		t.Errorf("expected existing session to be reused, got %d", rec.Code)
+	}
+
+	// a different user behind the proxy gets their own session
+	req = proxyRequest("10.1.2.3:4567", "bob", "bob@example.com")
+	req.AddCookie(session)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Body.String() != "bob@example.com" || sessionCookie(rec) == nil {
+		t.Errorf("expected a new session for bob, got %q", rec.Body.String())
+	}
+}
+
+func TestAuthMiddleware_RejectsProxyHeadersFromUntrustedPeer(t *testing.T) {
+	handler := newProxyAuthTestHandler(t)
+
+	for _, remoteAddr := range []string{"203.0.113.7:4567", "192.168.1.11:4567", "[::1]:4567"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, proxyRequest(remoteAddr, "mallory", "admin@example.com"))
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: expected status %d, got %d", remoteAddr, http.StatusForbidden, rec.Code)
+		}
+		if sessionCookie(rec) != nil {
+			t.Errorf("%s: expected no session to be created", remoteAddr)
+		}
+	}
+}
+
+func TestAuthMiddleware_ProxyUsernameAsEmail(t *testing.T) {
+	handler := newProxyAuthTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, proxyRequest("10.0.0.1:80", "carol@example.com", ""))
+	if rec.Code != http.StatusOK || rec.Body.String() != "carol@example.com" {
+		t.Errorf("expected email-like username to be used, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, proxyRequest("10.0.0.1:80", "dave", ""))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected request without an email to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestAuthMiddleware_NoProxyHeadersFallsBackToSession(t *testing.T) {
+	handler := newProxyAuthTestHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/home", nil)
+	req.RemoteAddr = "10.0.0.1:80"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/login" {
+		t.Errorf("expected redirect to login, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestSessionClient_ForwardedForOnlyFromTrustedProxies(t *testing.T) {
+	trusted, err := services.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authService := &services.AuthService{TrustedProxies: trusted}
+
+	tests := []struct {
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"203.0.113.7:1234", nil, "203.0.113.7"},
+		{"203.0.113.7:1234", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"10.0.0.2:1234", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"10.0.0.2:1234", nil, "10.0.0.2"},
+		// a proxy appending its own line, the first one comes from the client
+		{"10.0.0.2:1234", []string{"1.2.3.4", "198.51.100.1, 10.0.0.3"}, "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/home", nil)
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set("User-Agent", "test-agent")
+		for _, forwarded := range tt.forwarded {
+			req.Header.Add("X-Forwarded-For", forwarded)
+		}
+
+		client := SessionClient(req, authService)
+		if client.IP != tt.want || client.UserAgent != "test-agent" {
+			t.Errorf("%s via %q: expected IP %s, got %+v", tt.remoteAddr, tt.forwarded, tt.want, client)
+		}
+
+		var ip string
+		ClientIPMiddleware(authService)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip = common.GetClientIP(r.Context())
+		})).ServeHTTP(httptest.NewRecorder(), req)
+		if ip != tt.want {
+			t.Errorf("%s via %q: expected the middleware to store IP %s, got %s", tt.remoteAddr, tt.forwarded, tt.want, ip)
+		}
+	}
+}
+
+func TestAuthMiddleware_ImpersonationIsReadOnly(t *testing.T) {
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+
+	authService := services.NewAuthService(testDB, services.NewListService(testDB, nil, nil), time.Hour, false, "Welcome123!", models.RegistrationOpen, time.Hour)
+	adminID, err := authService.RegisterUser(t.Context(), "admin@example.com", "Admin", "Password123!", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, err := authService.RegisterUser(t.Context(), "user@example.com", "User", "Password123!", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := authService.SetUserAsAdmin(t.Context(), adminID); err != nil {
+		t.Fatal(err)
+	}
+	admin, err := authService.GetUserByID(t.Context(), adminID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := authService.CreateSession(t.Context(), admin.ID, models.SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminCtx := context.WithValue(t.Context(), common.UserKey, admin)
+	if _, err := authService.StartImpersonation(adminCtx, token, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := AuthMiddleware(*authService)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := common.GetUser(r.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(user.Email))
+	}))
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: token})
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodGet, "/home"); rec.Code != http.StatusOK || rec.Body.String() != "user@example.com" {
+		t.Errorf("expected to read as the user, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := serve(http.MethodPost, "/account/profile"); rec.Code != http.StatusForbidden {
+		t.Errorf("expected changes to be refused, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodPost, ImpersonationStopPath); rec.Code != http.StatusOK {
+		t.Errorf("expected the impersonation to be stoppable, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+)
+
+const (
+	sessionCookieName = "session_id"
+	csrfCookieName    = "csrf_token"
+	// CSRFFormField can be used instead of the header by plain HTML forms
+	CSRFFormField  = "csrf_token"
+	csrfTokenBytes = 32
+)
+
+// CSRFMiddleware stores the CSRF token of the request in its context so layouts can
+// send it back in the X-CSRF-Token header. Signed in visitors get the token of their
+// session, derived from the session cookie, so it changes with every session and cannot
+// be planted by whoever can set cookies. Visitors without a session, as on the login
+// page, are issued a token cookie instead.
+// Unsafe methods are rejected unless they come from the same origin and carry the token.
+func CSRFMiddleware(secure bool) func(next http.Handler) http.Handler {
+	crossOrigin := http.NewCrossOriginProtection()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := ""
+			session := false
+			if cookie, err := r.Cookie(sessionCookieName); err == nil && cookie.Value != "" {
+				token = services.SessionCSRFToken(cookie.Value)
+				session = true
+			} else if cookie, err := r.Cookie(csrfCookieName); err == nil && validCSRFToken(cookie.Value) {
+				token = cookie.Value
+			}
+
+			if !isSafeMethod(r.Method) {
+				if err := crossOrigin.Check(r); err != nil {
+					log.Warn("rejected cross-origin request", "method", r.Method, "path", r.URL.Path, "error", err)
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+
+				if token == "" || !csrfTokensEqual(token, requestCSRFToken(r)) {
+					log.Warn("rejected request with invalid CSRF token", "method", r.Method, "path", r.URL.Path)
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+			}
+
+			if token == "" && !session {
+				var err error
+				token, err = generateCSRFToken()
+				if err != nil {
+					log.Error("failed to generate CSRF token", "error", err)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+				http.SetCookie(w, &http.Cookie{
+					Name:     csrfCookieName,
+					Value:    token,
+					Path:     "/",
+					HttpOnly: true,
+					Secure:   secure,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			ctx := context.WithValue(r.Context(), common.CSRFTokenKey, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ResetCSRFToken expires the CSRF cookie of visitors without a session so a fresh token
+// is issued on the next request. Call it whenever the user's session changes (login, logout).
+func ResetCSRFToken(w http.ResponseWriter, secure bool) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func requestCSRFToken(r *http.Request) string {
+	if token := r.Header.Get(common.CSRFHeader); token != "" {
+		return token
+	}
+	return r.PostFormValue(CSRFFormField)
+}
+
+func csrfTokensEqual(expected, actual string) bool {
+	return actual != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) == 1
+}
+
+func validCSRFToken(token string) bool {
+	if len(token) != csrfTokenBytes*2 {
+		return false
+	}
+	_, err := hex.DecodeString(token)
+	return err == nil
+}
+
+func generateCSRFToken() (string, error) {
+	bytes := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
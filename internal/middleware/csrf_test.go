@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+)
+
+func newCSRFTestHandler() http.Handler {
+	return CSRFMiddleware(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(common.GetCSRFToken(r.Context())))
+	}))
+}
+
+// issueCSRFToken performs a GET and returns the issued cookie
+func issueCSRFToken(t *testing.T, handler http.Handler) *http.Cookie {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected GET to succeed, got %d", rec.Code)
+	}
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == csrfCookieName {
+			if rec.Body.String() != cookie.Value {
+				t.Fatalf("expected token in context to match cookie")
+			}
+			return cookie
+		}
+	}
+	t.Fatal("expected CSRF cookie to be issued")
+	return nil
+}
+
+func TestCSRFMiddleware_SafeMethodsReuseExistingToken(t *testing.T) {
+	handler := newCSRFTestHandler()
+	cookie := issueCSRFToken(t, handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/home", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Body.String() != cookie.Value {
+		t.Errorf("expected existing token to be reused")
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Errorf("expected no new cookie when a valid one is present")
+	}
+}
+
+func TestCSRFMiddleware_UnsafeMethods(t *testing.T) {
+	handler := newCSRFTestHandler()
+	cookie := issueCSRFToken(t, handler)
+
+	tests := []struct {
+		name       string
+		method     string
+		header     map[string]string
+		form       url.Values
+		withCookie bool
+		want       int
+	}{
+		{
+			name:       "same origin with header token",
+			method:     http.MethodPost,
+			header:     map[string]string{common.CSRFHeader: cookie.Value, "Sec-Fetch-Site": "same-origin"},
+			withCookie: true,
+			want:       http.StatusOK,
+		},
+		{
+			name:       "form field token",
+			method:     http.MethodPost,
+			form:       url.Values{CSRFFormField: {cookie.Value}},
+			withCookie: true,
+			want:       http.StatusOK,
+		},
+		{
+			name:       "delete with header token",
+			method:     http.MethodDelete,
+			header:     map[string]string{common.CSRFHeader: cookie.Value},
+			withCookie: true,
+			want:       http.StatusOK,
+		},
+		{
+			name:       "missing token",
+			method:     http.MethodPost,
+			withCookie: true,
+			want:       http.StatusForbidden,
+		},
+		{
+			name:       "mismatched token",
+			method:     http.MethodPatch,
+			header:     map[string]string{common.CSRFHeader: strings.Repeat("a", 64)},
+			withCookie: true,
+			want:       http.StatusForbidden,
+		},
+		{
+			name:   "missing cookie",
+			method: http.MethodPost,
+			header: map[string]string{common.CSRFHeader: cookie.Value},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:       "cross-site fetch metadata",
+			method:     http.MethodPost,
+			header:     map[string]string{common.CSRFHeader: cookie.Value, "Sec-Fetch-Site": "cross-site"},
+			withCookie: true,
+			want:       http.StatusForbidden,
+		},
+		{
+			name:       "cross-origin form post",
+			method:     http.MethodPost,
+			header:     map[string]string{"Origin": "https://evil.example"},
+			form:       url.Values{"email": {"victim@example.com"}},
+			withCookie: true,
+			want:       http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			if tt.form != nil {
+				req = httptest.NewRequest(tt.method, "/login", strings.NewReader(tt.form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				req = httptest.NewRequest(tt.method, "/login", nil)
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			if tt.withCookie {
+				req.AddCookie(cookie)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestCSRFMiddleware_SessionBoundToken(t *testing.T) {
+	handler := newCSRFTestHandler()
+	planted := issueCSRFToken(t, handler)
+	session := &http.Cookie{Name: sessionCookieName, Value: "session-a"}
+	sessionToken := services.SessionCSRFToken(session.Value)
+
+	get := httptest.NewRequest(http.MethodGet, "/home", nil)
+	get.AddCookie(session)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, get)
+
+	if rec.Body.String() != sessionToken {
+		t.Errorf("expected the token to be derived from the session")
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Errorf("expected no CSRF cookie for a request with a session")
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{name: "session token", token: sessionToken, want: http.StatusOK},
+		{name: "planted cookie token", token: planted.Value, want: http.StatusForbidden},
+		{name: "other session token", token: services.SessionCSRFToken("session-b"), want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+			req.Header.Set(common.CSRFHeader, tt.token)
+			req.AddCookie(session)
+			req.AddCookie(planted)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestResetCSRFToken(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ResetCSRFToken(rec, true)
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != csrfCookieName || cookies[0].MaxAge >= 0 || !cookies[0].Secure {
+		t.Errorf("expected an expired secure CSRF cookie, got %+v", cookies)
+	}
+}
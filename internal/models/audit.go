@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// AuditAction is the kind of event recorded in the audit log
+type AuditAction string
+
+const (
+	AuditLoginSucceeded    AuditAction = "login.succeeded"
+	AuditLoginFailed       AuditAction = "login.failed"
+	AuditPasswordChanged   AuditAction = "password.changed"
+	AuditPasswordReset     AuditAction = "password.reset"
+	AuditPasswordResetSent AuditAction = "password.reset_sent"
+	AuditUserCreated       AuditAction = "user.created"
+	AuditUserDeleted       AuditAction = "user.deleted"
+	AuditUserDisabled      AuditAction = "user.disabled"
+	AuditUserEnabled       AuditAction = "user.enabled"
+	AuditUserRoleChanged   AuditAction = "user.role_changed"
+	AuditProfileUpdated    AuditAction = "user.profile_updated"
+	AuditDeletionScheduled AuditAction = "user.deletion_scheduled"
+	AuditDeletionCanceled  AuditAction = "user.deletion_canceled"
+	AuditDataImported      AuditAction = "data.imported"
+	AuditDataExported      AuditAction = "data.exported"
+	AuditAuditLogExported  AuditAction = "audit.exported"
+
+	AuditImpersonationStarted AuditAction = "impersonation.started"
+	AuditImpersonationStopped AuditAction = "impersonation.stopped"
+)
+
+// AllAuditActions lists every audit action, in display order
+var AllAuditActions = []AuditAction{
+	AuditLoginSucceeded,
+	AuditLoginFailed,
+	AuditPasswordChanged,
+	AuditPasswordReset,
+	AuditPasswordResetSent,
+	AuditUserCreated,
+	AuditUserDeleted,
+	AuditUserDisabled,
+	AuditUserEnabled,
+	AuditUserRoleChanged,
+	AuditProfileUpdated,
+	AuditDeletionScheduled,
+	AuditDeletionCanceled,
+	AuditDataImported,
+	AuditDataExported,
+	AuditAuditLogExported,
+	AuditImpersonationStarted,
+	AuditImpersonationStopped,
+}
+
+// Valid reports whether a is a known audit action
+func (a AuditAction) Valid() bool {
+	for _, action := range AllAuditActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditEntry is a single event of the audit log. The actor is who acted and the target
+// the account acted upon, either may be missing. Emails are copied at the time of the
+// event so entries stay readable after an account is deleted.
+type AuditEntry struct {
+	ID          int64       `json:"id"`
+	Action      AuditAction `json:"action"`
+	ActorID     *int64      `json:"actor_id,omitempty"`
+	ActorEmail  string      `json:"actor_email,omitempty"`
+	TargetID    *int64      `json:"target_id,omitempty"`
+	TargetEmail string      `json:"target_email,omitempty"`
+	IP          string      `json:"ip,omitempty"`
+	Detail      string      `json:"detail,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// AuditFilter narrows down the audit log, zero values match everything
+type AuditFilter struct {
+	Action AuditAction
+	// UserID matches entries where the user is either the actor or the target
+	UserID int64
+	// Search matches part of an email, IP or detail
+	Search string
+	Since  time.Time
+	Until  time.Time
+	Limit  int64
+}
+
+// AdminAuditLogData is the data shown on the admin audit log page
+type AdminAuditLogData struct {
+	Entries []AuditEntry
+	Filter  AuditFilter
+	// Users are offered as choices for the user filter
+	Users []User
+}
@@ -3,8 +3,35 @@ package models
 import "time"
 
 type Session struct {
+	// ID is the SHA-256 of the session token, the token itself is only known to the cookie
+	ID        string
 	UserID    int64
 	ExpiresAt time.Time
+	// MaxExpiresAt is the absolute end of the session, activity never extends ExpiresAt past it
+	MaxExpiresAt time.Time
+	CreatedAt    *time.Time
+	UserAgent    string
+	IP           string
+	LastSeenAt   *time.Time
+	// ImpersonatedUserID is the user an admin views gowatch as from this session, read-only
+	ImpersonatedUserID *int64
+}
+
+// SessionClient describes the device a session is used from
+type SessionClient struct {
+	UserAgent string
+	IP        string
+}
+
+// Device is a session as shown on the devices page
+type Device struct {
+	// Handle identifies the session without revealing its token
+	Handle     string
+	Browser    string
+	IP         string
+	CreatedAt  *time.Time
+	LastSeenAt *time.Time
+	Current    bool
 }
 
 type User struct {
@@ -15,10 +42,163 @@ type User struct {
 	Admin                 bool
 	CreatedAt             *time.Time
 	PasswordResetRequired bool
+	// DeletionScheduledAt is when the account will be deleted, set while the user has asked to delete it
+	DeletionScheduledAt *time.Time
+	// DisabledAt is set while an admin has disabled the account, disabled users cannot sign in
+	DisabledAt *time.Time
+}
+
+// Disabled reports whether an admin has disabled the account
+func (u User) Disabled() bool {
+	return u.DisabledAt != nil
 }
 
 type UserWithStats struct {
 	User
 	WatchedCount int64
 	ListCount    int64
+	TOTPEnabled  bool
+	SessionCount int64
+	FailedLogins int64
+	// LockedUntil is set when the account was locked after too many failed logins
+	LockedUntil *time.Time
+}
+
+// Locked reports whether the account is locked out of password login at the given time
+func (u UserWithStats) Locked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// AdminUsersData is the data shown on the admin user management page
+type AdminUsersData struct {
+	Users            []UserWithStats
+	RegistrationMode RegistrationMode
+	// CurrentUserID is the signed-in admin, who cannot demote or disable themselves
+	CurrentUserID int64
+	// PasswordResetByEmail offers to email reset links, when SMTP is configured
+	PasswordResetByEmail bool
+}
+
+// LoginLockout tracks the failed password logins of an account
+type LoginLockout struct {
+	UserID       int64
+	FailedCount  int64
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+}
+
+// RegistrationMode controls who can create an account through the register page
+type RegistrationMode string
+
+const (
+	RegistrationOpen       RegistrationMode = "open"
+	RegistrationClosed     RegistrationMode = "closed"
+	RegistrationInviteOnly RegistrationMode = "invite"
+)
+
+// Valid reports whether m is one of the known registration modes
+func (m RegistrationMode) Valid() bool {
+	switch m {
+	case RegistrationOpen, RegistrationClosed, RegistrationInviteOnly:
+		return true
+	}
+	return false
+}
+
+// Invite is a single-use registration link generated by an admin.
+// Only a hash of the token is stored, Token is set right after the invite is created.
+type Invite struct {
+	ID            int64
+	Token         string
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	CreatedByName *string
+	UsedByEmail   *string
+}
+
+// Usable reports whether the invite can still be redeemed at the given time
+func (i Invite) Usable(now time.Time) bool {
+	return i.UsedAt == nil && now.Before(i.ExpiresAt)
+}
+
+type AdminInvitesData struct {
+	Invites []Invite
+	Now     time.Time
+}
+
+// OIDCFlow holds the per-login secrets of an OIDC authorization code flow.
+// It is kept in a short lived cookie between the redirect and the callback.
+type OIDCFlow struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// LoginPageData controls which sign-in methods the login page offers
+type LoginPageData struct {
+	PasswordLogin bool
+	// SSOName is the label of the single sign-on button, empty when SSO is not configured
+	SSOName string
+	// PasswordReset shows the forgot password link, when reset links can be emailed
+	PasswordReset bool
+	Error         string
+	// Notice is a confirmation shown above the form, like after a password reset
+	Notice string
+}
+
+// UserTOTP is the authenticator app secret of an user, Enabled once a first code was confirmed
+type UserTOTP struct {
+	UserID  int64
+	Secret  string
+	Enabled bool
+	// LastStep is the last accepted time step, codes of that step or earlier are rejected
+	LastStep  *int64
+	CreatedAt time.Time
+}
+
+// MFAChallenge is a login waiting for its second factor
+type MFAChallenge struct {
+	TokenHash string
+	UserID    int64
+	ExpiresAt time.Time
+	Attempts  int64
+}
+
+// TOTPEnrollment holds what the user needs to add gowatch to an authenticator app
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+	// QRCode is a PNG data URL of the QR code of URI
+	QRCode string
+}
+
+// ProfilePageData is the data shown on the profile page of the signed-in user
+type ProfilePageData struct {
+	User User
+	// DeletionGrace is how long a deleted account is kept before it is removed
+	DeletionGrace time.Duration
+}
+
+type TwoFactorPageData struct {
+	Enabled           bool
+	RecoveryCodesLeft int64
+	// Enrollment is set while a secret waits for confirmation
+	Enrollment *TOTPEnrollment
+}
+
+// Passkey is a WebAuthn credential an user can sign in with
+type Passkey struct {
+	ID     int64
+	UserID int64
+	// CredentialID is the base64url encoded credential ID chosen by the authenticator
+	CredentialID string
+	// PublicKey is the COSE encoded public key
+	PublicKey []byte
+	SignCount int64
+	// BackupEligible is set for passkeys that can be synced between devices
+	BackupEligible bool
+	Name           string
+	CreatedAt      time.Time
+	LastUsedAt     *time.Time
 }
@@ -3,12 +3,20 @@ package models
 // HomeData contains all data needed for the home page
 type HomeData struct {
 	RecentMovies             []WatchedMovieInDay
-	WatchNextMovies          []MovieItem
-	WatchlistMovieCount      int
+	WatchNext                WatchNext
 	DailyWatchCountsLastYear []DailyWatchCount
 	Stats                    HomeStatsSummary
 }
 
+// WatchNext is the panel of the home page suggesting what to watch from the watchlist
+type WatchNext struct {
+	Movies []MovieItem
+	Filter WatchNextFilter
+	// Tags are the tags of the movies of the watchlist, to filter by
+	Tags                []string
+	WatchlistMovieCount int
+}
+
 // HomeStatsSummary contains key stats for home page overview
 type HomeStatsSummary struct {
 	TotalWatched int64
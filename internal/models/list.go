@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // List is a list of movies
 type List struct {
@@ -9,25 +12,211 @@ type List struct {
 	CreationDate time.Time
 	Description  *string
 	IsWatchlist  bool
+	// Ranked lists are ordered by the Position of their movies
+	Ranked bool
+	// ShareToken lets anyone view the list read-only, nil while it is not shared
+	ShareToken *string
+	// ShareNotes shows the notes of the movies on the shared list
+	ShareNotes bool
+	// OwnerName is the name of the user who created the list
+	OwnerName string
+	// Role is what the user who fetched the list can do with it
+	Role ListRole
+	// MemberCount is how many other users the list is shared with
+	MemberCount int64
+	// Rules are set for smart lists, whose movies are computed from them
+	Rules *ListRules
+	// Source is the TMDB list or collection the list was imported from, nil otherwise
+	Source *ListSource
 
 	Movies []MovieItem
 }
 
+// Collaborative reports whether the list is shared between several users
+func (l List) Collaborative() bool {
+	return l.MemberCount > 0 || l.Role != ListRoleOwner
+}
+
+// Smart reports whether the movies of the list come from its rules
+func (l List) Smart() bool {
+	return l.Rules != nil
+}
+
+// Synced reports whether the movies of the list are kept in sync with its TMDB source
+func (l List) Synced() bool {
+	return l.Source != nil && l.Source.Sync
+}
+
+// ListSourceKind is the kind of TMDB page a list was imported from
+type ListSourceKind string
+
+const (
+	// ListSourceTMDBList is a public list made by a TMDB user
+	ListSourceTMDBList ListSourceKind = "list"
+	// ListSourceTMDBCollection is a TMDB collection, like the movies of a franchise
+	ListSourceTMDBCollection ListSourceKind = "collection"
+)
+
+// ListSource is the TMDB list or collection a list was imported from
+type ListSource struct {
+	Kind   ListSourceKind
+	TMDBID int64
+	// Sync keeps the movies of the list the ones of the source, they cannot be changed by
+	// hand meanwhile
+	Sync     bool
+	SyncedAt time.Time
+}
+
+// URL returns the page of the source on TMDB
+func (s ListSource) URL() string {
+	return fmt.Sprintf("https://www.themoviedb.org/%s/%d", s.Kind, s.TMDBID)
+}
+
+// TMDBList is a TMDB list or collection as fetched for an import
+type TMDBList struct {
+	Kind        ListSourceKind
+	ID          int64
+	Name        string
+	Description string
+	// MovieIDs are in the order of the list, or of release for a collection
+	MovieIDs []int64
+}
+
+// TMDBCollection is a collection found by name on TMDB
+type TMDBCollection struct {
+	ID         int64
+	Name       string
+	PosterPath string
+}
+
+// ListRules are the saved filters of a smart list, matched against the library of its
+// owner. Rules left nil match every movie.
+type ListRules struct {
+	// Watched keeps watched movies when true, unwatched ones when false
+	Watched *bool
+	// InTheater, WatchedYear and MinRating must hold for the same watch of a movie
+	InTheater   *bool
+	WatchedYear *int64
+	MinRating   *float64
+	// MinRuntime and MaxRuntime are in minutes
+	MinRuntime      *int64
+	MaxRuntime      *int64
+	ReleaseYearFrom *int64
+	ReleaseYearTo   *int64
+	GenreID         *int64
+	// InWatchlist keeps movies on the watchlist when true, the others when false
+	InWatchlist *bool
+	// DirectorMinRating keeps movies by directors the owner rated this much on average
+	DirectorMinRating *float64
+	// PersonName keeps movies with this person in their cast or crew
+	PersonName *string
+}
+
+// ListRole is what a user can do with a list
+type ListRole string
+
+const (
+	// ListRoleOwner created the list, only they can change it and its members
+	ListRoleOwner ListRole = "owner"
+	// ListRoleEditor can add, remove and reorder the movies of the list
+	ListRoleEditor ListRole = "editor"
+	// ListRoleViewer can only see the list
+	ListRoleViewer ListRole = "viewer"
+)
+
+// Valid reports whether a list can be shared with a user as r
+func (r ListRole) Valid() bool {
+	switch r {
+	case ListRoleEditor, ListRoleViewer:
+		return true
+	}
+	return false
+}
+
+// CanEdit reports whether r allows changing the movies of a list
+func (r ListRole) CanEdit() bool {
+	return r == ListRoleOwner || r == ListRoleEditor
+}
+
+// ListMember is a user a list is shared with
+type ListMember struct {
+	UserID  int64
+	Name    string
+	Email   string
+	Role    ListRole
+	AddedAt time.Time
+}
+
 // MovieItem represents a Movie inside a list
 type MovieItem struct {
 	MovieDetails MovieDetails
 	DateAdded    time.Time
 	Position     *int64
 	Note         *string
+	// AddedBy is the name of the user who added the movie, nil when unknown
+	AddedBy *string
+	// Priority, Tags and AvailableOn are planned for the movies of the watchlist, see
+	// WatchlistItem
+	Priority    WatchlistPriority
+	Tags        []string
+	AvailableOn *string
+}
+
+// ListInfo is the name and description of a list, as edited through the API
+type ListInfo struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
 }
 
 type ListEntry struct {
 	ID   int64
 	Name string
+	// Role is what the user can do with the list, lists shared with them are not owned
+	Role ListRole
+	// OwnerName is set for lists shared with the user
+	OwnerName string
+	// Smart lists compute their movies from rules, they cannot take new ones
+	Smart bool
+	// Synced lists mirror a TMDB list or collection, they cannot take new ones either
+	Synced bool
+}
+
+// MovieList is a list containing a movie, with the note and date the movie has there.
+// Smart lists only match the movie, they have neither.
+type MovieList struct {
+	List        ListEntry
+	IsWatchlist bool
+	Note        *string
+	DateAdded   time.Time
 }
 
 type ListGridData struct {
+	// List holds the movies View keeps, in its order
 	List           List
 	UpcomingMovies []MovieItem
 	ReleasedMovies []MovieItem
+	View           ListView
+	// Total is how many movies the list has before filtering
+	Total int
+	// Genres and Decades are the ones of the movies of the list, to filter by
+	Genres  []Genre
+	Decades []int64
+	// Watches are how the user watched the movies of the list, by movie ID. Movies they
+	// did not watch are missing.
+	Watches map[int64]MovieWatchSummary
+}
+
+// Split reports whether the movies are shown split between upcoming and released ones,
+// which the watchlist does in its own order
+func (d ListGridData) Split() bool {
+	return d.List.IsWatchlist && d.View.Sort == ListSortPosition && !d.View.Desc
+}
+
+// Movies returns the movies in the order they are shown
+func (d ListGridData) Movies() []MovieItem {
+	if d.Split() {
+		return append(append([]MovieItem{}, d.UpcomingMovies...), d.ReleasedMovies...)
+	}
+	return d.List.Movies
 }
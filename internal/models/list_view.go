@@ -0,0 +1,140 @@
+package models
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// ListSort is the order the movies of a list are shown in
+type ListSort string
+
+const (
+	// ListSortPosition is the order of the list itself: the ranking of ranked lists, the
+	// order movies were added otherwise. The watchlist splits upcoming movies from
+	// released ones.
+	ListSortPosition    ListSort = "position"
+	ListSortDateAdded   ListSort = "added"
+	ListSortReleaseDate ListSort = "release"
+	ListSortTitle       ListSort = "title"
+	// ListSortRating is the TMDB vote average
+	ListSortRating  ListSort = "rating"
+	ListSortRuntime ListSort = "runtime"
+	// ListSortMyRating is the latest rating the user gave to the movie
+	ListSortMyRating ListSort = "my_rating"
+)
+
+// ListSorts are the sorts a list can be shown in, in the order they are offered
+var ListSorts = []ListSort{
+	ListSortPosition,
+	ListSortDateAdded,
+	ListSortReleaseDate,
+	ListSortTitle,
+	ListSortRating,
+	ListSortRuntime,
+	ListSortMyRating,
+}
+
+// Valid reports whether s is one of ListSorts
+func (s ListSort) Valid() bool {
+	for _, sort := range ListSorts {
+		if s == sort {
+			return true
+		}
+	}
+	return false
+}
+
+func (s ListSort) Label() string {
+	switch s {
+	case ListSortPosition:
+		return "List order"
+	case ListSortDateAdded:
+		return "Date added"
+	case ListSortReleaseDate:
+		return "Release date"
+	case ListSortTitle:
+		return "Title"
+	case ListSortRating:
+		return "TMDB rating"
+	case ListSortRuntime:
+		return "Runtime"
+	case ListSortMyRating:
+		return "My rating"
+	}
+	return string(s)
+}
+
+// ListLayout is how the movies of a list are laid out
+type ListLayout string
+
+const (
+	// ListLayoutGrid shows the posters of the movies
+	ListLayoutGrid ListLayout = "grid"
+	// ListLayoutTable shows a compact row per movie
+	ListLayoutTable ListLayout = "table"
+)
+
+// Valid reports whether l is a known layout
+func (l ListLayout) Valid() bool {
+	return l == ListLayoutGrid || l == ListLayoutTable
+}
+
+// ListView is how a user arranges the movies of a list, saved per user and list. Filters
+// left nil keep every movie.
+type ListView struct {
+	Sort ListSort
+	// Desc reverses Sort, movies missing what they are sorted by stay last
+	Desc bool
+	// GenreID keeps the movies of the genre
+	GenreID *int64
+	// Watched keeps the movies the user watched when true, the others when false
+	Watched *bool
+	// Decade keeps the movies released in the ten years from it, like 1990
+	Decade *int64
+	Layout ListLayout
+}
+
+// DefaultListView is the view of a list the user has not arranged
+func DefaultListView() ListView {
+	return ListView{Sort: ListSortPosition, Layout: ListLayoutGrid}
+}
+
+// Filtered reports whether the view leaves some movies out
+func (v ListView) Filtered() bool {
+	return v.GenreID != nil || v.Watched != nil || v.Decade != nil
+}
+
+// ListOrder reports whether the view shows every movie in the order of the list, the only
+// view ranked lists can be reordered in
+func (v ListView) ListOrder() bool {
+	return v.Sort == ListSortPosition && !v.Desc && !v.Filtered()
+}
+
+// Query returns the view as the query parameters of a list page, so it can be linked
+func (v ListView) Query() url.Values {
+	query := url.Values{}
+	query.Set("sort", string(v.Sort))
+	if v.Desc {
+		query.Set("order", "desc")
+	} else {
+		query.Set("order", "asc")
+	}
+	if v.GenreID != nil {
+		query.Set("genre", strconv.FormatInt(*v.GenreID, 10))
+	}
+	if v.Watched != nil {
+		query.Set("watched", strconv.FormatBool(*v.Watched))
+	}
+	if v.Decade != nil {
+		query.Set("decade", strconv.FormatInt(*v.Decade, 10))
+	}
+	query.Set("layout", string(v.Layout))
+	return query
+}
+
+// MovieWatchSummary is how a user watched a movie
+type MovieWatchSummary struct {
+	Count int64
+	// Rating is the rating of the latest rated watch, nil when the user never rated it
+	Rating *float64
+}
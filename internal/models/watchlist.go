@@ -0,0 +1,64 @@
+package models
+
+// WatchlistPriority is how much a user wants to watch a movie of their watchlist
+type WatchlistPriority string
+
+const (
+	WatchlistPriorityHigh   WatchlistPriority = "high"
+	WatchlistPriorityNormal WatchlistPriority = "normal"
+	WatchlistPriorityLow    WatchlistPriority = "low"
+)
+
+// WatchlistPriorities are the priorities from the highest
+var WatchlistPriorities = []WatchlistPriority{WatchlistPriorityHigh, WatchlistPriorityNormal, WatchlistPriorityLow}
+
+// Valid reports whether p is one of WatchlistPriorities
+func (p WatchlistPriority) Valid() bool {
+	return p == WatchlistPriorityHigh || p == WatchlistPriorityNormal || p == WatchlistPriorityLow
+}
+
+// Rank orders priorities from the highest, 0. An unset priority is normal.
+func (p WatchlistPriority) Rank() int {
+	switch p {
+	case WatchlistPriorityHigh:
+		return 0
+	case WatchlistPriorityLow:
+		return 2
+	}
+	return 1
+}
+
+func (p WatchlistPriority) Label() string {
+	switch p {
+	case WatchlistPriorityHigh:
+		return "High"
+	case WatchlistPriorityLow:
+		return "Low"
+	}
+	return "Normal"
+}
+
+// WatchlistItem is what a user plans for a movie of their watchlist
+type WatchlistItem struct {
+	Priority WatchlistPriority
+	// Tags are free-form, like "with kids" or "cinema only"
+	Tags []string
+	// AvailableOn notes where the movie can be watched, like a streaming service
+	AvailableOn *string
+}
+
+// WatchNextFilter picks the watchlist movies the watch next panel of the home page
+// suggests, they are ordered by priority then by their order in the watchlist
+type WatchNextFilter struct {
+	// MinPriority leaves out the movies of lower priority
+	MinPriority WatchlistPriority
+	// Tag keeps the movies with the tag
+	Tag *string
+	// ExcludeTag leaves out the movies with the tag
+	ExcludeTag *string
+}
+
+// DefaultWatchNextFilter suggests every movie of the watchlist
+func DefaultWatchNextFilter() WatchNextFilter {
+	return WatchNextFilter{MinPriority: WatchlistPriorityLow}
+}
@@ -0,0 +1,96 @@
+package models
+
+import "time"
+
+// WebhookEvent is the name of an event that can be delivered to a webhook
+type WebhookEvent string
+
+const (
+	WebhookEventWatchedAdded    WebhookEvent = "watched.added"
+	WebhookEventWatchedUpdated  WebhookEvent = "watched.updated"
+	WebhookEventWatchedDeleted  WebhookEvent = "watched.deleted"
+	WebhookEventListItemAdded   WebhookEvent = "list.item_added"
+	WebhookEventListItemRemoved WebhookEvent = "list.item_removed"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// AllWebhookEvents lists every event a webhook can subscribe to, in display order
+var AllWebhookEvents = []WebhookEvent{
+	WebhookEventWatchedAdded,
+	WebhookEventWatchedUpdated,
+	WebhookEventWatchedDeleted,
+	WebhookEventListItemAdded,
+	WebhookEventListItemRemoved,
+}
+
+// Webhook is an outgoing webhook configured by a user
+type Webhook struct {
+	ID        int64
+	URL       string
+	Secret    string
+	Events    []WebhookEvent
+	Enabled   bool
+	CreatedAt *time.Time
+}
+
+// Subscribes reports whether the webhook wants to receive the given event
+func (w Webhook) Subscribes(event WebhookEvent) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is a single queued or attempted webhook delivery
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int64
+	URL            string
+	Secret         string
+	Event          WebhookEvent
+	Payload        string
+	Status         string
+	Attempts       int64
+	NextAttemptAt  time.Time
+	LastAttemptAt  *time.Time
+	ResponseStatus *int64
+	LastError      *string
+	CreatedAt      time.Time
+}
+
+// WebhookPayload is the JSON body sent to webhook receivers
+type WebhookPayload struct {
+	Event     WebhookEvent `json:"event"`
+	Timestamp time.Time    `json:"timestamp"`
+	UserID    int64        `json:"user_id"`
+	Data      any          `json:"data"`
+}
+
+// WatchedEventData is the payload data for watched.* events
+type WatchedEventData struct {
+	WatchedID  int64      `json:"watched_id,omitempty"`
+	MovieID    int64      `json:"movie_id"`
+	Date       *time.Time `json:"date,omitempty"`
+	InTheaters *bool      `json:"in_theaters,omitempty"`
+	Rating     *float64   `json:"rating,omitempty"`
+}
+
+// ListItemEventData is the payload data for list.* events
+type ListItemEventData struct {
+	ListID  int64   `json:"list_id"`
+	MovieID int64   `json:"movie_id"`
+	Note    *string `json:"note,omitempty"`
+}
+
+type WebhooksPageData struct {
+	Webhooks   []Webhook
+	Deliveries []WebhookDelivery
+}
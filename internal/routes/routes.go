@@ -5,6 +5,8 @@
 package routes
 
 import (
+	"net/http"
+
 	"github.com/marcosalvi-01/gowatch/db"
 	"github.com/marcosalvi-01/gowatch/internal/handlers/api"
 	"github.com/marcosalvi-01/gowatch/internal/handlers/htmx"
@@ -27,6 +29,7 @@ func NewRouter(
 	watchedService *services.WatchedService,
 	listService *services.ListService,
 	authService *services.AuthService,
+	webhookService *services.WebhookService,
 ) chi.Router {
 	log.Info("creating HTTP router")
 
@@ -35,12 +38,14 @@ func NewRouter(
 	log.Debug("applying global middleware")
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(middleware.ClientIPMiddleware(authService))
 
 	homeService := services.NewHomeService(watchedService, listService)
 
 	log.Debug("registering API routes")
-	apiHandlers := api.NewHandlers(db, watchedService, listService)
+	apiHandlers := api.NewHandlers(db, watchedService, listService, authService.Audit)
 	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(http.NewCrossOriginProtection().Handler)
 		r.Use(middleware.AuthMiddleware(*authService))
 		r.Use(middleware.JSONMiddleware)
 		apiHandlers.RegisterRoutes(r)
@@ -50,6 +55,7 @@ func NewRouter(
 	pagesHandlers := pages.NewHandlers(tmdbService, watchedService, listService, homeService, authService)
 	r.Route("/", func(r chi.Router) {
 		r.Use(middleware.HTMLMiddleware)
+		r.Use(middleware.CSRFMiddleware(authService.HTTPS))
 		pagesHandlers.RegisterRoutes(r)
 	})
 
@@ -68,8 +74,9 @@ func NewRouter(
 	})
 
 	log.Debug("registering HTMX routes")
-	htmxHandlers := htmx.NewHandlers(watchedService, listService, homeService, authService)
+	htmxHandlers := htmx.NewHandlers(watchedService, listService, homeService, authService, webhookService)
 	r.Route("/htmx", func(r chi.Router) {
+		r.Use(middleware.CSRFMiddleware(authService.HTTPS))
 		r.Use(middleware.AuthMiddleware(*authService))
 		r.Use(middleware.HTMLMiddleware)
 		htmxHandlers.RegisterRoutes(r)
@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/services"
+)
+
+func newTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+
+	movieService := services.NewMovieService(testDB, nil, time.Hour)
+	imageService := services.NewTMDBImageService(t.TempDir(), time.Hour, http.DefaultClient)
+	webhookService := services.NewWebhookService(testDB, nil)
+	listService := services.NewListService(testDB, movieService, webhookService)
+	watchedService := services.NewWatchedService(testDB, listService, movieService, webhookService)
+	authService := services.NewAuthService(testDB, listService, time.Hour, false, "Welcome123!", models.RegistrationOpen, time.Hour)
+
+	return NewRouter(testDB, movieService, imageService, watchedService, listService, authService, webhookService)
+}
+
+func TestRouter_RejectsCrossOriginMutations(t *testing.T) {
+	router := newTestRouter(t)
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/login"},
+		{http.MethodPost, "/register"},
+		{http.MethodPost, "/logout"},
+		{http.MethodPost, "/change-password"},
+		{http.MethodPost, "/admin/users/1/reset-password"},
+		{http.MethodDelete, "/admin/users/1"},
+		{http.MethodPost, "/htmx/lists"},
+		{http.MethodPatch, "/htmx/movies/watched/1"},
+		{http.MethodDelete, "/htmx/lists/items"},
+		{http.MethodPost, "/api/v1/import"},
+	}
+
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			form := url.Values{"email": {"admin@example.com"}, "password": {"hunter22"}}
+			req := httptest.NewRequest(route.method, route.path, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("Origin", "https://evil.example")
+			req.Header.Set("Sec-Fetch-Site", "cross-site")
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("expected %d, got %d", http.StatusForbidden, rec.Code)
+			}
+		})
+	}
+}
+
+func TestRouter_LoginRequiresCSRFToken(t *testing.T) {
+	router := newTestRouter(t)
+
+	// the login page issues the token and renders it into hx-headers
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected login page, got %d", rec.Code)
+	}
+
+	var csrfCookie *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "csrf_token" {
+			csrfCookie = cookie
+		}
+	}
+	if csrfCookie == nil {
+		t.Fatal("expected CSRF cookie on login page")
+	}
+	if !strings.Contains(rec.Body.String(), csrfCookie.Value) {
+		t.Error("expected CSRF token to be rendered in the page")
+	}
+
+	newLoginRequest := func(token string) *http.Request {
+		form := url.Values{"email": {"nobody@example.com"}, "password": {"wrong"}}
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Sec-Fetch-Site", "same-origin")
+		req.AddCookie(csrfCookie)
+		if token != "" {
+			req.Header.Set(common.CSRFHeader, token)
+		}
+		return req
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newLoginRequest(""))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected login without token to be forbidden, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, newLoginRequest(csrfCookie.Value))
+	if rec.Code == http.StatusForbidden {
+		t.Errorf("expected login with token to reach the handler, got %d", rec.Code)
+	}
+}
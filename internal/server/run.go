@@ -3,14 +3,17 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/models"
 	"github.com/marcosalvi-01/gowatch/internal/routes"
 	"github.com/marcosalvi-01/gowatch/internal/services"
 	"github.com/marcosalvi-01/gowatch/logging"
@@ -30,9 +33,41 @@ type Config struct {
 	ImageCacheTTL        time.Duration `mapstructure:"image_cache_ttl" yaml:"tmdb_image_cache_ttl"`
 	ImageCleanupInterval time.Duration `mapstructure:"image_cleanup_interval" yaml:"image_cleanup_interval"`
 	SessionExpiry        time.Duration `mapstructure:"session_expiry" yaml:"session_expiry"`
+	SessionMaxLifetime   time.Duration `mapstructure:"session_max_lifetime" yaml:"session_max_lifetime"`
 	ShutdownTimeout      time.Duration `mapstructure:"shutdown_timeout" yaml:"shutdown_timeout"`
 	HTTPS                bool          `mapstructure:"https" yaml:"https"`
 	AdminDefaultPassword string        `mapstructure:"admin_default_password" yaml:"admin_default_password"`
+	WebhookInterval      time.Duration `mapstructure:"webhook_interval" yaml:"webhook_interval"`
+	ListSyncInterval     time.Duration `mapstructure:"list_sync_interval" yaml:"list_sync_interval"`
+	RegistrationMode     string        `mapstructure:"registration_mode" yaml:"registration_mode"`
+	InviteExpiry         time.Duration `mapstructure:"invite_expiry" yaml:"invite_expiry"`
+	OIDCIssuer           string        `mapstructure:"oidc_issuer" yaml:"oidc_issuer"`
+	OIDCClientID         string        `mapstructure:"oidc_client_id" yaml:"oidc_client_id"`
+	OIDCClientSecret     string        `mapstructure:"oidc_client_secret" yaml:"oidc_client_secret"`
+	OIDCRedirectURL      string        `mapstructure:"oidc_redirect_url" yaml:"oidc_redirect_url"`
+	OIDCScopes           []string      `mapstructure:"oidc_scopes" yaml:"oidc_scopes"`
+	OIDCProviderName     string        `mapstructure:"oidc_provider_name" yaml:"oidc_provider_name"`
+	OIDCAdminGroup       string        `mapstructure:"oidc_admin_group" yaml:"oidc_admin_group"`
+	OIDCGroupsClaim      string        `mapstructure:"oidc_groups_claim" yaml:"oidc_groups_claim"`
+	OIDCAutoProvision    bool          `mapstructure:"oidc_auto_provision" yaml:"oidc_auto_provision"`
+	DisablePasswordLogin bool          `mapstructure:"disable_password_login" yaml:"disable_password_login"`
+	ProxyAuthUserHeader  string        `mapstructure:"proxy_auth_user_header" yaml:"proxy_auth_user_header"`
+	ProxyAuthEmailHeader string        `mapstructure:"proxy_auth_email_header" yaml:"proxy_auth_email_header"`
+	ProxyAuthNameHeader  string        `mapstructure:"proxy_auth_name_header" yaml:"proxy_auth_name_header"`
+	TrustedProxies       []string      `mapstructure:"trusted_proxies" yaml:"trusted_proxies"`
+	WebhookAllowedNets   []string      `mapstructure:"webhook_allowed_networks" yaml:"webhook_allowed_networks"`
+	WebAuthnOrigin       string        `mapstructure:"webauthn_origin" yaml:"webauthn_origin"`
+	LockoutThreshold     int64         `mapstructure:"lockout_threshold" yaml:"lockout_threshold"`
+	LockoutDuration      time.Duration `mapstructure:"lockout_duration" yaml:"lockout_duration"`
+	AccountDeletionGrace time.Duration `mapstructure:"account_deletion_grace" yaml:"account_deletion_grace"`
+	PublicURL            string        `mapstructure:"public_url" yaml:"public_url"`
+	SMTPHost             string        `mapstructure:"smtp_host" yaml:"smtp_host"`
+	SMTPPort             int           `mapstructure:"smtp_port" yaml:"smtp_port"`
+	SMTPUsername         string        `mapstructure:"smtp_username" yaml:"smtp_username"`
+	SMTPPassword         string        `mapstructure:"smtp_password" yaml:"smtp_password"`
+	SMTPFrom             string        `mapstructure:"smtp_from" yaml:"smtp_from"`
+	SMTPTLS              string        `mapstructure:"smtp_tls" yaml:"smtp_tls"`
+	PasswordResetExpiry  time.Duration `mapstructure:"password_reset_expiry" yaml:"password_reset_expiry"`
 }
 
 // RunServer starts the HTTP server with the given configuration.
@@ -52,8 +87,70 @@ func RunServer(cfg Config) {
 		"cacheTTL", cfg.CacheTTL,
 		"imageCacheTTL", cfg.ImageCacheTTL,
 		"imageCleanupInterval", cfg.ImageCleanupInterval,
+		"listSyncInterval", cfg.ListSyncInterval,
+		"registrationMode", cfg.RegistrationMode,
+		"oidcIssuer", cfg.OIDCIssuer,
+		"disablePasswordLogin", cfg.DisablePasswordLogin,
+		"proxyAuthUserHeader", cfg.ProxyAuthUserHeader,
+		"trustedProxies", cfg.TrustedProxies,
+		"webhookAllowedNetworks", cfg.WebhookAllowedNets,
+		"lockoutThreshold", cfg.LockoutThreshold,
+		"lockoutDuration", cfg.LockoutDuration,
+		"accountDeletionGrace", cfg.AccountDeletionGrace,
+		"publicURL", cfg.PublicURL,
+		"smtpHost", cfg.SMTPHost,
+		"smtpPort", cfg.SMTPPort,
+		"smtpTLS", cfg.SMTPTLS,
 	)
 
+	registrationMode := models.RegistrationMode(cfg.RegistrationMode)
+	if !registrationMode.Valid() {
+		log.Error("invalid registration mode, expected open, closed or invite", "registrationMode", cfg.RegistrationMode)
+		panic(fmt.Sprintf("invalid registration mode %q", cfg.RegistrationMode))
+	}
+	if cfg.OIDCIssuer != "" && (cfg.OIDCClientID == "" || cfg.OIDCRedirectURL == "") {
+		log.Error("oidc_client_id and oidc_redirect_url are required when oidc_issuer is set")
+		panic("incomplete OIDC configuration")
+	}
+	trustedProxies, err := services.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Error("invalid trusted_proxies", "error", err)
+		panic(err)
+	}
+	webhookAllowedNetworks, err := services.ParseWebhookAllowedNetworks(cfg.WebhookAllowedNets)
+	if err != nil {
+		log.Error("invalid webhook_allowed_networks", "error", err)
+		panic(err)
+	}
+	if cfg.ProxyAuthUserHeader != "" && len(trustedProxies) == 0 {
+		log.Error("proxy_auth_user_header requires trusted_proxies, otherwise anyone could set the header")
+		panic("proxy auth enabled without trusted proxies")
+	}
+	// links in emails cannot be derived from the request, the Host header is chosen by the client
+	if cfg.SMTPHost != "" && cfg.PublicURL == "" {
+		log.Error("public_url is required when smtp_host is set, emailed links point to it")
+		panic("SMTP configured without a public URL")
+	}
+	var mailService *services.MailService
+	if cfg.SMTPHost != "" {
+		mailService, err = services.NewMailService(services.MailConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			TLS:      services.MailTLS(cfg.SMTPTLS),
+		})
+		if err != nil {
+			log.Error("invalid SMTP configuration", "error", err)
+			panic(err)
+		}
+	}
+	if cfg.DisablePasswordLogin && cfg.OIDCIssuer == "" && cfg.ProxyAuthUserHeader == "" {
+		log.Error("disable_password_login requires OIDC or proxy auth to be configured, nobody could sign in")
+		panic("password login disabled without another login method")
+	}
+
 	db, err := db.NewSqliteDB(cfg.DBPath, cfg.DBName)
 	if err != nil {
 		log.Error("failed to initialize database", "error", err)
@@ -77,11 +174,53 @@ func RunServer(cfg Config) {
 		panic(err)
 	}
 	tmdbImageService := services.NewTMDBImageService(imageCacheDir, cfg.ImageCacheTTL, &http.Client{Timeout: cfg.Timeout})
-	listService := services.NewListService(db, movieService)
-	watchedService := services.NewWatchedService(db, listService, movieService)
-	authService := services.NewAuthService(db, listService, cfg.SessionExpiry, cfg.HTTPS, cfg.AdminDefaultPassword)
+	webhookService := services.NewWebhookService(db, &http.Client{Timeout: cfg.Timeout})
+	webhookService.AllowedNetworks = webhookAllowedNetworks
+	listService := services.NewListService(db, movieService, webhookService)
+	watchedService := services.NewWatchedService(db, listService, movieService, webhookService)
+	authService := services.NewAuthService(db, listService, cfg.SessionExpiry, cfg.HTTPS, cfg.AdminDefaultPassword, registrationMode, cfg.InviteExpiry)
+	authService.Audit = services.NewAuditService(db)
+	authService.PasswordLoginDisabled = cfg.DisablePasswordLogin
+	authService.WebAuthnOrigin = strings.TrimSuffix(cfg.WebAuthnOrigin, "/")
+	authService.TrustedProxies = trustedProxies
+	if cfg.SessionMaxLifetime > 0 {
+		authService.SessionMaxLifetime = cfg.SessionMaxLifetime
+	}
+	authService.LockoutThreshold = cfg.LockoutThreshold
+	if cfg.LockoutDuration > 0 {
+		authService.LockoutDuration = cfg.LockoutDuration
+	}
+	if cfg.AccountDeletionGrace > 0 {
+		authService.AccountDeletionGrace = cfg.AccountDeletionGrace
+	}
+	authService.Mail = mailService
+	authService.PublicURL = strings.TrimSuffix(cfg.PublicURL, "/")
+	if cfg.PasswordResetExpiry > 0 {
+		authService.PasswordResetExpiry = cfg.PasswordResetExpiry
+	}
+	if cfg.OIDCIssuer != "" {
+		authService.OIDC = services.NewOIDCProvider(services.OIDCConfig{
+			Issuer:        cfg.OIDCIssuer,
+			ClientID:      cfg.OIDCClientID,
+			ClientSecret:  cfg.OIDCClientSecret,
+			RedirectURL:   cfg.OIDCRedirectURL,
+			Scopes:        cfg.OIDCScopes,
+			ProviderName:  cfg.OIDCProviderName,
+			AdminGroup:    cfg.OIDCAdminGroup,
+			GroupsClaim:   cfg.OIDCGroupsClaim,
+			AutoProvision: cfg.OIDCAutoProvision,
+		}, &http.Client{Timeout: cfg.Timeout})
+	}
+	if cfg.ProxyAuthUserHeader != "" {
+		authService.ProxyAuth = &services.ProxyAuthConfig{
+			UserHeader:     cfg.ProxyAuthUserHeader,
+			EmailHeader:    cfg.ProxyAuthEmailHeader,
+			NameHeader:     cfg.ProxyAuthNameHeader,
+			TrustedProxies: trustedProxies,
+		}
+	}
 
-	router := routes.NewRouter(db, movieService, tmdbImageService, watchedService, listService, authService)
+	router := routes.NewRouter(db, movieService, tmdbImageService, watchedService, listService, authService, webhookService)
 
 	server := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -92,6 +231,8 @@ func RunServer(cfg Config) {
 
 	sessionCleanupTicker := time.NewTicker(cfg.SessionExpiry)
 	imageCacheCleanupTicker := time.NewTicker(cfg.ImageCleanupInterval)
+	webhookTicker := time.NewTicker(cfg.WebhookInterval)
+	listSyncTicker := time.NewTicker(cfg.ListSyncInterval)
 	done := make(chan struct{})
 
 	// clean expired sessions
@@ -108,6 +249,13 @@ func RunServer(cfg Config) {
 				} else {
 					log.Debug("successfully cleaned up expired sessions")
 				}
+
+				deleted, err := authService.DeleteScheduledAccounts(context.Background())
+				if err != nil {
+					log.Error("failed to delete scheduled accounts", "error", err)
+				} else if deleted > 0 {
+					log.Info("deleted accounts past their deletion grace period", "count", deleted)
+				}
 			}
 		}
 	}()
@@ -126,6 +274,40 @@ func RunServer(cfg Config) {
 		}
 	}()
 
+	// deliver queued webhooks
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-webhookTicker.C:
+				sent, err := webhookService.ProcessPendingDeliveries(context.Background())
+				if err != nil {
+					log.Error("failed to process webhook deliveries", "error", err)
+				} else if sent > 0 {
+					log.Debug("processed webhook deliveries", "count", sent)
+				}
+			}
+		}
+	}()
+
+	// keep lists imported from TMDB in sync with their source
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-listSyncTicker.C:
+				synced, err := listService.SyncTMDBLists(context.Background())
+				if err != nil {
+					log.Error("failed to sync lists with TMDB", "error", err)
+				} else if synced > 0 {
+					log.Debug("synced lists with TMDB", "count", synced)
+				}
+			}
+		}
+	}()
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
@@ -142,6 +324,8 @@ func RunServer(cfg Config) {
 	close(done)
 	sessionCleanupTicker.Stop()
 	imageCacheCleanupTicker.Stop()
+	webhookTicker.Stop()
+	listSyncTicker.Stop()
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
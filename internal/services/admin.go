@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var ErrAccountDisabled = errors.New("account is disabled")
+
+// AdminCreateUser creates an account with a random temporary password, which the user has
+// to change at their first sign-in. It returns the user and the temporary password.
+func (a *AuthService) AdminCreateUser(ctx context.Context, name, email string, admin bool) (*models.User, string, error) {
+	name, email, err := normalizeProfile(name, email)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := a.db.GetUserByEmail(ctx, email); err == nil {
+		return nil, "", ErrEmailTaken
+	}
+
+	password, err := generateTemporaryPassword()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate temporary password: %w", err)
+	}
+	userID, err := a.CreateUser(ctx, email, name, password)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := a.db.UpdatePasswordResetRequired(ctx, userID, true); err != nil {
+		return nil, "", fmt.Errorf("failed to require a password change for user %d: %w", userID, err)
+	}
+
+	user, err := a.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	detail := ""
+	if admin {
+		detail = "as admin"
+	}
+	a.Audit.Record(ctx, models.AuditUserCreated, user, detail)
+
+	if admin {
+		if err := a.SetUserAsAdmin(ctx, userID); err != nil {
+			return nil, "", err
+		}
+		user.Admin = true
+	}
+	return user, password, nil
+}
+
+// RevokeAdmin removes admin rights from an user, unless they are the last admin
+func (a *AuthService) RevokeAdmin(ctx context.Context, userID int64) error {
+	user, err := a.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.Admin {
+		return nil
+	}
+	if err := a.ensureAnotherAdmin(ctx, user); err != nil {
+		return err
+	}
+
+	if err := a.db.UnsetAdmin(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke admin from user %d: %w", userID, err)
+	}
+	a.Audit.Record(ctx, models.AuditUserRoleChanged, user, roleChangeDetail(false))
+	return nil
+}
+
+// DisableUser blocks an user from signing in and ends their sessions, keeping their
+// account and history. The last admin cannot be disabled.
+func (a *AuthService) DisableUser(ctx context.Context, userID int64) error {
+	user, err := a.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Disabled() {
+		return nil
+	}
+	if err := a.ensureAnotherAdmin(ctx, user); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if err := a.db.SetUserDisabledAt(ctx, userID, &now); err != nil {
+		return fmt.Errorf("failed to disable user %d: %w", userID, err)
+	}
+	a.Audit.Record(ctx, models.AuditUserDisabled, user, "")
+
+	if _, err := a.RevokeUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("failed to sign out disabled user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// EnableUser lets a disabled user sign in again
+func (a *AuthService) EnableUser(ctx context.Context, userID int64) error {
+	user, err := a.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.Disabled() {
+		return nil
+	}
+
+	if err := a.db.SetUserDisabledAt(ctx, userID, nil); err != nil {
+		return fmt.Errorf("failed to enable user %d: %w", userID, err)
+	}
+	a.Audit.Record(ctx, models.AuditUserEnabled, user, "")
+	return nil
+}
+
+// ensureAnotherAdmin returns ErrLastAdmin when user is the only admin able to sign in
+func (a *AuthService) ensureAnotherAdmin(ctx context.Context, user *models.User) error {
+	if !user.Admin || user.Disabled() {
+		return nil
+	}
+
+	admins, err := a.db.CountAdmins(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count admins: %w", err)
+	}
+	if admins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// checkEnabled returns ErrAccountDisabled for disabled users, recording the refused sign-in
+func (a *AuthService) checkEnabled(ctx context.Context, user *models.User) error {
+	if !user.Disabled() {
+		return nil
+	}
+	a.Audit.RecordAs(ctx, models.AuditLoginFailed, nil, user, "account disabled")
+	return ErrAccountDisabled
+}
+
+func generateTemporaryPassword() (string, error) {
+	bytes := make([]byte, 12)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+func TestAuthService_AdminCreateUser(t *testing.T) {
+	_, authService := newAuthTestService(t, models.RegistrationClosed)
+	registerTestUser(t, authService, "admin@example.com", "Password123!")
+
+	if _, _, err := authService.AdminCreateUser(context.Background(), "Taken", "admin@example.com", false); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("expected %v, got %v", ErrEmailTaken, err)
+	}
+	if _, _, err := authService.AdminCreateUser(context.Background(), "New", "not an email", false); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("expected %v, got %v", ErrInvalidEmail, err)
+	}
+
+	user, password, err := authService.AdminCreateUser(context.Background(), "New User", "new@example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Admin {
+		t.Error("expected the user to be an admin")
+	}
+
+	signedIn, err := authService.AuthenticateUser(context.Background(), "new@example.com", password, "192.0.2.1")
+	if err != nil {
+		t.Fatalf("expected the temporary password to work, got %v", err)
+	}
+	if !signedIn.PasswordResetRequired {
+		t.Error("expected the user to change the temporary password")
+	}
+}
+
+func TestAuthService_RevokeAdmin(t *testing.T) {
+	_, authService := newAuthTestService(t, models.RegistrationOpen)
+	admin := mustUser(t, registerTestUser(t, authService, "admin@example.com", "Password123!"))
+	other := mustUser(t, registerTestUser(t, authService, "other@example.com", "Password123!"))
+	for _, id := range []int64{admin.ID, other.ID} {
+		if err := authService.SetUserAsAdmin(context.Background(), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := authService.RevokeAdmin(context.Background(), other.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := authService.RevokeAdmin(context.Background(), admin.ID); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected %v, got %v", ErrLastAdmin, err)
+	}
+
+	stored, err := authService.GetUserByID(context.Background(), other.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Admin {
+		t.Error("expected admin rights to be revoked")
+	}
+}
+
+func TestAuthService_DisableUser(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	admin := mustUser(t, registerTestUser(t, authService, "admin@example.com", "Password123!"))
+	if err := authService.SetUserAsAdmin(context.Background(), admin.ID); err != nil {
+		t.Fatal(err)
+	}
+	user := mustUser(t, registerTestUser(t, authService, "user@example.com", "Password123!"))
+
+	if err := authService.DisableUser(context.Background(), admin.ID); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected %v, got %v", ErrLastAdmin, err)
+	}
+
+	sessionID, err := authService.CreateSession(context.Background(), user.ID, models.SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := authService.DisableUser(context.Background(), user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.GetSession(context.Background(), sessionID); err == nil {
+		t.Error("expected the sessions of the disabled user to be revoked")
+	}
+	if _, err := authService.AuthenticateUser(context.Background(), "user@example.com", "Password123!", "192.0.2.1"); !errors.Is(err, ErrAccountDisabled) {
+		t.Errorf("expected %v, got %v", ErrAccountDisabled, err)
+	}
+
+	// the history of a disabled user is kept
+	if _, err := testDB.GetUserByID(context.Background(), user.ID); err != nil {
+		t.Errorf("expected the disabled user to be kept, got %v", err)
+	}
+
+	if err := authService.EnableUser(context.Background(), user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.AuthenticateUser(context.Background(), "user@example.com", "Password123!", "192.0.2.1"); err != nil {
+		t.Errorf("expected the enabled user to sign in, got %v", err)
+	}
+}
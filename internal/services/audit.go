@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/logging"
+)
+
+const (
+	// auditLogPageLimit is how many entries the admin page shows at most
+	auditLogPageLimit = 200
+	// auditLogExportLimit caps a JSON export, narrow the filter to get older entries
+	auditLogExportLimit  = 100000
+	maxAuditDetailLength = 500
+)
+
+var ErrUnknownAuditAction = errors.New("unknown audit action")
+
+// auditLogEnd stands in for a missing upper bound, past any entry
+var auditLogEnd = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// AuditService records security and admin events to the append-only audit log.
+// A nil *AuditService is valid and records nothing.
+type AuditService struct {
+	db  db.DB
+	log *slog.Logger
+}
+
+func NewAuditService(db db.DB) *AuditService {
+	log := logging.Get("audit service")
+	log.Debug("creating new AuditService instance")
+	return &AuditService{
+		db:  db,
+		log: log,
+	}
+}
+
+// Record appends an event about target to the audit log, acted by the user in ctx, or by
+// the admin impersonating them. Failures are logged and never stop the audited action.
+func (s *AuditService) Record(ctx context.Context, action models.AuditAction, target *models.User, detail string) {
+	actor, _ := common.GetUser(ctx)
+	if impersonator := common.GetImpersonator(ctx); impersonator != nil {
+		actor = impersonator
+	}
+	s.RecordAs(ctx, action, actor, target, detail)
+}
+
+// RecordAs is Record with an explicit actor, for events that happen before anyone is
+// signed in. Either user may be nil. The IP is the client of the request in ctx.
+func (s *AuditService) RecordAs(ctx context.Context, action models.AuditAction, actor, target *models.User, detail string) {
+	if s == nil {
+		return
+	}
+
+	if len(detail) > maxAuditDetailLength {
+		detail = detail[:maxAuditDetailLength]
+	}
+	entry := models.AuditEntry{
+		Action:    action,
+		IP:        common.GetClientIP(ctx),
+		Detail:    detail,
+		CreatedAt: time.Now().UTC(),
+	}
+	if actor != nil {
+		entry.ActorID = &actor.ID
+		entry.ActorEmail = actor.Email
+	}
+	if target != nil {
+		entry.TargetID = &target.ID
+		entry.TargetEmail = target.Email
+	}
+
+	// the audited action already happened, a canceled request must not lose its entry
+	if err := s.db.InsertAuditEntry(context.WithoutCancel(ctx), entry); err != nil {
+		s.log.Error("failed to record audit entry", "action", action, "error", err)
+	}
+}
+
+// List returns the newest entries matching filter, at most as many as fit on a page
+func (s *AuditService) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	filter, err := normalizeAuditFilter(filter, auditLogPageLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.db.ListAuditEntries(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// Export returns every entry matching filter, up to auditLogExportLimit, and records the export
+func (s *AuditService) Export(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	filter, err := normalizeAuditFilter(filter, auditLogExportLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.db.ListAuditEntries(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export audit entries: %w", err)
+	}
+
+	s.Record(ctx, models.AuditAuditLogExported, nil, fmt.Sprintf("%d entries", len(entries)))
+	return entries, nil
+}
+
+// normalizeAuditFilter validates filter and fills in the bounds that were left empty
+func normalizeAuditFilter(filter models.AuditFilter, limit int64) (models.AuditFilter, error) {
+	if filter.Action != "" && !filter.Action.Valid() {
+		return filter, ErrUnknownAuditAction
+	}
+	if filter.Until.IsZero() {
+		filter.Until = auditLogEnd
+	}
+	filter.Since = filter.Since.UTC()
+	filter.Until = filter.Until.UTC()
+	if filter.Limit <= 0 || filter.Limit > limit {
+		filter.Limit = limit
+	}
+	return filter, nil
+}
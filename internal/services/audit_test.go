@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+func TestAuditService_NilRecordsNothing(t *testing.T) {
+	var audit *AuditService
+	audit.Record(context.Background(), models.AuditLoginFailed, nil, "ignored")
+	audit.RecordAs(context.Background(), models.AuditLoginFailed, nil, nil, "ignored")
+}
+
+func TestAuditService_RecordAndFilter(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	audit := NewAuditService(testDB)
+	ctx := setupTestUser(t, testDB)
+	admin := mustUser(t, ctx)
+	ctx = context.WithValue(ctx, common.ClientIPKey, "192.0.2.7")
+
+	targetID, err := authService.RegisterUser(context.Background(), "target@example.com", "Target", "Password123!", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := &models.User{ID: targetID, Email: "target@example.com"}
+
+	audit.Record(ctx, models.AuditPasswordReset, target, "")
+	audit.RecordAs(context.Background(), models.AuditLoginFailed, nil, nil, "unknown email nobody@example.com")
+	audit.Record(ctx, models.AuditDataExported, nil, "3 watched days, 1 lists")
+
+	entries, err := audit.List(context.Background(), models.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Action != models.AuditDataExported {
+		t.Errorf("expected newest entry first, got %s", entries[0].Action)
+	}
+
+	reset := entries[2]
+	if reset.ActorID == nil || *reset.ActorID != admin.ID || reset.ActorEmail != admin.Email {
+		t.Errorf("expected actor %d %s, got %v %s", admin.ID, admin.Email, reset.ActorID, reset.ActorEmail)
+	}
+	if reset.TargetID == nil || *reset.TargetID != targetID || reset.TargetEmail != "target@example.com" {
+		t.Errorf("expected target %d, got %v %s", targetID, reset.TargetID, reset.TargetEmail)
+	}
+	if reset.IP != "192.0.2.7" {
+		t.Errorf("expected IP from context, got %q", reset.IP)
+	}
+
+	cases := []struct {
+		name   string
+		filter models.AuditFilter
+		want   int
+	}{
+		{"action", models.AuditFilter{Action: models.AuditLoginFailed}, 1},
+		{"actor or target", models.AuditFilter{UserID: targetID}, 1},
+		{"search", models.AuditFilter{Search: "nobody@"}, 1},
+		{"since", models.AuditFilter{Since: time.Now().Add(time.Hour)}, 0},
+		{"until", models.AuditFilter{Until: time.Now().Add(-time.Hour)}, 0},
+		{"limit", models.AuditFilter{Limit: 2}, 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := audit.List(context.Background(), tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != tc.want {
+				t.Errorf("expected %d entries, got %d", tc.want, len(entries))
+			}
+		})
+	}
+
+	if _, err := audit.List(context.Background(), models.AuditFilter{Action: "bogus"}); !errors.Is(err, ErrUnknownAuditAction) {
+		t.Errorf("expected %v, got %v", ErrUnknownAuditAction, err)
+	}
+}
+
+func TestAuditService_ExportIsRecorded(t *testing.T) {
+	testDB, _ := newAuthTestService(t, models.RegistrationOpen)
+	audit := NewAuditService(testDB)
+	ctx := setupTestUser(t, testDB)
+
+	audit.Record(ctx, models.AuditDataImported, nil, "")
+
+	exported, err := audit.Export(ctx, models.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 1 {
+		t.Fatalf("expected 1 exported entry, got %d", len(exported))
+	}
+
+	entries, err := audit.List(context.Background(), models.AuditFilter{Action: models.AuditAuditLogExported})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Detail != "1 entries" {
+		t.Errorf("expected the export to be recorded, got %+v", entries)
+	}
+}
+
+func TestAuthService_AuditsAuthenticationAndPasswords(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	authService.Audit = NewAuditService(testDB)
+	ctx := setupTestUser(t, testDB)
+
+	userID, err := authService.RegisterUser(context.Background(), "user@example.com", "User", "Password123!", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := authService.AuthenticateUser(context.Background(), "user@example.com", "wrong", "192.0.2.1"); err == nil {
+		t.Fatal("expected wrong password to fail")
+	}
+	if _, err := authService.AuthenticateUser(context.Background(), "nobody@example.com", "wrong", "192.0.2.1"); err == nil {
+		t.Fatal("expected unknown email to fail")
+	}
+	if err := authService.UpdateUserPassword(ctx, userID, "Password456!"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.RequirePasswordReset(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[models.AuditAction]int{}
+	entries, err := authService.Audit.List(context.Background(), models.AuditFilter{UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		counts[entry.Action]++
+	}
+
+	if counts[models.AuditLoginFailed] != 1 {
+		t.Errorf("expected 1 failed login for the user, got %d", counts[models.AuditLoginFailed])
+	}
+	if counts[models.AuditPasswordChanged] != 1 || counts[models.AuditPasswordReset] != 1 {
+		t.Errorf("expected one change and one reset, got %v", counts)
+	}
+
+	unknown, err := authService.Audit.List(context.Background(), models.AuditFilter{Search: "unknown email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unknown) != 1 {
+		t.Errorf("expected the unknown email to be recorded, got %d entries", len(unknown))
+	}
+}
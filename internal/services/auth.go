@@ -3,9 +3,13 @@ package services
 import (
 	"context"
 	"crypto/rand"
+	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/netip"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,6 +21,19 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrRegistrationClosed = errors.New("registration is closed")
+	ErrInviteRequired     = errors.New("an invite is required to register")
+	ErrInvalidInvite      = errors.New("invite is invalid, expired or already used")
+	ErrInviteNotFound     = errors.New("invite not found")
+
+	ErrPasswordLoginDisabled = errors.New("password login is disabled")
+	ErrOIDCNotConfigured     = errors.New("single sign-on is not configured")
+	ErrOIDCUserNotFound      = errors.New("no account is linked to this identity")
+	ErrOIDCEmailMissing      = errors.New("identity provider did not return an email address")
+	ErrOIDCEmailNotVerified  = errors.New("identity provider does not report the email address as verified")
+)
+
 type AuthService struct {
 	db                   db.DB
 	listService          *ListService
@@ -24,9 +41,46 @@ type AuthService struct {
 	SessionExpiry        time.Duration
 	HTTPS                bool
 	DefaultAdminPassword string
+	RegistrationMode     models.RegistrationMode
+	InviteExpiry         time.Duration
+	// SessionMaxLifetime caps how far activity can extend a session past SessionExpiry
+	SessionMaxLifetime time.Duration
+	// Audit receives security and admin events, nil records nothing
+	Audit *AuditService
+	// OIDC is nil when single sign-on is not configured
+	OIDC *OIDCProvider
+	// ProxyAuth is nil when trusted reverse-proxy header auth is not configured
+	ProxyAuth *ProxyAuthConfig
+	// PasswordLoginDisabled turns off password login and registration, leaving only SSO
+	PasswordLoginDisabled bool
+	// WebAuthnOrigin overrides the origin passkeys are bound to, by default it is derived from the request
+	WebAuthnOrigin string
+	// TrustedProxies may set X-Forwarded-For, the client IP is read from it
+	TrustedProxies []netip.Prefix
+	// LockoutThreshold is the number of wrong passwords after which an account is locked, 0 disables lockout
+	LockoutThreshold int64
+	// LockoutDuration is the first lockout, each further wrong password doubles it
+	LockoutDuration time.Duration
+	// AccountDeletionGrace is how long after a user deletes their account it is actually removed
+	AccountDeletionGrace time.Duration
+	// Mail sends password reset links, nil when SMTP is not configured
+	Mail *MailService
+	// PublicURL is the address gowatch is reached on, links in emails and list share links point to it
+	PublicURL string
+	// PasswordResetExpiry is how long an emailed password reset link works
+	PasswordResetExpiry time.Duration
+	clients             *clientLimiter
 }
 
-func NewAuthService(db db.DB, listService *ListService, sessionExpiry time.Duration, https bool, defaultAdminPassword string) *AuthService {
+func NewAuthService(
+	db db.DB,
+	listService *ListService,
+	sessionExpiry time.Duration,
+	https bool,
+	defaultAdminPassword string,
+	registrationMode models.RegistrationMode,
+	inviteExpiry time.Duration,
+) *AuthService {
 	log := logging.Get("auth service")
 	log.Debug("creating new AuthService instance")
 	return &AuthService{
@@ -34,51 +88,99 @@ func NewAuthService(db db.DB, listService *ListService, sessionExpiry time.Durat
 		listService:          listService,
 		log:                  log,
 		SessionExpiry:        sessionExpiry,
+		SessionMaxLifetime:   DefaultSessionMaxLifetime,
 		HTTPS:                https,
 		DefaultAdminPassword: defaultAdminPassword,
+		RegistrationMode:     registrationMode,
+		InviteExpiry:         inviteExpiry,
+		LockoutThreshold:     DefaultLockoutThreshold,
+		LockoutDuration:      DefaultLockoutDuration,
+		AccountDeletionGrace: DefaultAccountDeletionGrace,
+		PasswordResetExpiry:  DefaultPasswordResetExpiry,
+		clients:              newClientLimiter(),
 	}
 }
 
-func (a *AuthService) AuthenticateUser(ctx context.Context, email, password string) (*models.User, error) {
+// AuthenticateUser checks the password of an user signing in from clientIP. Failures slow
+// down clientIP and, past LockoutThreshold, lock the account; both return a *ThrottleError.
+func (a *AuthService) AuthenticateUser(ctx context.Context, email, password, clientIP string) (*models.User, error) {
+	if a.PasswordLoginDisabled {
+		return nil, ErrPasswordLoginDisabled
+	}
+	if err := a.CheckClientThrottle(clientIP); err != nil {
+		return nil, err
+	}
+
 	user, err := a.db.GetUserByEmail(ctx, email)
 	if err != nil {
+		a.RecordClientFailure(clientIP)
+		a.Audit.RecordAs(ctx, models.AuditLoginFailed, nil, nil, "unknown email "+email)
 		return nil, fmt.Errorf("failed to retrieve user for email %s: %w", email, err)
 	}
+	if err := a.checkAccountLock(ctx, user.ID); err != nil {
+		a.Audit.RecordAs(ctx, models.AuditLoginFailed, nil, user, "account locked")
+		return nil, err
+	}
+
 	err = verifyPassword(user.PasswordHash, password)
 	if err != nil {
+		a.RecordClientFailure(clientIP)
+		a.Audit.RecordAs(ctx, models.AuditLoginFailed, nil, user, "wrong password")
+		if lockErr := a.recordAccountFailure(ctx, user.ID); lockErr != nil {
+			a.log.Error("failed to record failed login", "userID", user.ID, "error", lockErr)
+		}
 		return nil, fmt.Errorf("password verification failed for user %s: %w", email, err)
 	}
+	if err := a.checkEnabled(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// with two-factor enabled the login only succeeds once VerifyMFAChallenge accepts a
+	// code, until then the failures of the client and the account stand
+	totpEnabled, err := a.TOTPEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !totpEnabled {
+		a.loginSucceeded(ctx, user.ID, clientIP)
+	}
 	return user, nil
 }
 
-func (a *AuthService) CreateSession(ctx context.Context, userID int64) (string, error) {
-	sessionID, err := generateSessionID()
+// CreateSession starts a new session for the user, client is the device signing in.
+// It returns the token for the cookie, only its hash is stored.
+func (a *AuthService) CreateSession(ctx context.Context, userID int64, client models.SessionClient) (string, error) {
+	token, err := generateSessionID()
 	if err != nil {
 		return "", fmt.Errorf("failed to generate session ID: %w", err)
 	}
 
-	expiresAt := time.Now().Add(a.SessionExpiry)
-	err = a.db.CreateSession(ctx, sessionID, userID, expiresAt)
+	now := time.Now().UTC()
+	maxExpiresAt := now.Add(a.SessionMaxLifetime)
+	expiresAt := minTime(now.Add(a.SessionExpiry), maxExpiresAt)
+	err = a.db.CreateSession(ctx, hashSessionToken(token), userID, expiresAt, maxExpiresAt, client)
 	if err != nil {
 		return "", fmt.Errorf("failed to create session for user %d: %w", userID, err)
 	}
 
-	return sessionID, nil
+	return token, nil
 }
 
-func (a *AuthService) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
-	session, err := a.db.GetSession(ctx, sessionID)
+// GetSession returns the active session of the cookie token
+func (a *AuthService) GetSession(ctx context.Context, token string) (*models.Session, error) {
+	session, err := a.db.GetSession(ctx, hashSessionToken(token))
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve session %s: %w", sessionID, err)
+		return nil, fmt.Errorf("failed to retrieve session: %w", err)
 	}
 
 	return session, nil
 }
 
-func (a *AuthService) Logout(ctx context.Context, sessionID string) error {
-	err := a.db.DeleteSession(ctx, sessionID)
+// Logout ends the session of the cookie token
+func (a *AuthService) Logout(ctx context.Context, token string) error {
+	err := a.db.DeleteSession(ctx, hashSessionToken(token))
 	if err != nil {
-		return fmt.Errorf("failed to delete session %s: %w", sessionID, err)
+		return fmt.Errorf("failed to delete session: %w", err)
 	}
 	return nil
 }
@@ -96,6 +198,19 @@ func (a *AuthService) CleanupExpiredSessions(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to cleanup expired sessions: %w", err)
 	}
+	err = a.db.CleanupExpiredMFAChallenges(ctx, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to cleanup expired login challenges: %w", err)
+	}
+	a.clients.prune(time.Now())
+	err = a.db.CleanupExpiredWebAuthnChallenges(ctx, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to cleanup expired passkey challenges: %w", err)
+	}
+	err = a.db.CleanupExpiredPasswordResets(ctx, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to cleanup expired password reset links: %w", err)
+	}
 	return nil
 }
 
@@ -126,6 +241,307 @@ func (a *AuthService) CreateUser(ctx context.Context, email, name, password stri
 	return user.ID, nil
 }
 
+// CheckRegistration reports whether a visitor holding inviteToken (may be empty)
+// is allowed to register under the current registration mode.
+// The first account can always be created so a fresh instance can be bootstrapped.
+func (a *AuthService) CheckRegistration(ctx context.Context, inviteToken string) error {
+	if a.PasswordLoginDisabled {
+		return ErrPasswordLoginDisabled
+	}
+
+	count, err := a.db.CountUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count users: %w", err)
+	}
+	if count == 0 {
+		return nil
+	}
+
+	switch a.RegistrationMode {
+	case models.RegistrationClosed:
+		return ErrRegistrationClosed
+	case models.RegistrationInviteOnly:
+		if inviteToken == "" {
+			return ErrInviteRequired
+		}
+		invite, err := a.db.GetInviteByTokenHash(ctx, hashSessionToken(inviteToken))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidInvite
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get invite: %w", err)
+		}
+		if !invite.Usable(time.Now()) {
+			return ErrInvalidInvite
+		}
+	}
+	return nil
+}
+
+// RegisterUser creates a self-registered account, enforcing the registration mode.
+// In invite-only mode the invite is consumed, so it cannot be redeemed twice.
+func (a *AuthService) RegisterUser(ctx context.Context, email, name, password, inviteToken string) (int64, error) {
+	err := a.CheckRegistration(ctx, inviteToken)
+	if err != nil {
+		return 0, err
+	}
+
+	var inviteID int64
+	if a.RegistrationMode == models.RegistrationInviteOnly && inviteToken != "" {
+		inviteID, err = a.db.ClaimInvite(ctx, hashSessionToken(inviteToken), time.Now().UTC())
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidInvite
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to claim invite: %w", err)
+		}
+	}
+
+	userID, err := a.CreateUser(ctx, email, name, password)
+	if err != nil {
+		if inviteID != 0 {
+			if releaseErr := a.db.ReleaseInvite(ctx, inviteID); releaseErr != nil {
+				a.log.Error("failed to release invite after failed registration", "inviteID", inviteID, "error", releaseErr)
+			}
+		}
+		return 0, err
+	}
+
+	if inviteID != 0 {
+		if err := a.db.SetInviteUsedBy(ctx, inviteID, userID); err != nil {
+			// the invite is already consumed, only the audit trail is missing
+			a.log.Warn("failed to record invite redeemer", "inviteID", inviteID, "userID", userID, "error", err)
+		}
+		a.log.Info("user registered with invite", "userID", userID, "inviteID", inviteID)
+	}
+
+	return userID, nil
+}
+
+// CreateInvite generates a new single-use invite on behalf of the admin in ctx.
+// Only its hash is stored, the returned Token is the only chance to share the link.
+func (a *AuthService) CreateInvite(ctx context.Context) (*models.Invite, error) {
+	admin, err := common.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateSessionID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invite token: %w", err)
+	}
+
+	now := time.Now().UTC()
+	invite, err := a.db.CreateInvite(ctx, hashSessionToken(token), admin.ID, now, now.Add(a.InviteExpiry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+	invite.Token = token
+
+	a.log.Info("invite created", "inviteID", invite.ID, "createdBy", admin.ID)
+	return invite, nil
+}
+
+func (a *AuthService) GetInvites(ctx context.Context) ([]models.Invite, error) {
+	invites, err := a.db.GetInvites(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invites: %w", err)
+	}
+	return invites, nil
+}
+
+func (a *AuthService) RevokeInvite(ctx context.Context, inviteID int64) error {
+	rows, err := a.db.DeleteInvite(ctx, inviteID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invite %d: %w", inviteID, err)
+	}
+	if rows == 0 {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// LoginPageData reports which sign-in methods are available
+func (a *AuthService) LoginPageData() models.LoginPageData {
+	data := models.LoginPageData{
+		PasswordLogin: !a.PasswordLoginDisabled,
+		PasswordReset: a.PasswordResetByEmail(),
+	}
+	if a.OIDC != nil {
+		data.SSOName = a.OIDC.Config.ProviderName
+	}
+	return data
+}
+
+// BeginOIDCLogin generates the secrets of a new SSO login and returns the provider URL to redirect to
+func (a *AuthService) BeginOIDCLogin(ctx context.Context) (string, models.OIDCFlow, error) {
+	if a.OIDC == nil {
+		return "", models.OIDCFlow{}, ErrOIDCNotConfigured
+	}
+
+	var flow models.OIDCFlow
+	for _, secret := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		value, err := generateSessionID()
+		if err != nil {
+			return "", models.OIDCFlow{}, fmt.Errorf("failed to generate OIDC flow secret: %w", err)
+		}
+		*secret = value
+	}
+
+	authURL, err := a.OIDC.AuthCodeURL(ctx, flow)
+	if err != nil {
+		return "", models.OIDCFlow{}, err
+	}
+	return authURL, flow, nil
+}
+
+// CompleteOIDCLogin redeems the authorization code of an SSO callback and returns the local user.
+// Unknown identities are linked to the user with the same email, or provisioned when enabled.
+func (a *AuthService) CompleteOIDCLogin(ctx context.Context, code string, flow models.OIDCFlow) (*models.User, error) {
+	if a.OIDC == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	idToken, err := a.OIDC.Exchange(ctx, code, flow.Verifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := a.OIDC.VerifyIDToken(ctx, idToken, flow.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.db.GetUserByIdentity(ctx, claims.Issuer, claims.Subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = a.linkOIDCIdentity(ctx, claims)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkEnabled(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if group := a.OIDC.Config.AdminGroup; group != "" {
+		if err := a.syncOIDCAdmin(ctx, user, slices.Contains(claims.Groups, group), group); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// syncOIDCAdmin gives user the admin role when they are in the admin group of the provider
+// and takes it away when they left it. Like RevokeAdmin, the last admin keeps the role, a
+// changed group claim must not lock everyone out of administration.
+func (a *AuthService) syncOIDCAdmin(ctx context.Context, user *models.User, isAdmin bool, group string) error {
+	if isAdmin == user.Admin {
+		return nil
+	}
+
+	if isAdmin {
+		if err := a.db.SetAdmin(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to sync admin role for user %d: %w", user.ID, err)
+		}
+	} else {
+		err := a.ensureAnotherAdmin(ctx, user)
+		if errors.Is(err, ErrLastAdmin) {
+			a.log.Warn("kept admin role of the last admin, who left the OIDC admin group", "userID", user.ID, "group", group)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := a.db.UnsetAdmin(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to sync admin role for user %d: %w", user.ID, err)
+		}
+	}
+
+	a.log.Info("synced admin role from OIDC group", "userID", user.ID, "admin", isAdmin)
+	user.Admin = isAdmin
+	a.Audit.RecordAs(ctx, models.AuditUserRoleChanged, nil, user, roleChangeDetail(isAdmin)+" from OIDC group "+group)
+	return nil
+}
+
+func (a *AuthService) linkOIDCIdentity(ctx context.Context, claims *OIDCClaims) (*models.User, error) {
+	if claims.Email == "" {
+		return nil, ErrOIDCEmailMissing
+	}
+
+	user, err := a.db.GetUserByEmail(ctx, claims.Email)
+	if err == nil {
+		// never hand an existing account to an identity whose email is not proven, a
+		// provider leaving the claim out proves nothing
+		if claims.EmailVerified == nil || !*claims.EmailVerified {
+			return nil, ErrOIDCEmailNotVerified
+		}
+		if err := a.db.CreateUserIdentity(ctx, user.ID, claims.Issuer, claims.Subject, time.Now().UTC()); err != nil {
+			return nil, err
+		}
+		a.log.Info("linked OIDC identity to existing user", "userID", user.ID, "subject", claims.Subject)
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if !a.OIDC.Config.AutoProvision {
+		return nil, ErrOIDCUserNotFound
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	if name == "" {
+		name = strings.Split(claims.Email, "@")[0]
+	}
+
+	userID, err := a.createExternalUser(ctx, claims.Email, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.db.CreateUserIdentity(ctx, userID, claims.Issuer, claims.Subject, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	a.log.Info("provisioned user from OIDC identity", "userID", userID, "subject", claims.Subject)
+	return a.GetUserByID(ctx, userID)
+}
+
+// createExternalUser creates an account for a user authenticated elsewhere (SSO, trusted proxy).
+// These accounts get a random password nobody knows. The first account gets the same
+// bootstrap as the first password registration.
+func (a *AuthService) createExternalUser(ctx context.Context, email, name string) (int64, error) {
+	count, err := a.db.CountUsers(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	password, err := generateSessionID()
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate password: %w", err)
+	}
+	userID, err := a.CreateUser(ctx, email, name, password)
+	if err != nil {
+		return 0, err
+	}
+
+	if count == 0 {
+		if err := a.AssignNilUserLists(ctx, &userID); err != nil {
+			return 0, err
+		}
+		if err := a.AssignNilUserWatched(ctx, &userID); err != nil {
+			return 0, err
+		}
+		if err := a.SetUserAsAdmin(ctx, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	return userID, nil
+}
+
 func (a *AuthService) CountUsers(ctx context.Context) (int64, error) {
 	count, err := a.db.CountUsers(ctx)
 	if err != nil {
@@ -156,6 +572,7 @@ func (a *AuthService) SetUserAsAdmin(ctx context.Context, userID int64) error {
 	if err != nil {
 		return fmt.Errorf("failed to set user %d as admin: %w", userID, err)
 	}
+	a.Audit.Record(ctx, models.AuditUserRoleChanged, a.auditTarget(ctx, userID), roleChangeDetail(true))
 	return nil
 }
 
@@ -168,17 +585,34 @@ func (a *AuthService) GetAllUsersWithStats(ctx context.Context) ([]models.UserWi
 }
 
 func (a *AuthService) DeleteUser(ctx context.Context, userID int64) error {
+	return a.deleteUser(ctx, userID, "")
+}
+
+// deleteUser is DeleteUser with detail explaining the deletion in the audit log
+func (a *AuthService) deleteUser(ctx context.Context, userID int64, detail string) error {
+	// look the user up first, the audit entry keeps their email
+	target := a.auditTarget(ctx, userID)
 	err := a.db.DeleteUser(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user %d: %w", userID, err)
 	}
+	a.Audit.Record(ctx, models.AuditUserDeleted, target, detail)
 	return nil
 }
 
-// UpdateUserPassword updates the password of an user.
+// UpdateUserPassword updates the password of an user and signs them out of every device.
 //
 // The password should be passed as plain text, this function will hash it before updating the database
 func (a *AuthService) UpdateUserPassword(ctx context.Context, userID int64, password string) error {
+	if err := a.setPassword(ctx, userID, password); err != nil {
+		return err
+	}
+	a.Audit.Record(ctx, models.AuditPasswordChanged, a.auditTarget(ctx, userID), "")
+	return nil
+}
+
+// setPassword is UpdateUserPassword without the audit entry, for callers that record their own
+func (a *AuthService) setPassword(ctx context.Context, userID int64, password string) error {
 	hash, err := hashPassword(password)
 	if err != nil {
 		return fmt.Errorf("failed to hash password for user %d: %w", userID, err)
@@ -188,6 +622,14 @@ func (a *AuthService) UpdateUserPassword(ctx context.Context, userID int64, pass
 	if err != nil {
 		return fmt.Errorf("failed to update password for user %d: %w", userID, err)
 	}
+
+	if _, err := a.RevokeUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("failed to sign out user %d after password change: %w", userID, err)
+	}
+	// reset links mailed for the old password must not outlive it
+	if err := a.db.DeletePasswordResetsByUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke password reset links of user %d: %w", userID, err)
+	}
 	return nil
 }
 
@@ -201,7 +643,7 @@ func (a *AuthService) RequirePasswordReset(ctx context.Context, userID int64) (s
 	// email prefix + . + name (john@example.com + doe = john.doe)
 	newPass := fmt.Sprintf("%s.%s", strings.Split(user.Email, "@")[0], user.Name)
 
-	err = a.UpdateUserPassword(ctx, userID, newPass)
+	err = a.setPassword(ctx, userID, newPass)
 	if err != nil {
 		return "", fmt.Errorf("failed to update password during reset: %w", err)
 	}
@@ -210,6 +652,7 @@ func (a *AuthService) RequirePasswordReset(ctx context.Context, userID int64) (s
 	if err != nil {
 		return "", fmt.Errorf("failed to set password reset flag: %w", err)
 	}
+	a.Audit.Record(ctx, models.AuditPasswordReset, user, "")
 
 	return newPass, nil
 }
@@ -222,6 +665,31 @@ func (a *AuthService) ClearPasswordResetRequired(ctx context.Context, userID int
 	return nil
 }
 
+// RecordLogin adds a successful sign-in of user to the audit log, method names how they signed in
+func (a *AuthService) RecordLogin(ctx context.Context, user *models.User, method string) {
+	a.Audit.RecordAs(ctx, models.AuditLoginSucceeded, user, user, method)
+}
+
+// auditTarget looks up userID for an audit entry, falling back to only the ID when the
+// lookup fails so the entry is still recorded
+func (a *AuthService) auditTarget(ctx context.Context, userID int64) *models.User {
+	if a.Audit == nil {
+		return nil
+	}
+	user, err := a.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return &models.User{ID: userID}
+	}
+	return user
+}
+
+func roleChangeDetail(admin bool) string {
+	if admin {
+		return "granted admin"
+	}
+	return "revoked admin"
+}
+
 func generateSessionID() (string, error) {
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
@@ -246,3 +714,16 @@ func verifyPassword(hash, password string) error {
 	}
 	return nil
 }
+
+// BaseURL is the address links to gowatch should use: PublicURL when set, otherwise the
+// host the request reached, on tls or when gowatch runs behind HTTPS
+func (a *AuthService) BaseURL(host string, tls bool) string {
+	if a.PublicURL != "" {
+		return a.PublicURL
+	}
+	scheme := "http"
+	if tls || a.HTTPS {
+		scheme = "https"
+	}
+	return scheme + "://" + host
+}
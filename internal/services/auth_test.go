@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+func newAuthTestService(t *testing.T, mode models.RegistrationMode) (*db.SqliteDB, *AuthService) {
+	t.Helper()
+
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+
+	listService := NewListService(testDB, nil, nil)
+	return testDB, NewAuthService(testDB, listService, time.Hour, false, "Welcome123!", mode, time.Hour)
+}
+
+func TestAuthService_FirstUserCanAlwaysRegister(t *testing.T) {
+	_, authService := newAuthTestService(t, models.RegistrationClosed)
+
+	if _, err := authService.RegisterUser(context.Background(), "first@example.com", "First", "Password123!", ""); err != nil {
+		t.Fatalf("expected first user to register on a closed instance, got %v", err)
+	}
+
+	_, err := authService.RegisterUser(context.Background(), "second@example.com", "Second", "Password123!", "")
+	if !errors.Is(err, ErrRegistrationClosed) {
+		t.Errorf("expected %v, got %v", ErrRegistrationClosed, err)
+	}
+}
+
+func TestAuthService_InviteOnlyRegistration(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationInviteOnly)
+	ctx := setupTestUser(t, testDB)
+
+	_, err := authService.RegisterUser(context.Background(), "nope@example.com", "Nope", "Password123!", "")
+	if !errors.Is(err, ErrInviteRequired) {
+		t.Fatalf("expected %v, got %v", ErrInviteRequired, err)
+	}
+	_, err = authService.RegisterUser(context.Background(), "nope@example.com", "Nope", "Password123!", "bogus")
+	if !errors.Is(err, ErrInvalidInvite) {
+		t.Fatalf("expected %v, got %v", ErrInvalidInvite, err)
+	}
+
+	invite, err := authService.CreateInvite(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only a hash of the token is stored
+	if _, err := testDB.GetInviteByTokenHash(ctx, invite.Token); err == nil {
+		t.Error("expected the raw invite token not to be stored")
+	}
+	if _, err := testDB.GetInviteByTokenHash(ctx, hashSessionToken(invite.Token)); err != nil {
+		t.Errorf("expected the invite to be found by its token hash, got %v", err)
+	}
+
+	userID, err := authService.RegisterUser(context.Background(), "invited@example.com", "Invited", "Password123!", invite.Token)
+	if err != nil {
+		t.Fatalf("expected invited user to register, got %v", err)
+	}
+
+	// invites are single-use
+	_, err = authService.RegisterUser(context.Background(), "again@example.com", "Again", "Password123!", invite.Token)
+	if !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("expected reused invite to fail with %v, got %v", ErrInvalidInvite, err)
+	}
+
+	invites, err := authService.GetInvites(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invites) != 1 || invites[0].UsedAt == nil {
+		t.Fatalf("expected the invite to be marked as used, got %+v", invites)
+	}
+	if invites[0].UsedByEmail == nil || *invites[0].UsedByEmail != "invited@example.com" {
+		t.Errorf("expected invite to record its redeemer, got %v", invites[0].UsedByEmail)
+	}
+	if userID == 0 {
+		t.Error("expected a user ID")
+	}
+}
+
+func TestAuthService_FailedRegistrationReleasesInvite(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationInviteOnly)
+	ctx := setupTestUser(t, testDB)
+
+	invite, err := authService.CreateInvite(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// email already taken by the test user
+	if _, err := authService.RegisterUser(context.Background(), "test@example.com", "Dup", "Password123!", invite.Token); err == nil {
+		t.Fatal("expected duplicate email to fail")
+	}
+
+	if err := authService.CheckRegistration(context.Background(), invite.Token); err != nil {
+		t.Errorf("expected invite to be usable after a failed registration, got %v", err)
+	}
+}
+
+func TestAuthService_ExpiredAndRevokedInvites(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationInviteOnly)
+	ctx := setupTestUser(t, testDB)
+
+	authService.InviteExpiry = -time.Minute
+	expired, err := authService.CreateInvite(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = authService.CheckRegistration(context.Background(), expired.Token)
+	if !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("expected expired invite to fail with %v, got %v", ErrInvalidInvite, err)
+	}
+
+	authService.InviteExpiry = time.Hour
+	revoked, err := authService.CreateInvite(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := authService.RevokeInvite(ctx, revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+	err = authService.CheckRegistration(context.Background(), revoked.Token)
+	if !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("expected revoked invite to fail with %v, got %v", ErrInvalidInvite, err)
+	}
+	if err := authService.RevokeInvite(ctx, revoked.ID); !errors.Is(err, ErrInviteNotFound) {
+		t.Errorf("expected %v, got %v", ErrInviteNotFound, err)
+	}
+}
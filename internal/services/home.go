@@ -57,6 +57,35 @@ func (s *HomeService) GetHomeData(ctx context.Context) (*models.HomeData, error)
 		return nil, err
 	}
 
+	watchNext, err := s.GetWatchNext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	homeData := &models.HomeData{
+		RecentMovies:             recentMovies,
+		WatchNext:                *watchNext,
+		DailyWatchCountsLastYear: dailyWatchCountsLastYear,
+		Stats:                    *statsSummary,
+	}
+
+	s.log.Info("successfully aggregated home data")
+	return homeData, nil
+}
+
+// GetWatchNext returns the watchlist movies to watch next, picked by the watch next
+// filter of the user
+func (s *HomeService) GetWatchNext(ctx context.Context) (*models.WatchNext, error) {
+	filter, err := s.list.GetWatchNextFilter(ctx)
+	if err != nil {
+		s.log.Error("failed to retrieve watch next filter", "error", err)
+		return nil, err
+	}
+	watchNext := &models.WatchNext{
+		Movies: []models.MovieItem{},
+		Filter: filter,
+	}
+
 	watchlist, err := s.list.GetWatchlist(ctx)
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
@@ -65,27 +94,35 @@ func (s *HomeService) GetHomeData(ctx context.Context) (*models.HomeData, error)
 		}
 
 		s.log.Warn("watchlist not found, defaulting to empty watchlist")
+		return watchNext, nil
 	}
 
-	watchNextMovies := []models.MovieItem{}
-	watchlistMovieCount := 0
-	if watchlist != nil {
-		watchlistMovieCount = len(watchlist.Movies)
-		watchNextMovies = selectWatchNextMovies(watchlist.Movies, WatchNextMoviesLimit)
-	}
+	watchNext.WatchlistMovieCount = len(watchlist.Movies)
+	watchNext.Tags = watchlistTags(watchlist.Movies)
+	watchNext.Movies = selectWatchNextMovies(filterWatchNextMovies(watchlist.Movies, filter), WatchNextMoviesLimit)
+	return watchNext, nil
+}
 
-	homeData := &models.HomeData{
-		RecentMovies:             recentMovies,
-		WatchNextMovies:          watchNextMovies,
-		WatchlistMovieCount:      watchlistMovieCount,
-		DailyWatchCountsLastYear: dailyWatchCountsLastYear,
-		Stats:                    *statsSummary,
+// filterWatchNextMovies returns the movies filter keeps
+func filterWatchNextMovies(movies []models.MovieItem, filter models.WatchNextFilter) []models.MovieItem {
+	kept := make([]models.MovieItem, 0, len(movies))
+	for _, movie := range movies {
+		if movie.Priority.Rank() > filter.MinPriority.Rank() {
+			continue
+		}
+		if filter.Tag != nil && !hasTag(movie, *filter.Tag) {
+			continue
+		}
+		if filter.ExcludeTag != nil && hasTag(movie, *filter.ExcludeTag) {
+			continue
+		}
+		kept = append(kept, movie)
 	}
-
-	s.log.Info("successfully aggregated home data")
-	return homeData, nil
+	return kept
 }
 
+// selectWatchNextMovies returns the first limit movies by priority, then by position and
+// date added
 func selectWatchNextMovies(movies []models.MovieItem, limit int) []models.MovieItem {
 	if len(movies) == 0 || limit <= 0 {
 		return []models.MovieItem{}
@@ -94,33 +131,9 @@ func selectWatchNextMovies(movies []models.MovieItem, limit int) []models.MovieI
 	sorted := make([]models.MovieItem, len(movies))
 	copy(sorted, movies)
 
-	sort.Slice(sorted, func(i, j int) bool {
-		left := sorted[i]
-		right := sorted[j]
-
-		leftHasPosition := left.Position != nil
-		rightHasPosition := right.Position != nil
-
-		switch {
-		case leftHasPosition && rightHasPosition:
-			if *left.Position != *right.Position {
-				return *left.Position < *right.Position
-			}
-		case leftHasPosition:
-			return true
-		case rightHasPosition:
-			return false
-		}
-
-		if !left.DateAdded.Equal(right.DateAdded) {
-			return left.DateAdded.Before(right.DateAdded)
-		}
-
-		if left.MovieDetails.Movie.Title != right.MovieDetails.Movie.Title {
-			return left.MovieDetails.Movie.Title < right.MovieDetails.Movie.Title
-		}
-
-		return left.MovieDetails.Movie.ID < right.MovieDetails.Movie.ID
+	sortMoviesByPosition(sorted)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority.Rank() < sorted[j].Priority.Rank()
 	})
 
 	if len(sorted) > limit {
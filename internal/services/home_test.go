@@ -1,6 +1,7 @@
 package services
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -66,6 +67,62 @@ func TestSelectWatchNextMovies_ReturnsEmptyForNoMoviesOrInvalidLimit(t *testing.
 	}
 }
 
+func TestSelectWatchNextMovies_OrdersByPriorityFirst(t *testing.T) {
+	baseDate := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	movies := []models.MovieItem{
+		newHomeMovieItem(1, "A", baseDate, int64Ptr(1)),
+		newHomeMovieItem(2, "B", baseDate, int64Ptr(2)),
+		newHomeMovieItem(3, "C", baseDate, int64Ptr(3)),
+		newHomeMovieItem(4, "D", baseDate, int64Ptr(4)),
+	}
+	movies[0].Priority = models.WatchlistPriorityLow
+	movies[2].Priority = models.WatchlistPriorityHigh
+	movies[3].Priority = models.WatchlistPriorityNormal
+
+	result := selectWatchNextMovies(movies, 10)
+
+	// movie 2 has no priority, which is normal
+	expectedOrder := []int64{3, 2, 4, 1}
+	for i, expectedID := range expectedOrder {
+		if result[i].MovieDetails.Movie.ID != expectedID {
+			t.Fatalf("expected movie ID %d at index %d, got %d", expectedID, i, result[i].MovieDetails.Movie.ID)
+		}
+	}
+}
+
+func TestFilterWatchNextMovies(t *testing.T) {
+	baseDate := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	movies := []models.MovieItem{
+		newHomeMovieItem(1, "A", baseDate, nil),
+		newHomeMovieItem(2, "B", baseDate, nil),
+		newHomeMovieItem(3, "C", baseDate, nil),
+	}
+	movies[0].Priority, movies[0].Tags = models.WatchlistPriorityHigh, []string{"With Kids"}
+	movies[1].Priority, movies[1].Tags = models.WatchlistPriorityLow, []string{"with kids", "cinema only"}
+	movies[2].Priority = models.WatchlistPriorityNormal
+
+	withKids, cinema := "with kids", "Cinema Only"
+	tests := []struct {
+		name   string
+		filter models.WatchNextFilter
+		want   []int64
+	}{
+		{"default", models.DefaultWatchNextFilter(), []int64{1, 2, 3}},
+		{"normal and up", models.WatchNextFilter{MinPriority: models.WatchlistPriorityNormal}, []int64{1, 3}},
+		{"high only", models.WatchNextFilter{MinPriority: models.WatchlistPriorityHigh}, []int64{1}},
+		// tags match regardless of case
+		{"tag", models.WatchNextFilter{MinPriority: models.WatchlistPriorityLow, Tag: &withKids}, []int64{1, 2}},
+		{"excluded tag", models.WatchNextFilter{MinPriority: models.WatchlistPriorityLow, ExcludeTag: &cinema}, []int64{1, 3}},
+		{"both tags", models.WatchNextFilter{MinPriority: models.WatchlistPriorityLow, Tag: &withKids, ExcludeTag: &cinema}, []int64{1}},
+	}
+	for _, tt := range tests {
+		result := filterWatchNextMovies(movies, tt.filter)
+		if got := listMovieIDs(result); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
 func newHomeMovieItem(id int64, title string, dateAdded time.Time, position *int64) models.MovieItem {
 	return models.MovieItem{
 		MovieDetails: models.MovieDetails{
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var (
+	ErrImpersonateSelf  = errors.New("admins cannot impersonate themselves")
+	ErrImpersonateAdmin = errors.New("admins cannot be impersonated")
+	ErrNotImpersonating = errors.New("session is not impersonating anyone")
+)
+
+// StartImpersonation lets the admin in ctx view gowatch as userID from the session of
+// token, read-only, until StopImpersonation. It returns the impersonated user.
+func (a *AuthService) StartImpersonation(ctx context.Context, token string, userID int64) (*models.User, error) {
+	admin, err := common.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := a.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == admin.ID {
+		return nil, ErrImpersonateSelf
+	}
+	if target.Admin {
+		return nil, ErrImpersonateAdmin
+	}
+
+	rows, err := a.db.SetSessionImpersonation(ctx, hashSessionToken(token), &target.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start impersonating user %d: %w", target.ID, err)
+	}
+	if rows == 0 {
+		return nil, ErrSessionNotFound
+	}
+
+	a.Audit.Record(ctx, models.AuditImpersonationStarted, target, "")
+	return target, nil
+}
+
+// StopImpersonation returns the session of token to the admin impersonating the user in ctx
+func (a *AuthService) StopImpersonation(ctx context.Context, token string) error {
+	admin := common.GetImpersonator(ctx)
+	if admin == nil {
+		return ErrNotImpersonating
+	}
+	target, err := common.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.db.SetSessionImpersonation(ctx, hashSessionToken(token), nil); err != nil {
+		return fmt.Errorf("failed to stop impersonating user %d: %w", target.ID, err)
+	}
+
+	a.Audit.Record(ctx, models.AuditImpersonationStopped, target, "")
+	return nil
+}
+
+// ImpersonatedUser returns the user session views gowatch as, or nil when it does not
+// impersonate anyone. Impersonation is ignored once user lost admin rights.
+func (a *AuthService) ImpersonatedUser(ctx context.Context, session *models.Session, user *models.User) (*models.User, error) {
+	if session.ImpersonatedUserID == nil || !user.Admin {
+		return nil, nil
+	}
+
+	target, err := a.GetUserByID(ctx, *session.ImpersonatedUserID)
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
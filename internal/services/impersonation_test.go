@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+// newImpersonationTest registers an admin signed in with a session and a regular user
+func newImpersonationTest(t *testing.T) (authService *AuthService, admin, user *models.User, token string) {
+	t.Helper()
+
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	authService.Audit = NewAuditService(testDB)
+
+	admin = mustUser(t, registerTestUser(t, authService, "admin@example.com", "Password123!"))
+	if err := authService.SetUserAsAdmin(context.Background(), admin.ID); err != nil {
+		t.Fatal(err)
+	}
+	admin.Admin = true
+	user = mustUser(t, registerTestUser(t, authService, "user@example.com", "Password123!"))
+
+	token, err := authService.CreateSession(context.Background(), admin.ID, models.SessionClient{IP: "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authService, admin, user, token
+}
+
+func TestAuthService_ImpersonationStartAndStop(t *testing.T) {
+	authService, admin, user, token := newImpersonationTest(t)
+	adminCtx := context.WithValue(context.Background(), common.UserKey, admin)
+
+	if _, err := authService.StartImpersonation(adminCtx, token, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := authService.GetSession(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := authService.ImpersonatedUser(context.Background(), session, admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target == nil || target.ID != user.ID {
+		t.Fatalf("expected the session to impersonate user %d, got %+v", user.ID, target)
+	}
+
+	// the impersonation ends once the admin loses their rights
+	demoted := *admin
+	demoted.Admin = false
+	if target, err := authService.ImpersonatedUser(context.Background(), session, &demoted); err != nil || target != nil {
+		t.Errorf("expected no impersonation without admin rights, got %+v, %v", target, err)
+	}
+
+	impersonatingCtx := context.WithValue(context.WithValue(context.Background(), common.ImpersonatorKey, admin), common.UserKey, target)
+	if err := authService.StopImpersonation(impersonatingCtx, token); err != nil {
+		t.Fatal(err)
+	}
+	if err := authService.StopImpersonation(adminCtx, token); !errors.Is(err, ErrNotImpersonating) {
+		t.Errorf("expected %v, got %v", ErrNotImpersonating, err)
+	}
+
+	session, err = authService.GetSession(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.ImpersonatedUserID != nil {
+		t.Errorf("expected the impersonation to be cleared, got user %d", *session.ImpersonatedUserID)
+	}
+
+	// both ends are recorded with the admin as actor, even while acting as the user
+	entries, err := authService.Audit.List(context.Background(), models.AuditFilter{UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := map[models.AuditAction]bool{}
+	for _, entry := range entries {
+		if entry.Action != models.AuditImpersonationStarted && entry.Action != models.AuditImpersonationStopped {
+			continue
+		}
+		if entry.ActorID == nil || *entry.ActorID != admin.ID || entry.TargetID == nil || *entry.TargetID != user.ID {
+			t.Errorf("expected %s by the admin on the user, got %+v", entry.Action, entry)
+		}
+		recorded[entry.Action] = true
+	}
+	if !recorded[models.AuditImpersonationStarted] || !recorded[models.AuditImpersonationStopped] {
+		t.Errorf("expected the start and stop to be audited, got %v", recorded)
+	}
+}
+
+func TestAuthService_ImpersonationRefusesSelfAndAdmins(t *testing.T) {
+	authService, admin, _, token := newImpersonationTest(t)
+	adminCtx := context.WithValue(context.Background(), common.UserKey, admin)
+
+	other := mustUser(t, registerTestUser(t, authService, "other@example.com", "Password123!"))
+	if err := authService.SetUserAsAdmin(context.Background(), other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := authService.StartImpersonation(adminCtx, token, admin.ID); !errors.Is(err, ErrImpersonateSelf) {
+		t.Errorf("expected %v, got %v", ErrImpersonateSelf, err)
+	}
+	if _, err := authService.StartImpersonation(adminCtx, token, other.ID); !errors.Is(err, ErrImpersonateAdmin) {
+		t.Errorf("expected %v, got %v", ErrImpersonateAdmin, err)
+	}
+}
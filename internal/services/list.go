@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/marcosalvi-01/gowatch/db"
 	"github.com/marcosalvi-01/gowatch/internal/common"
@@ -14,25 +16,41 @@ import (
 	"github.com/marcosalvi-01/gowatch/logging"
 )
 
+const (
+	maxListNameLength        = 100
+	maxListDescriptionLength = 500
+)
+
+var (
+	ErrInvalidListName        = errors.New("list name must be between 1 and 100 characters")
+	ErrInvalidListDescription = errors.New("list description must be at most 500 characters")
+	ErrListNameTaken          = errors.New("another list already has this name")
+	ErrListNotFound           = errors.New("list not found")
+	ErrWatchlistNotEditable   = errors.New("the watchlist cannot be edited")
+)
+
 // ListService handles user's custom movie lists
 type ListService struct {
-	db   db.DB
-	tmdb *MovieService
-	log  *slog.Logger
+	db       db.DB
+	tmdb     *MovieService
+	webhooks *WebhookService
+	log      *slog.Logger
 }
 
-func NewListService(db db.DB, tmdb *MovieService) *ListService {
+func NewListService(db db.DB, tmdb *MovieService, webhooks *WebhookService) *ListService {
 	log := logging.Get("list service")
 	log.Debug("creating new ListService instance")
 	return &ListService{
-		db:   db,
-		tmdb: tmdb,
-		log:  log,
+		db:       db,
+		tmdb:     tmdb,
+		webhooks: webhooks,
+		log:      log,
 	}
 }
 
-// GetAllLists retrieves all user lists EXCEPT the watchlist
-// The watchlist is managed separately and not included in normal list operations
+// GetAllLists retrieves all user lists EXCEPT the watchlist, followed by the lists other
+// users share with them. The watchlist is managed separately and not included in normal
+// list operations
 func (s *ListService) GetAllLists(ctx context.Context) ([]models.ListEntry, error) {
 	s.log.Debug("retrieving all lists")
 
@@ -61,18 +79,30 @@ func (s *ListService) GetAllLists(ctx context.Context) ([]models.ListEntry, erro
 	lists := make([]models.ListEntry, len(filteredResults))
 	for i, result := range filteredResults {
 		lists[i] = models.ListEntry{
-			ID:   result.ID,
-			Name: result.Name,
+			ID:     result.ID,
+			Name:   result.Name,
+			Role:   models.ListRoleOwner,
+			Smart:  result.Smart,
+			Synced: result.Synced,
 		}
 	}
 
+	// lists other users share with this one come after their own
+	memberLists, err := s.db.GetMemberLists(ctx, user.ID)
+	if err != nil {
+		s.log.Error("failed to fetch lists shared with the user", "error", err)
+		return nil, fmt.Errorf("failed to get shared lists: %w", err)
+	}
+	lists = append(lists, memberLists...)
+
 	s.log.Info("successfully retrieved all lists", "count", len(lists))
 	return lists, nil
 }
 
 func (s *ListService) CreateList(ctx context.Context, name string, description *string, isWatchlist bool) (*models.List, error) {
-	if name == "" {
-		return nil, fmt.Errorf("list name cannot be empty")
+	name, description, err := normalizeListDetails(name, description)
+	if err != nil {
+		return nil, err
 	}
 	s.log.Debug("creating new list", "name", name)
 
@@ -88,6 +118,9 @@ func (s *ListService) CreateList(ctx context.Context, name string, description *
 		Description: description,
 		IsWatchlist: isWatchlist,
 	})
+	if errors.Is(err, db.ErrListNameTaken) {
+		return nil, ErrListNameTaken
+	}
 	if err != nil {
 		s.log.Error("failed to create list", "name", name, "error", err)
 		return nil, fmt.Errorf("failed to create list: %w", err)
@@ -103,6 +136,69 @@ func (s *ListService) CreateList(ctx context.Context, name string, description *
 	return list, nil
 }
 
+// UpdateList renames listID and replaces its description, an empty one removes it. Names
+// are unique among the custom lists of a user, so imports can merge lists by name.
+func (s *ListService) UpdateList(ctx context.Context, listID int64, name, description string) (*models.List, error) {
+	name, descPtr, err := normalizeListDetails(name, &description)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Debug("updating list", "listID", listID, "name", name)
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	if s.IsWatchlist(ctx, listID) {
+		s.log.Warn("attempted to edit watchlist", "listID", listID)
+		return nil, ErrWatchlistNotEditable
+	}
+
+	rows, err := s.db.UpdateList(ctx, user.ID, listID, name, descPtr)
+	if errors.Is(err, db.ErrListNameTaken) {
+		return nil, ErrListNameTaken
+	}
+	if err != nil {
+		s.log.Error("failed to update list", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to update list: %w", err)
+	}
+	if rows == 0 {
+		return nil, ErrListNotFound
+	}
+
+	list, err := s.db.GetList(ctx, user.ID, listID)
+	if err != nil {
+		s.log.Error("failed to retrieve updated list", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to retrieve updated list: %w", err)
+	}
+
+	s.log.Info("successfully updated list", "listID", listID, "name", name)
+	return list, nil
+}
+
+// normalizeListDetails trims the name and description of a list and checks their length
+// in characters. A blank description is returned as nil. Whether the name is taken is
+// left to the unique index on the lists of a user.
+func normalizeListDetails(name string, description *string) (string, *string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxListNameLength {
+		return "", nil, ErrInvalidListName
+	}
+	if description == nil {
+		return name, nil, nil
+	}
+	trimmed := strings.TrimSpace(*description)
+	if utf8.RuneCountInString(trimmed) > maxListDescriptionLength {
+		return "", nil, ErrInvalidListDescription
+	}
+	if trimmed == "" {
+		return name, nil, nil
+	}
+	return name, &trimmed, nil
+}
+
 func (s *ListService) AddMovieToList(ctx context.Context, listID, movieID int64, note *string) error {
 	if listID <= 0 {
 		return fmt.Errorf("invalid list ID")
@@ -117,6 +213,9 @@ func (s *ListService) AddMovieToList(ctx context.Context, listID, movieID int64,
 		s.log.Error("failed to get userID", "error", err)
 		return err
 	}
+	if _, err := s.editableList(ctx, listID); err != nil {
+		return err
+	}
 
 	err = s.db.AddMovieToList(ctx, user.ID, db.InsertMovieList{
 		MovieID:   movieID,
@@ -130,6 +229,8 @@ func (s *ListService) AddMovieToList(ctx context.Context, listID, movieID int64,
 		return fmt.Errorf("failed to add movie '%d' to list '%d': %w", movieID, listID, err)
 	}
 
+	s.enqueueListItems(ctx, models.WebhookEventListItemAdded, listID, []int64{movieID}, note)
+
 	s.log.Info("successfully added movie to list", "listID", listID, "movieID", movieID)
 	return nil
 }
@@ -150,6 +251,10 @@ func (s *ListService) GetListDetails(ctx context.Context, listID int64) (*models
 	}
 	s.log.Debug("fetched list details", "listID", listID, "movieCount", len(list.Movies))
 
+	if list.Ranked {
+		sortMoviesByPosition(list.Movies)
+	}
+
 	return list, nil
 }
 
@@ -186,12 +291,17 @@ func (s *ListService) DeleteMovieFromList(ctx context.Context, listID, movieID i
 		s.log.Error("failed to get userID", "error", err)
 		return err
 	}
+	if _, err := s.editableList(ctx, listID); err != nil {
+		return err
+	}
 
 	err = s.db.DeleteMovieFromList(ctx, user.ID, listID, movieID)
 	if err != nil {
 		s.log.Error("failed to remove movie from list", "listID", listID, "movieID", movieID, "error", err)
 		return fmt.Errorf("failed to delete movie for list from db: %w", err)
 	}
+
+	s.enqueueListItems(ctx, models.WebhookEventListItemRemoved, listID, []int64{movieID}, nil)
 	s.log.Info("successfully removed movie from list", "listID", listID, "movieID", movieID)
 
 	return nil
@@ -284,6 +394,24 @@ func (s *ListService) IsWatchlist(ctx context.Context, listID int64) bool {
 	return listID == watchlistID
 }
 
+// GetMovieLists returns the lists of the user, the watchlist and the ones shared with them
+// included, that contain movieID, then the smart lists whose rules match it
+func (s *ListService) GetMovieLists(ctx context.Context, movieID int64) ([]models.MovieList, error) {
+	s.log.Debug("retrieving lists containing movie", "movieID", movieID)
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	lists, err := s.db.GetMovieLists(ctx, user.ID, movieID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lists containing movie: %w", err)
+	}
+	return lists, nil
+}
+
 // IsMovieInWatchlist checks if the given movie is in the user's watchlist
 func (s *ListService) IsMovieInWatchlist(ctx context.Context, movieID int64) bool {
 	watchlist, err := s.GetWatchlist(ctx)
@@ -318,6 +446,10 @@ func (s *ListService) ExportLists(ctx context.Context) (models.ImportListsLog, e
 
 	exportLists := make(models.ImportListsLog, len(lists))
 	for i, list := range lists {
+		if list.Ranked {
+			sortMoviesByPosition(list.Movies)
+		}
+
 		exportMovies := make([]models.ImportListMovieRef, len(list.Movies))
 		for j, movie := range list.Movies {
 			exportMovies[j] = models.ImportListMovieRef{
@@ -332,6 +464,7 @@ func (s *ListService) ExportLists(ctx context.Context) (models.ImportListsLog, e
 			Name:        list.Name,
 			Description: list.Description,
 			IsWatchlist: list.IsWatchlist,
+			Ranked:      list.Ranked,
 			Movies:      exportMovies,
 		}
 	}
@@ -411,6 +544,12 @@ func (s *ListService) ImportLists(ctx context.Context, lists models.ImportListsL
 				targetListID = newList.ID
 				existingCustomLists[importList.Name] = newList.ID
 			}
+
+			if importList.Ranked {
+				if _, err := s.db.SetListRanked(ctx, user.ID, targetListID, true); err != nil {
+					s.log.Error("ImportLists: failed to rank list", "listName", importList.Name, "error", err)
+				}
+			}
 		}
 
 		// Upsert movies in the target list
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var (
+	ErrNoMoviesSelected = errors.New("no movies selected")
+	ErrSameList         = errors.New("the source and target list are the same")
+)
+
+// RemoveMoviesFromList removes movieIDs from listID, returning how many were in it
+func (s *ListService) RemoveMoviesFromList(ctx context.Context, listID int64, movieIDs []int64) (int, error) {
+	if len(movieIDs) == 0 {
+		return 0, ErrNoMoviesSelected
+	}
+	s.log.Debug("removing movies from list", "listID", listID, "movieCount", len(movieIDs))
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return 0, err
+	}
+	if _, err := s.editableList(ctx, listID); err != nil {
+		return 0, err
+	}
+
+	removed, err := s.db.DeleteListMovies(ctx, user.ID, listID, movieIDs)
+	if err != nil {
+		s.log.Error("failed to remove movies from list", "listID", listID, "error", err)
+		return 0, fmt.Errorf("failed to remove movies from list: %w", err)
+	}
+	// only the movies that were in the list were removed from it
+	s.enqueueListItems(ctx, models.WebhookEventListItemRemoved, listID, removed, nil)
+
+	s.log.Info("successfully removed movies from list", "listID", listID, "count", len(removed))
+	return len(removed), nil
+}
+
+// CopyMoviesToList copies movieIDs of fromListID to toListID with their notes, positions
+// and dates added, returning how many were not in toListID already. The source can be any
+// list the user sees, the target must be one they can edit.
+func (s *ListService) CopyMoviesToList(ctx context.Context, fromListID, toListID int64, movieIDs []int64) (int, error) {
+	return s.copyMoviesToList(ctx, fromListID, toListID, movieIDs, false)
+}
+
+// MoveMoviesToList moves movieIDs of fromListID to toListID like CopyMoviesToList, then
+// removes them from fromListID
+func (s *ListService) MoveMoviesToList(ctx context.Context, fromListID, toListID int64, movieIDs []int64) (int, error) {
+	return s.copyMoviesToList(ctx, fromListID, toListID, movieIDs, true)
+}
+
+func (s *ListService) copyMoviesToList(ctx context.Context, fromListID, toListID int64, movieIDs []int64, move bool) (int, error) {
+	if len(movieIDs) == 0 {
+		return 0, ErrNoMoviesSelected
+	}
+	if fromListID == toListID {
+		return 0, ErrSameList
+	}
+	s.log.Debug("copying movies to list", "fromListID", fromListID, "toListID", toListID, "movieCount", len(movieIDs), "move", move)
+
+	var from *models.List
+	var err error
+	if move {
+		from, err = s.editableList(ctx, fromListID)
+	} else {
+		from, err = s.visibleList(ctx, fromListID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if from.Smart() {
+		// the movies of a smart list are not stored to be copied
+		return 0, ErrSmartListReadOnly
+	}
+
+	// keep the order of the source, only the movies that are in it
+	selected := make(map[int64]bool, len(movieIDs))
+	for _, movieID := range movieIDs {
+		selected[movieID] = true
+	}
+	var ordered []int64
+	for _, movieID := range listMovieIDs(from.Movies) {
+		if selected[movieID] {
+			ordered = append(ordered, movieID)
+		}
+	}
+	if len(ordered) == 0 {
+		return 0, ErrMovieNotInList
+	}
+
+	added, err := s.copyListMovies(ctx, from, toListID, ordered, move)
+	if err != nil {
+		return 0, err
+	}
+
+	s.log.Info("successfully copied movies to list", "fromListID", fromListID, "toListID", toListID, "added", len(added), "move", move)
+	return len(added), nil
+}
+
+// GetTargetLists returns the lists the movies of listID can be copied, moved or merged
+// into: the watchlist and the other lists the user can add movies to
+func (s *ListService) GetTargetLists(ctx context.Context, listID int64) ([]models.ListEntry, error) {
+	lists, err := s.GetAllLists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []models.ListEntry
+	if !s.IsWatchlist(ctx, listID) {
+		watchlist, err := s.GetWatchlist(ctx)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, models.ListEntry{ID: watchlist.ID, Name: watchlist.Name, Role: models.ListRoleOwner})
+	}
+	for _, list := range lists {
+		if list.ID != listID && !list.Smart && !list.Synced && list.Role.CanEdit() {
+			targets = append(targets, list)
+		}
+	}
+	return targets, nil
+}
+
+// DuplicateList creates a list called name with the description, ranking, movies and
+// rules of listID. Lists shared with the user can be duplicated too, the copy is theirs.
+func (s *ListService) DuplicateList(ctx context.Context, listID int64, name string) (*models.List, error) {
+	s.log.Debug("duplicating list", "listID", listID, "name", name)
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	source, err := s.visibleList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.CreateList(ctx, name, source.Description, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.copyList(ctx, user.ID, source, list.ID); err != nil {
+		// do not leave half a copy behind
+		if deleteErr := s.DeleteList(ctx, list.ID); deleteErr != nil {
+			s.log.Error("failed to delete partial list copy", "listID", list.ID, "error", deleteErr)
+		}
+		return nil, err
+	}
+
+	s.log.Info("successfully duplicated list", "listID", listID, "copyID", list.ID)
+	return s.GetListDetails(ctx, list.ID)
+}
+
+func (s *ListService) copyList(ctx context.Context, userID int64, source *models.List, toListID int64) error {
+	if source.Ranked {
+		if _, err := s.db.SetListRanked(ctx, userID, toListID, true); err != nil {
+			s.log.Error("failed to rank list copy", "listID", toListID, "error", err)
+			return fmt.Errorf("failed to rank list copy: %w", err)
+		}
+	}
+	if source.Smart() {
+		if _, err := s.db.SetListRules(ctx, userID, toListID, *source.Rules); err != nil {
+			s.log.Error("failed to copy list rules", "listID", toListID, "error", err)
+			return fmt.Errorf("failed to copy list rules: %w", err)
+		}
+		return nil
+	}
+	if len(source.Movies) == 0 {
+		return nil
+	}
+
+	if _, err := s.db.CopyListMovies(ctx, userID, source.ID, toListID, listMovieIDs(source.Movies), false); err != nil {
+		s.log.Error("failed to copy list movies", "fromListID", source.ID, "toListID", toListID, "error", err)
+		return fmt.Errorf("failed to copy list movies: %w", err)
+	}
+	return nil
+}
+
+// MergeList adds every movie of fromListID to intoListID, then deletes fromListID. Movies
+// in both lists keep their entry in intoListID. Only the owner of fromListID can merge it
+// away, and the watchlist and smart lists cannot be. It returns how many movies were added.
+func (s *ListService) MergeList(ctx context.Context, fromListID, intoListID int64) (int, error) {
+	if fromListID == intoListID {
+		return 0, ErrSameList
+	}
+	s.log.Debug("merging list", "fromListID", fromListID, "intoListID", intoListID)
+
+	if s.IsWatchlist(ctx, fromListID) {
+		s.log.Warn("attempted to merge watchlist away", "listID", fromListID)
+		return 0, ErrWatchlistNotEditable
+	}
+	from, err := s.visibleList(ctx, fromListID)
+	if err != nil {
+		return 0, err
+	}
+	if from.Role != models.ListRoleOwner {
+		return 0, ErrListNotFound
+	}
+	if from.Smart() {
+		return 0, ErrSmartListReadOnly
+	}
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return 0, err
+	}
+	into, err := s.editableList(ctx, intoListID)
+	if err != nil {
+		return 0, err
+	}
+
+	movieIDs := listMovieIDs(from.Movies)
+	added := moviesNotInList(into, movieIDs)
+	var order []int64
+	if into.Ranked && len(added) > 0 {
+		order = append(listMovieIDs(into.Movies), added...)
+	}
+	// the list is only deleted if every movie made it into the other
+	if err := s.db.MergeList(ctx, user.ID, fromListID, intoListID, movieIDs, order); err != nil {
+		s.log.Error("failed to merge list", "fromListID", fromListID, "intoListID", intoListID, "error", err)
+		return 0, fmt.Errorf("failed to merge list: %w", err)
+	}
+	s.enqueueListItems(ctx, models.WebhookEventListItemAdded, intoListID, added, nil)
+
+	s.log.Info("successfully merged list", "fromListID", fromListID, "intoListID", intoListID, "added", len(added))
+	return len(added), nil
+}
+
+// copyListMovies copies movieIDs, in their order in from, to toListID and returns the ones
+// that were not in it yet. Copies to a ranked list go after the movies already ranked, in
+// the order they had in from.
+func (s *ListService) copyListMovies(ctx context.Context, from *models.List, toListID int64, movieIDs []int64, move bool) ([]int64, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	to, err := s.editableList(ctx, toListID)
+	if err != nil {
+		return nil, err
+	}
+	order := listMovieIDs(to.Movies)
+	added := moviesNotInList(to, movieIDs)
+
+	var moved []int64
+	if len(movieIDs) > 0 {
+		moved, err = s.db.CopyListMovies(ctx, user.ID, from.ID, toListID, movieIDs, move)
+		if err != nil {
+			s.log.Error("failed to copy movies to list", "fromListID", from.ID, "toListID", toListID, "error", err)
+			return nil, fmt.Errorf("failed to copy movies to list: %w", err)
+		}
+	}
+
+	if to.Ranked && len(added) > 0 {
+		if err := s.db.ReorderList(ctx, user.ID, toListID, append(order, added...)); err != nil {
+			s.log.Error("failed to rank copied movies", "listID", toListID, "error", err)
+			return nil, fmt.Errorf("failed to rank copied movies: %w", err)
+		}
+	}
+
+	s.enqueueListItems(ctx, models.WebhookEventListItemAdded, toListID, added, nil)
+	s.enqueueListItems(ctx, models.WebhookEventListItemRemoved, from.ID, moved, nil)
+	return added, nil
+}
+
+// moviesNotInList returns the movies of movieIDs that list does not hold yet
+func moviesNotInList(list *models.List, movieIDs []int64) []int64 {
+	inList := listMovieIDs(list.Movies)
+	var missing []int64
+	for _, movieID := range movieIDs {
+		if !slices.Contains(inList, movieID) {
+			missing = append(missing, movieID)
+		}
+	}
+	return missing
+}
+
+// enqueueListItems queues event for every movie of movieIDs to the webhooks of the owner
+// of the list, so that members editing a shared list notify its owner and not themselves
+func (s *ListService) enqueueListItems(ctx context.Context, event models.WebhookEvent, listID int64, movieIDs []int64, note *string) {
+	if s.webhooks == nil || len(movieIDs) == 0 {
+		return
+	}
+
+	ownerID, err := s.db.GetListOwner(ctx, listID)
+	if err != nil {
+		s.log.Warn("failed to get list owner to queue webhooks", "listID", listID, "event", event, "error", err)
+		return
+	}
+	for _, movieID := range movieIDs {
+		err := s.webhooks.EnqueueFor(ctx, ownerID, event, models.ListItemEventData{
+			ListID:  listID,
+			MovieID: movieID,
+			Note:    note,
+		})
+		if err != nil {
+			s.log.Warn("failed to queue webhook for list item", "listID", listID, "movieID", movieID, "event", event, "error", err)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+// listMovie returns movieID of listID, failing the test when it is not in the list
+func listMovie(t *testing.T, ctx context.Context, listService *ListService, listID, movieID int64) models.MovieItem {
+	t.Helper()
+
+	list, err := listService.GetListDetails(ctx, listID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, movie := range list.Movies {
+		if movie.MovieDetails.Movie.ID == movieID {
+			return movie
+		}
+	}
+	t.Fatalf("expected movie %d in list %d", movieID, listID)
+	return models.MovieItem{}
+}
+
+func sortedListMovieIDs(t *testing.T, ctx context.Context, listService *ListService, listID int64) []int64 {
+	t.Helper()
+
+	list, err := listService.GetListDetails(ctx, listID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := listMovieIDs(list.Movies)
+	slices.Sort(ids)
+	return ids
+}
+
+func TestListService_CopyMoviesToList(t *testing.T) {
+	ctx, listService, list := newRankedListTest(t)
+
+	target, err := listService.CreateList(ctx, "Target", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.AddMovieToList(ctx, target.ID, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := listService.CopyMoviesToList(ctx, list.ID, target.ID, []int64{1, 2, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 {
+		t.Errorf("expected 1 new movie, got %d", added)
+	}
+	if got := sortedListMovieIDs(t, ctx, listService, target.ID); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("expected movies [1 2] in the target, got %v", got)
+	}
+	if got := sortedListMovieIDs(t, ctx, listService, list.ID); !reflect.DeepEqual(got, []int64{1, 2, 3, 4}) {
+		t.Errorf("expected the source to keep its movies, got %v", got)
+	}
+
+	// the copy keeps the date it was added to the source
+	want := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	if got := listMovie(t, ctx, listService, target.ID, 1).DateAdded; !got.Equal(want) {
+		t.Errorf("expected date added %v, got %v", want, got)
+	}
+
+	if _, err := listService.CopyMoviesToList(ctx, list.ID, list.ID, []int64{1}); !errors.Is(err, ErrSameList) {
+		t.Errorf("expected %v, got %v", ErrSameList, err)
+	}
+	if _, err := listService.CopyMoviesToList(ctx, list.ID, target.ID, nil); !errors.Is(err, ErrNoMoviesSelected) {
+		t.Errorf("expected %v, got %v", ErrNoMoviesSelected, err)
+	}
+	if _, err := listService.CopyMoviesToList(ctx, list.ID, target.ID, []int64{9}); !errors.Is(err, ErrMovieNotInList) {
+		t.Errorf("expected %v, got %v", ErrMovieNotInList, err)
+	}
+}
+
+func TestListService_MoveMoviesToRankedList(t *testing.T) {
+	ctx, listService, list := newRankedListTest(t)
+	if err := listService.SetListRanked(ctx, list.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.ReorderList(ctx, list.ID, []int64{4, 3, 2, 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := listService.CreateList(ctx, "Target", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.AddMovieToList(ctx, target.ID, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.SetListRanked(ctx, target.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := listService.MoveMoviesToList(ctx, list.ID, target.ID, []int64{1, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 2 {
+		t.Errorf("expected 2 new movies, got %d", added)
+	}
+
+	// moved movies go after the ranked ones, in their order in the source
+	assertListOrder(t, ctx, listService, target.ID, []int64{2, 3, 1})
+	assertListOrder(t, ctx, listService, list.ID, []int64{4, 2})
+}
+
+func TestListService_RemoveMoviesFromList(t *testing.T) {
+	ctx, listService, list := newRankedListTest(t)
+
+	removed, err := listService.RemoveMoviesFromList(ctx, list.ID, []int64{1, 3, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Errorf("expected 2 removed movies, got %d", removed)
+	}
+	if got := sortedListMovieIDs(t, ctx, listService, list.ID); !reflect.DeepEqual(got, []int64{2, 4}) {
+		t.Errorf("expected movies [2 4] left, got %v", got)
+	}
+}
+
+func TestListService_DuplicateList(t *testing.T) {
+	ctx, listService, list := newRankedListTest(t)
+	if err := listService.SetListRanked(ctx, list.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.ReorderList(ctx, list.ID, []int64{3, 1, 4, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := listService.DuplicateList(ctx, list.ID, list.Name); !errors.Is(err, ErrListNameTaken) {
+		t.Errorf("expected %v, got %v", ErrListNameTaken, err)
+	}
+
+	duplicate, err := listService.DuplicateList(ctx, list.ID, "Best of (copy)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !duplicate.Ranked {
+		t.Error("expected the copy to be ranked")
+	}
+	assertListOrder(t, ctx, listService, duplicate.ID, []int64{3, 1, 4, 2})
+	assertListOrder(t, ctx, listService, list.ID, []int64{3, 1, 4, 2})
+}
+
+func TestListService_MergeList(t *testing.T) {
+	ctx, listService, list := newRankedListTest(t)
+
+	into, err := listService.CreateList(ctx, "Into", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.AddMovieToList(ctx, into.ID, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := listService.MergeList(ctx, list.ID, into.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 3 {
+		t.Errorf("expected 3 new movies, got %d", added)
+	}
+	if got := sortedListMovieIDs(t, ctx, listService, into.ID); !reflect.DeepEqual(got, []int64{1, 2, 3, 4}) {
+		t.Errorf("expected every movie in the merged list, got %v", got)
+	}
+	if _, err := listService.GetListDetails(ctx, list.ID); err == nil {
+		t.Error("expected the merged list to be deleted")
+	}
+
+	watchlist, err := listService.CreateList(ctx, "Watchlist", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listService.MergeList(ctx, watchlist.ID, into.ID); !errors.Is(err, ErrWatchlistNotEditable) {
+		t.Errorf("expected %v, got %v", ErrWatchlistNotEditable, err)
+	}
+}
+
+func TestListService_MergeListKeepsNotes(t *testing.T) {
+	ctx, listService, _ := newRankedListTest(t)
+
+	from, err := listService.CreateList(ctx, "From", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	into, err := listService.CreateList(ctx, "Into", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromNote, intoNote := "from note", "into note"
+	for _, add := range []struct {
+		listID, movieID int64
+		note            *string
+	}{
+		{from.ID, 1, &fromNote},
+		{from.ID, 2, &fromNote},
+		{into.ID, 1, nil},
+		{into.ID, 2, &intoNote},
+	} {
+		if err := listService.AddMovieToList(ctx, add.listID, add.movieID, add.note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := listService.MergeList(ctx, from.ID, into.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// movies in both lists take the note of the merged one only when they have none
+	for movieID, want := range map[int64]string{1: fromNote, 2: intoNote} {
+		note := listMovie(t, ctx, listService, into.ID, movieID).Note
+		if note == nil || *note != want {
+			t.Errorf("expected note %q for movie %d, got %v", want, movieID, note)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var (
+	ErrListReadOnly       = errors.New("the list is shared with this user as a viewer")
+	ErrInvalidListRole    = errors.New("list members are either viewers or editors")
+	ErrListMemberNotFound = errors.New("no user with this email")
+	ErrListMemberIsOwner  = errors.New("the owner of a list cannot be one of its members")
+)
+
+// GetListMembers returns the users listID is shared with, for its owner and members
+func (s *ListService) GetListMembers(ctx context.Context, listID int64) ([]models.ListMember, error) {
+	s.log.Debug("getting list members", "listID", listID)
+
+	if _, err := s.visibleList(ctx, listID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.db.GetListMembers(ctx, listID)
+	if err != nil {
+		s.log.Error("failed to get list members", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to get list members: %w", err)
+	}
+	return members, nil
+}
+
+// AddListMember shares listID with the user registered with email as role. Sharing it
+// again with the same user changes their role. Only the owner can share a list, and the
+// watchlist stays private.
+func (s *ListService) AddListMember(ctx context.Context, listID int64, email string, role models.ListRole) (*models.User, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidListRole
+	}
+	s.log.Debug("adding list member", "listID", listID, "role", role)
+
+	owner, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	member, err := s.db.GetUserByEmail(ctx, strings.TrimSpace(email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrListMemberNotFound
+	}
+	if err != nil {
+		s.log.Error("failed to look up list member", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to look up list member: %w", err)
+	}
+	if member.ID == owner.ID {
+		return nil, ErrListMemberIsOwner
+	}
+
+	if err := s.setListMember(ctx, owner.ID, listID, member.ID, role); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("successfully added list member", "listID", listID, "memberID", member.ID, "role", role)
+	return member, nil
+}
+
+// SetListMemberRole changes the role of memberID in listID, for the owner of the list
+func (s *ListService) SetListMemberRole(ctx context.Context, listID, memberID int64, role models.ListRole) error {
+	if !role.Valid() {
+		return ErrInvalidListRole
+	}
+	s.log.Debug("changing list member role", "listID", listID, "memberID", memberID, "role", role)
+
+	owner, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return err
+	}
+
+	members, err := s.GetListMembers(ctx, listID)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, member := range members {
+		found = found || member.UserID == memberID
+	}
+	if !found {
+		return ErrListMemberNotFound
+	}
+
+	if err := s.setListMember(ctx, owner.ID, listID, memberID, role); err != nil {
+		return err
+	}
+
+	s.log.Info("successfully changed list member role", "listID", listID, "memberID", memberID, "role", role)
+	return nil
+}
+
+func (s *ListService) setListMember(ctx context.Context, ownerID, listID, memberID int64, role models.ListRole) error {
+	if s.IsWatchlist(ctx, listID) {
+		s.log.Warn("attempted to share watchlist", "listID", listID)
+		return ErrWatchlistNotEditable
+	}
+
+	rows, err := s.db.UpsertListMember(ctx, ownerID, listID, memberID, role)
+	if err != nil {
+		s.log.Error("failed to set list member", "listID", listID, "memberID", memberID, "error", err)
+		return fmt.Errorf("failed to set list member: %w", err)
+	}
+	if rows == 0 {
+		return ErrListNotFound
+	}
+	return nil
+}
+
+// RemoveListMember stops sharing listID with memberID. The owner can remove anyone,
+// members can only remove themselves, see LeaveList.
+func (s *ListService) RemoveListMember(ctx context.Context, listID, memberID int64) error {
+	s.log.Debug("removing list member", "listID", listID, "memberID", memberID)
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return err
+	}
+
+	rows, err := s.db.DeleteListMember(ctx, user.ID, listID, memberID)
+	if err != nil {
+		s.log.Error("failed to remove list member", "listID", listID, "memberID", memberID, "error", err)
+		return fmt.Errorf("failed to remove list member: %w", err)
+	}
+	if rows == 0 {
+		return ErrListMemberNotFound
+	}
+
+	s.log.Info("successfully removed list member", "listID", listID, "memberID", memberID)
+	return nil
+}
+
+// LeaveList removes the user in ctx from a list shared with them
+func (s *ListService) LeaveList(ctx context.Context, listID int64) error {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return err
+	}
+	return s.RemoveListMember(ctx, listID, user.ID)
+}
+
+// visibleList returns listID when the user in ctx owns it or it is shared with them,
+// ErrListNotFound otherwise
+func (s *ListService) visibleList(ctx context.Context, listID int64) (*models.List, error) {
+	list, err := s.GetListDetails(ctx, listID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrListNotFound
+	}
+	return list, err
+}
+
+// editableList returns listID when the user in ctx can change its movies, ErrListReadOnly
+// when it is only shared with them to view, ErrSmartListReadOnly when its rules pick its
+// movies and ErrSyncedListReadOnly when TMDB does
+func (s *ListService) editableList(ctx context.Context, listID int64) (*models.List, error) {
+	list, err := s.visibleList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if list.Smart() {
+		return nil, ErrSmartListReadOnly
+	}
+	if list.Synced() {
+		return nil, ErrSyncedListReadOnly
+	}
+	if !list.Role.CanEdit() {
+		return nil, ErrListReadOnly
+	}
+	return list, nil
+}
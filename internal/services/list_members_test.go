@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+// newListMemberTest creates a list holding movie 1, shared by its owner with a second
+// user as role, and returns the context of each
+func newListMemberTest(t *testing.T, role models.ListRole) (ownerCtx, memberCtx context.Context, listService *ListService, list *models.List) {
+	t.Helper()
+
+	ownerCtx, testDB, listService, list := newSharedListTest(t, "")
+
+	member, err := testDB.CreateUser(ownerCtx, "member@example.com", "Member", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listService.AddListMember(ownerCtx, list.ID, "member@example.com", role); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int64{2, 3} {
+		if err := testDB.UpsertMovie(ownerCtx, &models.MovieDetails{Movie: models.Movie{ID: id, Title: "Movie"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	memberCtx = context.WithValue(context.Background(), common.UserKey, member)
+	return ownerCtx, memberCtx, listService, list
+}
+
+func TestListService_ViewerCannotChangeList(t *testing.T) {
+	_, memberCtx, listService, list := newListMemberTest(t, models.ListRoleViewer)
+
+	shared, err := listService.GetListDetails(memberCtx, list.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shared.Role != models.ListRoleViewer || shared.OwnerName != "Test User" || len(shared.Movies) != 1 {
+		t.Fatalf("expected to view the list of Test User with its movie, got role %q of %q with %d movies", shared.Role, shared.OwnerName, len(shared.Movies))
+	}
+
+	if err := listService.AddMovieToList(memberCtx, list.ID, 2, nil); !errors.Is(err, ErrListReadOnly) {
+		t.Errorf("expected %v adding a movie, got %v", ErrListReadOnly, err)
+	}
+	if err := listService.DeleteMovieFromList(memberCtx, list.ID, 1); !errors.Is(err, ErrListReadOnly) {
+		t.Errorf("expected %v removing a movie, got %v", ErrListReadOnly, err)
+	}
+	if _, err := listService.AddListMember(memberCtx, list.ID, "test@example.com", models.ListRoleEditor); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("expected only the owner to share the list, got %v", err)
+	}
+}
+
+func TestListService_EditorAddsAttributedMovies(t *testing.T) {
+	ownerCtx, memberCtx, listService, list := newListMemberTest(t, models.ListRoleEditor)
+
+	if err := listService.AddMovieToList(memberCtx, list.ID, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.AddMovieToList(ownerCtx, list.ID, 3, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.DeleteMovieFromList(memberCtx, list.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	details, err := listService.GetListDetails(ownerCtx, list.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !details.Collaborative() || details.MemberCount != 1 {
+		t.Errorf("expected the list to be shared with 1 member, got %d", details.MemberCount)
+	}
+	addedBy := map[int64]string{}
+	for _, movie := range details.Movies {
+		if movie.AddedBy != nil {
+			addedBy[movie.MovieDetails.Movie.ID] = *movie.AddedBy
+		}
+	}
+	if len(details.Movies) != 2 || addedBy[2] != "Member" || addedBy[3] != "Test User" {
+		t.Errorf("expected movies 2 and 3 added by Member and Test User, got %v", addedBy)
+	}
+}
+
+func TestListService_SharedListsInSidebar(t *testing.T) {
+	ownerCtx, memberCtx, listService, list := newListMemberTest(t, models.ListRoleViewer)
+
+	lists, err := listService.GetAllLists(memberCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 1 || lists[0].ID != list.ID || lists[0].Role != models.ListRoleViewer || lists[0].OwnerName != "Test User" {
+		t.Fatalf("expected the shared list with its owner, got %+v", lists)
+	}
+
+	member := mustUser(t, memberCtx)
+	if err := listService.SetListMemberRole(ownerCtx, list.ID, member.ID, models.ListRoleEditor); err != nil {
+		t.Fatal(err)
+	}
+	if lists, _ = listService.GetAllLists(memberCtx); lists[0].Role != models.ListRoleEditor {
+		t.Errorf("expected the member to become an editor, got %q", lists[0].Role)
+	}
+
+	if err := listService.LeaveList(memberCtx, list.ID); err != nil {
+		t.Fatal(err)
+	}
+	if lists, _ = listService.GetAllLists(memberCtx); len(lists) != 0 {
+		t.Errorf("expected no lists after leaving, got %+v", lists)
+	}
+	if _, err := listService.GetListDetails(memberCtx, list.ID); err == nil {
+		t.Error("expected the list not to be visible after leaving")
+	}
+}
+
+func TestListService_AddListMemberErrors(t *testing.T) {
+	ownerCtx, _, listService, list := newListMemberTest(t, models.ListRoleViewer)
+
+	if _, err := listService.AddListMember(ownerCtx, list.ID, "nobody@example.com", models.ListRoleViewer); !errors.Is(err, ErrListMemberNotFound) {
+		t.Errorf("expected %v, got %v", ErrListMemberNotFound, err)
+	}
+	if _, err := listService.AddListMember(ownerCtx, list.ID, "test@example.com", models.ListRoleViewer); !errors.Is(err, ErrListMemberIsOwner) {
+		t.Errorf("expected %v, got %v", ErrListMemberIsOwner, err)
+	}
+	if _, err := listService.AddListMember(ownerCtx, list.ID, "member@example.com", models.ListRoleOwner); !errors.Is(err, ErrInvalidListRole) {
+		t.Errorf("expected %v, got %v", ErrInvalidListRole, err)
+	}
+	if err := listService.RemoveListMember(ownerCtx, list.ID, 999); !errors.Is(err, ErrListMemberNotFound) {
+		t.Errorf("expected %v, got %v", ErrListMemberNotFound, err)
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var (
+	ErrListNotRanked       = errors.New("list is not ranked")
+	ErrInvalidListOrder    = errors.New("the order must contain every movie of the list exactly once")
+	ErrInvalidListPosition = errors.New("position must be at least 1")
+	ErrMovieNotInList      = errors.New("movie is not in the list")
+)
+
+// SetListRanked turns ranking of listID on or off. Turning it on ranks the movies in their
+// current order, so every movie has a position from then on.
+func (s *ListService) SetListRanked(ctx context.Context, listID int64, ranked bool) error {
+	s.log.Debug("setting list ranking", "listID", listID, "ranked", ranked)
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return err
+	}
+
+	if s.IsWatchlist(ctx, listID) {
+		s.log.Warn("attempted to rank watchlist", "listID", listID)
+		return ErrWatchlistNotEditable
+	}
+	if list, err := s.visibleList(ctx, listID); err == nil {
+		switch {
+		case ranked && list.Smart():
+			// smart lists are ordered by their rules
+			return ErrSmartListReadOnly
+		case list.Synced():
+			// synced lists keep the order of their source
+			return ErrSyncedListReadOnly
+		}
+	}
+
+	rows, err := s.db.SetListRanked(ctx, user.ID, listID, ranked)
+	if err != nil {
+		s.log.Error("failed to set list ranking", "listID", listID, "error", err)
+		return fmt.Errorf("failed to set list ranking: %w", err)
+	}
+	if rows == 0 {
+		return ErrListNotFound
+	}
+
+	if ranked {
+		list, err := s.GetListDetails(ctx, listID)
+		if err != nil {
+			return err
+		}
+		if err := s.db.ReorderList(ctx, user.ID, listID, listMovieIDs(list.Movies)); err != nil {
+			s.log.Error("failed to rank list movies", "listID", listID, "error", err)
+			return fmt.Errorf("failed to rank list movies: %w", err)
+		}
+	}
+
+	s.log.Info("successfully set list ranking", "listID", listID, "ranked", ranked)
+	return nil
+}
+
+// ReorderList ranks the movies of listID in the order of movieIDs, which must contain
+// every movie of the list exactly once
+func (s *ListService) ReorderList(ctx context.Context, listID int64, movieIDs []int64) error {
+	s.log.Debug("reordering list", "listID", listID, "movieCount", len(movieIDs))
+
+	list, err := s.rankedList(ctx, listID)
+	if err != nil {
+		return err
+	}
+
+	inList := make(map[int64]bool, len(list.Movies))
+	for _, movie := range list.Movies {
+		inList[movie.MovieDetails.Movie.ID] = true
+	}
+	if len(movieIDs) != len(inList) {
+		return ErrInvalidListOrder
+	}
+	for _, movieID := range movieIDs {
+		if !inList[movieID] {
+			return ErrInvalidListOrder
+		}
+		delete(inList, movieID)
+	}
+
+	return s.saveListOrder(ctx, listID, movieIDs)
+}
+
+// MoveListMovie moves movieID to position, starting from 1, in the ranking of listID.
+// Positions past the end of the list move the movie last.
+func (s *ListService) MoveListMovie(ctx context.Context, listID, movieID int64, position int) error {
+	if position < 1 {
+		return ErrInvalidListPosition
+	}
+	s.log.Debug("moving movie in list", "listID", listID, "movieID", movieID, "position", position)
+
+	list, err := s.rankedList(ctx, listID)
+	if err != nil {
+		return err
+	}
+
+	movieIDs := listMovieIDs(list.Movies)
+	from := -1
+	for i, id := range movieIDs {
+		if id == movieID {
+			from = i
+			break
+		}
+	}
+	if from == -1 {
+		return ErrMovieNotInList
+	}
+
+	movieIDs = append(movieIDs[:from], movieIDs[from+1:]...)
+	to := min(position-1, len(movieIDs))
+	movieIDs = append(movieIDs[:to], append([]int64{movieID}, movieIDs[to:]...)...)
+
+	return s.saveListOrder(ctx, listID, movieIDs)
+}
+
+// rankedList returns listID with its movies in rank order, or ErrListNotRanked
+func (s *ListService) rankedList(ctx context.Context, listID int64) (*models.List, error) {
+	list, err := s.editableList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if !list.Ranked {
+		return nil, ErrListNotRanked
+	}
+	return list, nil
+}
+
+func (s *ListService) saveListOrder(ctx context.Context, listID int64, movieIDs []int64) error {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return err
+	}
+
+	if err := s.db.ReorderList(ctx, user.ID, listID, movieIDs); err != nil {
+		s.log.Error("failed to reorder list", "listID", listID, "error", err)
+		return fmt.Errorf("failed to reorder list: %w", err)
+	}
+
+	s.log.Info("successfully reordered list", "listID", listID, "movieCount", len(movieIDs))
+	return nil
+}
+
+// sortMoviesByPosition orders movies by position, movies without one last, then by date
+// added, title and id
+func sortMoviesByPosition(movies []models.MovieItem) {
+	sort.SliceStable(movies, func(i, j int) bool {
+		left := movies[i]
+		right := movies[j]
+
+		leftHasPosition := left.Position != nil
+		rightHasPosition := right.Position != nil
+
+		switch {
+		case leftHasPosition && rightHasPosition:
+			if *left.Position != *right.Position {
+				return *left.Position < *right.Position
+			}
+		case leftHasPosition:
+			return true
+		case rightHasPosition:
+			return false
+		}
+
+		if !left.DateAdded.Equal(right.DateAdded) {
+			return left.DateAdded.Before(right.DateAdded)
+		}
+
+		if left.MovieDetails.Movie.Title != right.MovieDetails.Movie.Title {
+			return left.MovieDetails.Movie.Title < right.MovieDetails.Movie.Title
+		}
+
+		return left.MovieDetails.Movie.ID < right.MovieDetails.Movie.ID
+	})
+}
+
+func listMovieIDs(movies []models.MovieItem) []int64 {
+	ids := make([]int64, len(movies))
+	for i, movie := range movies {
+		ids[i] = movie.MovieDetails.Movie.ID
+	}
+	return ids
+}
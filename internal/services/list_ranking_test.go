@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+// newRankedListTest creates a list holding movies 1 to 4, added in that order
+func newRankedListTest(t *testing.T) (context.Context, *ListService, *models.List) {
+	t.Helper()
+
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+
+	listService := NewListService(testDB, nil, nil)
+	ctx := setupTestUser(t, testDB)
+
+	list, err := listService.CreateList(ctx, "Best of", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for id := int64(1); id <= 4; id++ {
+		if err := testDB.UpsertMovie(ctx, &models.MovieDetails{Movie: models.Movie{ID: id, Title: "Movie"}}); err != nil {
+			t.Fatal(err)
+		}
+		err := testDB.AddMovieToList(ctx, mustUser(t, ctx).ID, db.InsertMovieList{
+			MovieID:   id,
+			ListID:    list.ID,
+			DateAdded: added.AddDate(0, 0, int(id)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ctx, listService, list
+}
+
+func assertListOrder(t *testing.T, ctx context.Context, listService *ListService, listID int64, expected []int64) {
+	t.Helper()
+
+	list, err := listService.GetListDetails(ctx, listID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := listMovieIDs(list.Movies); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected order %v, got %v", expected, got)
+	}
+}
+
+func TestListService_RankedListReorder(t *testing.T) {
+	ctx, listService, list := newRankedListTest(t)
+
+	if err := listService.ReorderList(ctx, list.ID, []int64{4, 3, 2, 1}); !errors.Is(err, ErrListNotRanked) {
+		t.Fatalf("expected %v, got %v", ErrListNotRanked, err)
+	}
+
+	if err := listService.SetListRanked(ctx, list.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	assertListOrder(t, ctx, listService, list.ID, []int64{1, 2, 3, 4})
+
+	if err := listService.ReorderList(ctx, list.ID, []int64{4, 3, 2, 1}); err != nil {
+		t.Fatal(err)
+	}
+	assertListOrder(t, ctx, listService, list.ID, []int64{4, 3, 2, 1})
+
+	for _, order := range [][]int64{{4, 3, 2}, {4, 3, 2, 2}, {4, 3, 2, 9}} {
+		if err := listService.ReorderList(ctx, list.ID, order); !errors.Is(err, ErrInvalidListOrder) {
+			t.Errorf("expected %v for %v, got %v", ErrInvalidListOrder, order, err)
+		}
+	}
+	assertListOrder(t, ctx, listService, list.ID, []int64{4, 3, 2, 1})
+}
+
+func TestListService_MoveListMovie(t *testing.T) {
+	ctx, listService, list := newRankedListTest(t)
+	if err := listService.SetListRanked(ctx, list.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := listService.MoveListMovie(ctx, list.ID, 4, 1); err != nil {
+		t.Fatal(err)
+	}
+	assertListOrder(t, ctx, listService, list.ID, []int64{4, 1, 2, 3})
+
+	// positions past the end move the movie last
+	if err := listService.MoveListMovie(ctx, list.ID, 4, 99); err != nil {
+		t.Fatal(err)
+	}
+	assertListOrder(t, ctx, listService, list.ID, []int64{1, 2, 3, 4})
+
+	if err := listService.MoveListMovie(ctx, list.ID, 1, 0); !errors.Is(err, ErrInvalidListPosition) {
+		t.Errorf("expected %v, got %v", ErrInvalidListPosition, err)
+	}
+	if err := listService.MoveListMovie(ctx, list.ID, 9, 1); !errors.Is(err, ErrMovieNotInList) {
+		t.Errorf("expected %v, got %v", ErrMovieNotInList, err)
+	}
+}
+
+func TestListService_RankedListExportKeepsRankOrder(t *testing.T) {
+	ctx, listService, list := newRankedListTest(t)
+	if err := listService.SetListRanked(ctx, list.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.ReorderList(ctx, list.ID, []int64{3, 1, 4, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := listService.ExportLists(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 1 || !exported[0].Ranked {
+		t.Fatalf("expected one ranked list, got %+v", exported)
+	}
+	var order []int64
+	for _, movie := range exported[0].Movies {
+		order = append(order, movie.MovieID)
+	}
+	if !reflect.DeepEqual(order, []int64{3, 1, 4, 2}) {
+		t.Errorf("expected the export in rank order, got %v", order)
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+// ShareList makes listID viewable by anyone with its share link, creating the link when
+// the list is not shared yet. includeNotes shows the notes of the movies on the link.
+func (s *ListService) ShareList(ctx context.Context, listID int64, includeNotes bool) (*models.List, error) {
+	s.log.Debug("sharing list", "listID", listID, "includeNotes", includeNotes)
+
+	list, err := s.visibleList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	token := list.ShareToken
+	if token == nil {
+		newToken, err := generateSessionID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate share token: %w", err)
+		}
+		token = &newToken
+	}
+
+	return s.setListShare(ctx, listID, token, includeNotes)
+}
+
+// RegenerateShareLink replaces the share link of listID, the previous one stops working
+func (s *ListService) RegenerateShareLink(ctx context.Context, listID int64) (*models.List, error) {
+	s.log.Debug("regenerating list share link", "listID", listID)
+
+	list, err := s.visibleList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateSessionID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate share token: %w", err)
+	}
+	return s.setListShare(ctx, listID, &token, list.ShareNotes)
+}
+
+// UnshareList revokes the share link of listID
+func (s *ListService) UnshareList(ctx context.Context, listID int64) (*models.List, error) {
+	s.log.Debug("revoking list share link", "listID", listID)
+	return s.setListShare(ctx, listID, nil, false)
+}
+
+func (s *ListService) setListShare(ctx context.Context, listID int64, token *string, includeNotes bool) (*models.List, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	rows, err := s.db.SetListShare(ctx, user.ID, listID, token, includeNotes)
+	if err != nil {
+		s.log.Error("failed to set list share link", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to set list share link: %w", err)
+	}
+	if rows == 0 {
+		return nil, ErrListNotFound
+	}
+
+	s.log.Info("successfully updated list share link", "listID", listID, "shared", token != nil)
+	return s.GetListDetails(ctx, listID)
+}
+
+// GetSharedList returns the list shared with token for anyone to view. Notes are removed
+// unless the owner shares them, and so is the token itself.
+func (s *ListService) GetSharedList(ctx context.Context, token string) (*models.List, error) {
+	if token == "" {
+		return nil, ErrListNotFound
+	}
+
+	list, err := s.db.GetSharedList(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrListNotFound
+	}
+	if err != nil {
+		s.log.Error("failed to get shared list", "error", err)
+		return nil, fmt.Errorf("failed to get shared list: %w", err)
+	}
+
+	if list.Ranked {
+		sortMoviesByPosition(list.Movies)
+	}
+	if !list.ShareNotes {
+		for i := range list.Movies {
+			list.Movies[i].Note = nil
+		}
+	}
+	list.ShareToken = nil
+
+	s.log.Debug("fetched shared list", "listID", list.ID, "movieCount", len(list.Movies))
+	return list, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+// newSharedListTest creates a list holding movie 1, with note, for a user of testDB
+func newSharedListTest(t *testing.T, note string) (context.Context, db.DB, *ListService, *models.List) {
+	t.Helper()
+
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+
+	listService := NewListService(testDB, nil, nil)
+	ctx := setupTestUser(t, testDB)
+
+	list, err := listService.CreateList(ctx, "Movie night", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.UpsertMovie(ctx, &models.MovieDetails{Movie: models.Movie{ID: 1, Title: "Movie"}}); err != nil {
+		t.Fatal(err)
+	}
+	err = testDB.AddMovieToList(ctx, mustUser(t, ctx).ID, db.InsertMovieList{
+		MovieID:   1,
+		ListID:    list.ID,
+		DateAdded: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Note:      &note,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx, testDB, listService, list
+}
+
+func TestListService_ShareList(t *testing.T) {
+	note := "with friends"
+	ctx, _, listService, list := newSharedListTest(t, note)
+
+	shared, err := listService.ShareList(ctx, list.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shared.ShareToken == nil || len(*shared.ShareToken) < 32 {
+		t.Fatalf("expected an unguessable share token, got %v", shared.ShareToken)
+	}
+	token := *shared.ShareToken
+
+	public, err := listService.GetSharedList(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public.ID != list.ID || len(public.Movies) != 1 {
+		t.Fatalf("expected list %d with its movie, got %d with %d", list.ID, public.ID, len(public.Movies))
+	}
+	if public.ShareToken != nil {
+		t.Error("expected the shared list not to expose its token")
+	}
+	for _, movie := range public.Movies {
+		if movie.Note != nil {
+			t.Errorf("expected notes to be hidden, movie %d has %q", movie.MovieDetails.Movie.ID, *movie.Note)
+		}
+	}
+
+	// sharing again keeps the link and only changes whether notes are shown
+	shared, err = listService.ShareList(ctx, list.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *shared.ShareToken != token {
+		t.Error("expected sharing again to keep the link")
+	}
+	public, err = listService.GetSharedList(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := public.Movies[0].Note; got == nil || *got != note {
+		t.Errorf("expected the note %q to be shared, got %v", note, got)
+	}
+}
+
+func TestListService_RegenerateAndRevokeShareLink(t *testing.T) {
+	ctx, _, listService, list := newSharedListTest(t, "")
+
+	shared, err := listService.ShareList(ctx, list.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken := *shared.ShareToken
+
+	regenerated, err := listService.RegenerateShareLink(ctx, list.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *regenerated.ShareToken == oldToken || !regenerated.ShareNotes {
+		t.Fatal("expected a new link that keeps sharing notes")
+	}
+	if _, err := listService.GetSharedList(context.Background(), oldToken); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("expected the old link to stop working, got %v", err)
+	}
+	if _, err := listService.GetSharedList(context.Background(), *regenerated.ShareToken); err != nil {
+		t.Errorf("expected the new link to work, got %v", err)
+	}
+
+	revoked, err := listService.UnshareList(ctx, list.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked.ShareToken != nil {
+		t.Error("expected the list not to be shared anymore")
+	}
+	if _, err := listService.GetSharedList(context.Background(), *regenerated.ShareToken); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("expected the revoked link to stop working, got %v", err)
+	}
+	if _, err := listService.GetSharedList(context.Background(), ""); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("expected %v for an empty token, got %v", ErrListNotFound, err)
+	}
+}
+
+func TestListService_ShareListOfAnotherUser(t *testing.T) {
+	ctx, testDB, listService, list := newSharedListTest(t, "")
+
+	other, err := testDB.CreateUser(ctx, "other@example.com", "Other User", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCtx := context.WithValue(context.Background(), common.UserKey, other)
+
+	if _, err := listService.ShareList(otherCtx, list.ID, false); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("expected %v, got %v", ErrListNotFound, err)
+	}
+	if _, err := listService.UnshareList(otherCtx, list.ID); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("expected %v, got %v", ErrListNotFound, err)
+	}
+}
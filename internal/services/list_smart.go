@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var (
+	ErrSmartListReadOnly = errors.New("the movies of a smart list come from its rules")
+	ErrInvalidListRules  = errors.New("invalid smart list rules")
+)
+
+// CreateSmartList creates a list whose movies are the ones of the user's library matching
+// rules
+func (s *ListService) CreateSmartList(ctx context.Context, name string, description *string, rules models.ListRules) (*models.List, error) {
+	rules, err := normalizeListRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.CreateList(ctx, name, description, false)
+	if err != nil {
+		return nil, err
+	}
+
+	smart, err := s.SetListRules(ctx, list.ID, rules)
+	if err != nil {
+		// do not leave an empty list behind for rules that could not be saved
+		if deleteErr := s.DeleteList(ctx, list.ID); deleteErr != nil {
+			s.log.Error("failed to delete smart list without rules", "listID", list.ID, "error", deleteErr)
+		}
+		return nil, err
+	}
+
+	s.log.Info("successfully created smart list", "listID", smart.ID, "movieCount", len(smart.Movies))
+	return smart, nil
+}
+
+// SetListRules replaces the rules of listID, turning it into a smart list. The movies
+// added to it by hand are kept but not shown while it has rules. Only the owner can change
+// the rules of a list, and the watchlist cannot have any.
+func (s *ListService) SetListRules(ctx context.Context, listID int64, rules models.ListRules) (*models.List, error) {
+	rules, err := normalizeListRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Debug("setting list rules", "listID", listID)
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	if s.IsWatchlist(ctx, listID) {
+		s.log.Warn("attempted to set rules on watchlist", "listID", listID)
+		return nil, ErrWatchlistNotEditable
+	}
+
+	rows, err := s.db.SetListRules(ctx, user.ID, listID, rules)
+	if err != nil {
+		s.log.Error("failed to set list rules", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to set list rules: %w", err)
+	}
+	if rows == 0 {
+		return nil, ErrListNotFound
+	}
+
+	// smart lists are ordered by their rules, not ranked by hand
+	if _, err := s.db.SetListRanked(ctx, user.ID, listID, false); err != nil {
+		s.log.Error("failed to stop ranking smart list", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to stop ranking smart list: %w", err)
+	}
+
+	s.log.Info("successfully set list rules", "listID", listID)
+	return s.GetListDetails(ctx, listID)
+}
+
+// GetGenres returns the genres smart lists can filter by
+func (s *ListService) GetGenres(ctx context.Context) ([]models.Genre, error) {
+	genres, err := s.db.GetGenres(ctx)
+	if err != nil {
+		s.log.Error("failed to get genres", "error", err)
+		return nil, fmt.Errorf("failed to get genres: %w", err)
+	}
+	return genres, nil
+}
+
+// normalizeListRules trims the rules and checks that they make sense together. At least
+// one rule must be set, a smart list without rules would hold the whole library.
+func normalizeListRules(rules models.ListRules) (models.ListRules, error) {
+	if rules.PersonName != nil {
+		name := strings.TrimSpace(*rules.PersonName)
+		rules.PersonName = &name
+		if name == "" {
+			rules.PersonName = nil
+		}
+	}
+
+	if rules == (models.ListRules{}) {
+		return rules, fmt.Errorf("%w: set at least one rule", ErrInvalidListRules)
+	}
+
+	watchRule := rules.InTheater != nil || rules.WatchedYear != nil || rules.MinRating != nil
+	if rules.Watched != nil && !*rules.Watched && watchRule {
+		return rules, fmt.Errorf("%w: unwatched movies have no watches to match", ErrInvalidListRules)
+	}
+	for _, rating := range []*float64{rules.MinRating, rules.DirectorMinRating} {
+		if rating != nil && (*rating < 0 || *rating > maxMovieRating) {
+			return rules, fmt.Errorf("%w: ratings are between 0 and %.0f", ErrInvalidListRules, maxMovieRating)
+		}
+	}
+	for _, value := range []*int64{rules.MinRuntime, rules.MaxRuntime, rules.WatchedYear, rules.ReleaseYearFrom, rules.ReleaseYearTo} {
+		if value != nil && *value < 0 {
+			return rules, fmt.Errorf("%w: runtimes and years cannot be negative", ErrInvalidListRules)
+		}
+	}
+	if rules.MinRuntime != nil && rules.MaxRuntime != nil && *rules.MinRuntime > *rules.MaxRuntime {
+		return rules, fmt.Errorf("%w: the minimum runtime is over the maximum", ErrInvalidListRules)
+	}
+	if rules.ReleaseYearFrom != nil && rules.ReleaseYearTo != nil && *rules.ReleaseYearFrom > *rules.ReleaseYearTo {
+		return rules, fmt.Errorf("%w: the release years are reversed", ErrInvalidListRules)
+	}
+	return rules, nil
+}
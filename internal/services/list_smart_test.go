@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+// newSmartListTest fills a library with four movies:
+//   - 1, a 90 minute drama by director 10, watched in theater in 2025 and rated 4.5
+//   - 2, a 150 minute drama by director 10, watched at home in 2025 and rated 3
+//   - 3, a 95 minute comedy by director 10 with actor 20, on the watchlist and unwatched
+//   - 4, a 120 minute comedy by director 11, on the watchlist and unwatched
+func newSmartListTest(t *testing.T) (context.Context, *ListService) {
+	t.Helper()
+
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+
+	listService := NewListService(testDB, nil, nil)
+	ctx := setupTestUser(t, testDB)
+	userID := mustUser(t, ctx).ID
+
+	drama := models.Genre{ID: 18, Name: "Drama"}
+	comedy := models.Genre{ID: 35, Name: "Comedy"}
+	director := func(id int64) models.Crew {
+		return models.Crew{PersonID: id, CreditID: "director", Job: "Director", Department: "Directing", Person: models.Person{ID: id, Name: "Director"}}
+	}
+	movies := []models.MovieDetails{
+		{Movie: models.Movie{ID: 1, Title: "Theater Drama"}, Runtime: 90, Genres: []models.Genre{drama}, Credits: models.MovieCredits{Crew: []models.Crew{director(10)}}},
+		{Movie: models.Movie{ID: 2, Title: "Long Drama"}, Runtime: 150, Genres: []models.Genre{drama}, Credits: models.MovieCredits{Crew: []models.Crew{director(10)}}},
+		{Movie: models.Movie{ID: 3, Title: "Short Comedy"}, Runtime: 95, Genres: []models.Genre{comedy}, Credits: models.MovieCredits{
+			Crew: []models.Crew{director(10)},
+			Cast: []models.Cast{{PersonID: 20, CastID: 1, CreditID: "actor", Person: models.Person{ID: 20, Name: "Jane Actor"}}},
+		}},
+		{Movie: models.Movie{ID: 4, Title: "Other Comedy"}, Runtime: 120, Genres: []models.Genre{comedy}, Credits: models.MovieCredits{Crew: []models.Crew{director(11)}}},
+	}
+	for i := range movies {
+		// credits belong to the movie they are stored with
+		for j := range movies[i].Credits.Crew {
+			movies[i].Credits.Crew[j].MovieID = movies[i].Movie.ID
+		}
+		for j := range movies[i].Credits.Cast {
+			movies[i].Credits.Cast[j].MovieID = movies[i].Movie.ID
+		}
+		if err := testDB.UpsertMovie(ctx, &movies[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	high, low := 4.5, 3.0
+	watches := []db.InsertWatched{
+		{UserID: userID, MovieID: 1, Date: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), InTheaters: true, Rating: &high},
+		{UserID: userID, MovieID: 2, Date: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), Rating: &low},
+	}
+	for _, watch := range watches {
+		if err := testDB.InsertWatched(ctx, watch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	watchlist, err := listService.CreateList(ctx, "Watchlist", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, movieID := range []int64{3, 4} {
+		if err := listService.AddMovieToList(ctx, watchlist.ID, movieID, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ctx, listService
+}
+
+func smartListMovieIDs(t *testing.T, list *models.List) []int64 {
+	t.Helper()
+
+	ids := listMovieIDs(list.Movies)
+	if ids == nil {
+		ids = []int64{}
+	}
+	return ids
+}
+
+func TestListService_SmartListRules(t *testing.T) {
+	ctx, listService := newSmartListTest(t)
+
+	watched, unwatched, inTheater := true, false, true
+	year, rating, maxRuntime, genre := int64(2025), 4.0, int64(100), int64(35)
+	person := "  jane actor "
+	// the average rating of director 10, (4.5 + 3) / 2
+	directorRating := 3.75
+
+	tests := []struct {
+		name  string
+		rules models.ListRules
+		want  []int64
+	}{
+		{"watched in theater in 2025 rated at least 4", models.ListRules{InTheater: &inTheater, WatchedYear: &year, MinRating: &rating}, []int64{1}},
+		{"watched, most recent first", models.ListRules{Watched: &watched}, []int64{2, 1}},
+		{"unwatched by directors rated highly", models.ListRules{Watched: &unwatched, DirectorMinRating: &directorRating}, []int64{3}},
+		{"watchlist under 100 minutes", models.ListRules{InWatchlist: &watched, MaxRuntime: &maxRuntime}, []int64{3}},
+		{"genre", models.ListRules{GenreID: &genre}, []int64{4, 3}},
+		{"person in the cast", models.ListRules{PersonName: &person}, []int64{3}},
+		{"nothing matches", models.ListRules{Watched: &watched, GenreID: &genre}, []int64{}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := listService.CreateSmartList(ctx, "Smart "+string(rune('A'+i)), nil, tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !list.Smart() {
+				t.Fatal("expected a smart list")
+			}
+			if got := smartListMovieIDs(t, list); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected movies %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListService_SmartListOnlyPicksOwnerLibrary(t *testing.T) {
+	ctx, listService := newSmartListTest(t)
+
+	// another user looking a comedy up caches it, and watching it puts it in their library
+	other, err := listService.db.CreateUser(context.Background(), "other@example.com", "Other", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	comedy := models.MovieDetails{Movie: models.Movie{ID: 5, Title: "Cached Comedy"}, Runtime: 100, Genres: []models.Genre{{ID: 35, Name: "Comedy"}}}
+	if err := listService.db.UpsertMovie(ctx, &comedy); err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.db.InsertWatched(ctx, db.InsertWatched{UserID: other.ID, MovieID: 5, Date: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+
+	genre := int64(35)
+	list, err := listService.CreateSmartList(ctx, "Comedies", nil, models.ListRules{GenreID: &genre})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := smartListMovieIDs(t, list); !reflect.DeepEqual(got, []int64{4, 3}) {
+		t.Errorf("expected only the comedies of the owner, got %v", got)
+	}
+}
+
+func TestListService_SmartListIsReadOnly(t *testing.T) {
+	ctx, listService := newSmartListTest(t)
+
+	watched := true
+	list, err := listService.CreateSmartList(ctx, "Watched", nil, models.ListRules{Watched: &watched})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := listService.AddMovieToList(ctx, list.ID, 3, nil); !errors.Is(err, ErrSmartListReadOnly) {
+		t.Errorf("expected %v adding a movie, got %v", ErrSmartListReadOnly, err)
+	}
+	if err := listService.SetListRanked(ctx, list.ID, true); !errors.Is(err, ErrSmartListReadOnly) {
+		t.Errorf("expected %v ranking the list, got %v", ErrSmartListReadOnly, err)
+	}
+
+	lists, err := listService.GetAllLists(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 1 || !lists[0].Smart {
+		t.Errorf("expected the smart list in the sidebar, got %+v", lists)
+	}
+
+	// changing the rules changes the movies
+	genre := int64(35)
+	updated, err := listService.SetListRules(ctx, list.ID, models.ListRules{GenreID: &genre})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := smartListMovieIDs(t, updated); !reflect.DeepEqual(got, []int64{4, 3}) {
+		t.Errorf("expected the comedies, got %v", got)
+	}
+}
+
+func TestListService_InvalidSmartListRules(t *testing.T) {
+	ctx, listService := newSmartListTest(t)
+
+	unwatched, blank := false, " "
+	rating, tooHigh := 4.0, 6.0
+	from, to := int64(2020), int64(2000)
+
+	for name, rules := range map[string]models.ListRules{
+		"no rules":               {PersonName: &blank},
+		"rated but unwatched":    {Watched: &unwatched, MinRating: &rating},
+		"rating over the scale":  {MinRating: &tooHigh},
+		"release years reversed": {ReleaseYearFrom: &from, ReleaseYearTo: &to},
+	} {
+		if _, err := listService.CreateSmartList(ctx, "Invalid", nil, rules); !errors.Is(err, ErrInvalidListRules) {
+			t.Errorf("%s: expected %v, got %v", name, ErrInvalidListRules, err)
+		}
+	}
+
+	lists, err := listService.GetAllLists(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 0 {
+		t.Errorf("expected invalid rules not to create lists, got %+v", lists)
+	}
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,7 +30,7 @@ func TestListService_CRUD(t *testing.T) {
 	defer func() { _ = testDB.Close() }()
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
+	listService := NewListService(testDB, movieService, nil)
 
 	ctx := setupTestUser(t, testDB)
 
@@ -99,7 +101,7 @@ func TestListService_CreateList_EmptyName(t *testing.T) {
 	defer func() { _ = testDB.Close() }()
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
+	listService := NewListService(testDB, movieService, nil)
 
 	ctx := setupTestUser(t, testDB)
 
@@ -119,7 +121,7 @@ func TestListService_AddMovieToList_InvalidIDs(t *testing.T) {
 	defer func() { _ = testDB.Close() }()
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
+	listService := NewListService(testDB, movieService, nil)
 
 	ctx := setupTestUser(t, testDB)
 
@@ -153,7 +155,7 @@ func TestListService_ImportLists_MergesByNameAndPreservesMetadata(t *testing.T)
 	defer func() { _ = testDB.Close() }()
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
+	listService := NewListService(testDB, movieService, nil)
 	ctx := setupTestUser(t, testDB)
 
 	for i := 1; i <= 2; i++ {
@@ -249,7 +251,7 @@ func TestListService_ExportLists_IsStable(t *testing.T) {
 	defer func() { _ = testDB.Close() }()
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
+	listService := NewListService(testDB, movieService, nil)
 	ctx := setupTestUser(t, testDB)
 
 	for i := 1; i <= 2; i++ {
@@ -305,7 +307,7 @@ func TestListService_ImportLists_SkipsMovieFailuresAndContinues(t *testing.T) {
 	defer func() { _ = testDB.Close() }()
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
+	listService := NewListService(testDB, movieService, nil)
 	ctx := setupTestUser(t, testDB)
 
 	validMovie := &models.MovieDetails{
@@ -360,7 +362,7 @@ func TestListService_ImportLists_SkipsInvalidListAndContinues(t *testing.T) {
 	defer func() { _ = testDB.Close() }()
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
+	listService := NewListService(testDB, movieService, nil)
 	ctx := setupTestUser(t, testDB)
 
 	validMovie := &models.MovieDetails{
@@ -403,3 +405,157 @@ func TestListService_ImportLists_SkipsInvalidListAndContinues(t *testing.T) {
 		t.Fatalf("expected list name 'Valid List', got %q", lists[0].Name)
 	}
 }
+
+func TestListService_UpdateList(t *testing.T) {
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = testDB.Close() }()
+
+	listService := NewListService(testDB, nil, nil)
+	ctx := setupTestUser(t, testDB)
+
+	list, err := listService.CreateList(ctx, "Favorites", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listService.CreateList(ctx, "Horror", nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := listService.UpdateList(ctx, list.ID, "  All-time favorites ", "The best ones")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "All-time favorites" || updated.Description == nil || *updated.Description != "The best ones" {
+		t.Errorf("unexpected list after update: %q %v", updated.Name, updated.Description)
+	}
+
+	// an empty description removes it, and keeping the same name is not a conflict
+	updated, err = listService.UpdateList(ctx, list.ID, "All-time favorites", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Description != nil {
+		t.Errorf("expected the description to be removed, got %q", *updated.Description)
+	}
+
+	if _, err := listService.UpdateList(ctx, list.ID, "Horror", ""); !errors.Is(err, ErrListNameTaken) {
+		t.Errorf("expected %v, got %v", ErrListNameTaken, err)
+	}
+	if _, err := listService.CreateList(ctx, "Horror", nil, false); !errors.Is(err, ErrListNameTaken) {
+		t.Errorf("expected %v when creating a duplicate, got %v", ErrListNameTaken, err)
+	}
+	if _, err := listService.UpdateList(ctx, list.ID, " ", ""); !errors.Is(err, ErrInvalidListName) {
+		t.Errorf("expected %v, got %v", ErrInvalidListName, err)
+	}
+	if _, err := listService.UpdateList(ctx, list.ID, "Name", strings.Repeat("a", 501)); !errors.Is(err, ErrInvalidListDescription) {
+		t.Errorf("expected %v, got %v", ErrInvalidListDescription, err)
+	}
+	// creating a list is held to the same limits, counted in characters
+	if _, err := listService.CreateList(ctx, strings.Repeat("a", 101), nil, false); !errors.Is(err, ErrInvalidListName) {
+		t.Errorf("expected %v creating a list, got %v", ErrInvalidListName, err)
+	}
+	longDescription := strings.Repeat("a", 501)
+	if _, err := listService.CreateList(ctx, "Long", &longDescription, false); !errors.Is(err, ErrInvalidListDescription) {
+		t.Errorf("expected %v creating a list, got %v", ErrInvalidListDescription, err)
+	}
+	created, err := listService.CreateList(ctx, " "+strings.Repeat("é", 100)+" ", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Name != strings.Repeat("é", 100) {
+		t.Errorf("expected the name to be trimmed, got %q", created.Name)
+	}
+	if _, err := listService.UpdateList(ctx, 9999, "Missing", ""); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("expected %v, got %v", ErrListNotFound, err)
+	}
+
+	if err := listService.EnsureWatchlistExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	watchlist, err := listService.GetWatchlist(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listService.UpdateList(ctx, watchlist.ID, "Later", ""); !errors.Is(err, ErrWatchlistNotEditable) {
+		t.Errorf("expected %v, got %v", ErrWatchlistNotEditable, err)
+	}
+}
+
+func TestListService_GetMovieLists(t *testing.T) {
+	ownerCtx, memberCtx, listService, shared := newListMemberTest(t, models.ListRoleViewer)
+
+	watchlist, err := listService.CreateList(memberCtx, "Watchlist", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	own, err := listService.CreateList(memberCtx, "Favorites", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := listService.CreateList(memberCtx, "Other", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := "rewatch soon"
+	if err := listService.AddMovieToList(memberCtx, own.ID, 1, &note); err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.AddMovieToList(memberCtx, watchlist.ID, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.AddMovieToList(memberCtx, other.ID, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	inWatchlist, notInWatchlist := true, false
+	smart, err := listService.CreateSmartList(memberCtx, "Queued", nil, models.ListRules{InWatchlist: &inWatchlist})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unqueued, err := listService.CreateSmartList(memberCtx, "Unqueued", nil, models.ListRules{InWatchlist: &notInWatchlist})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lists, err := listService.GetMovieLists(memberCtx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the watchlist comes first, then lists shared with the user and the matching smart lists last
+	if len(lists) != 4 || lists[0].List.ID != watchlist.ID || lists[1].List.ID != own.ID || lists[2].List.ID != shared.ID || lists[3].List.ID != smart.ID {
+		t.Fatalf("expected the watchlist, Favorites, the shared list and Queued, got %+v", lists)
+	}
+	if !lists[3].List.Smart || lists[3].List.Role != models.ListRoleOwner {
+		t.Errorf("expected the owned smart list matching the movie, got %+v", lists[3].List)
+	}
+	// each smart list only shows up for the movies its own rules match
+	if lists, err := listService.GetMovieLists(memberCtx, 2); err != nil || len(lists) != 2 || lists[0].List.ID != other.ID || lists[1].List.ID != unqueued.ID {
+		t.Errorf("expected Other and Unqueued, got %+v and %v", lists, err)
+	}
+	if !lists[0].IsWatchlist || lists[1].IsWatchlist {
+		t.Errorf("expected only the first list to be the watchlist, got %+v", lists)
+	}
+	if lists[1].Note == nil || *lists[1].Note != note || lists[1].List.Role != models.ListRoleOwner {
+		t.Errorf("expected the note of an owned list, got %+v", lists[1])
+	}
+	if lists[1].DateAdded.IsZero() {
+		t.Error("expected the date the movie was added")
+	}
+	if lists[2].List.Role != models.ListRoleViewer || lists[2].List.OwnerName != "Test User" {
+		t.Errorf("expected the list shared by Test User as a viewer, got %+v", lists[2].List)
+	}
+
+	// lists of other users are not shown unless shared
+	lists, err = listService.GetMovieLists(ownerCtx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 1 || lists[0].List.ID != shared.ID || lists[0].List.OwnerName != "" {
+		t.Errorf("expected only the owned shared list, got %+v", lists)
+	}
+	if lists, err := listService.GetMovieLists(memberCtx, 3); err != nil || len(lists) != 0 {
+		t.Errorf("expected no list for a movie in none, got %+v and %v", lists, err)
+	}
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var (
+	ErrInvalidTMDBSource  = errors.New("not a TMDB list or collection")
+	ErrSyncedListReadOnly = errors.New("the movies of a synced list come from TMDB")
+	ErrListNotImported    = errors.New("the list was not imported from TMDB")
+	ErrListNotSynced      = errors.New("the list is not synced with TMDB")
+)
+
+// ParseTMDBSource reads the TMDB list or collection ref points to: the URL of its page,
+// like https://www.themoviedb.org/collection/10-star-wars-collection, or the ID of a list
+func ParseTMDBSource(ref string) (models.ListSourceKind, int64, error) {
+	ref = strings.TrimSpace(ref)
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil && id > 0 {
+		return models.ListSourceTMDBList, id, nil
+	}
+
+	if !strings.Contains(ref, "://") {
+		ref = "https://" + ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil || (u.Hostname() != "themoviedb.org" && !strings.HasSuffix(u.Hostname(), ".themoviedb.org")) {
+		return "", 0, fmt.Errorf("%w: %q", ErrInvalidTMDBSource, ref)
+	}
+
+	// the path is the kind and the ID, followed by a slug: /list/8250-best-of
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 {
+		return "", 0, fmt.Errorf("%w: %q", ErrInvalidTMDBSource, ref)
+	}
+	kind := models.ListSourceKind(parts[0])
+	if kind != models.ListSourceTMDBList && kind != models.ListSourceTMDBCollection {
+		return "", 0, fmt.Errorf("%w: %q", ErrInvalidTMDBSource, ref)
+	}
+	digits := strings.IndexFunc(parts[1], func(r rune) bool { return !unicode.IsDigit(r) })
+	if digits == -1 {
+		digits = len(parts[1])
+	}
+	id, err := strconv.ParseInt(parts[1][:digits], 10, 64)
+	if err != nil || id <= 0 {
+		return "", 0, fmt.Errorf("%w: %q", ErrInvalidTMDBSource, ref)
+	}
+	return kind, id, nil
+}
+
+// SearchTMDBCollections searches TMDB for the collections that can be imported as lists
+func (s *ListService) SearchTMDBCollections(ctx context.Context, query string) ([]models.TMDBCollection, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.tmdb.SearchCollections(query)
+}
+
+// ImportTMDBList creates a list with the movies of a TMDB list or collection, ranked in
+// their order there. The list takes the name and description of its source unless name is
+// set. Synced lists keep following their source, see SyncList. Movies whose details cannot
+// be fetched are left out, a later sync retries them.
+func (s *ListService) ImportTMDBList(ctx context.Context, kind models.ListSourceKind, tmdbID int64, name string, sync bool) (*models.List, error) {
+	s.log.Debug("importing TMDB list", "kind", kind, "tmdbID", tmdbID, "sync", sync)
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	source, err := s.tmdb.GetTMDBList(kind, tmdbID)
+	if err != nil {
+		s.log.Error("failed to fetch TMDB list", "kind", kind, "tmdbID", tmdbID, "error", err)
+		return nil, fmt.Errorf("failed to fetch TMDB list: %w", err)
+	}
+
+	if name == "" {
+		name = truncateRunes(strings.TrimSpace(source.Name), maxListNameLength)
+	}
+	var description *string
+	if overview := truncateRunes(strings.TrimSpace(source.Description), maxListDescriptionLength); overview != "" {
+		description = &overview
+	}
+
+	list, err := s.CreateList(ctx, name, description, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.importTMDBSource(ctx, user.ID, list, source, sync); err != nil {
+		// do not leave half an import behind
+		if deleteErr := s.DeleteList(ctx, list.ID); deleteErr != nil {
+			s.log.Error("failed to delete partial TMDB import", "listID", list.ID, "error", deleteErr)
+		}
+		return nil, err
+	}
+
+	s.log.Info("successfully imported TMDB list", "listID", list.ID, "kind", kind, "tmdbID", tmdbID)
+	return s.GetListDetails(ctx, list.ID)
+}
+
+func (s *ListService) importTMDBSource(ctx context.Context, userID int64, list *models.List, source *models.TMDBList, sync bool) error {
+	// the movies keep the order of the source
+	if _, err := s.db.SetListRanked(ctx, userID, list.ID, true); err != nil {
+		s.log.Error("failed to rank imported list", "listID", list.ID, "error", err)
+		return fmt.Errorf("failed to rank imported list: %w", err)
+	}
+	rows, err := s.db.SetListSource(ctx, userID, list.ID, models.ListSource{
+		Kind:     source.Kind,
+		TMDBID:   source.ID,
+		Sync:     sync,
+		SyncedAt: time.Now(),
+	})
+	if err != nil {
+		s.log.Error("failed to set list source", "listID", list.ID, "error", err)
+		return fmt.Errorf("failed to set list source: %w", err)
+	}
+	if rows == 0 {
+		return ErrListNotFound
+	}
+	_, _, err = s.syncListMovies(ctx, userID, list, source)
+	return err
+}
+
+// SetListSync starts or stops keeping listID in sync with the TMDB list or collection it
+// was imported from. Starting syncs it right away. Only the owner can change it.
+func (s *ListService) SetListSync(ctx context.Context, listID int64, sync bool) (*models.List, error) {
+	s.log.Debug("setting list sync", "listID", listID, "sync", sync)
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	list, err := s.visibleList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if list.Role != models.ListRoleOwner {
+		return nil, ErrListNotFound
+	}
+	if list.Source == nil {
+		return nil, ErrListNotImported
+	}
+
+	if _, err := s.db.SetListSourceSync(ctx, user.ID, listID, sync); err != nil {
+		s.log.Error("failed to set list sync", "listID", listID, "error", err)
+		return nil, fmt.Errorf("failed to set list sync: %w", err)
+	}
+	if sync {
+		if _, _, err := s.SyncList(ctx, listID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.log.Info("successfully set list sync", "listID", listID, "sync", sync)
+	return s.GetListDetails(ctx, listID)
+}
+
+// SyncList updates the movies of a synced list to the ones of its TMDB source, in its
+// order, returning how many movies were added and removed. Only the owner can sync a list.
+func (s *ListService) SyncList(ctx context.Context, listID int64) (int, int, error) {
+	s.log.Debug("syncing list with TMDB", "listID", listID)
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return 0, 0, err
+	}
+
+	list, err := s.visibleList(ctx, listID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if list.Role != models.ListRoleOwner {
+		return 0, 0, ErrListNotFound
+	}
+	if !list.Synced() {
+		return 0, 0, ErrListNotSynced
+	}
+
+	source, err := s.tmdb.GetTMDBList(list.Source.Kind, list.Source.TMDBID)
+	if err != nil {
+		s.log.Error("failed to fetch TMDB list", "listID", listID, "error", err)
+		return 0, 0, fmt.Errorf("failed to fetch TMDB list: %w", err)
+	}
+
+	if !list.Ranked {
+		if _, err := s.db.SetListRanked(ctx, user.ID, listID, true); err != nil {
+			s.log.Error("failed to rank synced list", "listID", listID, "error", err)
+			return 0, 0, fmt.Errorf("failed to rank synced list: %w", err)
+		}
+	}
+	return s.syncListMovies(ctx, user.ID, list, source)
+}
+
+// SyncTMDBLists syncs every list kept in sync with TMDB on behalf of its owner, returning
+// how many were synced. A list that fails to sync does not stop the others.
+func (s *ListService) SyncTMDBLists(ctx context.Context) (int, error) {
+	sources, err := s.db.GetSyncedListSources(ctx)
+	if err != nil {
+		s.log.Error("failed to get synced lists", "error", err)
+		return 0, fmt.Errorf("failed to get synced lists: %w", err)
+	}
+
+	synced := 0
+	for _, source := range sources {
+		owner, err := s.db.GetUserByID(ctx, source.UserID)
+		if err != nil {
+			s.log.Error("failed to get owner of synced list", "listID", source.ListID, "userID", source.UserID, "error", err)
+			continue
+		}
+		if owner.Disabled() {
+			continue
+		}
+
+		ownerCtx := context.WithValue(ctx, common.UserKey, owner)
+		added, removed, err := s.SyncList(ownerCtx, source.ListID)
+		if err != nil {
+			s.log.Error("failed to sync list with TMDB", "listID", source.ListID, "error", err)
+			continue
+		}
+		s.log.Debug("synced list with TMDB", "listID", source.ListID, "added", added, "removed", removed)
+		synced++
+	}
+	return synced, nil
+}
+
+// syncListMovies adds the movies of source missing from list, removes the ones source
+// dropped and ranks the rest in the order of source
+func (s *ListService) syncListMovies(ctx context.Context, userID int64, list *models.List, source *models.TMDBList) (int, int, error) {
+	current := make(map[int64]bool, len(list.Movies))
+	for _, movieID := range listMovieIDs(list.Movies) {
+		current[movieID] = true
+	}
+
+	now := time.Now()
+	inSource := make(map[int64]bool, len(source.MovieIDs))
+	var order, added []int64
+	for _, movieID := range source.MovieIDs {
+		if inSource[movieID] {
+			// TMDB lists can hold a movie twice
+			continue
+		}
+		inSource[movieID] = true
+
+		if !current[movieID] {
+			// the movie must be in the library before it can be added
+			if _, err := s.tmdb.GetMovieDetails(ctx, movieID); err != nil {
+				s.log.Warn("skipping TMDB list movie without details", "listID", list.ID, "movieID", movieID, "error", err)
+				continue
+			}
+			err := s.db.AddMovieToList(ctx, userID, db.InsertMovieList{
+				MovieID:   movieID,
+				ListID:    list.ID,
+				DateAdded: now,
+			})
+			if err != nil {
+				s.log.Error("failed to add TMDB list movie", "listID", list.ID, "movieID", movieID, "error", err)
+				return 0, 0, fmt.Errorf("failed to add movie '%d' to list '%d': %w", movieID, list.ID, err)
+			}
+			added = append(added, movieID)
+		}
+		order = append(order, movieID)
+	}
+
+	var dropped, removed []int64
+	for _, movieID := range listMovieIDs(list.Movies) {
+		if !inSource[movieID] {
+			dropped = append(dropped, movieID)
+		}
+	}
+	if len(dropped) > 0 {
+		var err error
+		if removed, err = s.db.DeleteListMovies(ctx, userID, list.ID, dropped); err != nil {
+			s.log.Error("failed to remove movies dropped from TMDB list", "listID", list.ID, "error", err)
+			return 0, 0, fmt.Errorf("failed to remove movies dropped from TMDB list: %w", err)
+		}
+	}
+
+	if err := s.db.ReorderList(ctx, userID, list.ID, order); err != nil {
+		s.log.Error("failed to rank synced list", "listID", list.ID, "error", err)
+		return 0, 0, fmt.Errorf("failed to rank synced list: %w", err)
+	}
+	if err := s.db.SetListSourceSyncedAt(ctx, list.ID, now); err != nil {
+		return 0, 0, err
+	}
+
+	s.enqueueListItems(ctx, models.WebhookEventListItemAdded, list.ID, added, nil)
+	s.enqueueListItems(ctx, models.WebhookEventListItemRemoved, list.ID, removed, nil)
+
+	s.log.Info("successfully synced list movies", "listID", list.ID, "added", len(added), "removed", len(removed))
+	return len(added), len(removed), nil
+}
+
+// truncateRunes cuts value to at most limit characters
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return strings.TrimSpace(string(runes[:limit]))
+}
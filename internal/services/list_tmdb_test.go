@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	tmdb "github.com/cyruzin/golang-tmdb"
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+// fakeTMDB answers TMDB API requests with the JSON body stored for their path. Pages after
+// the first are stored under their path and page, like /list/8250?page=2.
+type fakeTMDB map[string]string
+
+func (f fakeTMDB) RoundTrip(r *http.Request) (*http.Response, error) {
+	key := strings.TrimPrefix(r.URL.Path, "/3")
+	if page := r.URL.Query().Get("page"); page != "" && page != "1" {
+		key += "?page=" + page
+	}
+	body, ok := f[key]
+	status := http.StatusOK
+	if !ok {
+		status = http.StatusNotFound
+		body = `{"status_code": 34, "status_message": "The resource you requested could not be found."}`
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    r,
+	}, nil
+}
+
+// newTMDBListTest fills a library with movies 1 to 4, so importing them needs no request
+// to TMDB but the ones for the lists in responses
+func newTMDBListTest(t *testing.T, responses fakeTMDB) (context.Context, *ListService) {
+	t.Helper()
+
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+
+	client, err := tmdb.Init("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetClientConfig(http.Client{Transport: responses})
+
+	listService := NewListService(testDB, NewMovieService(testDB, client, time.Hour), nil)
+	ctx := setupTestUser(t, testDB)
+
+	for id := int64(1); id <= 4; id++ {
+		movie := &models.MovieDetails{Movie: models.Movie{ID: id, Title: "Movie", UpdatedAt: time.Now()}}
+		if err := testDB.UpsertMovie(ctx, movie); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ctx, listService
+}
+
+func TestParseTMDBSource(t *testing.T) {
+	tests := []struct {
+		ref  string
+		kind models.ListSourceKind
+		id   int64
+	}{
+		{" 8250 ", models.ListSourceTMDBList, 8250},
+		{"https://www.themoviedb.org/list/8250-best-of-2024", models.ListSourceTMDBList, 8250},
+		{"https://www.themoviedb.org/list/8250?language=it-IT", models.ListSourceTMDBList, 8250},
+		{"themoviedb.org/collection/10-star-wars-collection", models.ListSourceTMDBCollection, 10},
+	}
+	for _, tt := range tests {
+		kind, id, err := ParseTMDBSource(tt.ref)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.ref, err)
+			continue
+		}
+		if kind != tt.kind || id != tt.id {
+			t.Errorf("%q: expected %s %d, got %s %d", tt.ref, tt.kind, tt.id, kind, id)
+		}
+	}
+
+	for _, ref := range []string{
+		"", "-1", "https://example.com/list/8250", "https://www.themoviedb.org/movie/603", "https://www.themoviedb.org/list/best",
+		"https://evilthemoviedb.org/list/8250", "https://themoviedb.org.example.com/list/8250",
+	} {
+		if _, _, err := ParseTMDBSource(ref); !errors.Is(err, ErrInvalidTMDBSource) {
+			t.Errorf("%q: expected %v, got %v", ref, ErrInvalidTMDBSource, err)
+		}
+	}
+}
+
+func TestListService_ImportTMDBList(t *testing.T) {
+	responses := fakeTMDB{
+		"/list/8250": `{"id": 8250, "name": "Best Of", "description": "The best ones", "items": [
+			{"id": 2, "media_type": "movie"},
+			{"id": 99, "media_type": "tv"},
+			{"id": 1, "media_type": "movie"},
+			{"id": 2, "media_type": "movie"}
+		]}`,
+	}
+	ctx, listService := newTMDBListTest(t, responses)
+
+	list, err := listService.ImportTMDBList(ctx, models.ListSourceTMDBList, 8250, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Name != "Best Of" || list.Description == nil || *list.Description != "The best ones" {
+		t.Errorf("expected the name and description of the TMDB list, got %q and %v", list.Name, list.Description)
+	}
+	if list.Source == nil || list.Source.Kind != models.ListSourceTMDBList || list.Source.TMDBID != 8250 {
+		t.Errorf("expected the TMDB list as source, got %+v", list.Source)
+	}
+	if list.Synced() {
+		t.Error("expected the list not to be synced")
+	}
+	// TV shows are left out and movies listed twice added once
+	assertListOrder(t, ctx, listService, list.ID, []int64{2, 1})
+
+	// a list that is not synced can be changed
+	if err := listService.AddMovieToList(ctx, list.ID, 3, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := listService.SyncList(ctx, list.ID); !errors.Is(err, ErrListNotSynced) {
+		t.Errorf("expected %v, got %v", ErrListNotSynced, err)
+	}
+
+	if _, err := listService.ImportTMDBList(ctx, models.ListSourceTMDBList, 8250, "Best Of", false); !errors.Is(err, ErrListNameTaken) {
+		t.Errorf("expected %v, got %v", ErrListNameTaken, err)
+	}
+	if _, err := listService.ImportTMDBList(ctx, models.ListSourceTMDBList, 404, "Missing", false); err == nil {
+		t.Error("expected an error importing a missing list")
+	}
+	lists, err := listService.GetAllLists(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 1 {
+		t.Errorf("expected failed imports not to create lists, got %+v", lists)
+	}
+}
+
+func TestListService_SyncedMultiPageList(t *testing.T) {
+	responses := fakeTMDB{
+		"/list/8250": `{"id": 8250, "name": "Long List", "item_count": 4, "items": [
+			{"id": 1, "media_type": "movie"},
+			{"id": 2, "media_type": "movie"}
+		]}`,
+		"/list/8250?page=2": `{"id": 8250, "name": "Long List", "item_count": 4, "items": [
+			{"id": 99, "media_type": "tv"},
+			{"id": 3, "media_type": "movie"}
+		]}`,
+	}
+	ctx, listService := newTMDBListTest(t, responses)
+
+	list, err := listService.ImportTMDBList(ctx, models.ListSourceTMDBList, 8250, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertListOrder(t, ctx, listService, list.ID, []int64{1, 2, 3})
+
+	// the sync reads every page too, the movies of the second one are not removed
+	responses["/list/8250?page=2"] = `{"id": 8250, "name": "Long List", "item_count": 4, "items": [
+		{"id": 3, "media_type": "movie"},
+		{"id": 4, "media_type": "movie"}
+	]}`
+	if synced, err := listService.SyncTMDBLists(context.Background()); err != nil || synced != 1 {
+		t.Fatalf("expected 1 synced list, got %d and %v", synced, err)
+	}
+	assertListOrder(t, ctx, listService, list.ID, []int64{1, 2, 3, 4})
+
+	// a page that fails to load fails the sync instead of truncating the list
+	delete(responses, "/list/8250?page=2")
+	if synced, err := listService.SyncTMDBLists(context.Background()); err != nil || synced != 0 {
+		t.Errorf("expected the list to be skipped when a page is missing, got %d and %v", synced, err)
+	}
+	assertListOrder(t, ctx, listService, list.ID, []int64{1, 2, 3, 4})
+}
+
+func TestListService_SyncedList(t *testing.T) {
+	responses := fakeTMDB{
+		"/collection/10": `{"id": 10, "name": "Saga Collection", "parts": [
+			{"id": 3, "release_date": ""},
+			{"id": 2, "release_date": "1999-05-19"},
+			{"id": 1, "release_date": "1977-05-25"}
+		]}`,
+	}
+	ctx, listService := newTMDBListTest(t, responses)
+
+	list, err := listService.ImportTMDBList(ctx, models.ListSourceTMDBCollection, 10, "Saga", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !list.Synced() {
+		t.Fatal("expected the list to be synced")
+	}
+	// collections are in release order, unreleased movies last
+	assertListOrder(t, ctx, listService, list.ID, []int64{1, 2, 3})
+
+	// synced lists are managed by TMDB only
+	if err := listService.AddMovieToList(ctx, list.ID, 4, nil); !errors.Is(err, ErrSyncedListReadOnly) {
+		t.Errorf("expected %v adding a movie, got %v", ErrSyncedListReadOnly, err)
+	}
+	if err := listService.SetListRanked(ctx, list.ID, false); !errors.Is(err, ErrSyncedListReadOnly) {
+		t.Errorf("expected %v unranking the list, got %v", ErrSyncedListReadOnly, err)
+	}
+	lists, err := listService.GetAllLists(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 1 || !lists[0].Synced {
+		t.Errorf("expected the synced list in the sidebar, got %+v", lists)
+	}
+
+	// the scheduled sync follows the source without a user signed in
+	responses["/collection/10"] = `{"id": 10, "name": "Saga Collection", "parts": [
+		{"id": 4, "release_date": "2005-05-19"},
+		{"id": 1, "release_date": "1977-05-25"},
+		{"id": 2, "release_date": "1999-05-19"}
+	]}`
+	synced, err := listService.SyncTMDBLists(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if synced != 1 {
+		t.Errorf("expected 1 synced list, got %d", synced)
+	}
+	assertListOrder(t, ctx, listService, list.ID, []int64{1, 2, 4})
+
+	// stopping the sync hands the list back to its owner
+	list, err = listService.SetListSync(ctx, list.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Synced() || list.Source == nil {
+		t.Errorf("expected an imported list that is not synced, got %+v", list.Source)
+	}
+	if err := listService.AddMovieToList(ctx, list.ID, 3, nil); err != nil {
+		t.Fatal(err)
+	}
+	if synced, err := listService.SyncTMDBLists(context.Background()); err != nil || synced != 0 {
+		t.Errorf("expected no list to sync, got %d and %v", synced, err)
+	}
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var ErrInvalidListView = errors.New("invalid list view")
+
+// listViewParams are the query parameters of a list page that set its view
+var listViewParams = []string{"sort", "order", "genre", "watched", "decade", "layout"}
+
+// ParseListView reads the view of a list page from its query, as written by
+// models.ListView.Query. It reports false when the query sets no view, parameters left
+// out take their default.
+func ParseListView(query url.Values) (models.ListView, bool, error) {
+	view := models.DefaultListView()
+	if !slices.ContainsFunc(listViewParams, query.Has) {
+		return view, false, nil
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		view.Sort = models.ListSort(sort)
+	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		view.Desc = true
+	default:
+		return view, true, fmt.Errorf("%w: unknown order %q", ErrInvalidListView, query.Get("order"))
+	}
+	if genre := query.Get("genre"); genre != "" {
+		genreID, err := strconv.ParseInt(genre, 10, 64)
+		if err != nil {
+			return view, true, fmt.Errorf("%w: invalid genre %q", ErrInvalidListView, genre)
+		}
+		view.GenreID = &genreID
+	}
+	if watched := query.Get("watched"); watched != "" {
+		parsed, err := strconv.ParseBool(watched)
+		if err != nil {
+			return view, true, fmt.Errorf("%w: invalid watched filter %q", ErrInvalidListView, watched)
+		}
+		view.Watched = &parsed
+	}
+	if decade := query.Get("decade"); decade != "" {
+		parsed, err := strconv.ParseInt(decade, 10, 64)
+		if err != nil {
+			return view, true, fmt.Errorf("%w: invalid decade %q", ErrInvalidListView, decade)
+		}
+		view.Decade = &parsed
+	}
+	if layout := query.Get("layout"); layout != "" {
+		view.Layout = models.ListLayout(layout)
+	}
+	return view, true, validateListView(view)
+}
+
+func validateListView(view models.ListView) error {
+	if !view.Sort.Valid() {
+		return fmt.Errorf("%w: unknown sort %q", ErrInvalidListView, view.Sort)
+	}
+	if !view.Layout.Valid() {
+		return fmt.Errorf("%w: unknown layout %q", ErrInvalidListView, view.Layout)
+	}
+	if view.Decade != nil && (*view.Decade%10 != 0 || *view.Decade < 1800) {
+		return fmt.Errorf("%w: invalid decade %d", ErrInvalidListView, *view.Decade)
+	}
+	return nil
+}
+
+// GetListView returns how the user arranged listID, the default view when they never did
+func (s *ListService) GetListView(ctx context.Context, listID int64) (models.ListView, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return models.ListView{}, err
+	}
+
+	view, err := s.db.GetListView(ctx, user.ID, listID)
+	if err != nil {
+		return models.ListView{}, err
+	}
+	if view == nil || validateListView(*view) != nil {
+		return models.DefaultListView(), nil
+	}
+	return *view, nil
+}
+
+// SetListView saves how the user arranges listID, any list they can see. Every user keeps
+// their own view of a shared list.
+func (s *ListService) SetListView(ctx context.Context, listID int64, view models.ListView) error {
+	s.log.Debug("setting list view", "listID", listID, "sort", view.Sort, "layout", view.Layout)
+
+	if err := validateListView(view); err != nil {
+		return err
+	}
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return err
+	}
+
+	if _, err := s.visibleList(ctx, listID); err != nil {
+		return err
+	}
+
+	if err := s.db.SetListView(ctx, user.ID, listID, view); err != nil {
+		return fmt.Errorf("failed to set list view: %w", err)
+	}
+	return nil
+}
+
+// GetListGridData returns listID with the movies the saved view of the user keeps, in its
+// order. The watchlist in its own order splits upcoming movies from released ones.
+func (s *ListService) GetListGridData(ctx context.Context, listID int64, now time.Time) (models.ListGridData, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return models.ListGridData{}, err
+	}
+
+	list, err := s.GetListDetails(ctx, listID)
+	if err != nil {
+		return models.ListGridData{}, err
+	}
+	view, err := s.GetListView(ctx, listID)
+	if err != nil {
+		return models.ListGridData{}, err
+	}
+
+	movieIDs := listMovieIDs(list.Movies)
+	genres, err := s.db.GetMoviesGenres(ctx, movieIDs)
+	if err != nil {
+		return models.ListGridData{}, err
+	}
+	watches, err := s.db.GetMovieWatchSummaries(ctx, user.ID, movieIDs)
+	if err != nil {
+		return models.ListGridData{}, err
+	}
+
+	total := len(list.Movies)
+	decades := listDecades(list.Movies)
+	list.Movies = filterListMovies(list.Movies, view, genres, watches)
+	sortListMovies(list.Movies, view, watches)
+
+	data := models.ListGridData{List: *list, View: view}
+	if data.Split() {
+		data = s.BuildListGridData(list, now)
+		data.View = view
+	}
+	data.Total = total
+	data.Genres = listGenres(genres)
+	data.Decades = decades
+	data.Watches = watches
+	return data, nil
+}
+
+// filterListMovies returns the movies the filters of view keep
+func filterListMovies(movies []models.MovieItem, view models.ListView, genres map[int64][]models.Genre, watches map[int64]models.MovieWatchSummary) []models.MovieItem {
+	kept := make([]models.MovieItem, 0, len(movies))
+	for _, movie := range movies {
+		id := movie.MovieDetails.Movie.ID
+		if view.GenreID != nil && !slices.ContainsFunc(genres[id], func(genre models.Genre) bool { return genre.ID == *view.GenreID }) {
+			continue
+		}
+		if _, watched := watches[id]; view.Watched != nil && watched != *view.Watched {
+			continue
+		}
+		if view.Decade != nil {
+			releaseDate := movie.MovieDetails.Movie.ReleaseDate
+			if releaseDate == nil || decadeOf(*releaseDate) != *view.Decade {
+				continue
+			}
+		}
+		kept = append(kept, movie)
+	}
+	return kept
+}
+
+// sortListMovies orders movies, which are in the order of their list, by the sort of
+// view. Movies missing what they are sorted by go last in both directions, ties keep the
+// order of the list.
+func sortListMovies(movies []models.MovieItem, view models.ListView, watches map[int64]models.MovieWatchSummary) {
+	switch view.Sort {
+	case models.ListSortPosition:
+		if view.Desc {
+			slices.Reverse(movies)
+		}
+		return
+	case models.ListSortTitle:
+		sort.SliceStable(movies, func(i, j int) bool {
+			left := strings.ToLower(movies[i].MovieDetails.Movie.Title)
+			right := strings.ToLower(movies[j].MovieDetails.Movie.Title)
+			if view.Desc {
+				return left > right
+			}
+			return left < right
+		})
+		return
+	}
+
+	key := listSortKey(view.Sort, watches)
+	sort.SliceStable(movies, func(i, j int) bool {
+		left, leftOK := key(movies[i])
+		right, rightOK := key(movies[j])
+		switch {
+		case !leftOK || !rightOK:
+			return leftOK
+		case view.Desc:
+			return left > right
+		default:
+			return left < right
+		}
+	})
+}
+
+// listSortKey returns what sort orders movies by, false when a movie has no value for it
+func listSortKey(sort models.ListSort, watches map[int64]models.MovieWatchSummary) func(models.MovieItem) (float64, bool) {
+	return func(movie models.MovieItem) (float64, bool) {
+		switch sort {
+		case models.ListSortDateAdded:
+			return float64(movie.DateAdded.UnixMilli()), true
+		case models.ListSortReleaseDate:
+			if movie.MovieDetails.Movie.ReleaseDate == nil {
+				return 0, false
+			}
+			return float64(movie.MovieDetails.Movie.ReleaseDate.Unix()), true
+		case models.ListSortRating:
+			// TMDB rates movies nobody voted for 0
+			return float64(movie.MovieDetails.Movie.VoteAverage), movie.MovieDetails.Movie.VoteAverage > 0
+		case models.ListSortRuntime:
+			return float64(movie.MovieDetails.Runtime), movie.MovieDetails.Runtime > 0
+		case models.ListSortMyRating:
+			rating := watches[movie.MovieDetails.Movie.ID].Rating
+			if rating == nil {
+				return 0, false
+			}
+			return *rating, true
+		}
+		return 0, false
+	}
+}
+
+// listGenres returns the genres of the movies of a list, by name
+func listGenres(genres map[int64][]models.Genre) []models.Genre {
+	seen := make(map[int64]bool)
+	var all []models.Genre
+	for _, movieGenres := range genres {
+		for _, genre := range movieGenres {
+			if !seen[genre.ID] {
+				seen[genre.ID] = true
+				all = append(all, genre)
+			}
+		}
+	}
+	slices.SortFunc(all, func(a, b models.Genre) int { return strings.Compare(a.Name, b.Name) })
+	return all
+}
+
+// listDecades returns the decades the movies were released in, newest first
+func listDecades(movies []models.MovieItem) []int64 {
+	var decades []int64
+	for _, movie := range movies {
+		if releaseDate := movie.MovieDetails.Movie.ReleaseDate; releaseDate != nil {
+			decades = append(decades, decadeOf(*releaseDate))
+		}
+	}
+	slices.Sort(decades)
+	decades = slices.Compact(decades)
+	slices.Reverse(decades)
+	return decades
+}
+
+func decadeOf(t time.Time) int64 {
+	return int64(t.Year() / 10 * 10)
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+// newListViewTest fills a list with four movies, in this order:
+//   - 1, "Brazil", a 1985 drama of 142 minutes rated 7.8 on TMDB, watched twice and last
+//     rated 4
+//   - 2, "alien", a 1979 horror of 117 minutes rated 8.1, watched once and rated 5
+//   - 3, "Casablanca", a 1942 drama with no runtime nor TMDB rating, unwatched
+//   - 4, "Dune", a 1984 horror of 137 minutes rated 6.3 with no release date, unwatched
+func newListViewTest(t *testing.T) (context.Context, *ListService, *models.List) {
+	t.Helper()
+
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+
+	listService := NewListService(testDB, nil, nil)
+	ctx := setupTestUser(t, testDB)
+	userID := mustUser(t, ctx).ID
+
+	list, err := listService.CreateList(ctx, "Classics", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := func(year int) *time.Time {
+		d := time.Date(year, 6, 1, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	drama := models.Genre{ID: 18, Name: "Drama"}
+	horror := models.Genre{ID: 27, Name: "Horror"}
+	movies := []models.MovieDetails{
+		{Movie: models.Movie{ID: 1, Title: "Brazil", ReleaseDate: date(1985), VoteAverage: 7.8}, Runtime: 142, Genres: []models.Genre{drama}},
+		{Movie: models.Movie{ID: 2, Title: "alien", ReleaseDate: date(1979), VoteAverage: 8.1}, Runtime: 117, Genres: []models.Genre{horror}},
+		{Movie: models.Movie{ID: 3, Title: "Casablanca", ReleaseDate: date(1942)}, Genres: []models.Genre{drama}},
+		{Movie: models.Movie{ID: 4, Title: "Dune", VoteAverage: 6.3}, Runtime: 137, Genres: []models.Genre{horror}},
+	}
+	added := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range movies {
+		if err := testDB.UpsertMovie(ctx, &movies[i]); err != nil {
+			t.Fatal(err)
+		}
+		err := testDB.AddMovieToList(ctx, userID, db.InsertMovieList{
+			MovieID:   movies[i].Movie.ID,
+			ListID:    list.ID,
+			DateAdded: added.AddDate(0, 0, i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first, latest, best := 2.0, 4.0, 5.0
+	watches := []db.InsertWatched{
+		{UserID: userID, MovieID: 1, Date: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Rating: &first},
+		{UserID: userID, MovieID: 1, Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Rating: &latest},
+		{UserID: userID, MovieID: 2, Date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Rating: &best},
+	}
+	for _, watch := range watches {
+		if err := testDB.InsertWatched(ctx, watch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ctx, listService, list
+}
+
+func TestParseListView(t *testing.T) {
+	view, ok, err := ParseListView(url.Values{})
+	if ok || err != nil {
+		t.Errorf("expected no view from an empty query, got %v and %v", ok, err)
+	}
+	if view != models.DefaultListView() {
+		t.Errorf("expected the default view, got %+v", view)
+	}
+
+	genreID, watched, decade := int64(18), false, int64(1980)
+	want := models.ListView{
+		Sort:    models.ListSortMyRating,
+		Desc:    true,
+		GenreID: &genreID,
+		Watched: &watched,
+		Decade:  &decade,
+		Layout:  models.ListLayoutTable,
+	}
+	view, ok, err = ParseListView(want.Query())
+	if !ok || err != nil {
+		t.Fatalf("expected a view, got %v and %v", ok, err)
+	}
+	if !reflect.DeepEqual(view, want) {
+		t.Errorf("expected %+v, got %+v", want, view)
+	}
+
+	for _, query := range []string{"sort=popularity", "order=up", "genre=drama", "watched=maybe", "decade=1985", "layout=list"} {
+		values, _ := url.ParseQuery(query)
+		if _, _, err := ParseListView(values); !errors.Is(err, ErrInvalidListView) {
+			t.Errorf("%q: expected %v, got %v", query, ErrInvalidListView, err)
+		}
+	}
+}
+
+func TestListService_ListViewSorts(t *testing.T) {
+	ctx, listService, list := newListViewTest(t)
+
+	tests := []struct {
+		sort models.ListSort
+		desc bool
+		want []int64
+	}{
+		{models.ListSortPosition, false, []int64{1, 2, 3, 4}},
+		{models.ListSortPosition, true, []int64{4, 3, 2, 1}},
+		{models.ListSortDateAdded, true, []int64{4, 3, 2, 1}},
+		// titles sort regardless of case
+		{models.ListSortTitle, false, []int64{2, 1, 3, 4}},
+		// movies without the value stay last in both directions
+		{models.ListSortReleaseDate, false, []int64{3, 2, 1, 4}},
+		{models.ListSortReleaseDate, true, []int64{1, 2, 3, 4}},
+		{models.ListSortRating, true, []int64{2, 1, 4, 3}},
+		{models.ListSortRuntime, false, []int64{2, 4, 1, 3}},
+		// the latest rating counts
+		{models.ListSortMyRating, true, []int64{2, 1, 3, 4}},
+		{models.ListSortMyRating, false, []int64{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		view := models.DefaultListView()
+		view.Sort, view.Desc = tt.sort, tt.desc
+		if err := listService.SetListView(ctx, list.ID, view); err != nil {
+			t.Fatal(err)
+		}
+		data, err := listService.GetListGridData(ctx, list.ID, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := listMovieIDs(data.List.Movies); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s desc=%t: expected %v, got %v", tt.sort, tt.desc, tt.want, got)
+		}
+	}
+}
+
+func TestListService_ListViewFilters(t *testing.T) {
+	ctx, listService, list := newListViewTest(t)
+
+	horror, watched, unwatched, eighties := int64(27), true, false, int64(1980)
+	tests := []struct {
+		name string
+		view models.ListView
+		want []int64
+	}{
+		{"genre", models.ListView{GenreID: &horror}, []int64{2, 4}},
+		{"watched", models.ListView{Watched: &watched}, []int64{1, 2}},
+		{"unwatched", models.ListView{Watched: &unwatched}, []int64{3, 4}},
+		// movies without a release date are in no decade
+		{"decade", models.ListView{Decade: &eighties}, []int64{1}},
+		{"every filter", models.ListView{GenreID: &horror, Watched: &unwatched, Decade: &eighties}, []int64{}},
+	}
+	for _, tt := range tests {
+		tt.view.Sort, tt.view.Layout = models.ListSortPosition, models.ListLayoutTable
+		if err := listService.SetListView(ctx, list.ID, tt.view); err != nil {
+			t.Fatal(err)
+		}
+		data, err := listService.GetListGridData(ctx, list.ID, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := smartListMovieIDs(t, &data.List); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+		if data.Total != 4 {
+			t.Errorf("%s: expected 4 movies before filtering, got %d", tt.name, data.Total)
+		}
+		if !reflect.DeepEqual(data.View, tt.view) {
+			t.Errorf("%s: expected the saved view %+v, got %+v", tt.name, tt.view, data.View)
+		}
+	}
+
+	data, err := listService.GetListGridData(ctx, list.ID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data.Decades, []int64{1980, 1970, 1940}) {
+		t.Errorf("expected the decades of the list newest first, got %v", data.Decades)
+	}
+	if len(data.Genres) != 2 || data.Genres[0].Name != "Drama" || data.Genres[1].Name != "Horror" {
+		t.Errorf("expected the genres of the list, got %+v", data.Genres)
+	}
+	if watch := data.Watches[1]; watch.Count != 2 || watch.Rating == nil || *watch.Rating != 4 {
+		t.Errorf("expected 2 watches last rated 4 for movie 1, got %+v", watch)
+	}
+
+	// views are kept per user
+	other, err := listService.db.CreateUser(ctx, "other@example.com", "Other User", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCtx := context.WithValue(context.Background(), common.UserKey, other)
+	if view, err := listService.GetListView(otherCtx, list.ID); err != nil || view != models.DefaultListView() {
+		t.Errorf("expected the default view for another user, got %+v and %v", view, err)
+	}
+	if err := listService.SetListView(otherCtx, list.ID, models.DefaultListView()); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("expected %v saving a view of a list the user cannot see, got %v", ErrListNotFound, err)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var (
+	ErrInvalidWatchlistItem   = errors.New("invalid watchlist item")
+	ErrInvalidWatchNextFilter = errors.New("invalid watch next filter")
+)
+
+const (
+	maxWatchlistTags      = 10
+	maxWatchlistTagLength = 30
+	maxAvailableOnLength  = 100
+)
+
+// SetWatchlistItem replaces the priority, tags and availability of movieID on the
+// watchlist of the user
+func (s *ListService) SetWatchlistItem(ctx context.Context, movieID int64, item models.WatchlistItem) error {
+	item, err := normalizeWatchlistItem(item)
+	if err != nil {
+		return err
+	}
+	s.log.Debug("setting watchlist item", "movieID", movieID, "priority", item.Priority, "tags", len(item.Tags))
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return err
+	}
+
+	watchlistID, err := s.db.GetWatchlistID(ctx, user.ID)
+	if err != nil {
+		s.log.Error("failed to get watchlist ID", "error", err)
+		return fmt.Errorf("failed to get watchlist ID: %w", err)
+	}
+
+	rows, err := s.db.SetListMovieDetails(ctx, watchlistID, movieID, item)
+	if err != nil {
+		return fmt.Errorf("failed to set watchlist item: %w", err)
+	}
+	if rows == 0 {
+		return ErrMovieNotInList
+	}
+
+	s.log.Info("successfully set watchlist item", "movieID", movieID)
+	return nil
+}
+
+// normalizeWatchlistItem trims the tags and availability of item, dropping empty and
+// repeated tags. An unset priority is normal.
+func normalizeWatchlistItem(item models.WatchlistItem) (models.WatchlistItem, error) {
+	if item.Priority == "" {
+		item.Priority = models.WatchlistPriorityNormal
+	}
+	if !item.Priority.Valid() {
+		return item, fmt.Errorf("%w: unknown priority %q", ErrInvalidWatchlistItem, item.Priority)
+	}
+
+	tags := make([]string, 0, len(item.Tags))
+	for _, tag := range item.Tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		if tag == "" || slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxWatchlistTagLength {
+			return item, fmt.Errorf("%w: tags can be at most %d characters", ErrInvalidWatchlistItem, maxWatchlistTagLength)
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxWatchlistTags {
+		return item, fmt.Errorf("%w: a movie can have at most %d tags", ErrInvalidWatchlistItem, maxWatchlistTags)
+	}
+	item.Tags = tags
+
+	if item.AvailableOn != nil {
+		availableOn := strings.TrimSpace(*item.AvailableOn)
+		switch {
+		case availableOn == "":
+			item.AvailableOn = nil
+		case utf8.RuneCountInString(availableOn) > maxAvailableOnLength:
+			return item, fmt.Errorf("%w: where it is available can be at most %d characters", ErrInvalidWatchlistItem, maxAvailableOnLength)
+		default:
+			item.AvailableOn = &availableOn
+		}
+	}
+	return item, nil
+}
+
+// GetWatchNextFilter returns the filter of the watch next panel of the user, the default
+// one when they never set it
+func (s *ListService) GetWatchNextFilter(ctx context.Context) (models.WatchNextFilter, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return models.WatchNextFilter{}, err
+	}
+
+	filter, err := s.db.GetWatchNextFilter(ctx, user.ID)
+	if err != nil {
+		return models.WatchNextFilter{}, err
+	}
+	if filter == nil || !filter.MinPriority.Valid() {
+		return models.DefaultWatchNextFilter(), nil
+	}
+	return *filter, nil
+}
+
+// SetWatchNextFilter saves the filter of the watch next panel of the user, empty tags are
+// unset
+func (s *ListService) SetWatchNextFilter(ctx context.Context, filter models.WatchNextFilter) (models.WatchNextFilter, error) {
+	if filter.MinPriority == "" {
+		filter.MinPriority = models.WatchlistPriorityLow
+	}
+	if !filter.MinPriority.Valid() {
+		return filter, fmt.Errorf("%w: unknown priority %q", ErrInvalidWatchNextFilter, filter.MinPriority)
+	}
+	for _, tag := range []**string{&filter.Tag, &filter.ExcludeTag} {
+		if *tag != nil && strings.TrimSpace(**tag) == "" {
+			*tag = nil
+		}
+	}
+	s.log.Debug("setting watch next filter", "minPriority", filter.MinPriority)
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return filter, err
+	}
+
+	if err := s.db.SetWatchNextFilter(ctx, user.ID, filter); err != nil {
+		return filter, fmt.Errorf("failed to set watch next filter: %w", err)
+	}
+	return filter, nil
+}
+
+// watchlistTags returns the tags of movies, each once and by name
+func watchlistTags(movies []models.MovieItem) []string {
+	var tags []string
+	for _, movie := range movies {
+		for _, tag := range movie.Tags {
+			if !slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.SortFunc(tags, func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) })
+	return tags
+}
+
+// hasTag reports whether movie is tagged tag, regardless of case
+func hasTag(movie models.MovieItem, tag string) bool {
+	return slices.ContainsFunc(movie.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+}
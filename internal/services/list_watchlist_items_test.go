@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+func TestListService_SetWatchlistItem(t *testing.T) {
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer testDB.Close()
+
+	listService := NewListService(testDB, nil, nil)
+	homeService := NewHomeService(nil, listService)
+	ctx := setupTestUser(t, testDB)
+
+	watchlist, err := listService.CreateList(ctx, "Watchlist", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id := int64(1); id <= 3; id++ {
+		if err := testDB.UpsertMovie(ctx, &models.MovieDetails{Movie: models.Movie{ID: id, Title: "Movie"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := listService.AddMovieToList(ctx, watchlist.ID, id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	availableOn := "  Netflix "
+	item := models.WatchlistItem{
+		Priority:    models.WatchlistPriorityHigh,
+		Tags:        []string{" with   kids", "", "Cinema only", "With Kids"},
+		AvailableOn: &availableOn,
+	}
+	if err := listService.SetWatchlistItem(ctx, 2, item); err != nil {
+		t.Fatal(err)
+	}
+	blank := " "
+	if err := listService.SetWatchlistItem(ctx, 3, models.WatchlistItem{Priority: models.WatchlistPriorityLow, AvailableOn: &blank}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := listService.GetWatchlist(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	movie := got.Movies[1]
+	if movie.Priority != models.WatchlistPriorityHigh {
+		t.Errorf("expected high priority, got %q", movie.Priority)
+	}
+	// tags are trimmed, blank and repeated ones dropped
+	if !reflect.DeepEqual(movie.Tags, []string{"Cinema only", "with kids"}) {
+		t.Errorf("expected the tags by name, got %q", movie.Tags)
+	}
+	if movie.AvailableOn == nil || *movie.AvailableOn != "Netflix" {
+		t.Errorf("expected it available on Netflix, got %v", movie.AvailableOn)
+	}
+	if got.Movies[0].Priority != models.WatchlistPriorityNormal || len(got.Movies[0].Tags) != 0 {
+		t.Errorf("expected movies start with a normal priority and no tags, got %+v", got.Movies[0])
+	}
+	if got.Movies[2].AvailableOn != nil {
+		t.Errorf("expected a blank availability to be unset, got %q", *got.Movies[2].AvailableOn)
+	}
+
+	// setting the item again replaces its tags
+	if err := listService.SetWatchlistItem(ctx, 2, models.WatchlistItem{Priority: models.WatchlistPriorityHigh, Tags: []string{"date night"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []models.WatchlistItem{
+		{Priority: "urgent"},
+		{Tags: []string{"a tag far too long to be a useful tag"}},
+		{Tags: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}},
+	}
+	for _, item := range invalid {
+		if err := listService.SetWatchlistItem(ctx, 1, item); !errors.Is(err, ErrInvalidWatchlistItem) {
+			t.Errorf("%+v: expected %v, got %v", item, ErrInvalidWatchlistItem, err)
+		}
+	}
+	if err := listService.SetWatchlistItem(ctx, 4, models.WatchlistItem{}); !errors.Is(err, ErrMovieNotInList) {
+		t.Errorf("expected %v for a movie out of the watchlist, got %v", ErrMovieNotInList, err)
+	}
+
+	filter, err := listService.GetWatchNextFilter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter != models.DefaultWatchNextFilter() {
+		t.Errorf("expected the default filter, got %+v", filter)
+	}
+
+	tag, none := "Date Night", ""
+	if _, err := listService.SetWatchNextFilter(ctx, models.WatchNextFilter{MinPriority: models.WatchlistPriorityNormal, Tag: &tag, ExcludeTag: &none}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listService.SetWatchNextFilter(ctx, models.WatchNextFilter{MinPriority: "urgent"}); !errors.Is(err, ErrInvalidWatchNextFilter) {
+		t.Errorf("expected %v, got %v", ErrInvalidWatchNextFilter, err)
+	}
+
+	watchNext, err := homeService.GetWatchNext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if watchNext.Filter.Tag == nil || *watchNext.Filter.Tag != tag || watchNext.Filter.ExcludeTag != nil {
+		t.Errorf("expected the saved filter, got %+v", watchNext.Filter)
+	}
+	if got := listMovieIDs(watchNext.Movies); !reflect.DeepEqual(got, []int64{2}) {
+		t.Errorf("expected the movies tagged %q, got %v", tag, got)
+	}
+	if watchNext.WatchlistMovieCount != 3 || !reflect.DeepEqual(watchNext.Tags, []string{"date night"}) {
+		t.Errorf("expected 3 movies tagged date night in the watchlist, got %d and %q", watchNext.WatchlistMovieCount, watchNext.Tags)
+	}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// failures a client IP gets for free before it has to wait between attempts
+	clientFreeFailures = 3
+	clientMaxBackoff   = 15 * time.Minute
+	// a client IP is forgotten after this long without failures
+	clientFailureWindow = time.Hour
+	// prune the client table early when it grows past this many IPs
+	clientTableLimit = 10000
+
+	maxLockoutDuration = 24 * time.Hour
+
+	DefaultLockoutThreshold = 5
+	DefaultLockoutDuration  = 15 * time.Minute
+)
+
+var (
+	// ErrLoginThrottled is returned while a client IP has to wait after too many failures
+	ErrLoginThrottled = errors.New("too many failed attempts from this address")
+	// ErrAccountLocked is returned while an account is locked after too many failed passwords
+	ErrAccountLocked = errors.New("account is temporarily locked")
+)
+
+// ThrottleError is returned while login attempts are held back. It matches
+// ErrLoginThrottled or ErrAccountLocked with errors.Is.
+type ThrottleError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("%v, retry in %s", e.Err, e.RetryAfter.Round(time.Second))
+}
+
+func (e *ThrottleError) Unwrap() error {
+	return e.Err
+}
+
+type clientFailures struct {
+	count        int
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// clientLimiter applies exponential backoff to client IPs that keep failing to sign in or register.
+// It is kept in memory, a restart forgets every client.
+type clientLimiter struct {
+	mu      sync.Mutex
+	clients map[string]*clientFailures
+}
+
+func newClientLimiter() *clientLimiter {
+	return &clientLimiter{clients: make(map[string]*clientFailures)}
+}
+
+// wait returns how long ip has to wait before its next attempt
+func (l *clientLimiter) wait(ip string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	client, ok := l.clients[ip]
+	if !ok || !now.Before(client.blockedUntil) {
+		return 0
+	}
+	return client.blockedUntil.Sub(now)
+}
+
+func (l *clientLimiter) fail(ip string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.clients) >= clientTableLimit {
+		l.pruneLocked(now)
+	}
+
+	client, ok := l.clients[ip]
+	if !ok || now.Sub(client.last) > clientFailureWindow {
+		client = &clientFailures{}
+		l.clients[ip] = client
+	}
+	client.count++
+	client.last = now
+
+	if client.count > clientFreeFailures {
+		client.blockedUntil = now.Add(backoff(time.Second, client.count-clientFreeFailures-1, clientMaxBackoff))
+	}
+}
+
+func (l *clientLimiter) reset(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.clients, ip)
+}
+
+func (l *clientLimiter) prune(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pruneLocked(now)
+}
+
+func (l *clientLimiter) pruneLocked(now time.Time) {
+	for ip, client := range l.clients {
+		if now.Sub(client.last) > clientFailureWindow {
+			delete(l.clients, ip)
+		}
+	}
+}
+
+// backoff doubles base for every step, up to limit
+func backoff(base time.Duration, steps int, limit time.Duration) time.Duration {
+	wait := base
+	for range steps {
+		wait *= 2
+		if wait >= limit {
+			return limit
+		}
+	}
+	return min(wait, limit)
+}
+
+// CheckClientThrottle returns a *ThrottleError while clientIP has to wait before its next attempt
+func (a *AuthService) CheckClientThrottle(clientIP string) error {
+	if wait := a.clients.wait(clientIP, time.Now()); wait > 0 {
+		return &ThrottleError{Err: ErrLoginThrottled, RetryAfter: wait}
+	}
+	return nil
+}
+
+// RecordClientFailure counts a failed login or registration attempt from clientIP
+func (a *AuthService) RecordClientFailure(clientIP string) {
+	a.clients.fail(clientIP, time.Now())
+}
+
+// checkAccountLock returns a *ThrottleError while userID is locked out of password login
+// and of its second step
+func (a *AuthService) checkAccountLock(ctx context.Context, userID int64) error {
+	lockout, err := a.db.GetLoginLockout(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get login lockout: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if lockout.LockedUntil != nil && now.Before(*lockout.LockedUntil) {
+		return &ThrottleError{Err: ErrAccountLocked, RetryAfter: lockout.LockedUntil.Sub(now)}
+	}
+	return nil
+}
+
+// recordAccountFailure counts a wrong password or second-factor code for userID and locks
+// the account once the threshold is reached. Each further failure doubles the lockout.
+func (a *AuthService) recordAccountFailure(ctx context.Context, userID int64) error {
+	now := time.Now().UTC()
+	count, err := a.db.RecordFailedLogin(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+	if a.LockoutThreshold <= 0 || count < a.LockoutThreshold {
+		return nil
+	}
+
+	duration := backoff(a.LockoutDuration, int(count-a.LockoutThreshold), maxLockoutDuration)
+	if err := a.db.LockUserLogin(ctx, userID, now.Add(duration)); err != nil {
+		return err
+	}
+
+	a.log.Warn("account locked after failed logins", "userID", userID, "failures", count, "duration", duration)
+	return nil
+}
+
+// loginSucceeded forgets the failures of clientIP and userID once they signed in
+func (a *AuthService) loginSucceeded(ctx context.Context, userID int64, clientIP string) {
+	a.clients.reset(clientIP)
+	if _, err := a.db.ResetLoginLockout(ctx, userID); err != nil {
+		a.log.Error("failed to reset failed logins", "userID", userID, "error", err)
+	}
+}
+
+// UnlockUser clears the failed logins and lockout of userID
+func (a *AuthService) UnlockUser(ctx context.Context, userID int64) error {
+	if _, err := a.db.ResetLoginLockout(ctx, userID); err != nil {
+		return fmt.Errorf("failed to unlock user %d: %w", userID, err)
+	}
+
+	a.log.Info("user login unlocked", "userID", userID)
+	return nil
+}
+
+// ClientIP resolves the address of the client from the peer address of the connection.
+// X-Forwarded-For is only honored when the peer is a trusted proxy: its entries are
+// walked from the right, each appended by the hop before, and the first address that is
+// not a trusted proxy is the client. Entries left of it could have been set by anyone.
+func (a *AuthService) ClientIP(remoteAddr, forwardedFor string) string {
+	ip := remoteAddr
+	if addrPort, err := netip.ParseAddrPort(remoteAddr); err == nil {
+		ip = addrPort.Addr().Unmap().String()
+	}
+
+	if forwardedFor == "" || !prefixesContain(a.TrustedProxies, remoteAddr) {
+		return ip
+	}
+
+	forwarded := strings.Split(forwardedFor, ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			// the last trusted hop is the nearest address known for sure
+			return ip
+		}
+		ip = addr.Unmap().String()
+		if !prefixesContainAddr(a.TrustedProxies, addr) {
+			return ip
+		}
+	}
+	return ip
+}
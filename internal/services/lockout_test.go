@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+func TestClientLimiter_BacksOffAfterFreeFailures(t *testing.T) {
+	limiter := newClientLimiter()
+	now := time.Now()
+
+	for range clientFreeFailures {
+		limiter.fail("192.0.2.1", now)
+	}
+	if wait := limiter.wait("192.0.2.1", now); wait != 0 {
+		t.Fatalf("expected no wait within the free failures, got %s", wait)
+	}
+
+	limiter.fail("192.0.2.1", now)
+	if wait := limiter.wait("192.0.2.1", now); wait != time.Second {
+		t.Errorf("expected 1s wait, got %s", wait)
+	}
+	limiter.fail("192.0.2.1", now)
+	if wait := limiter.wait("192.0.2.1", now); wait != 2*time.Second {
+		t.Errorf("expected 2s wait, got %s", wait)
+	}
+	if wait := limiter.wait("192.0.2.2", now); wait != 0 {
+		t.Errorf("expected other clients not to wait, got %s", wait)
+	}
+
+	limiter.reset("192.0.2.1")
+	if wait := limiter.wait("192.0.2.1", now); wait != 0 {
+		t.Errorf("expected no wait after reset, got %s", wait)
+	}
+}
+
+func TestClientLimiter_ForgetsOldFailures(t *testing.T) {
+	limiter := newClientLimiter()
+	now := time.Now()
+
+	for range clientFreeFailures + 1 {
+		limiter.fail("192.0.2.1", now)
+	}
+
+	later := now.Add(clientFailureWindow + time.Minute)
+	limiter.fail("192.0.2.1", later)
+	if wait := limiter.wait("192.0.2.1", later); wait != 0 {
+		t.Errorf("expected failures outside the window to be forgotten, got %s", wait)
+	}
+
+	limiter.prune(later.Add(clientFailureWindow + time.Minute))
+	if len(limiter.clients) != 0 {
+		t.Errorf("expected prune to drop stale clients, got %d", len(limiter.clients))
+	}
+}
+
+func TestBackoff_CapsAtLimit(t *testing.T) {
+	if got := backoff(time.Minute, 3, time.Hour); got != 8*time.Minute {
+		t.Errorf("expected 8m, got %s", got)
+	}
+	if got := backoff(time.Minute, 100, time.Hour); got != time.Hour {
+		t.Errorf("expected the limit, got %s", got)
+	}
+}
+
+func TestAuthenticateUser_ThrottlesClient(t *testing.T) {
+	_, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := context.Background()
+
+	for range clientFreeFailures + 1 {
+		if _, err := authService.AuthenticateUser(ctx, "nobody@example.com", "wrong", "192.0.2.1"); errors.Is(err, ErrLoginThrottled) {
+			t.Fatalf("expected free failures before throttling, got %v", err)
+		}
+	}
+
+	_, err := authService.AuthenticateUser(ctx, "nobody@example.com", "wrong", "192.0.2.1")
+	var throttled *ThrottleError
+	if !errors.As(err, &throttled) || !errors.Is(err, ErrLoginThrottled) || throttled.RetryAfter <= 0 {
+		t.Fatalf("expected a throttle error with a retry time, got %v", err)
+	}
+	if err := authService.CheckClientThrottle("192.0.2.2"); err != nil {
+		t.Errorf("expected other clients not to be throttled, got %v", err)
+	}
+}
+
+func TestAuthenticateUser_LocksAccount(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := context.Background()
+
+	userID, err := authService.RegisterUser(ctx, "locked@example.com", "Locked", "Password123!", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every attempt from its own address so the client throttle stays out of the way
+	for i := range DefaultLockoutThreshold {
+		_, err := authService.AuthenticateUser(ctx, "locked@example.com", "wrong", fmt.Sprintf("192.0.2.%d", i))
+		if errors.Is(err, ErrAccountLocked) {
+			t.Fatalf("expected no lock before the threshold, attempt %d", i)
+		}
+	}
+
+	_, err = authService.AuthenticateUser(ctx, "locked@example.com", "Password123!", "198.51.100.1")
+	var throttled *ThrottleError
+	if !errors.As(err, &throttled) || !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("expected the account to be locked even with the right password, got %v", err)
+	}
+	if throttled.RetryAfter > DefaultLockoutDuration || throttled.RetryAfter < DefaultLockoutDuration-time.Minute {
+		t.Errorf("expected a lockout of about %s, got %s", DefaultLockoutDuration, throttled.RetryAfter)
+	}
+
+	lockout, err := testDB.GetLoginLockout(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lockout.FailedCount != DefaultLockoutThreshold {
+		t.Errorf("expected %d failed logins, got %d", DefaultLockoutThreshold, lockout.FailedCount)
+	}
+
+	if err := authService.UnlockUser(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.AuthenticateUser(ctx, "locked@example.com", "Password123!", "198.51.100.1"); err != nil {
+		t.Errorf("expected login after unlock, got %v", err)
+	}
+}
+
+func TestAuthenticateUser_LockoutDoublesAndSuccessResets(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := context.Background()
+
+	userID, err := authService.RegisterUser(ctx, "user@example.com", "User", "Password123!", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range DefaultLockoutThreshold + 1 {
+		if err := authService.recordAccountFailure(ctx, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lockout, err := testDB.GetLoginLockout(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remaining := time.Until(*lockout.LockedUntil)
+	if remaining <= DefaultLockoutDuration || remaining > 2*DefaultLockoutDuration {
+		t.Errorf("expected the lockout to double, %s remaining", remaining)
+	}
+
+	if _, err := testDB.ResetLoginLockout(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.AuthenticateUser(ctx, "user@example.com", "wrong", "192.0.2.1"); err == nil {
+		t.Fatal("expected wrong password to fail")
+	}
+	if _, err := authService.AuthenticateUser(ctx, "user@example.com", "Password123!", "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testDB.GetLoginLockout(ctx, userID); err == nil {
+		t.Error("expected a successful login to clear the failed logins")
+	}
+}
+
+func TestAuthService_ClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authService := &AuthService{TrustedProxies: trusted}
+
+	tests := []struct {
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"203.0.113.7:1234", "", "203.0.113.7"},
+		{"203.0.113.7:1234", "198.51.100.1", "203.0.113.7"},
+		{"10.0.0.2:1234", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		{"10.0.0.2:1234", "garbage", "10.0.0.2"},
+		{"[::ffff:10.0.0.2]:1234", "198.51.100.1", "198.51.100.1"},
+		{"[2001:db8::1]:1234", "", "2001:db8::1"},
+		// several trusted proxies, the client is the first untrusted entry from the right
+		{"10.0.0.2:1234", "198.51.100.1, 10.0.0.3", "198.51.100.1"},
+		{"10.0.0.2:1234", "6.6.6.6, 198.51.100.1, 10.1.0.1, 10.0.0.3", "198.51.100.1"},
+		{"10.0.0.2:1234", "10.0.0.4, 10.0.0.3", "10.0.0.4"},
+		{"10.0.0.2:1234", "garbage, 10.0.0.3", "10.0.0.3"},
+	}
+
+	for _, tt := range tests {
+		if got := authService.ClientIP(tt.remoteAddr, tt.forwarded); got != tt.want {
+			t.Errorf("%s via %q: expected %s, got %s", tt.remoteAddr, tt.forwarded, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/logging"
+)
+
+// MailTLS is how the connection to the SMTP server is secured
+type MailTLS string
+
+const (
+	MailTLSNone     MailTLS = "none"
+	MailTLSStartTLS MailTLS = "starttls"
+	MailTLSImplicit MailTLS = "tls"
+
+	mailTimeout = 30 * time.Second
+)
+
+func (t MailTLS) Valid() bool {
+	switch t {
+	case MailTLSNone, MailTLSStartTLS, MailTLSImplicit:
+		return true
+	}
+	return false
+}
+
+var (
+	ErrInvalidMailConfig   = errors.New("invalid SMTP configuration")
+	ErrUnknownMailTemplate = errors.New("unknown email template")
+	ErrInvalidRecipient    = errors.New("invalid email recipient")
+)
+
+// emails holds one <name>.txt per email, defining its "subject" and "text" body, and the
+// matching <name>.html body
+//
+//go:embed emails
+var emailFS embed.FS
+
+var mailTemplates = mustLoadMailTemplates("password_reset")
+
+type mailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+func mustLoadMailTemplates(names ...string) map[string]mailTemplate {
+	templates := make(map[string]mailTemplate, len(names))
+	for _, name := range names {
+		templates[name] = mailTemplate{
+			text: texttemplate.Must(texttemplate.ParseFS(emailFS, "emails/"+name+".txt")),
+			html: htmltemplate.Must(htmltemplate.ParseFS(emailFS, "emails/"+name+".html")),
+		}
+	}
+	return templates
+}
+
+type MailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// From is the sender, a bare address or one with a name like "gowatch <gowatch@example.com>"
+	From string
+	TLS  MailTLS
+}
+
+// MailService sends templated emails through an SMTP server
+type MailService struct {
+	Config MailConfig
+	from   *mail.Address
+	log    *slog.Logger
+}
+
+func NewMailService(cfg MailConfig) (*MailService, error) {
+	log := logging.Get("mail service")
+	log.Debug("creating new MailService instance", "host", cfg.Host, "port", cfg.Port, "tls", cfg.TLS)
+
+	if cfg.Host == "" || cfg.Port <= 0 {
+		return nil, fmt.Errorf("%w: host and port are required", ErrInvalidMailConfig)
+	}
+	if !cfg.TLS.Valid() {
+		return nil, fmt.Errorf("%w: tls must be none, starttls or tls, got %q", ErrInvalidMailConfig, cfg.TLS)
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from address %q: %w", ErrInvalidMailConfig, cfg.From, err)
+	}
+
+	return &MailService{
+		Config: cfg,
+		from:   from,
+		log:    log,
+	}, nil
+}
+
+// Send renders the email template name with data and sends it to the address to
+func (s *MailService) Send(ctx context.Context, to, name string, data any) error {
+	tmpl, ok := mailTemplates[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownMailTemplate, name)
+	}
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRecipient, err)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return fmt.Errorf("failed to render subject of %s email: %w", name, err)
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return fmt.Errorf("failed to render text of %s email: %w", name, err)
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return fmt.Errorf("failed to render html of %s email: %w", name, err)
+	}
+
+	message, err := s.buildMessage(recipient, strings.TrimSpace(subject.String()), text.String(), html.String())
+	if err != nil {
+		return fmt.Errorf("failed to build %s email: %w", name, err)
+	}
+
+	if err := s.deliver(ctx, recipient.Address, message); err != nil {
+		s.log.Error("failed to send email", "template", name, "error", err)
+		return fmt.Errorf("failed to send %s email: %w", name, err)
+	}
+
+	s.log.Info("email sent", "template", name)
+	return nil
+}
+
+// buildMessage assembles a multipart/alternative message with a plain text and an html body
+func (s *MailService) buildMessage(to *mail.Address, subject, text, html string) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	messageID, err := s.messageID()
+	if err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", s.from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", `multipart/alternative; boundary="` + parts.Boundary() + `"`},
+	}
+	for _, header := range headers {
+		message.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+func (s *MailService) messageID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate message ID: %w", err)
+	}
+	domain := s.from.Address[strings.LastIndex(s.from.Address, "@")+1:]
+	return "<" + hex.EncodeToString(bytes) + "@" + domain + ">", nil
+}
+
+// deliver sends message to the SMTP server. Credentials are only sent over TLS, or in the
+// clear to a server on localhost.
+func (s *MailService) deliver(ctx context.Context, to string, message []byte) error {
+	addr := net.JoinHostPort(s.Config.Host, strconv.Itoa(s.Config.Port))
+	tlsConfig := &tls.Config{ServerName: s.Config.Host, MinVersion: tls.VersionTLS12}
+
+	dialer := &net.Dialer{Timeout: mailTimeout}
+	var conn net.Conn
+	var err error
+	if s.Config.TLS == MailTLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	deadline := time.Now().Add(mailTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.Config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if s.Config.TLS == MailTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if s.Config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Config.Username, s.Config.Password, s.Config.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSink is a minimal SMTP server keeping every message it receives
+type smtpSink struct {
+	host     string
+	port     int
+	messages chan string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	addr := listener.Addr().(*net.TCPAddr)
+	sink := &smtpSink{host: "127.0.0.1", port: addr.Port, messages: make(chan string, 10)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	return sink
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+	reply("220 sink ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(command, "DATA"):
+			reply("354 end with .")
+			var message strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				message.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.messages <- message.String()
+			reply("250 queued")
+		case strings.HasPrefix(command, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *smtpSink) mailService(t *testing.T) *MailService {
+	t.Helper()
+
+	service, err := NewMailService(MailConfig{
+		Host: s.host,
+		Port: s.port,
+		From: "gowatch <gowatch@example.com>",
+		TLS:  MailTLSNone,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+// next returns the next message received by the sink
+func (s *smtpSink) next(t *testing.T) *mail.Message {
+	t.Helper()
+
+	select {
+	case raw := <-s.messages:
+		message, err := mail.ReadMessage(strings.NewReader(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email received")
+		return nil
+	}
+}
+
+func (s *smtpSink) expectNone(t *testing.T) {
+	t.Helper()
+
+	select {
+	case <-s.messages:
+		t.Fatal("expected no email")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// mailParts decodes the bodies of a multipart message by content type
+func mailParts(t *testing.T, message *mail.Message) map[string]string {
+	t.Helper()
+
+	_, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string]string)
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return parts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[mediaType] = string(body)
+	}
+}
+
+func TestNewMailService_RejectsInvalidConfig(t *testing.T) {
+	tests := []MailConfig{
+		{Port: 587, From: "gowatch@example.com", TLS: MailTLSStartTLS},
+		{Host: "smtp.example.com", Port: 587, From: "not an address", TLS: MailTLSStartTLS},
+		{Host: "smtp.example.com", Port: 587, From: "gowatch@example.com", TLS: "ssl"},
+	}
+	for _, cfg := range tests {
+		if _, err := NewMailService(cfg); !errors.Is(err, ErrInvalidMailConfig) {
+			t.Errorf("expected %v for %+v, got %v", ErrInvalidMailConfig, cfg, err)
+		}
+	}
+}
+
+func TestMailService_Send(t *testing.T) {
+	sink := newSMTPSink(t)
+	service := sink.mailService(t)
+
+	err := service.Send(t.Context(), "Jane <jane@example.com>", "password_reset", passwordResetEmail{
+		Name:      "Jane <b>",
+		Link:      "https://gowatch.example.com/reset-password?token=abc",
+		ExpiresIn: "1 hour",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := sink.next(t)
+	if got := message.Header.Get("Subject"); got != "Reset your gowatch password" {
+		t.Errorf("unexpected subject %q", got)
+	}
+	if got := message.Header.Get("To"); got != `"Jane" <jane@example.com>` {
+		t.Errorf("unexpected recipient %q", got)
+	}
+
+	parts := mailParts(t, message)
+	if !strings.Contains(parts["text/plain"], "Hi Jane <b>,") || !strings.Contains(parts["text/plain"], "token=abc") {
+		t.Errorf("unexpected text body %q", parts["text/plain"])
+	}
+	if !strings.Contains(parts["text/html"], "Hi Jane &lt;b&gt;,") || !strings.Contains(parts["text/html"], "token=abc") {
+		t.Errorf("expected an escaped html body with the link, got %q", parts["text/html"])
+	}
+}
+
+func TestMailService_SendRejectsUnknownTemplate(t *testing.T) {
+	sink := newSMTPSink(t)
+	service := sink.mailService(t)
+
+	if err := service.Send(t.Context(), "jane@example.com", "missing", nil); !errors.Is(err, ErrUnknownMailTemplate) {
+		t.Errorf("expected %v, got %v", ErrUnknownMailTemplate, err)
+	}
+	if err := service.Send(t.Context(), "jane@example.com\r\nBcc: eve@example.com", "password_reset", passwordResetEmail{}); !errors.Is(err, ErrInvalidRecipient) {
+		t.Errorf("expected %v, got %v", ErrInvalidRecipient, err)
+	}
+	sink.expectNone(t)
+}
+
+func TestMailService_SendFailsWhenServerIsDown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+
+	service, err := NewMailService(MailConfig{Host: "127.0.0.1", Port: port, From: "gowatch@example.com", TLS: MailTLSNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Send(t.Context(), "jane@example.com", "password_reset", passwordResetEmail{}); err == nil {
+		t.Error("expected an error, nothing listens on port " + strconv.Itoa(port))
+	}
+}
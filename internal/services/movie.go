@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -99,6 +100,100 @@ func (s *MovieService) SearchMulti(query string) ([]models.SearchResult, error)
 	return results, nil
 }
 
+// SearchCollections searches TMDB for collections, like the movies of a franchise, by name
+func (s *MovieService) SearchCollections(query string) ([]models.TMDBCollection, error) {
+	s.log.Debug("searching collections", "query", query)
+
+	if s.client == nil {
+		s.log.Error("tmdb client not configured", "query", query)
+		return nil, fmt.Errorf("tmdb client not configured")
+	}
+
+	search, err := s.client.GetSearchCollections(query, nil)
+	if err != nil {
+		s.log.Error("TMDB collection search failed", "query", query, "error", err)
+		return nil, fmt.Errorf("error searching TMDB collections for query '%s': %w", query, err)
+	}
+
+	var collections []models.TMDBCollection
+	if search.SearchCollectionsResults != nil {
+		for _, result := range search.Results {
+			collections = append(collections, models.TMDBCollection{
+				ID:         result.ID,
+				Name:       result.Name,
+				PosterPath: result.PosterPath,
+			})
+		}
+	}
+
+	s.log.Info("collection search completed", "query", query, "resultCount", len(collections))
+	return collections, nil
+}
+
+// GetTMDBList fetches the movies of a public TMDB list or of a collection. The TV shows a
+// list may hold are left out, the movies of a collection are sorted by release date.
+func (s *MovieService) GetTMDBList(kind models.ListSourceKind, id int64) (*models.TMDBList, error) {
+	s.log.Debug("getting TMDB list", "kind", kind, "tmdbID", id)
+
+	if s.client == nil {
+		s.log.Error("tmdb client not configured", "kind", kind, "tmdbID", id)
+		return nil, fmt.Errorf("tmdb client not configured")
+	}
+
+	list := &models.TMDBList{Kind: kind, ID: id}
+	switch kind {
+	case models.ListSourceTMDBList:
+		// TMDB pages the items of long lists, a partial list would look like removed
+		// movies to the sync, so every page is fetched until item_count is reached
+		items := int64(0)
+		for page := 1; ; page++ {
+			details, err := s.client.GetListDetails(id, map[string]string{"page": strconv.Itoa(page)})
+			if err != nil {
+				s.log.Error("failed to get list details from TMDB", "tmdbID", id, "page", page, "error", err)
+				return nil, fmt.Errorf("error getting page %d of TMDB list details for id '%d': %w", page, id, err)
+			}
+			if page == 1 {
+				list.Name = details.Name
+				list.Description = details.Description
+			}
+			for _, item := range details.Items {
+				if item.MediaType == string(models.SearchResultTypeMovie) {
+					list.MovieIDs = append(list.MovieIDs, item.ID)
+				}
+			}
+			items += int64(len(details.Items))
+			if len(details.Items) == 0 || items >= details.ItemCount {
+				break
+			}
+		}
+	case models.ListSourceTMDBCollection:
+		details, err := s.client.GetCollectionDetails(int(id), nil)
+		if err != nil {
+			s.log.Error("failed to get collection details from TMDB", "tmdbID", id, "error", err)
+			return nil, fmt.Errorf("error getting TMDB collection details for id '%d': %w", id, err)
+		}
+		parts := details.Parts
+		// unreleased parts without a date go last
+		sort.SliceStable(parts, func(i, j int) bool {
+			first, second := parseTMDBDate(parts[i].ReleaseDate), parseTMDBDate(parts[j].ReleaseDate)
+			if first == nil || second == nil {
+				return first != nil
+			}
+			return first.Before(*second)
+		})
+		list.Name = details.Name
+		list.Description = details.Overview
+		for _, part := range parts {
+			list.MovieIDs = append(list.MovieIDs, part.ID)
+		}
+	default:
+		return nil, fmt.Errorf("unknown TMDB list kind %q", kind)
+	}
+
+	s.log.Info("successfully fetched TMDB list", "kind", kind, "tmdbID", id, "name", list.Name, "movieCount", len(list.MovieIDs))
+	return list, nil
+}
+
 func mapTMDBSearchMultiMovieToSearchResult(
 	id int64,
 	title string,
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/logging"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcClockSkew   = time.Minute
+	maxOIDCResponse = 1 << 20
+	// tokens naming an unknown key refresh the key set at most this often, so that
+	// they cannot be used to flood the provider
+	oidcKeysRefreshInterval = time.Minute
+)
+
+var (
+	ErrOIDCDiscovery     = errors.New("failed to discover OIDC provider")
+	ErrOIDCTokenExchange = errors.New("failed to exchange OIDC authorization code")
+	ErrInvalidIDToken    = errors.New("invalid OIDC ID token")
+)
+
+// oidcSigningAlgs are the ID token signatures gowatch accepts, anything else, "none"
+// and HMAC included, is rejected before the key is looked up
+var oidcSigningAlgs = []string{oidc.RS256, oidc.RS384, oidc.RS512, oidc.ES256, oidc.ES384, oidc.ES512}
+
+// OIDCConfig configures single sign-on through an OpenID Connect provider
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// ProviderName is shown on the login button
+	ProviderName string
+	// AdminGroup, when set, makes members of this group admins and removes admin from everyone else on login
+	AdminGroup  string
+	GroupsClaim string
+	// AutoProvision creates an account for identities that do not match an existing user
+	AutoProvision bool
+}
+
+// OIDCClaims are the ID token claims gowatch cares about
+type OIDCClaims struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     *bool
+	Name              string
+	PreferredUsername string
+	Groups            []string
+}
+
+// OIDCProvider implements the authorization code flow with PKCE against an
+// OpenID Connect provider. Discovery and signing keys are fetched lazily and cached,
+// so an unreachable provider does not prevent startup.
+type OIDCProvider struct {
+	Config OIDCConfig
+	client *http.Client
+	log    *slog.Logger
+
+	// mu guards discovery, it is never held during requests to the provider
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+}
+
+// oidcDiscovery holds what is built from the provider metadata
+type oidcDiscovery struct {
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	keys     *oidcKeySet
+}
+
+func NewOIDCProvider(cfg OIDCConfig, client *http.Client) *OIDCProvider {
+	log := logging.Get("oidc provider")
+	log.Debug("creating new OIDCProvider instance", "issuer", cfg.Issuer)
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+	if !slices.Contains(cfg.Scopes, oidc.ScopeOpenID) {
+		cfg.Scopes = append([]string{oidc.ScopeOpenID}, cfg.Scopes...)
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if cfg.ProviderName == "" {
+		cfg.ProviderName = "SSO"
+	}
+	return &OIDCProvider{
+		Config: cfg,
+		client: client,
+		log:    log,
+	}
+}
+
+// AuthCodeURL returns the provider URL the browser is sent to in order to start the flow
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, flow models.OIDCFlow) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return discovery.oauth2.AuthCodeURL(flow.State, oidc.Nonce(flow.Nonce), oauth2.S256ChallengeOption(flow.Verifier)), nil
+}
+
+// Exchange redeems an authorization code and returns the raw ID token
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := discovery.oauth2.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.client), code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrOIDCTokenExchange, err)
+	}
+	idToken, _ := token.Extra("id_token").(string)
+	if idToken == "" {
+		return "", fmt.Errorf("%w: response has no id_token", ErrOIDCTokenExchange)
+	}
+
+	return idToken, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, rawToken, nonce string) (*OIDCClaims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := discovery.verifier.Verify(ctx, rawToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+	if token.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if token.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	var payload struct {
+		Email             string `json:"email"`
+		EmailVerified     *bool  `json:"email_verified"`
+		Name              string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
+	}
+	if err := token.Claims(&payload); err != nil {
+		return nil, fmt.Errorf("%w: payload: %w", ErrInvalidIDToken, err)
+	}
+	var raw map[string]json.RawMessage
+	if err := token.Claims(&raw); err != nil {
+		return nil, fmt.Errorf("%w: payload: %w", ErrInvalidIDToken, err)
+	}
+
+	claims := &OIDCClaims{
+		Issuer:            token.Issuer,
+		Subject:           token.Subject,
+		Email:             payload.Email,
+		EmailVerified:     payload.EmailVerified,
+		Name:              payload.Name,
+		PreferredUsername: payload.PreferredUsername,
+	}
+	if groups, ok := raw[p.Config.GroupsClaim]; ok {
+		// a single group may be sent as a plain string
+		if err := json.Unmarshal(groups, &claims.Groups); err != nil {
+			var group string
+			if json.Unmarshal(groups, &group) == nil {
+				claims.Groups = []string{group}
+			}
+		}
+	}
+
+	return claims, nil
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	cached := p.discovery
+	p.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, p.client), p.Config.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOIDCDiscovery, err)
+	}
+	var metadata struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := provider.Claims(&metadata); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOIDCDiscovery, err)
+	}
+	endpoint := provider.Endpoint()
+	if endpoint.AuthURL == "" || endpoint.TokenURL == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: provider metadata is missing required endpoints", ErrOIDCDiscovery)
+	}
+	endpoint.AuthStyle = oauth2.AuthStyleInHeader
+	if p.Config.ClientSecret == "" {
+		// public client
+		endpoint.AuthStyle = oauth2.AuthStyleInParams
+	}
+
+	keys := &oidcKeySet{url: metadata.JWKSURI, client: p.client, log: p.log}
+	discovery := &oidcDiscovery{
+		oauth2: &oauth2.Config{
+			ClientID:     p.Config.ClientID,
+			ClientSecret: p.Config.ClientSecret,
+			Endpoint:     endpoint,
+			RedirectURL:  p.Config.RedirectURL,
+			Scopes:       p.Config.Scopes,
+		},
+		verifier: oidc.NewVerifier(metadata.Issuer, keys, &oidc.Config{
+			ClientID:             p.Config.ClientID,
+			SupportedSigningAlgs: oidcSigningAlgs,
+			// tokens that expired within oidcClockSkew are still accepted
+			Now: func() time.Time { return time.Now().Add(-oidcClockSkew) },
+		}),
+		keys: keys,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// concurrent logins may have discovered the provider meanwhile, keep the first result
+	if p.discovery == nil {
+		p.log.Info("discovered OIDC provider", "issuer", metadata.Issuer)
+		p.discovery = discovery
+	}
+	return p.discovery, nil
+}
+
+// oidcKeySet verifies ID token signatures with the keys published by the provider.
+// Unlike oidc.RemoteKeySet, a token naming an unknown key refreshes the set at most
+// once every oidcKeysRefreshInterval, so provider key rotation is picked up without
+// letting forged tokens hammer the provider.
+type oidcKeySet struct {
+	url    string
+	client *http.Client
+	log    *slog.Logger
+
+	// mu guards the cached keys, it is never held during requests to the provider
+	mu        sync.Mutex
+	keys      []jose.JSONWebKey
+	fetchedAt time.Time
+}
+
+// VerifySignature implements oidc.KeySet. The verifier already checked the token
+// has a single signature with one of oidcSigningAlgs.
+func (s *oidcKeySet) VerifySignature(ctx context.Context, jwt string) ([]byte, error) {
+	algs := make([]jose.SignatureAlgorithm, len(oidcSigningAlgs))
+	for i, alg := range oidcSigningAlgs {
+		algs[i] = jose.SignatureAlgorithm(alg)
+	}
+	jws, err := jose.ParseSigned(jwt, algs)
+	if err != nil {
+		return nil, err
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, errors.New("expected a single signature")
+	}
+
+	key, err := s.key(ctx, jws.Signatures[0].Header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	return jws.Verify(key)
+}
+
+func (s *oidcKeySet) key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	s.mu.Lock()
+	key, ok := s.lookup(kid)
+	if ok {
+		s.mu.Unlock()
+		return key, nil
+	}
+	if !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < oidcKeysRefreshInterval {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	// claimed before fetching, concurrent tokens with unknown keys do not refresh again
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := getOIDCJSON(ctx, s.client, s.url, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+
+	keys := make([]jose.JSONWebKey, 0, len(set.Keys))
+	for _, raw := range set.Keys {
+		var jwk jose.JSONWebKey
+		if err := jwk.UnmarshalJSON(raw); err != nil {
+			s.log.Warn("skipping unusable OIDC signing key", "error", err)
+			continue
+		}
+		if !jwk.IsPublic() || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	key, ok = s.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// lookup must be called with s.mu held. A token without a kid is accepted
+// when the provider publishes a single key.
+func (s *oidcKeySet) lookup(kid string) (*jose.JSONWebKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		return &s.keys[0], true
+	}
+	for i := range s.keys {
+		if s.keys[i].KeyID == kid {
+			return &s.keys[i], true
+		}
+	}
+	return nil, false
+}
+
+func getOIDCJSON(ctx context.Context, client *http.Client, rawURL string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s responded with status %d", rawURL, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxOIDCResponse)).Decode(v)
+}
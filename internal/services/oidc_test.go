@@ -0,0 +1,495 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+const (
+	testOIDCClientID     = "gowatch"
+	testOIDCClientSecret = "s3cret"
+	testOIDCRedirectURL  = "http://gowatch.test/auth/oidc/callback"
+)
+
+type mockOIDCGrant struct {
+	challenge string
+	nonce     string
+	claims    map[string]any
+}
+
+// mockOIDCProvider is a minimal OpenID Connect provider serving discovery,
+// a key set and a token endpoint that enforces PKCE and client authentication
+type mockOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu          sync.Mutex
+	grants      map[string]mockOIDCGrant
+	jwksFetches int
+	// kid is the key id sign puts in the token header, the key set publishes "test-key"
+	kid string
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOIDCProvider{key: key, grants: map[string]mockOIDCGrant{}, kid: "test-key"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.jwksFetches++
+		m.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != testOIDCClientID || secret != testOIDCClientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+
+		m.mu.Lock()
+		grant, ok := m.grants[r.FormValue("code")]
+		delete(m.grants, r.FormValue("code"))
+		m.mu.Unlock()
+
+		verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if !ok || r.FormValue("redirect_uri") != testOIDCRedirectURL ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != grant.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		claims := map[string]any{
+			"iss":   m.server.URL,
+			"aud":   testOIDCClientID,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": grant.nonce,
+		}
+		for k, v := range grant.claims {
+			claims[k] = v
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "opaque",
+			"token_type":   "Bearer",
+			"id_token":     m.sign(t, "RS256", claims),
+		})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// authorize plays the user logging in at the provider and returns the code
+// the browser would bring back to the callback
+func (m *mockOIDCProvider) authorize(t *testing.T, authURL string, claims map[string]any) string {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != testOIDCClientID {
+		t.Fatalf("unexpected authorization request: %s", authURL)
+	}
+
+	code := "code-" + query.Get("state")[:8]
+	m.mu.Lock()
+	m.grants[code] = mockOIDCGrant{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), claims: claims}
+	m.mu.Unlock()
+	return code
+}
+
+func (m *mockOIDCProvider) sign(t *testing.T, alg string, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": m.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (m *mockOIDCProvider) keySetFetches() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.jwksFetches
+}
+
+func newOIDCTestEnv(t *testing.T, cfg OIDCConfig) (*db.SqliteDB, *AuthService, *mockOIDCProvider) {
+	t.Helper()
+
+	provider := newMockOIDCProvider(t)
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+
+	cfg.Issuer = provider.server.URL
+	cfg.ClientID = testOIDCClientID
+	cfg.ClientSecret = testOIDCClientSecret
+	cfg.RedirectURL = testOIDCRedirectURL
+	authService.OIDC = NewOIDCProvider(cfg, provider.server.Client())
+
+	return testDB, authService, provider
+}
+
+func oidcLogin(t *testing.T, authService *AuthService, provider *mockOIDCProvider, claims map[string]any) (*models.User, error) {
+	t.Helper()
+
+	authURL, flow, err := authService.BeginOIDCLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := provider.authorize(t, authURL, claims)
+	return authService.CompleteOIDCLogin(context.Background(), code, flow)
+}
+
+func TestOIDC_ProvisionsUserAndReusesIdentity(t *testing.T) {
+	_, authService, provider := newOIDCTestEnv(t, OIDCConfig{AutoProvision: true})
+
+	user, err := oidcLogin(t, authService, provider, map[string]any{
+		"sub":            "user-1",
+		"email":          "sso@example.com",
+		"email_verified": true,
+		"name":           "SSO User",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "sso@example.com" || user.Name != "SSO User" {
+		t.Errorf("unexpected provisioned user: %+v", user)
+	}
+	if !user.Admin {
+		t.Error("expected the first user to become admin")
+	}
+
+	// the identity, not the email, identifies the user on later logins
+	again, err := oidcLogin(t, authService, provider, map[string]any{
+		"sub":   "user-1",
+		"email": "renamed@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != user.ID {
+		t.Errorf("expected the same user, got %d and %d", user.ID, again.ID)
+	}
+}
+
+func TestOIDC_LinksExistingUserByEmail(t *testing.T) {
+	testDB, authService, provider := newOIDCTestEnv(t, OIDCConfig{})
+	ctx := setupTestUser(t, testDB)
+
+	_, err := oidcLogin(t, authService, provider, map[string]any{
+		"sub":            "user-1",
+		"email":          "test@example.com",
+		"email_verified": false,
+	})
+	if !errors.Is(err, ErrOIDCEmailNotVerified) {
+		t.Fatalf("expected %v, got %v", ErrOIDCEmailNotVerified, err)
+	}
+
+	// a provider leaving the claim out does not prove the email either
+	_, err = oidcLogin(t, authService, provider, map[string]any{
+		"sub":   "user-1",
+		"email": "test@example.com",
+	})
+	if !errors.Is(err, ErrOIDCEmailNotVerified) {
+		t.Fatalf("expected %v without the email_verified claim, got %v", ErrOIDCEmailNotVerified, err)
+	}
+	if _, err := testDB.GetUserByIdentity(ctx, provider.server.URL, "user-1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected no identity to be linked, got %v", err)
+	}
+
+	user, err := oidcLogin(t, authService, provider, map[string]any{
+		"sub":            "user-1",
+		"email":          "test@example.com",
+		"email_verified": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing, err := common.GetUser(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != existing.ID {
+		t.Errorf("expected identity to be linked to user %d, got %d", existing.ID, user.ID)
+	}
+}
+
+func TestOIDC_UnknownIdentityWithoutAutoProvision(t *testing.T) {
+	_, authService, provider := newOIDCTestEnv(t, OIDCConfig{AutoProvision: false})
+
+	_, err := oidcLogin(t, authService, provider, map[string]any{"sub": "user-1", "email": "new@example.com"})
+	if !errors.Is(err, ErrOIDCUserNotFound) {
+		t.Errorf("expected %v, got %v", ErrOIDCUserNotFound, err)
+	}
+}
+
+func TestOIDC_AdminGroupSync(t *testing.T) {
+	testDB, authService, provider := newOIDCTestEnv(t, OIDCConfig{AutoProvision: true, AdminGroup: "gowatch-admins"})
+	// another admin, so the group member can be demoted
+	ctx := setupTestUser(t, testDB)
+	if err := testDB.SetAdmin(ctx, mustUser(t, ctx).ID); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := oidcLogin(t, authService, provider, map[string]any{
+		"sub":    "user-1",
+		"email":  "member@example.com",
+		"groups": []string{"staff", "gowatch-admins"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Admin {
+		t.Error("expected group member to become admin")
+	}
+
+	user, err = oidcLogin(t, authService, provider, map[string]any{
+		"sub":    "user-1",
+		"email":  "member@example.com",
+		"groups": []string{"staff"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := authService.GetUserByID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Admin || stored.Admin {
+		t.Error("expected admin to be removed when the user leaves the group")
+	}
+}
+
+func TestOIDC_AdminGroupSyncKeepsLastAdmin(t *testing.T) {
+	_, authService, provider := newOIDCTestEnv(t, OIDCConfig{AutoProvision: true, AdminGroup: "gowatch-admins"})
+
+	admin, err := oidcLogin(t, authService, provider, map[string]any{
+		"sub":    "user-1",
+		"email":  "admin@example.com",
+		"groups": []string{"gowatch-admins"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !admin.Admin {
+		t.Fatal("expected group member to be admin")
+	}
+
+	// leaving the group would leave the instance without admins
+	user, err := oidcLogin(t, authService, provider, map[string]any{
+		"sub":    "user-1",
+		"email":  "admin@example.com",
+		"groups": []string{"staff"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := authService.GetUserByID(context.Background(), admin.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Admin || !stored.Admin {
+		t.Error("expected the last admin to keep the role")
+	}
+}
+
+func TestOIDC_ExchangeRequiresPKCEVerifier(t *testing.T) {
+	_, authService, provider := newOIDCTestEnv(t, OIDCConfig{AutoProvision: true})
+
+	authURL, flow, err := authService.BeginOIDCLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := provider.authorize(t, authURL, map[string]any{"sub": "user-1", "email": "a@example.com"})
+
+	flow.Verifier = strings.Repeat("0", len(flow.Verifier))
+	_, err = authService.CompleteOIDCLogin(context.Background(), code, flow)
+	if !errors.Is(err, ErrOIDCTokenExchange) {
+		t.Errorf("expected %v, got %v", ErrOIDCTokenExchange, err)
+	}
+}
+
+func TestOIDC_VerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	_, authService, provider := newOIDCTestEnv(t, OIDCConfig{})
+	oidc := authService.OIDC
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss":   provider.server.URL,
+			"aud":   []string{"other", testOIDCClientID},
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": "n0nce",
+			"sub":   "user-1",
+		}
+	}
+	if _, err := oidc.VerifyIDToken(context.Background(), provider.sign(t, "RS256", valid()), "n0nce"); err != nil {
+		t.Fatalf("expected valid token to verify, got %v", err)
+	}
+
+	forged := newMockOIDCProvider(t)
+
+	tests := []struct {
+		name  string
+		token func() string
+		want  error
+	}{
+		{"wrong nonce", func() string {
+			c := valid()
+			c["nonce"] = "replayed"
+			return provider.sign(t, "RS256", c)
+		}, ErrInvalidIDToken},
+		{"wrong audience", func() string {
+			c := valid()
+			c["aud"] = "other"
+			return provider.sign(t, "RS256", c)
+		}, ErrInvalidIDToken},
+		{"wrong issuer", func() string {
+			c := valid()
+			c["iss"] = "https://evil.example.com"
+			return provider.sign(t, "RS256", c)
+		}, ErrInvalidIDToken},
+		{"expired", func() string {
+			c := valid()
+			c["exp"] = time.Now().Add(-time.Hour).Unix()
+			return provider.sign(t, "RS256", c)
+		}, ErrInvalidIDToken},
+		{"not yet valid", func() string {
+			c := valid()
+			c["nbf"] = time.Now().Add(time.Hour).Unix()
+			return provider.sign(t, "RS256", c)
+		}, ErrInvalidIDToken},
+		{"missing subject", func() string {
+			c := valid()
+			delete(c, "sub")
+			return provider.sign(t, "RS256", c)
+		}, ErrInvalidIDToken},
+		{"signed by another key", func() string { return forged.sign(t, "RS256", valid()) }, ErrInvalidIDToken},
+		{"tampered payload", func() string {
+			parts := strings.Split(provider.sign(t, "RS256", valid()), ".")
+			c := valid()
+			c["sub"] = "user-2"
+			payload, _ := json.Marshal(c)
+			return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+		}, ErrInvalidIDToken},
+		{"unsigned", func() string {
+			parts := strings.Split(provider.sign(t, "none", valid()), ".")
+			return parts[0] + "." + parts[1] + "."
+		}, ErrInvalidIDToken},
+		{"HMAC algorithm", func() string { return provider.sign(t, "HS256", valid()) }, ErrInvalidIDToken},
+		{"algorithm not matching the key", func() string { return provider.sign(t, "ES256", valid()) }, ErrInvalidIDToken},
+		{"malformed", func() string { return "not.a.jwt" }, ErrInvalidIDToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := oidc.VerifyIDToken(context.Background(), tt.token(), "n0nce")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestOIDC_UnknownKeysRefreshKeySetOncePerInterval(t *testing.T) {
+	_, authService, provider := newOIDCTestEnv(t, OIDCConfig{})
+	oidc := authService.OIDC
+
+	claims := map[string]any{
+		"iss":   provider.server.URL,
+		"aud":   testOIDCClientID,
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": "n0nce",
+		"sub":   "user-1",
+	}
+	if _, err := oidc.VerifyIDToken(context.Background(), provider.sign(t, "RS256", claims), "n0nce"); err != nil {
+		t.Fatal(err)
+	}
+
+	provider.kid = "rotated"
+	for range 5 {
+		if _, err := oidc.VerifyIDToken(context.Background(), provider.sign(t, "RS256", claims), "n0nce"); !errors.Is(err, ErrInvalidIDToken) {
+			t.Fatalf("expected %v for an unknown key, got %v", ErrInvalidIDToken, err)
+		}
+	}
+	if fetches := provider.keySetFetches(); fetches != 1 {
+		t.Errorf("expected the key set to be fetched once, got %d fetches", fetches)
+	}
+
+	// once the interval has passed an unknown key refreshes the set again
+	keys := oidc.discovery.keys
+	keys.mu.Lock()
+	keys.fetchedAt = time.Now().Add(-oidcKeysRefreshInterval)
+	keys.mu.Unlock()
+	if _, err := oidc.VerifyIDToken(context.Background(), provider.sign(t, "RS256", claims), "n0nce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("expected %v for an unknown key, got %v", ErrInvalidIDToken, err)
+	}
+	if fetches := provider.keySetFetches(); fetches != 2 {
+		t.Errorf("expected the key set to be refreshed after the interval, got %d fetches", fetches)
+	}
+}
+
+func TestAuthService_PasswordLoginDisabled(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	setupTestUser(t, testDB)
+	authService.PasswordLoginDisabled = true
+
+	if _, err := authService.AuthenticateUser(context.Background(), "test@example.com", "hash", "192.0.2.1"); !errors.Is(err, ErrPasswordLoginDisabled) {
+		t.Errorf("expected %v, got %v", ErrPasswordLoginDisabled, err)
+	}
+	if err := authService.CheckRegistration(context.Background(), ""); !errors.Is(err, ErrPasswordLoginDisabled) {
+		t.Errorf("expected %v, got %v", ErrPasswordLoginDisabled, err)
+	}
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+const (
+	passkeyChallengeExpiry = 5 * time.Minute
+	maxPasskeyNameLength   = 64
+)
+
+var (
+	ErrInvalidPasskey          = errors.New("passkey verification failed")
+	ErrPasskeyChallengeInvalid = errors.New("passkey challenge is invalid or expired")
+	ErrPasskeyNotFound         = errors.New("passkey not found")
+	ErrInvalidPasskeyName      = errors.New("passkey name must be between 1 and 64 characters")
+)
+
+var base64URL = base64.RawURLEncoding
+
+// passkeyCredentialParams are the key algorithms offered to authenticators, registrations
+// with any other algorithm are refused
+var passkeyCredentialParams = []protocol.CredentialParameter{
+	{Type: protocol.PublicKeyCredentialType, Algorithm: webauthncose.AlgES256},
+	{Type: protocol.PublicKeyCredentialType, Algorithm: webauthncose.AlgEdDSA},
+	{Type: protocol.PublicKeyCredentialType, Algorithm: webauthncose.AlgRS256},
+}
+
+// passkeyUser adapts an user and their passkeys to webauthn.User
+type passkeyUser struct {
+	user     *models.User
+	passkeys []models.Passkey
+}
+
+func (u passkeyUser) WebAuthnID() []byte {
+	return passkeyUserHandle(u.user.ID)
+}
+
+func (u passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u passkeyUser) WebAuthnDisplayName() string {
+	return u.user.Name
+}
+
+func (u passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.passkeys))
+	for _, passkey := range u.passkeys {
+		id, err := base64URL.DecodeString(passkey.CredentialID)
+		if err != nil {
+			continue
+		}
+		credentials = append(credentials, webauthn.Credential{
+			ID:            id,
+			PublicKey:     passkey.PublicKey,
+			Flags:         webauthn.CredentialFlags{BackupEligible: passkey.BackupEligible},
+			Authenticator: webauthn.Authenticator{SignCount: uint32(passkey.SignCount)},
+		})
+	}
+	return credentials
+}
+
+// BeginPasskeyRegistration starts adding a passkey to the user in ctx. origin is the
+// web origin the browser runs the ceremony on, its host is the relying party ID.
+func (a *AuthService) BeginPasskeyRegistration(ctx context.Context, origin string) (*protocol.PublicKeyCredentialCreationOptions, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	relyingParty, err := newPasskeyRelyingParty(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := a.db.GetPasskeysByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkeys: %w", err)
+	}
+	owner := passkeyUser{user: user, passkeys: existing}
+
+	creation, session, err := relyingParty.BeginRegistration(owner,
+		webauthn.WithCredentialParameters(passkeyCredentialParams),
+		webauthn.WithExclusions(webauthn.Credentials(owner.WebAuthnCredentials()).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start passkey registration: %w", err)
+	}
+
+	if err := a.storePasskeyChallenge(ctx, session.Challenge, &user.ID); err != nil {
+		return nil, err
+	}
+	return &creation.Response, nil
+}
+
+// FinishPasskeyRegistration verifies the new credential returned by the browser and stores it.
+// Attestation statements are not checked, any authenticator model is accepted.
+func (a *AuthService) FinishPasskeyRegistration(ctx context.Context, origin, name string, credentialJSON []byte) (*models.Passkey, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	if len(name) > maxPasskeyNameLength {
+		return nil, ErrInvalidPasskeyName
+	}
+
+	relyingParty, err := newPasskeyRelyingParty(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(credentialJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+	session, err := a.claimPasskeyChallenge(ctx, parsed.Response.CollectedClientData, &user.ID)
+	if err != nil {
+		return nil, err
+	}
+	session.CredParams = passkeyCredentialParams
+
+	credential, err := relyingParty.CreateCredential(passkeyUser{user: user}, *session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+	credentialID := base64URL.EncodeToString(credential.ID)
+
+	_, err = a.db.GetPasskeyByCredentialID(ctx, credentialID)
+	if err == nil {
+		return nil, fmt.Errorf("%w: credential already registered", ErrInvalidPasskey)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check existing passkeys: %w", err)
+	}
+
+	passkey, err := a.db.CreatePasskey(ctx, models.Passkey{
+		UserID:         user.ID,
+		CredentialID:   credentialID,
+		PublicKey:      credential.PublicKey,
+		SignCount:      int64(credential.Authenticator.SignCount),
+		BackupEligible: credential.Flags.BackupEligible,
+		Name:           name,
+		CreatedAt:      time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store passkey: %w", err)
+	}
+
+	a.log.Info("passkey registered", "userID", user.ID, "passkeyID", passkey.ID)
+	return passkey, nil
+}
+
+// BeginPasskeyLogin starts a passkey sign-in on origin. No credentials are listed,
+// so the browser offers every discoverable passkey of the site.
+func (a *AuthService) BeginPasskeyLogin(ctx context.Context, origin string) (*protocol.PublicKeyCredentialRequestOptions, error) {
+	relyingParty, err := newPasskeyRelyingParty(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, session, err := relyingParty.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start passkey login: %w", err)
+	}
+
+	if err := a.storePasskeyChallenge(ctx, session.Challenge, nil); err != nil {
+		return nil, err
+	}
+	return &assertion.Response, nil
+}
+
+// FinishPasskeyLogin verifies the assertion returned by the browser and returns the user owning the passkey
+func (a *AuthService) FinishPasskeyLogin(ctx context.Context, origin string, credentialJSON []byte) (*models.User, error) {
+	relyingParty, err := newPasskeyRelyingParty(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(credentialJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+	session, err := a.claimPasskeyChallenge(ctx, parsed.Response.CollectedClientData, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey, err := a.db.GetPasskeyByCredentialID(ctx, base64URL.EncodeToString(parsed.RawID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPasskeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkey: %w", err)
+	}
+	user, err := a.GetUserByID(ctx, passkey.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := func(rawID, userHandle []byte) (webauthn.User, error) {
+		return passkeyUser{user: user, passkeys: []models.Passkey{*passkey}}, nil
+	}
+	credential, err := relyingParty.ValidateDiscoverableLogin(owner, *session, parsed)
+	if err != nil {
+		a.Audit.RecordAs(ctx, models.AuditLoginFailed, nil, a.auditTarget(ctx, passkey.UserID), "invalid passkey assertion")
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+
+	// authenticators that keep a counter must increase it, otherwise the key may have been cloned.
+	// Synced passkeys always report zero.
+	if credential.Authenticator.CloneWarning {
+		a.log.Warn("passkey sign count did not increase", "passkeyID", passkey.ID, "stored", passkey.SignCount, "received", parsed.Response.AuthenticatorData.Counter)
+		a.Audit.RecordAs(ctx, models.AuditLoginFailed, nil, a.auditTarget(ctx, passkey.UserID), "passkey sign count did not increase, it may have been cloned")
+		return nil, fmt.Errorf("%w: sign count did not increase", ErrInvalidPasskey)
+	}
+
+	if err := a.db.UpdatePasskeyUsage(ctx, passkey.ID, int64(credential.Authenticator.SignCount), time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("failed to update passkey usage: %w", err)
+	}
+
+	if err := a.checkEnabled(ctx, user); err != nil {
+		return nil, err
+	}
+
+	a.log.Info("user signed in with passkey", "userID", passkey.UserID, "passkeyID", passkey.ID)
+	return user, nil
+}
+
+func (a *AuthService) GetPasskeys(ctx context.Context) ([]models.Passkey, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys, err := a.db.GetPasskeysByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkeys: %w", err)
+	}
+	return passkeys, nil
+}
+
+func (a *AuthService) RenamePasskey(ctx context.Context, passkeyID int64, name string) error {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxPasskeyNameLength {
+		return ErrInvalidPasskeyName
+	}
+
+	rows, err := a.db.RenamePasskey(ctx, user.ID, passkeyID, name)
+	if err != nil {
+		return fmt.Errorf("failed to rename passkey %d: %w", passkeyID, err)
+	}
+	if rows == 0 {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+func (a *AuthService) DeletePasskey(ctx context.Context, passkeyID int64) error {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows, err := a.db.DeletePasskey(ctx, user.ID, passkeyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete passkey %d: %w", passkeyID, err)
+	}
+	if rows == 0 {
+		return ErrPasskeyNotFound
+	}
+
+	a.log.Info("passkey revoked", "userID", user.ID, "passkeyID", passkeyID)
+	return nil
+}
+
+func (a *AuthService) storePasskeyChallenge(ctx context.Context, challenge string, userID *int64) error {
+	err := a.db.CreateWebAuthnChallenge(ctx, challenge, userID, time.Now().UTC().Add(passkeyChallengeExpiry))
+	if err != nil {
+		return fmt.Errorf("failed to store passkey challenge: %w", err)
+	}
+	return nil
+}
+
+// claimPasskeyChallenge consumes the challenge the client data answers and returns the
+// session WebAuthn verifies the ceremony against. Registration challenges belong to
+// userID, login challenges to nobody.
+func (a *AuthService) claimPasskeyChallenge(ctx context.Context, clientData protocol.CollectedClientData, userID *int64) (*webauthn.SessionData, error) {
+	// WebAuthn does not check this unless top origins are configured
+	if clientData.CrossOrigin {
+		return nil, fmt.Errorf("%w: cross-origin ceremony", ErrInvalidPasskey)
+	}
+
+	owner, err := a.db.ClaimWebAuthnChallenge(ctx, clientData.Challenge, time.Now().UTC())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPasskeyChallengeInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim passkey challenge: %w", err)
+	}
+	if (owner == nil) != (userID == nil) || (owner != nil && *owner != *userID) {
+		return nil, ErrPasskeyChallengeInvalid
+	}
+
+	session := &webauthn.SessionData{
+		Challenge:        clientData.Challenge,
+		UserVerification: protocol.VerificationPreferred,
+	}
+	if userID != nil {
+		session.UserID = passkeyUserHandle(*userID)
+	}
+	return session, nil
+}
+
+// newPasskeyRelyingParty configures WebAuthn for origin. The relying party ID is its
+// host without port. Challenges expire through the database, not through WebAuthn.
+func newPasskeyRelyingParty(origin string) (*webauthn.WebAuthn, error) {
+	u, err := url.Parse(origin)
+	if err != nil || u.Hostname() == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("%w: invalid origin %q", ErrInvalidPasskey, origin)
+	}
+
+	timeout := webauthn.TimeoutConfig{Timeout: passkeyChallengeExpiry, TimeoutUVD: passkeyChallengeExpiry}
+	return webauthn.New(&webauthn.Config{
+		RPID:                  u.Hostname(),
+		RPDisplayName:         "Gowatch",
+		RPOrigins:             []string{origin},
+		AttestationPreference: protocol.PreferNoAttestation,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			UserVerification:   protocol.VerificationPreferred,
+		},
+		Timeouts: webauthn.TimeoutsConfig{Login: timeout, Registration: timeout},
+	})
+}
+
+// passkeyUserHandle is the opaque WebAuthn user ID of an user
+func passkeyUserHandle(userID int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
@@ -0,0 +1,386 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+const testPasskeyOrigin = "https://gowatch.example.com"
+
+// softAuthenticator is a software WebAuthn authenticator holding a single discoverable credential.
+// EdDSA authenticators act like synced passkeys, they are backed up and never count signatures.
+type softAuthenticator struct {
+	ecKey        *ecdsa.PrivateKey
+	edKey        ed25519.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+	// origin written in the client data, defaults to testPasskeyOrigin
+	origin string
+}
+
+// softCredential is the JSON the browser script posts for a credential, unused fields are sent empty
+type softCredential struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AttestationObject string `json:"attestationObject"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+func newSoftAuthenticator(t *testing.T, ed bool) *softAuthenticator {
+	t.Helper()
+
+	authenticator := &softAuthenticator{credentialID: make([]byte, 16), origin: testPasskeyOrigin}
+	if _, err := rand.Read(authenticator.credentialID); err != nil {
+		t.Fatal(err)
+	}
+
+	var err error
+	if ed {
+		_, authenticator.edKey, err = ed25519.GenerateKey(rand.Reader)
+	} else {
+		authenticator.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authenticator
+}
+
+func (s *softAuthenticator) coseKey(t *testing.T) []byte {
+	t.Helper()
+
+	var key any
+	if s.edKey != nil {
+		key = webauthncose.OKPPublicKeyData{
+			PublicKeyData: webauthncose.PublicKeyData{KeyType: int64(webauthncose.OctetKey), Algorithm: int64(webauthncose.AlgEdDSA)},
+			Curve:         int64(webauthncose.Ed25519),
+			XCoord:        s.edKey.Public().(ed25519.PublicKey),
+		}
+	} else {
+		x, y := make([]byte, 32), make([]byte, 32)
+		s.ecKey.X.FillBytes(x)
+		s.ecKey.Y.FillBytes(y)
+		key = webauthncose.EC2PublicKeyData{
+			PublicKeyData: webauthncose.PublicKeyData{KeyType: int64(webauthncose.EllipticKey), Algorithm: int64(webauthncose.AlgES256)},
+			Curve:         int64(webauthncose.P256),
+			XCoord:        x,
+			YCoord:        y,
+		}
+	}
+	return mustMarshalCBOR(t, key)
+}
+
+func (s *softAuthenticator) authenticatorData(flags protocol.AuthenticatorFlags) []byte {
+	if s.edKey != nil {
+		flags |= protocol.FlagBackupEligible | protocol.FlagBackupState
+	}
+	rpIDHash := sha256.Sum256([]byte("gowatch.example.com"))
+	data := append(rpIDHash[:], byte(flags))
+	return binary.BigEndian.AppendUint32(data, s.signCount)
+}
+
+func (s *softAuthenticator) clientData(t *testing.T, ceremony protocol.CeremonyType, challenge protocol.URLEncodedBase64) []byte {
+	t.Helper()
+
+	return mustMarshal(t, protocol.CollectedClientData{Type: ceremony, Challenge: challenge.String(), Origin: s.origin})
+}
+
+func (s *softAuthenticator) credential() softCredential {
+	var credential softCredential
+	credential.ID = base64URL.EncodeToString(s.credentialID)
+	credential.RawID = credential.ID
+	credential.Type = string(protocol.PublicKeyCredentialType)
+	return credential
+}
+
+func (s *softAuthenticator) create(t *testing.T, options *protocol.PublicKeyCredentialCreationOptions) []byte {
+	t.Helper()
+
+	s.userHandle = options.User.ID.(protocol.URLEncodedBase64)
+	authData := s.authenticatorData(protocol.FlagUserPresent | protocol.FlagUserVerified | protocol.FlagAttestedCredentialData)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(s.credentialID)))
+	authData = append(authData, s.credentialID...)
+	authData = append(authData, s.coseKey(t)...)
+
+	attestationObject := mustMarshalCBOR(t, map[string]any{"fmt": "none", "attStmt": map[string]any{}, "authData": authData})
+
+	credential := s.credential()
+	credential.Response.ClientDataJSON = base64URL.EncodeToString(s.clientData(t, protocol.CreateCeremony, options.Challenge))
+	credential.Response.AttestationObject = base64URL.EncodeToString(attestationObject)
+	return mustMarshal(t, credential)
+}
+
+func (s *softAuthenticator) get(t *testing.T, options *protocol.PublicKeyCredentialRequestOptions) []byte {
+	t.Helper()
+
+	if s.ecKey != nil {
+		s.signCount++
+	}
+	authData := s.authenticatorData(protocol.FlagUserPresent | protocol.FlagUserVerified)
+	clientData := s.clientData(t, protocol.AssertCeremony, options.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	signed := append(append([]byte{}, authData...), clientDataHash[:]...)
+
+	var signature []byte
+	if s.edKey != nil {
+		signature = ed25519.Sign(s.edKey, signed)
+	} else {
+		digest := sha256.Sum256(signed)
+		var err error
+		signature, err = ecdsa.SignASN1(rand.Reader, s.ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	credential := s.credential()
+	credential.Response.ClientDataJSON = base64URL.EncodeToString(clientData)
+	credential.Response.AuthenticatorData = base64URL.EncodeToString(authData)
+	credential.Response.Signature = base64URL.EncodeToString(signature)
+	credential.Response.UserHandle = base64URL.EncodeToString(s.userHandle)
+	return mustMarshal(t, credential)
+}
+
+func mustMarshalCBOR(t *testing.T, value any) []byte {
+	t.Helper()
+
+	data, err := webauthncbor.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func mustMarshal(t *testing.T, value any) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// registerPasskey adds a passkey from authenticator to the user in ctx
+func registerPasskey(t *testing.T, ctx context.Context, authService *AuthService, authenticator *softAuthenticator) *models.Passkey {
+	t.Helper()
+
+	options, err := authService.BeginPasskeyRegistration(ctx, testPasskeyOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passkey, err := authService.FinishPasskeyRegistration(ctx, testPasskeyOrigin, "Laptop", authenticator.create(t, options))
+	if err != nil {
+		t.Fatalf("expected passkey to be registered, got %v", err)
+	}
+	return passkey
+}
+
+func loginWithPasskey(t *testing.T, authService *AuthService, authenticator *softAuthenticator) (*models.User, error) {
+	t.Helper()
+
+	options, err := authService.BeginPasskeyLogin(context.Background(), testPasskeyOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authService.FinishPasskeyLogin(context.Background(), testPasskeyOrigin, authenticator.get(t, options))
+}
+
+func TestAuthService_PasskeyRegistrationAndLogin(t *testing.T) {
+	for name, ed := range map[string]bool{"ES256": false, "EdDSA": true} {
+		t.Run(name, func(t *testing.T) {
+			testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+			ctx := setupTestUser(t, testDB)
+			user := mustUser(t, ctx)
+			authenticator := newSoftAuthenticator(t, ed)
+
+			options, err := authService.BeginPasskeyRegistration(ctx, testPasskeyOrigin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if options.RelyingParty.ID != "gowatch.example.com" || !bytes.Equal(options.User.ID.(protocol.URLEncodedBase64), passkeyUserHandle(user.ID)) {
+				t.Fatalf("unexpected creation options %+v", options)
+			}
+			if _, err := authService.FinishPasskeyRegistration(ctx, testPasskeyOrigin, "Laptop", authenticator.create(t, options)); err != nil {
+				t.Fatalf("expected passkey to be registered, got %v", err)
+			}
+
+			passkeys, err := authService.GetPasskeys(ctx)
+			if err != nil || len(passkeys) != 1 || passkeys[0].Name != "Laptop" || passkeys[0].LastUsedAt != nil {
+				t.Fatalf("expected one unused passkey named Laptop, got %+v, %v", passkeys, err)
+			}
+
+			loggedIn, err := loginWithPasskey(t, authService, authenticator)
+			if err != nil {
+				t.Fatalf("expected passkey login to succeed, got %v", err)
+			}
+			if loggedIn.ID != user.ID {
+				t.Errorf("expected user %d, got %d", user.ID, loggedIn.ID)
+			}
+
+			passkeys, _ = authService.GetPasskeys(ctx)
+			if passkeys[0].LastUsedAt == nil || passkeys[0].SignCount != int64(authenticator.signCount) {
+				t.Errorf("expected usage to be recorded, got %+v", passkeys[0])
+			}
+		})
+	}
+}
+
+func TestAuthService_PasskeyRegistrationRejectsWrongOrigin(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	authenticator := newSoftAuthenticator(t, false)
+	authenticator.origin = "https://evil.example.com"
+
+	options, err := authService.BeginPasskeyRegistration(ctx, testPasskeyOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = authService.FinishPasskeyRegistration(ctx, testPasskeyOrigin, "Laptop", authenticator.create(t, options))
+	if !errors.Is(err, ErrInvalidPasskey) {
+		t.Errorf("expected %v, got %v", ErrInvalidPasskey, err)
+	}
+}
+
+func TestAuthService_PasskeyLoginRejectsWrongRelyingParty(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	authenticator := newSoftAuthenticator(t, false)
+	registerPasskey(t, ctx, authService, authenticator)
+
+	// the client data claims the other origin, but the authenticator data is scoped to gowatch.example.com
+	const otherOrigin = "https://other.example.com"
+	authenticator.origin = otherOrigin
+	options, err := authService.BeginPasskeyLogin(context.Background(), otherOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = authService.FinishPasskeyLogin(context.Background(), otherOrigin, authenticator.get(t, options))
+	if !errors.Is(err, ErrInvalidPasskey) {
+		t.Errorf("expected %v, got %v", ErrInvalidPasskey, err)
+	}
+}
+
+func TestAuthService_PasskeyChallengeIsSingleUse(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	authenticator := newSoftAuthenticator(t, false)
+	registerPasskey(t, ctx, authService, authenticator)
+
+	options, err := authService.BeginPasskeyLogin(context.Background(), testPasskeyOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertion := authenticator.get(t, options)
+	if _, err := authService.FinishPasskeyLogin(context.Background(), testPasskeyOrigin, assertion); err != nil {
+		t.Fatalf("expected first login to succeed, got %v", err)
+	}
+	_, err = authService.FinishPasskeyLogin(context.Background(), testPasskeyOrigin, assertion)
+	if !errors.Is(err, ErrPasskeyChallengeInvalid) {
+		t.Errorf("expected replayed assertion to be rejected with %v, got %v", ErrPasskeyChallengeInvalid, err)
+	}
+
+	// a registration challenge cannot be answered with an assertion
+	registration, err := authService.BeginPasskeyRegistration(ctx, testPasskeyOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = authService.FinishPasskeyLogin(context.Background(), testPasskeyOrigin,
+		authenticator.get(t, &protocol.PublicKeyCredentialRequestOptions{Challenge: registration.Challenge}))
+	if !errors.Is(err, ErrPasskeyChallengeInvalid) {
+		t.Errorf("expected registration challenge to be rejected with %v, got %v", ErrPasskeyChallengeInvalid, err)
+	}
+}
+
+func TestAuthService_PasskeySignCountRegression(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	authenticator := newSoftAuthenticator(t, false)
+	registerPasskey(t, ctx, authService, authenticator)
+
+	if _, err := loginWithPasskey(t, authService, authenticator); err != nil {
+		t.Fatal(err)
+	}
+
+	// a cloned authenticator reports a counter the server has already seen
+	authenticator.signCount--
+	if _, err := loginWithPasskey(t, authService, authenticator); !errors.Is(err, ErrInvalidPasskey) {
+		t.Errorf("expected %v, got %v", ErrInvalidPasskey, err)
+	}
+}
+
+func TestAuthService_PasskeyRejectsBadSignature(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	authenticator := newSoftAuthenticator(t, false)
+	registerPasskey(t, ctx, authService, authenticator)
+
+	// register the same credential ID, then sign with a different key
+	impostor := newSoftAuthenticator(t, false)
+	impostor.credentialID = authenticator.credentialID
+	impostor.userHandle = authenticator.userHandle
+	if _, err := loginWithPasskey(t, authService, impostor); !errors.Is(err, ErrInvalidPasskey) {
+		t.Errorf("expected %v, got %v", ErrInvalidPasskey, err)
+	}
+}
+
+func TestAuthService_PasskeyRenameAndRevoke(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	authenticator := newSoftAuthenticator(t, false)
+	passkey := registerPasskey(t, ctx, authService, authenticator)
+
+	other, err := testDB.CreateUser(context.Background(), "other@example.com", "Other", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCtx := context.WithValue(context.Background(), common.UserKey, other)
+
+	if err := authService.RenamePasskey(otherCtx, passkey.ID, "Mine"); !errors.Is(err, ErrPasskeyNotFound) {
+		t.Errorf("expected other users not to rename the passkey, got %v", err)
+	}
+	if err := authService.DeletePasskey(otherCtx, passkey.ID); !errors.Is(err, ErrPasskeyNotFound) {
+		t.Errorf("expected other users not to revoke the passkey, got %v", err)
+	}
+
+	if err := authService.RenamePasskey(ctx, passkey.ID, " "); !errors.Is(err, ErrInvalidPasskeyName) {
+		t.Errorf("expected %v, got %v", ErrInvalidPasskeyName, err)
+	}
+	if err := authService.RenamePasskey(ctx, passkey.ID, "Phone"); err != nil {
+		t.Fatal(err)
+	}
+	passkeys, _ := authService.GetPasskeys(ctx)
+	if len(passkeys) != 1 || passkeys[0].Name != "Phone" {
+		t.Errorf("expected passkey to be renamed, got %+v", passkeys)
+	}
+
+	if err := authService.DeletePasskey(ctx, passkey.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loginWithPasskey(t, authService, authenticator); !errors.Is(err, ErrPasskeyNotFound) {
+		t.Errorf("expected revoked passkey to be rejected with %v, got %v", ErrPasskeyNotFound, err)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+const (
+	// DefaultPasswordResetExpiry is how long an emailed reset link works
+	DefaultPasswordResetExpiry = time.Hour
+	// passwordResetsPerHour caps the reset emails an account receives, so the form cannot
+	// be used to flood a mailbox
+	passwordResetsPerHour = 3
+)
+
+var (
+	ErrPasswordResetUnavailable = errors.New("password reset by email is not configured")
+	ErrInvalidPasswordReset     = errors.New("password reset link is invalid, expired or already used")
+)
+
+// passwordResetEmail is the data of the password_reset email template
+type passwordResetEmail struct {
+	Name        string
+	Link        string
+	ExpiresIn   string
+	SentByAdmin bool
+}
+
+// PasswordResetByEmail reports whether password reset links can be emailed
+func (a *AuthService) PasswordResetByEmail() bool {
+	return a.Mail != nil && a.PublicURL != "" && !a.PasswordLoginDisabled
+}
+
+// RequestPasswordReset emails a reset link to the account of email. Unknown and disabled
+// accounts are skipped without an error, so callers cannot tell which emails are registered.
+func (a *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	if !a.PasswordResetByEmail() {
+		return ErrPasswordResetUnavailable
+	}
+
+	user, err := a.db.GetUserByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		a.log.Info("password reset requested for an unknown email")
+		return nil
+	}
+	if user.Disabled() {
+		a.log.Info("password reset requested for a disabled account", "userID", user.ID)
+		return nil
+	}
+
+	recent, err := a.db.CountPasswordResetsSince(ctx, user.ID, time.Now().UTC().Add(-time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to count password resets of user %d: %w", user.ID, err)
+	}
+	if recent >= passwordResetsPerHour {
+		a.log.Warn("too many password reset requests, not sending another email", "userID", user.ID)
+		return nil
+	}
+
+	return a.sendPasswordReset(ctx, user, false)
+}
+
+// EmailPasswordReset emails a reset link to userID on behalf of the admin in ctx
+func (a *AuthService) EmailPasswordReset(ctx context.Context, userID int64) error {
+	if !a.PasswordResetByEmail() {
+		return ErrPasswordResetUnavailable
+	}
+
+	user, err := a.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return a.sendPasswordReset(ctx, user, true)
+}
+
+func (a *AuthService) sendPasswordReset(ctx context.Context, user *models.User, sentByAdmin bool) error {
+	// same entropy as session IDs, and stored hashed like them
+	token, err := generateSessionID()
+	if err != nil {
+		return fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if err := a.db.CreatePasswordReset(ctx, hashSessionToken(token), user.ID, now, now.Add(a.PasswordResetExpiry)); err != nil {
+		return err
+	}
+
+	err = a.Mail.Send(ctx, user.Email, "password_reset", passwordResetEmail{
+		Name:        user.Name,
+		Link:        a.PublicURL + "/reset-password?token=" + url.QueryEscape(token),
+		ExpiresIn:   expiryText(a.PasswordResetExpiry),
+		SentByAdmin: sentByAdmin,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to email password reset link to user %d: %w", user.ID, err)
+	}
+
+	detail := "requested by the user"
+	if sentByAdmin {
+		detail = "sent by an admin"
+	}
+	a.Audit.Record(ctx, models.AuditPasswordResetSent, user, detail)
+	return nil
+}
+
+// CheckPasswordReset returns ErrInvalidPasswordReset unless token is an unused, unexpired reset link
+func (a *AuthService) CheckPasswordReset(ctx context.Context, token string) error {
+	_, err := a.db.GetPasswordResetUserID(ctx, hashSessionToken(token), time.Now().UTC())
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidPasswordReset
+	}
+	return err
+}
+
+// ResetPassword sets the password of the account the reset link token was sent to and uses
+// the link up. Proving access to the mailbox also lifts a login lockout.
+func (a *AuthService) ResetPassword(ctx context.Context, token, password string) (*models.User, error) {
+	userID, err := a.db.ClaimPasswordReset(ctx, hashSessionToken(token), time.Now().UTC())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidPasswordReset
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkEnabled(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if err := a.setPassword(ctx, userID, password); err != nil {
+		return nil, err
+	}
+	if err := a.ClearPasswordResetRequired(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := a.UnlockUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	a.Audit.RecordAs(ctx, models.AuditPasswordChanged, user, user, "with an emailed reset link")
+	return user, nil
+}
+
+// expiryText describes d in minutes, hours or days, whichever reads best
+func expiryText(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute")
+	case d < 48*time.Hour:
+		return plural(int(d.Hours()), "hour")
+	default:
+		return plural(int(d.Hours()/24), "day")
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
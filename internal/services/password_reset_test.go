@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var resetLinkPattern = regexp.MustCompile(`https://gowatch\.example\.com/reset-password\?token=\S+`)
+
+func newPasswordResetTestService(t *testing.T) (*AuthService, *smtpSink) {
+	t.Helper()
+
+	_, authService := newAuthTestService(t, models.RegistrationOpen)
+	sink := newSMTPSink(t)
+	authService.Mail = sink.mailService(t)
+	authService.PublicURL = "https://gowatch.example.com"
+	return authService, sink
+}
+
+// resetToken reads the token of the reset link in the next email of sink
+func resetToken(t *testing.T, sink *smtpSink) string {
+	t.Helper()
+
+	link := resetLinkPattern.FindString(mailParts(t, sink.next(t))["text/plain"])
+	if link == "" {
+		t.Fatal("expected a reset link in the email")
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Query().Get("token")
+}
+
+func TestAuthService_PasswordResetUnavailableWithoutMail(t *testing.T) {
+	_, authService := newAuthTestService(t, models.RegistrationOpen)
+
+	if authService.PasswordResetByEmail() {
+		t.Error("expected password reset by email to be off without SMTP")
+	}
+	if err := authService.RequestPasswordReset(context.Background(), "user@example.com"); !errors.Is(err, ErrPasswordResetUnavailable) {
+		t.Errorf("expected %v, got %v", ErrPasswordResetUnavailable, err)
+	}
+}
+
+func TestAuthService_ResetPasswordWithEmailedLink(t *testing.T) {
+	authService, sink := newPasswordResetTestService(t)
+	registerTestUser(t, authService, "user@example.com", "Password123!")
+
+	// unknown emails look the same to the caller, but nothing is sent
+	if err := authService.RequestPasswordReset(context.Background(), "nobody@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	sink.expectNone(t)
+
+	if err := authService.RequestPasswordReset(context.Background(), "user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	token := resetToken(t, sink)
+
+	if err := authService.CheckPasswordReset(context.Background(), token); err != nil {
+		t.Fatalf("expected the link to be usable, got %v", err)
+	}
+	if err := authService.CheckPasswordReset(context.Background(), "wrong"); !errors.Is(err, ErrInvalidPasswordReset) {
+		t.Errorf("expected %v, got %v", ErrInvalidPasswordReset, err)
+	}
+
+	if _, err := authService.ResetPassword(context.Background(), token, "Password456!"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.ResetPassword(context.Background(), token, "Password789!"); !errors.Is(err, ErrInvalidPasswordReset) {
+		t.Errorf("expected the link to work once, got %v", err)
+	}
+
+	if _, err := authService.AuthenticateUser(context.Background(), "user@example.com", "Password456!", "192.0.2.1"); err != nil {
+		t.Errorf("expected the new password to work, got %v", err)
+	}
+}
+
+func TestAuthService_PasswordResetLinkExpires(t *testing.T) {
+	authService, sink := newPasswordResetTestService(t)
+	registerTestUser(t, authService, "user@example.com", "Password123!")
+	authService.PasswordResetExpiry = -1
+
+	if err := authService.RequestPasswordReset(context.Background(), "user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.ResetPassword(context.Background(), resetToken(t, sink), "Password456!"); !errors.Is(err, ErrInvalidPasswordReset) {
+		t.Errorf("expected %v, got %v", ErrInvalidPasswordReset, err)
+	}
+}
+
+func TestAuthService_PasswordChangeRevokesResetLinks(t *testing.T) {
+	authService, sink := newPasswordResetTestService(t)
+	ctx := registerTestUser(t, authService, "user@example.com", "Password123!")
+
+	if err := authService.RequestPasswordReset(context.Background(), "user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	token := resetToken(t, sink)
+
+	if err := authService.ChangeOwnPassword(ctx, "Password123!", "Password456!"); err != nil {
+		t.Fatal(err)
+	}
+	if err := authService.CheckPasswordReset(context.Background(), token); !errors.Is(err, ErrInvalidPasswordReset) {
+		t.Errorf("expected %v, got %v", ErrInvalidPasswordReset, err)
+	}
+}
+
+func TestAuthService_PasswordResetRequestsAreCapped(t *testing.T) {
+	authService, sink := newPasswordResetTestService(t)
+	registerTestUser(t, authService, "user@example.com", "Password123!")
+
+	for range passwordResetsPerHour {
+		if err := authService.RequestPasswordReset(context.Background(), "user@example.com"); err != nil {
+			t.Fatal(err)
+		}
+		sink.next(t)
+	}
+
+	if err := authService.RequestPasswordReset(context.Background(), "user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	sink.expectNone(t)
+}
+
+func TestAuthService_DisabledUserCannotResetPassword(t *testing.T) {
+	authService, sink := newPasswordResetTestService(t)
+	admin := mustUser(t, registerTestUser(t, authService, "admin@example.com", "Password123!"))
+	if err := authService.SetUserAsAdmin(context.Background(), admin.ID); err != nil {
+		t.Fatal(err)
+	}
+	user := mustUser(t, registerTestUser(t, authService, "user@example.com", "Password123!"))
+
+	if err := authService.EmailPasswordReset(context.Background(), user.ID); err != nil {
+		t.Fatal(err)
+	}
+	token := resetToken(t, sink)
+
+	if err := authService.DisableUser(context.Background(), user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.ResetPassword(context.Background(), token, "Password456!"); !errors.Is(err, ErrAccountDisabled) {
+		t.Errorf("expected %v, got %v", ErrAccountDisabled, err)
+	}
+
+	if err := authService.RequestPasswordReset(context.Background(), "user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	sink.expectNone(t)
+}
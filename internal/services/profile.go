@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+const (
+	// DefaultAccountDeletionGrace is how long a deleted account can still be restored
+	DefaultAccountDeletionGrace = 7 * 24 * time.Hour
+	maxProfileNameLength        = 64
+)
+
+var (
+	ErrWrongPassword        = errors.New("current password is wrong")
+	ErrInvalidProfileName   = errors.New("name must be between 1 and 64 characters")
+	ErrInvalidEmail         = errors.New("email address is invalid")
+	ErrEmailTaken           = errors.New("email address is used by another account")
+	ErrLastAdmin            = errors.New("the last admin cannot be removed")
+	ErrDeletionNotScheduled = errors.New("account deletion is not scheduled")
+)
+
+// UpdateProfile changes the name and email of the signed-in user, password re-confirms
+// their identity. It returns the updated user.
+func (a *AuthService) UpdateProfile(ctx context.Context, name, email, password string) (*models.User, error) {
+	user, err := a.confirmPassword(ctx, password)
+	if err != nil {
+		return nil, err
+	}
+
+	name, email, err = normalizeProfile(name, email)
+	if err != nil {
+		return nil, err
+	}
+	if email != user.Email {
+		if existing, err := a.db.GetUserByEmail(ctx, email); err == nil && existing.ID != user.ID {
+			return nil, ErrEmailTaken
+		}
+	}
+
+	if err := a.db.UpdateUserProfile(ctx, user.ID, name, email); err != nil {
+		return nil, fmt.Errorf("failed to update profile of user %d: %w", user.ID, err)
+	}
+
+	var changes []string
+	if name != user.Name {
+		changes = append(changes, "name")
+	}
+	if email != user.Email {
+		changes = append(changes, "email from "+user.Email+" to "+email)
+	}
+	if len(changes) > 0 {
+		a.Audit.Record(ctx, models.AuditProfileUpdated, user, "changed "+strings.Join(changes, " and "))
+	}
+
+	updated := *user
+	updated.Name = name
+	updated.Email = email
+	return &updated, nil
+}
+
+// ChangeOwnPassword replaces the password of the signed-in user after checking the current
+// one. Like every password change it signs the user out of every device.
+func (a *AuthService) ChangeOwnPassword(ctx context.Context, currentPassword, newPassword string) error {
+	user, err := a.confirmPassword(ctx, currentPassword)
+	if err != nil {
+		return err
+	}
+	return a.UpdateUserPassword(ctx, user.ID, newPassword)
+}
+
+// ScheduleAccountDeletion asks for the signed-in account to be deleted once
+// AccountDeletionGrace has passed and signs it out of every device. It returns when the
+// account will be deleted.
+func (a *AuthService) ScheduleAccountDeletion(ctx context.Context, password string) (time.Time, error) {
+	user, err := a.confirmPassword(ctx, password)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := a.ensureAnotherAdmin(ctx, user); err != nil {
+		return time.Time{}, err
+	}
+
+	deleteAt := time.Now().UTC().Add(a.AccountDeletionGrace)
+	if err := a.db.SetUserDeletionScheduledAt(ctx, user.ID, &deleteAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to schedule deletion of user %d: %w", user.ID, err)
+	}
+	a.Audit.Record(ctx, models.AuditDeletionScheduled, user, "deletes on "+deleteAt.Format(time.RFC3339))
+
+	if _, err := a.RevokeUserSessions(ctx, user.ID); err != nil {
+		return time.Time{}, fmt.Errorf("failed to sign out user %d after scheduling deletion: %w", user.ID, err)
+	}
+	return deleteAt, nil
+}
+
+// CancelAccountDeletion keeps the signed-in account after it was scheduled for deletion
+func (a *AuthService) CancelAccountDeletion(ctx context.Context) error {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := a.db.GetUserByID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve user %d: %w", user.ID, err)
+	}
+	if current.DeletionScheduledAt == nil {
+		return ErrDeletionNotScheduled
+	}
+
+	if err := a.db.SetUserDeletionScheduledAt(ctx, user.ID, nil); err != nil {
+		return fmt.Errorf("failed to cancel deletion of user %d: %w", user.ID, err)
+	}
+	a.Audit.Record(ctx, models.AuditDeletionCanceled, current, "")
+	return nil
+}
+
+// DeleteScheduledAccounts deletes every account whose deletion grace period is over and
+// returns how many were deleted
+func (a *AuthService) DeleteScheduledAccounts(ctx context.Context) (int, error) {
+	userIDs, err := a.db.GetUsersDueForDeletion(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get accounts due for deletion: %w", err)
+	}
+
+	deleted := 0
+	for _, userID := range userIDs {
+		if err := a.deleteUser(ctx, userID, "deletion requested by the user"); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// normalizeProfile trims name and email and checks they are valid
+func normalizeProfile(name, email string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxProfileNameLength {
+		return "", "", ErrInvalidProfileName
+	}
+	email = strings.TrimSpace(email)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return "", "", ErrInvalidEmail
+	}
+	return name, email, nil
+}
+
+// confirmPassword returns the signed-in user when password is theirs. Wrong passwords count
+// towards the account lockout like failed logins do.
+func (a *AuthService) confirmPassword(ctx context.Context, password string) (*models.User, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the user in ctx may be stale, the password hash has to be the current one
+	current, err := a.db.GetUserByID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve user %d: %w", user.ID, err)
+	}
+
+	if err := a.checkAccountLock(ctx, current.ID); err != nil {
+		return nil, err
+	}
+	if err := verifyPassword(current.PasswordHash, password); err != nil {
+		if lockErr := a.recordAccountFailure(ctx, current.ID); lockErr != nil {
+			a.log.Error("failed to record wrong password", "userID", current.ID, "error", lockErr)
+		}
+		return nil, ErrWrongPassword
+	}
+	return current, nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+// registerTestUser registers an user with password and returns a context signed in as them
+func registerTestUser(t *testing.T, authService *AuthService, email, password string) context.Context {
+	t.Helper()
+
+	userID, err := authService.RegisterUser(context.Background(), email, "User", password, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := authService.GetUserByID(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return context.WithValue(context.Background(), common.UserKey, user)
+}
+
+func TestAuthService_UpdateProfile(t *testing.T) {
+	_, authService := newAuthTestService(t, models.RegistrationOpen)
+	registerTestUser(t, authService, "taken@example.com", "Password123!")
+	ctx := registerTestUser(t, authService, "user@example.com", "Password123!")
+
+	if _, err := authService.UpdateProfile(ctx, "New Name", "new@example.com", "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("expected %v, got %v", ErrWrongPassword, err)
+	}
+	if _, err := authService.UpdateProfile(ctx, " ", "new@example.com", "Password123!"); !errors.Is(err, ErrInvalidProfileName) {
+		t.Errorf("expected %v, got %v", ErrInvalidProfileName, err)
+	}
+	if _, err := authService.UpdateProfile(ctx, "New Name", "not an email", "Password123!"); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("expected %v, got %v", ErrInvalidEmail, err)
+	}
+	if _, err := authService.UpdateProfile(ctx, "New Name", "taken@example.com", "Password123!"); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("expected %v, got %v", ErrEmailTaken, err)
+	}
+
+	updated, err := authService.UpdateProfile(ctx, "New Name", "new@example.com", "Password123!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := authService.GetUserByID(context.Background(), updated.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "New Name" || stored.Email != "new@example.com" {
+		t.Errorf("expected the profile to be saved, got %q %q", stored.Name, stored.Email)
+	}
+
+	if _, err := authService.AuthenticateUser(context.Background(), "new@example.com", "Password123!", "192.0.2.1"); err != nil {
+		t.Errorf("expected to sign in with the new email, got %v", err)
+	}
+}
+
+func TestAuthService_ChangeOwnPassword(t *testing.T) {
+	_, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := registerTestUser(t, authService, "user@example.com", "Password123!")
+
+	if err := authService.ChangeOwnPassword(ctx, "wrong", "Password456!"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected %v, got %v", ErrWrongPassword, err)
+	}
+	if err := authService.ChangeOwnPassword(ctx, "Password123!", "Password456!"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := authService.AuthenticateUser(context.Background(), "user@example.com", "Password123!", "192.0.2.1"); err == nil {
+		t.Error("expected the old password to stop working")
+	}
+	if _, err := authService.AuthenticateUser(context.Background(), "user@example.com", "Password456!", "192.0.2.1"); err != nil {
+		t.Errorf("expected the new password to work, got %v", err)
+	}
+}
+
+func TestAuthService_ScheduledAccountDeletion(t *testing.T) {
+	_, authService := newAuthTestService(t, models.RegistrationOpen)
+	adminCtx := registerTestUser(t, authService, "admin@example.com", "Password123!")
+	admin := mustUser(t, adminCtx)
+	if err := authService.SetUserAsAdmin(context.Background(), admin.ID); err != nil {
+		t.Fatal(err)
+	}
+	ctx := registerTestUser(t, authService, "user@example.com", "Password123!")
+	user := mustUser(t, ctx)
+
+	adminCtx = context.WithValue(context.Background(), common.UserKey, &models.User{ID: admin.ID, Admin: true})
+	if _, err := authService.ScheduleAccountDeletion(adminCtx, "Password123!"); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected %v, got %v", ErrLastAdmin, err)
+	}
+
+	if _, err := authService.ScheduleAccountDeletion(ctx, "wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected %v, got %v", ErrWrongPassword, err)
+	}
+	if _, err := authService.ScheduleAccountDeletion(ctx, "Password123!"); err != nil {
+		t.Fatal(err)
+	}
+	if err := authService.CancelAccountDeletion(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := authService.CancelAccountDeletion(ctx); !errors.Is(err, ErrDeletionNotScheduled) {
+		t.Errorf("expected %v, got %v", ErrDeletionNotScheduled, err)
+	}
+
+	// a deletion still in its grace period is kept
+	if _, err := authService.ScheduleAccountDeletion(ctx, "Password123!"); err != nil {
+		t.Fatal(err)
+	}
+	if deleted, err := authService.DeleteScheduledAccounts(context.Background()); err != nil || deleted != 0 {
+		t.Fatalf("expected nothing to be deleted yet, got %d, %v", deleted, err)
+	}
+
+	authService.AccountDeletionGrace = -time.Minute
+	if _, err := authService.ScheduleAccountDeletion(ctx, "Password123!"); err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := authService.DeleteScheduledAccounts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 deleted account, got %d", deleted)
+	}
+	if _, err := authService.GetUserByID(context.Background(), user.ID); err == nil {
+		t.Error("expected the account to be gone")
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var ErrProxyAuthNoEmail = errors.New("trusted proxy did not send an email address")
+
+// ProxyAuthConfig configures authentication through headers set by a trusted
+// reverse proxy such as Authelia or oauth2-proxy
+type ProxyAuthConfig struct {
+	// UserHeader carries the authenticated username, it also enables proxy auth
+	UserHeader  string
+	EmailHeader string
+	NameHeader  string
+	// TrustedProxies are the only peers allowed to send the headers
+	TrustedProxies []netip.Prefix
+}
+
+// ParseTrustedProxies parses CIDRs and bare IP addresses. Each value may hold a
+// comma separated list, as set through the TRUSTED_PROXIES environment variable.
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	return parseNetworks("trusted proxy", values)
+}
+
+// ParseWebhookAllowedNetworks parses the networks webhooks may be delivered to
+// despite being internal, in the same format as ParseTrustedProxies
+func ParseWebhookAllowedNetworks(values []string) ([]netip.Prefix, error) {
+	return parseNetworks("webhook network", values)
+}
+
+func parseNetworks(kind string, values []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, value := range strings.FieldsFunc(strings.Join(values, ","), func(r rune) bool { return r == ',' || r == ' ' }) {
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", kind, value, err)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", kind, value, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// Trusts reports whether remoteAddr, as found in http.Request.RemoteAddr, is a trusted proxy
+func (c *ProxyAuthConfig) Trusts(remoteAddr string) bool {
+	return prefixesContain(c.TrustedProxies, remoteAddr)
+}
+
+// prefixesContain reports whether the address of remoteAddr is in one of prefixes
+func prefixesContain(prefixes []netip.Prefix, remoteAddr string) bool {
+	addrPort, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	return prefixesContainAddr(prefixes, addrPort.Addr())
+}
+
+// prefixesContainAddr reports whether addr is in one of prefixes
+func prefixesContainAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProxyIdentity extracts the identity headers. ok is false when the request carries none of them.
+func (c *ProxyAuthConfig) ProxyIdentity(header func(string) string) (username, email, name string, ok bool) {
+	username = strings.TrimSpace(header(c.UserHeader))
+	if c.EmailHeader != "" {
+		email = strings.TrimSpace(header(c.EmailHeader))
+	}
+	if c.NameHeader != "" {
+		name = strings.TrimSpace(header(c.NameHeader))
+	}
+	return username, email, name, username != "" || email != ""
+}
+
+// AuthenticateProxyUser returns the user asserted by a trusted proxy, creating it on first sight.
+// Users are matched by email; a username that looks like an email is used when no email header is sent.
+func (a *AuthService) AuthenticateProxyUser(ctx context.Context, username, email, name string) (*models.User, error) {
+	if email == "" && strings.Contains(username, "@") {
+		email = username
+	}
+	if email == "" {
+		return nil, ErrProxyAuthNoEmail
+	}
+
+	user, err := a.db.GetUserByEmail(ctx, email)
+	if err == nil {
+		if err := a.checkEnabled(ctx, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if name == "" {
+		name = username
+	}
+	if name == "" || name == email {
+		name = strings.Split(email, "@")[0]
+	}
+
+	userID, err := a.createExternalUser(ctx, email, name)
+	if err != nil {
+		return nil, err
+	}
+
+	a.log.Info("created user from trusted proxy headers", "userID", userID, "email", email)
+	return a.GetUserByID(ctx, userID)
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+const (
+	// sessionTouchInterval limits how often the last seen time of a session is written
+	sessionTouchInterval = time.Minute
+
+	DefaultSessionMaxLifetime = 30 * 24 * time.Hour
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// TouchSession records that session was just used by client and slides its expiry, up to
+// its max lifetime. Writes are skipped while the previous one is recent, so most requests
+// do not hit the database.
+func (a *AuthService) TouchSession(ctx context.Context, session *models.Session, client models.SessionClient) error {
+	now := time.Now().UTC()
+	if session.LastSeenAt != nil && now.Sub(*session.LastSeenAt) < sessionTouchInterval {
+		return nil
+	}
+
+	expiresAt := minTime(now.Add(a.SessionExpiry), session.MaxExpiresAt)
+	err := a.db.TouchSession(ctx, session.ID, now, expiresAt, client)
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+	return nil
+}
+
+// GetDevices lists the active sessions of the user in ctx, marking the one of currentToken
+func (a *AuthService) GetDevices(ctx context.Context, currentToken string) ([]models.Device, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := a.db.GetSessionsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	currentSessionID := hashSessionToken(currentToken)
+	devices := make([]models.Device, len(sessions))
+	for i, session := range sessions {
+		devices[i] = models.Device{
+			Handle:     sessionHandle(session.ID),
+			Browser:    describeUserAgent(session.UserAgent),
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentSessionID,
+		}
+	}
+	return devices, nil
+}
+
+// RevokeDevice signs the user in ctx out of the session identified by handle
+func (a *AuthService) RevokeDevice(ctx context.Context, handle string) error {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := a.db.GetSessionsByUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		if sessionHandle(session.ID) != handle {
+			continue
+		}
+
+		rows, err := a.db.DeleteUserSession(ctx, user.ID, session.ID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		if rows == 0 {
+			return ErrSessionNotFound
+		}
+
+		a.log.Info("session revoked", "userID", user.ID)
+		return nil
+	}
+	return ErrSessionNotFound
+}
+
+// SignOutEverywhere ends every session of the user in ctx, including the current one
+func (a *AuthService) SignOutEverywhere(ctx context.Context) (int64, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return a.RevokeUserSessions(ctx, user.ID)
+}
+
+// RevokeUserSessions ends every session of userID and returns how many were ended
+func (a *AuthService) RevokeUserSessions(ctx context.Context, userID int64) (int64, error) {
+	count, err := a.db.DeleteSessionsByUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions of user %d: %w", userID, err)
+	}
+
+	a.log.Info("revoked all sessions of user", "userID", userID, "count", count)
+	return count, nil
+}
+
+// hashSessionToken derives the stored session ID from the token in the cookie, so a
+// leaked database holds no usable credentials
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SessionCSRFToken derives the CSRF token of the session whose cookie holds token. Only
+// the holder of the session can compute it, and it differs from the stored session ID.
+func SessionCSRFToken(token string) string {
+	return hashSessionToken("csrf:" + token)
+}
+
+// minTime returns the earlier of a and b
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// sessionHandle derives a stable public identifier from a stored session ID
+func sessionHandle(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:8])
+}
+
+// describeUserAgent turns a user agent into a short "Browser on OS" label.
+// It only knows the common browsers, anything else is shown as unknown.
+func describeUserAgent(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	// order matters, most browsers also claim to be Chrome, Safari or Mozilla
+	for _, candidate := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	os := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"},
+		{"Windows", "Windows"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			os = candidate.name
+			break
+		}
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+var testSessionClient = models.SessionClient{
+	UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0",
+	IP:        "203.0.113.7",
+}
+
+func TestAuthService_GetDevices(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+
+	current, err := authService.CreateSession(ctx, user.ID, testSessionClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.CreateSession(ctx, user.ID, models.SessionClient{}); err != nil {
+		t.Fatal(err)
+	}
+
+	devices, err := authService.GetDevices(ctx, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(devices))
+	}
+
+	currentCount := 0
+	for _, device := range devices {
+		if device.Handle == current || device.Handle == "" {
+			t.Errorf("expected an opaque handle, got %q", device.Handle)
+		}
+		if device.Current {
+			currentCount++
+			if device.Browser != "Firefox on Linux" || device.IP != testSessionClient.IP || device.LastSeenAt == nil {
+				t.Errorf("unexpected current device %+v", device)
+			}
+		}
+	}
+	if currentCount != 1 {
+		t.Errorf("expected exactly one current device, got %d", currentCount)
+	}
+}
+
+func TestAuthService_RevokeDevice(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+
+	sessionID, err := authService.CreateSession(ctx, user.ID, testSessionClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handle := sessionHandle(hashSessionToken(sessionID))
+
+	other, err := testDB.CreateUser(context.Background(), "other@example.com", "Other", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCtx := context.WithValue(context.Background(), common.UserKey, other)
+	if err := authService.RevokeDevice(otherCtx, handle); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected other users not to revoke the session, got %v", err)
+	}
+
+	if err := authService.RevokeDevice(ctx, handle); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.GetSession(ctx, sessionID); err == nil {
+		t.Error("expected revoked session to be gone")
+	}
+	if err := authService.RevokeDevice(ctx, handle); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected %v, got %v", ErrSessionNotFound, err)
+	}
+}
+
+func TestAuthService_PasswordChangeEndsSessions(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+
+	other, err := testDB.CreateUser(context.Background(), "other@example.com", "Other", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSession, err := authService.CreateSession(ctx, other.ID, testSessionClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := make([]string, 3)
+	for i := range sessions {
+		sessions[i], err = authService.CreateSession(ctx, user.ID, testSessionClient)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := authService.UpdateUserPassword(ctx, user.ID, "NewPassword123!"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sessionID := range sessions {
+		if _, err := authService.GetSession(ctx, sessionID); err == nil {
+			t.Error("expected session to end after a password change")
+		}
+	}
+	if _, err := authService.GetSession(ctx, otherSession); err != nil {
+		t.Errorf("expected sessions of other users to survive, got %v", err)
+	}
+}
+
+func TestAuthService_TouchSessionIsThrottled(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+
+	sessionID, err := authService.CreateSession(ctx, user.ID, testSessionClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := authService.GetSession(ctx, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the session was just created, so the new client is not recorded yet
+	moved := models.SessionClient{UserAgent: testSessionClient.UserAgent, IP: "198.51.100.1"}
+	if err := authService.TouchSession(ctx, session, moved); err != nil {
+		t.Fatal(err)
+	}
+	devices, _ := authService.GetDevices(ctx, sessionID)
+	if devices[0].IP != testSessionClient.IP {
+		t.Errorf("expected a recent session not to be updated, got %s", devices[0].IP)
+	}
+
+	stale := session.LastSeenAt.Add(-2 * sessionTouchInterval)
+	session.LastSeenAt = &stale
+	if err := authService.TouchSession(ctx, session, moved); err != nil {
+		t.Fatal(err)
+	}
+	devices, _ = authService.GetDevices(ctx, sessionID)
+	if devices[0].IP != moved.IP {
+		t.Errorf("expected a stale session to be updated, got %s", devices[0].IP)
+	}
+}
+
+func TestAuthService_SessionTokenIsHashedAtRest(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+
+	token, err := authService.CreateSession(ctx, user.ID, testSessionClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := testDB.GetSession(ctx, token); err == nil {
+		t.Error("expected the raw token not to be stored")
+	}
+	stored, err := testDB.GetSession(ctx, hashSessionToken(token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.UserID != user.ID {
+		t.Errorf("expected session of user %d, got %d", user.ID, stored.UserID)
+	}
+
+	if err := authService.Logout(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.GetSession(ctx, token); err == nil {
+		t.Error("expected the session to end on logout")
+	}
+}
+
+func TestAuthService_TouchSessionSlidesExpiryUpToMaxLifetime(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	authService.SessionMaxLifetime = 90 * time.Minute
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+
+	token, err := authService.CreateSession(ctx, user.ID, testSessionClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := authService.GetSession(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.MaxExpiresAt.Sub(session.ExpiresAt) != 30*time.Minute {
+		t.Fatalf("expected the idle expiry 30m before the max lifetime, got %s and %s", session.ExpiresAt, session.MaxExpiresAt)
+	}
+
+	// pretend the session was created 50 minutes ago and used since
+	session.MaxExpiresAt = session.MaxExpiresAt.Add(-50 * time.Minute)
+	stale := session.LastSeenAt.Add(-2 * sessionTouchInterval)
+	session.LastSeenAt = &stale
+	if err := authService.TouchSession(ctx, session, testSessionClient); err != nil {
+		t.Fatal(err)
+	}
+
+	touched, err := authService.GetSession(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !touched.ExpiresAt.Equal(session.MaxExpiresAt) {
+		t.Errorf("expected expiry to be capped at %s, got %s", session.MaxExpiresAt, touched.ExpiresAt)
+	}
+}
+
+func TestDescribeUserAgent(t *testing.T) {
+	tests := map[string]string{
+		"": "Unknown device",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0":           "Edge on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1": "Safari on iOS",
+		"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36":                            "Chrome on Android",
+		"curl/8.8.0": "curl",
+	}
+
+	for userAgent, want := range tests {
+		if got := describeUserAgent(userAgent); got != want {
+			t.Errorf("%q: expected %q, got %q", userAgent, want, got)
+		}
+	}
+}
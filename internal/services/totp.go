@@ -0,0 +1,433 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpIssuer = "Gowatch"
+	totpDigits = 6
+	totpPeriod = 30
+	// totpSkew is the number of time steps accepted before and after the current one
+	totpSkew = 1
+	// totpQRCodeSize is the width and height in pixels of the enrollment QR code
+	totpQRCodeSize = 256
+
+	recoveryCodeCount = 10
+
+	MFAChallengeExpiry   = 5 * time.Minute
+	mfaChallengeAttempts = 5
+)
+
+var (
+	ErrTOTPAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrTOTPNotEnrolled     = errors.New("no two-factor enrollment is pending")
+	ErrInvalidTOTPCode     = errors.New("invalid authentication code")
+	ErrMFAChallengeInvalid = errors.New("login challenge is invalid or expired")
+	ErrMFATooManyAttempts  = errors.New("too many invalid authentication codes")
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpOpts matches the parameters in the otpauth URI, most authenticator apps ignore them
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// TwoFactorPageData returns the two-factor state of the user in ctx
+func (a *AuthService) TwoFactorPageData(ctx context.Context) (models.TwoFactorPageData, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return models.TwoFactorPageData{}, err
+	}
+
+	totp, err := a.db.GetUserTOTP(ctx, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.TwoFactorPageData{}, nil
+	}
+	if err != nil {
+		return models.TwoFactorPageData{}, fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+
+	if !totp.Enabled {
+		secret, err := base32NoPadding.DecodeString(totp.Secret)
+		if err != nil {
+			return models.TwoFactorPageData{}, fmt.Errorf("failed to decode TOTP secret: %w", err)
+		}
+		enrollment, err := totpEnrollment(user.Email, secret)
+		if err != nil {
+			return models.TwoFactorPageData{}, err
+		}
+		return models.TwoFactorPageData{Enrollment: enrollment}, nil
+	}
+
+	left, err := a.db.CountUnusedRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return models.TwoFactorPageData{}, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return models.TwoFactorPageData{Enabled: true, RecoveryCodesLeft: left}, nil
+}
+
+// BeginTOTPEnrollment generates a new secret for the user in ctx. It is only used for
+// logins once confirmed with ConfirmTOTPEnrollment.
+func (a *AuthService) BeginTOTPEnrollment(ctx context.Context) (*models.TOTPEnrollment, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := a.TOTPEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+
+	err = a.db.UpsertUserTOTP(ctx, user.ID, base32NoPadding.EncodeToString(secret), time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+
+	return totpEnrollment(user.Email, secret)
+}
+
+// ConfirmTOTPEnrollment enables two-factor authentication once the user proves their
+// authenticator app works, and returns the recovery codes to show them once.
+func (a *AuthService) ConfirmTOTPEnrollment(ctx context.Context, code string) ([]string, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	totp, err := a.db.GetUserTOTP(ctx, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTOTPNotEnrolled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TOTP secret: %w", err)
+	}
+	if totp.Enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	if err := a.useTOTPCode(ctx, totp, code, time.Now()); err != nil {
+		return nil, err
+	}
+
+	err = a.db.EnableUserTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+
+	codes, err := a.replaceRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	a.log.Info("two-factor authentication enabled", "userID", user.ID)
+	return codes, nil
+}
+
+// DisableTOTP turns off two-factor authentication for the user in ctx, after checking
+// a current authentication or recovery code
+func (a *AuthService) DisableTOTP(ctx context.Context, code string) error {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := a.verifySecondFactor(ctx, user.ID, code); err != nil {
+		return err
+	}
+
+	if err := a.removeTOTP(ctx, user.ID); err != nil {
+		return err
+	}
+
+	a.log.Info("two-factor authentication disabled", "userID", user.ID)
+	return nil
+}
+
+// RegenerateRecoveryCodes invalidates the recovery codes of the user in ctx and returns new ones,
+// after checking a current authentication code
+func (a *AuthService) RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.verifySecondFactor(ctx, user.ID, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := a.replaceRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	a.log.Info("recovery codes regenerated", "userID", user.ID)
+	return codes, nil
+}
+
+// ResetTOTP removes the second factor of an user who lost both their device and recovery codes
+func (a *AuthService) ResetTOTP(ctx context.Context, userID int64) error {
+	enabled, err := a.TOTPEnabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrTOTPNotEnabled
+	}
+
+	return a.removeTOTP(ctx, userID)
+}
+
+// TOTPEnabled reports whether logins of the user require a second factor
+func (a *AuthService) TOTPEnabled(ctx context.Context, userID int64) (bool, error) {
+	totp, err := a.db.GetUserTOTP(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get TOTP state for user %d: %w", userID, err)
+	}
+	return totp.Enabled, nil
+}
+
+// CreateMFAChallenge starts the second login step for an user whose password was verified
+// and returns the token identifying it, only its hash is stored
+func (a *AuthService) CreateMFAChallenge(ctx context.Context, userID int64) (string, error) {
+	token, err := generateSessionID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate login challenge: %w", err)
+	}
+
+	err = a.db.CreateMFAChallenge(ctx, hashSessionToken(token), userID, time.Now().UTC().Add(MFAChallengeExpiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to create login challenge: %w", err)
+	}
+
+	return token, nil
+}
+
+// VerifyMFAChallenge completes the second login step from clientIP with an authentication
+// or recovery code. The challenge is consumed on success and after too many invalid codes.
+// Invalid codes also slow down clientIP and count towards locking the account, as wrong
+// passwords do, so new challenges do not bring new guesses.
+func (a *AuthService) VerifyMFAChallenge(ctx context.Context, token, code, clientIP string) (*models.User, error) {
+	if err := a.CheckClientThrottle(clientIP); err != nil {
+		return nil, err
+	}
+
+	tokenHash := hashSessionToken(token)
+	challenge, err := a.db.GetMFAChallenge(ctx, tokenHash, time.Now().UTC())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMFAChallengeInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login challenge: %w", err)
+	}
+	if err := a.checkAccountLock(ctx, challenge.UserID); err != nil {
+		a.Audit.RecordAs(ctx, models.AuditLoginFailed, nil, a.auditTarget(ctx, challenge.UserID), "account locked")
+		return nil, err
+	}
+
+	err = a.verifySecondFactor(ctx, challenge.UserID, code)
+	if errors.Is(err, ErrInvalidTOTPCode) {
+		a.Audit.RecordAs(ctx, models.AuditLoginFailed, nil, a.auditTarget(ctx, challenge.UserID), "invalid two-factor code")
+		a.RecordClientFailure(clientIP)
+		if lockErr := a.recordAccountFailure(ctx, challenge.UserID); lockErr != nil {
+			a.log.Error("failed to record failed login", "userID", challenge.UserID, "error", lockErr)
+		}
+
+		attempts, countErr := a.db.IncrementMFAChallengeAttempts(ctx, tokenHash)
+		if countErr != nil {
+			return nil, countErr
+		}
+		if attempts >= mfaChallengeAttempts {
+			a.log.Warn("login challenge locked after too many invalid codes", "userID", challenge.UserID)
+			if err := a.db.DeleteMFAChallenge(ctx, tokenHash); err != nil {
+				return nil, err
+			}
+			return nil, ErrMFATooManyAttempts
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.db.DeleteMFAChallenge(ctx, tokenHash); err != nil {
+		return nil, err
+	}
+	a.loginSucceeded(ctx, challenge.UserID, clientIP)
+
+	return a.GetUserByID(ctx, challenge.UserID)
+}
+
+// verifySecondFactor accepts a code from the authenticator app or an unused recovery code,
+// both can only be used once
+func (a *AuthService) verifySecondFactor(ctx context.Context, userID int64, code string) error {
+	totp, err := a.db.GetUserTOTP(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTOTPNotEnabled
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get TOTP secret: %w", err)
+	}
+	if !totp.Enabled {
+		return ErrTOTPNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totpDigits {
+		return a.useTOTPCode(ctx, totp, code, time.Now())
+	}
+
+	used, err := a.db.UseRecoveryCode(ctx, userID, hashRecoveryCode(code), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if !used {
+		return ErrInvalidTOTPCode
+	}
+
+	a.log.Info("recovery code used", "userID", userID)
+	return nil
+}
+
+// useTOTPCode checks code against the secret and records its time step so it cannot be replayed
+func (a *AuthService) useTOTPCode(ctx context.Context, totp *models.UserTOTP, code string, now time.Time) error {
+	step, ok := validateTOTP(totp.Secret, strings.TrimSpace(code), now)
+	if !ok {
+		return ErrInvalidTOTPCode
+	}
+
+	fresh, err := a.db.UseTOTPStep(ctx, totp.UserID, step)
+	if err != nil {
+		return fmt.Errorf("failed to record TOTP step: %w", err)
+	}
+	if !fresh {
+		a.log.Warn("rejected replayed TOTP code", "userID", totp.UserID)
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+func (a *AuthService) replaceRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := strings.ToLower(base32NoPadding.EncodeToString(raw))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	err := a.db.ReplaceRecoveryCodes(ctx, userID, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+func (a *AuthService) removeTOTP(ctx context.Context, userID int64) error {
+	if _, err := a.db.DeleteUserTOTP(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete TOTP secret: %w", err)
+	}
+	if err := a.db.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	return nil
+}
+
+// hashRecoveryCode normalizes the code, so dashes, spaces and case do not matter, and hashes it.
+// Recovery codes are random enough that a fast hash is fine.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func totpEnrollment(email string, secret []byte) (*models.TOTPEnrollment, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: email,
+		Period:      totpOpts.Period,
+		Secret:      secret,
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build TOTP key: %w", err)
+	}
+
+	img, err := key.Image(totpQRCodeSize, totpQRCodeSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render TOTP QR code: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode TOTP QR code: %w", err)
+	}
+
+	return &models.TOTPEnrollment{
+		Secret: key.Secret(),
+		URI:    key.URL(),
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// totpCode computes the RFC 6238 code of the given time step
+func totpCode(secret string, step int64) (string, error) {
+	return totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totpOpts)
+}
+
+// validateTOTP checks code against the steps around now and returns the matching step
+func validateTOTP(secret, code string, now time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		want, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	secret := base32NoPadding.EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		if got := mustTOTPCode(t, secret, tt.unix/totpPeriod); got != tt.code {
+			t.Errorf("time %d: expected %s, got %s", tt.unix, tt.code, got)
+		}
+	}
+}
+
+func TestValidateTOTP_Window(t *testing.T) {
+	secret := base32NoPadding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / totpPeriod
+
+	for offset, want := range map[int64]bool{-2: false, -1: true, 0: true, 1: true, 2: false} {
+		step, ok := validateTOTP(secret, mustTOTPCode(t, secret, current+offset), now)
+		if ok != want {
+			t.Errorf("offset %d: expected valid=%v, got %v", offset, want, ok)
+		}
+		if ok && step != current+offset {
+			t.Errorf("offset %d: expected step %d, got %d", offset, current+offset, step)
+		}
+	}
+}
+
+func TestValidateTOTP_RejectsMalformedInput(t *testing.T) {
+	secret := base32NoPadding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1234567890, 0)
+	code := mustTOTPCode(t, secret, now.Unix()/totpPeriod)
+
+	for name, tt := range map[string]struct{ secret, code string }{
+		"invalid secret": {"not base32!", code},
+		"short code":     {secret, code[:5]},
+		"long code":      {secret, code + "0"},
+		"empty code":     {secret, ""},
+	} {
+		if _, ok := validateTOTP(tt.secret, tt.code, now); ok {
+			t.Errorf("%s: expected code to be rejected", name)
+		}
+	}
+}
+
+func mustTOTPCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+
+	code, err := totpCode(secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// enrollTOTP enables two-factor authentication for the user in ctx. It returns the secret,
+// the time step of the confirmation code and the recovery codes.
+func enrollTOTP(t *testing.T, ctx context.Context, authService *AuthService) (string, int64, []string) {
+	t.Helper()
+
+	enrollment, err := authService.BeginTOTPEnrollment(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(enrollment.QRCode, "data:image/png;base64,") {
+		t.Errorf("expected a PNG QR code, got %.40q", enrollment.QRCode)
+	}
+
+	if _, err := authService.ConfirmTOTPEnrollment(ctx, "abcdef"); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Fatalf("expected %v for a wrong code, got %v", ErrInvalidTOTPCode, err)
+	}
+
+	step := time.Now().Unix() / totpPeriod
+	codes, err := authService.ConfirmTOTPEnrollment(ctx, mustTOTPCode(t, enrollment.Secret, step))
+	if err != nil {
+		t.Fatalf("expected enrollment to be confirmed, got %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", recoveryCodeCount, len(codes))
+	}
+	return enrollment.Secret, step, codes
+}
+
+func TestAuthService_TOTPEnrollment(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+
+	data, err := authService.TwoFactorPageData(ctx)
+	if err != nil || data.Enabled || data.Enrollment != nil {
+		t.Fatalf("expected two-factor to start disabled, got %+v, %v", data, err)
+	}
+
+	enabled, _ := authService.TOTPEnabled(ctx, user.ID)
+	if enabled {
+		t.Fatal("expected an unconfirmed secret not to enable two-factor")
+	}
+
+	enrollTOTP(t, ctx, authService)
+
+	data, err = authService.TwoFactorPageData(ctx)
+	if err != nil || !data.Enabled || data.RecoveryCodesLeft != recoveryCodeCount {
+		t.Fatalf("expected two-factor to be enabled with all recovery codes, got %+v, %v", data, err)
+	}
+	if _, err := authService.BeginTOTPEnrollment(ctx); !errors.Is(err, ErrTOTPAlreadyEnabled) {
+		t.Errorf("expected %v, got %v", ErrTOTPAlreadyEnabled, err)
+	}
+}
+
+func TestAuthService_MFAChallenge(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+	secret, current, _ := enrollTOTP(t, ctx, authService)
+
+	token, err := authService.CreateMFAChallenge(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the code used to confirm the enrollment cannot be replayed
+	if _, err := authService.VerifyMFAChallenge(ctx, token, mustTOTPCode(t, secret, current), "192.0.2.1"); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Fatalf("expected replayed code to be rejected, got %v", err)
+	}
+
+	loggedIn, err := authService.VerifyMFAChallenge(ctx, token, mustTOTPCode(t, secret, current+1), "192.0.2.1")
+	if err != nil || loggedIn.ID != user.ID {
+		t.Fatalf("expected challenge to complete, got %v", err)
+	}
+
+	if _, err := authService.VerifyMFAChallenge(ctx, token, mustTOTPCode(t, secret, current+1), "192.0.2.1"); !errors.Is(err, ErrMFAChallengeInvalid) {
+		t.Errorf("expected challenge to be single use, got %v", err)
+	}
+}
+
+func TestAuthService_MFAChallengeAttemptLimit(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+	secret, current, _ := enrollTOTP(t, ctx, authService)
+
+	// the cap of the challenge holds on its own, without the client throttle or lockout
+	authService.LockoutThreshold = 0
+	token, err := authService.CreateMFAChallenge(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i < mfaChallengeAttempts; i++ {
+		if _, err := authService.VerifyMFAChallenge(ctx, token, "bad-code", fmt.Sprintf("192.0.2.%d", i)); !errors.Is(err, ErrInvalidTOTPCode) {
+			t.Fatalf("attempt %d: expected %v, got %v", i, ErrInvalidTOTPCode, err)
+		}
+	}
+	if _, err := authService.VerifyMFAChallenge(ctx, token, "bad-code", "198.51.100.1"); !errors.Is(err, ErrMFATooManyAttempts) {
+		t.Fatalf("expected %v, got %v", ErrMFATooManyAttempts, err)
+	}
+
+	if _, err := authService.VerifyMFAChallenge(ctx, token, mustTOTPCode(t, secret, current+1), "198.51.100.2"); !errors.Is(err, ErrMFAChallengeInvalid) {
+		t.Errorf("expected locked challenge to be gone, got %v", err)
+	}
+}
+
+func TestAuthService_MFAFailuresLockAccount(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+	secret, current, _ := enrollTOTP(t, ctx, authService)
+	if err := authService.UpdateUserPassword(ctx, user.ID, "Password123!"); err != nil {
+		t.Fatal(err)
+	}
+
+	// each password login brings a new challenge, but the invalid codes of all of them
+	// count towards the lockout and a correct password does not reset it
+	for i := range authService.LockoutThreshold {
+		ip := fmt.Sprintf("192.0.2.%d", i)
+		if _, err := authService.AuthenticateUser(ctx, user.Email, "Password123!", ip); err != nil {
+			t.Fatalf("attempt %d: expected the password to be accepted, got %v", i, err)
+		}
+		token, err := authService.CreateMFAChallenge(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := authService.VerifyMFAChallenge(ctx, token, "bad-code", ip); !errors.Is(err, ErrInvalidTOTPCode) {
+			t.Fatalf("attempt %d: expected %v, got %v", i, ErrInvalidTOTPCode, err)
+		}
+	}
+
+	token, err := authService.CreateMFAChallenge(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.VerifyMFAChallenge(ctx, token, mustTOTPCode(t, secret, current+1), "198.51.100.1"); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("expected %v, got %v", ErrAccountLocked, err)
+	}
+	if _, err := authService.AuthenticateUser(ctx, user.Email, "Password123!", "198.51.100.1"); !errors.Is(err, ErrAccountLocked) {
+		t.Errorf("expected the password login to be locked too, got %v", err)
+	}
+
+	// a completed login forgets the failures
+	if err := authService.UnlockUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.VerifyMFAChallenge(ctx, token, mustTOTPCode(t, secret, current+1), "198.51.100.1"); err != nil {
+		t.Fatalf("expected the challenge to complete once unlocked, got %v", err)
+	}
+}
+
+func TestAuthService_MFAFailuresThrottleClient(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+	enrollTOTP(t, ctx, authService)
+	authService.LockoutThreshold = 0
+
+	for i := range clientFreeFailures + 1 {
+		token, err := authService.CreateMFAChallenge(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := authService.VerifyMFAChallenge(ctx, token, "bad-code", "192.0.2.1"); !errors.Is(err, ErrInvalidTOTPCode) {
+			t.Fatalf("attempt %d: expected %v, got %v", i, ErrInvalidTOTPCode, err)
+		}
+	}
+
+	token, err := authService.CreateMFAChallenge(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.VerifyMFAChallenge(ctx, token, "bad-code", "192.0.2.1"); !errors.Is(err, ErrLoginThrottled) {
+		t.Errorf("expected %v, got %v", ErrLoginThrottled, err)
+	}
+}
+
+func TestAuthService_RecoveryCodesAreSingleUse(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+	_, _, codes := enrollTOTP(t, ctx, authService)
+
+	token, err := authService.CreateMFAChallenge(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.VerifyMFAChallenge(ctx, token, codes[0], "192.0.2.1"); err != nil {
+		t.Fatalf("expected recovery code to be accepted, got %v", err)
+	}
+
+	token, err = authService.CreateMFAChallenge(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.VerifyMFAChallenge(ctx, token, codes[0], "192.0.2.1"); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Errorf("expected used recovery code to be rejected, got %v", err)
+	}
+	// dashes, spaces and case do not matter
+	if _, err := authService.VerifyMFAChallenge(ctx, token, " "+strings.ToUpper(strings.ReplaceAll(codes[1], "-", "")), "192.0.2.1"); err != nil {
+		t.Errorf("expected reformatted recovery code to be accepted, got %v", err)
+	}
+
+	data, err := authService.TwoFactorPageData(ctx)
+	if err != nil || data.RecoveryCodesLeft != recoveryCodeCount-2 {
+		t.Errorf("expected %d recovery codes left, got %+v, %v", recoveryCodeCount-2, data, err)
+	}
+}
+
+func TestAuthService_DisableAndResetTOTP(t *testing.T) {
+	testDB, authService := newAuthTestService(t, models.RegistrationOpen)
+	ctx := setupTestUser(t, testDB)
+	user := mustUser(t, ctx)
+	_, _, codes := enrollTOTP(t, ctx, authService)
+
+	if err := authService.DisableTOTP(ctx, "abcdef"); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Fatalf("expected %v, got %v", ErrInvalidTOTPCode, err)
+	}
+	if err := authService.DisableTOTP(ctx, codes[0]); err != nil {
+		t.Fatalf("expected two-factor to be disabled with a recovery code, got %v", err)
+	}
+	if enabled, _ := authService.TOTPEnabled(ctx, user.ID); enabled {
+		t.Fatal("expected two-factor to be disabled")
+	}
+	if err := authService.ResetTOTP(ctx, user.ID); !errors.Is(err, ErrTOTPNotEnabled) {
+		t.Errorf("expected %v, got %v", ErrTOTPNotEnabled, err)
+	}
+
+	enrollTOTP(t, ctx, authService)
+	if err := authService.ResetTOTP(ctx, user.ID); err != nil {
+		t.Fatalf("expected admin reset to succeed, got %v", err)
+	}
+	data, err := authService.TwoFactorPageData(ctx)
+	if err != nil || data.Enabled || data.Enrollment != nil || data.RecoveryCodesLeft != 0 {
+		t.Errorf("expected reset to remove every factor, got %+v, %v", data, err)
+	}
+}
+
+func mustUser(t *testing.T, ctx context.Context) *models.User {
+	t.Helper()
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
@@ -37,16 +37,18 @@ type WatchedService struct {
 	db          db.DB
 	listService *ListService
 	tmdb        *MovieService
+	webhooks    *WebhookService
 	log         *slog.Logger
 }
 
-func NewWatchedService(db db.DB, listService *ListService, tmdb *MovieService) *WatchedService {
+func NewWatchedService(db db.DB, listService *ListService, tmdb *MovieService, webhooks *WebhookService) *WatchedService {
 	log := logging.Get("watched service")
 	log.Debug("creating new WatchedService instance")
 	return &WatchedService{
 		db:          db,
 		listService: listService,
 		tmdb:        tmdb,
+		webhooks:    webhooks,
 		log:         log,
 	}
 }
@@ -86,10 +88,82 @@ func (s *WatchedService) AddWatched(ctx context.Context, movieID int64, date tim
 		// don't stop on fail
 	}
 
+	err = s.webhooks.Enqueue(ctx, models.WebhookEventWatchedAdded, models.WatchedEventData{
+		MovieID:    movieID,
+		Date:       &date,
+		InTheaters: &inTheaters,
+		Rating:     rating,
+	})
+	if err != nil {
+		s.log.Warn("AddWatched: failed to queue webhook", "movieID", movieID, "error", err)
+	}
+
 	s.log.Info("AddWatched: successfully added watched movie", "movieID", movieID, "userID", user.ID)
 	return nil
 }
 
+// AddWatchedListMovies marks the movies of movieIDs that are in listID as watched on date,
+// at home and without a rating, like AddWatched. Either every movie is marked or none is.
+// It returns how many were marked.
+func (s *WatchedService) AddWatchedListMovies(ctx context.Context, listID int64, movieIDs []int64, date time.Time) (int, error) {
+	if len(movieIDs) == 0 {
+		return 0, ErrNoMoviesSelected
+	}
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("AddWatchedListMovies: failed to get user", "error", err)
+		return 0, fmt.Errorf("AddWatchedListMovies: failed to get user: %w", err)
+	}
+
+	list, err := s.listService.visibleList(ctx, listID)
+	if err != nil {
+		return 0, err
+	}
+	// only the selected movies that are in the list, in its order
+	selected := make(map[int64]bool, len(movieIDs))
+	for _, movieID := range movieIDs {
+		selected[movieID] = true
+	}
+	var marked []int64
+	for _, movieID := range listMovieIDs(list.Movies) {
+		if selected[movieID] {
+			marked = append(marked, movieID)
+		}
+	}
+	if len(marked) == 0 {
+		return 0, ErrMovieNotInList
+	}
+
+	s.log.Debug("AddWatchedListMovies: adding watched movies", "listID", listID, "count", len(marked), "date", date, "userID", user.ID)
+
+	entries := make([]db.InsertWatched, len(marked))
+	for i, movieID := range marked {
+		entries[i] = db.InsertWatched{UserID: user.ID, MovieID: movieID, Date: date}
+	}
+	if err := s.db.InsertWatchedMovies(ctx, entries); err != nil {
+		s.log.Error("AddWatchedListMovies: failed to insert watched entries", "listID", listID, "error", err, "userID", user.ID)
+		return 0, fmt.Errorf("AddWatchedListMovies: failed to record watched entries: %w", err)
+	}
+
+	inTheaters := false
+	for _, movieID := range marked {
+		if err := s.listService.RemoveMovieFromWatchlist(ctx, movieID); err != nil {
+			s.log.Warn("AddWatchedListMovies: failed to auto-remove movie from watchlist after marking as watched", "movieID", movieID, "error", err)
+		}
+		err := s.webhooks.Enqueue(ctx, models.WebhookEventWatchedAdded, models.WatchedEventData{
+			MovieID:    movieID,
+			Date:       &date,
+			InTheaters: &inTheaters,
+		})
+		if err != nil {
+			s.log.Warn("AddWatchedListMovies: failed to queue webhook", "movieID", movieID, "error", err)
+		}
+	}
+
+	s.log.Info("AddWatchedListMovies: successfully added watched movies", "listID", listID, "count", len(marked), "userID", user.ID)
+	return len(marked), nil
+}
+
 func (s *WatchedService) UpdateWatchedEntry(ctx context.Context, watchedID int64, date time.Time, inTheaters bool, rating *float64) (int64, error) {
 	if watchedID <= 0 {
 		return 0, fmt.Errorf("UpdateWatchedEntry: invalid watched ID")
@@ -127,6 +201,17 @@ func (s *WatchedService) UpdateWatchedEntry(ctx context.Context, watchedID int64
 		return 0, fmt.Errorf("UpdateWatchedEntry: failed to update watched entry: %w", err)
 	}
 
+	err = s.webhooks.Enqueue(ctx, models.WebhookEventWatchedUpdated, models.WatchedEventData{
+		WatchedID:  watchedID,
+		MovieID:    movieID,
+		Date:       &date,
+		InTheaters: &inTheaters,
+		Rating:     rating,
+	})
+	if err != nil {
+		s.log.Warn("UpdateWatchedEntry: failed to queue webhook", "watchedID", watchedID, "error", err)
+	}
+
 	s.log.Info("UpdateWatchedEntry: successfully updated watched movie", "watchedID", watchedID, "movieID", movieID, "userID", user.ID)
 	return movieID, nil
 }
@@ -153,6 +238,14 @@ func (s *WatchedService) DeleteWatchedEntry(ctx context.Context, watchedID int64
 		return 0, fmt.Errorf("DeleteWatchedEntry: failed to delete watched entry: %w", err)
 	}
 
+	err = s.webhooks.Enqueue(ctx, models.WebhookEventWatchedDeleted, models.WatchedEventData{
+		WatchedID: watchedID,
+		MovieID:   movieID,
+	})
+	if err != nil {
+		s.log.Warn("DeleteWatchedEntry: failed to queue webhook", "watchedID", watchedID, "error", err)
+	}
+
 	s.log.Info("DeleteWatchedEntry: successfully deleted watched movie", "watchedID", watchedID, "movieID", movieID, "userID", user.ID)
 	return movieID, nil
 }
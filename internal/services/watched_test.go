@@ -22,8 +22,8 @@ func TestWatchedService_AddWatched(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour) // No TMDB for test
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	// Insert a movie
 	movie := &models.MovieDetails{
@@ -52,6 +52,62 @@ func TestWatchedService_AddWatched(t *testing.T) {
 	}
 }
 
+func TestWatchedService_AddWatchedListMovies(t *testing.T) {
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = testDB.Close() }()
+	ctx := setupTestUser(t, testDB)
+
+	listService := NewListService(testDB, nil, nil)
+	watchedService := NewWatchedService(testDB, listService, nil, nil)
+
+	watchlist, err := listService.CreateList(ctx, "Watchlist", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id := int64(1); id <= 3; id++ {
+		if err := testDB.UpsertMovie(ctx, &models.MovieDetails{Movie: models.Movie{ID: id, Title: "Movie"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := listService.AddMovieToList(ctx, watchlist.ID, id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := listService.CreateList(ctx, "Picks", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int64{1, 2} {
+		if err := listService.AddMovieToList(ctx, list.ID, id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// movie 3 is not in the list and must be left alone
+	marked, err := watchedService.AddWatchedListMovies(ctx, list.ID, []int64{1, 2, 3}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if marked != 2 {
+		t.Errorf("expected 2 movies marked, got %d", marked)
+	}
+	if count, err := watchedService.GetWatchedCount(ctx); err != nil || count != 2 {
+		t.Errorf("expected 2 watched movies, got %d (%v)", count, err)
+	}
+	if got := sortedListMovieIDs(t, ctx, listService, watchlist.ID); !reflect.DeepEqual(got, []int64{3}) {
+		t.Errorf("expected only the unmarked movie left in the watchlist, got %v", got)
+	}
+
+	if _, err := watchedService.AddWatchedListMovies(ctx, list.ID, []int64{3}, time.Now()); !errors.Is(err, ErrMovieNotInList) {
+		t.Errorf("expected %v, got %v", ErrMovieNotInList, err)
+	}
+	if _, err := watchedService.AddWatchedListMovies(ctx, list.ID, nil, time.Now()); !errors.Is(err, ErrNoMoviesSelected) {
+		t.Errorf("expected %v, got %v", ErrNoMoviesSelected, err)
+	}
+}
+
 func TestWatchedService_UpdateWatchedEntry(t *testing.T) {
 	testDB, err := db.NewTestDB()
 	if err != nil {
@@ -61,8 +117,8 @@ func TestWatchedService_UpdateWatchedEntry(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	movie := &models.MovieDetails{
 		Movie: models.Movie{
@@ -129,8 +185,8 @@ func TestWatchedService_UpdateWatchedEntry_ZeroRatingClearsRating(t *testing.T)
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	movie := &models.MovieDetails{
 		Movie: models.Movie{
@@ -179,8 +235,8 @@ func TestWatchedService_UpdateWatchedEntry_DuplicateDateConflict(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	movie := &models.MovieDetails{
 		Movie: models.Movie{
@@ -235,8 +291,8 @@ func TestWatchedService_UpdateWatchedEntry_NotFound(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	_, err = watchedService.UpdateWatchedEntry(ctx, 999, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), false, nil)
 	if !errors.Is(err, ErrWatchedEntryNotFound) {
@@ -253,8 +309,8 @@ func TestWatchedService_DeleteWatchedEntry(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	movie := &models.MovieDetails{
 		Movie: models.Movie{
@@ -313,8 +369,8 @@ func TestWatchedService_DeleteWatchedEntry_NotFound(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	_, err = watchedService.DeleteWatchedEntry(ctx, 999)
 	if !errors.Is(err, ErrWatchedEntryNotFound) {
@@ -336,8 +392,8 @@ func TestWatchedService_DeleteWatchedEntry_NonOwned(t *testing.T) {
 	otherCtx := context.WithValue(context.Background(), common.UserKey, otherUser)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	movie := &models.MovieDetails{
 		Movie: models.Movie{
@@ -377,8 +433,8 @@ func TestWatchedService_ImportExportWatched(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	// Insert movies
 	for i := 1; i <= 2; i++ {
@@ -445,8 +501,8 @@ func TestWatchedService_GetWatchedStats(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	// Insert movie with genres
 	movie := &models.MovieDetails{
@@ -491,8 +547,8 @@ func TestWatchedService_GetWatchedStats_NewFields(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	releaseDate := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
 
@@ -641,8 +697,8 @@ func TestWatchedService_GetWatchedStats_RatingFields(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	makeMovie := func(id int64, title string, releaseDate time.Time, voteAverage float32, voteCount int64, directorID int64, directorName string, actorID int64, actorName string) *models.MovieDetails {
 		return &models.MovieDetails{
@@ -817,8 +873,8 @@ func TestWatchedService_GetPersonWatchActivity(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	const targetPersonID int64 = 77
 	const competingMalePersonID int64 = 88
@@ -995,8 +1051,8 @@ func TestWatchedService_GetPersonWatchActivity_CrewOnlyActorRankNil(t *testing.T
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	const targetPersonID int64 = 55
 
@@ -1052,8 +1108,8 @@ func TestWatchedService_GetPersonWatchActivity_NoMatches(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	movie := testMovieDetailsWithCredits(
 		1,
@@ -1108,8 +1164,8 @@ func TestWatchedService_AddWatched_InvalidMovie(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	// Try to add watched for non-existent movie
 	date := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
@@ -1128,8 +1184,8 @@ func TestWatchedService_ImportExport_Empty(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	// Import empty data
 	importData := models.ImportWatchedMoviesLog{}
@@ -1157,8 +1213,8 @@ func TestWatchedService_ImportWatched_ContinuesOnPerMovieErrors(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	for i := 1; i <= 2; i++ {
 		movie := &models.MovieDetails{
@@ -1222,8 +1278,8 @@ func TestWatchedService_ImportAll_ContinuesWithListsWhenWatchedHasErrors(t *test
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	for i := 1; i <= 2; i++ {
 		movie := &models.MovieDetails{
@@ -1292,8 +1348,8 @@ func TestWatchedService_ImportAll(t *testing.T) {
 	ctx := setupTestUser(t, testDB)
 
 	movieService := NewMovieService(testDB, nil, time.Hour)
-	listService := NewListService(testDB, movieService)
-	watchedService := NewWatchedService(testDB, listService, movieService)
+	listService := NewListService(testDB, movieService, nil)
+	watchedService := NewWatchedService(testDB, listService, movieService, nil)
 
 	for i := 1; i <= 2; i++ {
 		movie := &models.MovieDetails{
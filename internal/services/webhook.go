@@ -0,0 +1,411 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/logging"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultWebhookMaxAttempts = 8
+	defaultWebhookBaseBackoff = 30 * time.Second
+	maxWebhookBackoff         = 6 * time.Hour
+	webhookDeliveryBatchSize  = 50
+	webhookDeliveryLogLimit   = 50
+	maxWebhookErrorLength     = 500
+	// a slow receiver delays the others of its batch by at most this much
+	webhookDeliveryTimeout = 10 * time.Second
+	webhookSendConcurrency = 8
+
+	// WebhookSignatureHeader carries the hex encoded HMAC-SHA256 of the request body
+	WebhookSignatureHeader = "X-Gowatch-Signature"
+	WebhookEventHeader     = "X-Gowatch-Event"
+	WebhookDeliveryHeader  = "X-Gowatch-Delivery"
+)
+
+var (
+	ErrInvalidWebhookURL   = errors.New("webhook URL must be an absolute http or https URL")
+	ErrNoWebhookEvents     = errors.New("webhook must subscribe to at least one event")
+	ErrUnknownWebhookEvent = errors.New("unknown webhook event")
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	// ErrWebhookDestinationNotAllowed is returned for loopback, private and link-local
+	// destinations outside of AllowedNetworks
+	ErrWebhookDestinationNotAllowed = errors.New("webhook destination is not allowed")
+)
+
+// WebhookService manages user webhooks and delivers queued events to them.
+// A nil *WebhookService is valid and silently drops events.
+type WebhookService struct {
+	db          db.DB
+	client      *http.Client
+	log         *slog.Logger
+	MaxAttempts int64
+	BaseBackoff time.Duration
+	// AllowedNetworks are the loopback, private and link-local networks webhooks may
+	// still be delivered to, every other non-public address is refused when dialing
+	AllowedNetworks []netip.Prefix
+}
+
+// NewWebhookService returns a service sending deliveries with client. The transport of
+// client is cloned so that every connection is checked against the allowed destinations,
+// also after redirects and whatever the host name resolves to.
+func NewWebhookService(db db.DB, client *http.Client) *WebhookService {
+	log := logging.Get("webhook service")
+	log.Debug("creating new WebhookService instance")
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	s := &WebhookService{
+		db:          db,
+		log:         log,
+		MaxAttempts: defaultWebhookMaxAttempts,
+		BaseBackoff: defaultWebhookBaseBackoff,
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+	transport = transport.Clone()
+	// a proxy would be dialed instead of the destination, defeating the check
+	transport.Proxy = nil
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: s.checkDial}
+	transport.DialContext = dialer.DialContext
+	guarded := *client
+	guarded.Transport = transport
+	s.client = &guarded
+
+	return s
+}
+
+// checkDial refuses connections to addresses that are not allowed destinations
+func (s *WebhookService) checkDial(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrWebhookDestinationNotAllowed, address)
+	}
+	if !s.destinationAllowed(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrWebhookDestinationNotAllowed, addrPort.Addr())
+	}
+	return nil
+}
+
+// destinationAllowed reports whether webhooks may be delivered to addr: public
+// addresses always are, internal ones only when in AllowedNetworks
+func (s *WebhookService) destinationAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() && !addr.IsMulticast() && !addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range s.AllowedNetworks {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, rawURL, secret string, events []models.WebhookEvent) (*models.Webhook, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	// host names are checked when dialing, as they may resolve differently later
+	if addr, err := netip.ParseAddr(parsed.Hostname()); err == nil && !s.destinationAllowed(addr) {
+		return nil, ErrWebhookDestinationNotAllowed
+	}
+	if len(events) == 0 {
+		return nil, ErrNoWebhookEvents
+	}
+	for _, event := range events {
+		if !slices.Contains(models.AllWebhookEvents, event) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownWebhookEvent, event)
+		}
+	}
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	if secret == "" {
+		// same entropy as session IDs
+		secret, err = generateSessionID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+	}
+
+	webhook, err := s.db.InsertWebhook(ctx, user.ID, rawURL, secret, events)
+	if err != nil {
+		s.log.Error("failed to create webhook", "url", rawURL, "error", err)
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	s.log.Info("successfully created webhook", "webhookID", webhook.ID, "userID", user.ID)
+	return webhook, nil
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	webhooks, err := s.db.GetWebhooksByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (s *WebhookService) SetWebhookEnabled(ctx context.Context, webhookID int64, enabled bool) error {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return err
+	}
+
+	rows, err := s.db.SetWebhookEnabled(ctx, user.ID, webhookID, enabled)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+
+	s.log.Info("updated webhook", "webhookID", webhookID, "enabled", enabled)
+	return nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookID int64) error {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return err
+	}
+
+	rows, err := s.db.DeleteWebhook(ctx, user.ID, webhookID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+
+	s.log.Info("deleted webhook", "webhookID", webhookID)
+	return nil
+}
+
+// GetRecentDeliveries returns the most recent deliveries across all of the user's webhooks
+func (s *WebhookService) GetRecentDeliveries(ctx context.Context) ([]models.WebhookDelivery, error) {
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		s.log.Error("failed to get userID", "error", err)
+		return nil, err
+	}
+
+	deliveries, err := s.db.GetWebhookDeliveriesByUser(ctx, user.ID, webhookDeliveryLogLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Enqueue queues a delivery of event for every enabled webhook of the user in ctx
+// that subscribes to it. Deliveries are sent later by ProcessPendingDeliveries.
+func (s *WebhookService) Enqueue(ctx context.Context, event models.WebhookEvent, data any) error {
+	if s == nil {
+		return nil
+	}
+
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+	return s.EnqueueFor(ctx, user.ID, event, data)
+}
+
+// EnqueueFor queues event like Enqueue, for the webhooks of ownerID instead: the user
+// the event belongs to, as the owner of a list edited by a member. The payload names
+// the user in ctx as the one who caused it, or ownerID without one.
+func (s *WebhookService) EnqueueFor(ctx context.Context, ownerID int64, event models.WebhookEvent, data any) error {
+	if s == nil {
+		return nil
+	}
+
+	actorID := ownerID
+	if user, err := common.GetUser(ctx); err == nil {
+		actorID = user.ID
+	}
+
+	webhooks, err := s.db.GetEnabledWebhooksByUser(ctx, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to get webhooks for event %s: %w", event, err)
+	}
+
+	now := time.Now().UTC()
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(models.WebhookPayload{
+				Event:     event,
+				Timestamp: now,
+				UserID:    actorID,
+				Data:      data,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to encode webhook payload: %w", err)
+			}
+		}
+
+		err = s.db.InsertWebhookDelivery(ctx, db.InsertWebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       string(payload),
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+		s.log.Debug("queued webhook delivery", "webhookID", webhook.ID, "event", event)
+	}
+
+	return nil
+}
+
+// ProcessPendingDeliveries sends every delivery that is due and records the outcome.
+// Deliveries are sent concurrently, each given at most webhookDeliveryTimeout.
+// Failed deliveries are retried with exponential backoff until MaxAttempts is reached.
+func (s *WebhookService) ProcessPendingDeliveries(ctx context.Context) (int, error) {
+	deliveries, err := s.db.GetDueWebhookDeliveries(ctx, time.Now().UTC(), webhookDeliveryBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due webhook deliveries: %w", err)
+	}
+
+	type result struct {
+		status int
+		err    error
+	}
+	results := make([]result, len(deliveries))
+	var g errgroup.Group
+	g.SetLimit(webhookSendConcurrency)
+	for i, delivery := range deliveries {
+		g.Go(func() error {
+			sendCtx, cancel := context.WithTimeout(ctx, webhookDeliveryTimeout)
+			defer cancel()
+			results[i].status, results[i].err = s.send(sendCtx, delivery)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	for i, delivery := range deliveries {
+		responseStatus, sendErr := results[i].status, results[i].err
+
+		now := time.Now().UTC()
+		attempt := db.UpdateWebhookDeliveryAttempt{
+			ID:            delivery.ID,
+			Status:        models.WebhookDeliveryDelivered,
+			Attempts:      delivery.Attempts + 1,
+			NextAttemptAt: now,
+			LastAttemptAt: now,
+		}
+		if responseStatus != 0 {
+			status := int64(responseStatus)
+			attempt.ResponseStatus = &status
+		}
+		if sendErr != nil {
+			msg := sendErr.Error()
+			if len(msg) > maxWebhookErrorLength {
+				msg = msg[:maxWebhookErrorLength]
+			}
+			attempt.LastError = &msg
+			if attempt.Attempts >= s.MaxAttempts {
+				attempt.Status = models.WebhookDeliveryFailed
+			} else {
+				attempt.Status = models.WebhookDeliveryPending
+				attempt.NextAttemptAt = now.Add(s.backoff(attempt.Attempts))
+			}
+			s.log.Warn("webhook delivery failed", "deliveryID", delivery.ID, "attempts", attempt.Attempts, "error", sendErr)
+		}
+
+		if err := s.db.UpdateWebhookDeliveryAttempt(ctx, attempt); err != nil {
+			return 0, fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+func (s *WebhookService) send(ctx context.Context, delivery models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gowatch-webhook")
+	req.Header.Set(WebhookEventHeader, string(delivery.Event))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.Secret, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt after the given number of attempts
+func (s *WebhookService) backoff(attempts int64) time.Duration {
+	delay := s.BaseBackoff
+	for i := int64(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= maxWebhookBackoff {
+			return maxWebhookBackoff
+		}
+	}
+	return delay
+}
+
+// SignWebhookPayload returns the signature header value for body, in the form "sha256=<hex>"
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
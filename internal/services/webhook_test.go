@@ -0,0 +1,455 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/db"
+	"github.com/marcosalvi-01/gowatch/internal/common"
+	"github.com/marcosalvi-01/gowatch/internal/models"
+)
+
+type receivedWebhook struct {
+	path      string
+	event     string
+	signature string
+	body      []byte
+}
+
+// webhookReceiver is a local HTTP receiver that records every request and
+// answers with the next status in statuses (the last one is repeated)
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	received []receivedWebhook
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.received = append(rcv.received, receivedWebhook{
+		path:      r.URL.Path,
+		event:     r.Header.Get(WebhookEventHeader),
+		signature: r.Header.Get(WebhookSignatureHeader),
+		body:      body,
+	})
+
+	status := http.StatusNoContent
+	if len(rcv.statuses) > 0 {
+		status = rcv.statuses[0]
+		if len(rcv.statuses) > 1 {
+			rcv.statuses = rcv.statuses[1:]
+		}
+	}
+	w.WriteHeader(status)
+}
+
+func newWebhookTestEnv(t *testing.T, statuses ...int) (*db.SqliteDB, *WebhookService, *webhookReceiver, *httptest.Server) {
+	t.Helper()
+
+	testDB, err := db.NewTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = testDB.Close() })
+
+	receiver := &webhookReceiver{statuses: statuses}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	webhookService := NewWebhookService(testDB, server.Client())
+	// the receiver listens on loopback
+	webhookService.AllowedNetworks = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+	return testDB, webhookService, receiver, server
+}
+
+func TestWebhookService_DeliversSignedPayload(t *testing.T) {
+	testDB, webhookService, receiver, server := newWebhookTestEnv(t)
+	ctx := setupTestUser(t, testDB)
+
+	listService := NewListService(testDB, nil, webhookService)
+
+	if err := testDB.UpsertMovie(ctx, &models.MovieDetails{Movie: models.Movie{ID: 1, Title: "Test Movie"}}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := listService.CreateList(ctx, "Favorites", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := webhookService.CreateWebhook(ctx, server.URL, "s3cret", []models.WebhookEvent{models.WebhookEventListItemAdded}); err != nil {
+		t.Fatal(err)
+	}
+
+	note := "must watch"
+	if err := listService.AddMovieToList(ctx, list.ID, 1, &note); err != nil {
+		t.Fatal(err)
+	}
+	// not subscribed, must not be queued
+	if err := listService.DeleteMovieFromList(ctx, list.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	sent, err := webhookService.ProcessPendingDeliveries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 {
+		t.Fatalf("expected 1 delivery, got %d", sent)
+	}
+
+	if len(receiver.received) != 1 {
+		t.Fatalf("expected receiver to get 1 request, got %d", len(receiver.received))
+	}
+	got := receiver.received[0]
+	if got.event != string(models.WebhookEventListItemAdded) {
+		t.Errorf("expected event header %q, got %q", models.WebhookEventListItemAdded, got.event)
+	}
+	if want := SignWebhookPayload("s3cret", got.body); got.signature != want {
+		t.Errorf("signature mismatch: got %q, want %q", got.signature, want)
+	}
+
+	var payload struct {
+		Event models.WebhookEvent      `json:"event"`
+		Data  models.ListItemEventData `json:"data"`
+	}
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Event != models.WebhookEventListItemAdded || payload.Data.ListID != list.ID || payload.Data.MovieID != 1 {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+	if payload.Data.Note == nil || *payload.Data.Note != note {
+		t.Errorf("expected note %q in payload, got %v", note, payload.Data.Note)
+	}
+
+	deliveries, err := webhookService.GetRecentDeliveries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("expected 1 delivery in log, got %d", len(deliveries))
+	}
+	if deliveries[0].Status != models.WebhookDeliveryDelivered || deliveries[0].Attempts != 1 {
+		t.Errorf("expected delivered after 1 attempt, got %s after %d", deliveries[0].Status, deliveries[0].Attempts)
+	}
+	if deliveries[0].ResponseStatus == nil || *deliveries[0].ResponseStatus != http.StatusNoContent {
+		t.Errorf("expected response status %d, got %v", http.StatusNoContent, deliveries[0].ResponseStatus)
+	}
+}
+
+func TestWebhookService_RetriesWithBackoff(t *testing.T) {
+	testDB, webhookService, receiver, server := newWebhookTestEnv(t, http.StatusInternalServerError, http.StatusOK)
+	ctx := setupTestUser(t, testDB)
+
+	if _, err := webhookService.CreateWebhook(ctx, server.URL, "s3cret", models.AllWebhookEvents); err != nil {
+		t.Fatal(err)
+	}
+	if err := webhookService.Enqueue(ctx, models.WebhookEventWatchedDeleted, models.WatchedEventData{WatchedID: 3, MovieID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	webhookService.BaseBackoff = time.Hour
+	if _, err := webhookService.ProcessPendingDeliveries(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries, err := webhookService.GetRecentDeliveries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := deliveries[0]
+	if first.Status != models.WebhookDeliveryPending || first.Attempts != 1 {
+		t.Fatalf("expected pending after 1 attempt, got %s after %d", first.Status, first.Attempts)
+	}
+	if first.LastError == nil {
+		t.Error("expected last error to be recorded")
+	}
+	if delay := time.Until(first.NextAttemptAt); delay < 50*time.Minute {
+		t.Errorf("expected next attempt about an hour away, got %s", delay)
+	}
+
+	// backoff has not elapsed yet
+	sent, err := webhookService.ProcessPendingDeliveries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 0 {
+		t.Fatalf("expected no deliveries before backoff elapsed, got %d", sent)
+	}
+
+	if err := testDB.UpdateWebhookDeliveryAttempt(ctx, db.UpdateWebhookDeliveryAttempt{
+		ID:            first.ID,
+		Status:        models.WebhookDeliveryPending,
+		Attempts:      first.Attempts,
+		NextAttemptAt: time.Now().UTC().Add(-time.Second),
+		LastAttemptAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := webhookService.ProcessPendingDeliveries(ctx); err != nil {
+		t.Fatal(err)
+	}
+	deliveries, err = webhookService.GetRecentDeliveries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deliveries[0].Status != models.WebhookDeliveryDelivered || deliveries[0].Attempts != 2 {
+		t.Errorf("expected delivered after 2 attempts, got %s after %d", deliveries[0].Status, deliveries[0].Attempts)
+	}
+	if len(receiver.received) != 2 {
+		t.Errorf("expected 2 requests, got %d", len(receiver.received))
+	}
+}
+
+func TestWebhookService_GivesUpAfterMaxAttempts(t *testing.T) {
+	testDB, webhookService, _, server := newWebhookTestEnv(t, http.StatusBadGateway)
+	ctx := setupTestUser(t, testDB)
+
+	webhookService.BaseBackoff = 0
+	webhookService.MaxAttempts = 3
+
+	if _, err := webhookService.CreateWebhook(ctx, server.URL, "", []models.WebhookEvent{models.WebhookEventWatchedAdded}); err != nil {
+		t.Fatal(err)
+	}
+	if err := webhookService.Enqueue(ctx, models.WebhookEventWatchedAdded, models.WatchedEventData{MovieID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 5 {
+		if _, err := webhookService.ProcessPendingDeliveries(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deliveries, err := webhookService.GetRecentDeliveries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deliveries[0].Status != models.WebhookDeliveryFailed || deliveries[0].Attempts != 3 {
+		t.Errorf("expected failed after 3 attempts, got %s after %d", deliveries[0].Status, deliveries[0].Attempts)
+	}
+}
+
+func TestWebhookService_DisabledWebhookIsSkipped(t *testing.T) {
+	testDB, webhookService, _, server := newWebhookTestEnv(t)
+	ctx := setupTestUser(t, testDB)
+
+	webhook, err := webhookService.CreateWebhook(ctx, server.URL, "", models.AllWebhookEvents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if webhook.Secret == "" {
+		t.Error("expected a secret to be generated")
+	}
+	if err := webhookService.SetWebhookEnabled(ctx, webhook.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := webhookService.Enqueue(ctx, models.WebhookEventWatchedAdded, models.WatchedEventData{MovieID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries, err := webhookService.GetRecentDeliveries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 0 {
+		t.Errorf("expected no deliveries for a disabled webhook, got %d", len(deliveries))
+	}
+}
+
+func TestWebhookService_CreateWebhookValidation(t *testing.T) {
+	testDB, webhookService, _, _ := newWebhookTestEnv(t)
+	ctx := setupTestUser(t, testDB)
+
+	tests := []struct {
+		name   string
+		url    string
+		events []models.WebhookEvent
+		want   error
+	}{
+		{"relative url", "/hooks", models.AllWebhookEvents, ErrInvalidWebhookURL},
+		{"unsupported scheme", "ftp://example.com", models.AllWebhookEvents, ErrInvalidWebhookURL},
+		{"no events", "https://example.com", nil, ErrNoWebhookEvents},
+		{"unknown event", "https://example.com", []models.WebhookEvent{"movie.deleted"}, ErrUnknownWebhookEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := webhookService.CreateWebhook(ctx, tt.url, "", tt.events)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestWebhookService_SharedListNotifiesOwner(t *testing.T) {
+	testDB, webhookService, receiver, server := newWebhookTestEnv(t)
+	ownerCtx := setupTestUser(t, testDB)
+	member, err := testDB.CreateUser(ownerCtx, "member@example.com", "Member", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	memberCtx := context.WithValue(context.Background(), common.UserKey, member)
+
+	listService := NewListService(testDB, nil, webhookService)
+	if err := testDB.UpsertMovie(ownerCtx, &models.MovieDetails{Movie: models.Movie{ID: 1, Title: "Test Movie"}}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := listService.CreateList(ownerCtx, "Shared", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listService.AddListMember(ownerCtx, list.ID, member.Email, models.ListRoleEditor); err != nil {
+		t.Fatal(err)
+	}
+	events := []models.WebhookEvent{models.WebhookEventListItemAdded}
+	if _, err := webhookService.CreateWebhook(ownerCtx, server.URL+"/owner", "", events); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := webhookService.CreateWebhook(memberCtx, server.URL+"/member", "", events); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := listService.AddMovieToList(memberCtx, list.ID, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := webhookService.ProcessPendingDeliveries(ownerCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(receiver.received) != 1 || receiver.received[0].path != "/owner" {
+		t.Fatalf("expected only the webhook of the list owner to be called, got %+v", receiver.received)
+	}
+	var payload models.WebhookPayload
+	if err := json.Unmarshal(receiver.received[0].body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.UserID != member.ID {
+		t.Errorf("expected the payload to name member %d as the one who added the movie, got %d", member.ID, payload.UserID)
+	}
+}
+
+func TestWebhookService_RemovalsOnlyNotifyRemovedMovies(t *testing.T) {
+	testDB, webhookService, receiver, server := newWebhookTestEnv(t)
+	ctx := setupTestUser(t, testDB)
+
+	listService := NewListService(testDB, nil, webhookService)
+	for id := int64(1); id <= 3; id++ {
+		if err := testDB.UpsertMovie(ctx, &models.MovieDetails{Movie: models.Movie{ID: id, Title: "Test Movie"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := listService.CreateList(ctx, "From", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := listService.CreateList(ctx, "To", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, movieID := range []int64{1, 2} {
+		if err := listService.AddMovieToList(ctx, list.ID, movieID, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := webhookService.CreateWebhook(ctx, server.URL, "", []models.WebhookEvent{models.WebhookEventListItemRemoved}); err != nil {
+		t.Fatal(err)
+	}
+
+	// movie 3 was never in the list
+	if removed, err := listService.RemoveMoviesFromList(ctx, list.ID, []int64{1, 3}); err != nil || removed != 1 {
+		t.Fatalf("expected 1 removed movie, got %d and %v", removed, err)
+	}
+	if _, err := listService.MoveMoviesToList(ctx, list.ID, other.ID, []int64{2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := webhookService.ProcessPendingDeliveries(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(receiver.received) != 2 {
+		t.Fatalf("expected a removal for movies 1 and 2 only, got %d deliveries", len(receiver.received))
+	}
+	var removed []int64
+	for _, delivery := range receiver.received {
+		var payload struct {
+			Data struct {
+				MovieID int64 `json:"movie_id"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(delivery.body, &payload); err != nil {
+			t.Fatal(err)
+		}
+		removed = append(removed, payload.Data.MovieID)
+	}
+	slices.Sort(removed)
+	if !slices.Equal(removed, []int64{1, 2}) {
+		t.Errorf("expected removals of movies 1 and 2, got %v", removed)
+	}
+}
+
+func TestWebhookService_RefusesInternalDestinations(t *testing.T) {
+	testDB, webhookService, receiver, server := newWebhookTestEnv(t)
+	ctx := setupTestUser(t, testDB)
+	webhookService.AllowedNetworks = nil
+
+	listService := NewListService(testDB, nil, webhookService)
+	if err := testDB.UpsertMovie(ctx, &models.MovieDetails{Movie: models.Movie{ID: 1, Title: "Test Movie"}}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := listService.CreateList(ctx, "Favorites", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rawURL := range []string{server.URL, "http://10.0.0.1/hook", "http://[::1]/hook", "http://169.254.169.254/latest"} {
+		if _, err := webhookService.CreateWebhook(ctx, rawURL, "", models.AllWebhookEvents); !errors.Is(err, ErrWebhookDestinationNotAllowed) {
+			t.Errorf("%s: expected %v, got %v", rawURL, ErrWebhookDestinationNotAllowed, err)
+		}
+	}
+
+	// a host name is only resolved when delivering
+	hostURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	if _, err := webhookService.CreateWebhook(ctx, hostURL, "", models.AllWebhookEvents); err != nil {
+		t.Fatal(err)
+	}
+	if err := listService.AddMovieToList(ctx, list.ID, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := webhookService.ProcessPendingDeliveries(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(receiver.received) != 0 {
+		t.Fatalf("expected no request to reach the loopback receiver, got %d", len(receiver.received))
+	}
+
+	deliveries, err := webhookService.GetRecentDeliveries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].LastError == nil || !strings.Contains(*deliveries[0].LastError, ErrWebhookDestinationNotAllowed.Error()) {
+		t.Fatalf("expected the delivery to be refused, got %+v", deliveries)
+	}
+}
+
+func TestWebhookService_NilServiceDropsEvents(t *testing.T) {
+	var webhookService *WebhookService
+	if err := webhookService.Enqueue(t.Context(), models.WebhookEventWatchedAdded, nil); err != nil {
+		t.Errorf("expected nil service to ignore events, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package pages
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/ui/templui/badge"
+)
+
+// auditDateLayout is the format of the date filters of the audit log
+const auditDateLayout = "2006-01-02"
+
+// auditExportPath downloads the audit log entries matching filter
+func auditExportPath(filter models.AuditFilter) string {
+	query := url.Values{}
+	if filter.Action != "" {
+		query.Set("action", string(filter.Action))
+	}
+	if filter.UserID != 0 {
+		query.Set("user", strconv.FormatInt(filter.UserID, 10))
+	}
+	if filter.Search != "" {
+		query.Set("q", filter.Search)
+	}
+	if since := auditSinceValue(filter); since != "" {
+		query.Set("since", since)
+	}
+	if until := auditUntilValue(filter); until != "" {
+		query.Set("until", until)
+	}
+
+	if len(query) == 0 {
+		return "/admin/audit/export"
+	}
+	return "/admin/audit/export?" + query.Encode()
+}
+
+func auditSinceValue(filter models.AuditFilter) string {
+	if filter.Since.IsZero() {
+		return ""
+	}
+	return filter.Since.Format(auditDateLayout)
+}
+
+// auditUntilValue is the last day included by filter, the filter itself ends at the
+// start of the following day
+func auditUntilValue(filter models.AuditFilter) string {
+	if filter.Until.IsZero() {
+		return ""
+	}
+	return filter.Until.Add(-time.Second).Format(auditDateLayout)
+}
+
+// auditActionVariant highlights failures and destructive actions in the audit log
+func auditActionVariant(action models.AuditAction) badge.Variant {
+	switch action {
+	case models.AuditLoginFailed, models.AuditUserDeleted:
+		return badge.VariantDestructive
+	case models.AuditLoginSucceeded:
+		return badge.VariantOutline
+	default:
+		return badge.VariantSecondary
+	}
+}
@@ -0,0 +1,17 @@
+package pages
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+)
+
+// csrfHeaders returns the hx-headers value that attaches the CSRF token to every HTMX request
+func csrfHeaders(ctx context.Context) string {
+	headers, err := json.Marshal(map[string]string{common.CSRFHeader: common.GetCSRFToken(ctx)})
+	if err != nil {
+		return "{}"
+	}
+	return string(headers)
+}
@@ -0,0 +1,19 @@
+package pages
+
+import (
+	"context"
+
+	"github.com/marcosalvi-01/gowatch/internal/common"
+)
+
+// impersonatedName returns the name of the user an admin is viewing gowatch as, if any
+func impersonatedName(ctx context.Context) (string, bool) {
+	if common.GetImpersonator(ctx) == nil {
+		return "", false
+	}
+	user, err := common.GetUser(ctx)
+	if err != nil {
+		return "", false
+	}
+	return user.Name, true
+}
@@ -11,6 +11,7 @@ import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
 import (
+	"github.com/marcosalvi-01/gowatch/internal/ui/components/listgrid"
 	"github.com/marcosalvi-01/gowatch/internal/ui/components/moviecard"
 	"github.com/marcosalvi-01/gowatch/internal/ui/components/sidebar"
 	"github.com/marcosalvi-01/gowatch/internal/ui/templui/avatar"
@@ -66,11 +67,24 @@ func Layout() templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</head><body class=\"bg-background\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</head><body class=\"bg-background\" hx-headers=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Var2 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		var templ_7745c5c3_Var2 string
+		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(csrfHeaders(ctx))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/ui/pages/layout.templ`, Line: 39, Col: 59}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Var3 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
 			if !templ_7745c5c3_IsBuffer {
@@ -82,7 +96,7 @@ func Layout() templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, " <div id=\"sidebar-content\" hx-get=\"/htmx/sidebar\" hx-target=\"this\" hx-swap=\"innerHTML\" hx-trigger=\"refreshSidebar from:body, load\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, " <div id=\"sidebar-content\" hx-get=\"/htmx/sidebar\" hx-target=\"this\" hx-swap=\"innerHTML\" hx-trigger=\"refreshSidebar from:body, load\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -90,11 +104,11 @@ func Layout() templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "</div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Var3 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_Var4 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 				templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 				templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
 				if !templ_7745c5c3_IsBuffer {
@@ -106,7 +120,15 @@ func Layout() templ.Component {
 					}()
 				}
 				ctx = templ.InitializeContext(ctx)
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<div id=\"toast\"></div> <div class=\"flex items-center gap-2 p-2 flex-shrink-0 sticky z-10 top-0 border-b-1 bg-background\"><div class=\"md:hidden\">")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<div id=\"toast\"></div>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = ImpersonationBanner().Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "  <div class=\"flex items-center gap-2 p-2 flex-shrink-0 sticky z-10 top-0 border-b-1 bg-background\"><div class=\"md:hidden\">")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -116,7 +138,7 @@ func Layout() templ.Component {
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "</div>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</div>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -128,7 +150,7 @@ func Layout() templ.Component {
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "</div><div class=\"relative flex flex-1 flex-col min-w-0 overflow-hidden h-full\">")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "</div><div class=\"relative flex flex-1 flex-col min-w-0 overflow-hidden h-full\">")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -156,7 +178,7 @@ func Layout() templ.Component {
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "<div id=\"main-scroll-container\" class=\"flex flex-1 flex-col overflow-auto w-full min-w-0\"><div id=\"main-content\" class=\"p-4 w-full max-w-full min-w-0\">")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "<div id=\"main-scroll-container\" class=\"flex flex-1 flex-col overflow-auto w-full min-w-0\"><div id=\"main-content\" class=\"p-4 w-full max-w-full min-w-0\">")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -164,13 +186,13 @@ func Layout() templ.Component {
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "</div></div></div>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</div></div></div>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 				return nil
 			})
-			templ_7745c5c3_Err = templSidebar.Inset(templSidebar.InsetProps{Class: "min-w-0"}).Render(templ.WithChildren(ctx, templ_7745c5c3_Var3), templ_7745c5c3_Buffer)
+			templ_7745c5c3_Err = templSidebar.Inset(templSidebar.InsetProps{Class: "min-w-0"}).Render(templ.WithChildren(ctx, templ_7745c5c3_Var4), templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -178,11 +200,11 @@ func Layout() templ.Component {
 		})
 		templ_7745c5c3_Err = templSidebar.Layout(templSidebar.LayoutProps{
 			Class: "w-full h-screen",
-		}).Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
+		}).Render(templ.WithChildren(ctx, templ_7745c5c3_Var3), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "</body></html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "</body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -190,6 +212,93 @@ func Layout() templ.Component {
 	})
 }
 
+// ImpersonationBanner reminds an admin viewing gowatch as another user that nothing can
+// be changed, and lets them return to their own account
+func ImpersonationBanner() templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var5 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var5 == nil {
+			templ_7745c5c3_Var5 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		if name, ok := impersonatedName(ctx); ok {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<div class=\"flex items-center gap-2 px-4 py-2 flex-shrink-0 bg-primary text-primary-foreground text-sm\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = icon.Eye(icon.Props{Class: "size-4 shrink-0"}).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "<span class=\"flex-1 min-w-0 truncate\">Viewing as <strong>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var6 string
+			templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(name)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/ui/pages/layout.templ`, Line: 86, Col: 29}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "</strong>, read-only</span>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Var7 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+				templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+				templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+				if !templ_7745c5c3_IsBuffer {
+					defer func() {
+						templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+						if templ_7745c5c3_Err == nil {
+							templ_7745c5c3_Err = templ_7745c5c3_BufErr
+						}
+					}()
+				}
+				ctx = templ.InitializeContext(ctx)
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "Stop Viewing")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				return nil
+			})
+			templ_7745c5c3_Err = button.Button(button.Props{
+				Size:    button.SizeSm,
+				Variant: button.VariantSecondary,
+				Attributes: templ.Attributes{
+					"hx-post":   "/impersonation/stop",
+					"hx-target": "#toast",
+				},
+			}).Render(templ.WithChildren(ctx, templ_7745c5c3_Var7), templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "</div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		return nil
+	})
+}
+
 func AuthLayout() templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
@@ -206,12 +315,89 @@ func AuthLayout() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var4 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var4 == nil {
-			templ_7745c5c3_Var4 = templ.NopComponent
+		templ_7745c5c3_Var8 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var8 == nil {
+			templ_7745c5c3_Var8 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "<!doctype html><html lang=\"en\"><head><meta charset=\"utf-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Gowatch</title><link rel=\"stylesheet\" href=\"/static/css/output.css\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = Scripts().Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = Favicons().Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "</head><body class=\"bg-background min-h-screen flex items-center justify-center\" hx-headers=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var9 string
+		templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(csrfHeaders(ctx))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/ui/pages/layout.templ`, Line: 113, Col: 105}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "\"><div class=\"w-full max-w-md p-4\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templ_7745c5c3_Var8.Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "</div><div id=\"toast\"></div></body></html>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+// PublicLayout is the page of content anyone can view without signing in, head adds
+// page specific tags such as link previews
+func PublicLayout(title string, head templ.Component) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var10 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var10 == nil {
+			templ_7745c5c3_Var10 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "<!doctype html><html lang=\"en\"><head><meta charset=\"utf-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Gowatch</title><link rel=\"stylesheet\" href=\"/static/css/output.css\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "<!doctype html><html lang=\"en\"><head><meta charset=\"utf-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var11 string
+		templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(title)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/ui/pages/layout.templ`, Line: 130, Col: 17}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, " · Gowatch</title><link rel=\"stylesheet\" href=\"/static/css/output.css\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -223,15 +409,53 @@ func AuthLayout() templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "</head><body class=\"bg-background min-h-screen flex items-center justify-center\"><div class=\"w-full max-w-md p-4\">")
+		templ_7745c5c3_Err = head.Render(ctx, templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templ_7745c5c3_Var4.Render(ctx, templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "</head><body class=\"bg-background min-h-screen\"><div class=\"flex items-center justify-between gap-2 p-2 sticky z-10 top-0 border-b-1 bg-background\"><a href=\"/login\" class=\"flex items-center gap-2 px-2 font-bold\"><img src=\"/static/favicon.svg\" alt=\"Gowatch Logo\" class=\"size-6\"> Gowatch</a><div class=\"flex items-center gap-2\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "</div><div id=\"toast\"></div></body></html>")
+		templ_7745c5c3_Err = ThemeSwitcher().Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Var12 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "Sign in")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			return nil
+		})
+		templ_7745c5c3_Err = button.Button(button.Props{
+			Variant: button.VariantOutline,
+			Size:    button.SizeSm,
+			Href:    "/login",
+		}).Render(templ.WithChildren(ctx, templ_7745c5c3_Var12), templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "</div></div><main class=\"p-4 w-full max-w-full min-w-0\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templ_7745c5c3_Var10.Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, "</main></body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -255,12 +479,12 @@ func mainContentLoading() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var5 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var5 == nil {
-			templ_7745c5c3_Var5 = templ.NopComponent
+		templ_7745c5c3_Var13 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var13 == nil {
+			templ_7745c5c3_Var13 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "<div id=\"main-content-loading\" class=\"htmx-indicator absolute inset-0 bg-background z-30 pointer-events-none\"><div class=\"flex flex-col justify-center items-center h-full p-8\"><div class=\"w-full max-w-3xl space-y-6\"><div class=\"space-y-2\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, "<div id=\"main-content-loading\" class=\"htmx-indicator absolute inset-0 bg-background z-30 pointer-events-none\"><div class=\"flex flex-col justify-center items-center h-full p-8\"><div class=\"w-full max-w-3xl space-y-6\"><div class=\"space-y-2\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -272,7 +496,7 @@ func mainContentLoading() templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "</div><div class=\"space-y-4 pt-4\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, "</div><div class=\"space-y-4 pt-4\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -292,7 +516,7 @@ func mainContentLoading() templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "</div><div class=\"flex justify-between items-center pt-6\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "</div><div class=\"flex justify-between items-center pt-6\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -304,7 +528,7 @@ func mainContentLoading() templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "</div></div></div></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, "</div></div></div></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -328,12 +552,12 @@ func ThemeSwitcher() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var6 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var6 == nil {
-			templ_7745c5c3_Var6 = templ.NopComponent
+		templ_7745c5c3_Var14 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var14 == nil {
+			templ_7745c5c3_Var14 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Var7 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_Var15 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
 			if !templ_7745c5c3_IsBuffer {
@@ -349,7 +573,7 @@ func ThemeSwitcher() templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, " ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, " ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -366,7 +590,7 @@ func ThemeSwitcher() templ.Component {
 				"onclick":    "toggleTheme()",
 				"aria-label": "Toggle theme",
 			},
-		}).Render(templ.WithChildren(ctx, templ_7745c5c3_Var7), templ_7745c5c3_Buffer)
+		}).Render(templ.WithChildren(ctx, templ_7745c5c3_Var15), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -390,12 +614,12 @@ func Scripts() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var8 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var8 == nil {
-			templ_7745c5c3_Var8 = templ.NopComponent
+		templ_7745c5c3_Var16 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var16 == nil {
+			templ_7745c5c3_Var16 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "<script src=\"/static/js/htmx.min.js\"></script><script>\n\t  document.addEventListener(\"DOMContentLoaded\", () => {\n\t\tconst initTemplUI = (target) => {\n\t\t  if (!window.templUI) {\n\t\t\treturn;\n\t\t  }\n\n\t\t  Object.values(window.templUI).forEach((comp) => {\n\t\t\tcomp.init?.(target);\n\t\t  });\n\t\t};\n\n\t\tconst forceChartRefresh = () => {\n\t\t  const root = document.documentElement;\n\t\t  if (!root) {\n\t\t\treturn;\n\t\t  }\n\n\t\t  // Toggle a temporary CSS variable so chart.min.js MutationObservers\n\t\t  // detect a document style change and remount canvas charts.\n\t\t  // The token avoids deleting a newer value if multiple refreshes overlap.\n\t\t  const token = String(Date.now());\n\t\t  root.style.setProperty(\"--tui-chart-refresh\", token);\n\n\t\t  requestAnimationFrame(() => {\n\t\t\tif (root.style.getPropertyValue(\"--tui-chart-refresh\") === token) {\n\t\t\t  root.style.removeProperty(\"--tui-chart-refresh\");\n\t\t\t}\n\t\t  });\n\t\t};\n\n\t\t// Re-initialize templUI components after HTMX swaps\n\t\tdocument.body.addEventListener(\"htmx:afterSwap\", (e) => {\n\t\t  initTemplUI(e.detail?.elt ?? document);\n\t\t});\n\n\t\t// Re-initialize components after out-of-band swaps\n\t\tdocument.body.addEventListener(\"htmx:oobAfterSwap\", (e) => {\n\t\t  initTemplUI(e.detail?.target ?? document);\n\t\t});\n\n\t\t// HTMX history restoration reuses cached DOM; force chart remount.\n\t\tdocument.body.addEventListener(\"htmx:historyRestore\", () => {\n\t\t  initTemplUI(document);\n\t\t  forceChartRefresh();\n\t\t});\n\t  });\n\t</script>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, "<script src=\"/static/js/htmx.min.js\"></script><script>\n\t  document.addEventListener(\"DOMContentLoaded\", () => {\n\t\tconst initTemplUI = (target) => {\n\t\t  if (!window.templUI) {\n\t\t\treturn;\n\t\t  }\n\n\t\t  Object.values(window.templUI).forEach((comp) => {\n\t\t\tcomp.init?.(target);\n\t\t  });\n\t\t};\n\n\t\tconst forceChartRefresh = () => {\n\t\t  const root = document.documentElement;\n\t\t  if (!root) {\n\t\t\treturn;\n\t\t  }\n\n\t\t  // Toggle a temporary CSS variable so chart.min.js MutationObservers\n\t\t  // detect a document style change and remount canvas charts.\n\t\t  // The token avoids deleting a newer value if multiple refreshes overlap.\n\t\t  const token = String(Date.now());\n\t\t  root.style.setProperty(\"--tui-chart-refresh\", token);\n\n\t\t  requestAnimationFrame(() => {\n\t\t\tif (root.style.getPropertyValue(\"--tui-chart-refresh\") === token) {\n\t\t\t  root.style.removeProperty(\"--tui-chart-refresh\");\n\t\t\t}\n\t\t  });\n\t\t};\n\n\t\t// Re-initialize templUI components after HTMX swaps\n\t\tdocument.body.addEventListener(\"htmx:afterSwap\", (e) => {\n\t\t  initTemplUI(e.detail?.elt ?? document);\n\t\t});\n\n\t\t// Re-initialize components after out-of-band swaps\n\t\tdocument.body.addEventListener(\"htmx:oobAfterSwap\", (e) => {\n\t\t  initTemplUI(e.detail?.target ?? document);\n\t\t});\n\n\t\t// HTMX history restoration reuses cached DOM; force chart remount.\n\t\tdocument.body.addEventListener(\"htmx:historyRestore\", () => {\n\t\t  initTemplUI(document);\n\t\t  forceChartRefresh();\n\t\t});\n\t  });\n\t</script>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -475,6 +699,10 @@ func Scripts() templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
+		templ_7745c5c3_Err = listgrid.Script().Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
 		return nil
 	})
 }
@@ -495,12 +723,12 @@ func SidebarNavigationScript() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var9 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var9 == nil {
-			templ_7745c5c3_Var9 = templ.NopComponent
+		templ_7745c5c3_Var17 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var17 == nil {
+			templ_7745c5c3_Var17 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "<script>\n\tdocument.addEventListener(\"DOMContentLoaded\", () => {\n\t  const navSelector = \"[data-tui-sidebar=menu-button][data-nav-url], [data-tui-sidebar=menu-sub-button][data-nav-url]\";\n\t  const sidebarSelectedPathCookieName = \"sidebar_selected_path\";\n\t  const sidebarListsOpenCookieName = \"sidebar_lists_open\";\n\t  const sidebarCookieDurationSeconds = 60 * 60 * 24 * 7;\n\n\t  function getPath(url = window.location.pathname) {\n\t\ttry {\n\t\t  const parsed = new URL(url, window.location.origin);\n\t\t  let path = parsed.pathname || \"\";\n\t\t  if (path.length > 1 && path.endsWith(\"/\")) {\n\t\t\tpath = path.slice(0, -1);\n\t\t  }\n\t\t  return path;\n\t\t} catch (_error) {\n\t\t  return \"\";\n\t\t}\n\t  }\n\n\t  function isSidebarPath(path) {\n\t\tif (\n\t\t  path === \"/home\" ||\n\t\t  path === \"/watched\" ||\n\t\t  path === \"/watchlist\" ||\n\t\t  path === \"/stats\" ||\n\t\t  path === \"/admin/users\"\n\t\t) {\n\t\t  return true;\n\t\t}\n\n\t\treturn /^\\/list\\/\\d+$/.test(path);\n\t  }\n\n\t  function setSidebarSelectedPath(path) {\n\t\tif (!path || !isSidebarPath(path)) {\n\t\t  return;\n\t\t}\n\n\t\tdocument.cookie = `${sidebarSelectedPathCookieName}=${path}; path=/; max-age=${sidebarCookieDurationSeconds}`;\n\t  }\n\n\t  function setSidebarListsOpen(isOpen) {\n\t\tdocument.cookie = `${sidebarListsOpenCookieName}=${isOpen ? \"true\" : \"false\"}; path=/; max-age=${sidebarCookieDurationSeconds}`;\n\t  }\n\n\t  function syncSidebarListsOpenFromDOM() {\n\t\tconst listsCollapsible = document.querySelector(\n\t\t  \"[data-sidebar-lists-collapsible='true']\"\n\t\t);\n\t\tif (!listsCollapsible) {\n\t\t  return;\n\t\t}\n\n\t\tconst isOpen = listsCollapsible.getAttribute(\"data-tui-collapsible-state\") === \"open\";\n\t\tsetSidebarListsOpen(isOpen);\n\t  }\n\n\t  function syncSidebarSelection(path) {\n\t\tif (!isSidebarPath(path)) {\n\t\t  return;\n\t\t}\n\n\t\tupdateSidebarActiveState(path);\n\t\tsetSidebarSelectedPath(path);\n\t  }\n\n\t  function updateSidebarActiveState(url = window.location.pathname) {\n\t\tconst path = getPath(url);\n\t\tconst allNavButtons = document.querySelectorAll(navSelector);\n\n\t\tallNavButtons.forEach(btn => {\n\t\t  if (btn.getAttribute(\"data-nav-url\") === path) {\n\t\t\tbtn.setAttribute(\"data-tui-sidebar-active\", \"true\");\n\t\t  } else {\n\t\t\tbtn.removeAttribute(\"data-tui-sidebar-active\");\n\t\t  }\n\t\t});\n\t  }\n\n\t  const initialPath = getPath(window.location.pathname);\n\t  syncSidebarSelection(initialPath);\n\n\t  document.body.addEventListener(\"click\", e => {\n\t\tconst listsTrigger = e.target.closest(\"[data-sidebar-lists-collapsible-trigger='true']\");\n\t\tif (listsTrigger) {\n\t\t  window.requestAnimationFrame(syncSidebarListsOpenFromDOM);\n\t\t}\n\n\t\tconst navButton = e.target.closest(\"[data-nav-url]\");\n\t\tif (!navButton) {\n\t\t  return;\n\t\t}\n\n\t\tconst targetPath = getPath(navButton.getAttribute(\"data-nav-url\") || \"\");\n\t\tif (!targetPath) {\n\t\t  return;\n\t\t}\n\n\t\tsyncSidebarSelection(targetPath);\n\t  });\n\n\t  document.body.addEventListener(\"htmx:afterSwap\", e => {\n\t\tif (!e.detail.target) {\n\t\t  return;\n\t\t}\n\n\t\tif (e.detail.target.id === \"sidebar-content\") {\n\t\t  syncSidebarListsOpenFromDOM();\n\t\t}\n\t  });\n\n\t  window.addEventListener(\"popstate\", () => {\n\t\tconst path = getPath(window.location.pathname);\n\t\tsyncSidebarSelection(path);\n\t  });\n\t});\n\t</script>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, "<script>\n\tdocument.addEventListener(\"DOMContentLoaded\", () => {\n\t  const navSelector = \"[data-tui-sidebar=menu-button][data-nav-url], [data-tui-sidebar=menu-sub-button][data-nav-url]\";\n\t  const sidebarSelectedPathCookieName = \"sidebar_selected_path\";\n\t  const sidebarListsOpenCookieName = \"sidebar_lists_open\";\n\t  const sidebarCookieDurationSeconds = 60 * 60 * 24 * 7;\n\n\t  function getPath(url = window.location.pathname) {\n\t\ttry {\n\t\t  const parsed = new URL(url, window.location.origin);\n\t\t  let path = parsed.pathname || \"\";\n\t\t  if (path.length > 1 && path.endsWith(\"/\")) {\n\t\t\tpath = path.slice(0, -1);\n\t\t  }\n\t\t  return path;\n\t\t} catch (_error) {\n\t\t  return \"\";\n\t\t}\n\t  }\n\n\t  function isSidebarPath(path) {\n\t\tif (\n\t\t  path === \"/home\" ||\n\t\t  path === \"/watched\" ||\n\t\t  path === \"/watchlist\" ||\n\t\t  path === \"/stats\" ||\n\t\t  path === \"/admin/users\"\n\t\t) {\n\t\t  return true;\n\t\t}\n\n\t\treturn /^\\/list\\/\\d+$/.test(path);\n\t  }\n\n\t  function setSidebarSelectedPath(path) {\n\t\tif (!path || !isSidebarPath(path)) {\n\t\t  return;\n\t\t}\n\n\t\tdocument.cookie = `${sidebarSelectedPathCookieName}=${path}; path=/; max-age=${sidebarCookieDurationSeconds}`;\n\t  }\n\n\t  function setSidebarListsOpen(isOpen) {\n\t\tdocument.cookie = `${sidebarListsOpenCookieName}=${isOpen ? \"true\" : \"false\"}; path=/; max-age=${sidebarCookieDurationSeconds}`;\n\t  }\n\n\t  function syncSidebarListsOpenFromDOM() {\n\t\tconst listsCollapsible = document.querySelector(\n\t\t  \"[data-sidebar-lists-collapsible='true']\"\n\t\t);\n\t\tif (!listsCollapsible) {\n\t\t  return;\n\t\t}\n\n\t\tconst isOpen = listsCollapsible.getAttribute(\"data-tui-collapsible-state\") === \"open\";\n\t\tsetSidebarListsOpen(isOpen);\n\t  }\n\n\t  function syncSidebarSelection(path) {\n\t\tif (!isSidebarPath(path)) {\n\t\t  return;\n\t\t}\n\n\t\tupdateSidebarActiveState(path);\n\t\tsetSidebarSelectedPath(path);\n\t  }\n\n\t  function updateSidebarActiveState(url = window.location.pathname) {\n\t\tconst path = getPath(url);\n\t\tconst allNavButtons = document.querySelectorAll(navSelector);\n\n\t\tallNavButtons.forEach(btn => {\n\t\t  if (btn.getAttribute(\"data-nav-url\") === path) {\n\t\t\tbtn.setAttribute(\"data-tui-sidebar-active\", \"true\");\n\t\t  } else {\n\t\t\tbtn.removeAttribute(\"data-tui-sidebar-active\");\n\t\t  }\n\t\t});\n\t  }\n\n\t  const initialPath = getPath(window.location.pathname);\n\t  syncSidebarSelection(initialPath);\n\n\t  document.body.addEventListener(\"click\", e => {\n\t\tconst listsTrigger = e.target.closest(\"[data-sidebar-lists-collapsible-trigger='true']\");\n\t\tif (listsTrigger) {\n\t\t  window.requestAnimationFrame(syncSidebarListsOpenFromDOM);\n\t\t}\n\n\t\tconst navButton = e.target.closest(\"[data-nav-url]\");\n\t\tif (!navButton) {\n\t\t  return;\n\t\t}\n\n\t\tconst targetPath = getPath(navButton.getAttribute(\"data-nav-url\") || \"\");\n\t\tif (!targetPath) {\n\t\t  return;\n\t\t}\n\n\t\tsyncSidebarSelection(targetPath);\n\t  });\n\n\t  document.body.addEventListener(\"htmx:afterSwap\", e => {\n\t\tif (!e.detail.target) {\n\t\t  return;\n\t\t}\n\n\t\tif (e.detail.target.id === \"sidebar-content\") {\n\t\t  syncSidebarListsOpenFromDOM();\n\t\t}\n\t  });\n\n\t  window.addEventListener(\"popstate\", () => {\n\t\tconst path = getPath(window.location.pathname);\n\t\tsyncSidebarSelection(path);\n\t  });\n\t});\n\t</script>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -524,12 +752,12 @@ func ThemeScript() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var10 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var10 == nil {
-			templ_7745c5c3_Var10 = templ.NopComponent
+		templ_7745c5c3_Var18 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var18 == nil {
+			templ_7745c5c3_Var18 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "<script>\n\t(() => {\n\t  const root = document.documentElement;\n\t  const themeColorMeta = document.querySelector(\"meta[name='theme-color']\");\n\t  const lightThemeColor = \"#ffffff\";\n\t  const darkThemeColor = \"#09090b\";\n\n\t  const syncThemeColor = () => {\n\t\tif (!themeColorMeta) {\n\t\t  return;\n\t\t}\n\n\t\tthemeColorMeta.setAttribute(\"content\", root.classList.contains(\"dark\") ? darkThemeColor : lightThemeColor);\n\t  };\n\n\t  const saved = localStorage.getItem(\"theme\");\n\t  const shouldBeDark = saved ? saved === \"dark\" : true;\n\t  if (shouldBeDark) {\n\t    root.classList.add(\"dark\");\n\t  }\n\t  syncThemeColor();\n\n\t  window.toggleTheme = () => {\n\t    const isDark = root.classList.toggle(\"dark\");\n\t    localStorage.setItem(\"theme\", isDark ? \"dark\" : \"light\");\n\t    syncThemeColor();\n\t  };\n\t})();\n\t</script>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "<script>\n\t(() => {\n\t  const root = document.documentElement;\n\t  const themeColorMeta = document.querySelector(\"meta[name='theme-color']\");\n\t  const lightThemeColor = \"#ffffff\";\n\t  const darkThemeColor = \"#09090b\";\n\n\t  const syncThemeColor = () => {\n\t\tif (!themeColorMeta) {\n\t\t  return;\n\t\t}\n\n\t\tthemeColorMeta.setAttribute(\"content\", root.classList.contains(\"dark\") ? darkThemeColor : lightThemeColor);\n\t  };\n\n\t  const saved = localStorage.getItem(\"theme\");\n\t  const shouldBeDark = saved ? saved === \"dark\" : true;\n\t  if (shouldBeDark) {\n\t    root.classList.add(\"dark\");\n\t  }\n\t  syncThemeColor();\n\n\t  window.toggleTheme = () => {\n\t    const isDark = root.classList.toggle(\"dark\");\n\t    localStorage.setItem(\"theme\", isDark ? \"dark\" : \"light\");\n\t    syncThemeColor();\n\t  };\n\t})();\n\t</script>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -553,12 +781,12 @@ func Favicons() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var11 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var11 == nil {
-			templ_7745c5c3_Var11 = templ.NopComponent
+		templ_7745c5c3_Var19 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var19 == nil {
+			templ_7745c5c3_Var19 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "<link rel=\"icon\" type=\"image/svg+xml\" href=\"/static/favicon.svg\"><meta name=\"theme-color\" content=\"#09090b\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "<link rel=\"icon\" type=\"image/svg+xml\" href=\"/static/favicon.svg\"><meta name=\"theme-color\" content=\"#09090b\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -582,17 +810,17 @@ func skeletonDashboard() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var12 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var12 == nil {
-			templ_7745c5c3_Var12 = templ.NopComponent
+		templ_7745c5c3_Var20 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var20 == nil {
+			templ_7745c5c3_Var20 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "<div><div class=\"grid gap-6 md:grid-cols-3\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "<div><div class=\"grid gap-6 md:grid-cols-3\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		for _ = range 3 {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "<div class=\"p-4\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "<div class=\"p-4\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -604,7 +832,7 @@ func skeletonDashboard() templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "<div class=\"flex items-center gap-2\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, "<div class=\"flex items-center gap-2\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -616,12 +844,12 @@ func skeletonDashboard() templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "</div></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "</div></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, "</div><div class=\"mt-4 p-4\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "</div><div class=\"mt-4 p-4\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -633,7 +861,7 @@ func skeletonDashboard() templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, "</div></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "</div></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -657,12 +885,12 @@ func SearchBar(searchContent string) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var13 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var13 == nil {
-			templ_7745c5c3_Var13 = templ.NopComponent
+		templ_7745c5c3_Var21 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var21 == nil {
+			templ_7745c5c3_Var21 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, "<div class=\"relative flex items-center flex-1\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, "<div class=\"relative flex items-center flex-1\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -690,7 +918,7 @@ func SearchBar(searchContent string) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "</div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "</div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -0,0 +1,70 @@
+package pages
+
+import (
+	"fmt"
+
+	"github.com/marcosalvi-01/gowatch/internal/models"
+	"github.com/marcosalvi-01/gowatch/internal/utils"
+)
+
+// sharedListPreviewPosters is how many posters a shared list offers to link previews
+const sharedListPreviewPosters = 4
+
+// listDescription returns the description of list, empty when it has none
+func listDescription(list *models.List) string {
+	if list.Description == nil {
+		return ""
+	}
+	return *list.Description
+}
+
+// SharedListPath is the public page of the list shared with token
+func SharedListPath(token string) string {
+	return "/shared/" + token
+}
+
+// sharedListSummary describes a shared list in link previews
+func sharedListSummary(list *models.List) string {
+	if description := listDescription(list); description != "" {
+		return description
+	}
+	if len(list.Movies) == 1 {
+		return "A list of 1 movie on Gowatch"
+	}
+	return fmt.Sprintf("A list of %d movies on Gowatch", len(list.Movies))
+}
+
+// sharedListPosters returns absolute URLs of the first posters of list, link previews
+// are fetched from outside so relative URLs would not load
+func sharedListPosters(list *models.List, baseURL string) []string {
+	posters := make([]string, 0, sharedListPreviewPosters)
+	for _, movie := range list.Movies {
+		if len(posters) == sharedListPreviewPosters {
+			break
+		}
+		if poster := utils.TMDBImageURL("w500", movie.MovieDetails.Movie.PosterPath); poster != "" {
+			posters = append(posters, baseURL+poster)
+		}
+	}
+	return posters
+}
+
+// listRoleName is how role is shown to users
+func listRoleName(role models.ListRole) string {
+	switch role {
+	case models.ListRoleOwner:
+		return "Owner"
+	case models.ListRoleEditor:
+		return "Editor"
+	default:
+		return "Viewer"
+	}
+}
+
+// listRoleAction is what a user with role can do with a list
+func listRoleAction(role models.ListRole) string {
+	if role.CanEdit() {
+		return "edit"
+	}
+	return "view"
+}